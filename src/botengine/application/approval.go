@@ -0,0 +1,305 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	domain "github.com/AzielCF/az-wap/botengine/domain"
+	domainBot "github.com/AzielCF/az-wap/botengine/domain/bot"
+	pkgError "github.com/AzielCF/az-wap/core/pkg/error"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// Eventos emitidos por la cola (se reenvían por websocket al panel).
+const (
+	ApprovalEventPending  = "TOOL_APPROVAL_PENDING"
+	ApprovalEventResolved = "TOOL_APPROVAL_RESOLVED"
+)
+
+const (
+	defaultApprovalTimeout = 15 * time.Minute
+	approvalRetention      = time.Hour // Tiempo que las decisiones siguen visibles en la cola
+)
+
+// ApprovalQueue retiene en memoria las llamadas de herramientas que requieren confirmación.
+type ApprovalQueue struct {
+	mu       sync.Mutex
+	items    map[string]*domain.PendingApproval
+	timeout  time.Duration
+	onEvent  func(event string, a domain.PendingApproval)
+	onExpire func(a *domain.PendingApproval)
+}
+
+func NewApprovalQueue(timeout time.Duration) *ApprovalQueue {
+	if timeout <= 0 {
+		timeout = defaultApprovalTimeout
+	}
+	return &ApprovalQueue{
+		items:   make(map[string]*domain.PendingApproval),
+		timeout: timeout,
+	}
+}
+
+// OnEvent registra un observador para altas y resoluciones (ej. broadcast por websocket).
+func (q *ApprovalQueue) OnEvent(fn func(event string, a domain.PendingApproval)) {
+	q.onEvent = fn
+}
+
+// OnExpire registra la acción a ejecutar cuando una aprobación caduca sin decisión.
+func (q *ApprovalQueue) OnExpire(fn func(a *domain.PendingApproval)) {
+	q.onExpire = fn
+}
+
+// Hold encola una llamada pendiente. Una confirmación previa del mismo usuario en el chat queda reemplazada.
+func (q *ApprovalQueue) Hold(a *domain.PendingApproval) {
+	now := time.Now()
+	a.ID = uuid.NewString()
+	a.Status = domain.ApprovalPending
+	a.CreatedAt = now
+	a.ExpiresAt = now.Add(q.timeout)
+
+	var replaced *domain.PendingApproval
+	q.mu.Lock()
+	if a.Policy == domainBot.ApprovalAskUser {
+		if prev := q.userPendingLocked(a.InstanceID, a.ChatID, a.SenderID); prev != nil {
+			q.resolveLocked(prev, domain.ApprovalExpired, "system")
+			replaced = prev
+		}
+	}
+	q.items[a.ID] = a
+	q.mu.Unlock()
+
+	if replaced != nil {
+		q.emit(ApprovalEventResolved, replaced)
+	}
+	q.emit(ApprovalEventPending, a)
+}
+
+// AwaitsUserReply indica, sin consumirla, si el remitente tiene una confirmación pendiente en el chat
+// y el texto es un sí o un no claro.
+func (q *ApprovalQueue) AwaitsUserReply(instanceID, chatID, senderID, text string) bool {
+	if _, known := ParseConfirmation(text); !known {
+		return false
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	a := q.userPendingLocked(instanceID, chatID, senderID)
+	return a != nil && !time.Now().After(a.ExpiresAt)
+}
+
+// TakeUserReply consume la confirmación pendiente del remitente solo si la respuesta es un sí o un no claro.
+// Solo quien provocó la llamada puede confirmarla: en un grupo el "sí" de otro miembro no cuenta.
+// Cualquier otro texto deja la confirmación pendiente y sigue el flujo normal de la conversación.
+func (q *ApprovalQueue) TakeUserReply(instanceID, chatID, senderID, text string) (*domain.PendingApproval, bool) {
+	q.mu.Lock()
+	a := q.userPendingLocked(instanceID, chatID, senderID)
+	if a == nil || time.Now().After(a.ExpiresAt) {
+		q.mu.Unlock()
+		return nil, false
+	}
+	approved, known := ParseConfirmation(text)
+	if !known {
+		q.mu.Unlock()
+		return nil, false
+	}
+	status := domain.ApprovalRejected
+	if approved {
+		status = domain.ApprovalApproved
+	}
+	q.resolveLocked(a, status, "user")
+	q.mu.Unlock()
+
+	q.emit(ApprovalEventResolved, a)
+	return a, approved
+}
+
+// Decide registra la decisión de un operador sobre una aprobación pendiente.
+func (q *ApprovalQueue) Decide(id string, approved bool, decidedBy string) (*domain.PendingApproval, error) {
+	q.mu.Lock()
+	a, ok := q.items[id]
+	if !ok {
+		q.mu.Unlock()
+		return nil, pkgError.NotFoundError(fmt.Sprintf("approval %s not found", id))
+	}
+	if a.Status != domain.ApprovalPending {
+		q.mu.Unlock()
+		return nil, pkgError.ValidationError(fmt.Sprintf("approval %s is already %s", id, a.Status))
+	}
+	status := domain.ApprovalRejected
+	if approved {
+		status = domain.ApprovalApproved
+	}
+	if decidedBy == "" {
+		decidedBy = "operator"
+	}
+	q.resolveLocked(a, status, decidedBy)
+	q.mu.Unlock()
+
+	q.emit(ApprovalEventResolved, a)
+	return a, nil
+}
+
+// Get devuelve una copia de la aprobación.
+func (q *ApprovalQueue) Get(id string) (domain.PendingApproval, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	a, ok := q.items[id]
+	if !ok {
+		return domain.PendingApproval{}, false
+	}
+	return *a, true
+}
+
+// List devuelve las aprobaciones (opcionalmente filtradas por estado), de la más antigua a la más reciente.
+func (q *ApprovalQueue) List(status domain.ApprovalStatus) []domain.PendingApproval {
+	q.mu.Lock()
+	out := make([]domain.PendingApproval, 0, len(q.items))
+	for _, a := range q.items {
+		if status != "" && a.Status != status {
+			continue
+		}
+		out = append(out, *a)
+	}
+	q.mu.Unlock()
+
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.Before(out[j].CreatedAt) })
+	return out
+}
+
+// StartExpiryLoop caduca las aprobaciones vencidas y purga las ya resueltas.
+func (q *ApprovalQueue) StartExpiryLoop(ctx context.Context) {
+	ticker := time.NewTicker(30 * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			q.sweep(now)
+		}
+	}
+}
+
+func (q *ApprovalQueue) sweep(now time.Time) {
+	var expired []*domain.PendingApproval
+	q.mu.Lock()
+	for id, a := range q.items {
+		if a.Status == domain.ApprovalPending && now.After(a.ExpiresAt) {
+			q.resolveLocked(a, domain.ApprovalExpired, "system")
+			expired = append(expired, a)
+			continue
+		}
+		if a.Status != domain.ApprovalPending && a.DecidedAt != nil && now.Sub(*a.DecidedAt) > approvalRetention {
+			delete(q.items, id)
+		}
+	}
+	q.mu.Unlock()
+
+	for _, a := range expired {
+		logrus.Infof("[APPROVAL] Tool call %s (%s) expired without a decision", a.ToolCall.Name, a.ID)
+		q.emit(ApprovalEventResolved, a)
+		if q.onExpire != nil {
+			q.onExpire(a)
+		}
+	}
+}
+
+func (q *ApprovalQueue) userPendingLocked(instanceID, chatID, senderID string) *domain.PendingApproval {
+	for _, a := range q.items {
+		if a.Status == domain.ApprovalPending && a.Policy == domainBot.ApprovalAskUser &&
+			a.InstanceID == instanceID && a.ChatID == chatID && a.SenderID == senderID {
+			return a
+		}
+	}
+	return nil
+}
+
+func (q *ApprovalQueue) resolveLocked(a *domain.PendingApproval, status domain.ApprovalStatus, by string) {
+	now := time.Now()
+	a.Status = status
+	a.DecidedAt = &now
+	a.DecidedBy = by
+}
+
+// emit copia la aprobación bajo el lock: Decide y sweep pueden estar modificándola en paralelo.
+func (q *ApprovalQueue) emit(event string, a *domain.PendingApproval) {
+	if q.onEvent == nil {
+		return
+	}
+	q.mu.Lock()
+	snapshot := *a
+	q.mu.Unlock()
+	q.onEvent(event, snapshot)
+}
+
+var (
+	confirmWords = []string{"si", "yes", "y", "ok", "okay", "dale", "confirmo", "confirmar", "confirm", "claro", "adelante", "acepto", "sure", "proceed", "👍"}
+	denyWords    = []string{"no", "n", "nope", "cancelar", "cancela", "cancel", "negativo", "rechazo", "stop", "👎"}
+)
+
+// ParseConfirmation interpreta una respuesta de sí/no. known es false si la respuesta es ambigua.
+func ParseConfirmation(text string) (approved bool, known bool) {
+	normalized := strings.ToLower(strings.TrimSpace(text))
+	normalized = strings.NewReplacer("í", "i", "á", "a", "é", "e", "ó", "o", "ú", "u").Replace(normalized)
+	first := strings.FieldsFunc(normalized, func(r rune) bool {
+		return unicode.IsSpace(r) || (unicode.IsPunct(r) && r != '\'')
+	})
+	if len(first) == 0 {
+		return false, false
+	}
+	word := first[0]
+	for _, w := range denyWords {
+		if word == w {
+			return false, true
+		}
+	}
+	for _, w := range confirmWords {
+		if word == w {
+			return true, true
+		}
+	}
+	return false, false
+}
+
+// describeToolCall resume una llamada para mostrarla al usuario o al operador.
+func describeToolCall(tc domain.ToolCall) string {
+	if len(tc.Args) == 0 {
+		return tc.Name
+	}
+	keys := make([]string, 0, len(tc.Args))
+	for k := range tc.Args {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		v := fmt.Sprintf("%v", tc.Args[k])
+		if len(v) > 60 {
+			v = v[:57] + "..."
+		}
+		parts = append(parts, fmt.Sprintf("%s: %s", k, v))
+	}
+	return fmt.Sprintf("%s (%s)", tc.Name, strings.Join(parts, ", "))
+}
+
+// approvalMessage es el texto que recibe el usuario mientras la llamada está retenida.
+func approvalMessage(language string, a *domain.PendingApproval) string {
+	spanish := language == "" || strings.HasPrefix(strings.ToLower(language), "es")
+	if a.Policy == domainBot.ApprovalAskOperator {
+		if spanish {
+			return fmt.Sprintf("Esta acción requiere la aprobación de un operador: %s.\nTe aviso en cuanto se resuelva.", a.Summary)
+		}
+		return fmt.Sprintf("This action needs to be approved by an operator: %s.\nI'll let you know as soon as it's resolved.", a.Summary)
+	}
+	if spanish {
+		return fmt.Sprintf("Antes de continuar necesito tu confirmación para: %s.\n¿Confirmas? Responde *SÍ* o *NO*.", a.Summary)
+	}
+	return fmt.Sprintf("Before I go ahead I need your confirmation to: %s.\nDo you confirm? Reply *YES* or *NO*.", a.Summary)
+}
@@ -0,0 +1,121 @@
+package application
+
+import (
+	"testing"
+	"time"
+
+	domain "github.com/AzielCF/az-wap/botengine/domain"
+	domainBot "github.com/AzielCF/az-wap/botengine/domain/bot"
+)
+
+func TestParseConfirmation(t *testing.T) {
+	cases := []struct {
+		text     string
+		approved bool
+		known    bool
+	}{
+		{"Sí, adelante", true, true},
+		{"yes", true, true},
+		{"OK!", true, true},
+		{"no gracias", false, true},
+		{"Cancelar", false, true},
+		{"mejor mañana", false, false},
+		{"", false, false},
+	}
+	for _, tc := range cases {
+		approved, known := ParseConfirmation(tc.text)
+		if approved != tc.approved || known != tc.known {
+			t.Errorf("ParseConfirmation(%q) = (%v, %v), want (%v, %v)", tc.text, approved, known, tc.approved, tc.known)
+		}
+	}
+}
+
+func TestApprovalQueue_UserReplyResolvesPending(t *testing.T) {
+	q := NewApprovalQueue(time.Minute)
+	var events []string
+	q.OnEvent(func(event string, a domain.PendingApproval) { events = append(events, event) })
+
+	q.Hold(&domain.PendingApproval{InstanceID: "inst", ChatID: "chat", SenderID: "ana", Policy: domainBot.ApprovalAskUser, ToolCall: domain.ToolCall{Name: "delete_my_field"}})
+
+	if a, _ := q.TakeUserReply("inst", "other", "ana", "si"); a != nil {
+		t.Fatalf("TakeUserReply() on another chat returned %+v, want nil", a)
+	}
+	// En un grupo, el "sí" de otro miembro no confirma la llamada de Ana
+	if q.AwaitsUserReply("inst", "chat", "luis", "si") {
+		t.Fatalf("AwaitsUserReply() matched another sender")
+	}
+	if a, _ := q.TakeUserReply("inst", "chat", "luis", "si"); a != nil {
+		t.Fatalf("TakeUserReply() by another sender returned %+v, want nil", a)
+	}
+	if !q.AwaitsUserReply("inst", "chat", "ana", "si") {
+		t.Fatalf("AwaitsUserReply() = false for the requester's clear reply")
+	}
+
+	// Una respuesta ambigua no consume la confirmación
+	if a, _ := q.TakeUserReply("inst", "chat", "ana", "mejor mañana"); a != nil {
+		t.Fatalf("TakeUserReply() resolved the approval with an ambiguous reply: %+v", a)
+	}
+
+	a, approved := q.TakeUserReply("inst", "chat", "ana", "Sí")
+	if a == nil || !approved || a.Status != domain.ApprovalApproved || a.DecidedBy != "user" {
+		t.Fatalf("TakeUserReply() = (%+v, %v), want approved by user", a, approved)
+	}
+	if again, _ := q.TakeUserReply("inst", "chat", "ana", "si"); again != nil {
+		t.Fatalf("TakeUserReply() resolved the same approval twice")
+	}
+	if len(events) != 2 || events[0] != ApprovalEventPending || events[1] != ApprovalEventResolved {
+		t.Fatalf("events = %v, want [pending resolved]", events)
+	}
+}
+
+func TestApprovalQueue_OperatorDecision(t *testing.T) {
+	q := NewApprovalQueue(time.Minute)
+	pending := &domain.PendingApproval{InstanceID: "inst", ChatID: "chat", Policy: domainBot.ApprovalAskOperator}
+	q.Hold(pending)
+
+	// Las aprobaciones de operador no se resuelven con la respuesta del usuario
+	if a, _ := q.TakeUserReply("inst", "chat", "", "si"); a != nil {
+		t.Fatalf("TakeUserReply() resolved an operator approval")
+	}
+
+	if _, err := q.Decide("missing", true, ""); err == nil {
+		t.Fatalf("Decide() on unknown id expected error, got nil")
+	}
+	a, err := q.Decide(pending.ID, false, "alice")
+	if err != nil {
+		t.Fatalf("Decide() unexpected error: %v", err)
+	}
+	if a.Status != domain.ApprovalRejected || a.DecidedBy != "alice" {
+		t.Fatalf("Decide() = %+v, want rejected by alice", a)
+	}
+	if _, err := q.Decide(pending.ID, true, "bob"); err == nil {
+		t.Fatalf("Decide() twice expected error, got nil")
+	}
+}
+
+func TestApprovalQueue_SweepExpires(t *testing.T) {
+	q := NewApprovalQueue(time.Minute)
+	var expired []string
+	q.OnExpire(func(a *domain.PendingApproval) { expired = append(expired, a.ID) })
+
+	pending := &domain.PendingApproval{InstanceID: "inst", ChatID: "chat", Policy: domainBot.ApprovalAskOperator}
+	q.Hold(pending)
+
+	q.sweep(time.Now())
+	if len(expired) != 0 {
+		t.Fatalf("sweep() expired a fresh approval")
+	}
+
+	q.sweep(pending.ExpiresAt.Add(time.Second))
+	if len(expired) != 1 || expired[0] != pending.ID {
+		t.Fatalf("expired = %v, want [%s]", expired, pending.ID)
+	}
+	if a, _ := q.Get(pending.ID); a.Status != domain.ApprovalExpired {
+		t.Fatalf("status = %s, want expired", a.Status)
+	}
+
+	q.sweep(time.Now().Add(2 * approvalRetention))
+	if _, ok := q.Get(pending.ID); ok {
+		t.Fatalf("sweep() kept a resolved approval past the retention window")
+	}
+}
@@ -18,6 +18,7 @@ import (
 
 type botService struct {
 	repo        domainBot.IBotRepository
+	revisions   domainBot.IBotRevisionRepository // nil = sin historial de revisiones
 	credService domainCredential.ICredentialUsecase
 	health      domainHealth.IHealthUsecase
 }
@@ -43,14 +44,20 @@ func NewBotService(credService domainCredential.ICredentialUsecase) domainBot.IB
 	if err := repo.Init(context.Background()); err != nil {
 		logrus.WithError(err).Error("[BOT] failed to init bot repository schema")
 	}
+	revisions := repository.NewBotRevisionGormRepository(db)
+	if err := revisions.Init(context.Background()); err != nil {
+		logrus.WithError(err).Error("[BOT] failed to init bot revision schema")
+	}
 
-	return &botService{repo: repo, credService: credService}
+	return &botService{repo: repo, revisions: revisions, credService: credService}
 }
 
 // NewBotServiceWithDeps permite inyectar dependencias para tests o configuraciones personalizadas.
-func NewBotServiceWithDeps(repo domainBot.IBotRepository, credService domainCredential.ICredentialUsecase) domainBot.IBotUsecase {
+// revisions puede ser nil: los cambios se aplican sin guardar historial.
+func NewBotServiceWithDeps(repo domainBot.IBotRepository, revisions domainBot.IBotRevisionRepository, credService domainCredential.ICredentialUsecase) domainBot.IBotUsecase {
 	return &botService{
 		repo:        repo,
+		revisions:   revisions,
 		credService: credService,
 	}
 }
@@ -86,6 +93,9 @@ func (s *botService) Create(ctx context.Context, req domainBot.CreateBotRequest)
 		provider != string(domainBot.ProviderClaude) {
 		return domainBot.Bot{}, pkgError.ValidationError("provider: unsupported provider.")
 	}
+	if err := req.Typing.Validate(); err != nil {
+		return domainBot.Bot{}, pkgError.ValidationError("typing: " + err.Error())
+	}
 
 	id := uuid.NewString()
 
@@ -113,13 +123,32 @@ func (s *botService) Create(ctx context.Context, req domainBot.CreateBotRequest)
 		ChatwootBotToken:     strings.TrimSpace(req.ChatwootBotToken),
 		Whitelist:            req.Whitelist,
 		Variants:             req.Variants,
+		ToolPolicies:         req.ToolPolicies,
+		Voice:                req.Voice.Sanitize(),
+		Typing:               req.Typing,
+		StreamReplies:        req.StreamReplies,
 	}
 
 	bot.SanitizeVariants()
+	bot.SanitizeToolPolicies()
+	if s.revisions != nil {
+		bot.Revision = 1
+	}
 
 	if err := s.repo.Create(ctx, bot); err != nil {
 		return domainBot.Bot{}, err
 	}
+	if err := s.saveRevision(ctx, domainBot.BotRevision{
+		BotID:    bot.ID,
+		Number:   1,
+		Author:   strings.TrimSpace(req.Author),
+		Note:     "created",
+		Snapshot: bot.Snapshot(),
+	}); err != nil {
+		// Sin su revisión inicial el bot quedaría fuera del historial
+		_ = s.repo.Delete(ctx, bot.ID)
+		return domainBot.Bot{}, err
+	}
 
 	return bot, nil
 }
@@ -171,10 +200,15 @@ func (s *botService) Update(ctx context.Context, id string, req domainBot.Update
 		return domainBot.Bot{}, err
 	}
 
+	// La revisión se compara contra lo guardado, no contra el bot con credenciales resueltas
 	existing, err := s.GetByID(ctx, id)
 	if err != nil {
 		return domainBot.Bot{}, err
 	}
+	stored, err := s.repo.GetByID(ctx, existing.ID)
+	if err != nil {
+		return domainBot.Bot{}, err
+	}
 
 	name := strings.TrimSpace(req.Name)
 	if name == "" {
@@ -191,6 +225,9 @@ func (s *botService) Update(ctx context.Context, id string, req domainBot.Update
 		provider != string(domainBot.ProviderClaude) {
 		return domainBot.Bot{}, pkgError.ValidationError("provider: unsupported provider.")
 	}
+	if err := req.Typing.Validate(); err != nil {
+		return domainBot.Bot{}, pkgError.ValidationError("typing: " + err.Error())
+	}
 
 	updated := existing
 	updated.Name = name
@@ -213,12 +250,19 @@ func (s *botService) Update(ctx context.Context, id string, req domainBot.Update
 	updated.ChatwootBotToken = strings.TrimSpace(req.ChatwootBotToken)
 	updated.Whitelist = req.Whitelist
 	updated.Variants = req.Variants
+	updated.ToolPolicies = req.ToolPolicies
+	updated.Voice = req.Voice.Sanitize()
+	updated.Typing = req.Typing
+	updated.StreamReplies = req.StreamReplies
 
 	updated.SanitizeVariants()
+	updated.SanitizeToolPolicies()
 
-	if err := s.repo.Update(ctx, updated); err != nil {
+	revision, err := s.commit(ctx, stored, updated, strings.TrimSpace(req.Author), strings.TrimSpace(req.ChangeNote), 0)
+	if err != nil {
 		return domainBot.Bot{}, err
 	}
+	updated.Revision = revision
 
 	return updated, nil
 }
@@ -233,7 +277,15 @@ func (s *botService) Delete(ctx context.Context, id string) error {
 		return pkgError.ValidationError("id: cannot be blank.")
 	}
 
-	return s.repo.Delete(ctx, trimmed)
+	if err := s.repo.Delete(ctx, trimmed); err != nil {
+		return err
+	}
+	if s.revisions != nil {
+		if err := s.revisions.DeleteRevisions(ctx, trimmed); err != nil {
+			logrus.WithError(err).Warnf("[BOT] Failed to delete revision history of bot %s", trimmed)
+		}
+	}
+	return nil
 }
 
 // === Lifecycle & Health ===
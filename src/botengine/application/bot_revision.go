@@ -0,0 +1,207 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	domainBot "github.com/AzielCF/az-wap/botengine/domain/bot"
+	pkgError "github.com/AzielCF/az-wap/core/pkg/error"
+	"github.com/sirupsen/logrus"
+)
+
+// revisionCommitAttempts limita los reintentos cuando otro cambio concurrente toma el mismo número
+const revisionCommitAttempts = 3
+
+// commit guarda la configuración nueva y, si cambió algo versionable, la registra como revisión.
+// Devuelve el número de revisión vigente tras el cambio.
+func (s *botService) commit(ctx context.Context, stored, updated domainBot.Bot, author, note string, restoredFrom int) (int, error) {
+	if s.revisions == nil {
+		updated.Revision = stored.Revision
+		return updated.Revision, s.repo.Update(ctx, updated)
+	}
+
+	for attempt := 1; ; attempt++ {
+		revision, err := s.commitRevision(ctx, stored, updated, author, note, restoredFrom)
+		if !errors.Is(err, domainBot.ErrRevisionConflict) || attempt == revisionCommitAttempts {
+			return revision, err
+		}
+		// Otro cambio tomó el número: se recarga lo guardado y se vuelve a comparar
+		logrus.Warnf("[BOT] Concurrent change on bot %s, retrying revision (attempt %d)", stored.ID, attempt+1)
+		if stored, err = s.repo.GetByID(ctx, stored.ID); err != nil {
+			return 0, err
+		}
+	}
+}
+
+// commitRevision reserva el siguiente número insertando la revisión (bot_id + number es único)
+// y solo entonces actualiza el bot; si la actualización falla, la revisión se retira.
+func (s *botService) commitRevision(ctx context.Context, stored, updated domainBot.Bot, author, note string, restoredFrom int) (int, error) {
+	updated.Revision = stored.Revision
+	changes := domainBot.DiffBots(stored, updated)
+	if len(changes) == 0 {
+		return updated.Revision, s.repo.Update(ctx, updated)
+	}
+
+	latest := stored.Revision
+	history, err := s.revisions.ListRevisions(ctx, stored.ID, 1)
+	if err != nil {
+		return stored.Revision, fmt.Errorf("failed to read bot revisions: %w", err)
+	}
+	if len(history) > 0 && history[0].Number > latest {
+		latest = history[0].Number
+	}
+
+	// Bots anteriores al versionado: su configuración actual queda como revisión base
+	if latest == 0 {
+		err := s.saveRevision(ctx, domainBot.BotRevision{
+			BotID:    stored.ID,
+			Number:   1,
+			Author:   "system",
+			Note:     "baseline",
+			Snapshot: stored.Snapshot(),
+		})
+		if err != nil && !errors.Is(err, domainBot.ErrRevisionConflict) {
+			return stored.Revision, err
+		}
+		latest = 1
+	}
+
+	number := latest + 1
+	if err := s.saveRevision(ctx, domainBot.BotRevision{
+		BotID:        updated.ID,
+		Number:       number,
+		Author:       author,
+		Note:         note,
+		Snapshot:     updated.Snapshot(),
+		Changes:      changes,
+		RestoredFrom: restoredFrom,
+	}); err != nil {
+		return stored.Revision, err
+	}
+
+	updated.Revision = number
+	if err := s.repo.Update(ctx, updated); err != nil {
+		if derr := s.revisions.DeleteRevision(ctx, updated.ID, number); derr != nil {
+			logrus.WithError(derr).Errorf("[BOT] Failed to withdraw unapplied revision %d of bot %s", number, updated.ID)
+		}
+		return stored.Revision, err
+	}
+	return number, nil
+}
+
+func (s *botService) saveRevision(ctx context.Context, rev domainBot.BotRevision) error {
+	if s.revisions == nil {
+		return nil
+	}
+	rev.CreatedAt = time.Now()
+	if err := s.revisions.CreateRevision(ctx, rev); err != nil {
+		if errors.Is(err, domainBot.ErrRevisionConflict) {
+			return err
+		}
+		return fmt.Errorf("failed to store revision %d of bot %s: %w", rev.Number, rev.BotID, err)
+	}
+	return nil
+}
+
+func (s *botService) ensureRevisions() error {
+	if err := s.ensureRepo(); err != nil {
+		return err
+	}
+	if s.revisions == nil {
+		return pkgError.InternalServerError("bot revision storage is not initialized")
+	}
+	return nil
+}
+
+func (s *botService) ListRevisions(ctx context.Context, id string) ([]domainBot.BotRevision, error) {
+	if err := s.ensureRevisions(); err != nil {
+		return nil, err
+	}
+	return s.revisions.ListRevisions(ctx, id, 0)
+}
+
+func (s *botService) GetRevision(ctx context.Context, id string, number int) (domainBot.BotRevision, error) {
+	if err := s.ensureRevisions(); err != nil {
+		return domainBot.BotRevision{}, err
+	}
+	return s.revisions.GetRevision(ctx, id, number)
+}
+
+func (s *botService) GetAtRevision(ctx context.Context, id string, number int) (domainBot.Bot, error) {
+	current, err := s.GetByID(ctx, id)
+	if err != nil || number <= 0 || number == current.Revision {
+		return current, err
+	}
+	if err := s.ensureRevisions(); err != nil {
+		return domainBot.Bot{}, err
+	}
+	rev, err := s.revisions.GetRevision(ctx, current.ID, number)
+	if err != nil {
+		return domainBot.Bot{}, err
+	}
+	stored, err := s.repo.GetByID(ctx, current.ID)
+	if err != nil {
+		return domainBot.Bot{}, err
+	}
+
+	b := stored.Restore(rev.Snapshot)
+	b.Revision = number
+	if b.Model == "" {
+		b.Model = domainBot.DefaultGeminiModel
+	}
+	s.resolveCredentials(ctx, &b)
+	return b, nil
+}
+
+func (s *botService) Rollback(ctx context.Context, id string, number int, author string) (domainBot.Bot, error) {
+	if err := s.ensureRevisions(); err != nil {
+		return domainBot.Bot{}, err
+	}
+	stored, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return domainBot.Bot{}, err
+	}
+	rev, err := s.revisions.GetRevision(ctx, stored.ID, number)
+	if err != nil {
+		return domainBot.Bot{}, err
+	}
+
+	restored := stored.Restore(rev.Snapshot)
+	revision, err := s.commit(ctx, stored, restored, author, fmt.Sprintf("rollback to revision %d", number), number)
+	if err != nil {
+		return domainBot.Bot{}, err
+	}
+	logrus.Infof("[BOT] Bot %s rolled back to revision %d (now revision %d)", stored.ID, number, revision)
+	return s.GetByID(ctx, stored.ID)
+}
+
+func (s *botService) RecordVersionUsage(ctx context.Context, usage domainBot.VersionUsage) error {
+	if s.revisions == nil {
+		return nil
+	}
+	return s.revisions.RecordVersionUsage(ctx, usage)
+}
+
+func (s *botService) RecordVersionFeedback(ctx context.Context, feedback domainBot.VersionFeedback) error {
+	if err := s.ensureRevisions(); err != nil {
+		return err
+	}
+	bot, err := s.repo.GetByID(ctx, feedback.BotID)
+	if err != nil {
+		return err
+	}
+	feedback.BotID = bot.ID
+	if feedback.Revision <= 0 {
+		feedback.Revision = bot.Revision // Sin revisión explícita cuenta para la vigente
+	}
+	return s.revisions.RecordVersionFeedback(ctx, feedback)
+}
+
+func (s *botService) VersionStats(ctx context.Context, id string) ([]domainBot.VersionStats, error) {
+	if err := s.ensureRevisions(); err != nil {
+		return nil, err
+	}
+	return s.revisions.ListVersionStats(ctx, id)
+}
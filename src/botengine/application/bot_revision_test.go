@@ -0,0 +1,133 @@
+package application
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	domainBot "github.com/AzielCF/az-wap/botengine/domain/bot"
+	"github.com/AzielCF/az-wap/botengine/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func newRevisionedBotService(t *testing.T) *botService {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "bots.db")), &gorm.Config{})
+	require.NoError(t, err)
+
+	repo := repository.NewBotGormRepository(db)
+	require.NoError(t, repo.Init(context.Background()))
+	revisions := repository.NewBotRevisionGormRepository(db)
+	require.NoError(t, revisions.Init(context.Background()))
+
+	return NewBotServiceWithDeps(repo, revisions, nil).(*botService)
+}
+
+func TestBotService_RevisionsAndRollback(t *testing.T) {
+	svc := newRevisionedBotService(t)
+	ctx := context.Background()
+
+	created, err := svc.Create(ctx, domainBot.CreateBotRequest{Name: "Bot", APIKey: "key-1", SystemPrompt: "v1", Author: "ana"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, created.Revision)
+
+	updated, err := svc.Update(ctx, created.ID, domainBot.UpdateBotRequest{Name: "Bot", APIKey: "key-2", SystemPrompt: "v2", Author: "luis", ChangeNote: "tone"})
+	require.NoError(t, err)
+	assert.Equal(t, 2, updated.Revision)
+
+	// Cambiar solo la clave no genera revisión: los secretos no se versionan
+	same, err := svc.Update(ctx, created.ID, domainBot.UpdateBotRequest{Name: "Bot", APIKey: "key-3", SystemPrompt: "v2"})
+	require.NoError(t, err)
+	assert.Equal(t, 2, same.Revision)
+
+	rev, err := svc.GetRevision(ctx, created.ID, 2)
+	require.NoError(t, err)
+	assert.Equal(t, "luis", rev.Author)
+	assert.Empty(t, rev.Snapshot.APIKey)
+	require.Len(t, rev.Changes, 1)
+	assert.Equal(t, "system_prompt", rev.Changes[0].Field)
+
+	pinned, err := svc.GetAtRevision(ctx, created.ID, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "v1", pinned.SystemPrompt)
+	assert.Equal(t, "key-3", pinned.APIKey)
+
+	restored, err := svc.Rollback(ctx, created.ID, 1, "ana")
+	require.NoError(t, err)
+	assert.Equal(t, "v1", restored.SystemPrompt)
+	assert.Equal(t, 3, restored.Revision)
+	assert.Equal(t, "key-3", restored.APIKey)
+
+	revisions, err := svc.ListRevisions(ctx, created.ID)
+	require.NoError(t, err)
+	require.Len(t, revisions, 3)
+	assert.Equal(t, 1, revisions[0].RestoredFrom)
+}
+
+func TestBotService_RevisionsStripVoiceKeysAndTrackFeedback(t *testing.T) {
+	svc := newRevisionedBotService(t)
+	ctx := context.Background()
+
+	voice := &domainBot.VoiceConfig{Policy: domainBot.VoiceReplyAlways, APIKey: "tts-1"}
+	variants := map[string]domainBot.BotVariant{"short": {Name: "Short", SystemPrompt: "short", Voice: &domainBot.VoiceConfig{Policy: domainBot.VoiceReplyMirror, APIKey: "tts-v"}}}
+	created, err := svc.Create(ctx, domainBot.CreateBotRequest{Name: "Bot", SystemPrompt: "v1", Voice: voice, Variants: variants})
+	require.NoError(t, err)
+
+	rev, err := svc.GetRevision(ctx, created.ID, 1)
+	require.NoError(t, err)
+	require.NotNil(t, rev.Snapshot.Voice)
+	assert.Empty(t, rev.Snapshot.Voice.APIKey)
+	assert.Empty(t, rev.Snapshot.Variants["short"].Voice.APIKey)
+
+	_, err = svc.Update(ctx, created.ID, domainBot.UpdateBotRequest{Name: "Bot", SystemPrompt: "v2", Voice: &domainBot.VoiceConfig{Policy: domainBot.VoiceReplyAlways, APIKey: "tts-2"}, Variants: variants})
+	require.NoError(t, err)
+	restored, err := svc.Rollback(ctx, created.ID, 1, "ana")
+	require.NoError(t, err)
+	assert.Equal(t, "tts-2", restored.Voice.APIKey)
+	assert.Equal(t, "tts-v", restored.Variants["short"].Voice.APIKey)
+
+	// Dos cambios que parten de la misma lectura no pueden reclamar el mismo número
+	stored, err := svc.repo.GetByID(ctx, created.ID)
+	require.NoError(t, err)
+	a, b := stored, stored
+	a.SystemPrompt, b.SystemPrompt = "race-a", "race-b"
+	first, err := svc.commit(ctx, stored, a, "ana", "", 0)
+	require.NoError(t, err)
+	second, err := svc.commit(ctx, stored, b, "luis", "", 0)
+	require.NoError(t, err)
+	assert.Equal(t, first+1, second)
+
+	require.NoError(t, svc.RecordVersionFeedback(ctx, domainBot.VersionFeedback{BotID: created.ID, Revision: 1, Positive: true}))
+	require.NoError(t, svc.RecordVersionFeedback(ctx, domainBot.VersionFeedback{BotID: created.ID, Revision: 1, Positive: false}))
+	require.NoError(t, svc.RecordVersionFeedback(ctx, domainBot.VersionFeedback{BotID: created.ID, Revision: 1, Positive: true}))
+	stats, err := svc.VersionStats(ctx, created.ID)
+	require.NoError(t, err)
+	require.Len(t, stats, 1)
+	assert.Equal(t, int64(2), stats[0].PositiveFeedback)
+	assert.Equal(t, int64(1), stats[0].NegativeFeedback)
+	assert.InDelta(t, 2.0/3.0, stats[0].FeedbackScore, 0.001)
+}
+
+func TestTrafficSplit_ChooseIsStickyAndWeighted(t *testing.T) {
+	split := &domainBot.TrafficSplit{Arms: []domainBot.SplitArm{
+		{Name: "a", Revision: 3, Weight: 90},
+		{Name: "b", VariantID: "short", Weight: 10},
+	}}
+	require.NoError(t, split.Validate())
+
+	first, _ := split.Choose("ch|chat-1")
+	again, _ := split.Choose("ch|chat-1")
+	assert.Equal(t, first, again)
+
+	counts := map[string]int{}
+	for i := 0; i < 2000; i++ {
+		arm, ok := split.Choose("ch|" + string(rune('a'+i%26)) + string(rune(i)))
+		require.True(t, ok)
+		counts[arm.Name]++
+	}
+	assert.Greater(t, counts["a"], counts["b"]*4)
+	assert.Greater(t, counts["b"], 0)
+}
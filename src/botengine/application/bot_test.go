@@ -35,7 +35,7 @@ func newTestBotService(t *testing.T) *botService {
 	}
 
 	// Crear el servicio inyectando el repositorio
-	svc := NewBotServiceWithDeps(repo, nil)
+	svc := NewBotServiceWithDeps(repo, nil, nil)
 
 	bs, ok := svc.(*botService)
 	if !ok {
@@ -24,6 +24,7 @@ type mcpService struct {
 	repo     domainMCP.IMCPRepository
 	provider domainMCP.IMCPProvider
 	health   domainHealth.IHealthUsecase
+	cache    mcpContentCache
 }
 
 func NewMCPService(db *gorm.DB) domainMCP.IMCPUsecase {
@@ -63,6 +64,10 @@ func (s *mcpService) AddServer(ctx context.Context, server domainMCP.MCPServer)
 		server.ID = uuid.NewString()
 	}
 
+	if err := checkStdioAllowed(server); err != nil {
+		return server, err
+	}
+
 	// Validación de HTTPS
 	if server.Type == domainMCP.ConnTypeSSE {
 		if os.Getenv("MCP_ALLOW_INSECURE_HTTP") != "true" && !strings.HasPrefix(server.URL, "https://") {
@@ -94,7 +99,13 @@ func (s *mcpService) AddServer(ctx context.Context, server domainMCP.MCPServer)
 		server.Tools = tools
 	}
 
-	return server, s.repo.AddServer(ctx, server)
+	if err := s.repo.AddServer(ctx, server); err != nil {
+		return server, err
+	}
+	if !isDynamic {
+		s.refreshCapabilitiesQuietly(ctx, server)
+	}
+	return server, nil
 }
 
 func (s *mcpService) ListServers(ctx context.Context) ([]domainMCP.MCPServer, error) {
@@ -116,6 +127,10 @@ func (s *mcpService) UpdateServer(ctx context.Context, id string, server domainM
 		return server, err
 	}
 
+	if err := checkStdioAllowed(server); err != nil {
+		return server, err
+	}
+
 	// Validation Logic (Same as AddServer)
 	isDynamic := server.IsTemplate || strings.Contains(server.URL, "{")
 
@@ -136,7 +151,25 @@ func (s *mcpService) UpdateServer(ctx context.Context, id string, server domainM
 		server.Tools = tools
 	}
 
-	return server, s.repo.UpdateServer(ctx, id, server)
+	// El body no trae el cache de resources/prompts; se conserva el existente.
+	if existing, err := s.repo.GetServer(ctx, id); err == nil {
+		if server.Resources == nil {
+			server.Resources = existing.Resources
+		}
+		if server.Prompts == nil {
+			server.Prompts = existing.Prompts
+		}
+	}
+
+	if err := s.repo.UpdateServer(ctx, id, server); err != nil {
+		return server, err
+	}
+	s.cache.invalidate(id)
+	if !isDynamic {
+		server.ID = id
+		s.refreshCapabilitiesQuietly(ctx, server)
+	}
+	return server, nil
 }
 
 func (s *mcpService) DeleteServer(ctx context.Context, id string) error {
@@ -166,36 +199,12 @@ func (s *mcpService) ListTools(ctx context.Context, id string) ([]domainMCP.Tool
 }
 
 func (s *mcpService) CallTool(ctx context.Context, botID string, req domainMCP.CallToolRequest) (domainMCP.CallToolResult, error) {
-	srv, err := s.GetServer(ctx, req.ServerID)
+	// Inyectar cabeceras personalizadas y variables de URL del bot
+	srv, err := s.serverForBot(ctx, botID, req.ServerID)
 	if err != nil {
 		return domainMCP.CallToolResult{}, err
 	}
 
-	// Inyectar cabeceras personalizadas del bot
-	if cfg, err := s.repo.GetBotMCPConfig(ctx, botID, req.ServerID); err == nil && cfg.ConfigJSON != "" {
-		var bc domainMCP.BotMCPConfigJSON
-		if err := json.Unmarshal([]byte(cfg.ConfigJSON), &bc); err == nil {
-			if srv.Headers == nil {
-				srv.Headers = make(map[string]string)
-			}
-			for k, v := range bc.CustomHeaders {
-				if dec, err := crypto.Decrypt(v); err == nil {
-					srv.Headers[k] = dec
-				} else {
-					srv.Headers[k] = v
-				}
-			}
-			// Apply URL Variables
-			for k, v := range bc.URLVariables {
-				val := v
-				if dec, err := crypto.Decrypt(v); err == nil {
-					val = dec
-				}
-				srv.URL = strings.ReplaceAll(srv.URL, "{"+k+"}", val)
-			}
-		}
-	}
-
 	res, err := s.provider.CallTool(ctx, srv, req.ToolName, req.Arguments)
 	if err != nil {
 		s.reportHealth(ctx, req.ServerID, err)
@@ -263,6 +272,8 @@ func (s *mcpService) ListServersForBot(ctx context.Context, botID string) ([]dom
 				servers[i].BotInstructions = bc.Instructions
 				servers[i].CustomHeaders = s.decryptMap(bc.CustomHeaders)
 				servers[i].URLVariables = s.decryptMap(bc.URLVariables)
+				servers[i].AttachedResources = bc.AttachedResources
+				servers[i].ReadableResources = bc.ReadableResources
 			}
 		}
 	}
@@ -343,8 +354,12 @@ func (s *mcpService) UpdateBotMCPConfig(ctx context.Context, cfg domainMCP.BotMC
 		CustomHeaders: encHeaders,
 		Instructions:  cfg.Instructions,
 		URLVariables:  encVars,
+
+		AttachedResources: cfg.AttachedResources,
+		ReadableResources: cfg.ReadableResources,
 	})
 
+	s.cache.invalidate(cfg.ServerID)
 	return s.repo.SaveBotMCPConfig(ctx, cfg.BotID, cfg.ServerID, cfg.Enabled, string(confJSON))
 }
 
@@ -366,6 +381,9 @@ func (s *mcpService) Validate(ctx context.Context, id string) error {
 	if err == nil && len(tools) > 0 {
 		_ = s.repo.UpdateServerTools(ctx, id, tools)
 	}
+	if err == nil {
+		s.refreshCapabilitiesQuietly(ctx, srv)
+	}
 	return err
 }
 
@@ -415,6 +433,14 @@ func (s *mcpService) ListBotsUsingServer(ctx context.Context, sid string) ([]str
 
 func (s *mcpService) SetHealthUsecase(h domainHealth.IHealthUsecase) {
 	s.health = h
+	// Los crashes de procesos stdio ocurren fuera de cualquier petición; el adaptador los reporta aquí.
+	if reporter, ok := s.provider.(interface {
+		SetHealthReporter(fn func(serverID string, err error))
+	}); ok {
+		reporter.SetHealthReporter(func(serverID string, err error) {
+			s.reportHealth(context.Background(), serverID, err)
+		})
+	}
 }
 
 func (s *mcpService) Shutdown() {
@@ -423,6 +449,20 @@ func (s *mcpService) Shutdown() {
 
 // === Helpers ===
 
+// checkStdioAllowed bloquea servidores stdio salvo que el operador los habilite por entorno.
+func checkStdioAllowed(server domainMCP.MCPServer) error {
+	if server.Type != domainMCP.ConnTypeStdio {
+		return nil
+	}
+	if coreconfig.Global == nil || !coreconfig.Global.MCP.StdioEnabled {
+		return pkgError.ValidationError("stdio MCP servers are disabled on this instance (MCP_STDIO_ENABLED)")
+	}
+	if strings.TrimSpace(server.Command) == "" {
+		return pkgError.ValidationError("command: cannot be blank for stdio servers.")
+	}
+	return nil
+}
+
 func (s *mcpService) reportHealth(ctx context.Context, id string, err error) {
 	if s.health == nil {
 		return
@@ -0,0 +1,94 @@
+package application
+
+import (
+	"encoding/base64"
+	"fmt"
+	"mime"
+	"strings"
+
+	domain "github.com/AzielCF/az-wap/botengine/domain"
+	domainMCP "github.com/AzielCF/az-wap/botengine/domain/mcp"
+	"github.com/sirupsen/logrus"
+)
+
+// maxMCPAttachmentBytes limita el tamaño de cada archivo devuelto por una herramienta MCP.
+const maxMCPAttachmentBytes = 16 * 1024 * 1024
+
+// mcpContentSplit separa el resultado de una herramienta MCP en lo que ve el modelo,
+// lo que se analiza vía multimodal y lo que se reenvía al usuario.
+type mcpContentSplit struct {
+	ModelParts []map[string]any   // Resumen seguro para el historial (sin base64)
+	ForModel   []*domain.BotMedia // Imágenes/audio/recursos binarios a interpretar
+	ForUser    []*domain.BotMedia // Archivos marcados con audience "user"
+}
+
+func splitMCPContent(toolName string, contents []domainMCP.CallToolContent) mcpContentSplit {
+	var out mcpContentSplit
+	for i, c := range contents {
+		if !c.IsBinary() {
+			part := map[string]any{"type": c.Type}
+			if c.Text != "" {
+				part["text"] = c.Text
+			}
+			if c.URI != "" {
+				part["uri"] = c.URI
+			}
+			if c.MimeType != "" {
+				part["mime_type"] = c.MimeType
+			}
+			out.ModelParts = append(out.ModelParts, part)
+			continue
+		}
+
+		data, err := base64.StdEncoding.DecodeString(c.Data)
+		if err != nil {
+			out.ModelParts = append(out.ModelParts, map[string]any{"type": c.Type, "error": "invalid base64 payload"})
+			continue
+		}
+		if len(data) > maxMCPAttachmentBytes {
+			logrus.Warnf("[MCP] Tool %s returned a %d byte attachment, dropping it", toolName, len(data))
+			out.ModelParts = append(out.ModelParts, map[string]any{"type": c.Type, "error": "attachment too large"})
+			continue
+		}
+
+		media := &domain.BotMedia{
+			Data:     data,
+			MimeType: c.MimeType,
+			FileName: attachmentName(toolName, i, c),
+			URL:      c.URI,
+			State:    domain.MediaStateAvailable,
+		}
+
+		part := map[string]any{"type": c.Type, "mime_type": c.MimeType, "file_name": media.FileName}
+		if c.ForUser() {
+			out.ForUser = append(out.ForUser, media)
+			part["delivered_to_user"] = true
+		}
+		// Sin audience explícita el archivo es para el modelo; con audience solo si incluye "assistant".
+		if len(c.Audience) == 0 || containsRole(c.Audience, "assistant") {
+			out.ForModel = append(out.ForModel, media)
+		}
+		out.ModelParts = append(out.ModelParts, part)
+	}
+	return out
+}
+
+func attachmentName(toolName string, idx int, c domainMCP.CallToolContent) string {
+	if c.Name != "" && c.Name != "." && c.Name != "/" {
+		return c.Name
+	}
+	ext := ""
+	if exts, _ := mime.ExtensionsByType(c.MimeType); len(exts) > 0 {
+		ext = exts[0]
+	}
+	return fmt.Sprintf("%s_%d%s", strings.ReplaceAll(toolName, "/", "_"), idx+1, ext)
+}
+
+func containsRole(roles []string, role string) bool {
+	for _, r := range roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,43 @@
+package application
+
+import (
+	"encoding/base64"
+	"testing"
+
+	domainMCP "github.com/AzielCF/az-wap/botengine/domain/mcp"
+)
+
+func TestSplitMCPContent(t *testing.T) {
+	png := base64.StdEncoding.EncodeToString([]byte("\x89PNG fake"))
+	pdf := base64.StdEncoding.EncodeToString([]byte("%PDF fake"))
+
+	split := splitMCPContent("render_chart", []domainMCP.CallToolContent{
+		{Type: domainMCP.ContentText, Text: "chart ready"},
+		{Type: domainMCP.ContentImage, Data: png, MimeType: "image/png"},
+		{Type: domainMCP.ContentResource, Data: pdf, MimeType: "application/pdf", URI: "file:///tmp/report.pdf", Name: "report.pdf", Audience: []string{"user"}},
+		{Type: domainMCP.ContentImage, Data: "%%%not-base64", MimeType: "image/png"},
+	})
+
+	if len(split.ModelParts) != 4 {
+		t.Fatalf("ModelParts = %d, want 4", len(split.ModelParts))
+	}
+	for _, part := range split.ModelParts {
+		if _, leaked := part["data"]; leaked {
+			t.Fatalf("binary payload leaked into model parts: %v", part)
+		}
+	}
+	if split.ModelParts[3]["error"] == nil {
+		t.Fatalf("invalid base64 part should be reported as error, got %v", split.ModelParts[3])
+	}
+
+	if len(split.ForModel) != 1 || split.ForModel[0].MimeType != "image/png" {
+		t.Fatalf("ForModel = %+v, want the untagged image only", split.ForModel)
+	}
+	if string(split.ForModel[0].Data) != "\x89PNG fake" {
+		t.Fatalf("ForModel data not decoded")
+	}
+
+	if len(split.ForUser) != 1 || split.ForUser[0].FileName != "report.pdf" {
+		t.Fatalf("ForUser = %+v, want report.pdf", split.ForUser)
+	}
+}
@@ -0,0 +1,194 @@
+package application
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	domainMCP "github.com/AzielCF/az-wap/botengine/domain/mcp"
+	"github.com/AzielCF/az-wap/core/pkg/crypto"
+	pkgError "github.com/AzielCF/az-wap/core/pkg/error"
+	"github.com/sirupsen/logrus"
+)
+
+// mcpContentCacheTTL evita pedir el mismo recurso/prompt al servidor en cada mensaje.
+const mcpContentCacheTTL = 5 * time.Minute
+
+type mcpCacheEntry struct {
+	value   any
+	expires time.Time
+}
+
+// mcpContentCache es un cache en memoria con expiración para recursos y prompts ya resueltos.
+type mcpContentCache struct {
+	entries sync.Map // key -> mcpCacheEntry
+}
+
+func (c *mcpContentCache) get(key string) (any, bool) {
+	val, ok := c.entries.Load(key)
+	if !ok {
+		return nil, false
+	}
+	entry := val.(mcpCacheEntry)
+	if time.Now().After(entry.expires) {
+		c.entries.Delete(key)
+		return nil, false
+	}
+	return entry.value, true
+}
+
+func (c *mcpContentCache) set(key string, value any) {
+	c.entries.Store(key, mcpCacheEntry{value: value, expires: time.Now().Add(mcpContentCacheTTL)})
+}
+
+// invalidate descarta todo lo cacheado de un servidor (tras editarlo o refrescar capacidades).
+func (c *mcpContentCache) invalidate(serverID string) {
+	c.entries.Range(func(key, _ any) bool {
+		if strings.HasPrefix(key.(string), serverID+"|") {
+			c.entries.Delete(key)
+		}
+		return true
+	})
+}
+
+// === Resources & Prompts ===
+
+func (s *mcpService) ListResources(ctx context.Context, serverID string) ([]domainMCP.Resource, error) {
+	srv, err := s.GetServer(ctx, serverID)
+	if err != nil {
+		return nil, err
+	}
+	caps, err := s.refreshCapabilities(ctx, srv)
+	if err != nil {
+		return nil, err
+	}
+	return caps.Resources, nil
+}
+
+func (s *mcpService) ListPrompts(ctx context.Context, serverID string) ([]domainMCP.Prompt, error) {
+	srv, err := s.GetServer(ctx, serverID)
+	if err != nil {
+		return nil, err
+	}
+	caps, err := s.refreshCapabilities(ctx, srv)
+	if err != nil {
+		return nil, err
+	}
+	return caps.Prompts, nil
+}
+
+func (s *mcpService) ReadResource(ctx context.Context, botID, serverID, uri string) ([]domainMCP.CallToolContent, error) {
+	srv, err := s.serverForBot(ctx, botID, serverID)
+	if err != nil {
+		return nil, err
+	}
+	if !srv.CanRead(uri) {
+		return nil, pkgError.ValidationError(fmt.Sprintf("resource %s is not enabled for this bot", uri))
+	}
+
+	key := serverID + "|resource|" + botID + "|" + uri
+	if cached, ok := s.cache.get(key); ok {
+		return cached.([]domainMCP.CallToolContent), nil
+	}
+
+	contents, err := s.provider.ReadResource(ctx, srv, uri)
+	if err != nil {
+		s.reportHealth(ctx, serverID, err)
+		return nil, err
+	}
+	s.cache.set(key, contents)
+	return contents, nil
+}
+
+func (s *mcpService) GetPrompt(ctx context.Context, botID, serverID, name string, args map[string]string) (domainMCP.PromptResult, error) {
+	srv, err := s.serverForBot(ctx, botID, serverID)
+	if err != nil {
+		return domainMCP.PromptResult{}, err
+	}
+
+	argsJSON, _ := json.Marshal(args) // json ordena las claves: la key es estable
+	key := serverID + "|prompt|" + botID + "|" + name + "|" + string(argsJSON)
+	if cached, ok := s.cache.get(key); ok {
+		return cached.(domainMCP.PromptResult), nil
+	}
+
+	res, err := s.provider.GetPrompt(ctx, srv, name, args)
+	if err != nil {
+		s.reportHealth(ctx, serverID, err)
+		return domainMCP.PromptResult{}, err
+	}
+	s.cache.set(key, res)
+	return res, nil
+}
+
+// refreshCapabilities consulta resources/list y prompts/list y actualiza el cache del repositorio.
+func (s *mcpService) refreshCapabilities(ctx context.Context, srv domainMCP.MCPServer) (domainMCP.ServerCapabilities, error) {
+	var caps domainMCP.ServerCapabilities
+	var err error
+
+	if caps.Resources, err = s.provider.ListResources(ctx, srv); err != nil {
+		return caps, fmt.Errorf("list resources: %w", err)
+	}
+	if caps.Prompts, err = s.provider.ListPrompts(ctx, srv); err != nil {
+		return caps, fmt.Errorf("list prompts: %w", err)
+	}
+
+	sort.Slice(caps.Resources, func(i, j int) bool { return caps.Resources[i].URI < caps.Resources[j].URI })
+	sort.Slice(caps.Prompts, func(i, j int) bool { return caps.Prompts[i].Name < caps.Prompts[j].Name })
+
+	if err := s.repo.UpdateServerCapabilities(ctx, srv.ID, caps); err != nil {
+		logrus.WithError(err).Warnf("[MCP] Failed to cache capabilities for %s", srv.Name)
+	}
+	s.cache.invalidate(srv.ID)
+	return caps, nil
+}
+
+// refreshCapabilitiesQuietly se usa tras validar: un servidor sin resources/prompts no es un error.
+func (s *mcpService) refreshCapabilitiesQuietly(ctx context.Context, srv domainMCP.MCPServer) {
+	if _, err := s.refreshCapabilities(ctx, srv); err != nil {
+		logrus.Debugf("[MCP] Capabilities refresh skipped for %s: %v", srv.Name, err)
+	}
+}
+
+// serverForBot resuelve el servidor con las cabeceras, variables de URL y permisos de recursos del bot.
+func (s *mcpService) serverForBot(ctx context.Context, botID, serverID string) (domainMCP.MCPServer, error) {
+	srv, err := s.GetServer(ctx, serverID)
+	if err != nil {
+		return srv, err
+	}
+
+	cfg, err := s.repo.GetBotMCPConfig(ctx, botID, serverID)
+	if err != nil || cfg.ConfigJSON == "" {
+		return srv, nil
+	}
+
+	var bc domainMCP.BotMCPConfigJSON
+	if err := json.Unmarshal([]byte(cfg.ConfigJSON), &bc); err != nil {
+		return srv, nil
+	}
+
+	if srv.Headers == nil {
+		srv.Headers = make(map[string]string)
+	}
+	for k, v := range bc.CustomHeaders {
+		if dec, err := crypto.Decrypt(v); err == nil {
+			srv.Headers[k] = dec
+		} else {
+			srv.Headers[k] = v
+		}
+	}
+	for k, v := range bc.URLVariables {
+		val := v
+		if dec, err := crypto.Decrypt(v); err == nil {
+			val = dec
+		}
+		srv.URL = strings.ReplaceAll(srv.URL, "{"+k+"}", val)
+	}
+	srv.AttachedResources = bc.AttachedResources
+	srv.ReadableResources = bc.ReadableResources
+	return srv, nil
+}
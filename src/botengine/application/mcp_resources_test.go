@@ -0,0 +1,136 @@
+package application
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	domainMCP "github.com/AzielCF/az-wap/botengine/domain/mcp"
+	"github.com/AzielCF/az-wap/botengine/repository"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// fakeMCPProvider simula un servidor MCP con un recurso y un prompt.
+type fakeMCPProvider struct {
+	reads int
+}
+
+func (f *fakeMCPProvider) ListTools(ctx context.Context, server domainMCP.MCPServer) ([]domainMCP.Tool, error) {
+	return nil, nil
+}
+
+func (f *fakeMCPProvider) CallTool(ctx context.Context, server domainMCP.MCPServer, toolName string, args map[string]interface{}) (domainMCP.CallToolResult, error) {
+	return domainMCP.CallToolResult{}, nil
+}
+
+func (f *fakeMCPProvider) ListResources(ctx context.Context, server domainMCP.MCPServer) ([]domainMCP.Resource, error) {
+	return []domainMCP.Resource{
+		{URI: "docs://pricing", Name: "Pricing", MimeType: "text/markdown"},
+		{URI: "docs://internal", Name: "Internal"},
+	}, nil
+}
+
+func (f *fakeMCPProvider) ReadResource(ctx context.Context, server domainMCP.MCPServer, uri string) ([]domainMCP.CallToolContent, error) {
+	f.reads++
+	return []domainMCP.CallToolContent{{Type: domainMCP.ContentResource, URI: uri, Text: "Plan Pro: $10"}}, nil
+}
+
+func (f *fakeMCPProvider) ListPrompts(ctx context.Context, server domainMCP.MCPServer) ([]domainMCP.Prompt, error) {
+	return []domainMCP.Prompt{{Name: "sales", Arguments: []domainMCP.PromptArgument{{Name: "tone", Required: true}}}}, nil
+}
+
+func (f *fakeMCPProvider) GetPrompt(ctx context.Context, server domainMCP.MCPServer, name string, args map[string]string) (domainMCP.PromptResult, error) {
+	return domainMCP.PromptResult{Messages: []domainMCP.PromptMessage{
+		{Role: "user", Text: "You are a sales assistant."},
+		{Role: "user", Text: "Tone: " + args["tone"]},
+	}}, nil
+}
+
+func (f *fakeMCPProvider) Validate(ctx context.Context, server domainMCP.MCPServer, fullHandshake bool) ([]domainMCP.Tool, error) {
+	return nil, nil
+}
+
+func (f *fakeMCPProvider) Shutdown() {}
+
+func newTestMCPService(t *testing.T) (*mcpService, *fakeMCPProvider) {
+	t.Helper()
+
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "test_mcp.db")+"?_foreign_keys=on")
+	if err != nil {
+		t.Fatalf("Failed to open test database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	repo, err := repository.NewMCPSQLiteRepositoryWithDB(db)
+	if err != nil {
+		t.Fatalf("Failed to create MCP repository: %v", err)
+	}
+	if err := repo.AddServer(context.Background(), domainMCP.MCPServer{ID: "srv-1", Name: "docs", Type: domainMCP.ConnTypeHTTP, URL: "https://docs.example"}); err != nil {
+		t.Fatalf("AddServer() unexpected error: %v", err)
+	}
+
+	provider := &fakeMCPProvider{}
+	return NewMCPServiceWithDeps(repo, provider).(*mcpService), provider
+}
+
+func TestMCPService_CapabilitiesAreCached(t *testing.T) {
+	svc, _ := newTestMCPService(t)
+	ctx := context.Background()
+
+	if _, err := svc.ListResources(ctx, "srv-1"); err != nil {
+		t.Fatalf("ListResources() unexpected error: %v", err)
+	}
+
+	srv, err := svc.GetServer(ctx, "srv-1")
+	if err != nil {
+		t.Fatalf("GetServer() unexpected error: %v", err)
+	}
+	if len(srv.Resources) != 2 || srv.Resources[0].URI != "docs://internal" {
+		t.Fatalf("cached resources = %+v, want 2 sorted by URI", srv.Resources)
+	}
+	if len(srv.Prompts) != 1 || srv.Prompts[0].Name != "sales" {
+		t.Fatalf("cached prompts = %+v, want [sales]", srv.Prompts)
+	}
+}
+
+func TestMCPService_ReadResourceRequiresPermission(t *testing.T) {
+	svc, provider := newTestMCPService(t)
+	ctx := context.Background()
+
+	if err := svc.repo.SaveBotMCPConfig(ctx, "bot-1", "srv-1", true, `{"readable_resources":["docs://pricing"]}`); err != nil {
+		t.Fatalf("SaveBotMCPConfig() unexpected error: %v", err)
+	}
+
+	if _, err := svc.ReadResource(ctx, "bot-1", "srv-1", "docs://internal"); err == nil {
+		t.Fatalf("ReadResource() of a non-permitted resource expected error, got nil")
+	}
+	if _, err := svc.ReadResource(ctx, "bot-2", "srv-1", "docs://pricing"); err == nil {
+		t.Fatalf("ReadResource() from a bot without config expected error, got nil")
+	}
+
+	for i := 0; i < 2; i++ {
+		contents, err := svc.ReadResource(ctx, "bot-1", "srv-1", "docs://pricing")
+		if err != nil {
+			t.Fatalf("ReadResource() unexpected error: %v", err)
+		}
+		if len(contents) != 1 || contents[0].Text != "Plan Pro: $10" {
+			t.Fatalf("ReadResource() = %+v", contents)
+		}
+	}
+	if provider.reads != 1 {
+		t.Fatalf("provider reads = %d, want 1 (second read served from cache)", provider.reads)
+	}
+}
+
+func TestMCPService_GetPromptRendersText(t *testing.T) {
+	svc, _ := newTestMCPService(t)
+
+	res, err := svc.GetPrompt(context.Background(), "bot-1", "srv-1", "sales", map[string]string{"tone": "friendly"})
+	if err != nil {
+		t.Fatalf("GetPrompt() unexpected error: %v", err)
+	}
+	if got, want := res.Text(), "You are a sales assistant.\n\nTone: friendly"; got != want {
+		t.Fatalf("Text() = %q, want %q", got, want)
+	}
+}
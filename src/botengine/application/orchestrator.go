@@ -19,6 +19,10 @@ type Orchestrator struct {
 	mcpUsecase       domainMCP.IMCPUsecase
 	nativeToolCaller func(ctx context.Context, name string, input domain.BotInput, args map[string]interface{}) (map[string]interface{}, error)
 	mediaService     *domain.MediaService
+
+	// Aprobación humana de herramientas (opcional)
+	approvals       *ApprovalQueue
+	defaultApproval func(toolName string) domainBot.ToolApprovalPolicy
 }
 
 func NewOrchestrator(mcp domainMCP.IMCPUsecase, nativeCaller func(context.Context, string, domain.BotInput, map[string]interface{}) (map[string]interface{}, error), mediaService *domain.MediaService) *Orchestrator {
@@ -29,74 +33,102 @@ func NewOrchestrator(mcp domainMCP.IMCPUsecase, nativeCaller func(context.Contex
 	}
 }
 
-// Execute realiza el bucle de razonamiento de l IA hasta obtener una respuesta de texto
-func (o *Orchestrator) Execute(ctx context.Context, p domain.AIProvider, b domainBot.Bot, input domain.BotInput, req domain.ChatRequest, serverMap map[string]string) (domain.BotOutput, error) {
-	traceID := input.TraceID
-	instanceID := input.InstanceID
-	chatJID := input.ChatID
-	originalUserText := input.Text
-
-	var finalAction string
-	var farewellMsg string
-	var finalResponse string
-	var lastAIText string
-	var totalCost float64 // Acumulador de costos de todas las iteraciones
-	var costDetails []domain.ExecutionCost
+// SetApprovals activa la retención de herramientas. defaultPolicy resuelve la política de las
+// herramientas que el bot no configura explícitamente (ej. la política por defecto de una nativa).
+func (o *Orchestrator) SetApprovals(q *ApprovalQueue, defaultPolicy func(toolName string) domainBot.ToolApprovalPolicy) {
+	o.approvals = q
+	o.defaultApproval = defaultPolicy
+}
 
-	addCost := func(botID, model string, cost float64) {
-		if cost <= 0 {
-			return
-		}
-		totalCost += cost
-		for i, d := range costDetails {
-			if d.BotID == botID && d.Model == model {
-				costDetails[i].Cost += cost
-				return
-			}
-		}
-		costDetails = append(costDetails, domain.ExecutionCost{BotID: botID, Model: model, Cost: cost})
-	}
+// execState acumula costos, archivos y la acción final a lo largo del bucle de herramientas.
+type execState struct {
+	totalCost   float64 // Acumulador de costos de todas las iteraciones
+	costDetails []domain.ExecutionCost
+	outMedias   []*domain.BotMedia // Archivos devueltos por herramientas para reenviar al usuario
+	finalAction string
+	farewellMsg string
+	isTester    bool
+}
 
+func newExecState(input domain.BotInput) *execState {
 	// REDACTION LOGIC: Check if client is a tester
 	isTester := input.IsTester
 	if input.ClientContext != nil && input.ClientContext.IsTester {
 		isTester = true
 	}
+	return &execState{isTester: isTester}
+}
 
-	// Helper for redaction
-	redactIfNeeded := func(text string) string {
-		if isTester {
-			return text
+func (st *execState) addCost(botID, model string, cost float64) {
+	if cost <= 0 {
+		return
+	}
+	st.totalCost += cost
+	for i, d := range st.costDetails {
+		if d.BotID == botID && d.Model == model {
+			st.costDetails[i].Cost += cost
+			return
 		}
-		return "[REDACTED]"
 	}
+	st.costDetails = append(st.costDetails, domain.ExecutionCost{BotID: botID, Model: model, Cost: cost})
+}
 
-	// Helper for tool args redaction
-	redactArgsIfNeeded := func(toolName string, args map[string]interface{}, isNative bool) string {
-		if isTester {
-			js, _ := json.Marshal(args)
-			return string(js)
-		}
-		// Operational args allowed ONLY for native tools
-		if isNative {
-			allowedProps := []string{"time", "date", "duration", "quantity", "status"}
-			cleanArgs := make(map[string]interface{})
-			for k, v := range args {
-				for _, allowed := range allowedProps {
-					if strings.Contains(strings.ToLower(k), allowed) {
-						cleanArgs[k] = v
-						break
-					}
+// redact oculta el texto en el monitor salvo para testers
+func (st *execState) redact(text string) string {
+	if st.isTester {
+		return text
+	}
+	return "[REDACTED]"
+}
+
+// redactArgs oculta los argumentos de herramientas salvo los operativos de las nativas
+func (st *execState) redactArgs(toolName string, args map[string]interface{}, isNative bool) string {
+	if st.isTester {
+		js, _ := json.Marshal(args)
+		return string(js)
+	}
+	// Operational args allowed ONLY for native tools
+	if isNative {
+		allowedProps := []string{"time", "date", "duration", "quantity", "status"}
+		cleanArgs := make(map[string]interface{})
+		for k, v := range args {
+			for _, allowed := range allowedProps {
+				if strings.Contains(strings.ToLower(k), allowed) {
+					cleanArgs[k] = v
+					break
 				}
 			}
-			if len(cleanArgs) > 0 {
-				cleanArgs["_redacted"] = "Sensitive content hidden"
-				js, _ := json.Marshal(cleanArgs)
-				return string(js)
-			}
 		}
-		return `{"_redacted": "Content hidden for privacy"}`
+		if len(cleanArgs) > 0 {
+			cleanArgs["_redacted"] = "Sensitive content hidden"
+			js, _ := json.Marshal(cleanArgs)
+			return string(js)
+		}
 	}
+	return `{"_redacted": "Content hidden for privacy"}`
+}
+
+func (st *execState) output(finalResponse, lastAIText string) domain.BotOutput {
+	if st.finalAction == "terminate_session" {
+		if st.farewellMsg != "" {
+			finalResponse = st.farewellMsg
+		} else if finalResponse == "" {
+			finalResponse = lastAIText
+		}
+	}
+
+	return domain.BotOutput{
+		Text:        finalResponse,
+		Action:      st.finalAction,
+		TotalCost:   st.totalCost,
+		CostDetails: st.costDetails,
+		Medias:      st.outMedias,
+	}
+}
+
+// Execute realiza el bucle de razonamiento de l IA hasta obtener una respuesta de texto
+func (o *Orchestrator) Execute(ctx context.Context, p domain.AIProvider, b domainBot.Bot, input domain.BotInput, req domain.ChatRequest, serverMap map[string]string) (domain.BotOutput, error) {
+	st := newExecState(input)
 
 	// Preparar historial para evitar repetición de UserText en el bucle (Identidad Paridad)
 	if req.UserText != "" {
@@ -107,6 +139,78 @@ func (o *Orchestrator) Execute(ctx context.Context, p domain.AIProvider, b domai
 		req.UserText = ""
 	}
 
+	return o.run(ctx, p, b, input, req, serverMap, st)
+}
+
+// Resume reanuda un bucle retenido tras la decisión sobre la herramienta pendiente.
+// userReply es la respuesta literal del usuario (vacía si decidió un operador o si caducó).
+func (o *Orchestrator) Resume(ctx context.Context, p domain.AIProvider, b domainBot.Bot, input domain.BotInput, pending *domain.PendingApproval, approved bool, userReply string) (domain.BotOutput, error) {
+	st := newExecState(input)
+	req := pending.Request
+	tc := pending.ToolCall
+
+	var toolResult map[string]any
+	stop := false
+	switch {
+	case approved:
+		toolResult, stop = o.executeToolCall(ctx, p, b, input, tc, pending.ServerMap, st)
+	case pending.Status == domain.ApprovalExpired:
+		toolResult = map[string]any{"error": "approval expired before anyone confirmed the action", "status": string(domain.ApprovalExpired)}
+	default:
+		toolResult = map[string]any{"error": "the action was not approved, it was NOT executed", "status": string(domain.ApprovalRejected)}
+		if userReply != "" {
+			toolResult["user_reply"] = userReply
+		}
+	}
+
+	botmonitor.Record(botmonitor.Event{
+		TraceID: input.TraceID, InstanceID: input.InstanceID, ChatJID: input.ChatID,
+		Stage: "tool_approval", Kind: tc.Name, Status: string(pending.Status),
+		Metadata: map[string]string{
+			"approval_id": pending.ID,
+			"policy":      string(pending.Policy),
+			"decided_by":  pending.DecidedBy,
+		},
+	})
+
+	responses := append(append([]domain.ToolResponse{}, pending.Responses...), domain.ToolResponse{
+		ID:   tc.ID,
+		Name: tc.Name,
+		Data: toolResult,
+	})
+	req.History = append(req.History, domain.ChatTurn{
+		Role:          "user",
+		ToolResponses: responses,
+	})
+
+	if stop {
+		return st.output("", ""), nil
+	}
+	return o.run(ctx, p, b, input, req, pending.ServerMap, st)
+}
+
+// chat usa el streaming del proveedor cuando la ejecución tiene un receptor de eventos adjunto
+func (o *Orchestrator) chat(ctx context.Context, p domain.AIProvider, b domainBot.Bot, req domain.ChatRequest) (domain.ChatResponse, error) {
+	onEvent := domain.StreamHandlerFrom(ctx)
+	sp, ok := p.(domain.StreamingProvider)
+	if onEvent == nil || !ok {
+		return p.Chat(ctx, b, req)
+	}
+	res, err := sp.ChatStream(ctx, b, req, onEvent)
+	onEvent(domain.StreamEvent{Type: domain.StreamEventDone})
+	return res, err
+}
+
+// run ejecuta el bucle de herramientas sobre un historial ya preparado
+func (o *Orchestrator) run(ctx context.Context, p domain.AIProvider, b domainBot.Bot, input domain.BotInput, req domain.ChatRequest, serverMap map[string]string, st *execState) (domain.BotOutput, error) {
+	traceID := input.TraceID
+	instanceID := input.InstanceID
+	chatJID := input.ChatID
+	originalUserText := input.Text
+
+	var finalResponse string
+	var lastAIText string
+
 	// Bucle de herramientas (máximo 10 iteraciones)
 	for i := 0; i < 10; i++ {
 		botmonitor.Record(botmonitor.Event{
@@ -116,13 +220,13 @@ func (o *Orchestrator) Execute(ctx context.Context, p domain.AIProvider, b domai
 			Metadata: map[string]string{
 				"trace_id":            traceID,
 				"iteration":           fmt.Sprintf("%d", i+1),
-				"system_instructions": redactIfNeeded(req.SystemPrompt),
-				"input":               redactIfNeeded(originalUserText),
+				"system_instructions": st.redact(req.SystemPrompt),
+				"input":               st.redact(originalUserText),
 			},
 		})
 
 		start := time.Now()
-		res, err := p.Chat(ctx, b, req)
+		res, err := o.chat(ctx, p, b, req)
 		duration := time.Since(start).Milliseconds()
 
 		if err == nil {
@@ -141,7 +245,7 @@ func (o *Orchestrator) Execute(ctx context.Context, p domain.AIProvider, b domai
 		// Identidad Original: Extraer multimodal_content para el log si existe
 		md := map[string]string{
 			"trace_id": traceID,
-			"response": redactIfNeeded(res.Text),
+			"response": st.redact(res.Text),
 		}
 		if idx := strings.Index(res.Text, "]: "); idx != -1 && idx < 20 {
 			md["multimodal_content"] = res.Text
@@ -149,7 +253,7 @@ func (o *Orchestrator) Execute(ctx context.Context, p domain.AIProvider, b domai
 
 		// Acumular costo de esta iteración y registrar en monitor si existe usage
 		if res.Usage != nil {
-			addCost(b.ID, res.Usage.Model, res.Usage.CostUSD)
+			st.addCost(b.ID, res.Usage.Model, res.Usage.CostUSD)
 			md["model"] = res.Usage.Model
 			md["usage_cost"] = fmt.Sprintf("$%.6f", res.Usage.CostUSD)
 			md["usage_input_tokens"] = fmt.Sprintf("%d", res.Usage.InputTokens)
@@ -193,174 +297,27 @@ func (o *Orchestrator) Execute(ctx context.Context, p domain.AIProvider, b domai
 		// PARIDAD ULTRA: Agrupar TODAS las respuestas de herramientas en un solo turno (Identidad Gemini)
 		var responses []domain.ToolResponse
 		shouldBreak := false
+		var held *domain.ToolCall
+		var heldPolicy domainBot.ToolApprovalPolicy
 		for _, tc := range res.ToolCalls {
-			var toolResult map[string]any
-
-			// 1. Intentar MCP
-			if serverID, ok := serverMap[tc.Name]; ok && o.mcpUsecase != nil {
-				startCall := time.Now()
-				mcpRes, mErr := o.mcpUsecase.CallTool(ctx, b.ID, domainMCP.CallToolRequest{
-					ServerID:  serverID,
-					ToolName:  tc.Name,
-					Arguments: tc.Args,
-				})
-				duration := time.Since(startCall).Milliseconds()
-				if mErr != nil {
-					toolResult = map[string]any{"error": mErr.Error()}
-				} else {
-					toolResult = map[string]any{"content": mcpRes.Content, "is_error": mcpRes.IsError}
+			// Herramientas sensibles: se retiene la primera y el resto de sensibles se difiere
+			if policy := o.approvalPolicy(b, tc.Name); policy != domainBot.ApprovalAuto {
+				if held == nil {
+					call := tc
+					held, heldPolicy = &call, policy
+					continue
 				}
-
-				botmonitor.Record(botmonitor.Event{
-					TraceID: traceID, InstanceID: instanceID, ChatJID: chatJID,
-					Provider: "mcp", Stage: "mcp_call", Kind: tc.Name, Status: "ok", DurationMs: duration,
-					Metadata: map[string]string{
-						"trace_id": traceID,
-						"request":  redactArgsIfNeeded(tc.Name, tc.Args, false),    // MCP: Not Native
-						"response": redactArgsIfNeeded(tc.Name, toolResult, false), // Redact response too
-					},
+				responses = append(responses, domain.ToolResponse{
+					ID:   tc.ID,
+					Name: tc.Name,
+					Data: map[string]any{"error": "another action is awaiting confirmation; request this one again once it is resolved"},
 				})
-			} else if o.nativeToolCaller != nil {
-				// 2. Try Native Tool
-				logrus.Infof("[GEMINI] Executing native tool: %s", tc.Name)
-
-				// Inject bot (Channel) time context for tools
-				toolInput := input
-				if toolInput.Metadata == nil {
-					toolInput.Metadata = make(map[string]interface{})
-				}
-				// Clone metadata to ensure thread safety and avoid side effects
-				toolMetadata := make(map[string]interface{})
-				for k, v := range toolInput.Metadata {
-					toolMetadata[k] = v
-				}
-
-				// Timezone resolution: Client (if registered) > Channel > UTC
-				tz := ""
-				if input.ClientContext != nil && input.ClientContext.IsRegistered && input.ClientContext.Timezone != "" {
-					tz = input.ClientContext.Timezone
-				} else if channelTZ, ok := toolMetadata["channel_timezone"].(string); ok && channelTZ != "" {
-					tz = channelTZ
-				}
-				if tz == "" {
-					tz = "UTC"
-				}
-				toolMetadata["bot_timezone"] = tz
-
-				// Inject client country for regional context (currency, time format, etc.)
-				if input.ClientContext != nil && input.ClientContext.Country != "" {
-					toolMetadata["client_country"] = input.ClientContext.Country
-				}
-
-				toolInput.Metadata = toolMetadata
-
-				startCall := time.Now()
-				nRes, nErr := o.nativeToolCaller(ctx, tc.Name, toolInput, tc.Args)
-				duration := time.Since(startCall).Milliseconds()
-
-				if nErr != nil {
-					logrus.Errorf("[GEMINI] Native tool %s error: %v", tc.Name, nErr)
-					toolResult = map[string]any{"error": nErr.Error()}
-				} else {
-					logrus.Infof("[GEMINI] Native tool %s success", tc.Name)
-
-					// SPECIAL LOGIC: If the native tool asks for dynamic multimodal analysis
-					if action, ok := nRes["action"].(string); ok && action == "trigger_multimodal_analysis" {
-						logrus.Infof("[GEMINI] Triggering dynamic multimodal analysis for %s", tc.Name)
-						path, _ := nRes["path"].(string)
-						mime, _ := nRes["mime_type"].(string)
-						fname, _ := nRes["filename"].(string)
-						intent, _ := nRes["intent"].(string)
-						isURL, _ := nRes["is_url"].(bool)
-
-						var err error
-						var media *domain.BotMedia
-
-						if o.mediaService != nil {
-							media, err = o.mediaService.ProcessMedia(ctx, isURL, path, mime, fname)
-						} else {
-							err = fmt.Errorf("media domain service not initialized")
-						}
-
-						if err != nil {
-							// Exact parity in the read error message
-							toolResult = map[string]any{"error": fmt.Sprintf("could not read file at %s: %v", path, err)}
-						} else if multimodal, ok := p.(domain.MultimodalInterpreter); ok && media != nil {
-							interp, usageInt, errInt := multimodal.Interpret(ctx, b.APIKey, b.Model, intent, input.Language, []*domain.BotMedia{media})
-
-							// Release RAM/Disk immediately after uploading/interpreting is done
-							// Don't use defer in this loop to avoid holding resources until the end of the chat turn
-							media.Cleanup()
-
-							if errInt == nil && usageInt != nil {
-								addCost(b.ID, usageInt.Model, usageInt.CostUSD)
-							}
-							if errInt != nil {
-								toolResult = map[string]any{"error": fmt.Sprintf("multimodal analysis error: %v", errInt)}
-							} else {
-								toolResult = map[string]any{
-									"analysis": interp,
-									"message":  "Analysis completed successfully",
-								}
-								if usageInt != nil {
-									toolResult["usage"] = map[string]any{
-										"cost":          usageInt.CostUSD,
-										"input_tokens":  usageInt.InputTokens,
-										"output_tokens": usageInt.OutputTokens,
-									}
-								}
-							}
-						} else {
-							if media != nil {
-								media.Cleanup()
-							}
-							toolResult = map[string]any{"error": "provider does not support multimodal vision/analysis"}
-						}
-					} else {
-						toolResult = nRes
-						// Check for termination action
-						if action, ok := nRes["action"].(string); ok && action == "terminate_session" {
-							finalAction = "terminate_session"
-							shouldBreak = true
-							if fw, ok := nRes["farewell_message"].(string); ok && fw != "" {
-								farewellMsg = fw
-							}
-						}
-					}
-				}
-
-				md := map[string]string{
-					"trace_id": traceID,
-					"request":  redactArgsIfNeeded(tc.Name, tc.Args, true), // Native: Is Native
-					"response": redactArgsIfNeeded(tc.Name, toolResult, true),
-				}
-				// Si hubo un análisis multimodal en esta tool, extraer su costo para el monitor
-				if usageRaw, ok := toolResult["usage"]; ok {
-					if usage, ok := usageRaw.(map[string]any); ok {
-						if m, ok := usage["model"].(string); ok {
-							md["model"] = m
-						}
-						if c, ok := usage["cost"].(float64); ok {
-							md["usage_cost"] = fmt.Sprintf("$%.6f", c)
-						}
-					}
-				}
-				status := "ok"
-				var errorMsg string
-				if errRaw, ok := toolResult["error"]; ok {
-					status = "error"
-					errorMsg = fmt.Sprintf("%v", errRaw)
-				}
+				continue
+			}
 
-				botmonitor.Record(botmonitor.Event{
-					TraceID: traceID, InstanceID: instanceID, ChatJID: chatJID,
-					Provider: "native", Stage: "native_call", Kind: tc.Name, Status: status, Error: errorMsg, DurationMs: duration,
-					Metadata: md,
-				})
-			} else {
-				// Warn exacto del original
-				logrus.Warnf("[GEMINI] Tool caller not found for: %s (NativeCallerIsNil: %v)", tc.Name, o.nativeToolCaller == nil)
-				toolResult = map[string]any{"error": "tool not found"}
+			toolResult, stop := o.executeToolCall(ctx, p, b, input, tc, serverMap, st)
+			if stop {
+				shouldBreak = true
 			}
 
 			// Añadir resultado a la lista de respuestas de este turno
@@ -371,6 +328,10 @@ func (o *Orchestrator) Execute(ctx context.Context, p domain.AIProvider, b domai
 			})
 		}
 
+		if held != nil {
+			return o.hold(b, input, req, responses, *held, heldPolicy, serverMap, st), nil
+		}
+
 		// Registrar un ÚNICO turno de usuario con TODAS las respuestas (Importante para Gemini)
 		if len(responses) > 0 {
 			req.History = append(req.History, domain.ChatTurn{
@@ -384,18 +345,266 @@ func (o *Orchestrator) Execute(ctx context.Context, p domain.AIProvider, b domai
 		}
 	}
 
-	if finalAction == "terminate_session" {
-		if farewellMsg != "" {
-			finalResponse = farewellMsg
-		} else if finalResponse == "" {
-			finalResponse = lastAIText
+	return st.output(finalResponse, lastAIText), nil
+}
+
+// approvalPolicy resuelve la política efectiva: override del bot > valor por defecto de la herramienta > auto.
+func (o *Orchestrator) approvalPolicy(b domainBot.Bot, toolName string) domainBot.ToolApprovalPolicy {
+	if o.approvals == nil {
+		return domainBot.ApprovalAuto
+	}
+	if policy := b.ApprovalPolicyFor(toolName); policy != "" {
+		return policy
+	}
+	if o.defaultApproval != nil {
+		if policy := o.defaultApproval(toolName); policy.IsValid() {
+			return policy
 		}
 	}
+	return domainBot.ApprovalAuto
+}
 
-	return domain.BotOutput{
-		Text:        finalResponse,
-		Action:      finalAction,
-		TotalCost:   totalCost,
-		CostDetails: costDetails,
-	}, nil
+// hold deja la llamada en la cola de aprobación y responde al usuario con la confirmación o el aviso de espera.
+func (o *Orchestrator) hold(b domainBot.Bot, input domain.BotInput, req domain.ChatRequest, responses []domain.ToolResponse, tc domain.ToolCall, policy domainBot.ToolApprovalPolicy, serverMap map[string]string, st *execState) domain.BotOutput {
+	pending := &domain.PendingApproval{
+		BotID:       b.ID,
+		WorkspaceID: input.WorkspaceID,
+		InstanceID:  input.InstanceID,
+		ChatID:      input.ChatID,
+		SenderID:    input.SenderID,
+		Policy:      policy,
+		ToolCall:    tc,
+		ServerID:    serverMap[tc.Name],
+		Summary:     describeToolCall(tc),
+		Input:       input,
+		Request:     req,
+		Responses:   responses,
+		ServerMap:   serverMap,
+	}
+	o.approvals.Hold(pending)
+
+	botmonitor.Record(botmonitor.Event{
+		TraceID: input.TraceID, InstanceID: input.InstanceID, ChatJID: input.ChatID,
+		Stage: "tool_approval", Kind: tc.Name, Status: string(domain.ApprovalPending),
+		Metadata: map[string]string{
+			"approval_id": pending.ID,
+			"policy":      string(policy),
+			"request":     st.redactArgs(tc.Name, tc.Args, serverMap[tc.Name] == ""),
+		},
+	})
+
+	out := st.output(approvalMessage(input.Language, pending), "")
+	out.Metadata = map[string]any{
+		"approval_id":     pending.ID,
+		"approval_policy": string(policy),
+	}
+	return out
+}
+
+// executeToolCall ejecuta una llamada (MCP o nativa). stop indica que la herramienta pidió cerrar la sesión.
+func (o *Orchestrator) executeToolCall(ctx context.Context, p domain.AIProvider, b domainBot.Bot, input domain.BotInput, tc domain.ToolCall, serverMap map[string]string, st *execState) (toolResult map[string]any, stop bool) {
+	traceID := input.TraceID
+	instanceID := input.InstanceID
+	chatJID := input.ChatID
+
+	// 0. Harness de evaluación: resultado grabado o, si no, observar el real
+	if hooks := domain.ToolHooksFrom(ctx); hooks != nil {
+		if hooks.Stub != nil {
+			if res, ok := hooks.Stub(tc); ok {
+				return res, false
+			}
+		}
+		if hooks.Observe != nil {
+			defer func() { hooks.Observe(tc, toolResult) }()
+		}
+	}
+
+	// 1. Intentar MCP
+	if serverID, ok := serverMap[tc.Name]; ok && o.mcpUsecase != nil {
+		startCall := time.Now()
+		mcpRes, mErr := o.mcpUsecase.CallTool(ctx, b.ID, domainMCP.CallToolRequest{
+			ServerID:  serverID,
+			ToolName:  tc.Name,
+			Arguments: tc.Args,
+		})
+		duration := time.Since(startCall).Milliseconds()
+		if mErr != nil {
+			toolResult = map[string]any{"error": mErr.Error()}
+		} else {
+			split := splitMCPContent(tc.Name, mcpRes.Content)
+			toolResult = map[string]any{"content": split.ModelParts, "is_error": mcpRes.IsError}
+			st.outMedias = append(st.outMedias, split.ForUser...)
+
+			// Imágenes, audio y recursos binarios se interpretan con el proveedor multimodal
+			if len(split.ForModel) > 0 {
+				if multimodal, ok := p.(domain.MultimodalInterpreter); ok {
+					intent := fmt.Sprintf("Describe the files returned by the tool %q so they can answer: %s", tc.Name, input.Text)
+					interp, usageInt, errInt := multimodal.Interpret(ctx, b.APIKey, b.Model, intent, input.Language, split.ForModel)
+					if errInt == nil && usageInt != nil {
+						st.addCost(b.ID, usageInt.Model, usageInt.CostUSD)
+					}
+					if errInt != nil {
+						toolResult["analysis_error"] = errInt.Error()
+					} else {
+						toolResult["analysis"] = interp
+					}
+				} else {
+					toolResult["analysis_error"] = "provider does not support multimodal vision/analysis"
+				}
+			}
+		}
+
+		botmonitor.Record(botmonitor.Event{
+			TraceID: traceID, InstanceID: instanceID, ChatJID: chatJID,
+			Provider: "mcp", Stage: "mcp_call", Kind: tc.Name, Status: "ok", DurationMs: duration,
+			Metadata: map[string]string{
+				"trace_id": traceID,
+				"request":  st.redactArgs(tc.Name, tc.Args, false),    // MCP: Not Native
+				"response": st.redactArgs(tc.Name, toolResult, false), // Redact response too
+			},
+		})
+	} else if o.nativeToolCaller != nil {
+		// 2. Try Native Tool
+		logrus.Infof("[GEMINI] Executing native tool: %s", tc.Name)
+
+		// Inject bot (Channel) time context for tools
+		toolInput := input
+		if toolInput.Metadata == nil {
+			toolInput.Metadata = make(map[string]interface{})
+		}
+		// Clone metadata to ensure thread safety and avoid side effects
+		toolMetadata := make(map[string]interface{})
+		for k, v := range toolInput.Metadata {
+			toolMetadata[k] = v
+		}
+
+		// Timezone resolution: Client (if registered) > Channel > UTC
+		tz := ""
+		if input.ClientContext != nil && input.ClientContext.IsRegistered && input.ClientContext.Timezone != "" {
+			tz = input.ClientContext.Timezone
+		} else if channelTZ, ok := toolMetadata["channel_timezone"].(string); ok && channelTZ != "" {
+			tz = channelTZ
+		}
+		if tz == "" {
+			tz = "UTC"
+		}
+		toolMetadata["bot_timezone"] = tz
+
+		// Inject client country for regional context (currency, time format, etc.)
+		if input.ClientContext != nil && input.ClientContext.Country != "" {
+			toolMetadata["client_country"] = input.ClientContext.Country
+		}
+
+		toolInput.Metadata = toolMetadata
+
+		startCall := time.Now()
+		nRes, nErr := o.nativeToolCaller(ctx, tc.Name, toolInput, tc.Args)
+		duration := time.Since(startCall).Milliseconds()
+
+		if nErr != nil {
+			logrus.Errorf("[GEMINI] Native tool %s error: %v", tc.Name, nErr)
+			toolResult = map[string]any{"error": nErr.Error()}
+		} else {
+			logrus.Infof("[GEMINI] Native tool %s success", tc.Name)
+
+			// SPECIAL LOGIC: If the native tool asks for dynamic multimodal analysis
+			if action, ok := nRes["action"].(string); ok && action == "trigger_multimodal_analysis" {
+				logrus.Infof("[GEMINI] Triggering dynamic multimodal analysis for %s", tc.Name)
+				path, _ := nRes["path"].(string)
+				mime, _ := nRes["mime_type"].(string)
+				fname, _ := nRes["filename"].(string)
+				intent, _ := nRes["intent"].(string)
+				isURL, _ := nRes["is_url"].(bool)
+
+				var err error
+				var media *domain.BotMedia
+
+				if o.mediaService != nil {
+					media, err = o.mediaService.ProcessMedia(ctx, isURL, path, mime, fname)
+				} else {
+					err = fmt.Errorf("media domain service not initialized")
+				}
+
+				if err != nil {
+					// Exact parity in the read error message
+					toolResult = map[string]any{"error": fmt.Sprintf("could not read file at %s: %v", path, err)}
+				} else if multimodal, ok := p.(domain.MultimodalInterpreter); ok && media != nil {
+					interp, usageInt, errInt := multimodal.Interpret(ctx, b.APIKey, b.Model, intent, input.Language, []*domain.BotMedia{media})
+
+					// Release RAM/Disk immediately after uploading/interpreting is done
+					// Don't use defer in this loop to avoid holding resources until the end of the chat turn
+					media.Cleanup()
+
+					if errInt == nil && usageInt != nil {
+						st.addCost(b.ID, usageInt.Model, usageInt.CostUSD)
+					}
+					if errInt != nil {
+						toolResult = map[string]any{"error": fmt.Sprintf("multimodal analysis error: %v", errInt)}
+					} else {
+						toolResult = map[string]any{
+							"analysis": interp,
+							"message":  "Analysis completed successfully",
+						}
+						if usageInt != nil {
+							toolResult["usage"] = map[string]any{
+								"cost":          usageInt.CostUSD,
+								"input_tokens":  usageInt.InputTokens,
+								"output_tokens": usageInt.OutputTokens,
+							}
+						}
+					}
+				} else {
+					if media != nil {
+						media.Cleanup()
+					}
+					toolResult = map[string]any{"error": "provider does not support multimodal vision/analysis"}
+				}
+			} else {
+				toolResult = nRes
+				// Check for termination action
+				if action, ok := nRes["action"].(string); ok && action == "terminate_session" {
+					st.finalAction = "terminate_session"
+					stop = true
+					if fw, ok := nRes["farewell_message"].(string); ok && fw != "" {
+						st.farewellMsg = fw
+					}
+				}
+			}
+		}
+
+		md := map[string]string{
+			"trace_id": traceID,
+			"request":  st.redactArgs(tc.Name, tc.Args, true), // Native: Is Native
+			"response": st.redactArgs(tc.Name, toolResult, true),
+		}
+		// Si hubo un análisis multimodal en esta tool, extraer su costo para el monitor
+		if usageRaw, ok := toolResult["usage"]; ok {
+			if usage, ok := usageRaw.(map[string]any); ok {
+				if m, ok := usage["model"].(string); ok {
+					md["model"] = m
+				}
+				if c, ok := usage["cost"].(float64); ok {
+					md["usage_cost"] = fmt.Sprintf("$%.6f", c)
+				}
+			}
+		}
+		status := "ok"
+		var errorMsg string
+		if errRaw, ok := toolResult["error"]; ok {
+			status = "error"
+			errorMsg = fmt.Sprintf("%v", errRaw)
+		}
+
+		botmonitor.Record(botmonitor.Event{
+			TraceID: traceID, InstanceID: instanceID, ChatJID: chatJID,
+			Provider: "native", Stage: "native_call", Kind: tc.Name, Status: status, Error: errorMsg, DurationMs: duration,
+			Metadata: md,
+		})
+	} else {
+		// Warn exacto del original
+		logrus.Warnf("[GEMINI] Tool caller not found for: %s (NativeCallerIsNil: %v)", tc.Name, o.nativeToolCaller == nil)
+		toolResult = map[string]any{"error": "tool not found"}
+	}
+
+	return toolResult, stop
 }
@@ -91,6 +91,9 @@ func (p *Prompter) BuildInstructionsSplit(b domainBot.Bot, input domain.BotInput
 	// 5. Situational Behavior (Rules)
 	stable.WriteString("### SITUATIONAL BEHAVIOR (MINDSET)\n")
 	stable.WriteString("You must ALWAYS start your response with a HIDDEN internal mindset tag: <mindset pace=\"fast|steady|deep\" focus=\"true|false\" work=\"true|false\" />\n\n")
+	if b.Voice != nil && b.Voice.Policy == domainBot.VoiceReplyAI {
+		stable.WriteString("VOICE REPLIES: You can answer with a voice note instead of text. Add voice=\"true\" to the mindset tag when a spoken reply fits better (emotional or casual moments, the user sent audio, short explanations). Keep voice replies short, without lists, links or formatting.\n\n")
+	}
 
 	// 6. Toolset Guidelines (MCP)
 	if mcpInstructions != "" {
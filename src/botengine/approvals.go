@@ -0,0 +1,118 @@
+package botengine
+
+import (
+	"context"
+	"time"
+
+	"github.com/AzielCF/az-wap/botengine/application"
+	"github.com/AzielCF/az-wap/botengine/domain"
+	"github.com/AzielCF/az-wap/botengine/domain/bot"
+	"github.com/sirupsen/logrus"
+)
+
+// approvalResumeTimeout limita la reanudación en segundo plano de una herramienta aprobada por un operador
+const approvalResumeTimeout = 3 * time.Minute
+
+// Approvals expone la cola de herramientas retenidas (para websocket y API)
+func (e *Engine) Approvals() *application.ApprovalQueue {
+	return e.approvals
+}
+
+// ListApprovals devuelve las aprobaciones, opcionalmente filtradas por estado
+func (e *Engine) ListApprovals(status domain.ApprovalStatus) []domain.PendingApproval {
+	return e.approvals.List(status)
+}
+
+// GetApproval devuelve una aprobación por ID
+func (e *Engine) GetApproval(id string) (domain.PendingApproval, bool) {
+	return e.approvals.Get(id)
+}
+
+// DecideApproval registra la decisión de un operador y reanuda la conversación en segundo plano.
+func (e *Engine) DecideApproval(ctx context.Context, id string, approved bool, decidedBy string) (domain.PendingApproval, error) {
+	pending, err := e.approvals.Decide(id, approved, decidedBy)
+	if err != nil {
+		return domain.PendingApproval{}, err
+	}
+
+	go e.resumeApproval(pending, approved)
+	return *pending, nil
+}
+
+// defaultApprovalPolicy es la política declarada por la herramienta nativa, si la tiene
+func (e *Engine) defaultApprovalPolicy(toolName string) bot.ToolApprovalPolicy {
+	if t, ok := e.nativeTools[toolName]; ok {
+		return t.Approval
+	}
+	return ""
+}
+
+// onApprovalExpired cierra el bucle de las aprobaciones de operador que caducan.
+// Las confirmaciones del usuario simplemente se descartan: el usuario ya siguió conversando.
+func (e *Engine) onApprovalExpired(a *domain.PendingApproval) {
+	if a.Policy != bot.ApprovalAskOperator {
+		return
+	}
+	go e.resumeApproval(a, false)
+}
+
+func (e *Engine) resumeApproval(pending *domain.PendingApproval, approved bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), approvalResumeTimeout)
+	defer cancel()
+
+	b, err := e.botUsecase.GetByID(ctx, pending.BotID)
+	if err != nil {
+		logrus.Errorf("[APPROVAL] Failed to load bot %s to resume approval %s: %v", pending.BotID, pending.ID, err)
+		return
+	}
+	providerName := string(b.Provider)
+	if providerName == "" {
+		providerName = "ai"
+	}
+	p, ok := e.providers[providerName]
+	if !ok {
+		logrus.Errorf("[APPROVAL] Provider %s not registered, cannot resume approval %s", providerName, pending.ID)
+		return
+	}
+
+	input := pending.Input
+	output, err := e.orchestrator.Resume(ctx, p, b, input, pending, approved, "")
+	if err != nil {
+		logrus.Errorf("[APPROVAL] Failed to resume approval %s: %v", pending.ID, err)
+		return
+	}
+	output.Text = e.cleanMindsetTags(output.Text)
+	e.deliver(ctx, b, input, output)
+}
+
+// deliver envía una respuesta fuera del ciclo de Process (sin simulación de presencia)
+func (e *Engine) deliver(ctx context.Context, b bot.Bot, input domain.BotInput, output domain.BotOutput) {
+	e.mu.RLock()
+	transport, hasTransport := e.transports[input.InstanceID]
+	e.mu.RUnlock()
+
+	if hasTransport {
+		for _, bubble := range e.humanizer.SplitIntoBubbles(output.Text, true) {
+			if err := transport.SendMessage(ctx, input.ChatID, bubble, ""); err != nil {
+				logrus.Warnf("[APPROVAL] Failed to send resumed reply to %s: %v", input.ChatID, err)
+				break
+			}
+		}
+		if mt, ok := transport.(domain.MediaTransport); ok {
+			for _, m := range output.Medias {
+				if err := mt.SendMedia(ctx, input.ChatID, m, ""); err != nil {
+					logrus.Warnf("[APPROVAL] Failed to forward tool media %s to %s: %v", m.FileName, input.ChatID, err)
+				}
+			}
+		}
+	} else {
+		logrus.Warnf("[APPROVAL] No transport for instance %s, resumed reply for %s only reaches hooks", input.InstanceID, input.ChatID)
+	}
+
+	for _, h := range e.onPostReply {
+		h(ctx, b, input, output)
+	}
+	for _, h := range e.onResumed {
+		h(ctx, b, input, output)
+	}
+}
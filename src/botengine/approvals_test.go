@@ -0,0 +1,63 @@
+package botengine
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/AzielCF/az-wap/botengine/domain"
+	"github.com/AzielCF/az-wap/botengine/domain/bot"
+	domainMCP "github.com/AzielCF/az-wap/botengine/domain/mcp"
+	coreconfig "github.com/AzielCF/az-wap/core/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// approvalTestBots añade lo que Process usa fuera de las evaluaciones
+type approvalTestBots struct {
+	evalTestBots
+}
+
+func (approvalTestBots) RecordVersionUsage(context.Context, bot.VersionUsage) error {
+	return nil
+}
+
+func TestDecideApproval_ResumedReplyReachesResumedHooks(t *testing.T) {
+	coreconfig.Global = &coreconfig.Config{}
+	ctx := context.Background()
+
+	e := NewEngine(approvalTestBots{}, evalTestMCP{}, nil)
+	e.RegisterProvider("scripted", &scriptedProvider{})
+	e.RegisterNativeTool(&domain.NativeTool{
+		Tool:     domainMCP.Tool{Name: "lookup_order"},
+		Approval: bot.ApprovalAskOperator,
+		Handler: func(context.Context, map[string]interface{}, map[string]interface{}) (map[string]interface{}, error) {
+			return map[string]interface{}{"day": "lunes"}, nil
+		},
+	})
+
+	var postReplies int
+	e.RegisterPostReplyHook(func(context.Context, bot.Bot, domain.BotInput, domain.BotOutput) { postReplies++ })
+	resumed := make(chan domain.BotOutput, 1)
+	e.RegisterResumedReplyHook(func(_ context.Context, _ bot.Bot, input domain.BotInput, output domain.BotOutput) {
+		assert.Equal(t, "chat-1", input.ChatID)
+		resumed <- output
+	})
+
+	_, err := e.Process(ctx, domain.BotInput{BotID: "bot-1", InstanceID: "ch-1", ChatID: "chat-1", SenderID: "chat-1", Text: "¿Dónde está mi pedido 123?"})
+	require.NoError(t, err)
+
+	pending := e.ListApprovals(domain.ApprovalPending)
+	require.Len(t, pending, 1)
+	_, err = e.DecideApproval(ctx, pending[0].ID, true, "operator")
+	require.NoError(t, err)
+
+	select {
+	case out := <-resumed:
+		assert.Equal(t, "Tu pedido llega el lunes", out.Text)
+	case <-time.After(5 * time.Second):
+		t.Fatal("resumed reply never reached the resumed-reply hooks")
+	}
+	// Process ran its post-reply hooks once; the resume runs them again for billing
+	assert.Equal(t, 2, postReplies)
+}
@@ -0,0 +1,43 @@
+package domain
+
+import (
+	"time"
+
+	domainBot "github.com/AzielCF/az-wap/botengine/domain/bot"
+)
+
+// ApprovalStatus es el estado de una llamada de herramienta retenida.
+type ApprovalStatus string
+
+const (
+	ApprovalPending  ApprovalStatus = "pending"
+	ApprovalApproved ApprovalStatus = "approved"
+	ApprovalRejected ApprovalStatus = "rejected"
+	ApprovalExpired  ApprovalStatus = "expired"
+)
+
+// PendingApproval es una llamada de herramienta que espera confirmación del usuario o de un operador.
+// Guarda el estado del bucle de herramientas para poder reanudarlo tras la decisión.
+type PendingApproval struct {
+	ID          string                       `json:"id"`
+	BotID       string                       `json:"bot_id"`
+	WorkspaceID string                       `json:"workspace_id,omitempty"`
+	InstanceID  string                       `json:"instance_id"`
+	ChatID      string                       `json:"chat_id"`
+	SenderID    string                       `json:"sender_id"`
+	Policy      domainBot.ToolApprovalPolicy `json:"policy"`
+	ToolCall    ToolCall                     `json:"tool_call"`
+	ServerID    string                       `json:"server_id,omitempty"` // Vacío para herramientas nativas
+	Summary     string                       `json:"summary"`
+	Status      ApprovalStatus               `json:"status"`
+	CreatedAt   time.Time                    `json:"created_at"`
+	ExpiresAt   time.Time                    `json:"expires_at"`
+	DecidedAt   *time.Time                   `json:"decided_at,omitempty"`
+	DecidedBy   string                       `json:"decided_by,omitempty"`
+
+	// Snapshot del bucle; no se expone por la API.
+	Input     BotInput          `json:"-"`
+	Request   ChatRequest       `json:"-"`
+	Responses []ToolResponse    `json:"-"` // Respuestas ya calculadas del mismo turno
+	ServerMap map[string]string `json:"-"`
+}
@@ -16,6 +16,20 @@ const (
 	ProviderClaude Provider = "claude"
 )
 
+// ToolApprovalPolicy define si una herramienta se ejecuta directamente o requiere confirmación.
+type ToolApprovalPolicy string
+
+const (
+	ApprovalAuto        ToolApprovalPolicy = "auto"
+	ApprovalAskUser     ToolApprovalPolicy = "ask_user"     // Se pide un sí/no en el mismo chat
+	ApprovalAskOperator ToolApprovalPolicy = "ask_operator" // Queda en la cola de aprobación del operador
+)
+
+// IsValid indica si la política es conocida.
+func (p ToolApprovalPolicy) IsValid() bool {
+	return p == ApprovalAuto || p == ApprovalAskUser || p == ApprovalAskOperator
+}
+
 type Bot struct {
 	ID          string   `json:"id"`
 	Name        string   `json:"name"`
@@ -43,6 +57,21 @@ type Bot struct {
 	ChatwootCredential ChatwootCredential    `json:"chatwoot_credential,omitempty"`
 	Whitelist          []string              `json:"whitelist,omitempty"`
 	Variants           map[string]BotVariant `json:"variants,omitempty"`
+
+	// ToolPolicies sobrescribe la política de aprobación por nombre de herramienta (nativa o MCP).
+	ToolPolicies map[string]ToolApprovalPolicy `json:"tool_policies,omitempty"`
+
+	// Voice habilita respuestas con notas de voz (TTS). Nil = solo texto.
+	Voice *VoiceConfig `json:"voice,omitempty"`
+
+	// Typing personaliza la escritura simulada. Nil = perfil según el ritmo (pace) de la IA.
+	Typing *TypingSettings `json:"typing,omitempty"`
+
+	// StreamReplies envía las burbujas a medida que el proveedor genera el texto (si lo soporta).
+	StreamReplies bool `json:"stream_replies"`
+
+	// Revision es el número de la revisión vigente (ver BotRevision). 0 = bot anterior al versionado
+	Revision int `json:"revision"`
 }
 
 type BotVariant struct {
@@ -53,11 +82,25 @@ type BotVariant struct {
 	AllowedTools []string `json:"allowed_tools,omitempty"`
 	AllowedMCPs  []string `json:"allowed_mcps,omitempty"`
 
+	// MCPPrompt reemplaza SystemPrompt por un prompt publicado por un servidor MCP.
+	// SystemPrompt queda como respaldo si el servidor no responde.
+	MCPPrompt *MCPPromptRef `json:"mcp_prompt,omitempty"`
+
 	AudioEnabled    *bool `json:"audio_enabled,omitempty"`
 	ImageEnabled    *bool `json:"image_enabled,omitempty"`
 	VideoEnabled    *bool `json:"video_enabled,omitempty"`
 	DocumentEnabled *bool `json:"document_enabled,omitempty"`
 	MemoryEnabled   *bool `json:"memory_enabled,omitempty"`
+
+	// Voice reemplaza la configuración de voz del bot para esta variante
+	Voice *VoiceConfig `json:"voice,omitempty"`
+}
+
+// MCPPromptRef identifica un prompt (prompts/get) y los argumentos con los que se renderiza.
+type MCPPromptRef struct {
+	ServerID  string            `json:"server_id"`
+	Name      string            `json:"name"`
+	Arguments map[string]string `json:"arguments,omitempty"`
 }
 
 type ChatwootCredential struct {
@@ -88,6 +131,14 @@ type CreateBotRequest struct {
 	ChatwootBotToken     string                `json:"chatwoot_bot_token"`
 	Whitelist            []string              `json:"whitelist"`
 	Variants             map[string]BotVariant `json:"variants"`
+
+	ToolPolicies map[string]ToolApprovalPolicy `json:"tool_policies"`
+	Voice        *VoiceConfig                  `json:"voice"`
+	Typing       *TypingSettings               `json:"typing"`
+
+	StreamReplies bool `json:"stream_replies"`
+
+	Author string `json:"author,omitempty"` // Autor de la revisión inicial
 }
 
 type UpdateBotRequest struct {
@@ -113,6 +164,16 @@ type UpdateBotRequest struct {
 	ChatwootBotToken     string                `json:"chatwoot_bot_token"`
 	Whitelist            []string              `json:"whitelist"`
 	Variants             map[string]BotVariant `json:"variants"`
+
+	ToolPolicies map[string]ToolApprovalPolicy `json:"tool_policies"`
+	Voice        *VoiceConfig                  `json:"voice"`
+	Typing       *TypingSettings               `json:"typing"`
+
+	StreamReplies bool `json:"stream_replies"`
+
+	// Datos de la revisión que genera el cambio
+	Author     string `json:"author,omitempty"`
+	ChangeNote string `json:"change_note,omitempty"`
 }
 
 type IBotUsecase interface {
@@ -122,10 +183,49 @@ type IBotUsecase interface {
 	Update(ctx context.Context, id string, req UpdateBotRequest) (Bot, error)
 	Delete(ctx context.Context, id string) error
 
+	// Historial de configuración
+	ListRevisions(ctx context.Context, id string) ([]BotRevision, error)
+	GetRevision(ctx context.Context, id string, number int) (BotRevision, error)
+	// GetAtRevision devuelve el bot con la configuración de una revisión (credenciales resueltas)
+	GetAtRevision(ctx context.Context, id string, number int) (Bot, error)
+	// Rollback restaura una revisión creando una revisión nueva
+	Rollback(ctx context.Context, id string, number int, author string) (Bot, error)
+
+	// Métricas por versión (revisión + variante) para comparar experimentos
+	RecordVersionUsage(ctx context.Context, usage VersionUsage) error
+	RecordVersionFeedback(ctx context.Context, feedback VersionFeedback) error
+	VersionStats(ctx context.Context, id string) ([]VersionStats, error)
+
 	SetHealthUsecase(h domainHealth.IHealthUsecase)
 	Shutdown()
 }
 
+// ApprovalPolicyFor devuelve la política configurada para una herramienta, o "" si no hay override.
+func (b Bot) ApprovalPolicyFor(toolName string) ToolApprovalPolicy {
+	if p, ok := b.ToolPolicies[toolName]; ok && p.IsValid() {
+		return p
+	}
+	return ""
+}
+
+// SanitizeToolPolicies descarta políticas desconocidas o nombres vacíos.
+func (b *Bot) SanitizeToolPolicies() {
+	cleaned := make(map[string]ToolApprovalPolicy)
+	for name, policy := range b.ToolPolicies {
+		name = strings.TrimSpace(name)
+		policy = ToolApprovalPolicy(strings.TrimSpace(string(policy)))
+		if name == "" || !policy.IsValid() {
+			continue
+		}
+		cleaned[name] = policy
+	}
+	if len(cleaned) == 0 {
+		b.ToolPolicies = nil
+	} else {
+		b.ToolPolicies = cleaned
+	}
+}
+
 func (b *Bot) SanitizeVariants() {
 	if b.Variants == nil {
 		return
@@ -160,6 +260,16 @@ func (b *Bot) SanitizeVariants() {
 		}
 		variant.AllowedMCPs = validMCPs
 
+		variant.Voice = variant.Voice.Sanitize()
+
+		if variant.MCPPrompt != nil {
+			variant.MCPPrompt.ServerID = strings.TrimSpace(variant.MCPPrompt.ServerID)
+			variant.MCPPrompt.Name = strings.TrimSpace(variant.MCPPrompt.Name)
+			if variant.MCPPrompt.ServerID == "" || variant.MCPPrompt.Name == "" {
+				variant.MCPPrompt = nil
+			}
+		}
+
 		cleaned[key] = variant
 	}
 
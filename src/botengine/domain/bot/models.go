@@ -159,6 +159,16 @@ var GeminiModelPrices = map[string]ModelPricing{
 		// No soporta Context Caching
 	},
 
+	// Text-to-Speech (la salida son tokens de audio)
+	"gemini-2.5-flash-preview-tts": {
+		InputPerMToken:  0.50,
+		OutputPerMToken: 10.00,
+	},
+	"gemini-2.5-pro-preview-tts": {
+		InputPerMToken:  1.00,
+		OutputPerMToken: 20.00,
+	},
+
 	// Legacy/Fallback (precios estimados similares a 2.0 flash)
 	"gemini-1.5-pro-latest": {
 		InputPerMToken:   1.25,
@@ -174,6 +184,18 @@ var GeminiModelPrices = map[string]ModelPricing{
 	},
 }
 
+const (
+	DefaultGeminiSpeechModel = "gemini-2.5-flash-preview-tts"
+	DefaultOpenAISpeechModel = "gpt-4o-mini-tts"
+)
+
+// OpenAISpeechPrices contiene el costo TTS de OpenAI en USD por 1M caracteres de entrada
+var OpenAISpeechPrices = map[string]float64{
+	"tts-1":           15.00,
+	"tts-1-hd":        30.00,
+	"gpt-4o-mini-tts": 12.00, // ~0.015 USD por minuto de audio
+}
+
 var ProviderModels = map[Provider][]ModelInfo{
 	ProviderGemini: GeminiModels,
 	ProviderOpenAI: OpenAIModels,
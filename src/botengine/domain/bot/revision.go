@@ -0,0 +1,242 @@
+package bot
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ErrRevisionConflict indica que otro cambio ya tomó ese número de revisión (bot_id + number es único)
+var ErrRevisionConflict = errors.New("bot revision already exists")
+
+// BotRevision es una versión inmutable de la configuración de un bot
+type BotRevision struct {
+	BotID        string        `json:"bot_id"`
+	Number       int           `json:"number"`
+	Author       string        `json:"author,omitempty"`
+	Note         string        `json:"note,omitempty"`
+	Snapshot     Bot           `json:"snapshot"` // Configuración completa, sin secretos
+	Changes      []FieldChange `json:"changes,omitempty"`
+	RestoredFrom int           `json:"restored_from,omitempty"` // Revisión restaurada (rollback)
+	CreatedAt    time.Time     `json:"created_at"`
+}
+
+// FieldChange es la diferencia de un campo entre dos revisiones
+type FieldChange struct {
+	Field  string          `json:"field"`
+	Before json.RawMessage `json:"before,omitempty"`
+	After  json.RawMessage `json:"after,omitempty"`
+}
+
+// VersionUsage es una respuesta atribuida a una versión del bot
+type VersionUsage struct {
+	BotID     string
+	Revision  int
+	VariantID string
+	CostUSD   float64
+	LatencyMs int64
+}
+
+// VersionFeedback es una valoración (positiva o negativa) de una respuesta de una versión del bot
+type VersionFeedback struct {
+	BotID     string `json:"bot_id"`
+	Revision  int    `json:"revision"`
+	VariantID string `json:"variant_id,omitempty"`
+	Positive  bool   `json:"positive"`
+}
+
+// VersionStats acumula respuestas, costo, latencia y feedback por revisión y variante
+type VersionStats struct {
+	BotID            string    `json:"bot_id"`
+	Revision         int       `json:"revision"`
+	VariantID        string    `json:"variant_id,omitempty"`
+	Replies          int64     `json:"replies"`
+	TotalCost        float64   `json:"total_cost"`
+	TotalLatencyMs   int64     `json:"total_latency_ms"`
+	AvgCost          float64   `json:"avg_cost"`
+	AvgLatencyMs     int64     `json:"avg_latency_ms"`
+	PositiveFeedback int64     `json:"positive_feedback"`
+	NegativeFeedback int64     `json:"negative_feedback"`
+	FeedbackScore    float64   `json:"feedback_score"` // Positivas / total valoradas (0 sin feedback)
+	LastReplyAt      time.Time `json:"last_reply_at"`
+}
+
+// IBotRevisionRepository persiste el historial de revisiones y las métricas por versión
+type IBotRevisionRepository interface {
+	Init(ctx context.Context) error
+	// CreateRevision devuelve ErrRevisionConflict si el número ya existe para el bot
+	CreateRevision(ctx context.Context, rev BotRevision) error
+	GetRevision(ctx context.Context, botID string, number int) (BotRevision, error)
+	ListRevisions(ctx context.Context, botID string, limit int) ([]BotRevision, error)
+	DeleteRevision(ctx context.Context, botID string, number int) error
+	DeleteRevisions(ctx context.Context, botID string) error
+	RecordVersionUsage(ctx context.Context, usage VersionUsage) error
+	RecordVersionFeedback(ctx context.Context, feedback VersionFeedback) error
+	ListVersionStats(ctx context.Context, botID string) ([]VersionStats, error)
+}
+
+// Snapshot devuelve la configuración versionable: sin secretos ni datos resueltos en tiempo de ejecución
+func (b Bot) Snapshot() Bot {
+	s := b
+	s.APIKey = ""
+	s.ChatwootBotToken = ""
+	s.ChatwootCredential = ChatwootCredential{}
+	s.Revision = 0
+	s.Voice = voiceWithKey(b.Voice, "")
+	if b.Variants != nil {
+		s.Variants = make(map[string]BotVariant, len(b.Variants))
+		for id, v := range b.Variants {
+			v.Voice = voiceWithKey(v.Voice, "")
+			s.Variants[id] = v
+		}
+	}
+	return s
+}
+
+// Restore aplica la configuración de una revisión conservando identidad y secretos del bot actual
+func (b Bot) Restore(snapshot Bot) Bot {
+	restored := snapshot
+	restored.ID = b.ID
+	restored.APIKey = b.APIKey
+	restored.ChatwootBotToken = b.ChatwootBotToken
+	restored.ChatwootCredential = b.ChatwootCredential
+	restored.Revision = b.Revision
+	// Las claves de voz también son las vigentes (las revisiones antiguas podían traerlas guardadas)
+	restored.Voice = voiceWithKey(snapshot.Voice, voiceKey(b.Voice))
+	if snapshot.Variants != nil {
+		restored.Variants = make(map[string]BotVariant, len(snapshot.Variants))
+		for id, v := range snapshot.Variants {
+			v.Voice = voiceWithKey(v.Voice, voiceKey(b.Variants[id].Voice))
+			restored.Variants[id] = v
+		}
+	}
+	return restored
+}
+
+func voiceKey(v *VoiceConfig) string {
+	if v == nil {
+		return ""
+	}
+	return v.APIKey
+}
+
+// voiceWithKey copia la configuración de voz con otra API key (no modifica la original)
+func voiceWithKey(v *VoiceConfig, apiKey string) *VoiceConfig {
+	if v == nil {
+		return nil
+	}
+	cp := *v
+	cp.APIKey = apiKey
+	return &cp
+}
+
+// DiffBots compara dos configuraciones campo a campo (nombres JSON)
+func DiffBots(before, after Bot) []FieldChange {
+	a, b := fieldsOf(before.Snapshot()), fieldsOf(after.Snapshot())
+	keys := map[string]bool{}
+	for k := range a {
+		keys[k] = true
+	}
+	for k := range b {
+		keys[k] = true
+	}
+	names := make([]string, 0, len(keys))
+	for k := range keys {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	var changes []FieldChange
+	for _, k := range names {
+		if !bytes.Equal(a[k], b[k]) {
+			changes = append(changes, FieldChange{Field: k, Before: a[k], After: b[k]})
+		}
+	}
+	return changes
+}
+
+func fieldsOf(b Bot) map[string]json.RawMessage {
+	data, _ := json.Marshal(b)
+	fields := map[string]json.RawMessage{}
+	_ = json.Unmarshal(data, &fields)
+	return fields
+}
+
+// TrafficSplit reparte las conversaciones de un canal entre dos versiones del bot (A/B).
+// La asignación es estable por chat: el mismo usuario siempre habla con la misma versión.
+type TrafficSplit struct {
+	Arms []SplitArm `json:"arms"`
+}
+
+// SplitArm es una de las versiones del experimento
+type SplitArm struct {
+	Name      string `json:"name"`                 // Etiqueta del brazo (ej. "a", "b")
+	Revision  int    `json:"revision,omitempty"`   // Revisión fija del bot. 0 = la actual
+	VariantID string `json:"variant_id,omitempty"` // Variante del bot. Vacío = bot base
+	Weight    int    `json:"weight"`               // Peso relativo
+}
+
+// Validate revisa que el reparto tenga dos brazos distintos con peso
+func (s *TrafficSplit) Validate() error {
+	if s == nil {
+		return nil
+	}
+	if len(s.Arms) != 2 {
+		return fmt.Errorf("traffic split requires exactly two arms")
+	}
+	total := 0
+	for i, arm := range s.Arms {
+		if strings.TrimSpace(arm.Name) == "" {
+			return fmt.Errorf("arm %d: name cannot be blank", i+1)
+		}
+		if arm.Weight < 0 || arm.Revision < 0 {
+			return fmt.Errorf("arm %s: weight and revision cannot be negative", arm.Name)
+		}
+		total += arm.Weight
+	}
+	if s.Arms[0].Name == s.Arms[1].Name {
+		return fmt.Errorf("arm names must be different")
+	}
+	if s.Arms[0].Revision == s.Arms[1].Revision && s.Arms[0].VariantID == s.Arms[1].VariantID {
+		return fmt.Errorf("both arms point to the same version")
+	}
+	if total == 0 {
+		return fmt.Errorf("at least one arm needs a positive weight")
+	}
+	return nil
+}
+
+// Choose asigna el brazo para una clave (ej. canal|chat) de forma determinista según los pesos
+func (s *TrafficSplit) Choose(key string) (SplitArm, bool) {
+	if s == nil {
+		return SplitArm{}, false
+	}
+	total := 0
+	for _, arm := range s.Arms {
+		if arm.Weight > 0 {
+			total += arm.Weight
+		}
+	}
+	if total == 0 {
+		return SplitArm{}, false
+	}
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(key))
+	point := int(h.Sum32() % uint32(total))
+	for _, arm := range s.Arms {
+		if arm.Weight <= 0 {
+			continue
+		}
+		if point < arm.Weight {
+			return arm, true
+		}
+		point -= arm.Weight
+	}
+	return SplitArm{}, false
+}
@@ -0,0 +1,139 @@
+package bot
+
+import (
+	"fmt"
+	"strings"
+)
+
+// TypingProfile defines the bot's typing style.
+type TypingProfile struct {
+	BaseCharDelayMs        int `json:"base_char_delay_ms"`
+	CharDelayVarianceMs    int `json:"char_delay_variance_ms"`
+	PunctuationPauseChance int `json:"punctuation_pause_chance"` // 0-100
+	PunctuationPauseMinMs  int `json:"punctuation_pause_min_ms"`
+	PunctuationPauseMaxMs  int `json:"punctuation_pause_max_ms"`
+	WordsPerBreak          int `json:"words_per_break"`
+	WordsBreakVariance     int `json:"words_break_variance"`
+	ThinkingPauseChance    int `json:"thinking_pause_chance"` // 0-100
+	ThinkingPauseMinMs     int `json:"thinking_pause_min_ms"`
+	ThinkingPauseMaxMs     int `json:"thinking_pause_max_ms"`
+}
+
+// DefaultTypingProfile simulates an average human typer.
+var DefaultTypingProfile = TypingProfile{
+	BaseCharDelayMs:        12,
+	CharDelayVarianceMs:    8,
+	PunctuationPauseChance: 40,
+	PunctuationPauseMinMs:  150,
+	PunctuationPauseMaxMs:  350,
+	WordsPerBreak:          20,
+	WordsBreakVariance:     12,
+	ThinkingPauseChance:    25,
+	ThinkingPauseMinMs:     200,
+	ThinkingPauseMaxMs:     500,
+}
+
+// FastTypingProfile simulates a fast typer (e.g., experienced support agent).
+var FastTypingProfile = TypingProfile{
+	BaseCharDelayMs:        6,
+	CharDelayVarianceMs:    4,
+	PunctuationPauseChance: 20,
+	PunctuationPauseMinMs:  80,
+	PunctuationPauseMaxMs:  180,
+	WordsPerBreak:          35,
+	WordsBreakVariance:     15,
+	ThinkingPauseChance:    10,
+	ThinkingPauseMinMs:     100,
+	ThinkingPauseMaxMs:     250,
+}
+
+// CasualTypingProfile simulates a relaxed typer, potentially on mobile.
+var CasualTypingProfile = TypingProfile{
+	BaseCharDelayMs:        18,
+	CharDelayVarianceMs:    12,
+	PunctuationPauseChance: 60,
+	PunctuationPauseMinMs:  250,
+	PunctuationPauseMaxMs:  600,
+	WordsPerBreak:          12,
+	WordsBreakVariance:     8,
+	ThinkingPauseChance:    40,
+	ThinkingPauseMinMs:     350,
+	ThinkingPauseMaxMs:     800,
+}
+
+// InstantTypingProfile for robots/official APIs (minimal latency)
+var InstantTypingProfile = TypingProfile{
+	BaseCharDelayMs:        1,
+	CharDelayVarianceMs:    1,
+	PunctuationPauseChance: 0,
+	ThinkingPauseChance:    0,
+}
+
+// TypingPresets son los perfiles seleccionables por nombre desde la configuración
+var TypingPresets = map[string]TypingProfile{
+	"default": DefaultTypingProfile,
+	"fast":    FastTypingProfile,
+	"casual":  CasualTypingProfile,
+	"instant": InstantTypingProfile,
+}
+
+// TypingSettings personaliza la escritura simulada de un bot o de un canal.
+// Profile (si viene) reemplaza al preset completo.
+type TypingSettings struct {
+	Preset        string         `json:"preset,omitempty"`
+	Profile       *TypingProfile `json:"profile,omitempty"`
+	IgnoreMindset bool           `json:"ignore_mindset,omitempty"` // true = el ritmo (pace) de la IA no acelera ni frena el perfil
+}
+
+// Resolve devuelve el perfil efectivo de la configuración.
+func (s *TypingSettings) Resolve() (TypingProfile, bool) {
+	if s == nil {
+		return TypingProfile{}, false
+	}
+	if s.Profile != nil {
+		return *s.Profile, true
+	}
+	p, ok := TypingPresets[s.Preset]
+	return p, ok
+}
+
+// Validate revisa que el preset exista y que los valores del perfil sean coherentes.
+func (s *TypingSettings) Validate() error {
+	if s == nil {
+		return nil
+	}
+	s.Preset = strings.ToLower(strings.TrimSpace(s.Preset))
+	if s.Profile == nil {
+		if _, ok := TypingPresets[s.Preset]; !ok {
+			return fmt.Errorf("unknown typing preset %q", s.Preset)
+		}
+		return nil
+	}
+
+	p := s.Profile
+	if p.BaseCharDelayMs < 0 || p.CharDelayVarianceMs < 0 || p.WordsPerBreak < 0 || p.WordsBreakVariance < 0 {
+		return fmt.Errorf("typing delays must not be negative")
+	}
+	if p.PunctuationPauseChance < 0 || p.PunctuationPauseChance > 100 || p.ThinkingPauseChance < 0 || p.ThinkingPauseChance > 100 {
+		return fmt.Errorf("typing pause chances must be between 0 and 100")
+	}
+	if p.PunctuationPauseMaxMs < p.PunctuationPauseMinMs || p.ThinkingPauseMaxMs < p.ThinkingPauseMinMs {
+		return fmt.Errorf("typing pause max must be >= min")
+	}
+	return nil
+}
+
+// Scaled devuelve una copia con todas las pausas multiplicadas por factor (ej: 0.6 más rápido).
+func (p TypingProfile) Scaled(factor float64) TypingProfile {
+	if factor <= 0 || factor == 1 {
+		return p
+	}
+	scale := func(v int) int { return int(float64(v) * factor) }
+	p.BaseCharDelayMs = scale(p.BaseCharDelayMs)
+	p.CharDelayVarianceMs = scale(p.CharDelayVarianceMs)
+	p.PunctuationPauseMinMs = scale(p.PunctuationPauseMinMs)
+	p.PunctuationPauseMaxMs = scale(p.PunctuationPauseMaxMs)
+	p.ThinkingPauseMinMs = scale(p.ThinkingPauseMinMs)
+	p.ThinkingPauseMaxMs = scale(p.ThinkingPauseMaxMs)
+	return p
+}
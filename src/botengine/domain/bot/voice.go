@@ -0,0 +1,89 @@
+package bot
+
+import "strings"
+
+// VoiceReplyPolicy decide cuándo el bot responde con una nota de voz en lugar de texto.
+type VoiceReplyPolicy string
+
+const (
+	VoiceReplyNever  VoiceReplyPolicy = "never"
+	VoiceReplyMirror VoiceReplyPolicy = "mirror" // Responde en voz cuando el usuario envió voz
+	VoiceReplyAI     VoiceReplyPolicy = "ai"     // La IA decide con reply_with_voice en su mindset
+	VoiceReplyAlways VoiceReplyPolicy = "always"
+)
+
+// DefaultVoiceMaxChars limita el largo de las respuestas habladas; las más largas se envían como texto.
+const DefaultVoiceMaxChars = 600
+
+// IsValid indica si la política es conocida.
+func (p VoiceReplyPolicy) IsValid() bool {
+	switch p {
+	case VoiceReplyNever, VoiceReplyMirror, VoiceReplyAI, VoiceReplyAlways:
+		return true
+	}
+	return false
+}
+
+// VoiceConfig configura las respuestas por voz (TTS) de un bot o de una de sus variantes.
+type VoiceConfig struct {
+	Policy   VoiceReplyPolicy `json:"policy"`
+	Provider string           `json:"provider,omitempty"` // Motor TTS (gemini, openai, local). Vacío = proveedor del bot
+	APIKey   string           `json:"api_key,omitempty"`  // Vacío = la API key del bot
+	Model    string           `json:"model,omitempty"`
+	Voice    string           `json:"voice,omitempty"`
+	Speed    float64          `json:"speed,omitempty"`     // 0.25 - 4.0. 0 = velocidad normal
+	Language string           `json:"language,omitempty"`  // Vacío = idioma de la conversación
+	MaxChars int              `json:"max_chars,omitempty"` // 0 = DefaultVoiceMaxChars
+}
+
+// Enabled indica si la configuración puede producir respuestas por voz.
+func (c *VoiceConfig) Enabled() bool {
+	return c != nil && c.Policy != "" && c.Policy != VoiceReplyNever
+}
+
+// Wants decide si una respuesta concreta se envía como nota de voz.
+func (c *VoiceConfig) Wants(userSentVoice, aiRequested bool, text string) bool {
+	if !c.Enabled() || strings.TrimSpace(text) == "" {
+		return false
+	}
+	maxChars := c.MaxChars
+	if maxChars <= 0 {
+		maxChars = DefaultVoiceMaxChars
+	}
+	if len([]rune(text)) > maxChars {
+		return false
+	}
+
+	switch c.Policy {
+	case VoiceReplyAlways:
+		return true
+	case VoiceReplyMirror:
+		return userSentVoice
+	case VoiceReplyAI:
+		return aiRequested
+	}
+	return false
+}
+
+// Sanitize normaliza la configuración y descarta políticas desconocidas.
+func (c *VoiceConfig) Sanitize() *VoiceConfig {
+	if c == nil {
+		return nil
+	}
+	c.Policy = VoiceReplyPolicy(strings.ToLower(strings.TrimSpace(string(c.Policy))))
+	if !c.Policy.IsValid() {
+		return nil
+	}
+	c.Provider = strings.ToLower(strings.TrimSpace(c.Provider))
+	c.APIKey = strings.TrimSpace(c.APIKey)
+	c.Model = strings.TrimSpace(c.Model)
+	c.Voice = strings.TrimSpace(c.Voice)
+	c.Language = strings.TrimSpace(c.Language)
+	if c.Speed < 0.25 || c.Speed > 4 {
+		c.Speed = 0
+	}
+	if c.MaxChars < 0 {
+		c.MaxChars = 0
+	}
+	return c
+}
@@ -0,0 +1,178 @@
+package eval
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+)
+
+const diffPreview = 160
+
+// Check evalúa una aserción determinista sobre la respuesta. Devuelve la diferencia legible si falla.
+// Las aserciones llm_judge las evalúa el runner con un modelo.
+func Check(a Assertion, reply string) (bool, string) {
+	switch a.Type {
+	case AssertContains:
+		if strings.Contains(strings.ToLower(reply), strings.ToLower(a.Value)) {
+			return true, ""
+		}
+		return false, fmt.Sprintf("contains: expected %q in reply %q", a.Value, preview(reply))
+	case AssertNotContains:
+		if !strings.Contains(strings.ToLower(reply), strings.ToLower(a.Value)) {
+			return true, ""
+		}
+		return false, fmt.Sprintf("not_contains: %q found in reply %q", a.Value, preview(reply))
+	case AssertRegex:
+		re, err := regexp.Compile(a.Value)
+		if err != nil {
+			return false, fmt.Sprintf("regex: invalid expression: %v", err)
+		}
+		if re.MatchString(reply) {
+			return true, ""
+		}
+		return false, fmt.Sprintf("regex: %s did not match reply %q", a.Value, preview(reply))
+	case AssertJSONSchema:
+		var v any
+		if err := json.Unmarshal([]byte(stripCodeFence(reply)), &v); err != nil {
+			return false, fmt.Sprintf("json_schema: reply is not valid JSON (%v): %q", err, preview(reply))
+		}
+		if errs := validateSchema(a.Schema, v, "$"); len(errs) > 0 {
+			return false, "json_schema: " + strings.Join(errs, "; ")
+		}
+		return true, ""
+	}
+	return false, fmt.Sprintf("%s: not a deterministic assertion", a.Type)
+}
+
+// CheckTools compara las herramientas llamadas con las esperadas y las prohibidas
+func CheckTools(t Turn, called []string) []string {
+	got := map[string]bool{}
+	for _, name := range called {
+		got[name] = true
+	}
+	var failures []string
+	var missing []string
+	for _, name := range t.ExpectTools {
+		if !got[name] {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		failures = append(failures, fmt.Sprintf("tools: expected %v, missing %v, got %v", t.ExpectTools, missing, called))
+	}
+	for _, name := range t.ForbidTools {
+		if got[name] {
+			failures = append(failures, fmt.Sprintf("tools: %s must not be called, got %v", name, called))
+		}
+	}
+	return failures
+}
+
+// validateSchema cubre el subconjunto de JSON Schema útil para respuestas de bots:
+// type, enum, required, properties, items, minItems y maxItems.
+func validateSchema(schema map[string]any, v any, path string) []string {
+	var errs []string
+	if t, ok := schema["type"].(string); ok && !matchesType(t, v) {
+		return []string{fmt.Sprintf("%s: expected %s, got %s", path, t, jsonType(v))}
+	}
+	if enum, ok := schema["enum"].([]any); ok {
+		found := false
+		for _, e := range enum {
+			if fmt.Sprint(e) == fmt.Sprint(v) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			errs = append(errs, fmt.Sprintf("%s: %v is not one of %v", path, v, enum))
+		}
+	}
+
+	switch val := v.(type) {
+	case map[string]any:
+		if req, ok := schema["required"].([]any); ok {
+			for _, r := range req {
+				if _, ok := val[fmt.Sprint(r)]; !ok {
+					errs = append(errs, fmt.Sprintf("%s: missing required property %q", path, r))
+				}
+			}
+		}
+		if props, ok := schema["properties"].(map[string]any); ok {
+			for name, sub := range props {
+				subSchema, ok := sub.(map[string]any)
+				if !ok {
+					continue
+				}
+				if pv, exists := val[name]; exists {
+					errs = append(errs, validateSchema(subSchema, pv, path+"."+name)...)
+				}
+			}
+		}
+	case []any:
+		if min, ok := schema["minItems"].(float64); ok && float64(len(val)) < min {
+			errs = append(errs, fmt.Sprintf("%s: expected at least %v items, got %d", path, min, len(val)))
+		}
+		if max, ok := schema["maxItems"].(float64); ok && float64(len(val)) > max {
+			errs = append(errs, fmt.Sprintf("%s: expected at most %v items, got %d", path, max, len(val)))
+		}
+		if items, ok := schema["items"].(map[string]any); ok {
+			for i, item := range val {
+				errs = append(errs, validateSchema(items, item, fmt.Sprintf("%s[%d]", path, i))...)
+			}
+		}
+	}
+	return errs
+}
+
+func matchesType(t string, v any) bool {
+	switch t {
+	case "integer":
+		n, ok := v.(float64)
+		return ok && n == math.Trunc(n)
+	case "number":
+		_, ok := v.(float64)
+		return ok
+	}
+	return jsonType(v) == t
+}
+
+func jsonType(v any) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+// stripCodeFence quita el bloque ```json ... ``` con el que los modelos suelen envolver el JSON
+func stripCodeFence(s string) string {
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, "```") {
+		return s
+	}
+	s = strings.TrimPrefix(s, "```")
+	if i := strings.Index(s, "\n"); i >= 0 {
+		s = s[i+1:]
+	}
+	return strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(s), "```"))
+}
+
+func preview(s string) string {
+	r := []rune(s)
+	if len(r) <= diffPreview {
+		return s
+	}
+	return string(r[:diffPreview]) + "…"
+}
@@ -0,0 +1,53 @@
+package eval
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheck_TextAssertions(t *testing.T) {
+	reply := "Tu pedido #123 llega el LUNES."
+
+	ok, _ := Check(Assertion{Type: AssertContains, Value: "lunes"}, reply)
+	assert.True(t, ok)
+	ok, diff := Check(Assertion{Type: AssertNotContains, Value: "pedido"}, reply)
+	assert.False(t, ok)
+	assert.Contains(t, diff, "not_contains")
+	ok, _ = Check(Assertion{Type: AssertRegex, Value: `#\d{3}`}, reply)
+	assert.True(t, ok)
+}
+
+func TestCheck_JSONSchema(t *testing.T) {
+	var schema map[string]any
+	_ = json.Unmarshal([]byte(`{
+		"type": "object",
+		"required": ["status", "items"],
+		"properties": {
+			"status": {"enum": ["ok", "pending"]},
+			"items": {"type": "array", "minItems": 1, "items": {"type": "integer"}}
+		}
+	}`), &schema)
+	a := Assertion{Type: AssertJSONSchema, Schema: schema}
+
+	ok, diff := Check(a, "```json\n{\"status\": \"ok\", \"items\": [1, 2]}\n```")
+	assert.True(t, ok, diff)
+
+	ok, diff = Check(a, `{"status": "done", "items": [1.5]}`)
+	assert.False(t, ok)
+	assert.Contains(t, diff, `$.status: done is not one of`)
+	assert.Contains(t, diff, `$.items[0]: expected integer`)
+
+	ok, _ = Check(a, "no es JSON")
+	assert.False(t, ok)
+}
+
+func TestCheckTools(t *testing.T) {
+	turn := Turn{ExpectTools: []string{"search", "book"}, ForbidTools: []string{"cancel"}}
+
+	assert.Empty(t, CheckTools(turn, []string{"book", "search"}))
+	failures := CheckTools(turn, []string{"search", "cancel"})
+	assert.Len(t, failures, 2)
+	assert.Contains(t, failures[0], "missing [book]")
+}
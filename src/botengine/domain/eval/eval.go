@@ -0,0 +1,209 @@
+package eval
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/AzielCF/az-wap/botengine/domain"
+)
+
+// RunMode decide contra qué se ejecuta una suite
+type RunMode string
+
+const (
+	ModeLive   RunMode = "live"   // Proveedor y herramientas reales
+	ModeRecord RunMode = "record" // Como live, y guarda las respuestas y resultados de herramientas en la suite
+	ModeReplay RunMode = "replay" // Reproduce la grabación: determinista y sin costo (CI)
+)
+
+// AssertionType es el tipo de comprobación sobre la respuesta de un turno
+type AssertionType string
+
+const (
+	AssertContains    AssertionType = "contains"     // La respuesta contiene Value (sin distinguir mayúsculas)
+	AssertNotContains AssertionType = "not_contains" // La respuesta no contiene Value
+	AssertRegex       AssertionType = "regex"        // La respuesta coincide con la expresión Value
+	AssertJSONSchema  AssertionType = "json_schema"  // La respuesta es JSON válido según el esquema Schema
+	AssertLLMJudge    AssertionType = "llm_judge"    // Un modelo evalúa la respuesta con la rúbrica Value
+)
+
+// Assertion es una comprobación sobre la respuesta del bot
+type Assertion struct {
+	Type   AssertionType  `json:"type"`
+	Value  string         `json:"value,omitempty"`
+	Schema map[string]any `json:"schema,omitempty"`
+}
+
+// Turn es un mensaje del usuario y lo que se espera del bot al responderlo
+type Turn struct {
+	User        string      `json:"user"`
+	ExpectTools []string    `json:"expect_tools,omitempty"` // Herramientas que deben llamarse (en cualquier orden)
+	ForbidTools []string    `json:"forbid_tools,omitempty"` // Herramientas que no deben llamarse
+	Assertions  []Assertion `json:"assertions,omitempty"`
+}
+
+// Case es una conversación guionizada de varios turnos
+type Case struct {
+	Name  string `json:"name"`
+	Turns []Turn `json:"turns"`
+}
+
+// Suite es un conjunto de casos que se ejecuta contra un bot (o una de sus variantes)
+type Suite struct {
+	ID          string    `json:"id"`
+	BotID       string    `json:"bot_id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description,omitempty"`
+	VariantID   string    `json:"variant_id,omitempty"` // Variante por defecto. Vacío = bot base
+	Cases       []Case    `json:"cases"`
+	Recording   Recording `json:"recording,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// Recording guarda, por caso, lo que devolvieron el proveedor y las herramientas en modo record
+type Recording map[string][]Exchange
+
+// Exchange es una llamada al proveedor grabada
+type Exchange struct {
+	Mindset     *domain.Mindset   `json:"mindset,omitempty"` // Llamada de intuición (PreAnalyzeMindset) en lugar de Chat
+	Text        string            `json:"text,omitempty"`
+	ToolCalls   []domain.ToolCall `json:"tool_calls,omitempty"`
+	ToolResults []ToolResult      `json:"tool_results,omitempty"` // Resultados de las herramientas pedidas en esta llamada
+}
+
+// ToolResult es el resultado grabado de una herramienta
+type ToolResult struct {
+	Name string         `json:"name"`
+	Data map[string]any `json:"data"`
+}
+
+// Run es el resultado de ejecutar una suite
+type Run struct {
+	ID         string       `json:"id"`
+	SuiteID    string       `json:"suite_id"`
+	BotID      string       `json:"bot_id"`
+	VariantID  string       `json:"variant_id,omitempty"`
+	Mode       RunMode      `json:"mode"`
+	Passed     int          `json:"passed"`
+	Failed     int          `json:"failed"`
+	TotalCost  float64      `json:"total_cost"`
+	DurationMs int64        `json:"duration_ms"`
+	Cases      []CaseResult `json:"cases"`
+	StartedAt  time.Time    `json:"started_at"`
+	FinishedAt time.Time    `json:"finished_at"`
+}
+
+// OK indica si todos los casos pasaron
+func (r Run) OK() bool {
+	return r.Failed == 0
+}
+
+// CaseResult es el resultado de un caso
+type CaseResult struct {
+	Name   string       `json:"name"`
+	Passed bool         `json:"passed"`
+	Error  string       `json:"error,omitempty"` // Error de ejecución (no de aserción)
+	Turns  []TurnResult `json:"turns"`
+}
+
+// TurnResult es el resultado de un turno: lo que respondió el bot y qué no se cumplió
+type TurnResult struct {
+	User      string   `json:"user"`
+	Reply     string   `json:"reply"`
+	Tools     []string `json:"tools,omitempty"`
+	Passed    bool     `json:"passed"`
+	Failures  []string `json:"failures,omitempty"` // Diferencias legibles esperado/obtenido
+	Skipped   []string `json:"skipped,omitempty"`  // Aserciones no evaluables en este modo
+	CostUSD   float64  `json:"cost_usd"`
+	LatencyMs int64    `json:"latency_ms"`
+}
+
+// RunOptions configura una ejecución
+type RunOptions struct {
+	Mode      RunMode `json:"mode"`
+	VariantID string  `json:"variant_id,omitempty"` // Vacío = la variante de la suite
+}
+
+// IEvalRepository persiste suites y ejecuciones
+type IEvalRepository interface {
+	CreateSuite(ctx context.Context, s Suite) error
+	UpdateSuite(ctx context.Context, s Suite) error
+	GetSuite(ctx context.Context, id string) (Suite, error)
+	ListSuites(ctx context.Context, botID string) ([]Suite, error)
+	DeleteSuite(ctx context.Context, id string) error
+	SaveRun(ctx context.Context, r Run) error
+	GetRun(ctx context.Context, id string) (Run, error)
+	ListRuns(ctx context.Context, suiteID string, limit int) ([]Run, error)
+}
+
+// Validate revisa que la suite se pueda ejecutar
+func (s Suite) Validate() error {
+	if strings.TrimSpace(s.Name) == "" {
+		return fmt.Errorf("name: cannot be blank")
+	}
+	if len(s.Cases) == 0 {
+		return fmt.Errorf("cases: at least one case is required")
+	}
+	seen := map[string]bool{}
+	for i, c := range s.Cases {
+		if strings.TrimSpace(c.Name) == "" {
+			return fmt.Errorf("case %d: name cannot be blank", i+1)
+		}
+		if seen[c.Name] {
+			return fmt.Errorf("case %q: duplicated name", c.Name)
+		}
+		seen[c.Name] = true
+		if len(c.Turns) == 0 {
+			return fmt.Errorf("case %q: at least one turn is required", c.Name)
+		}
+		for j, t := range c.Turns {
+			if strings.TrimSpace(t.User) == "" {
+				return fmt.Errorf("case %q turn %d: user message cannot be blank", c.Name, j+1)
+			}
+			for _, a := range t.Assertions {
+				if err := a.Validate(); err != nil {
+					return fmt.Errorf("case %q turn %d: %w", c.Name, j+1, err)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// Validate revisa el tipo y los parámetros de la aserción
+func (a Assertion) Validate() error {
+	switch a.Type {
+	case AssertContains, AssertNotContains, AssertLLMJudge:
+		if strings.TrimSpace(a.Value) == "" {
+			return fmt.Errorf("%s assertion requires a value", a.Type)
+		}
+	case AssertRegex:
+		if _, err := regexp.Compile(a.Value); err != nil {
+			return fmt.Errorf("regex assertion: %w", err)
+		}
+	case AssertJSONSchema:
+		if len(a.Schema) == 0 {
+			return fmt.Errorf("json_schema assertion requires a schema")
+		}
+	default:
+		return fmt.Errorf("unknown assertion type %q", a.Type)
+	}
+	return nil
+}
+
+// ParseMode normaliza el modo (vacío = live)
+func ParseMode(v string) (RunMode, error) {
+	switch RunMode(strings.ToLower(strings.TrimSpace(v))) {
+	case "", ModeLive:
+		return ModeLive, nil
+	case ModeRecord:
+		return ModeRecord, nil
+	case ModeReplay:
+		return ModeReplay, nil
+	}
+	return "", fmt.Errorf("unknown mode %q (use live, record or replay)", v)
+}
@@ -2,6 +2,7 @@ package mcp
 
 import (
 	"context"
+	"strings"
 
 	domainHealth "github.com/AzielCF/az-wap/core/common/health/domain"
 )
@@ -20,7 +21,7 @@ type MCPServer struct {
 	Description     string            `json:"description"`
 	Type            ConnectionType    `json:"type"`
 	URL             string            `json:"url,omitempty"`     // For SSE
-	Command         string            `json:"command,omitempty"` // For Stdio (disabled by default)
+	Command         string            `json:"command,omitempty"` // For Stdio (requires MCP_STDIO_ENABLED)
 	Args            []string          `json:"args,omitempty"`
 	Env             map[string]string `json:"env,omitempty"`
 	Headers         map[string]string `json:"headers,omitempty"`
@@ -33,6 +34,11 @@ type MCPServer struct {
 	Instructions    string            `json:"instructions,omitempty"`     // Global instructions for this MCP server
 	BotInstructions string            `json:"bot_instructions,omitempty"` // Bot-specific instructions for this MCP server
 	URLVariables    map[string]string `json:"url_variables,omitempty"`    // Per-bot URL variable values
+	Resources       []Resource        `json:"resources,omitempty"`        // Cached resources/list
+	Prompts         []Prompt          `json:"prompts,omitempty"`          // Cached prompts/list
+
+	AttachedResources []string `json:"attached_resources,omitempty"` // Per-bot resources injected as context
+	ReadableResources []string `json:"readable_resources,omitempty"` // Per-bot resources the AI may read on demand
 }
 
 type Tool struct {
@@ -41,6 +47,70 @@ type Tool struct {
 	InputSchema interface{} `json:"input_schema"`
 }
 
+// Resource es un recurso publicado por un servidor MCP (resources/list).
+type Resource struct {
+	URI         string `json:"uri"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	MimeType    string `json:"mime_type,omitempty"`
+}
+
+// Prompt es una plantilla publicada por un servidor MCP (prompts/list).
+type Prompt struct {
+	Name        string           `json:"name"`
+	Description string           `json:"description,omitempty"`
+	Arguments   []PromptArgument `json:"arguments,omitempty"`
+}
+
+type PromptArgument struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Required    bool   `json:"required,omitempty"`
+}
+
+// PromptMessage es un mensaje ya renderizado por prompts/get.
+type PromptMessage struct {
+	Role string `json:"role"`
+	Text string `json:"text"`
+}
+
+type PromptResult struct {
+	Description string          `json:"description,omitempty"`
+	Messages    []PromptMessage `json:"messages"`
+}
+
+// Text une los mensajes del prompt en un único bloque usable como system prompt.
+func (r PromptResult) Text() string {
+	parts := make([]string, 0, len(r.Messages))
+	for _, m := range r.Messages {
+		if t := strings.TrimSpace(m.Text); t != "" {
+			parts = append(parts, t)
+		}
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// ServerCapabilities agrupa lo que se cachea de un servidor además de sus tools.
+type ServerCapabilities struct {
+	Resources []Resource `json:"resources"`
+	Prompts   []Prompt   `json:"prompts"`
+}
+
+// CanRead indica si el bot tiene permitido leer el recurso (adjunto o de lectura bajo demanda).
+func (s MCPServer) CanRead(uri string) bool {
+	for _, u := range s.AttachedResources {
+		if u == uri {
+			return true
+		}
+	}
+	for _, u := range s.ReadableResources {
+		if u == uri {
+			return true
+		}
+	}
+	return false
+}
+
 type CallToolRequest struct {
 	ServerID  string                 `json:"server_id"`
 	ToolName  string                 `json:"tool_name"`
@@ -52,9 +122,40 @@ type CallToolResult struct {
 	IsError bool              `json:"is_error"`
 }
 
+// Tipos de contenido que puede devolver una herramienta MCP.
+const (
+	ContentText         = "text"
+	ContentImage        = "image"
+	ContentAudio        = "audio"
+	ContentResource     = "resource"
+	ContentResourceLink = "resource_link"
+)
+
+// CallToolContent refleja la unión completa de contenidos MCP.
+// Data lleva el binario en base64 (image, audio, resource blob); nunca se envía tal cual al modelo.
 type CallToolContent struct {
-	Type string `json:"type"`
-	Text string `json:"text"`
+	Type     string   `json:"type"`
+	Text     string   `json:"text,omitempty"`
+	Data     string   `json:"data,omitempty"`
+	MimeType string   `json:"mime_type,omitempty"`
+	URI      string   `json:"uri,omitempty"`
+	Name     string   `json:"name,omitempty"`
+	Audience []string `json:"audience,omitempty"` // "user" indica que el archivo debe reenviarse al chat
+}
+
+// IsBinary indica si el contenido trae un archivo embebido.
+func (c CallToolContent) IsBinary() bool {
+	return c.Data != ""
+}
+
+// ForUser indica si el servidor marcó el contenido para el usuario final.
+func (c CallToolContent) ForUser() bool {
+	for _, a := range c.Audience {
+		if a == "user" {
+			return true
+		}
+	}
+	return false
 }
 
 type BotMCPConfig struct {
@@ -65,6 +166,9 @@ type BotMCPConfig struct {
 	CustomHeaders map[string]string `json:"custom_headers"` // Bot-specific headers (auth, etc)
 	Instructions  string            `json:"instructions"`   // Bot-specific instructions for this MCP server
 	URLVariables  map[string]string `json:"url_variables"`  // Values for dynamic URL placeholders
+
+	AttachedResources []string `json:"attached_resources"` // Resource URIs always injected as context
+	ReadableResources []string `json:"readable_resources"` // Resource URIs the AI may read on demand
 }
 
 // BotMCPConfigJSON define el esquema exacto de config_json en la BD.
@@ -73,6 +177,9 @@ type BotMCPConfigJSON struct {
 	CustomHeaders map[string]string `json:"custom_headers"`
 	Instructions  string            `json:"instructions"`
 	URLVariables  map[string]string `json:"url_variables"`
+
+	AttachedResources []string `json:"attached_resources,omitempty"`
+	ReadableResources []string `json:"readable_resources,omitempty"`
 }
 
 type IMCPUsecase interface {
@@ -87,6 +194,12 @@ type IMCPUsecase interface {
 	ListTools(ctx context.Context, serverID string) ([]Tool, error)
 	CallTool(ctx context.Context, botID string, req CallToolRequest) (CallToolResult, error)
 
+	// Resources & Prompts
+	ListResources(ctx context.Context, serverID string) ([]Resource, error)
+	ListPrompts(ctx context.Context, serverID string) ([]Prompt, error)
+	ReadResource(ctx context.Context, botID, serverID, uri string) ([]CallToolContent, error)
+	GetPrompt(ctx context.Context, botID, serverID, name string, args map[string]string) (PromptResult, error)
+
 	// Bot specific
 	GetBotTools(ctx context.Context, botID string) ([]Tool, error)
 	ListServersForBot(ctx context.Context, botID string) ([]MCPServer, error)
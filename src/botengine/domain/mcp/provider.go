@@ -11,6 +11,18 @@ type IMCPProvider interface {
 	// CallTool ejecuta una herramienta en un servidor.
 	CallTool(ctx context.Context, server MCPServer, toolName string, args map[string]interface{}) (CallToolResult, error)
 
+	// ListResources solicita resources/list. Devuelve nil si el servidor no publica recursos.
+	ListResources(ctx context.Context, server MCPServer) ([]Resource, error)
+
+	// ReadResource lee un recurso (resources/read) como contenidos text/blob.
+	ReadResource(ctx context.Context, server MCPServer, uri string) ([]CallToolContent, error)
+
+	// ListPrompts solicita prompts/list. Devuelve nil si el servidor no publica prompts.
+	ListPrompts(ctx context.Context, server MCPServer) ([]Prompt, error)
+
+	// GetPrompt renderiza un prompt (prompts/get) con sus argumentos.
+	GetPrompt(ctx context.Context, server MCPServer, name string, args map[string]string) (PromptResult, error)
+
 	// Validate verifica la conectividad y disponibilidad de un servidor.
 	Validate(ctx context.Context, server MCPServer, fullHandshake bool) ([]Tool, error)
 
@@ -30,6 +30,9 @@ type IMCPRepository interface {
 	// UpdateServerTools actualiza el cache de tools de un servidor.
 	UpdateServerTools(ctx context.Context, serverID string, tools []Tool) error
 
+	// UpdateServerCapabilities actualiza el cache de resources y prompts de un servidor.
+	UpdateServerCapabilities(ctx context.Context, serverID string, caps ServerCapabilities) error
+
 	// === Bot MCP Configs ===
 
 	// GetBotServerIDs retorna los IDs de servidores habilitados para un bot.
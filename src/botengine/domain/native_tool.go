@@ -3,6 +3,7 @@ package domain
 import (
 	"context"
 
+	domainBot "github.com/AzielCF/az-wap/botengine/domain/bot"
 	domainMCP "github.com/AzielCF/az-wap/botengine/domain/mcp"
 )
 
@@ -14,4 +15,6 @@ type NativeTool struct {
 	domainMCP.Tool
 	Handler   func(ctx context.Context, context map[string]interface{}, args map[string]interface{}) (map[string]interface{}, error)
 	IsVisible ToolVisibilityCondition
+	// Approval es la política por defecto; el bot puede sobrescribirla en ToolPolicies.
+	Approval domainBot.ToolApprovalPolicy
 }
@@ -0,0 +1,53 @@
+package domain
+
+import (
+	"context"
+
+	domainBot "github.com/AzielCF/az-wap/botengine/domain/bot"
+)
+
+// StreamEventType identifica el tipo de evento emitido durante una generación en streaming
+type StreamEventType string
+
+const (
+	StreamEventText     StreamEventType = "text"      // Fragmento de texto (delta)
+	StreamEventToolCall StreamEventType = "tool_call" // La IA pidió una herramienta: el texto previo no es la respuesta final
+	StreamEventDone     StreamEventType = "done"      // Fin de la generación de esta iteración
+)
+
+// StreamEvent es un evento parcial de una respuesta en streaming
+type StreamEvent struct {
+	Type     StreamEventType
+	Text     string    // Delta de texto (StreamEventText)
+	ToolCall *ToolCall // Llamada completa (StreamEventToolCall)
+}
+
+// StreamHandler recibe los eventos en el orden en que llegan del proveedor
+type StreamHandler func(StreamEvent)
+
+// StreamingProvider es la capacidad opcional de un AIProvider de emitir la respuesta mientras se genera.
+// ChatStream devuelve la misma ChatResponse completa que Chat; los eventos son solo un adelanto.
+type StreamingProvider interface {
+	ChatStream(ctx context.Context, b domainBot.Bot, req ChatRequest, onEvent StreamHandler) (ChatResponse, error)
+}
+
+// StreamTransport es la capacidad opcional de un transporte de mostrar los tokens en vivo.
+// Hoy solo la implementa el simulador: el canal webchat aún no tiene transporte propio, así que su
+// streaming queda fuera de este cambio. Las burbujas completas siguen llegando por SendMessage.
+type StreamTransport interface {
+	// SendDelta envía un fragmento de texto aún sin cerrar; final=true descarta el borrador en curso
+	SendDelta(ctx context.Context, chatID string, delta string, final bool) error
+}
+
+type streamHandlerKey struct{}
+
+// WithStreamHandler adjunta el receptor de eventos de streaming al contexto de ejecución
+func WithStreamHandler(ctx context.Context, h StreamHandler) context.Context {
+	return context.WithValue(ctx, streamHandlerKey{}, h)
+}
+
+// StreamHandlerFrom devuelve el receptor adjunto al contexto (nil si la ejecución no es en streaming)
+func StreamHandlerFrom(ctx context.Context) StreamHandler {
+	h, _ := ctx.Value(streamHandlerKey{}).(StreamHandler)
+	return h
+}
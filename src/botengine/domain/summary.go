@@ -0,0 +1,114 @@
+package domain
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	domainBot "github.com/AzielCF/az-wap/botengine/domain/bot"
+)
+
+// SummaryTurnPrefix encabeza el turno fijo con el resumen de la conversación anterior
+const SummaryTurnPrefix = "[SUMMARY OF THE EARLIER CONVERSATION]"
+
+// minKeptTurns son los turnos recientes que nunca se resumen, aunque excedan el presupuesto
+const minKeptTurns = 4
+
+// HistorySummary es el resultado de condensar el inicio del historial
+type HistorySummary struct {
+	Text      string `json:"text"`      // Resumen acumulado (incluye el resumen anterior)
+	Condensed int    `json:"condensed"` // Turnos no fijos del inicio del historial que quedan reemplazados
+}
+
+// HistorySummarizer es la capacidad opcional de un AIProvider de resumir turnos antiguos con un modelo barato
+type HistorySummarizer interface {
+	SummarizeHistory(ctx context.Context, b domainBot.Bot, previous string, turns []ChatTurn, language string) (string, *UsageStats, error)
+}
+
+// SummaryTurn crea el turno fijo que abre el historial con el resumen
+func SummaryTurn(summary string) ChatTurn {
+	return ChatTurn{Role: "user", Text: SummaryTurnPrefix + "\n" + summary, Pinned: true}
+}
+
+// SummaryText devuelve el texto del resumen de un turno fijo
+func SummaryText(turn ChatTurn) string {
+	return strings.TrimSpace(strings.TrimPrefix(turn.Text, SummaryTurnPrefix))
+}
+
+// EstimateTurnTokens estima los tokens de un turno (~4 caracteres por token)
+func EstimateTurnTokens(t ChatTurn) int {
+	n := len(t.Text)
+	for _, tc := range t.ToolCalls {
+		js, _ := json.Marshal(tc.Args)
+		n += len(tc.Name) + len(js)
+	}
+	for _, tr := range t.ToolResponses {
+		js, _ := json.Marshal(tr.Data)
+		n += len(tr.Name) + len(js)
+	}
+	return n/4 + 2
+}
+
+// SummarySplit decide cuántos turnos del inicio (sin contar el fijo) hay que resumir para que el
+// historial quepa en el presupuesto. Los recientes se conservan hasta la mitad del presupuesto y
+// un par llamada/respuesta de herramienta nunca se separa. 0 = no hace falta resumir.
+func SummarySplit(turns []ChatTurn, budget int) int {
+	if budget <= 0 || len(turns) <= minKeptTurns {
+		return 0
+	}
+	total := 0
+	for _, t := range turns {
+		total += EstimateTurnTokens(t)
+	}
+	if total <= budget {
+		return 0
+	}
+
+	// Conservar desde el final hasta llenar la mitad del presupuesto
+	kept, k := 0, len(turns)
+	for k > 0 {
+		cost := EstimateTurnTokens(turns[k-1])
+		if len(turns)-k >= minKeptTurns && kept+cost > budget/2 {
+			break
+		}
+		kept += cost
+		k--
+	}
+
+	// Si el primer turno conservado es una respuesta de herramienta, su llamada va con él
+	for k > 0 && len(turns[k].ToolResponses) > 0 {
+		k--
+	}
+	return k
+}
+
+// SummaryPrompt arma la instrucción para condensar los turnos sobre el resumen previo
+func SummaryPrompt(previous string, turns []ChatTurn, language string) string {
+	var sb strings.Builder
+	sb.WriteString("Condense the following chat between a user and an assistant into a compact summary that the assistant will use as memory.\n")
+	sb.WriteString("Keep every concrete detail: names, numbers, dates, orders, addresses, decisions, commitments, open questions and the user's preferences. Drop greetings and small talk.\n")
+	sb.WriteString("Write plain text bullet points, at most 200 words, no preamble.")
+	if language != "" {
+		sb.WriteString(fmt.Sprintf(" Write it in %s.", language))
+	}
+	if previous != "" {
+		sb.WriteString("\n\nSUMMARY SO FAR (merge it, do not lose anything):\n")
+		sb.WriteString(previous)
+	}
+	sb.WriteString("\n\nCONVERSATION:\n")
+	for _, t := range turns {
+		if t.Text != "" {
+			sb.WriteString(fmt.Sprintf("%s: %s\n", t.Role, t.Text))
+		}
+		for _, tc := range t.ToolCalls {
+			js, _ := json.Marshal(tc.Args)
+			sb.WriteString(fmt.Sprintf("%s called tool %s(%s)\n", t.Role, tc.Name, js))
+		}
+		for _, tr := range t.ToolResponses {
+			js, _ := json.Marshal(tr.Data)
+			sb.WriteString(fmt.Sprintf("tool %s returned %s\n", tr.Name, js))
+		}
+	}
+	return sb.String()
+}
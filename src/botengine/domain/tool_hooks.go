@@ -0,0 +1,24 @@
+package domain
+
+import "context"
+
+// ToolHooks permite interceptar la ejecución de herramientas (harness de evaluación).
+// Stub devuelve un resultado sin ejecutar la herramienta (ok=false = ejecutarla de verdad);
+// Observe recibe el resultado de cada herramienta ejecutada.
+type ToolHooks struct {
+	Stub    func(tc ToolCall) (map[string]any, bool)
+	Observe func(tc ToolCall, result map[string]any)
+}
+
+type toolHooksKey struct{}
+
+// WithToolHooks adjunta los ganchos de herramientas al contexto de ejecución
+func WithToolHooks(ctx context.Context, h *ToolHooks) context.Context {
+	return context.WithValue(ctx, toolHooksKey{}, h)
+}
+
+// ToolHooksFrom devuelve los ganchos adjuntos al contexto (nil en ejecuciones normales)
+func ToolHooksFrom(ctx context.Context) *ToolHooks {
+	h, _ := ctx.Value(toolHooksKey{}).(*ToolHooks)
+	return h
+}
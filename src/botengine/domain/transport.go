@@ -18,3 +18,15 @@ type Transport interface {
 	// MarkRead marca uno o más mensajes como leídos
 	MarkRead(ctx context.Context, chatID string, messageIDs []string) error
 }
+
+// MediaTransport es opcional: los transportes que lo implementan pueden reenviar
+// al chat archivos producidos por herramientas (ej. imágenes devueltas por un MCP).
+type MediaTransport interface {
+	SendMedia(ctx context.Context, chatID string, media *BotMedia, caption string) error
+}
+
+// VoiceTransport es opcional: los transportes que lo implementan pueden enviar
+// notas de voz (PTT) generadas por TTS.
+type VoiceTransport interface {
+	SendVoice(ctx context.Context, chatID string, audio []byte, mimeType string) error
+}
@@ -0,0 +1,44 @@
+package domain
+
+import (
+	"context"
+	"time"
+	"unicode/utf8"
+)
+
+// SpeechRequest es una petición agnóstica de síntesis de voz (TTS)
+type SpeechRequest struct {
+	Text     string
+	Model    string
+	Voice    string
+	Language string  // Idioma de la respuesta (es, en-US...). Algunos motores lo detectan solos
+	Speed    float64 // 0 = velocidad normal
+}
+
+// SpeechAudio es el audio generado. El transporte lo convierte a nota de voz (OGG/Opus) si hace falta.
+type SpeechAudio struct {
+	Data     []byte
+	MimeType string
+	Duration time.Duration // 0 si el motor no la informa
+	Usage    *UsageStats
+}
+
+// SpeechSynthesizer es el puerto que implementan los proveedores de texto a voz.
+type SpeechSynthesizer interface {
+	Synthesize(ctx context.Context, apiKey string, req SpeechRequest) (*SpeechAudio, error)
+}
+
+// speechCharsPerSecond es el ritmo medio de lectura en voz alta
+const speechCharsPerSecond = 14.0
+
+// EstimateSpeechDuration aproxima cuánto dura leer el texto en voz alta.
+func EstimateSpeechDuration(text string, speed float64) time.Duration {
+	if speed <= 0 {
+		speed = 1
+	}
+	seconds := float64(utf8.RuneCountInString(text)) / (speechCharsPerSecond * speed)
+	if seconds < 1 {
+		seconds = 1
+	}
+	return time.Duration(seconds * float64(time.Second))
+}
@@ -1,6 +1,7 @@
 package domain
 
 import (
+	"fmt"
 	"time"
 )
 
@@ -24,6 +25,8 @@ type ChatTurn struct {
 	Text          string         `json:"text,omitempty"`
 	ToolCalls     []ToolCall     `json:"tool_calls,omitempty"`
 	ToolResponses []ToolResponse `json:"tool_responses,omitempty"`
+	// Pinned marca el turno de resumen de la conversación anterior: nunca se recorta
+	Pinned bool `json:"pinned,omitempty"`
 	// RawContent almacena el contenido original del proveedor (ej: *genai.Content)
 	// para ser re-inyectado en iteraciones subsecuentes del bucle de herramientas.
 	RawContent interface{} `json:"-"`
@@ -89,19 +92,27 @@ type BotInput struct {
 	Language      string         `json:"language,omitempty"` // Idioma resuelto para la respuesta (es, en, etc.)
 	IsTester      bool           `json:"is_tester"`
 
+	// HistoryTokenBudget activa el resumen del historial cuando lo supera (tokens estimados). 0 = sin resumen
+	HistoryTokenBudget int `json:"history_token_budget,omitempty"`
+
+	// Versión fijada por un experimento A/B del canal. 0 = revisión vigente del bot
+	BotRevision int    `json:"bot_revision,omitempty"`
+	SplitArm    string `json:"split_arm,omitempty"`
+
 	// Client Context - Información del cliente registrado (si existe)
 	ClientContext *ClientContext `json:"client_context,omitempty"`
 }
 
 // Mindset representa la mentalidad situacional de la IA para una respuesta
 type Mindset struct {
-	Pace            string `json:"pace"`            // fast, steady, deep
-	Focus           bool   `json:"focus"`           // true si debe entrar en modo enfoque
-	Work            bool   `json:"work"`            // true si realizó una tarea pesada
-	Acknowledgement string `json:"acknowledgement"` // Respuesta rápida inmediata (ej: "Un momento...")
-	ShouldRespond   bool   `json:"should_respond"`  // Determina si realmente vale la pena responder
-	EnqueueTask     string `json:"enqueue_task"`    // Descripción de una tarea para la cola de espera
-	ClearTasks      bool   `json:"clear_tasks"`     // True si las tareas pendientes han sido resueltas
+	Pace            string `json:"pace"`             // fast, steady, deep
+	Focus           bool   `json:"focus"`            // true si debe entrar en modo enfoque
+	Work            bool   `json:"work"`             // true si realizó una tarea pesada
+	Acknowledgement string `json:"acknowledgement"`  // Respuesta rápida inmediata (ej: "Un momento...")
+	ShouldRespond   bool   `json:"should_respond"`   // Determina si realmente vale la pena responder
+	EnqueueTask     string `json:"enqueue_task"`     // Descripción de una tarea para la cola de espera
+	ClearTasks      bool   `json:"clear_tasks"`      // True si las tareas pendientes han sido resueltas
+	ReplyWithVoice  bool   `json:"reply_with_voice"` // La IA prefiere responder con nota de voz
 }
 
 // ExecutionCost representa el costo de una parte de la ejecución (vía un modelo específico)
@@ -120,6 +131,32 @@ type BotOutput struct {
 	Mindset     *Mindset        `json:"mindset,omitempty"`
 	TotalCost   float64         `json:"total_cost,omitempty"`   // Costo acumulado de esta ejecución en USD
 	CostDetails []ExecutionCost `json:"cost_details,omitempty"` // Desglose por bot/modelo
+	Medias      []*BotMedia     `json:"medias,omitempty"`       // Archivos de herramientas para reenviar al usuario
+
+	// HistorySummary indica que el historial de entrada se condensó: la sesión debe aplicarlo a su memoria
+	HistorySummary *HistorySummary `json:"history_summary,omitempty"`
+
+	// Version identifica la configuración del bot que generó la respuesta
+	Version *BotVersion `json:"version,omitempty"`
+}
+
+// BotVersion es la revisión (y variante) del bot usada en una ejecución
+type BotVersion struct {
+	Revision  int    `json:"revision"`
+	VariantID string `json:"variant_id,omitempty"`
+	Arm       string `json:"arm,omitempty"` // Brazo del experimento A/B, si lo hubo
+}
+
+// Tags devuelve la versión como etiquetas para los eventos del monitor
+func (v BotVersion) Tags() map[string]string {
+	tags := map[string]string{"bot_revision": fmt.Sprintf("%d", v.Revision)}
+	if v.VariantID != "" {
+		tags["bot_variant"] = v.VariantID
+	}
+	if v.Arm != "" {
+		tags["split_arm"] = v.Arm
+	}
+	return tags
 }
 
 // PresenceConfig centraliza los tiempos y umbrales de la humanización situacional
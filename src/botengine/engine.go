@@ -14,6 +14,7 @@ import (
 	domainMCP "github.com/AzielCF/az-wap/botengine/domain/mcp"
 	"github.com/AzielCF/az-wap/botengine/infrastructure"
 	botmonitor "github.com/AzielCF/az-wap/botengine/infrastructure/monitoring"
+	coreconfig "github.com/AzielCF/az-wap/core/config"
 	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
 )
@@ -43,12 +44,20 @@ type Engine struct {
 	transports  map[string]domain.Transport
 	humanizer   *infrastructure.Humanizer
 	onPostReply []PostReplyHook
+	onResumed   []PostReplyHook // Respuestas de aprobaciones reanudadas, fuera del ciclo de Process
 	nativeTools map[string]*domain.NativeTool
 
 	// Nuevos servicios desacoplados
 	prompter     *application.Prompter
 	orchestrator *application.Orchestrator
 	mediaService *domain.MediaService
+	approvals    *application.ApprovalQueue
+
+	// Motores de texto a voz para responder con notas de voz
+	synthesizers map[string]domain.SpeechSynthesizer
+
+	// Chats (instancia|chat) cuyo último resumen de historial falló -> hora hasta la que no se reintenta
+	summaryBackoff sync.Map
 }
 
 func NewEngine(botService bot.IBotUsecase, mcpService domainMCP.IMCPUsecase, mediaService *domain.MediaService) *Engine {
@@ -60,6 +69,7 @@ func NewEngine(botService bot.IBotUsecase, mcpService domainMCP.IMCPUsecase, med
 		humanizer:    infrastructure.NewHumanizer(true),
 		nativeTools:  make(map[string]*domain.NativeTool),
 		mediaService: mediaService,
+		synthesizers: make(map[string]domain.SpeechSynthesizer),
 	}
 
 	// Default tools are now registered in cmd/root.go to avoid import cycles
@@ -68,6 +78,14 @@ func NewEngine(botService bot.IBotUsecase, mcpService domainMCP.IMCPUsecase, med
 	e.prompter = application.NewPrompter()
 	e.orchestrator = application.NewOrchestrator(mcpService, e.CallNativeTool, mediaService)
 
+	var approvalTimeout time.Duration
+	if coreconfig.Global != nil {
+		approvalTimeout = coreconfig.Global.AI.ApprovalTimeout
+	}
+	e.approvals = application.NewApprovalQueue(approvalTimeout)
+	e.approvals.OnExpire(e.onApprovalExpired)
+	e.orchestrator.SetApprovals(e.approvals, e.defaultApprovalPolicy)
+
 	return e
 }
 
@@ -96,11 +114,13 @@ func (e *Engine) CallNativeTool(ctx context.Context, name string, input domain.B
 	// Prepare generic context
 	ctxData := map[string]interface{}{
 		"metadata":       input.Metadata,
+		"bot_id":         input.BotID,
 		"text":           input.Text,
 		"sender_id":      input.SenderID,
 		"chat_id":        input.ChatID,
 		"instance_id":    input.InstanceID,
 		"workspace_id":   input.WorkspaceID,
+		"platform":       string(input.Platform),
 		"client_context": input.ClientContext,
 	}
 
@@ -111,10 +131,21 @@ func (e *Engine) RegisterPostReplyHook(h PostReplyHook) {
 	e.onPostReply = append(e.onPostReply, h)
 }
 
+// RegisterResumedReplyHook recibe las respuestas enviadas al reanudar una aprobación. Process no
+// interviene en ellas, así que quien guarda el historial del chat debe añadirlas aquí.
+func (e *Engine) RegisterResumedReplyHook(h PostReplyHook) {
+	e.onResumed = append(e.onResumed, h)
+}
+
 func (e *Engine) RegisterProvider(name string, p domain.AIProvider) {
 	e.providers[name] = p
 }
 
+// RegisterSynthesizer registra un motor TTS bajo el nombre usado en VoiceConfig.Provider
+func (e *Engine) RegisterSynthesizer(name string, s domain.SpeechSynthesizer) {
+	e.synthesizers[name] = s
+}
+
 func (e *Engine) RegisterTransport(t domain.Transport) {
 	e.mu.Lock()
 	defer e.mu.Unlock()
@@ -148,6 +179,8 @@ func (e *Engine) Process(ctx context.Context, input domain.BotInput) (out domain
 		}
 	}()
 
+	started := time.Now()
+
 	// 0. Ensure TraceID
 	if input.TraceID == "" {
 		input.TraceID = uuid.NewString()
@@ -212,13 +245,32 @@ func (e *Engine) Process(ctx context.Context, input domain.BotInput) (out domain
 		return domain.BotOutput{}, fmt.Errorf("failed to load bot %s: %w", input.BotID, err)
 	}
 
+	// 1.2 Revisión fijada (experimento A/B): se usa la configuración de esa revisión
+	if input.BotRevision > 0 && input.BotRevision != b.Revision {
+		if pinned, errRev := e.botUsecase.GetAtRevision(ctx, b.ID, input.BotRevision); errRev == nil {
+			b = pinned
+		} else {
+			logrus.Warnf("[ENGINE] Revision %d of bot %s unavailable, using current revision %d: %v", input.BotRevision, b.ID, b.Revision, errRev)
+		}
+	}
+	version := domain.BotVersion{Revision: b.Revision, Arm: input.SplitArm}
+
 	// 1.5 Apply Template Variant Override
 	if input.BotTemplateID != "" {
 		if variant, ok := b.Variants[input.BotTemplateID]; ok && variant.IsActive {
 			logrus.Infof("[ENGINE] Applying variant %s to bot %s", input.BotTemplateID, b.ID)
+			version.VariantID = input.BotTemplateID
 			if variant.SystemPrompt != "" {
 				b.SystemPrompt = variant.SystemPrompt
 			}
+			if variant.MCPPrompt != nil && e.mcpUsecase != nil {
+				res, err := e.mcpUsecase.GetPrompt(ctx, b.ID, variant.MCPPrompt.ServerID, variant.MCPPrompt.Name, variant.MCPPrompt.Arguments)
+				if text := res.Text(); err == nil && text != "" {
+					b.SystemPrompt = text
+				} else {
+					logrus.Warnf("[ENGINE] MCP prompt %s unavailable for variant %s, keeping fallback prompt: %v", variant.MCPPrompt.Name, input.BotTemplateID, err)
+				}
+			}
 			if variant.AudioEnabled != nil {
 				b.AudioEnabled = *variant.AudioEnabled
 			}
@@ -234,6 +286,9 @@ func (e *Engine) Process(ctx context.Context, input domain.BotInput) (out domain
 			if variant.MemoryEnabled != nil {
 				b.MemoryEnabled = *variant.MemoryEnabled
 			}
+			if variant.Voice != nil {
+				b.Voice = variant.Voice
+			}
 			// Tools and MCP filtering is done in orchestration later. 
 			// I am attaching the variant to the bot input metadata so tools loader can filter them if needed.
 			input.Metadata["allowed_tools"] = variant.AllowedTools
@@ -243,6 +298,10 @@ func (e *Engine) Process(ctx context.Context, input domain.BotInput) (out domain
 		}
 	}
 
+	// Todos los eventos de esta traza quedan etiquetados con la versión
+	botmonitor.TagTrace(input.TraceID, version.Tags())
+	defer botmonitor.UntagTrace(input.TraceID)
+
 	// 2. Whitelist logic
 	if len(b.Whitelist) > 0 {
 		allowed := false
@@ -267,6 +326,7 @@ func (e *Engine) Process(ctx context.Context, input domain.BotInput) (out domain
 
 	// 2.5 Media Intent Detection (Conserje de Recursos)
 	e.detectMediaIntents(b, &input)
+	userSentVoice := hasVoiceNote(input.Medias)
 
 	// NOTE: Resource tracking is now handled by Workspace.SessionEntry
 	// Files are tracked in SessionEntry.DownloadedFiles and cleaned up
@@ -283,6 +343,12 @@ func (e *Engine) Process(ctx context.Context, input domain.BotInput) (out domain
 		return domain.BotOutput{}, fmt.Errorf("provider %s not registered", providerName)
 	}
 
+	// 3.1 Harness de evaluación: el proveedor se graba o se sustituye por la grabación
+	harness := evalHarnessFrom(ctx)
+	if harness != nil {
+		p = harness.wrap(p)
+	}
+
 	// 4. Cargar Herramientas
 	var tools []domainMCP.Tool
 	if e.mcpUsecase != nil {
@@ -296,12 +362,9 @@ func (e *Engine) Process(ctx context.Context, input domain.BotInput) (out domain
 		return tools[i].Name < tools[j].Name
 	})
 
-	// 5. INTUITION PHASE: Pre-analyze mindset before presence
-	// Prepare history for intuition
-	var intuitionHistory []domain.ChatTurn
-	if b.MemoryEnabled && len(input.History) > 0 {
-		intuitionHistory = input.History
-	}
+	// 4.5 Confirmación pendiente: la respuesta del usuario decide la herramienta retenida.
+	// Aquí solo se consulta; se consume justo antes de reanudar, cuando el bot ya está resuelto.
+	awaitingApproval := e.approvals.AwaitsUserReply(input.InstanceID, input.ChatID, input.SenderID, input.Text)
 
 	var totalExecutionCost float64
 	var costDetails []domain.ExecutionCost
@@ -320,6 +383,16 @@ func (e *Engine) Process(ctx context.Context, input domain.BotInput) (out domain
 		costDetails = append(costDetails, domain.ExecutionCost{BotID: botID, Model: model, Cost: cost})
 	}
 
+	// 4.6 Memoria larga: resumir los turnos antiguos en lugar de perderlos
+	historySummary := e.compactHistory(ctx, p, b, &input, addExecutionCost)
+
+	// 5. INTUITION PHASE: Pre-analyze mindset before presence
+	// Prepare history for intuition
+	var intuitionHistory []domain.ChatTurn
+	if b.MemoryEnabled && len(input.History) > 0 {
+		intuitionHistory = input.History
+	}
+
 	mindset, usageInt, err := p.PreAnalyzeMindset(ctx, b, input, intuitionHistory)
 	if err == nil && usageInt != nil {
 		modelName := usageInt.Model
@@ -368,6 +441,13 @@ func (e *Engine) Process(ctx context.Context, input domain.BotInput) (out domain
 		})
 	}
 
+	// Una confirmación siempre merece respuesta (el bucle de herramientas sigue abierto),
+	// igual que una tarea programada: nadie escribió, el mensaje lo genera el bot.
+	proactive, _ := input.Metadata["proactive_task"].(bool)
+	if (awaitingApproval || proactive) && mindset != nil {
+		mindset.ShouldRespond = true
+	}
+
 	// inteligente Gateway: Si la IA decide que no es necesario responder
 	if mindset != nil && !mindset.ShouldRespond {
 		logrus.Infof("[ENGINE] AI decided NOT to respond to this message (ShouldRespond=false). Trace: %s", input.TraceID)
@@ -383,7 +463,7 @@ func (e *Engine) Process(ctx context.Context, input domain.BotInput) (out domain
 			Stage: "ai_response", Status: "skipped",
 			Metadata: map[string]string{"reason": "intuitive_gatekeeper"},
 		})
-		return domain.BotOutput{Mindset: mindset, Metadata: map[string]any{"skipped": true}}, nil
+		return domain.BotOutput{Mindset: mindset, Metadata: map[string]any{"skipped": true}, HistorySummary: historySummary}, nil
 	}
 
 	// 5.5 HUMAN PRESENCE SIMULATOR (Parallel to AI)
@@ -535,6 +615,8 @@ func (e *Engine) Process(ctx context.Context, input domain.BotInput) (out domain
 			}
 		}
 
+		mcpInstructions.WriteString(e.attachedResourcesContext(ctx, b.ID, srv))
+
 		for _, t := range srv.Tools {
 			serverMap[t.Name] = srv.ID
 		}
@@ -655,13 +737,112 @@ func (e *Engine) Process(ctx context.Context, input domain.BotInput) (out domain
 		ChatKey:        input.InstanceID + "|" + input.ChatID,
 	}
 
+	// sendBubble simula la escritura de una burbuja y la envía. false = la escritura se canceló
+	typing := typingSettingsFor(b, input)
+	configuredProfile, hasConfiguredProfile := typing.Resolve()
+	sendBubble := func(i int, bubble string, m *domain.Mindset) bool {
+		// 2. Select Typing Profile based on Mindset Pace
+		profile := infrastructure.DefaultProfile
+		if isHighSpeedPlatform {
+			profile = infrastructure.InstantProfile
+		} else if hasConfiguredProfile {
+			// Perfil configurado (canal o bot): el pace solo lo acelera o frena
+			profile = configuredProfile
+			if m != nil && !typing.IgnoreMindset {
+				switch m.Pace {
+				case "fast":
+					profile = profile.Scaled(0.6)
+				case "deep":
+					profile = profile.Scaled(1.4)
+				}
+			}
+		} else if m != nil {
+			switch m.Pace {
+			case "fast":
+				profile = infrastructure.FastTyperProfile
+			case "deep":
+				profile = infrastructure.CasualTyperProfile // More pauses for deep thoughts
+			}
+		}
+
+		// 3. Simulate Typing for this bubble (Skip for Telegram as it's an official bot)
+		if !isHighSpeedPlatform {
+			if ok := e.humanizer.SimulateTypingWithProfile(ctx, transport, input.ChatID, bubble, profile); !ok {
+				return false
+			}
+		}
+
+		// HUMAN ESSENCE: Decide if we should REPLY (quote) the message
+		quoteID := ""
+		if i == 0 {
+			chance := e.humanizer.BaseQuoteChance
+
+			// 1. If previous response was multi-bubble, we MUST quote to maintain context (100% chance)
+			if lastCount, ok := input.Metadata["last_bubble_count"].(int); ok && lastCount > 1 {
+				chance = e.humanizer.MultiBubbleQuoteChance
+			}
+
+			// 2. If message is delayed, high chance of quoting
+			if delayed, ok := input.Metadata["is_delayed"].(bool); ok && delayed {
+				if chance < e.humanizer.DelayedQuoteChance {
+					chance = e.humanizer.DelayedQuoteChance
+				}
+			}
+
+			if e.humanizer.Rng.Intn(100) < chance {
+				if id, ok := input.Metadata["message_id"].(string); ok {
+					quoteID = id
+				}
+			}
+		}
+
+		// 3. Send message
+		_ = transport.SendMessage(ctx, input.ChatID, bubble, quoteID)
+		return true
+	}
+
+	// C2. Streaming: las burbujas salen mientras la IA genera (y el simulador ve los tokens en vivo)
+	var streamer *replyStreamer
+	if hasTransport {
+		deliver := func(i int, bubble string) bool {
+			if i == 0 {
+				// Igual que en el envío normal: no escribir antes de terminar de "leer"
+				select {
+				case <-presencerStartedTyping:
+					presencerStartedTyping <- true // El post-proceso vuelve a esperarlo
+				case <-ctx.Done():
+					return false
+				}
+			} else if !e.bubbleGap(ctx, isHighSpeedPlatform) {
+				return false
+			}
+			return sendBubble(i, bubble, mindset)
+		}
+		streamer = e.newReplyStreamer(ctx, p, b, transport, input.ChatID, userSentVoice, isHighSpeedPlatform, deliver)
+	}
+	execCtx := ctx
+	if streamer != nil {
+		execCtx = domain.WithStreamHandler(ctx, streamer.handle)
+	}
+
 	// D. Ejecutar Orquestador (Ciclo de herramientas) con AUTO-RETRY
 	// Si la IA falla "en silencio" (sin texto), reintentamos una vez forzándola.
-	output, err := e.orchestrator.Execute(ctx, p, b, input, req, serverMap)
+	var output domain.BotOutput
+	var pendingApproval *domain.PendingApproval
+	var approvedByUser bool
+	if awaitingApproval {
+		pendingApproval, approvedByUser = e.approvals.TakeUserReply(input.InstanceID, input.ChatID, input.SenderID, input.Text)
+	}
+	if pendingApproval != nil {
+		// Reanudar el bucle retenido en lugar de abrir uno nuevo
+		output, err = e.orchestrator.Resume(execCtx, p, b, input, pendingApproval, approvedByUser, input.Text)
+	} else {
+		output, err = e.orchestrator.Execute(execCtx, p, b, input, req, serverMap)
+	}
 
 	// Check for "Silent Failure" -> No Text, No Error, but Mindset says Respond (or is nil/default)
 	// Note: We access the *output* text. The orchestrator sets output.Text.
-	isSilentFailure := err == nil && output.Text == "" && output.Action == "" && (mindset == nil || mindset.ShouldRespond)
+	isSilentFailure := pendingApproval == nil && err == nil && output.Text == "" && output.Action == "" && (mindset == nil || mindset.ShouldRespond)
 
 	if isSilentFailure {
 		logrus.Warnf("[ENGINE] AI returned EMPTY response (Silent Failure) for trace %s. Retrying execution with enforcement...", input.TraceID)
@@ -671,8 +852,9 @@ func (e *Engine) Process(ctx context.Context, input domain.BotInput) (out domain
 		retryReq.SystemPrompt += "\n\nSYSTEM CRITICAL: Your previous response was EMPTY. You MUST generate text or use a tool. Do NOT stay silent. Respond to the user NOW."
 
 		// Retry
-		output, err = e.orchestrator.Execute(ctx, p, b, input, retryReq, serverMap)
+		output, err = e.orchestrator.Execute(execCtx, p, b, input, retryReq, serverMap)
 	}
+	streamed := streamer.Finish()
 
 	if err != nil {
 		// Defensive copy for error metadata
@@ -689,6 +871,11 @@ func (e *Engine) Process(ctx context.Context, input domain.BotInput) (out domain
 
 		// CRITICAL FIX: If orchestrator fails (e.g. API error), DO NOT return empty.
 		// Reply to user informing about the error so they know what happened.
+		// A scheduled task has nobody waiting for the reply: let the scheduler record the failure
+		if proactive {
+			return domain.BotOutput{}, err
+		}
+
 		logrus.Errorf("[ENGINE] Orchestrator fatal error for trace %s: %v. Recovering with error message.", input.TraceID, err)
 		return domain.BotOutput{
 			Text:    "⚠️ Lo siento, ocurrió un error técnico al conectar con mi cerebro (API Error). Por favor intenta de nuevo en unos segundos.",
@@ -712,6 +899,16 @@ func (e *Engine) Process(ctx context.Context, input domain.BotInput) (out domain
 	}
 	// Re-calculate total just in case or trust the sum
 	output.TotalCost += totalExecutionCost
+	output.HistorySummary = historySummary
+	output.Version = &version
+
+	// Métricas por versión para comparar revisiones y variantes (las evaluaciones no cuentan)
+	if harness == nil {
+		usage := bot.VersionUsage{BotID: b.ID, Revision: version.Revision, VariantID: version.VariantID, CostUSD: output.TotalCost, LatencyMs: time.Since(started).Milliseconds()}
+		if errStats := e.botUsecase.RecordVersionUsage(ctx, usage); errStats != nil {
+			logrus.Debugf("[ENGINE] Failed to record version usage for bot %s: %v", b.ID, errStats)
+		}
+	}
 	output.Mindset = mindset     // Preservar mindset para el hook si es necesario
 	output.UserText = input.Text // RETURN ENRICHED TEXT (Transcriptions, etc.)
 
@@ -740,6 +937,7 @@ func (e *Engine) Process(ctx context.Context, input domain.BotInput) (out domain
 	} else if mindset != nil {
 		output.Mindset = mindset // Use intuition fallback
 	}
+	aiWantsVoice := newMindset != nil && newMindset.ReplyWithVoice
 	output.Text = e.cleanMindsetTags(output.Text)
 
 	// Final safety check: if after cleaning text is effectively empty but AI should respond
@@ -759,7 +957,7 @@ func (e *Engine) Process(ctx context.Context, input domain.BotInput) (out domain
 		}
 
 		// 8. Work Simulation (If IA says it was hard work)
-		if output.Mindset != nil && output.Mindset.Work && !isHighSpeedPlatform {
+		if output.Mindset != nil && output.Mindset.Work && !isHighSpeedPlatform && len(streamed) == 0 {
 			// Additional delay to simulate "processing/working"
 			workDelay := time.Duration(1500+e.humanizer.Rng.Intn(2500)) * time.Millisecond
 			select {
@@ -769,11 +967,34 @@ func (e *Engine) Process(ctx context.Context, input domain.BotInput) (out domain
 			}
 		}
 
-		bubbles := e.humanizer.SplitIntoBubbles(output.Text, isHighSpeedPlatform)
+		recordOutbound := func(first string, count int) {
+			botmonitor.Record(botmonitor.Event{
+				TraceID:    input.TraceID,
+				InstanceID: input.InstanceID, ChatJID: input.ChatID,
+				Provider: fmt.Sprintf("%s / %s", input.Platform, providerName),
+				Stage:    "outbound", Status: "ok",
+				Metadata: map[string]string{
+					"trace_id":             input.TraceID,
+					"model":                b.Model,
+					"output":               redactIfNeeded(first),
+					"bubbles":              fmt.Sprintf("%d", count),
+					"total_execution_cost": fmt.Sprintf("$%.6f", output.TotalCost),
+				},
+			})
+		}
+
+		// 8b. Nota de voz: si se envía, no hay burbujas de texto
+		// Con streaming progresivo las burbujas ya se enviaron durante la generación
+		var bubbles []string
+		if len(streamed) > 0 {
+			recordOutbound(streamed[0], len(streamed))
+		} else if !e.sendVoiceReply(ctx, transport, b, input, &output, userSentVoice, aiWantsVoice) {
+			bubbles = e.humanizer.SplitIntoBubbles(output.Text, isHighSpeedPlatform)
+		}
 		if output.Metadata == nil {
 			output.Metadata = make(map[string]any)
 		}
-		output.Metadata["bubbles"] = fmt.Sprintf("%d", len(bubbles))
+		output.Metadata["bubbles"] = fmt.Sprintf("%d", len(bubbles)+len(streamed))
 
 		// En Telegram, apagamos el indicador de "Thinking" antes de empezar la ráfaga
 		if isHighSpeedPlatform {
@@ -781,81 +1002,31 @@ func (e *Engine) Process(ctx context.Context, input domain.BotInput) (out domain
 		}
 
 		for i, bubble := range bubbles {
-			// 2. Select Typing Profile based on Mindset Pace
-			profile := infrastructure.DefaultProfile
-			if isHighSpeedPlatform {
-				profile = infrastructure.InstantProfile
-			} else if output.Mindset != nil {
-				switch output.Mindset.Pace {
-				case "fast":
-					profile = infrastructure.FastTyperProfile
-				case "deep":
-					profile = infrastructure.CasualTyperProfile // More pauses for deep thoughts
-				}
-			}
-
-			// 3. Simulate Typing for this bubble (Skip for Telegram as it's an official bot)
-			if !isHighSpeedPlatform {
-				if ok := e.humanizer.SimulateTypingWithProfile(ctx, transport, input.ChatID, bubble, profile); !ok {
-					break
-				}
-			}
-
-			// HUMAN ESSENCE: Decide if we should REPLY (quote) the message
-			quoteID := ""
-			if i == 0 {
-				chance := e.humanizer.BaseQuoteChance
-
-				// 1. If previous response was multi-bubble, we MUST quote to maintain context (100% chance)
-				if lastCount, ok := input.Metadata["last_bubble_count"].(int); ok && lastCount > 1 {
-					chance = e.humanizer.MultiBubbleQuoteChance
-				}
-
-				// 2. If message is delayed, high chance of quoting
-				if delayed, ok := input.Metadata["is_delayed"].(bool); ok && delayed {
-					if chance < e.humanizer.DelayedQuoteChance {
-						chance = e.humanizer.DelayedQuoteChance
-					}
-				}
-
-				if e.humanizer.Rng.Intn(100) < chance {
-					if id, ok := input.Metadata["message_id"].(string); ok {
-						quoteID = id
-					}
-				}
+			if !sendBubble(i, bubble, output.Mindset) {
+				break
 			}
 
-			// 3. Send message
-			_ = transport.SendMessage(ctx, input.ChatID, bubble, quoteID)
-
 			// 4. Record event
 			if i == 0 {
-				botmonitor.Record(botmonitor.Event{
-					TraceID:    input.TraceID,
-					InstanceID: input.InstanceID, ChatJID: input.ChatID,
-					Provider: fmt.Sprintf("%s / %s", input.Platform, providerName),
-					Stage:    "outbound", Status: "ok",
-					Metadata: map[string]string{
-						"trace_id":             input.TraceID,
-						"model":                b.Model,
-						"output":               redactIfNeeded(bubble),
-						"bubbles":              fmt.Sprintf("%d", len(bubbles)),
-						"total_execution_cost": fmt.Sprintf("$%.6f", output.TotalCost),
-					},
-				})
+				recordOutbound(bubble, len(bubbles))
 			}
 
 			// 5. Small gap between bubbles
-			if i < len(bubbles)-1 {
-				gap := time.Duration(500+e.humanizer.Rng.Intn(700)) * time.Millisecond
-				if isHighSpeedPlatform {
-					gap = 100 * time.Millisecond
-				}
-				select {
-				case <-time.After(gap):
-				case <-ctx.Done():
-					return output, nil
+			if i < len(bubbles)-1 && !e.bubbleGap(ctx, isHighSpeedPlatform) {
+				return output, nil
+			}
+		}
+
+		// 5b. Archivos devueltos por herramientas (ej. imagen generada por un MCP)
+		if len(output.Medias) > 0 {
+			if mt, ok := transport.(domain.MediaTransport); ok {
+				for _, m := range output.Medias {
+					if err := mt.SendMedia(ctx, input.ChatID, m, ""); err != nil {
+						logrus.Warnf("[ENGINE] Failed to forward tool media %s to %s: %v", m.FileName, input.ChatID, err)
+					}
 				}
+			} else {
+				logrus.Warnf("[ENGINE] Transport %s cannot send media, dropping %d tool file(s)", transport.ID(), len(output.Medias))
 			}
 		}
 
@@ -863,8 +1034,8 @@ func (e *Engine) Process(ctx context.Context, input domain.BotInput) (out domain
 		for _, h := range e.onPostReply {
 			h(ctx, b, input, output)
 		}
-	} else {
-		// No transport (REST API), hooks only.
+	} else if harness == nil {
+		// No transport (REST API), hooks only. Las evaluaciones no facturan ni notifican
 		for _, h := range e.onPostReply {
 			h(ctx, b, input, output)
 		}
@@ -873,6 +1044,48 @@ func (e *Engine) Process(ctx context.Context, input domain.BotInput) (out domain
 	return output, nil
 }
 
+// bubbleGap espera la pausa corta entre burbujas. false = el contexto se canceló
+func (e *Engine) bubbleGap(ctx context.Context, highSpeed bool) bool {
+	gap := time.Duration(500+e.humanizer.Rng.Intn(700)) * time.Millisecond
+	if highSpeed {
+		gap = 100 * time.Millisecond
+	}
+	select {
+	case <-time.After(gap):
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// attachedResourcesContext lee los recursos MCP adjuntos al bot y los devuelve como bloque de contexto.
+// Solo se inyecta texto; los recursos binarios se anuncian para que la IA los pida con read_mcp_resource.
+func (e *Engine) attachedResourcesContext(ctx context.Context, botID string, srv domainMCP.MCPServer) string {
+	if len(srv.AttachedResources) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	for _, uri := range srv.AttachedResources {
+		contents, err := e.mcpUsecase.ReadResource(ctx, botID, srv.ID, uri)
+		if err != nil {
+			logrus.Warnf("[ENGINE] Could not read attached MCP resource %s from %s: %v", uri, srv.Name, err)
+			continue
+		}
+		for _, c := range contents {
+			if c.IsBinary() {
+				sb.WriteString(fmt.Sprintf("\n\n### RESOURCE: %s (%s, binary; not inlined)", uri, c.MimeType))
+				continue
+			}
+			if strings.TrimSpace(c.Text) == "" {
+				continue
+			}
+			sb.WriteString(fmt.Sprintf("\n\n### RESOURCE: %s\n%s", uri, c.Text))
+		}
+	}
+	return sb.String()
+}
+
 func (e *Engine) parseMindset(text string) *domain.Mindset {
 	m := &domain.Mindset{Pace: "steady", Focus: false, Work: false}
 	if !strings.Contains(text, "<mindset") {
@@ -891,6 +1104,9 @@ func (e *Engine) parseMindset(text string) *domain.Mindset {
 	if strings.Contains(text, `work="true"`) {
 		m.Work = true
 	}
+	if strings.Contains(text, `voice="true"`) {
+		m.ReplyWithVoice = true
+	}
 	// Note: Strings like acknowledgement and enqueue_task are usually parsed
 	// from JSON in PreAnalyzeMindset, but here we clean tags for the full response.
 	// We'll add simple regex/strings detection for them if they appear in tags too.
@@ -911,6 +1127,14 @@ func (e *Engine) cleanMindsetTags(text string) string {
 	return strings.TrimSpace(text[:start] + text[start+end+2:])
 }
 
+// typingSettingsFor devuelve el perfil de escritura configurado: el del canal tiene prioridad sobre el del bot
+func typingSettingsFor(b bot.Bot, input domain.BotInput) *bot.TypingSettings {
+	if ts, ok := input.Metadata["typing_settings"].(*bot.TypingSettings); ok && ts != nil {
+		return ts
+	}
+	return b.Typing
+}
+
 // detectMediaIntents clasifica los medios en inmediatos o diferidos según la intención y los switches del bot
 func (e *Engine) detectMediaIntents(b bot.Bot, input *domain.BotInput) {
 	if len(input.Medias) == 0 {
@@ -0,0 +1,358 @@
+package botengine
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/AzielCF/az-wap/botengine/domain"
+	"github.com/AzielCF/az-wap/botengine/domain/bot"
+	"github.com/AzielCF/az-wap/botengine/domain/eval"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// evalHarness sustituye el proveedor de una ejecución de Process por el de la evaluación
+type evalHarness struct {
+	wrap func(domain.AIProvider) domain.AIProvider
+}
+
+type evalHarnessKey struct{}
+
+func withEvalHarness(ctx context.Context, h *evalHarness) context.Context {
+	return context.WithValue(ctx, evalHarnessKey{}, h)
+}
+
+// evalHarnessFrom devuelve el harness adjunto (nil fuera de una evaluación)
+func evalHarnessFrom(ctx context.Context) *evalHarness {
+	h, _ := ctx.Value(evalHarnessKey{}).(*evalHarness)
+	return h
+}
+
+// RunEvalSuite ejecuta los casos de la suite contra el bot (o la variante) a través del motor completo.
+// En modo record devuelve además la grabación nueva, que el llamador guarda en la suite.
+func (e *Engine) RunEvalSuite(ctx context.Context, suite eval.Suite, opts eval.RunOptions) (eval.Run, eval.Recording, error) {
+	mode := opts.Mode
+	if mode == "" {
+		mode = eval.ModeLive
+	}
+	if err := suite.Validate(); err != nil {
+		return eval.Run{}, nil, err
+	}
+	variantID := opts.VariantID
+	if variantID == "" {
+		variantID = suite.VariantID
+	}
+
+	b, err := e.botUsecase.GetByID(ctx, suite.BotID)
+	if err != nil {
+		return eval.Run{}, nil, fmt.Errorf("failed to load bot %s: %w", suite.BotID, err)
+	}
+
+	run := eval.Run{
+		ID:        uuid.NewString(),
+		SuiteID:   suite.ID,
+		BotID:     suite.BotID,
+		VariantID: variantID,
+		Mode:      mode,
+		StartedAt: time.Now(),
+	}
+	var recording eval.Recording
+	if mode == eval.ModeRecord {
+		recording = eval.Recording{}
+	}
+
+	logrus.Infof("[EVAL] Running suite %s (%d cases, mode %s) against bot %s", suite.Name, len(suite.Cases), mode, suite.BotID)
+	for i, c := range suite.Cases {
+		res, tape := e.runEvalCase(ctx, b, suite, c, fmt.Sprintf("eval_%s_%d", run.ID[:8], i+1), variantID, mode)
+		if recording != nil && res.Error == "" {
+			recording[c.Name] = tape
+		}
+		for _, t := range res.Turns {
+			run.TotalCost += t.CostUSD
+		}
+		if res.Passed {
+			run.Passed++
+		} else {
+			run.Failed++
+		}
+		run.Cases = append(run.Cases, res)
+	}
+	run.FinishedAt = time.Now()
+	run.DurationMs = run.FinishedAt.Sub(run.StartedAt).Milliseconds()
+	logrus.Infof("[EVAL] Suite %s finished: %d passed, %d failed ($%.6f)", suite.Name, run.Passed, run.Failed, run.TotalCost)
+	return run, recording, nil
+}
+
+func (e *Engine) runEvalCase(ctx context.Context, b bot.Bot, suite eval.Suite, c eval.Case, chatID, variantID string, mode eval.RunMode) (eval.CaseResult, []eval.Exchange) {
+	result := eval.CaseResult{Name: c.Name, Passed: true}
+
+	rec := &evalProvider{mode: mode, tape: suite.Recording[c.Name]}
+	if mode == eval.ModeReplay && len(rec.tape) == 0 {
+		result.Passed = false
+		result.Error = "no recording for this case: run the suite in record mode first"
+		return result, nil
+	}
+	cctx := withEvalHarness(ctx, &evalHarness{wrap: rec.bind})
+	cctx = domain.WithToolHooks(cctx, rec.toolHooks())
+
+	var history []domain.ChatTurn
+	for _, t := range c.Turns {
+		rec.beginTurn()
+		start := time.Now()
+		out, err := e.Process(cctx, domain.BotInput{
+			BotID:         suite.BotID,
+			BotTemplateID: variantID,
+			SenderID:      chatID,
+			ChatID:        chatID,
+			Platform:      domain.PlatformTest,
+			Text:          t.User,
+			History:       history,
+			InstanceID:    "eval_" + suite.ID,
+			Metadata:      map[string]any{"eval": true},
+			IsTester:      true,
+		})
+		if tapeErr := rec.turnTapeErr(); err == nil && tapeErr != nil {
+			err = tapeErr
+		}
+		tr := eval.TurnResult{
+			User:      t.User,
+			Reply:     out.Text,
+			Tools:     rec.turnTools(),
+			CostUSD:   out.TotalCost,
+			LatencyMs: time.Since(start).Milliseconds(),
+		}
+		if err != nil {
+			tr.Failures = append(tr.Failures, "engine: "+err.Error())
+			result.Turns = append(result.Turns, tr)
+			result.Passed = false
+			result.Error = err.Error()
+			break
+		}
+
+		tr.Failures = append(tr.Failures, eval.CheckTools(t, tr.Tools)...)
+		for _, a := range t.Assertions {
+			if a.Type != eval.AssertLLMJudge {
+				if ok, diff := eval.Check(a, out.Text); !ok {
+					tr.Failures = append(tr.Failures, diff)
+				}
+				continue
+			}
+			// El juez es un modelo: en replay no hay forma determinista de evaluarlo
+			if mode == eval.ModeReplay {
+				tr.Skipped = append(tr.Skipped, "llm_judge: "+a.Value)
+				continue
+			}
+			ok, reason, cost, jErr := e.judgeReply(ctx, b, a.Value, history, t.User, out.Text)
+			tr.CostUSD += cost
+			if jErr != nil {
+				tr.Failures = append(tr.Failures, "llm_judge: "+jErr.Error())
+			} else if !ok {
+				tr.Failures = append(tr.Failures, fmt.Sprintf("llm_judge: %q failed: %s", a.Value, reason))
+			}
+		}
+		tr.Passed = len(tr.Failures) == 0
+		if !tr.Passed {
+			result.Passed = false
+		}
+		result.Turns = append(result.Turns, tr)
+
+		history = append(history,
+			domain.ChatTurn{Role: "user", Text: t.User},
+			domain.ChatTurn{Role: "assistant", Text: out.Text},
+		)
+	}
+	return result, rec.recorded
+}
+
+// judgeReply pide al proveedor del bot que evalúe la respuesta con la rúbrica (LLM-as-judge)
+func (e *Engine) judgeReply(ctx context.Context, b bot.Bot, rubric string, history []domain.ChatTurn, userText, reply string) (bool, string, float64, error) {
+	providerName := string(b.Provider)
+	if providerName == "" {
+		providerName = "ai"
+	}
+	p, ok := e.providers[providerName]
+	if !ok {
+		return false, "", 0, fmt.Errorf("provider %s not registered", providerName)
+	}
+
+	var sb strings.Builder
+	sb.WriteString("RUBRIC:\n" + rubric + "\n\nCONVERSATION:\n")
+	for _, t := range history {
+		sb.WriteString(fmt.Sprintf("%s: %s\n", t.Role, t.Text))
+	}
+	sb.WriteString(fmt.Sprintf("user: %s\n\nASSISTANT REPLY TO EVALUATE:\n%s", userText, reply))
+
+	model := b.MindsetModel
+	if model == "" {
+		model = b.Model
+	}
+	res, err := p.Chat(ctx, b, domain.ChatRequest{
+		SystemPrompt: "You are a strict QA evaluator for a chat assistant. Decide whether the assistant reply satisfies the rubric. " +
+			`Answer only with JSON: {"pass": true|false, "reason": "<one short sentence>"}`,
+		UserText: sb.String(),
+		Model:    model,
+	})
+	if err != nil {
+		return false, "", 0, err
+	}
+	cost := 0.0
+	if res.Usage != nil {
+		cost = res.Usage.CostUSD
+	}
+
+	text := res.Text
+	if i, j := strings.Index(text, "{"), strings.LastIndex(text, "}"); i >= 0 && j > i {
+		text = text[i : j+1]
+	}
+	var verdict struct {
+		Pass   bool   `json:"pass"`
+		Reason string `json:"reason"`
+	}
+	if err := json.Unmarshal([]byte(text), &verdict); err != nil {
+		return false, "", cost, fmt.Errorf("unreadable verdict %q", res.Text)
+	}
+	return verdict.Pass, verdict.Reason, cost, nil
+}
+
+// evalProvider envuelve al proveedor real durante un caso: registra las herramientas pedidas,
+// graba las llamadas (record) o las reproduce sin tocar la red (replay).
+type evalProvider struct {
+	mu    sync.Mutex
+	mode  eval.RunMode
+	inner domain.AIProvider
+
+	tape    []eval.Exchange // Grabación a reproducir (replay)
+	pos     int
+	results []eval.ToolResult // Resultados pendientes de la última llamada reproducida
+
+	recorded []eval.Exchange // Grabación nueva (record)
+	tools    []string        // Herramientas pedidas en el turno en curso
+	tapeErr  error           // Desincronización de la grabación en el turno en curso (replay)
+}
+
+func (r *evalProvider) bind(p domain.AIProvider) domain.AIProvider {
+	r.inner = p
+	return r
+}
+
+func (r *evalProvider) beginTurn() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.tools = nil
+	r.tapeErr = nil
+}
+
+// turnTapeErr devuelve la desincronización del turno: Process la convierte en una respuesta de
+// error para el usuario, así que el runner no la vería como error del motor
+func (r *evalProvider) turnTapeErr() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.tapeErr
+}
+
+func (r *evalProvider) turnTools() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.tools...)
+}
+
+// next devuelve la siguiente llamada grabada del tipo pedido
+func (r *evalProvider) next(mindset bool) (eval.Exchange, error) {
+	if r.pos >= len(r.tape) {
+		return eval.Exchange{}, fmt.Errorf("recording exhausted after %d calls: the conversation diverged, record the suite again", r.pos)
+	}
+	ex := r.tape[r.pos]
+	if (ex.Mindset != nil) != mindset {
+		return eval.Exchange{}, fmt.Errorf("recording out of sync at call %d: record the suite again", r.pos+1)
+	}
+	r.pos++
+	return ex, nil
+}
+
+func (r *evalProvider) Chat(ctx context.Context, b bot.Bot, req domain.ChatRequest) (domain.ChatResponse, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var res domain.ChatResponse
+	if r.mode == eval.ModeReplay {
+		ex, err := r.next(false)
+		if err != nil {
+			r.tapeErr = err
+			return res, err
+		}
+		r.results = append([]eval.ToolResult(nil), ex.ToolResults...)
+		res = domain.ChatResponse{Text: ex.Text, ToolCalls: ex.ToolCalls}
+	} else {
+		// La red no se bloquea bajo el candado: solo este caso usa el proveedor
+		r.mu.Unlock()
+		var err error
+		res, err = r.inner.Chat(ctx, b, req)
+		r.mu.Lock()
+		if err != nil {
+			return res, err
+		}
+		if r.mode == eval.ModeRecord {
+			r.recorded = append(r.recorded, eval.Exchange{Text: res.Text, ToolCalls: res.ToolCalls})
+		}
+	}
+	for _, tc := range res.ToolCalls {
+		r.tools = append(r.tools, tc.Name)
+	}
+	return res, nil
+}
+
+func (r *evalProvider) PreAnalyzeMindset(ctx context.Context, b bot.Bot, input domain.BotInput, history []domain.ChatTurn) (*domain.Mindset, *domain.UsageStats, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.mode == eval.ModeReplay {
+		ex, err := r.next(true)
+		if err != nil {
+			// Sin intuición grabada se responde siempre, igual que si el análisis fallara
+			return &domain.Mindset{Pace: "steady", ShouldRespond: true}, nil, nil
+		}
+		m := *ex.Mindset
+		return &m, nil, nil
+	}
+
+	r.mu.Unlock()
+	m, usage, err := r.inner.PreAnalyzeMindset(ctx, b, input, history)
+	r.mu.Lock()
+	if err == nil && m != nil && r.mode == eval.ModeRecord {
+		saved := *m
+		r.recorded = append(r.recorded, eval.Exchange{Mindset: &saved})
+	}
+	return m, usage, err
+}
+
+// toolHooks graba los resultados de las herramientas (record) o los sirve desde la grabación (replay)
+func (r *evalProvider) toolHooks() *domain.ToolHooks {
+	switch r.mode {
+	case eval.ModeRecord:
+		return &domain.ToolHooks{Observe: func(tc domain.ToolCall, result map[string]any) {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			if n := len(r.recorded); n > 0 {
+				r.recorded[n-1].ToolResults = append(r.recorded[n-1].ToolResults, eval.ToolResult{Name: tc.Name, Data: result})
+			}
+		}}
+	case eval.ModeReplay:
+		return &domain.ToolHooks{Stub: func(tc domain.ToolCall) (map[string]any, bool) {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			for i, res := range r.results {
+				if res.Name == tc.Name {
+					r.results = append(r.results[:i], r.results[i+1:]...)
+					return res.Data, true
+				}
+			}
+			// En replay nunca se ejecutan herramientas reales
+			return map[string]any{"error": fmt.Sprintf("no recorded result for tool %s", tc.Name)}, true
+		}}
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package botengine
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/AzielCF/az-wap/botengine/domain"
+	"github.com/AzielCF/az-wap/botengine/domain/bot"
+	"github.com/AzielCF/az-wap/botengine/domain/eval"
+	domainMCP "github.com/AzielCF/az-wap/botengine/domain/mcp"
+	coreconfig "github.com/AzielCF/az-wap/core/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type evalTestBots struct {
+	bot.IBotUsecase
+}
+
+func (evalTestBots) GetByID(_ context.Context, id string) (bot.Bot, error) {
+	return bot.Bot{ID: id, Name: "Orders", Provider: "scripted", Model: "test-model"}, nil
+}
+
+type evalTestMCP struct {
+	domainMCP.IMCPUsecase
+}
+
+func (evalTestMCP) GetBotTools(context.Context, string) ([]domainMCP.Tool, error) {
+	return nil, nil
+}
+
+func (evalTestMCP) ListServersForBot(context.Context, string) ([]domainMCP.MCPServer, error) {
+	return nil, nil
+}
+
+// scriptedProvider pide la herramienta lookup_order y responde con su resultado; también hace de juez
+type scriptedProvider struct {
+	chats  int32
+	judged int32
+}
+
+func (p *scriptedProvider) Chat(_ context.Context, _ bot.Bot, req domain.ChatRequest) (domain.ChatResponse, error) {
+	if strings.Contains(req.SystemPrompt, "QA evaluator") {
+		atomic.AddInt32(&p.judged, 1)
+		return domain.ChatResponse{Text: `{"pass": true, "reason": "mentions the delivery day"}`}, nil
+	}
+	atomic.AddInt32(&p.chats, 1)
+	last := req.History[len(req.History)-1]
+	if len(last.ToolResponses) == 0 {
+		return domain.ChatResponse{ToolCalls: []domain.ToolCall{{ID: "call-1", Name: "lookup_order", Args: map[string]any{"id": "123"}}}}, nil
+	}
+	data, _ := last.ToolResponses[0].Data.(map[string]any)
+	day, _ := data["day"].(string)
+	return domain.ChatResponse{Text: "Tu pedido llega el " + day}, nil
+}
+
+func (p *scriptedProvider) PreAnalyzeMindset(context.Context, bot.Bot, domain.BotInput, []domain.ChatTurn) (*domain.Mindset, *domain.UsageStats, error) {
+	return &domain.Mindset{Pace: "steady", ShouldRespond: true}, nil, nil
+}
+
+func TestRunEvalSuite_RecordAndReplay(t *testing.T) {
+	coreconfig.Global = &coreconfig.Config{}
+	ctx := context.Background()
+	provider := &scriptedProvider{}
+	var lookups int32
+
+	e := NewEngine(evalTestBots{}, evalTestMCP{}, nil)
+	e.RegisterProvider("scripted", provider)
+	e.RegisterNativeTool(&domain.NativeTool{
+		Tool: domainMCP.Tool{Name: "lookup_order"},
+		Handler: func(context.Context, map[string]interface{}, map[string]interface{}) (map[string]interface{}, error) {
+			atomic.AddInt32(&lookups, 1)
+			return map[string]interface{}{"day": "lunes"}, nil
+		},
+	})
+
+	suite := eval.Suite{ID: "suite-1", BotID: "bot-1", Name: "Orders", Cases: []eval.Case{{
+		Name: "where is my order",
+		Turns: []eval.Turn{{
+			User:        "¿Dónde está mi pedido 123?",
+			ExpectTools: []string{"lookup_order"},
+			Assertions: []eval.Assertion{
+				{Type: eval.AssertContains, Value: "lunes"},
+				{Type: eval.AssertLLMJudge, Value: "Tells the user the delivery day"},
+			},
+		}},
+	}}}
+
+	// Record: proveedor y herramienta reales; el juez se llama con el proveedor del bot
+	run, recording, err := e.RunEvalSuite(ctx, suite, eval.RunOptions{Mode: eval.ModeRecord})
+	require.NoError(t, err)
+	require.True(t, run.OK(), "record run failed: %+v", run.Cases)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&provider.chats))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&provider.judged))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&lookups))
+
+	tape := recording["where is my order"]
+	require.Len(t, tape, 3) // Intuición, llamada con herramienta, respuesta final
+	require.NotNil(t, tape[0].Mindset)
+	require.Len(t, tape[1].ToolResults, 1)
+	assert.Equal(t, "lunes", tape[1].ToolResults[0].Data["day"])
+
+	// Replay: ni el proveedor ni la herramienta real se tocan; el juez se omite
+	suite.Recording = recording
+	run, _, err = e.RunEvalSuite(ctx, suite, eval.RunOptions{Mode: eval.ModeReplay})
+	require.NoError(t, err)
+	require.True(t, run.OK(), "replay run failed: %+v", run.Cases)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&provider.chats))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&provider.judged))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&lookups))
+	turn := run.Cases[0].Turns[0]
+	assert.Equal(t, "Tu pedido llega el lunes", turn.Reply)
+	assert.Equal(t, []string{"lookup_order"}, turn.Tools)
+	assert.Len(t, turn.Skipped, 1)
+
+	// Un turno que no está en la grabación se reporta como desincronización, no como respuesta
+	suite.Cases[0].Turns = append(suite.Cases[0].Turns, eval.Turn{User: "¿Y el pedido 456?"})
+	run, _, err = e.RunEvalSuite(ctx, suite, eval.RunOptions{Mode: eval.ModeReplay})
+	require.NoError(t, err)
+	assert.False(t, run.OK())
+	assert.Contains(t, run.Cases[0].Error, "record the suite again")
+	assert.Equal(t, int32(2), atomic.LoadInt32(&provider.chats))
+}
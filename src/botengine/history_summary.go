@@ -0,0 +1,79 @@
+package botengine
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/AzielCF/az-wap/botengine/domain"
+	"github.com/AzielCF/az-wap/botengine/domain/bot"
+	botmonitor "github.com/AzielCF/az-wap/botengine/infrastructure/monitoring"
+	"github.com/sirupsen/logrus"
+)
+
+// summaryRetryAfter es la espera tras un resumen fallido: sin ella cada mensaje reintentaría
+// (y pagaría) el resumen de un historial cada vez más largo
+const summaryRetryAfter = 10 * time.Minute
+
+// compactHistory condensa el inicio del historial en el turno fijo de resumen cuando supera el
+// presupuesto de tokens de la sesión. Devuelve nil si no hizo falta o si el proveedor no sabe
+// resumir: en ese caso el historial queda intacto.
+func (e *Engine) compactHistory(ctx context.Context, p domain.AIProvider, b bot.Bot, input *domain.BotInput, addCost func(botID, model string, cost float64)) *domain.HistorySummary {
+	if !b.MemoryEnabled || input.HistoryTokenBudget <= 0 || len(input.History) == 0 {
+		return nil
+	}
+	summarizer, ok := p.(domain.HistorySummarizer)
+	if !ok {
+		return nil
+	}
+	backoffKey := input.InstanceID + "|" + input.ChatID
+	if until, ok := e.summaryBackoff.Load(backoffKey); ok {
+		if time.Now().Before(until.(time.Time)) {
+			return nil
+		}
+		e.summaryBackoff.Delete(backoffKey)
+	}
+
+	turns := input.History
+	previous := ""
+	budget := input.HistoryTokenBudget
+	if turns[0].Pinned {
+		previous = domain.SummaryText(turns[0])
+		budget -= domain.EstimateTurnTokens(turns[0])
+		turns = turns[1:]
+	}
+	n := domain.SummarySplit(turns, budget)
+	if n == 0 {
+		return nil
+	}
+
+	start := time.Now()
+	summary, usage, err := summarizer.SummarizeHistory(ctx, b, previous, turns[:n], input.Language)
+	md := map[string]string{
+		"condensed_turns": fmt.Sprintf("%d", n),
+		"kept_turns":      fmt.Sprintf("%d", len(turns)-n),
+	}
+	if usage != nil {
+		addCost(b.ID, usage.Model, usage.CostUSD)
+		md["model"] = usage.Model
+		md["cost"] = fmt.Sprintf("$%.6f", usage.CostUSD)
+	}
+	if err != nil {
+		logrus.Warnf("[ENGINE] History summarization failed for %s, keeping full history and retrying in %s: %v", input.ChatID, summaryRetryAfter, err)
+		e.summaryBackoff.Store(backoffKey, time.Now().Add(summaryRetryAfter))
+		botmonitor.Record(botmonitor.Event{
+			TraceID: input.TraceID, InstanceID: input.InstanceID, ChatJID: input.ChatID,
+			Provider: string(b.Provider), Stage: "history_summary", Status: "error", Error: err.Error(),
+			DurationMs: time.Since(start).Milliseconds(), Metadata: md,
+		})
+		return nil
+	}
+	botmonitor.Record(botmonitor.Event{
+		TraceID: input.TraceID, InstanceID: input.InstanceID, ChatJID: input.ChatID,
+		Provider: string(b.Provider), Stage: "history_summary", Status: "ok",
+		DurationMs: time.Since(start).Milliseconds(), Metadata: md,
+	})
+
+	input.History = append([]domain.ChatTurn{domain.SummaryTurn(summary)}, turns[n:]...)
+	return &domain.HistorySummary{Text: summary, Condensed: n}
+}
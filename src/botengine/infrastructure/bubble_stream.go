@@ -0,0 +1,132 @@
+package infrastructure
+
+import (
+	"strings"
+)
+
+const mindsetTagOpen = "<mindset"
+
+// BubbleStream corta en burbujas un texto que llega por fragmentos (streaming) con las mismas
+// reglas que SplitIntoBubbles: una burbuja por párrafo, frases en los párrafos largos y un
+// máximo de burbujas (el resto se junta en la última). Además retira la etiqueta <mindset .../>.
+type BubbleStream struct {
+	limit      int
+	maxBubbles int
+
+	raw string // Texto sin revisar: puede contener una etiqueta <mindset> incompleta
+	cur string // Texto visible de la burbuja en curso
+	cut int    // Burbujas ya entregadas
+
+	Tag string // Última etiqueta <mindset .../> encontrada en el texto
+}
+
+// NewBubbleStream crea un cortador para una respuesta. Igual que SplitIntoBubbles, a veces no divide nada.
+func (h *Humanizer) NewBubbleStream(highSpeed bool) *BubbleStream {
+	s := &BubbleStream{limit: 600, maxBubbles: 3}
+	if highSpeed {
+		s.limit, s.maxBubbles = 900, 10
+	} else if h.jitter(100) < 30 {
+		s.maxBubbles = 1
+	}
+	return s
+}
+
+// Push añade un delta. Devuelve el texto visible nuevo (sin etiquetas) y las burbujas ya completas.
+func (s *BubbleStream) Push(delta string) (string, []string) {
+	s.raw += delta
+	visible := s.release()
+	s.cur += visible
+
+	var bubbles []string
+	for s.cut < s.maxBubbles-1 {
+		if i := strings.Index(s.cur, "\n\n"); i >= 0 {
+			p := strings.TrimSpace(s.cur[:i])
+			s.cur = s.cur[i+2:]
+			if p != "" {
+				bubbles = append(bubbles, p)
+				s.cut++
+			}
+			continue
+		}
+		// Párrafo demasiado largo: se corta en la última frase completa
+		if len(s.cur) > s.limit {
+			if i := lastSentenceEnd(s.cur); i > 0 {
+				bubbles = append(bubbles, strings.TrimSpace(s.cur[:i]))
+				s.cur = s.cur[i:]
+				s.cut++
+				continue
+			}
+		}
+		break
+	}
+	return visible, bubbles
+}
+
+// Flush entrega el texto restante como última burbuja
+func (s *BubbleStream) Flush() []string {
+	if !strings.HasPrefix(s.raw, mindsetTagOpen) {
+		s.cur += s.raw // Un "<" suelto al final era texto
+	}
+	s.raw = ""
+	rest := strings.TrimSpace(s.cur)
+	s.cur = ""
+	if rest == "" {
+		return nil
+	}
+	s.cut++
+	return []string{rest}
+}
+
+// Reset descarta el texto pendiente: la IA pidió una herramienta y no era la respuesta final
+func (s *BubbleStream) Reset() {
+	s.raw, s.cur = "", ""
+}
+
+// Bubbles devuelve cuántas burbujas se han cortado
+func (s *BubbleStream) Bubbles() int {
+	return s.cut
+}
+
+// release devuelve el texto que ya se puede mostrar, reteniendo una posible etiqueta <mindset> a medias
+func (s *BubbleStream) release() string {
+	var out strings.Builder
+	for {
+		i := strings.Index(s.raw, "<")
+		if i < 0 {
+			out.WriteString(s.raw)
+			s.raw = ""
+			break
+		}
+		out.WriteString(s.raw[:i])
+		s.raw = s.raw[i:]
+
+		if strings.HasPrefix(s.raw, mindsetTagOpen) {
+			end := strings.Index(s.raw, "/>")
+			if end < 0 {
+				break // Esperar al cierre de la etiqueta
+			}
+			s.Tag = s.raw[:end+2]
+			s.raw = s.raw[end+2:]
+			continue
+		}
+		if strings.HasPrefix(mindsetTagOpen, s.raw) {
+			break // Prefijo incompleto ("<min"): esperar al siguiente delta
+		}
+		out.WriteString("<")
+		s.raw = s.raw[1:]
+	}
+	return out.String()
+}
+
+// lastSentenceEnd devuelve la posición tras el último . ! ? seguido de espacio (0 si no hay)
+func lastSentenceEnd(text string) int {
+	for i := len(text) - 2; i >= 0; i-- {
+		switch text[i] {
+		case '.', '!', '?':
+			if text[i+1] == ' ' || text[i+1] == '\n' {
+				return i + 1
+			}
+		}
+	}
+	return 0
+}
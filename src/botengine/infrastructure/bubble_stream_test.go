@@ -0,0 +1,39 @@
+package infrastructure
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBubbleStream_CutsParagraphsAndStripsMindset(t *testing.T) {
+	s := &BubbleStream{limit: 600, maxBubbles: 3}
+
+	var visible strings.Builder
+	var bubbles []string
+	for _, delta := range []string{"<min", `dset pace="fast" voice="true"/>Hola, `, "¿cómo estás?\n", "\nTe cuento que ya ", "está listo.\n\nUn tercero.\n\nY un cuarto."} {
+		v, b := s.Push(delta)
+		visible.WriteString(v)
+		bubbles = append(bubbles, b...)
+	}
+	bubbles = append(bubbles, s.Flush()...)
+
+	assert.Equal(t, `<mindset pace="fast" voice="true"/>`, s.Tag)
+	assert.NotContains(t, visible.String(), "mindset")
+	// Máximo 3 burbujas: el resto se junta en la última
+	assert.Equal(t, []string{"Hola, ¿cómo estás?", "Te cuento que ya está listo.", "Un tercero.\n\nY un cuarto."}, bubbles)
+}
+
+func TestBubbleStream_LongParagraphAndReset(t *testing.T) {
+	s := &BubbleStream{limit: 40, maxBubbles: 3}
+
+	_, b := s.Push("Primera frase bastante larga. Segunda frase que pasa el límite")
+	assert.Equal(t, []string{"Primera frase bastante larga."}, b)
+
+	// Una herramienta interrumpe: lo pendiente se descarta
+	s.Reset()
+	_, b = s.Push("a < b")
+	assert.Empty(t, b)
+	assert.Equal(t, []string{"a < b"}, s.Flush())
+}
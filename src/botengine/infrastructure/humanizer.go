@@ -9,6 +9,7 @@ import (
 	"unicode/utf8"
 
 	"github.com/AzielCF/az-wap/botengine/domain"
+	domainBot "github.com/AzielCF/az-wap/botengine/domain/bot"
 )
 
 // Humanizer manages human-like behavior simulation for bot responses.
@@ -25,69 +26,20 @@ type Humanizer struct {
 	AdaptiveDebouncePercent int
 }
 
-// TypingProfile defines the bot's typing style.
-type TypingProfile struct {
-	BaseCharDelayMs        int
-	CharDelayVarianceMs    int
-	PunctuationPauseChance int
-	PunctuationPauseMinMs  int
-	PunctuationPauseMaxMs  int
-	WordsPerBreak          int
-	WordsBreakVariance     int
-	ThinkingPauseChance    int
-	ThinkingPauseMinMs     int
-	ThinkingPauseMaxMs     int
-}
+// TypingProfile defines the bot's typing style. Los perfiles son configurables por bot o canal (domain/bot).
+type TypingProfile = domainBot.TypingProfile
 
 // DefaultProfile simulates an average human typer.
-var DefaultProfile = TypingProfile{
-	BaseCharDelayMs:        12,
-	CharDelayVarianceMs:    8,
-	PunctuationPauseChance: 40,
-	PunctuationPauseMinMs:  150,
-	PunctuationPauseMaxMs:  350,
-	WordsPerBreak:          20,
-	WordsBreakVariance:     12,
-	ThinkingPauseChance:    25,
-	ThinkingPauseMinMs:     200,
-	ThinkingPauseMaxMs:     500,
-}
+var DefaultProfile = domainBot.DefaultTypingProfile
 
 // FastTyperProfile simulates a fast typer (e.g., experienced support agent).
-var FastTyperProfile = TypingProfile{
-	BaseCharDelayMs:        6,
-	CharDelayVarianceMs:    4,
-	PunctuationPauseChance: 20,
-	PunctuationPauseMinMs:  80,
-	PunctuationPauseMaxMs:  180,
-	WordsPerBreak:          35,
-	WordsBreakVariance:     15,
-	ThinkingPauseChance:    10,
-	ThinkingPauseMinMs:     100,
-	ThinkingPauseMaxMs:     250,
-}
+var FastTyperProfile = domainBot.FastTypingProfile
 
 // CasualTyperProfile simulates a relaxed typer, potentially on mobile.
-var CasualTyperProfile = TypingProfile{
-	BaseCharDelayMs:        18,
-	CharDelayVarianceMs:    12,
-	PunctuationPauseChance: 60,
-	PunctuationPauseMinMs:  250,
-	PunctuationPauseMaxMs:  600,
-	WordsPerBreak:          12,
-	WordsBreakVariance:     8,
-	ThinkingPauseChance:    40,
-	ThinkingPauseMinMs:     350,
-	ThinkingPauseMaxMs:     800,
-}
+var CasualTyperProfile = domainBot.CasualTypingProfile
 
 // InstantProfile for robots/official APIs (minimal latency)
-var InstantProfile = TypingProfile{
-	BaseCharDelayMs:        1,
-	CharDelayVarianceMs:    1,
-	PunctuationPauseChance: 0,
-	ThinkingPauseChance:    0,
-}
+var InstantProfile = domainBot.InstantTypingProfile
 
 func NewHumanizer(enabled bool) *Humanizer {
 	return &Humanizer{
@@ -256,7 +208,7 @@ func (h *Humanizer) SimulateTypingWithProfile(ctx context.Context, t domain.Tran
 
 		// Rule 1: Pause every N words (thinking pause)
 		if wordCount >= nextWordBreak {
-			perCharBase = time.Duration(profile.BaseCharDelayMs+h.Rng.Intn(profile.CharDelayVarianceMs)) * time.Millisecond
+			perCharBase = time.Duration(profile.BaseCharDelayMs+h.jitter(profile.CharDelayVarianceMs)) * time.Millisecond
 
 			if !flushSegment() {
 				return false
@@ -269,7 +221,7 @@ func (h *Humanizer) SimulateTypingWithProfile(ctx context.Context, t domain.Tran
 			}
 
 			wordCount = 0
-			nextWordBreak = profile.WordsPerBreak + h.Rng.Intn(profile.WordsBreakVariance*2) - profile.WordsBreakVariance
+			nextWordBreak = profile.WordsPerBreak + h.jitter(profile.WordsBreakVariance*2) - profile.WordsBreakVariance
 			if nextWordBreak < 5 {
 				nextWordBreak = 5
 			}
@@ -279,7 +231,7 @@ func (h *Humanizer) SimulateTypingWithProfile(ctx context.Context, t domain.Tran
 		// Rule 2: Strong punctuation pauses (. ! ?)
 		if r == '.' || r == '!' || r == '?' {
 			if i < len(runes)-1 && h.Rng.Intn(100) < profile.PunctuationPauseChance {
-				perCharBase = time.Duration(profile.BaseCharDelayMs+h.Rng.Intn(profile.CharDelayVarianceMs)) * time.Millisecond
+				perCharBase = time.Duration(profile.BaseCharDelayMs+h.jitter(profile.CharDelayVarianceMs)) * time.Millisecond
 				if !flushSegment() {
 					return false
 				}
@@ -291,7 +243,7 @@ func (h *Humanizer) SimulateTypingWithProfile(ctx context.Context, t domain.Tran
 
 		// Rule 3: Newline pauses
 		if r == '\n' {
-			perCharBase = time.Duration(profile.BaseCharDelayMs+h.Rng.Intn(profile.CharDelayVarianceMs)) * time.Millisecond
+			perCharBase = time.Duration(profile.BaseCharDelayMs+h.jitter(profile.CharDelayVarianceMs)) * time.Millisecond
 			if !flushSegment() {
 				return false
 			}
@@ -337,7 +289,7 @@ func (h *Humanizer) SimulateTypingWithProfile(ctx context.Context, t domain.Tran
 	}
 
 	// 5. Final flush and pre-send pause
-	perCharBase = time.Duration(profile.BaseCharDelayMs+h.Rng.Intn(profile.CharDelayVarianceMs)) * time.Millisecond
+	perCharBase = time.Duration(profile.BaseCharDelayMs+h.jitter(profile.CharDelayVarianceMs)) * time.Millisecond
 	if !flushSegment() {
 		return false
 	}
@@ -346,6 +298,32 @@ func (h *Humanizer) SimulateTypingWithProfile(ctx context.Context, t domain.Tran
 	return h.sleep(ctx, time.Duration(80+h.Rng.Intn(180))*time.Millisecond)
 }
 
+// maxRecordingSimulation evita que una nota de voz larga deje el chat "grabando" demasiado tiempo
+const maxRecordingSimulation = 20 * time.Second
+
+// SimulateRecording shows the "recording audio" presence for roughly the length of the voice note.
+func (h *Humanizer) SimulateRecording(ctx context.Context, t domain.Transport, chatID string, d time.Duration) bool {
+	if !h.Enabled || t == nil {
+		return true
+	}
+	if d > maxRecordingSimulation {
+		d = maxRecordingSimulation
+	}
+
+	_ = t.SendPresence(ctx, chatID, true, true)
+	ok := h.sleep(ctx, d)
+	h.stopTyping(t, chatID)
+	return ok
+}
+
+// jitter devuelve un aleatorio en [0, n); 0 si n no es positivo (perfiles configurados sin varianza)
+func (h *Humanizer) jitter(n int) int {
+	if n <= 0 {
+		return 0
+	}
+	return h.Rng.Intn(n)
+}
+
 func (h *Humanizer) stopTyping(t domain.Transport, chatID string) {
 	if t == nil {
 		return
@@ -4,9 +4,11 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"path"
 	"reflect"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	domainMCP "github.com/AzielCF/az-wap/botengine/domain/mcp"
@@ -19,20 +21,87 @@ import (
 type mcpClientEntry struct {
 	client   *client.Client
 	config   domainMCP.MCPServer
-	lastUsed time.Time
+	lastUsed atomic.Int64  // UnixNano; lo tocan las llamadas concurrentes y el limpiador
+	proc     *stdioProcess // Solo para servidores stdio
+}
+
+func (e *mcpClientEntry) touch() {
+	e.lastUsed.Store(time.Now().UnixNano())
+}
+
+func (e *mcpClientEntry) idleFor(now time.Time) time.Duration {
+	return now.Sub(time.Unix(0, e.lastUsed.Load()))
+}
+
+// close libera el cliente y, si es stdio, mata el proceso supervisado.
+func (e *mcpClientEntry) close() {
+	if e.proc != nil {
+		e.proc.stop()
+	}
+	e.client.Close()
 }
 
 // MCPProviderAdapter implementa IMCPProvider usando mcp-go.
 type MCPProviderAdapter struct {
-	clients sync.Map // serverID -> *mcpClientEntry
+	clients    sync.Map // serverID -> *mcpClientEntry
+	connecting sync.Map // serverID -> *sync.Mutex; una sola conexión (y un solo proceso stdio) por servidor
+	stdio      *stdioSupervisor
+	health     func(serverID string, err error)
+	connect    func(ctx context.Context, server domainMCP.MCPServer) (*client.Client, *stdioProcess, error)
 }
 
 func NewMCPProviderAdapter() *MCPProviderAdapter {
-	a := &MCPProviderAdapter{}
+	a := &MCPProviderAdapter{stdio: newStdioSupervisor(stdioLimitsFromConfig())}
+	a.connect = a.createClient
+	a.stdio.onExit = a.handleStdioExit
 	go a.startIdleClientCleaner()
 	return a
 }
 
+// SetHealthReporter permite al servicio registrar caídas de procesos stdio en el health check.
+func (a *MCPProviderAdapter) SetHealthReporter(fn func(serverID string, err error)) {
+	a.health = fn
+}
+
+// handleStdioExit limpia el cliente muerto, reporta el crash y programa el reinicio.
+func (a *MCPProviderAdapter) handleStdioExit(server domainMCP.MCPServer, proc *stdioProcess, reason string, restartAfter time.Duration) {
+	if val, ok := a.clients.Load(server.ID); ok {
+		if entry := val.(*mcpClientEntry); entry.proc == proc {
+			a.clients.Delete(server.ID)
+			entry.client.Close()
+		}
+	}
+
+	if reason == "" {
+		return
+	}
+	if a.health != nil {
+		a.health(server.ID, fmt.Errorf("%s", reason))
+	}
+	if restartAfter < 0 {
+		return
+	}
+
+	time.AfterFunc(restartAfter, func() {
+		if _, exists := a.clients.Load(server.ID); exists {
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		if _, err := a.getOrConnectClient(ctx, server); err != nil {
+			logrus.Warnf("[MCPAdapter] Restart of stdio server %s failed: %v", server.Name, err)
+			if a.health != nil {
+				a.health(server.ID, err)
+			}
+			return
+		}
+		logrus.Infof("[MCPAdapter] stdio server %s restarted", server.Name)
+		if a.health != nil {
+			a.health(server.ID, nil)
+		}
+	})
+}
+
 func (a *MCPProviderAdapter) ListTools(ctx context.Context, server domainMCP.MCPServer) (tools []domainMCP.Tool, err error) {
 	defer func() {
 		if r := recover(); r != nil {
@@ -94,14 +163,191 @@ func (a *MCPProviderAdapter) CallTool(ctx context.Context, server domainMCP.MCPS
 	var result domainMCP.CallToolResult
 	result.IsError = callRes.IsError
 	for _, content := range callRes.Content {
-		if textContent, ok := content.(mcp.TextContent); ok {
-			result.Content = append(result.Content, domainMCP.CallToolContent{
-				Type: "text",
-				Text: textContent.Text,
+		if c, ok := toDomainContent(content); ok {
+			result.Content = append(result.Content, c)
+		}
+	}
+
+	return result, nil
+}
+
+// toDomainContent traduce la unión de contenidos de mcp-go al dominio sin perder binarios.
+func toDomainContent(content mcp.Content) (domainMCP.CallToolContent, bool) {
+	switch c := content.(type) {
+	case mcp.TextContent:
+		return domainMCP.CallToolContent{Type: domainMCP.ContentText, Text: c.Text, Audience: audience(c.Annotated)}, true
+	case mcp.ImageContent:
+		return domainMCP.CallToolContent{Type: domainMCP.ContentImage, Data: c.Data, MimeType: c.MIMEType, Audience: audience(c.Annotated)}, true
+	case mcp.AudioContent:
+		return domainMCP.CallToolContent{Type: domainMCP.ContentAudio, Data: c.Data, MimeType: c.MIMEType, Audience: audience(c.Annotated)}, true
+	case mcp.ResourceLink:
+		return domainMCP.CallToolContent{Type: domainMCP.ContentResourceLink, URI: c.URI, Name: c.Name, Text: c.Description, MimeType: c.MIMEType, Audience: audience(c.Annotated)}, true
+	case mcp.EmbeddedResource:
+		out := domainMCP.CallToolContent{Type: domainMCP.ContentResource, Audience: audience(c.Annotated)}
+		switch r := c.Resource.(type) {
+		case mcp.TextResourceContents:
+			out.URI, out.MimeType, out.Text = r.URI, r.MIMEType, r.Text
+		case mcp.BlobResourceContents:
+			out.URI, out.MimeType, out.Data = r.URI, r.MIMEType, r.Blob
+		default:
+			return out, false
+		}
+		out.Name = path.Base(out.URI)
+		return out, true
+	}
+	return domainMCP.CallToolContent{}, false
+}
+
+func audience(a mcp.Annotated) []string {
+	if a.Annotations == nil {
+		return nil
+	}
+	out := make([]string, 0, len(a.Annotations.Audience))
+	for _, r := range a.Annotations.Audience {
+		out = append(out, string(r))
+	}
+	return out
+}
+
+func (a *MCPProviderAdapter) ListResources(ctx context.Context, server domainMCP.MCPServer) (resources []domainMCP.Resource, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic in MCP ListResources: %v", r)
+			logrus.Errorf("[MCPAdapter] PANIC in ListResources for %s: %v", server.Name, r)
+		}
+	}()
+
+	c, err := a.getOrConnectClient(ctx, server)
+	if err != nil {
+		return nil, err
+	}
+	if c.GetServerCapabilities().Resources == nil {
+		return nil, nil
+	}
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	res, err := c.ListResources(timeoutCtx, mcp.ListResourcesRequest{})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, r := range res.Resources {
+		resources = append(resources, domainMCP.Resource{
+			URI:         r.URI,
+			Name:        r.Name,
+			Description: r.Description,
+			MimeType:    r.MIMEType,
+		})
+	}
+	return resources, nil
+}
+
+func (a *MCPProviderAdapter) ReadResource(ctx context.Context, server domainMCP.MCPServer, uri string) (contents []domainMCP.CallToolContent, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic in MCP ReadResource: %v", r)
+			logrus.Errorf("[MCPAdapter] PANIC in ReadResource for %s (uri: %s): %v", server.Name, uri, r)
+		}
+	}()
+
+	c, err := a.getOrConnectClient(ctx, server)
+	if err != nil {
+		return nil, err
+	}
+
+	req := mcp.ReadResourceRequest{}
+	req.Params.URI = uri
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	res, err := c.ReadResource(timeoutCtx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, rc := range res.Contents {
+		if out, ok := toDomainContent(mcp.EmbeddedResource{Type: mcp.ContentTypeResource, Resource: rc}); ok {
+			contents = append(contents, out)
+		}
+	}
+	return contents, nil
+}
+
+func (a *MCPProviderAdapter) ListPrompts(ctx context.Context, server domainMCP.MCPServer) (prompts []domainMCP.Prompt, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic in MCP ListPrompts: %v", r)
+			logrus.Errorf("[MCPAdapter] PANIC in ListPrompts for %s: %v", server.Name, r)
+		}
+	}()
+
+	c, err := a.getOrConnectClient(ctx, server)
+	if err != nil {
+		return nil, err
+	}
+	if c.GetServerCapabilities().Prompts == nil {
+		return nil, nil
+	}
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	res, err := c.ListPrompts(timeoutCtx, mcp.ListPromptsRequest{})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, p := range res.Prompts {
+		prompt := domainMCP.Prompt{Name: p.Name, Description: p.Description}
+		for _, arg := range p.Arguments {
+			prompt.Arguments = append(prompt.Arguments, domainMCP.PromptArgument{
+				Name:        arg.Name,
+				Description: arg.Description,
+				Required:    arg.Required,
 			})
 		}
+		prompts = append(prompts, prompt)
+	}
+	return prompts, nil
+}
+
+func (a *MCPProviderAdapter) GetPrompt(ctx context.Context, server domainMCP.MCPServer, name string, args map[string]string) (result domainMCP.PromptResult, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic in MCP GetPrompt: %v", r)
+			logrus.Errorf("[MCPAdapter] PANIC in GetPrompt for %s (prompt: %s): %v", server.Name, name, r)
+		}
+	}()
+
+	c, err := a.getOrConnectClient(ctx, server)
+	if err != nil {
+		return domainMCP.PromptResult{}, err
+	}
+
+	req := mcp.GetPromptRequest{}
+	req.Params.Name = name
+	req.Params.Arguments = args
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	res, err := c.GetPrompt(timeoutCtx, req)
+	if err != nil {
+		return domainMCP.PromptResult{}, err
 	}
 
+	result.Description = res.Description
+	for _, m := range res.Messages {
+		// Solo el texto sirve como system prompt; imágenes o blobs se descartan.
+		content, ok := toDomainContent(m.Content)
+		if !ok || content.Text == "" {
+			continue
+		}
+		result.Messages = append(result.Messages, domainMCP.PromptMessage{Role: string(m.Role), Text: content.Text})
+	}
 	return result, nil
 }
 
@@ -122,12 +368,17 @@ func (a *MCPProviderAdapter) Validate(ctx context.Context, server domainMCP.MCPS
 		return nil, nil
 	}
 
+	// Los procesos stdio son caros de lanzar: el handshake reutiliza el proceso supervisado.
+	if server.Type == domainMCP.ConnTypeStdio {
+		return a.ListTools(ctx, server)
+	}
+
 	// Full connection and tools listing
-	mcpClient, err := a.createClient(ctx, server)
+	mcpClient, proc, err := a.createClient(ctx, server)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create client: %w", err)
 	}
-	defer mcpClient.Close()
+	defer (&mcpClientEntry{client: mcpClient, proc: proc}).close()
 
 	if err := a.initializeClient(ctx, mcpClient, server.Name); err != nil {
 		return nil, err
@@ -154,7 +405,7 @@ func (a *MCPProviderAdapter) Shutdown() {
 	logrus.Info("[MCPAdapter] Shutting down connections...")
 	a.clients.Range(func(key, value interface{}) bool {
 		if entry, ok := value.(*mcpClientEntry); ok {
-			entry.client.Close()
+			entry.close()
 		}
 		return true
 	})
@@ -163,41 +414,81 @@ func (a *MCPProviderAdapter) Shutdown() {
 // === Lógica interna de red (Adaptador) ===
 
 func (a *MCPProviderAdapter) getOrConnectClient(ctx context.Context, server domainMCP.MCPServer) (*client.Client, error) {
-	if val, ok := a.clients.Load(server.ID); ok {
-		entry := val.(*mcpClientEntry)
-		if entry.config.URL == server.URL && reflect.DeepEqual(entry.config.Headers, server.Headers) {
-			entry.lastUsed = time.Now()
-			return entry.client, nil
-		}
-		entry.client.Close()
-		a.clients.Delete(server.ID)
+	if c, ok := a.cachedClient(server); ok {
+		return c, nil
 	}
 
-	mcpClient, err := a.createClient(ctx, server)
+	// Llamadas concurrentes (tools en paralelo, ListTools contra CallTool) esperan a la primera
+	// conexión en vez de lanzar cada una su propio proceso
+	mu, _ := a.connecting.LoadOrStore(server.ID, &sync.Mutex{})
+	mu.(*sync.Mutex).Lock()
+	defer mu.(*sync.Mutex).Unlock()
+
+	if c, ok := a.cachedClient(server); ok {
+		return c, nil
+	}
+	if val, ok := a.clients.LoadAndDelete(server.ID); ok {
+		val.(*mcpClientEntry).close()
+	}
+
+	mcpClient, proc, err := a.connect(ctx, server)
 	if err != nil {
 		return nil, err
 	}
 
+	entry := &mcpClientEntry{client: mcpClient, config: server, proc: proc}
+	entry.touch()
 	if err := a.initializeClient(ctx, mcpClient, server.Name); err != nil {
-		mcpClient.Close()
+		entry.close()
 		return nil, err
 	}
 
-	entry := &mcpClientEntry{
-		client:   mcpClient,
-		config:   server,
-		lastUsed: time.Now(),
+	// Otro camino (ej. un reinicio) pudo guardar un cliente mientras conectábamos: gana el primero
+	if val, loaded := a.clients.LoadOrStore(server.ID, entry); loaded {
+		entry.close()
+		winner := val.(*mcpClientEntry)
+		winner.touch()
+		return winner.client, nil
 	}
-	a.clients.Store(server.ID, entry)
 	return mcpClient, nil
 }
 
-func (a *MCPProviderAdapter) createClient(ctx context.Context, server domainMCP.MCPServer) (*client.Client, error) {
+// cachedClient devuelve el cliente abierto si sigue sirviendo para la configuración actual.
+func (a *MCPProviderAdapter) cachedClient(server domainMCP.MCPServer) (*client.Client, bool) {
+	val, ok := a.clients.Load(server.ID)
+	if !ok {
+		return nil, false
+	}
+	entry := val.(*mcpClientEntry)
+	if !sameConnection(entry.config, server) {
+		return nil, false
+	}
+	entry.touch()
+	return entry.client, true
+}
+
+// sameConnection indica si un cliente ya abierto sirve para la configuración actual.
+func sameConnection(current, next domainMCP.MCPServer) bool {
+	if current.Type != next.Type {
+		return false
+	}
+	if next.Type == domainMCP.ConnTypeStdio {
+		return current.Command == next.Command &&
+			reflect.DeepEqual(current.Args, next.Args) &&
+			reflect.DeepEqual(current.Env, next.Env)
+	}
+	return current.URL == next.URL && reflect.DeepEqual(current.Headers, next.Headers)
+}
+
+func (a *MCPProviderAdapter) createClient(ctx context.Context, server domainMCP.MCPServer) (*client.Client, *stdioProcess, error) {
 	logrus.Infof("[MCPAdapter] Connecting to %s (%s)", server.Name, server.Type)
 	var mcpClient *client.Client
 	var err error
 
 	switch server.Type {
+	case domainMCP.ConnTypeStdio:
+		// El supervisor arranca el transporte; no hay que llamar a Start de nuevo.
+		return a.stdio.Start(server)
 	case domainMCP.ConnTypeHTTP:
 		var opts []transport.StreamableHTTPCOption
 		if len(server.Headers) > 0 {
@@ -215,12 +506,12 @@ func (a *MCPProviderAdapter) createClient(ctx context.Context, server domainMCP.
 	}
 
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	if err := mcpClient.Start(ctx); err != nil {
-		return nil, err
+		return nil, nil, err
 	}
-	return mcpClient, nil
+	return mcpClient, nil, nil
 }
 
 func (a *MCPProviderAdapter) initializeClient(ctx context.Context, mcpClient *client.Client, name string) error {
@@ -247,6 +538,9 @@ func (a *MCPProviderAdapter) initializeClient(ctx context.Context, mcpClient *cl
 }
 
 func (a *MCPProviderAdapter) checkAvailability(ctx context.Context, server domainMCP.MCPServer) error {
+	if server.Type == domainMCP.ConnTypeStdio {
+		return a.stdio.Preflight(server)
+	}
 	if server.URL == "" {
 		return fmt.Errorf("URL missing")
 	}
@@ -286,9 +580,9 @@ func (a *MCPProviderAdapter) startIdleClientCleaner() {
 		now := time.Now()
 		a.clients.Range(func(key, value interface{}) bool {
 			entry := value.(*mcpClientEntry)
-			if now.Sub(entry.lastUsed) > 10*time.Minute {
+			if entry.idleFor(now) > 10*time.Minute {
 				logrus.Infof("[MCPAdapter] Closing idle connection for %s", entry.config.Name)
-				entry.client.Close()
+				entry.close()
 				a.clients.Delete(key)
 			}
 			return true
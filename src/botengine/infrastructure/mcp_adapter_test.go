@@ -0,0 +1,67 @@
+package infrastructure
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	domainMCP "github.com/AzielCF/az-wap/botengine/domain/mcp"
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+func TestGetOrConnectClient_ConcurrentFirstCallsSpawnOnce(t *testing.T) {
+	srv := server.NewMCPServer("echo", "1.0.0", server.WithToolCapabilities(false))
+	srv.AddTool(mcp.NewTool("ping"), func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return mcp.NewToolResultText("pong"), nil
+	})
+
+	var spawns atomic.Int32
+	a := &MCPProviderAdapter{}
+	a.connect = func(ctx context.Context, _ domainMCP.MCPServer) (*client.Client, *stdioProcess, error) {
+		spawns.Add(1)
+		time.Sleep(50 * time.Millisecond) // Arrancar un proceso no es instantáneo: ensancha la carrera
+		c, err := client.NewInProcessClient(srv)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := c.Start(ctx); err != nil {
+			return nil, nil, err
+		}
+		return c, nil, nil
+	}
+	defer a.Shutdown()
+
+	cfg := domainMCP.MCPServer{ID: "s1", Name: "echo", Type: domainMCP.ConnTypeStdio, Command: "echo-server"}
+
+	const callers = 8
+	var wg sync.WaitGroup
+	errs := make(chan error, callers)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			var err error
+			if i%2 == 0 {
+				_, err = a.ListTools(context.Background(), cfg)
+			} else {
+				_, err = a.CallTool(context.Background(), cfg, "ping", nil)
+			}
+			errs <- err
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("concurrent call failed: %v", err)
+		}
+	}
+	if got := spawns.Load(); got != 1 {
+		t.Fatalf("spawned %d clients for concurrent first calls, want 1", got)
+	}
+}
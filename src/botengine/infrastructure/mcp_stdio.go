@@ -0,0 +1,377 @@
+package infrastructure
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	domainMCP "github.com/AzielCF/az-wap/botengine/domain/mcp"
+	coreconfig "github.com/AzielCF/az-wap/core/config"
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/client/transport"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	stdioStderrTail     = 4096
+	stdioBackoffBase    = 2 * time.Second
+	stdioBackoffMax     = 5 * time.Minute
+	stdioStableAfter    = 2 * time.Minute // Uptime after which a crash no longer counts as a crash loop
+	stdioMaxAutoRestart = 5
+)
+
+// stdioLimits define el sandbox aplicado a cada proceso MCP stdio.
+type stdioLimits struct {
+	Enabled      bool
+	WorkDirRoot  string
+	EnvAllowlist []string
+	ServerEnv    []string // Variables que un servidor puede fijar en su Env ("*" al inicio o al final como comodín)
+	CPUSeconds   uint64
+	MemoryBytes  uint64
+	MaxLifetime  time.Duration
+}
+
+func stdioLimitsFromConfig() stdioLimits {
+	if coreconfig.Global == nil {
+		return stdioLimits{}
+	}
+	cfg := coreconfig.Global.MCP
+	return stdioLimits{
+		Enabled:      cfg.StdioEnabled,
+		WorkDirRoot:  cfg.StdioWorkDir,
+		EnvAllowlist: cfg.StdioEnvAllowlist,
+		ServerEnv:    cfg.StdioServerEnv,
+		CPUSeconds:   cfg.StdioCPUSeconds,
+		MemoryBytes:  cfg.StdioMemoryMB * 1024 * 1024,
+		MaxLifetime:  cfg.StdioMaxLifetime,
+	}
+}
+
+// stdioProcess es un proceso hijo vivo asociado a un cliente MCP.
+type stdioProcess struct {
+	serverID  string
+	cmd       *exec.Cmd
+	cancel    context.CancelFunc
+	startedAt time.Time
+	stopping  bool
+	mu        sync.Mutex
+}
+
+// stop marca la parada como intencional y mata el grupo de procesos.
+func (p *stdioProcess) stop() {
+	p.mu.Lock()
+	p.stopping = true
+	p.mu.Unlock()
+	p.cancel()
+	if p.cmd != nil && p.cmd.Process != nil {
+		killProcessGroup(p.cmd.Process.Pid)
+	}
+}
+
+func (p *stdioProcess) isStopping() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.stopping
+}
+
+// stdioState sobrevive a los reinicios y guarda el historial de fallos de un servidor.
+type stdioState struct {
+	failures    int
+	nextAttempt time.Time
+	stderr      []byte
+}
+
+// stdioSupervisor lanza, vigila y reinicia servidores MCP stdio.
+type stdioSupervisor struct {
+	limits stdioLimits
+	mu     sync.Mutex
+	states map[string]*stdioState
+	// onExit se invoca cuando un proceso termina sin que lo hayamos parado.
+	// reason vacío indica reciclaje por tiempo de vida; restartAfter < 0 desactiva el reinicio.
+	onExit func(server domainMCP.MCPServer, proc *stdioProcess, reason string, restartAfter time.Duration)
+}
+
+func newStdioSupervisor(limits stdioLimits) *stdioSupervisor {
+	return &stdioSupervisor{
+		limits: limits,
+		states: make(map[string]*stdioState),
+	}
+}
+
+func (s *stdioSupervisor) state(serverID string) *stdioState {
+	st, ok := s.states[serverID]
+	if !ok {
+		st = &stdioState{}
+		s.states[serverID] = st
+	}
+	return st
+}
+
+// StderrTail devuelve las últimas líneas de stderr capturadas para un servidor.
+func (s *stdioSupervisor) StderrTail(serverID string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if st, ok := s.states[serverID]; ok {
+		return string(st.stderr)
+	}
+	return ""
+}
+
+var unsafeDirChars = regexp.MustCompile(`[^a-zA-Z0-9_-]`)
+
+// jailDir devuelve (y crea) el directorio de trabajo aislado del servidor.
+func (s *stdioSupervisor) jailDir(serverID string) (string, error) {
+	root, err := filepath.Abs(s.limits.WorkDirRoot)
+	if err != nil {
+		return "", err
+	}
+	name := unsafeDirChars.ReplaceAllString(serverID, "_")
+	if name == "" {
+		name = "default"
+	}
+	dir := filepath.Join(root, name)
+	if err := os.MkdirAll(filepath.Join(dir, "tmp"), 0o700); err != nil {
+		return "", err
+	}
+	return dir, nil
+}
+
+// checkArgs rechaza rutas que escapan del jail del servidor.
+func checkArgs(jail string, args []string) error {
+	for _, arg := range args {
+		for _, part := range strings.Split(arg, "=") {
+			if strings.Contains(part, "..") && strings.ContainsAny(part, `/\`) {
+				return fmt.Errorf("argument %q escapes the working directory", arg)
+			}
+			if filepath.IsAbs(part) && !insideDir(jail, filepath.Clean(part)) {
+				return fmt.Errorf("argument %q points outside the working directory", arg)
+			}
+		}
+	}
+	return nil
+}
+
+// insideDir indica si path es dir o está dentro (no basta el prefijo: /srv/jail-evil no está en /srv/jail).
+func insideDir(dir, path string) bool {
+	return path == dir || strings.HasPrefix(path, dir+string(os.PathSeparator))
+}
+
+// serverEnvAllowed indica si un servidor puede fijar esa variable en su Env.
+// HOME y TMPDIR apuntan siempre al jail y no se pueden sobrescribir.
+func (s *stdioSupervisor) serverEnvAllowed(key string) bool {
+	if key == "HOME" || key == "TMPDIR" {
+		return false
+	}
+	for _, pattern := range s.limits.ServerEnv {
+		pattern = strings.TrimSpace(pattern)
+		switch {
+		case pattern == "":
+			continue
+		case strings.HasPrefix(pattern, "*"):
+			if strings.HasSuffix(key, pattern[1:]) {
+				return true
+			}
+		case strings.HasSuffix(pattern, "*"):
+			if strings.HasPrefix(key, pattern[:len(pattern)-1]) {
+				return true
+			}
+		case key == pattern:
+			return true
+		}
+	}
+	return false
+}
+
+// checkServerEnv rechaza variables del servidor fuera de la allowlist (LD_PRELOAD, NODE_OPTIONS...).
+func (s *stdioSupervisor) checkServerEnv(env map[string]string) error {
+	for k := range env {
+		if !s.serverEnvAllowed(k) {
+			return fmt.Errorf("environment variable %q is not allowed (MCP_STDIO_SERVER_ENV_ALLOWLIST)", k)
+		}
+	}
+	return nil
+}
+
+// buildEnv forwards only allowlisted host variables plus the allowlisted part of the server's own Env.
+func (s *stdioSupervisor) buildEnv(jail string, server domainMCP.MCPServer) []string {
+	env := []string{
+		"HOME=" + jail,
+		"TMPDIR=" + filepath.Join(jail, "tmp"),
+	}
+	for _, key := range s.limits.EnvAllowlist {
+		key = strings.TrimSpace(key)
+		if key == "" || key == "HOME" || key == "TMPDIR" {
+			continue
+		}
+		if v, ok := os.LookupEnv(key); ok {
+			env = append(env, key+"="+v)
+		}
+	}
+	for k, v := range server.Env {
+		if s.serverEnvAllowed(k) {
+			env = append(env, k+"="+v)
+		}
+	}
+	return env
+}
+
+// Preflight valida que el servidor pueda lanzarse sin arrancarlo.
+func (s *stdioSupervisor) Preflight(server domainMCP.MCPServer) error {
+	if !s.limits.Enabled {
+		return fmt.Errorf("stdio MCP servers are disabled (MCP_STDIO_ENABLED)")
+	}
+	if strings.TrimSpace(server.Command) == "" {
+		return fmt.Errorf("command missing")
+	}
+	if _, err := exec.LookPath(server.Command); err != nil {
+		return fmt.Errorf("command not found: %s", server.Command)
+	}
+	jail, err := s.jailDir(server.ID)
+	if err != nil {
+		return err
+	}
+	if err := checkArgs(jail, server.Args); err != nil {
+		return err
+	}
+	// Con límites configurados el servidor no arranca sin ellos
+	if _, _, err := rlimitCommand(server.Command, server.Args, s.limits); err != nil {
+		return fmt.Errorf("resource limits cannot be applied: %w", err)
+	}
+	return s.checkServerEnv(server.Env)
+}
+
+// Start lanza el proceso y devuelve un cliente MCP arrancado (sin inicializar).
+func (s *stdioSupervisor) Start(server domainMCP.MCPServer) (*client.Client, *stdioProcess, error) {
+	if err := s.Preflight(server); err != nil {
+		return nil, nil, err
+	}
+
+	s.mu.Lock()
+	st := s.state(server.ID)
+	if wait := time.Until(st.nextAttempt); wait > 0 {
+		failures := st.failures
+		s.mu.Unlock()
+		return nil, nil, fmt.Errorf("stdio server is backing off after %d crash(es), retry in %s", failures, wait.Round(time.Second))
+	}
+	s.mu.Unlock()
+
+	jail, _ := s.jailDir(server.ID)
+	env := s.buildEnv(jail, server)
+
+	// El proceso vive con su propio contexto: no debe morir con la petición que lo lanzó.
+	var (
+		lifeCtx context.Context
+		cancel  context.CancelFunc
+	)
+	if s.limits.MaxLifetime > 0 {
+		lifeCtx, cancel = context.WithTimeout(context.Background(), s.limits.MaxLifetime)
+	} else {
+		lifeCtx, cancel = context.WithCancel(context.Background())
+	}
+
+	proc := &stdioProcess{serverID: server.ID, cancel: cancel, startedAt: time.Now()}
+	cmdFunc := func(ctx context.Context, command string, env []string, args []string) (*exec.Cmd, error) {
+		// Los límites se fijan antes del exec del servidor, no después de arrancarlo
+		wrapped, wrappedArgs, err := rlimitCommand(command, args, s.limits)
+		if err != nil {
+			return nil, fmt.Errorf("resource limits cannot be applied to %s: %w", server.Name, err)
+		}
+		command, args = wrapped, wrappedArgs
+		cmd := exec.CommandContext(ctx, command, args...)
+		cmd.Dir = jail
+		cmd.Env = env
+		applySandboxAttrs(cmd)
+		proc.cmd = cmd
+		return cmd, nil
+	}
+
+	tr := transport.NewStdioWithOptions(server.Command, env, server.Args, transport.WithCommandFunc(cmdFunc))
+	if err := tr.Start(lifeCtx); err != nil {
+		cancel()
+		return nil, nil, err
+	}
+
+	logrus.Infof("[MCPAdapter] Spawned stdio server %s (pid %d) in %s", server.Name, proc.cmd.Process.Pid, jail)
+	go s.watch(server, proc, tr.Stderr())
+
+	return client.NewClient(tr), proc, nil
+}
+
+// watch captura stderr hasta que el proceso termina y decide si fue un crash.
+func (s *stdioSupervisor) watch(server domainMCP.MCPServer, proc *stdioProcess, stderr io.Reader) {
+	scanner := bufio.NewScanner(stderr)
+	for scanner.Scan() {
+		line := scanner.Text()
+		logrus.Debugf("[MCPAdapter] [%s stderr] %s", server.Name, line)
+		s.mu.Lock()
+		st := s.state(server.ID)
+		st.stderr = append(st.stderr, line...)
+		st.stderr = append(st.stderr, '\n')
+		if len(st.stderr) > stdioStderrTail {
+			st.stderr = st.stderr[len(st.stderr)-stdioStderrTail:]
+		}
+		s.mu.Unlock()
+	}
+
+	// stderr se cierra cuando el proceso sale (o cuando lo cerramos nosotros).
+	if proc.isStopping() {
+		return
+	}
+
+	uptime := time.Since(proc.startedAt)
+	if s.limits.MaxLifetime > 0 && uptime >= s.limits.MaxLifetime {
+		logrus.Infof("[MCPAdapter] stdio server %s reached its max lifetime, recycling", server.Name)
+		s.notifyExit(server, proc, "", -1)
+		return
+	}
+
+	s.mu.Lock()
+	st := s.state(server.ID)
+	if uptime > stdioStableAfter {
+		st.failures = 0
+	}
+	st.failures++
+	backoff := stdioBackoffBase << (st.failures - 1)
+	if backoff > stdioBackoffMax || backoff <= 0 {
+		backoff = stdioBackoffMax
+	}
+	st.nextAttempt = time.Now().Add(backoff)
+	failures := st.failures
+	tail := lastLines(string(st.stderr), 5)
+	s.mu.Unlock()
+
+	reason := fmt.Sprintf("stdio process exited after %s (crash #%d)", uptime.Round(time.Second), failures)
+	if tail != "" {
+		reason += ": " + tail
+	}
+	logrus.Warnf("[MCPAdapter] %s: %s", server.Name, reason)
+
+	restartAfter := backoff
+	if failures > stdioMaxAutoRestart {
+		logrus.Errorf("[MCPAdapter] stdio server %s is crash looping, giving up auto-restart", server.Name)
+		restartAfter = -1
+	}
+	s.notifyExit(server, proc, reason, restartAfter)
+}
+
+func (s *stdioSupervisor) notifyExit(server domainMCP.MCPServer, proc *stdioProcess, reason string, restartAfter time.Duration) {
+	if s.onExit != nil {
+		s.onExit(server, proc, reason, restartAfter)
+	}
+}
+
+func lastLines(text string, n int) string {
+	lines := strings.Split(strings.TrimSpace(text), "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Join(lines, " | ")
+}
@@ -0,0 +1,43 @@
+//go:build linux
+
+package infrastructure
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+	"syscall"
+)
+
+// applySandboxAttrs coloca al hijo en su propio grupo de procesos para poder
+// matar también a los nietos (npx, uvx, shells...).
+func applySandboxAttrs(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+// rlimitCommand envuelve el comando en un sh que fija los límites de CPU y memoria
+// y luego hace exec: el servidor arranca ya limitado y sus hijos heredan los límites.
+func rlimitCommand(command string, args []string, limits stdioLimits) (string, []string, error) {
+	var ulimits []string
+	if limits.CPUSeconds > 0 {
+		ulimits = append(ulimits, fmt.Sprintf("ulimit -t %d", limits.CPUSeconds))
+	}
+	if limits.MemoryBytes > 0 {
+		ulimits = append(ulimits, fmt.Sprintf("ulimit -v %d", limits.MemoryBytes/1024))
+	}
+	if len(ulimits) == 0 {
+		return command, args, nil
+	}
+
+	// El PATH del hijo puede no estar en la allowlist: resolvemos aquí la ruta absoluta
+	path, err := exec.LookPath(command)
+	if err != nil {
+		return "", nil, fmt.Errorf("command not found: %s", command)
+	}
+	script := strings.Join(ulimits, " && ") + ` && exec "$0" "$@"`
+	return "/bin/sh", append([]string{"-c", script, path}, args...), nil
+}
+
+func killProcessGroup(pid int) {
+	_ = syscall.Kill(-pid, syscall.SIGKILL)
+}
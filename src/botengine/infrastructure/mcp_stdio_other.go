@@ -0,0 +1,25 @@
+//go:build !linux
+
+package infrastructure
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+)
+
+func applySandboxAttrs(cmd *exec.Cmd) {}
+
+// rlimitCommand no está soportado fuera de Linux: con límites configurados el servidor no arranca.
+func rlimitCommand(command string, args []string, limits stdioLimits) (string, []string, error) {
+	if limits.CPUSeconds > 0 || limits.MemoryBytes > 0 {
+		return command, args, fmt.Errorf("rlimits are only enforced on linux")
+	}
+	return command, args, nil
+}
+
+func killProcessGroup(pid int) {
+	if p, err := os.FindProcess(pid); err == nil {
+		_ = p.Kill()
+	}
+}
@@ -0,0 +1,154 @@
+package infrastructure
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	domainMCP "github.com/AzielCF/az-wap/botengine/domain/mcp"
+)
+
+func TestCheckArgs_Jail(t *testing.T) {
+	jail := "/srv/mcp/abc"
+
+	ok := [][]string{
+		{"-y", "@scope/server"},
+		{"--root=/srv/mcp/abc/data"},
+		{"/srv/mcp/abc"},
+		{"data/file.txt"},
+	}
+	for _, args := range ok {
+		if err := checkArgs(jail, args); err != nil {
+			t.Fatalf("checkArgs(%v) unexpected error: %v", args, err)
+		}
+	}
+
+	bad := [][]string{
+		{"/etc/passwd"},
+		{"--root=/home/user"},
+		{"../other/secret"},
+		{"/srv/mcp/abc-evil/data"},
+	}
+	for _, args := range bad {
+		if err := checkArgs(jail, args); err == nil {
+			t.Fatalf("checkArgs(%v) expected error, got nil", args)
+		}
+	}
+}
+
+func TestStdioSupervisor_BuildEnvAllowlist(t *testing.T) {
+	t.Setenv("MCP_TEST_ALLOWED", "yes")
+	t.Setenv("MCP_TEST_SECRET", "nope")
+
+	sup := newStdioSupervisor(stdioLimits{
+		EnvAllowlist: []string{"MCP_TEST_ALLOWED", "HOME"},
+		ServerEnv:    []string{"API_KEY", "*_TOKEN"},
+	})
+	serverEnv := map[string]string{"API_KEY": "k", "GITHUB_TOKEN": "t", "LD_PRELOAD": "/tmp/evil.so", "HOME": "/root"}
+	env := strings.Join(sup.buildEnv("/jail", domainMCP.MCPServer{Env: serverEnv}), "\n")
+
+	for _, want := range []string{"MCP_TEST_ALLOWED=yes", "API_KEY=k", "GITHUB_TOKEN=t", "HOME=/jail"} {
+		if !strings.Contains(env, want) {
+			t.Fatalf("buildEnv() missing %q in:\n%s", want, env)
+		}
+	}
+	for _, leaked := range []string{"MCP_TEST_SECRET", "LD_PRELOAD", "HOME=/root"} {
+		if strings.Contains(env, leaked) {
+			t.Fatalf("buildEnv() leaked a non-allowlisted variable %q:\n%s", leaked, env)
+		}
+	}
+	if err := sup.checkServerEnv(serverEnv); err == nil {
+		t.Fatalf("checkServerEnv() expected error for LD_PRELOAD, got nil")
+	}
+}
+
+func TestRlimitCommand_AppliesLimitsBeforeExec(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("rlimits are only enforced on linux")
+	}
+	if _, err := os.Stat("/bin/sh"); err != nil {
+		t.Skip("sh not available")
+	}
+
+	command, args, err := rlimitCommand("sh", []string{"-c", "ulimit -t; ulimit -v"}, stdioLimits{CPUSeconds: 7, MemoryBytes: 512 * 1024 * 1024})
+	if err != nil {
+		t.Fatalf("rlimitCommand() unexpected error: %v", err)
+	}
+	out, err := exec.Command(command, args...).Output()
+	if err != nil {
+		t.Fatalf("wrapped command failed: %v", err)
+	}
+	if got := strings.Fields(string(out)); len(got) != 2 || got[0] != "7" || got[1] != "524288" {
+		t.Fatalf("limits seen by the child = %q, want [7 524288]", got)
+	}
+}
+
+func TestStdioSupervisor_DisabledByDefault(t *testing.T) {
+	sup := newStdioSupervisor(stdioLimits{})
+	if err := sup.Preflight(domainMCP.MCPServer{ID: "x", Command: "sh"}); err == nil {
+		t.Fatalf("Preflight() expected error when stdio is disabled, got nil")
+	}
+}
+
+func TestStdioSupervisor_RefusesWithoutLimits(t *testing.T) {
+	if runtime.GOOS == "linux" {
+		t.Skip("rlimits are enforced on linux")
+	}
+	sup := newStdioSupervisor(stdioLimits{Enabled: true, WorkDirRoot: filepath.Join(t.TempDir(), "jail"), CPUSeconds: 5})
+	if err := sup.Preflight(domainMCP.MCPServer{ID: "x", Command: "sh"}); err == nil {
+		t.Fatalf("Preflight() expected error when limits cannot be applied, got nil")
+	}
+}
+
+func TestStdioSupervisor_CrashCapturesStderrAndBacksOff(t *testing.T) {
+	if _, err := os.Stat("/bin/sh"); err != nil {
+		t.Skip("sh not available")
+	}
+
+	sup := newStdioSupervisor(stdioLimits{
+		Enabled:     true,
+		WorkDirRoot: filepath.Join(t.TempDir(), "jail"),
+	})
+
+	type exitInfo struct {
+		reason  string
+		restart time.Duration
+	}
+	exited := make(chan exitInfo, 1)
+	sup.onExit = func(_ domainMCP.MCPServer, _ *stdioProcess, reason string, restartAfter time.Duration) {
+		exited <- exitInfo{reason, restartAfter}
+	}
+
+	server := domainMCP.MCPServer{
+		ID:      "crashy",
+		Name:    "crashy",
+		Type:    domainMCP.ConnTypeStdio,
+		Command: "sh",
+		Args:    []string{"-c", "echo boom >&2; exit 3"},
+	}
+	c, _, err := sup.Start(server)
+	if err != nil {
+		t.Fatalf("Start() unexpected error: %v", err)
+	}
+	defer c.Close()
+
+	select {
+	case info := <-exited:
+		if !strings.Contains(info.reason, "boom") {
+			t.Fatalf("exit reason %q does not include stderr tail", info.reason)
+		}
+		if info.restart != stdioBackoffBase {
+			t.Fatalf("first restart backoff = %s, want %s", info.restart, stdioBackoffBase)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("supervisor did not report the crash")
+	}
+
+	if _, _, err := sup.Start(server); err == nil || !strings.Contains(err.Error(), "backing off") {
+		t.Fatalf("Start() during backoff expected backoff error, got %v", err)
+	}
+}
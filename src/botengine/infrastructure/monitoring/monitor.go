@@ -44,6 +44,11 @@ func startDistributedSubscriber() {
 				if e.ServerID == localID {
 					return
 				}
+				// Borrado de datos personales pedido por otro nodo
+				if e.Stage == purgeStage {
+					defaultMonitor.purge(splitChatKeys(e.Metadata["chat_keys"]))
+					return
+				}
 				// Record locally without re-publishing
 				defaultMonitor.recordInternal(e, false)
 			}
@@ -99,7 +104,42 @@ func New(size int) *Monitor {
 }
 
 func (m *Monitor) Record(e Event) {
-	m.recordInternal(e, true)
+	m.recordInternal(withTraceTags(e), true)
+}
+
+// traceTags guarda etiquetas que se añaden a todos los eventos de una traza (ej. versión del bot)
+var traceTags sync.Map // traceID -> map[string]string
+
+// TagTrace etiqueta los eventos que se registren para la traza hasta llamar a UntagTrace
+func TagTrace(traceID string, tags map[string]string) {
+	if traceID == "" || len(tags) == 0 {
+		return
+	}
+	traceTags.Store(traceID, tags)
+}
+
+// UntagTrace deja de etiquetar los eventos de la traza
+func UntagTrace(traceID string) {
+	traceTags.Delete(traceID)
+}
+
+func withTraceTags(e Event) Event {
+	v, ok := traceTags.Load(e.TraceID)
+	if !ok {
+		return e
+	}
+	// Copia: los llamadores suelen reutilizar el mapa de metadatos entre eventos
+	md := make(map[string]string, len(e.Metadata)+2)
+	for k, val := range e.Metadata {
+		md[k] = val
+	}
+	for k, val := range v.(map[string]string) {
+		if _, exists := md[k]; !exists {
+			md[k] = val
+		}
+	}
+	e.Metadata = md
+	return e
 }
 
 func (m *Monitor) recordInternal(e Event, publish bool) {
@@ -0,0 +1,121 @@
+package monitoring
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/AzielCF/az-wap/core/pkg/utils"
+)
+
+// purgeStage es un evento de control: pide a los demás nodos que borren los eventos de ciertos chats
+const purgeStage = "purge"
+
+// EventsFor devuelve los eventos retenidos de los chats indicados (ver utils.ChatKey)
+func EventsFor(chatKeys []string) []Event {
+	keys := chatKeySet(chatKeys)
+	if len(keys) == 0 {
+		return nil
+	}
+	var res []Event
+	for _, e := range defaultMonitor.GetStats().RecentEvents {
+		if keys[utils.ChatKey(e.ChatJID)] {
+			res = append(res, e)
+		}
+	}
+	return res
+}
+
+// Purge borra del buffer los eventos de los chats indicados, en este nodo y en el resto del cluster.
+// Los contadores agregados no se tocan: no contienen datos personales.
+func Purge(chatKeys []string) int {
+	removed := defaultMonitor.purge(chatKeys)
+	if vkClient != nil && len(chatKeys) > 0 {
+		e := Event{
+			Timestamp: time.Now().UTC(),
+			ServerID:  localID,
+			Stage:     purgeStage,
+			Metadata:  map[string]string{"chat_keys": strings.Join(chatKeys, ",")},
+		}
+		data, _ := json.Marshal(e)
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		cmd := vkClient.Inner().B().Publish().Channel(eventChan).Message(string(data)).Build()
+		_ = vkClient.Inner().Do(ctx, cmd)
+	}
+	return removed
+}
+
+func (m *Monitor) purge(chatKeys []string) int {
+	keys := chatKeySet(chatKeys)
+	if len(keys) == 0 {
+		return 0
+	}
+
+	m.eventsMu.Lock()
+	defer m.eventsMu.Unlock()
+
+	kept := make([]Event, 0, m.count)
+	start := (m.idx - m.count) % len(m.events)
+	if start < 0 {
+		start += len(m.events)
+	}
+	for i := 0; i < m.count; i++ {
+		e := m.events[(start+i)%len(m.events)]
+		if keys[utils.ChatKey(e.ChatJID)] {
+			continue
+		}
+		kept = append(kept, e)
+	}
+	removed := m.count - len(kept)
+	if removed == 0 {
+		return 0
+	}
+
+	m.events = make([]Event, len(m.events))
+	copy(m.events, kept)
+	m.count = len(kept)
+	m.idx = len(kept) % len(m.events)
+	return removed
+}
+
+func chatKeySet(chatKeys []string) map[string]bool {
+	keys := make(map[string]bool, len(chatKeys))
+	for _, k := range chatKeys {
+		if k = utils.ChatKey(strings.TrimSpace(k)); k != "" {
+			keys[k] = true
+		}
+	}
+	return keys
+}
+
+func splitChatKeys(raw string) []string {
+	if raw == "" {
+		return nil
+	}
+	return strings.Split(raw, ",")
+}
+
+// ChatEvents expone el buffer de eventos a las solicitudes de datos personales
+// (ver clients/application.ChatEventStore)
+type ChatEvents struct{}
+
+func (ChatEvents) ExportChatEvents(chatKeys []string) (any, int) {
+	events := EventsFor(chatKeys)
+	return events, len(events)
+}
+
+func (ChatEvents) PurgeChatEvents(chatKeys []string) int {
+	return Purge(chatKeys)
+}
+
+func (ChatEvents) CountChatEvents(chatKeys []string) int {
+	return len(EventsFor(chatKeys))
+}
+
+// Clustered indica si otros nodos tienen su propio buffer: la purga se les pide por Valkey,
+// pero desde aquí solo se puede comprobar el buffer local
+func (ChatEvents) Clustered() bool {
+	return vkClient != nil
+}
@@ -0,0 +1,92 @@
+package rest
+
+import (
+	"fmt"
+
+	"github.com/AzielCF/az-wap/botengine/domain"
+	pkgError "github.com/AzielCF/az-wap/core/pkg/error"
+	"github.com/AzielCF/az-wap/core/pkg/utils"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Approval expone la cola de llamadas de herramientas retenidas para su revisión por un operador.
+// Usa el engine registrado con SetBotEngine.
+type Approval struct{}
+
+type decideApprovalRequest struct {
+	DecidedBy string `json:"decided_by"`
+}
+
+func InitRestApproval(app fiber.Router) Approval {
+	rest := Approval{}
+
+	group := app.Group("/approvals")
+	group.Get("", rest.ListApprovals)
+	group.Get("/:id", rest.GetApproval)
+	group.Post("/:id/approve", rest.Approve)
+	group.Post("/:id/reject", rest.Reject)
+
+	return rest
+}
+
+func (handler *Approval) ListApprovals(c *fiber.Ctx) error {
+	if engine == nil {
+		return handleMCPError(c, fmt.Errorf("bot engine not initialized"))
+	}
+	approvals := engine.ListApprovals(domain.ApprovalStatus(c.Query("status")))
+	return c.JSON(utils.ResponseData{
+		Status:  200,
+		Code:    "SUCCESS",
+		Message: "Approvals retrieved",
+		Results: approvals,
+	})
+}
+
+func (handler *Approval) GetApproval(c *fiber.Ctx) error {
+	if engine == nil {
+		return handleMCPError(c, fmt.Errorf("bot engine not initialized"))
+	}
+	id := c.Params("id")
+	approval, ok := engine.GetApproval(id)
+	if !ok {
+		return handleMCPError(c, pkgError.NotFoundError(fmt.Sprintf("approval %s not found", id)))
+	}
+	return c.JSON(utils.ResponseData{
+		Status:  200,
+		Code:    "SUCCESS",
+		Message: "Approval retrieved",
+		Results: approval,
+	})
+}
+
+func (handler *Approval) Approve(c *fiber.Ctx) error {
+	return handler.decide(c, true)
+}
+
+func (handler *Approval) Reject(c *fiber.Ctx) error {
+	return handler.decide(c, false)
+}
+
+func (handler *Approval) decide(c *fiber.Ctx, approved bool) error {
+	if engine == nil {
+		return handleMCPError(c, fmt.Errorf("bot engine not initialized"))
+	}
+	var req decideApprovalRequest
+	_ = c.BodyParser(&req) // El cuerpo es opcional
+
+	approval, err := engine.DecideApproval(c.UserContext(), c.Params("id"), approved, req.DecidedBy)
+	if err != nil {
+		return handleMCPError(c, err)
+	}
+
+	message := "Tool call rejected"
+	if approved {
+		message = "Tool call approved"
+	}
+	return c.JSON(utils.ResponseData{
+		Status:  200,
+		Code:    "SUCCESS",
+		Message: message,
+		Results: approval,
+	})
+}
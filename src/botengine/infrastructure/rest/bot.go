@@ -85,6 +85,13 @@ func InitRestBot(app fiber.Router, service domainBot.IBotUsecase, mcpService dom
 	app.Post("/bots/:id/memory/clear", rest.ClearMemory)
 	app.Get("/bots/config/models", rest.ListModels)
 
+	// Revisiones de configuración y métricas por versión
+	app.Get("/bots/:id/revisions", rest.ListRevisions)
+	app.Get("/bots/:id/revisions/:number", rest.GetRevision)
+	app.Post("/bots/:id/revisions/:number/rollback", rest.RollbackRevision)
+	app.Get("/bots/:id/versions/stats", rest.VersionStats)
+	app.Post("/bots/:id/versions/feedback", rest.RecordVersionFeedback)
+
 	// Bot-MCP relations
 	app.Get("/bots/:id/mcp", rest.ListBotMCPs)
 	app.Post("/bots/:id/mcp", rest.AddBotMCP)
@@ -149,6 +156,10 @@ func (h *Bot) CreateBot(c *fiber.Ctx) error {
 		})
 	}
 
+	if req.Author == "" {
+		req.Author = requestAuthor(c)
+	}
+
 	bot, err := h.Service.Create(c.UserContext(), req)
 	if err != nil {
 		return c.Status(400).JSON(utils.ResponseData{
@@ -196,6 +207,10 @@ func (h *Bot) UpdateBot(c *fiber.Ctx) error {
 		})
 	}
 
+	if req.Author == "" {
+		req.Author = requestAuthor(c)
+	}
+
 	bot, err := h.Service.Update(c.UserContext(), id, req)
 	if err != nil {
 		return c.Status(400).JSON(utils.ResponseData{
@@ -316,6 +331,8 @@ func (h *Bot) HandleWebhook(c *fiber.Ctx) error {
 	ok := botWebhookPool.TryDispatch(msgworker.MessageJob{
 		InstanceID: "bot:" + id,
 		ChatJID:    monitorChatID,
+		Tenant:     "bot:" + id,
+		Lane:       msgworker.LaneInteractive,
 		Handler: func(ctx context.Context) error {
 			defer func() {
 				if r := recover(); r != nil {
@@ -428,6 +445,9 @@ func (h *Bot) UpdateBotMCPConfig(c *fiber.Ctx) error {
 		CustomHeaders map[string]string `json:"custom_headers"`
 		Instructions  string            `json:"instructions"`
 		URLVariables  map[string]string `json:"url_variables"`
+
+		AttachedResources []string `json:"attached_resources"`
+		ReadableResources []string `json:"readable_resources"`
 	}
 	if err := c.BodyParser(&req); err != nil {
 		return c.Status(400).JSON(utils.ResponseData{Status: 400, Message: err.Error()})
@@ -440,6 +460,9 @@ func (h *Bot) UpdateBotMCPConfig(c *fiber.Ctx) error {
 		CustomHeaders: req.CustomHeaders,
 		Instructions:  req.Instructions,
 		URLVariables:  req.URLVariables,
+
+		AttachedResources: req.AttachedResources,
+		ReadableResources: req.ReadableResources,
 	})
 	if err != nil {
 		return c.Status(500).JSON(utils.ResponseData{Status: 500, Message: err.Error()})
@@ -0,0 +1,126 @@
+package rest
+
+import (
+	"strconv"
+
+	domainBot "github.com/AzielCF/az-wap/botengine/domain/bot"
+	pkgError "github.com/AzielCF/az-wap/core/pkg/error"
+	"github.com/AzielCF/az-wap/core/pkg/utils"
+	"github.com/gofiber/fiber/v2"
+)
+
+type rollbackRequest struct {
+	Author string `json:"author"`
+}
+
+type versionFeedbackRequest struct {
+	Revision  int    `json:"revision"` // 0 = revisión vigente
+	VariantID string `json:"variant_id"`
+	Positive  *bool  `json:"positive"`
+}
+
+func (h *Bot) ListRevisions(c *fiber.Ctx) error {
+	revisions, err := h.Service.ListRevisions(c.UserContext(), c.Params("id"))
+	if err != nil {
+		return handleMCPError(c, err)
+	}
+	return c.JSON(utils.ResponseData{
+		Status:  200,
+		Code:    "SUCCESS",
+		Message: "Bot revisions fetched",
+		Results: revisions,
+	})
+}
+
+func (h *Bot) GetRevision(c *fiber.Ctx) error {
+	number, err := strconv.Atoi(c.Params("number"))
+	if err != nil || number <= 0 {
+		return handleMCPError(c, pkgError.ValidationError("number: must be a positive integer."))
+	}
+	revision, err := h.Service.GetRevision(c.UserContext(), c.Params("id"), number)
+	if err != nil {
+		return handleMCPError(c, err)
+	}
+	return c.JSON(utils.ResponseData{
+		Status:  200,
+		Code:    "SUCCESS",
+		Message: "Bot revision fetched",
+		Results: revision,
+	})
+}
+
+// RollbackRevision restaura la configuración de una revisión. El historial no se reescribe:
+// la restauración queda registrada como una revisión nueva.
+func (h *Bot) RollbackRevision(c *fiber.Ctx) error {
+	number, err := strconv.Atoi(c.Params("number"))
+	if err != nil || number <= 0 {
+		return handleMCPError(c, pkgError.ValidationError("number: must be a positive integer."))
+	}
+	var req rollbackRequest
+	_ = c.BodyParser(&req) // El cuerpo es opcional
+	if req.Author == "" {
+		req.Author = requestAuthor(c)
+	}
+
+	bot, err := h.Service.Rollback(c.UserContext(), c.Params("id"), number, req.Author)
+	if err != nil {
+		return handleMCPError(c, err)
+	}
+	return c.JSON(utils.ResponseData{
+		Status:  200,
+		Code:    "SUCCESS",
+		Message: "Bot rolled back to revision " + strconv.Itoa(number),
+		Results: bot,
+	})
+}
+
+func (h *Bot) VersionStats(c *fiber.Ctx) error {
+	stats, err := h.Service.VersionStats(c.UserContext(), c.Params("id"))
+	if err != nil {
+		return handleMCPError(c, err)
+	}
+	return c.JSON(utils.ResponseData{
+		Status:  200,
+		Code:    "SUCCESS",
+		Message: "Bot version stats fetched",
+		Results: stats,
+	})
+}
+
+// RecordVersionFeedback registra la valoración de una respuesta (ej. pulgar arriba/abajo de un operador
+// o de una integración) para comparar versiones junto a costo y latencia.
+func (h *Bot) RecordVersionFeedback(c *fiber.Ctx) error {
+	var req versionFeedbackRequest
+	if err := c.BodyParser(&req); err != nil {
+		return handleMCPError(c, pkgError.ValidationError("invalid request body."))
+	}
+	if req.Positive == nil {
+		return handleMCPError(c, pkgError.ValidationError("positive: is required."))
+	}
+	if req.Revision < 0 {
+		return handleMCPError(c, pkgError.ValidationError("revision: cannot be negative."))
+	}
+
+	err := h.Service.RecordVersionFeedback(c.UserContext(), domainBot.VersionFeedback{
+		BotID:     c.Params("id"),
+		Revision:  req.Revision,
+		VariantID: req.VariantID,
+		Positive:  *req.Positive,
+	})
+	if err != nil {
+		return handleMCPError(c, err)
+	}
+	return c.JSON(utils.ResponseData{
+		Status:  200,
+		Code:    "SUCCESS",
+		Message: "Bot version feedback recorded",
+	})
+}
+
+// requestAuthor identifica al autor de un cambio por el usuario de basic auth
+func requestAuthor(c *fiber.Ctx) string {
+	if user, ok := c.Locals("username").(string); ok {
+		return user
+	}
+	return ""
+}
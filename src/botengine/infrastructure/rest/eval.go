@@ -0,0 +1,237 @@
+package rest
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	domainEval "github.com/AzielCF/az-wap/botengine/domain/eval"
+	pkgError "github.com/AzielCF/az-wap/core/pkg/error"
+	"github.com/AzielCF/az-wap/core/pkg/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// Eval expone las suites de evaluación de un bot y sus ejecuciones.
+// Usa el engine registrado con SetBotEngine.
+type Eval struct {
+	Repo domainEval.IEvalRepository
+}
+
+type runEvalRequest struct {
+	Mode      string `json:"mode"`
+	VariantID string `json:"variant_id"`
+}
+
+func InitRestEval(app fiber.Router, repo domainEval.IEvalRepository) Eval {
+	rest := Eval{Repo: repo}
+
+	group := app.Group("/bots/:id/evals")
+	group.Get("", rest.ListSuites)
+	group.Post("", rest.CreateSuite)
+	group.Get("/:suiteId", rest.GetSuite)
+	group.Put("/:suiteId", rest.UpdateSuite)
+	group.Delete("/:suiteId", rest.DeleteSuite)
+	group.Post("/:suiteId/run", rest.RunSuite)
+	group.Get("/:suiteId/runs", rest.ListRuns)
+	group.Get("/:suiteId/runs/:runId", rest.GetRun)
+
+	return rest
+}
+
+func (h *Eval) ListSuites(c *fiber.Ctx) error {
+	suites, err := h.Repo.ListSuites(c.UserContext(), c.Params("id"))
+	if err != nil {
+		return handleMCPError(c, err)
+	}
+	return c.JSON(utils.ResponseData{
+		Status:  200,
+		Code:    "SUCCESS",
+		Message: "Eval suites fetched",
+		Results: suites,
+	})
+}
+
+func (h *Eval) CreateSuite(c *fiber.Ctx) error {
+	var suite domainEval.Suite
+	if err := c.BodyParser(&suite); err != nil {
+		return handleMCPError(c, pkgError.ValidationError(err.Error()))
+	}
+	suite.ID = uuid.NewString()
+	suite.BotID = c.Params("id")
+	suite.Recording = nil
+	if err := suite.Validate(); err != nil {
+		return handleMCPError(c, pkgError.ValidationError(err.Error()))
+	}
+	suite.CreatedAt = time.Now()
+	suite.UpdatedAt = suite.CreatedAt
+
+	if err := h.Repo.CreateSuite(c.UserContext(), suite); err != nil {
+		return handleMCPError(c, err)
+	}
+	return c.JSON(utils.ResponseData{
+		Status:  200,
+		Code:    "SUCCESS",
+		Message: "Eval suite created",
+		Results: suite,
+	})
+}
+
+func (h *Eval) GetSuite(c *fiber.Ctx) error {
+	suite, err := h.suite(c)
+	if err != nil {
+		return handleMCPError(c, err)
+	}
+	return c.JSON(utils.ResponseData{
+		Status:  200,
+		Code:    "SUCCESS",
+		Message: "Eval suite fetched",
+		Results: suite,
+	})
+}
+
+// UpdateSuite reemplaza los casos. La grabación se conserva solo para los casos que no cambiaron de nombre
+func (h *Eval) UpdateSuite(c *fiber.Ctx) error {
+	current, err := h.suite(c)
+	if err != nil {
+		return handleMCPError(c, err)
+	}
+	var suite domainEval.Suite
+	if err := c.BodyParser(&suite); err != nil {
+		return handleMCPError(c, pkgError.ValidationError(err.Error()))
+	}
+	if err := suite.Validate(); err != nil {
+		return handleMCPError(c, pkgError.ValidationError(err.Error()))
+	}
+	suite.ID = current.ID
+	suite.BotID = current.BotID
+	suite.CreatedAt = current.CreatedAt
+	suite.UpdatedAt = time.Now()
+	suite.Recording = domainEval.Recording{}
+	for _, cs := range suite.Cases {
+		if tape, ok := current.Recording[cs.Name]; ok {
+			suite.Recording[cs.Name] = tape
+		}
+	}
+
+	if err := h.Repo.UpdateSuite(c.UserContext(), suite); err != nil {
+		return handleMCPError(c, err)
+	}
+	return c.JSON(utils.ResponseData{
+		Status:  200,
+		Code:    "SUCCESS",
+		Message: "Eval suite updated",
+		Results: suite,
+	})
+}
+
+func (h *Eval) DeleteSuite(c *fiber.Ctx) error {
+	suite, err := h.suite(c)
+	if err != nil {
+		return handleMCPError(c, err)
+	}
+	if err := h.Repo.DeleteSuite(c.UserContext(), suite.ID); err != nil {
+		return handleMCPError(c, err)
+	}
+	return c.JSON(utils.ResponseData{
+		Status:  200,
+		Code:    "SUCCESS",
+		Message: "Eval suite deleted",
+	})
+}
+
+// RunSuite ejecuta la suite de forma síncrona y guarda el resultado.
+// En modo record la grabación nueva reemplaza la de los casos ejecutados.
+func (h *Eval) RunSuite(c *fiber.Ctx) error {
+	if engine == nil {
+		return handleMCPError(c, fmt.Errorf("bot engine not initialized"))
+	}
+	suite, err := h.suite(c)
+	if err != nil {
+		return handleMCPError(c, err)
+	}
+	var req runEvalRequest
+	_ = c.BodyParser(&req) // El cuerpo es opcional
+	mode, err := domainEval.ParseMode(req.Mode)
+	if err != nil {
+		return handleMCPError(c, pkgError.ValidationError(err.Error()))
+	}
+
+	ctx := c.UserContext()
+	run, recording, err := engine.RunEvalSuite(ctx, suite, domainEval.RunOptions{Mode: mode, VariantID: req.VariantID})
+	if err != nil {
+		return handleMCPError(c, err)
+	}
+	if len(recording) > 0 {
+		if suite.Recording == nil {
+			suite.Recording = domainEval.Recording{}
+		}
+		for name, tape := range recording {
+			suite.Recording[name] = tape
+		}
+		suite.UpdatedAt = time.Now()
+		if err := h.Repo.UpdateSuite(ctx, suite); err != nil {
+			return handleMCPError(c, err)
+		}
+	}
+	if err := h.Repo.SaveRun(ctx, run); err != nil {
+		return handleMCPError(c, err)
+	}
+
+	return c.JSON(utils.ResponseData{
+		Status:  200,
+		Code:    "SUCCESS",
+		Message: fmt.Sprintf("Eval finished: %d passed, %d failed", run.Passed, run.Failed),
+		Results: run,
+	})
+}
+
+func (h *Eval) ListRuns(c *fiber.Ctx) error {
+	suite, err := h.suite(c)
+	if err != nil {
+		return handleMCPError(c, err)
+	}
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	runs, err := h.Repo.ListRuns(c.UserContext(), suite.ID, limit)
+	if err != nil {
+		return handleMCPError(c, err)
+	}
+	return c.JSON(utils.ResponseData{
+		Status:  200,
+		Code:    "SUCCESS",
+		Message: "Eval runs fetched",
+		Results: runs,
+	})
+}
+
+func (h *Eval) GetRun(c *fiber.Ctx) error {
+	suite, err := h.suite(c)
+	if err != nil {
+		return handleMCPError(c, err)
+	}
+	run, err := h.Repo.GetRun(c.UserContext(), c.Params("runId"))
+	if err == nil && run.SuiteID != suite.ID {
+		err = pkgError.NotFoundError("eval run not found")
+	}
+	if err != nil {
+		return handleMCPError(c, err)
+	}
+	return c.JSON(utils.ResponseData{
+		Status:  200,
+		Code:    "SUCCESS",
+		Message: "Eval run fetched",
+		Results: run,
+	})
+}
+
+// suite carga la suite de la ruta comprobando que pertenece al bot
+func (h *Eval) suite(c *fiber.Ctx) (domainEval.Suite, error) {
+	suite, err := h.Repo.GetSuite(c.UserContext(), c.Params("suiteId"))
+	if err != nil {
+		return domainEval.Suite{}, err
+	}
+	if suite.BotID != c.Params("id") {
+		return domainEval.Suite{}, pkgError.NotFoundError("eval suite not found")
+	}
+	return suite, nil
+}
@@ -23,6 +23,8 @@ func InitRestMCP(app fiber.Router, service domainMCP.IMCPUsecase) MCP {
 	group.Post("/servers/:id/validate", rest.ValidateServerConfig)
 
 	group.Get("/servers/:id/tools", rest.ListTools)
+	group.Get("/servers/:id/resources", rest.ListResources)
+	group.Get("/servers/:id/prompts", rest.ListPrompts)
 
 	return rest
 }
@@ -143,6 +145,32 @@ func (handler *MCP) ListTools(c *fiber.Ctx) error {
 	})
 }
 
+func (handler *MCP) ListResources(c *fiber.Ctx) error {
+	id := c.Params("id")
+	resources, err := handler.Service.ListResources(c.UserContext(), id)
+	if err != nil {
+		return handleMCPError(c, err)
+	}
+	return c.JSON(utils.ResponseData{
+		Status:  200,
+		Code:    "SUCCESS",
+		Results: resources,
+	})
+}
+
+func (handler *MCP) ListPrompts(c *fiber.Ctx) error {
+	id := c.Params("id")
+	prompts, err := handler.Service.ListPrompts(c.UserContext(), id)
+	if err != nil {
+		return handleMCPError(c, err)
+	}
+	return c.JSON(utils.ResponseData{
+		Status:  200,
+		Code:    "SUCCESS",
+		Results: prompts,
+	})
+}
+
 func handleMCPError(c *fiber.Ctx, err error) error {
 	status := 500
 	code := "INTERNAL_SERVER_ERROR"
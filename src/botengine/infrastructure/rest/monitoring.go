@@ -2,9 +2,11 @@ package rest
 
 import (
 	"net/url"
+	"strconv"
 
 	botdomain "github.com/AzielCF/az-wap/botengine/domain"
 	botmonitor "github.com/AzielCF/az-wap/botengine/infrastructure/monitoring"
+	"github.com/AzielCF/az-wap/core/pkg/msgworker"
 	"github.com/AzielCF/az-wap/workspace"
 	"github.com/AzielCF/az-wap/workspace/domain/monitoring"
 	"github.com/gofiber/fiber/v2"
@@ -28,6 +30,10 @@ func InitRestMonitoring(app fiber.Router, store monitoring.MonitoringStore, wm *
 	g.Get("/stats", h.GetGlobalStats)
 	g.Get("/typing", h.GetTypingStatus)
 
+	// Cola de jobs de mensajes (pool global de este nodo)
+	g.Get("/job-queue", h.GetJobQueueStats)
+	g.Get("/job-queue/quarantine", h.GetQuarantinedJobs)
+
 	// Feed de eventos (mantenemos monitoring por ahora para el log de eventos recientes)
 	g.Get("/events", h.GetRecentEvents)
 
@@ -62,6 +68,20 @@ func (h *MonitoringHandler) GetGlobalStats(c *fiber.Ctx) error {
 	return c.JSON(stats)
 }
 
+func (h *MonitoringHandler) GetJobQueueStats(c *fiber.Ctx) error {
+	return c.JSON(msgworker.GetGlobalStats())
+}
+
+// GetQuarantinedJobs lista los jobs que agotaron sus reintentos (?limit=, por defecto 100)
+func (h *MonitoringHandler) GetQuarantinedJobs(c *fiber.Ctx) error {
+	limit, _ := strconv.Atoi(c.Query("limit", "100"))
+	jobs, err := msgworker.GetGlobalPool().Quarantined(c.UserContext(), limit)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(jobs)
+}
+
 func (h *MonitoringHandler) GetRecentEvents(c *fiber.Ctx) error {
 	// Obtenemos los eventos de monitoring (log en vivo)
 	stats := botmonitor.GetStats()
@@ -71,6 +71,15 @@ Return ONLY a JSON object with these fields: pace, focus, work, acknowledgement,
 
 // Chat implementa la interfaz AIProvider enviando una petición a la API de Gemini
 func (p *GeminiProvider) Chat(ctx context.Context, b domainBot.Bot, req domain.ChatRequest) (domain.ChatResponse, error) {
+	return p.chat(ctx, b, req, nil)
+}
+
+// ChatStream implementa domain.StreamingProvider: emite el texto y las herramientas a medida que llegan
+func (p *GeminiProvider) ChatStream(ctx context.Context, b domainBot.Bot, req domain.ChatRequest, onEvent domain.StreamHandler) (domain.ChatResponse, error) {
+	return p.chat(ctx, b, req, onEvent)
+}
+
+func (p *GeminiProvider) chat(ctx context.Context, b domainBot.Bot, req domain.ChatRequest, onEvent domain.StreamHandler) (domain.ChatResponse, error) {
 	if b.APIKey == "" {
 		return domain.ChatResponse{}, fmt.Errorf("bot %s has no API key", b.ID)
 	}
@@ -336,7 +345,12 @@ func (p *GeminiProvider) Chat(ctx context.Context, b domainBot.Bot, req domain.C
 	}
 	userTokens := p.estimateTokens(textToEstimate)
 
-	result, err := p.generateContentWithRetry(ctx, client, model, contents, genConfig)
+	var result *genai.GenerateContentResponse
+	if onEvent != nil {
+		result, err = p.generateContentStream(ctx, client, model, contents, genConfig, onEvent)
+	} else {
+		result, err = p.generateContentWithRetry(ctx, client, model, contents, genConfig)
+	}
 	if err != nil {
 		return domain.ChatResponse{}, err
 	}
@@ -910,6 +924,66 @@ func (p *GeminiProvider) generateContentWithRetry(ctx context.Context, client *g
 	return nil, fmt.Errorf("max retries exceeded")
 }
 
+// generateContentStream consume la respuesta en streaming, emite los deltas y la reconstruye como una
+// respuesta única para que el resto del flujo (costos, herramientas, RawContent) no cambie.
+// Solo se reintenta un 503 si todavía no se emitió nada.
+func (p *GeminiProvider) generateContentStream(ctx context.Context, client *genai.Client, model string, contents []*genai.Content, cfg *genai.GenerateContentConfig, onEvent domain.StreamHandler) (*genai.GenerateContentResponse, error) {
+	for i := 0; i < 3; i++ {
+		merged := &genai.Candidate{Content: &genai.Content{Role: genai.RoleModel}}
+		result := &genai.GenerateContentResponse{Candidates: []*genai.Candidate{merged}}
+		emitted := false
+		var streamErr error
+
+		for chunk, err := range client.Models.GenerateContentStream(ctx, model, contents, cfg) {
+			if err != nil {
+				streamErr = err
+				break
+			}
+			if chunk.UsageMetadata != nil {
+				result.UsageMetadata = chunk.UsageMetadata
+			}
+			if len(chunk.Candidates) == 0 {
+				continue
+			}
+			c := chunk.Candidates[0]
+			if c.FinishReason != "" {
+				merged.FinishReason = c.FinishReason
+			}
+			if len(c.SafetyRatings) > 0 {
+				merged.SafetyRatings = c.SafetyRatings
+			}
+			if c.Content == nil {
+				continue
+			}
+			for _, part := range c.Content.Parts {
+				merged.Content.Parts = append(merged.Content.Parts, part)
+				switch {
+				case part.FunctionCall != nil:
+					emitted = true
+					onEvent(domain.StreamEvent{Type: domain.StreamEventToolCall, ToolCall: &domain.ToolCall{
+						ID:   part.FunctionCall.ID,
+						Name: part.FunctionCall.Name,
+						Args: part.FunctionCall.Args,
+					}})
+				case part.Text != "" && !part.Thought:
+					emitted = true
+					onEvent(domain.StreamEvent{Type: domain.StreamEventText, Text: part.Text})
+				}
+			}
+		}
+
+		if streamErr == nil {
+			return result, nil
+		}
+		if !emitted && strings.Contains(streamErr.Error(), "503") {
+			time.Sleep(time.Duration(1<<uint(i)) * time.Second)
+			continue
+		}
+		return nil, streamErr
+	}
+	return nil, fmt.Errorf("max retries exceeded")
+}
+
 func (p *GeminiProvider) applyThinking(cfg *genai.GenerateContentConfig, model string, mode string) {
 	if cfg == nil || model == "" {
 		return
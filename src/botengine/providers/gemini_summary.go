@@ -0,0 +1,50 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	domain "github.com/AzielCF/az-wap/botengine/domain"
+	domainBot "github.com/AzielCF/az-wap/botengine/domain/bot"
+	"google.golang.org/genai"
+)
+
+// SummarizeHistory implements domain.HistorySummarizer with the bot's cheap (mindset) model.
+func (p *GeminiProvider) SummarizeHistory(ctx context.Context, b domainBot.Bot, previous string, turns []domain.ChatTurn, language string) (string, *domain.UsageStats, error) {
+	if b.APIKey == "" {
+		return "", nil, fmt.Errorf("bot %s has no API key", b.ID)
+	}
+
+	client, err := genai.NewClient(ctx, &genai.ClientConfig{
+		APIKey:  b.APIKey,
+		Backend: genai.BackendGeminiAPI,
+	})
+	if err != nil {
+		return "", nil, err
+	}
+
+	model := b.MindsetModel
+	if model == "" {
+		model = domainBot.DefaultGeminiLiteModel
+	}
+
+	cfg := &genai.GenerateContentConfig{}
+	p.applyThinking(cfg, model, "off")
+
+	contents := []*genai.Content{{Role: "user", Parts: []*genai.Part{{Text: domain.SummaryPrompt(previous, turns, language)}}}}
+	result, err := p.generateContentWithRetry(ctx, client, model, contents, cfg)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var usage *domain.UsageStats
+	if result.UsageMetadata != nil {
+		usage = p.extractUsage(model, result.UsageMetadata)
+	}
+	summary := strings.TrimSpace(result.Text())
+	if summary == "" {
+		return "", usage, fmt.Errorf("gemini returned an empty summary")
+	}
+	return summary, usage, nil
+}
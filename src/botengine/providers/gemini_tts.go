@@ -0,0 +1,106 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	domain "github.com/AzielCF/az-wap/botengine/domain"
+	domainBot "github.com/AzielCF/az-wap/botengine/domain/bot"
+	"google.golang.org/genai"
+)
+
+const (
+	defaultGeminiVoice   = "Kore"
+	geminiTTSSampleRate  = 24000
+	geminiTTSModalityOut = "AUDIO"
+)
+
+// Synthesize implements domain.SpeechSynthesizer using Gemini native audio output.
+// Gemini devuelve PCM L16 crudo, así que se envuelve en WAV para que ffmpeg lo pueda convertir.
+func (p *GeminiProvider) Synthesize(ctx context.Context, apiKey string, req domain.SpeechRequest) (*domain.SpeechAudio, error) {
+	if apiKey == "" {
+		return nil, fmt.Errorf("speech synthesis requires an API key")
+	}
+
+	client, err := genai.NewClient(ctx, &genai.ClientConfig{
+		APIKey:  apiKey,
+		Backend: genai.BackendGeminiAPI,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	model := req.Model
+	if model == "" {
+		model = domainBot.DefaultGeminiSpeechModel
+	}
+	voice := req.Voice
+	if voice == "" {
+		voice = defaultGeminiVoice
+	}
+
+	// El modelo TTS no tiene parámetro de velocidad; se le indica en el propio prompt
+	prompt := req.Text
+	switch {
+	case req.Speed >= 1.2:
+		prompt = "Say quickly: " + req.Text
+	case req.Speed > 0 && req.Speed <= 0.8:
+		prompt = "Say slowly and calmly: " + req.Text
+	}
+
+	cfg := &genai.GenerateContentConfig{
+		ResponseModalities: []string{geminiTTSModalityOut},
+		SpeechConfig: &genai.SpeechConfig{
+			VoiceConfig: &genai.VoiceConfig{
+				PrebuiltVoiceConfig: &genai.PrebuiltVoiceConfig{VoiceName: voice},
+			},
+			LanguageCode: req.Language,
+		},
+	}
+
+	result, err := p.generateContentWithRetry(ctx, client, model, []*genai.Content{genai.NewContentFromText(prompt, genai.RoleUser)}, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	var blob *genai.Blob
+	if len(result.Candidates) > 0 && result.Candidates[0].Content != nil {
+		for _, part := range result.Candidates[0].Content.Parts {
+			if part.InlineData != nil && len(part.InlineData.Data) > 0 {
+				blob = part.InlineData
+				break
+			}
+		}
+	}
+	if blob == nil {
+		return nil, fmt.Errorf("gemini returned no audio")
+	}
+
+	audio := &domain.SpeechAudio{Data: blob.Data, MimeType: blob.MIMEType}
+	if strings.HasPrefix(strings.ToLower(blob.MIMEType), "audio/l16") || strings.Contains(blob.MIMEType, "pcm") {
+		rate := pcmSampleRate(blob.MIMEType)
+		audio.Data = wavFromPCM(blob.Data, rate, 1)
+		audio.MimeType = "audio/wav"
+		audio.Duration = time.Duration(len(blob.Data)/2) * time.Second / time.Duration(rate)
+	}
+	if result.UsageMetadata != nil {
+		audio.Usage = p.extractUsage(model, result.UsageMetadata)
+	}
+	return audio, nil
+}
+
+// pcmSampleRate extrae el rate de un mime tipo "audio/L16;codec=pcm;rate=24000"
+func pcmSampleRate(mimeType string) int {
+	for _, param := range strings.Split(mimeType, ";") {
+		key, value, ok := strings.Cut(strings.TrimSpace(param), "=")
+		if ok && strings.EqualFold(key, "rate") {
+			if rate, err := strconv.Atoi(value); err == nil && rate > 0 {
+				return rate
+			}
+		}
+	}
+	return geminiTTSSampleRate
+}
@@ -0,0 +1,63 @@
+package providers
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"strings"
+	"time"
+
+	domain "github.com/AzielCF/az-wap/botengine/domain"
+)
+
+const localTTSSampleRate = 8000
+
+// LocalSynthesizer is an offline stand-in for tests and development. It produces a silent WAV
+// as long as the text would take to read aloud, so the voice pipeline runs without an API key.
+type LocalSynthesizer struct{}
+
+func NewLocalSynthesizer() *LocalSynthesizer {
+	return &LocalSynthesizer{}
+}
+
+func (s *LocalSynthesizer) Synthesize(ctx context.Context, apiKey string, req domain.SpeechRequest) (*domain.SpeechAudio, error) {
+	if strings.TrimSpace(req.Text) == "" {
+		return nil, fmt.Errorf("nothing to synthesize")
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	duration := domain.EstimateSpeechDuration(req.Text, req.Speed)
+	samples := int(duration * localTTSSampleRate / time.Second)
+
+	return &domain.SpeechAudio{
+		Data:     wavFromPCM(make([]byte, samples*2), localTTSSampleRate, 1),
+		MimeType: "audio/wav",
+		Duration: duration,
+		Usage:    &domain.UsageStats{Model: "local"},
+	}, nil
+}
+
+// wavFromPCM envuelve PCM de 16 bits little-endian en un contenedor WAV
+func wavFromPCM(pcm []byte, sampleRate, channels int) []byte {
+	const bitsPerSample = 16
+	blockAlign := channels * bitsPerSample / 8
+
+	var buf bytes.Buffer
+	buf.WriteString("RIFF")
+	binary.Write(&buf, binary.LittleEndian, uint32(36+len(pcm)))
+	buf.WriteString("WAVEfmt ")
+	binary.Write(&buf, binary.LittleEndian, uint32(16))
+	binary.Write(&buf, binary.LittleEndian, uint16(1)) // PCM
+	binary.Write(&buf, binary.LittleEndian, uint16(channels))
+	binary.Write(&buf, binary.LittleEndian, uint32(sampleRate))
+	binary.Write(&buf, binary.LittleEndian, uint32(sampleRate*blockAlign))
+	binary.Write(&buf, binary.LittleEndian, uint16(blockAlign))
+	binary.Write(&buf, binary.LittleEndian, uint16(bitsPerSample))
+	buf.WriteString("data")
+	binary.Write(&buf, binary.LittleEndian, uint32(len(pcm)))
+	buf.Write(pcm)
+	return buf.Bytes()
+}
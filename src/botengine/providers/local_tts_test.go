@@ -0,0 +1,37 @@
+package providers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/AzielCF/az-wap/botengine/domain"
+	"github.com/AzielCF/az-wap/botengine/domain/bot"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocalSynthesizer_ProducesWavOfEstimatedLength(t *testing.T) {
+	s := NewLocalSynthesizer()
+	audio, err := s.Synthesize(context.Background(), "", domain.SpeechRequest{Text: "Hola, ya revisé tu pedido y sale mañana."})
+	assert.NoError(t, err)
+	assert.Equal(t, "audio/wav", audio.MimeType)
+	assert.Equal(t, "RIFF", string(audio.Data[:4]))
+	assert.GreaterOrEqual(t, audio.Duration, time.Second)
+
+	_, err = s.Synthesize(context.Background(), "", domain.SpeechRequest{Text: "  "})
+	assert.Error(t, err)
+}
+
+func TestVoiceConfig_Wants(t *testing.T) {
+	mirror := (&bot.VoiceConfig{Policy: "Mirror"}).Sanitize()
+	assert.True(t, mirror.Wants(true, false, "hola"))
+	assert.False(t, mirror.Wants(false, true, "hola"))
+
+	ai := &bot.VoiceConfig{Policy: bot.VoiceReplyAI, MaxChars: 5}
+	assert.True(t, ai.Wants(false, true, "hola"))
+	assert.False(t, ai.Wants(false, true, "respuesta demasiado larga"))
+
+	var none *bot.VoiceConfig
+	assert.False(t, none.Wants(true, true, "hola"))
+	assert.Nil(t, (&bot.VoiceConfig{Policy: "sometimes"}).Sanitize())
+}
@@ -29,6 +29,15 @@ func NewOpenAIProvider(mcpService domainMCP.IMCPUsecase) *OpenAIProvider {
 
 // Chat implements the AIProvider interface for OpenAI
 func (p *OpenAIProvider) Chat(ctx context.Context, b domainBot.Bot, req domain.ChatRequest) (domain.ChatResponse, error) {
+	return p.chat(ctx, b, req, nil)
+}
+
+// ChatStream implements domain.StreamingProvider, emitting content deltas and finished tool calls
+func (p *OpenAIProvider) ChatStream(ctx context.Context, b domainBot.Bot, req domain.ChatRequest, onEvent domain.StreamHandler) (domain.ChatResponse, error) {
+	return p.chat(ctx, b, req, onEvent)
+}
+
+func (p *OpenAIProvider) chat(ctx context.Context, b domainBot.Bot, req domain.ChatRequest, onEvent domain.StreamHandler) (domain.ChatResponse, error) {
 	if b.APIKey == "" {
 		return domain.ChatResponse{}, fmt.Errorf("bot %s has no API key", b.ID)
 	}
@@ -135,7 +144,13 @@ func (p *OpenAIProvider) Chat(ctx context.Context, b domainBot.Bot, req domain.C
 	}
 
 	// Execute call
-	completion, err := client.Chat.Completions.New(ctx, params)
+	var completion *openai.ChatCompletion
+	var err error
+	if onEvent != nil {
+		completion, err = p.streamCompletion(ctx, client, params, onEvent)
+	} else {
+		completion, err = client.Chat.Completions.New(ctx, params)
+	}
 	if err != nil {
 		return domain.ChatResponse{}, err
 	}
@@ -176,6 +191,36 @@ func (p *OpenAIProvider) Chat(ctx context.Context, b domainBot.Bot, req domain.C
 	return resp, nil
 }
 
+// streamCompletion runs a streaming completion and accumulates it into a regular ChatCompletion
+func (p *OpenAIProvider) streamCompletion(ctx context.Context, client openai.Client, params openai.ChatCompletionNewParams, onEvent domain.StreamHandler) (*openai.ChatCompletion, error) {
+	params.StreamOptions = openai.ChatCompletionStreamOptionsParam{IncludeUsage: openai.Bool(true)}
+	stream := client.Chat.Completions.NewStreaming(ctx, params)
+	defer stream.Close()
+
+	acc := openai.ChatCompletionAccumulator{}
+	for stream.Next() {
+		chunk := stream.Current()
+		acc.AddChunk(chunk)
+
+		if len(chunk.Choices) > 0 && chunk.Choices[0].Delta.Content != "" {
+			onEvent(domain.StreamEvent{Type: domain.StreamEventText, Text: chunk.Choices[0].Delta.Content})
+		}
+		if tc, ok := acc.JustFinishedToolCall(); ok {
+			var args map[string]any
+			_ = json.Unmarshal([]byte(tc.Arguments), &args)
+			onEvent(domain.StreamEvent{Type: domain.StreamEventToolCall, ToolCall: &domain.ToolCall{
+				ID:   tc.ID,
+				Name: tc.Name,
+				Args: args,
+			}})
+		}
+	}
+	if err := stream.Err(); err != nil {
+		return nil, err
+	}
+	return &acc.ChatCompletion, nil
+}
+
 // Interpret implements the MultimodalInterpreter interface for OpenAI
 func (p *OpenAIProvider) Interpret(ctx context.Context, apiKey string, model string, userText string, language string, medias []*domain.BotMedia) (*domain.MultimodalResult, *domain.UsageStats, error) {
 	if apiKey == "" {
@@ -0,0 +1,40 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	domain "github.com/AzielCF/az-wap/botengine/domain"
+	domainBot "github.com/AzielCF/az-wap/botengine/domain/bot"
+	"github.com/openai/openai-go/v3"
+	"github.com/openai/openai-go/v3/option"
+)
+
+// SummarizeHistory implements domain.HistorySummarizer with the bot's cheap (mindset) model.
+func (p *OpenAIProvider) SummarizeHistory(ctx context.Context, b domainBot.Bot, previous string, turns []domain.ChatTurn, language string) (string, *domain.UsageStats, error) {
+	if b.APIKey == "" {
+		return "", nil, fmt.Errorf("bot %s has no API key", b.ID)
+	}
+
+	client := openai.NewClient(option.WithAPIKey(b.APIKey))
+
+	model := b.MindsetModel
+	if model == "" {
+		model = domainBot.DefaultOpenAIMiniModel
+	}
+
+	completion, err := client.Chat.Completions.New(ctx, openai.ChatCompletionNewParams{
+		Model:    openai.ChatModel(model),
+		Messages: []openai.ChatCompletionMessageParamUnion{openai.UserMessage(domain.SummaryPrompt(previous, turns, language))},
+	})
+	if err != nil {
+		return "", nil, err
+	}
+
+	usage := p.extractUsage(model, completion.Usage)
+	if len(completion.Choices) == 0 || strings.TrimSpace(completion.Choices[0].Message.Content) == "" {
+		return "", usage, fmt.Errorf("openai returned an empty summary")
+	}
+	return strings.TrimSpace(completion.Choices[0].Message.Content), usage, nil
+}
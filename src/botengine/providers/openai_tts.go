@@ -0,0 +1,79 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"unicode/utf8"
+
+	domain "github.com/AzielCF/az-wap/botengine/domain"
+	domainBot "github.com/AzielCF/az-wap/botengine/domain/bot"
+	"github.com/openai/openai-go/v3"
+	"github.com/openai/openai-go/v3/option"
+)
+
+const defaultOpenAIVoice = "alloy"
+
+// Synthesize implements domain.SpeechSynthesizer using the OpenAI speech endpoint.
+// The audio is requested as Opus (OGG), so it can be sent as a voice note without transcoding.
+func (p *OpenAIProvider) Synthesize(ctx context.Context, apiKey string, req domain.SpeechRequest) (*domain.SpeechAudio, error) {
+	if apiKey == "" {
+		return nil, fmt.Errorf("speech synthesis requires an API key")
+	}
+
+	client := openai.NewClient(option.WithAPIKey(apiKey))
+
+	model := req.Model
+	if model == "" {
+		model = domainBot.DefaultOpenAISpeechModel
+	}
+	voice := req.Voice
+	if voice == "" {
+		voice = defaultOpenAIVoice
+	}
+
+	params := openai.AudioSpeechNewParams{
+		Input:          req.Text,
+		Model:          openai.SpeechModel(model),
+		Voice:          openai.AudioSpeechNewParamsVoice(voice),
+		ResponseFormat: openai.AudioSpeechNewParamsResponseFormatOpus,
+	}
+	if req.Speed > 0 {
+		params.Speed = openai.Float(req.Speed)
+	}
+	// Sólo los modelos gpt-4o-*-tts aceptan instrucciones de estilo
+	if req.Language != "" && model != string(openai.SpeechModelTTS1) && model != string(openai.SpeechModelTTS1HD) {
+		params.Instructions = openai.String(fmt.Sprintf("Speak naturally, in a conversational tone, using the language %s.", req.Language))
+	}
+
+	resp, err := client.Audio.Speech.New(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("openai returned empty audio")
+	}
+
+	pricePerMChar, ok := domainBot.OpenAISpeechPrices[model]
+	if !ok {
+		pricePerMChar = domainBot.OpenAISpeechPrices[domainBot.DefaultOpenAISpeechModel]
+	}
+	chars := utf8.RuneCountInString(req.Text)
+
+	return &domain.SpeechAudio{
+		Data:     data,
+		MimeType: "audio/ogg; codecs=opus",
+		Duration: domain.EstimateSpeechDuration(req.Text, req.Speed),
+		Usage: &domain.UsageStats{
+			Model:       model,
+			InputTokens: chars,
+			CostUSD:     float64(chars) * pricePerMChar / 1_000_000,
+		},
+	}, nil
+}
@@ -38,6 +38,21 @@ type botModel struct {
 	Variants             map[string]domainBot.BotVariant `gorm:"serializer:json"`
 	CreatedAt            time.Time                       `gorm:"autoCreateTime"`
 	UpdatedAt            time.Time                       `gorm:"autoUpdateTime"`
+
+	// Políticas de aprobación por herramienta (auto / ask_user / ask_operator)
+	ToolPolicies map[string]domainBot.ToolApprovalPolicy `gorm:"serializer:json"`
+
+	// Configuración de notas de voz (TTS)
+	Voice *domainBot.VoiceConfig `gorm:"serializer:json"`
+
+	// Perfil de escritura simulada
+	Typing *domainBot.TypingSettings `gorm:"serializer:json"`
+
+	// Respuestas en streaming (burbujas progresivas)
+	StreamReplies bool `gorm:"column:stream_replies;not null;default:false"`
+
+	// Revisión vigente de la configuración
+	Revision int `gorm:"column:revision;not null;default:0"`
 }
 
 // TableName especifica el nombre de la tabla para GORM.
@@ -132,6 +147,11 @@ func toBotModel(b domainBot.Bot) botModel {
 		ChatwootBotToken:     sql.NullString{String: b.ChatwootBotToken, Valid: b.ChatwootBotToken != ""},
 		Whitelist:            sql.NullString{String: strings.Join(b.Whitelist, ","), Valid: len(b.Whitelist) > 0},
 		Variants:             b.Variants,
+		ToolPolicies:         b.ToolPolicies,
+		Voice:                b.Voice,
+		Typing:               b.Typing,
+		StreamReplies:        b.StreamReplies,
+		Revision:             b.Revision,
 	}
 }
 
@@ -164,6 +184,11 @@ func fromBotModel(m botModel) domainBot.Bot {
 		ChatwootBotToken:     nullStringValue(m.ChatwootBotToken),
 		Whitelist:            whitelist,
 		Variants:             m.Variants,
+		ToolPolicies:         m.ToolPolicies,
+		Voice:                m.Voice,
+		Typing:               m.Typing,
+		StreamReplies:        m.StreamReplies,
+		Revision:             m.Revision,
 	}
 }
 
@@ -0,0 +1,212 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	domainBot "github.com/AzielCF/az-wap/botengine/domain/bot"
+	db_pkg "github.com/AzielCF/az-wap/core/pkg/db"
+	pkgError "github.com/AzielCF/az-wap/core/pkg/error"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// botRevisionModel es una revisión inmutable: solo se inserta, nunca se actualiza.
+// La clave primaria (bot_id, number) es el índice único que serializa los cambios concurrentes.
+type botRevisionModel struct {
+	BotID        string                  `gorm:"primaryKey;column:bot_id"`
+	Number       int                     `gorm:"primaryKey"`
+	Author       string                  `gorm:"column:author"`
+	Note         string                  `gorm:"column:note"`
+	Snapshot     domainBot.Bot           `gorm:"serializer:json"`
+	Changes      []domainBot.FieldChange `gorm:"serializer:json"`
+	RestoredFrom int                     `gorm:"column:restored_from;not null;default:0"`
+	CreatedAt    time.Time               `gorm:"autoCreateTime"`
+}
+
+func (botRevisionModel) TableName() string {
+	return "bot_revisions"
+}
+
+// botVersionStatsModel acumula las respuestas de cada versión (revisión + variante)
+type botVersionStatsModel struct {
+	BotID          string `gorm:"primaryKey;column:bot_id"`
+	Revision       int    `gorm:"primaryKey"`
+	VariantID      string `gorm:"primaryKey;column:variant_id"`
+	Replies        int64  `gorm:"not null;default:0"`
+	TotalCost      float64
+	TotalLatencyMs int64
+	Positive       int64 `gorm:"column:positive_feedback;not null;default:0"`
+	Negative       int64 `gorm:"column:negative_feedback;not null;default:0"`
+	LastReplyAt    time.Time
+}
+
+func (botVersionStatsModel) TableName() string {
+	return "bot_version_stats"
+}
+
+// BotRevisionGormRepository implementa IBotRevisionRepository usando GORM.
+type BotRevisionGormRepository struct {
+	db *gorm.DB
+}
+
+func NewBotRevisionGormRepository(db *gorm.DB) *BotRevisionGormRepository {
+	return &BotRevisionGormRepository{db: db}
+}
+
+func (r *BotRevisionGormRepository) Init(ctx context.Context) error {
+	models := map[string]interface{}{
+		"bot_revisions":     &botRevisionModel{},
+		"bot_version_stats": &botVersionStatsModel{},
+	}
+	return db_pkg.SafeMigrateSQLite(ctx, r.db, models)
+}
+
+func (r *BotRevisionGormRepository) CreateRevision(ctx context.Context, rev domainBot.BotRevision) error {
+	model := botRevisionModel{
+		BotID:        rev.BotID,
+		Number:       rev.Number,
+		Author:       rev.Author,
+		Note:         rev.Note,
+		Snapshot:     rev.Snapshot,
+		Changes:      rev.Changes,
+		RestoredFrom: rev.RestoredFrom,
+		CreatedAt:    rev.CreatedAt,
+	}
+	if err := r.db.WithContext(ctx).Create(&model).Error; err != nil {
+		if isUniqueViolation(err) {
+			return fmt.Errorf("%w: bot %s revision %d", domainBot.ErrRevisionConflict, rev.BotID, rev.Number)
+		}
+		return err
+	}
+	return nil
+}
+
+func isUniqueViolation(err error) bool {
+	return strings.Contains(err.Error(), "UNIQUE constraint failed") || strings.Contains(err.Error(), "duplicate key value")
+}
+
+func (r *BotRevisionGormRepository) GetRevision(ctx context.Context, botID string, number int) (domainBot.BotRevision, error) {
+	var model botRevisionModel
+	if err := r.db.WithContext(ctx).First(&model, "bot_id = ? AND number = ?", botID, number).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return domainBot.BotRevision{}, pkgError.NotFoundError("bot revision not found")
+		}
+		return domainBot.BotRevision{}, err
+	}
+	return fromBotRevisionModel(model), nil
+}
+
+// ListRevisions devuelve las revisiones más recientes primero
+func (r *BotRevisionGormRepository) ListRevisions(ctx context.Context, botID string, limit int) ([]domainBot.BotRevision, error) {
+	q := r.db.WithContext(ctx).Where("bot_id = ?", botID).Order("number DESC")
+	if limit > 0 {
+		q = q.Limit(limit)
+	}
+	var models []botRevisionModel
+	if err := q.Find(&models).Error; err != nil {
+		return nil, err
+	}
+	result := make([]domainBot.BotRevision, len(models))
+	for i, m := range models {
+		result[i] = fromBotRevisionModel(m)
+	}
+	return result, nil
+}
+
+// DeleteRevision retira una revisión que no llegó a aplicarse al bot
+func (r *BotRevisionGormRepository) DeleteRevision(ctx context.Context, botID string, number int) error {
+	return r.db.WithContext(ctx).Delete(&botRevisionModel{}, "bot_id = ? AND number = ?", botID, number).Error
+}
+
+func (r *BotRevisionGormRepository) DeleteRevisions(ctx context.Context, botID string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&botVersionStatsModel{}, "bot_id = ?", botID).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&botRevisionModel{}, "bot_id = ?", botID).Error
+	})
+}
+
+// RecordVersionUsage suma una respuesta a los acumulados de su versión (upsert atómico)
+func (r *BotRevisionGormRepository) RecordVersionUsage(ctx context.Context, usage domainBot.VersionUsage) error {
+	model := botVersionStatsModel{
+		BotID:          usage.BotID,
+		Revision:       usage.Revision,
+		VariantID:      usage.VariantID,
+		Replies:        1,
+		TotalCost:      usage.CostUSD,
+		TotalLatencyMs: usage.LatencyMs,
+		LastReplyAt:    time.Now(),
+	}
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "bot_id"}, {Name: "revision"}, {Name: "variant_id"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"replies":          gorm.Expr("bot_version_stats.replies + 1"),
+			"total_cost":       gorm.Expr("bot_version_stats.total_cost + ?", usage.CostUSD),
+			"total_latency_ms": gorm.Expr("bot_version_stats.total_latency_ms + ?", usage.LatencyMs),
+			"last_reply_at":    model.LastReplyAt,
+		}),
+	}).Create(&model).Error
+}
+
+// RecordVersionFeedback suma una valoración a los acumulados de su versión (upsert atómico)
+func (r *BotRevisionGormRepository) RecordVersionFeedback(ctx context.Context, feedback domainBot.VersionFeedback) error {
+	column := "negative_feedback"
+	model := botVersionStatsModel{BotID: feedback.BotID, Revision: feedback.Revision, VariantID: feedback.VariantID, Negative: 1}
+	if feedback.Positive {
+		column = "positive_feedback"
+		model.Negative, model.Positive = 0, 1
+	}
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "bot_id"}, {Name: "revision"}, {Name: "variant_id"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			column: gorm.Expr("bot_version_stats." + column + " + 1"),
+		}),
+	}).Create(&model).Error
+}
+
+func (r *BotRevisionGormRepository) ListVersionStats(ctx context.Context, botID string) ([]domainBot.VersionStats, error) {
+	var models []botVersionStatsModel
+	if err := r.db.WithContext(ctx).Where("bot_id = ?", botID).Order("revision DESC, variant_id ASC").Find(&models).Error; err != nil {
+		return nil, err
+	}
+	result := make([]domainBot.VersionStats, len(models))
+	for i, m := range models {
+		s := domainBot.VersionStats{
+			BotID:            m.BotID,
+			Revision:         m.Revision,
+			VariantID:        m.VariantID,
+			Replies:          m.Replies,
+			TotalCost:        m.TotalCost,
+			TotalLatencyMs:   m.TotalLatencyMs,
+			PositiveFeedback: m.Positive,
+			NegativeFeedback: m.Negative,
+			LastReplyAt:      m.LastReplyAt,
+		}
+		if m.Replies > 0 {
+			s.AvgCost = m.TotalCost / float64(m.Replies)
+			s.AvgLatencyMs = m.TotalLatencyMs / m.Replies
+		}
+		if rated := m.Positive + m.Negative; rated > 0 {
+			s.FeedbackScore = float64(m.Positive) / float64(rated)
+		}
+		result[i] = s
+	}
+	return result, nil
+}
+
+func fromBotRevisionModel(m botRevisionModel) domainBot.BotRevision {
+	return domainBot.BotRevision{
+		BotID:        m.BotID,
+		Number:       m.Number,
+		Author:       m.Author,
+		Note:         m.Note,
+		Snapshot:     m.Snapshot,
+		Changes:      m.Changes,
+		RestoredFrom: m.RestoredFrom,
+		CreatedAt:    m.CreatedAt,
+	}
+}
@@ -0,0 +1,198 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	domainEval "github.com/AzielCF/az-wap/botengine/domain/eval"
+	db_pkg "github.com/AzielCF/az-wap/core/pkg/db"
+	pkgError "github.com/AzielCF/az-wap/core/pkg/error"
+	"gorm.io/gorm"
+)
+
+// evalSuiteModel guarda la suite con sus casos y la grabación como JSON
+type evalSuiteModel struct {
+	ID          string `gorm:"primaryKey"`
+	BotID       string `gorm:"column:bot_id;index"`
+	Name        string
+	Description string
+	VariantID   string               `gorm:"column:variant_id"`
+	Cases       []domainEval.Case    `gorm:"serializer:json"`
+	Recording   domainEval.Recording `gorm:"serializer:json"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+func (evalSuiteModel) TableName() string {
+	return "eval_suites"
+}
+
+// evalRunModel guarda el resultado de una ejecución
+type evalRunModel struct {
+	ID         string `gorm:"primaryKey"`
+	SuiteID    string `gorm:"column:suite_id;index"`
+	BotID      string `gorm:"column:bot_id"`
+	VariantID  string `gorm:"column:variant_id"`
+	Mode       string
+	Passed     int
+	Failed     int
+	TotalCost  float64
+	DurationMs int64
+	Cases      []domainEval.CaseResult `gorm:"serializer:json"`
+	StartedAt  time.Time               `gorm:"index"`
+	FinishedAt time.Time
+}
+
+func (evalRunModel) TableName() string {
+	return "eval_runs"
+}
+
+// EvalGormRepository implementa domainEval.IEvalRepository usando GORM.
+type EvalGormRepository struct {
+	db *gorm.DB
+}
+
+func NewEvalGormRepository(db *gorm.DB) *EvalGormRepository {
+	return &EvalGormRepository{db: db}
+}
+
+func (r *EvalGormRepository) Init(ctx context.Context) error {
+	models := map[string]interface{}{
+		"eval_suites": &evalSuiteModel{},
+		"eval_runs":   &evalRunModel{},
+	}
+	return db_pkg.SafeMigrateSQLite(ctx, r.db, models)
+}
+
+func (r *EvalGormRepository) CreateSuite(ctx context.Context, s domainEval.Suite) error {
+	model := toEvalSuiteModel(s)
+	return r.db.WithContext(ctx).Create(&model).Error
+}
+
+func (r *EvalGormRepository) UpdateSuite(ctx context.Context, s domainEval.Suite) error {
+	model := toEvalSuiteModel(s)
+	return r.db.WithContext(ctx).Save(&model).Error
+}
+
+func (r *EvalGormRepository) GetSuite(ctx context.Context, id string) (domainEval.Suite, error) {
+	var model evalSuiteModel
+	if err := r.db.WithContext(ctx).First(&model, "id = ?", id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return domainEval.Suite{}, pkgError.NotFoundError("eval suite not found")
+		}
+		return domainEval.Suite{}, err
+	}
+	return fromEvalSuiteModel(model), nil
+}
+
+func (r *EvalGormRepository) ListSuites(ctx context.Context, botID string) ([]domainEval.Suite, error) {
+	var models []evalSuiteModel
+	if err := r.db.WithContext(ctx).Where("bot_id = ?", botID).Order("name ASC").Find(&models).Error; err != nil {
+		return nil, err
+	}
+	result := make([]domainEval.Suite, len(models))
+	for i, m := range models {
+		result[i] = fromEvalSuiteModel(m)
+	}
+	return result, nil
+}
+
+// DeleteSuite elimina la suite y su historial de ejecuciones
+func (r *EvalGormRepository) DeleteSuite(ctx context.Context, id string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&evalRunModel{}, "suite_id = ?", id).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&evalSuiteModel{}, "id = ?", id).Error
+	})
+}
+
+func (r *EvalGormRepository) SaveRun(ctx context.Context, run domainEval.Run) error {
+	model := evalRunModel{
+		ID:         run.ID,
+		SuiteID:    run.SuiteID,
+		BotID:      run.BotID,
+		VariantID:  run.VariantID,
+		Mode:       string(run.Mode),
+		Passed:     run.Passed,
+		Failed:     run.Failed,
+		TotalCost:  run.TotalCost,
+		DurationMs: run.DurationMs,
+		Cases:      run.Cases,
+		StartedAt:  run.StartedAt,
+		FinishedAt: run.FinishedAt,
+	}
+	return r.db.WithContext(ctx).Save(&model).Error
+}
+
+func (r *EvalGormRepository) GetRun(ctx context.Context, id string) (domainEval.Run, error) {
+	var model evalRunModel
+	if err := r.db.WithContext(ctx).First(&model, "id = ?", id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return domainEval.Run{}, pkgError.NotFoundError("eval run not found")
+		}
+		return domainEval.Run{}, err
+	}
+	return fromEvalRunModel(model), nil
+}
+
+// ListRuns devuelve las ejecuciones más recientes primero
+func (r *EvalGormRepository) ListRuns(ctx context.Context, suiteID string, limit int) ([]domainEval.Run, error) {
+	if limit <= 0 {
+		limit = 20
+	}
+	var models []evalRunModel
+	if err := r.db.WithContext(ctx).Where("suite_id = ?", suiteID).Order("started_at DESC").Limit(limit).Find(&models).Error; err != nil {
+		return nil, err
+	}
+	result := make([]domainEval.Run, len(models))
+	for i, m := range models {
+		result[i] = fromEvalRunModel(m)
+	}
+	return result, nil
+}
+
+func toEvalSuiteModel(s domainEval.Suite) evalSuiteModel {
+	return evalSuiteModel{
+		ID:          s.ID,
+		BotID:       s.BotID,
+		Name:        s.Name,
+		Description: s.Description,
+		VariantID:   s.VariantID,
+		Cases:       s.Cases,
+		Recording:   s.Recording,
+		CreatedAt:   s.CreatedAt,
+		UpdatedAt:   s.UpdatedAt,
+	}
+}
+
+func fromEvalSuiteModel(m evalSuiteModel) domainEval.Suite {
+	return domainEval.Suite{
+		ID:          m.ID,
+		BotID:       m.BotID,
+		Name:        m.Name,
+		Description: m.Description,
+		VariantID:   m.VariantID,
+		Cases:       m.Cases,
+		Recording:   m.Recording,
+		CreatedAt:   m.CreatedAt,
+		UpdatedAt:   m.UpdatedAt,
+	}
+}
+
+func fromEvalRunModel(m evalRunModel) domainEval.Run {
+	return domainEval.Run{
+		ID:         m.ID,
+		SuiteID:    m.SuiteID,
+		BotID:      m.BotID,
+		VariantID:  m.VariantID,
+		Mode:       domainEval.RunMode(m.Mode),
+		Passed:     m.Passed,
+		Failed:     m.Failed,
+		TotalCost:  m.TotalCost,
+		DurationMs: m.DurationMs,
+		Cases:      m.Cases,
+		StartedAt:  m.StartedAt,
+		FinishedAt: m.FinishedAt,
+	}
+}
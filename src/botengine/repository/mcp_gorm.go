@@ -28,6 +28,8 @@ type mcpServerModel struct {
 	Env            sql.NullString `gorm:"type:text"` // JSON
 	Headers        sql.NullString `gorm:"type:text"` // Encrypted JSON
 	Tools          sql.NullString `gorm:"type:text"` // JSON
+	Resources      sql.NullString `gorm:"type:text"` // JSON
+	Prompts        sql.NullString `gorm:"type:text"` // JSON
 	Enabled        bool           `gorm:"default:true"`
 	IsTemplate     bool           `gorm:"default:false"`
 	TemplateConfig sql.NullString `gorm:"type:text"` // JSON
@@ -132,6 +134,21 @@ func (r *MCPGormRepository) UpdateServerTools(ctx context.Context, serverID stri
 	return r.db.WithContext(ctx).Model(&mcpServerModel{}).Where("id = ?", serverID).Update("tools", sql.NullString{String: string(toolsJSON), Valid: true}).Error
 }
 
+func (r *MCPGormRepository) UpdateServerCapabilities(ctx context.Context, serverID string, caps domainMCP.ServerCapabilities) error {
+	resourcesJSON, err := json.Marshal(caps.Resources)
+	if err != nil {
+		return err
+	}
+	promptsJSON, err := json.Marshal(caps.Prompts)
+	if err != nil {
+		return err
+	}
+	return r.db.WithContext(ctx).Model(&mcpServerModel{}).Where("id = ?", serverID).Updates(map[string]interface{}{
+		"resources": sql.NullString{String: string(resourcesJSON), Valid: true},
+		"prompts":   sql.NullString{String: string(promptsJSON), Valid: true},
+	}).Error
+}
+
 // === Bot MCP Configs ===
 
 func (r *MCPGormRepository) GetBotServerIDs(ctx context.Context, botID string) ([]string, error) {
@@ -238,6 +255,18 @@ func toMCPServerModel(s domainMCP.MCPServer) (mcpServerModel, error) {
 		templateConfigJSON = string(b)
 	}
 
+	resourcesJSON := "[]"
+	if s.Resources != nil {
+		b, _ := json.Marshal(s.Resources)
+		resourcesJSON = string(b)
+	}
+
+	promptsJSON := "[]"
+	if s.Prompts != nil {
+		b, _ := json.Marshal(s.Prompts)
+		promptsJSON = string(b)
+	}
+
 	return mcpServerModel{
 		ID:             s.ID,
 		Name:           s.Name,
@@ -249,6 +278,8 @@ func toMCPServerModel(s domainMCP.MCPServer) (mcpServerModel, error) {
 		Env:            sql.NullString{String: string(envJSON), Valid: true},
 		Headers:        sql.NullString{String: string(headersJSON), Valid: true},
 		Tools:          sql.NullString{String: toolsJSON, Valid: true},
+		Resources:      sql.NullString{String: resourcesJSON, Valid: true},
+		Prompts:        sql.NullString{String: promptsJSON, Valid: true},
 		Enabled:        s.Enabled,
 		IsTemplate:     s.IsTemplate,
 		TemplateConfig: sql.NullString{String: templateConfigJSON, Valid: true},
@@ -296,6 +327,12 @@ func fromMCPServerModel(m mcpServerModel) (domainMCP.MCPServer, error) {
 	if templateConfigJSON != "" {
 		_ = json.Unmarshal([]byte(templateConfigJSON), &s.TemplateConfig)
 	}
+	if resourcesJSON := nullStringValue(m.Resources); resourcesJSON != "" {
+		_ = json.Unmarshal([]byte(resourcesJSON), &s.Resources)
+	}
+	if promptsJSON := nullStringValue(m.Prompts); promptsJSON != "" {
+		_ = json.Unmarshal([]byte(promptsJSON), &s.Prompts)
+	}
 
 	return s, nil
 }
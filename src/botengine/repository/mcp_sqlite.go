@@ -93,6 +93,8 @@ func (r *MCPSQLiteRepository) runMigrations(ctx context.Context) error {
 		{"mcp_servers", "is_template", "ALTER TABLE mcp_servers ADD COLUMN is_template INTEGER DEFAULT 0"},
 		{"mcp_servers", "template_config", "ALTER TABLE mcp_servers ADD COLUMN template_config TEXT"},
 		{"mcp_servers", "instructions", "ALTER TABLE mcp_servers ADD COLUMN instructions TEXT"},
+		{"mcp_servers", "resources", "ALTER TABLE mcp_servers ADD COLUMN resources TEXT"},
+		{"mcp_servers", "prompts", "ALTER TABLE mcp_servers ADD COLUMN prompts TEXT"},
 		{"bot_mcp_configs", "config_json", "ALTER TABLE bot_mcp_configs ADD COLUMN config_json TEXT"},
 	}
 
@@ -146,7 +148,7 @@ func (r *MCPSQLiteRepository) AddServer(ctx context.Context, server domainMCP.MC
 
 // ListServers retorna todos los servidores MCP.
 func (r *MCPSQLiteRepository) ListServers(ctx context.Context) ([]domainMCP.MCPServer, error) {
-	query := `SELECT id, name, description, type, url, command, args, env, headers, tools, COALESCE(is_template, 0), COALESCE(template_config, '{}'), COALESCE(instructions, '') FROM mcp_servers`
+	query := `SELECT id, name, description, type, url, command, args, env, headers, tools, COALESCE(is_template, 0), COALESCE(template_config, '{}'), COALESCE(instructions, ''), COALESCE(resources, '[]'), COALESCE(prompts, '[]') FROM mcp_servers`
 	rows, err := r.db.QueryContext(ctx, query)
 	if err != nil {
 		return nil, err
@@ -166,16 +168,16 @@ func (r *MCPSQLiteRepository) ListServers(ctx context.Context) ([]domainMCP.MCPS
 
 // GetServer obtiene un servidor por su ID.
 func (r *MCPSQLiteRepository) GetServer(ctx context.Context, id string) (domainMCP.MCPServer, error) {
-	query := `SELECT id, name, description, type, url, command, args, env, headers, tools, COALESCE(is_template, 0), COALESCE(template_config, '{}'), COALESCE(instructions, '') FROM mcp_servers WHERE id = ?`
+	query := `SELECT id, name, description, type, url, command, args, env, headers, tools, COALESCE(is_template, 0), COALESCE(template_config, '{}'), COALESCE(instructions, ''), COALESCE(resources, '[]'), COALESCE(prompts, '[]') FROM mcp_servers WHERE id = ?`
 	return r.scanServer(r.db.QueryRowContext(ctx, query, id))
 }
 
 func (r *MCPSQLiteRepository) scanServer(scanner interface{ Scan(...any) error }) (domainMCP.MCPServer, error) {
 	var srv domainMCP.MCPServer
-	var typeStr, argsJSON, envJSON, headersJSON, toolsJSON, templateConfigJSON string
+	var typeStr, argsJSON, envJSON, headersJSON, toolsJSON, templateConfigJSON, resourcesJSON, promptsJSON string
 	var isTemplateInt int
 
-	err := scanner.Scan(&srv.ID, &srv.Name, &srv.Description, &typeStr, &srv.URL, &srv.Command, &argsJSON, &envJSON, &headersJSON, &toolsJSON, &isTemplateInt, &templateConfigJSON, &srv.Instructions)
+	err := scanner.Scan(&srv.ID, &srv.Name, &srv.Description, &typeStr, &srv.URL, &srv.Command, &argsJSON, &envJSON, &headersJSON, &toolsJSON, &isTemplateInt, &templateConfigJSON, &srv.Instructions, &resourcesJSON, &promptsJSON)
 	if err != nil {
 		return srv, err
 	}
@@ -192,6 +194,8 @@ func (r *MCPSQLiteRepository) scanServer(scanner interface{ Scan(...any) error }
 
 	json.Unmarshal([]byte(toolsJSON), &srv.Tools)
 	json.Unmarshal([]byte(templateConfigJSON), &srv.TemplateConfig)
+	json.Unmarshal([]byte(resourcesJSON), &srv.Resources)
+	json.Unmarshal([]byte(promptsJSON), &srv.Prompts)
 	srv.IsTemplate = isTemplateInt != 0
 
 	return srv, nil
@@ -253,6 +257,14 @@ func (r *MCPSQLiteRepository) UpdateServerTools(ctx context.Context, serverID st
 	return err
 }
 
+// UpdateServerCapabilities actualiza el cache de resources y prompts de un servidor.
+func (r *MCPSQLiteRepository) UpdateServerCapabilities(ctx context.Context, serverID string, caps domainMCP.ServerCapabilities) error {
+	resourcesJSON, _ := json.Marshal(caps.Resources)
+	promptsJSON, _ := json.Marshal(caps.Prompts)
+	_, err := r.db.ExecContext(ctx, "UPDATE mcp_servers SET resources = ?, prompts = ? WHERE id = ?", string(resourcesJSON), string(promptsJSON), serverID)
+	return err
+}
+
 // === Bot MCP Configs ===
 
 // GetBotServerIDs retorna los IDs de servidores habilitados para un bot.
@@ -0,0 +1,117 @@
+package botengine
+
+import (
+	"context"
+
+	"github.com/AzielCF/az-wap/botengine/domain"
+	"github.com/AzielCF/az-wap/botengine/domain/bot"
+	"github.com/AzielCF/az-wap/botengine/infrastructure"
+	"github.com/sirupsen/logrus"
+)
+
+// replyStreamer recibe los eventos de una respuesta en streaming: reenvía los tokens a los
+// transportes que los muestran en vivo y, si el bot lo tiene activo, envía cada burbuja en
+// cuanto está completa en lugar de esperar al final de la generación.
+type replyStreamer struct {
+	ctx         context.Context
+	chatID      string
+	cutter      *infrastructure.BubbleStream
+	live        domain.StreamTransport // nil = el transporte no muestra tokens
+	progressive bool
+
+	deliver func(i int, bubble string) bool // Escritura simulada + envío. false = cancelado
+	queue   chan string
+	done    chan struct{}
+	sent    []string
+}
+
+// newReplyStreamer devuelve nil si la respuesta no se puede (o no se quiere) emitir en streaming
+func (e *Engine) newReplyStreamer(ctx context.Context, p domain.AIProvider, b bot.Bot, transport domain.Transport, chatID string, userSentVoice, highSpeed bool, deliver func(int, string) bool) *replyStreamer {
+	if _, ok := p.(domain.StreamingProvider); !ok {
+		return nil
+	}
+	live, _ := transport.(domain.StreamTransport)
+
+	// Si la respuesta puede acabar en nota de voz, hay que tenerla completa antes de enviar nada
+	progressive := b.StreamReplies
+	if progressive && b.Voice.Enabled() && (b.Voice.Policy != bot.VoiceReplyMirror || userSentVoice) {
+		progressive = false
+	}
+	if live == nil && !progressive {
+		return nil
+	}
+
+	s := &replyStreamer{
+		ctx:         ctx,
+		chatID:      chatID,
+		cutter:      e.humanizer.NewBubbleStream(highSpeed),
+		live:        live,
+		progressive: progressive,
+		deliver:     deliver,
+	}
+	if progressive {
+		s.queue = make(chan string, 32)
+		s.done = make(chan struct{})
+		go s.loop()
+	}
+	return s
+}
+
+// handle es el domain.StreamHandler que el orquestador invoca por cada evento del proveedor
+func (s *replyStreamer) handle(ev domain.StreamEvent) {
+	switch ev.Type {
+	case domain.StreamEventText:
+		visible, bubbles := s.cutter.Push(ev.Text)
+		if s.live != nil && visible != "" {
+			if err := s.live.SendDelta(s.ctx, s.chatID, visible, false); err != nil {
+				logrus.Debugf("[ENGINE] Failed to stream delta to %s: %v", s.chatID, err)
+			}
+		}
+		if s.progressive {
+			for _, bubble := range bubbles {
+				s.queue <- bubble
+			}
+		}
+	case domain.StreamEventToolCall:
+		// El texto de esta iteración acompañaba a una herramienta: lo no enviado se descarta
+		s.cutter.Reset()
+		if s.live != nil {
+			_ = s.live.SendDelta(s.ctx, s.chatID, "", true)
+		}
+	}
+}
+
+// Finish cierra el streaming, envía la última burbuja y devuelve las burbujas enviadas.
+// Es seguro llamarlo sobre un streamer nil.
+func (s *replyStreamer) Finish() []string {
+	if s == nil {
+		return nil
+	}
+	if s.live != nil {
+		_ = s.live.SendDelta(s.ctx, s.chatID, "", true)
+	}
+	if !s.progressive {
+		return nil
+	}
+	for _, bubble := range s.cutter.Flush() {
+		s.queue <- bubble
+	}
+	close(s.queue)
+	<-s.done
+	return s.sent
+}
+
+func (s *replyStreamer) loop() {
+	defer close(s.done)
+	cancelled := false
+	for bubble := range s.queue {
+		if cancelled {
+			continue
+		}
+		if !s.deliver(len(s.sent), bubble) {
+			cancelled = true
+			continue
+		}
+		s.sent = append(s.sent, bubble)
+	}
+}
@@ -0,0 +1,137 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"unicode/utf8"
+
+	domainMCP "github.com/AzielCF/az-wap/botengine/domain/mcp"
+)
+
+// maxResourceTextChars limita el texto de un recurso devuelto a la IA.
+const maxResourceTextChars = 32000
+
+// NewReadMCPResourceTool crea la herramienta para leer bajo demanda los recursos MCP permitidos al bot
+func NewReadMCPResourceTool(mcpUsecase domainMCP.IMCPUsecase) ToolDefinition {
+	return ToolDefinition{
+		Tool: domainMCP.Tool{
+			Name:        "read_mcp_resource",
+			Description: "Reads a reference resource (document, dataset, page) published by a connected integration. Call it without 'uri' to list the resources you are allowed to read, then call it again with the chosen 'uri' to get its content.",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"uri": map[string]interface{}{
+						"type":        "string",
+						"description": "URI of the resource to read. Omit it to list the available resources.",
+					},
+				},
+			},
+		},
+		Handler: func(ctx context.Context, contextData map[string]interface{}, args map[string]interface{}) (map[string]interface{}, error) {
+			botID, _ := contextData["bot_id"].(string)
+			if botID == "" || mcpUsecase == nil {
+				return nil, fmt.Errorf("bot context missing")
+			}
+
+			servers, err := mcpUsecase.ListServersForBot(ctx, botID)
+			if err != nil {
+				return nil, err
+			}
+			servers = filterAllowedMCPs(servers, contextData)
+
+			uri, _ := args["uri"].(string)
+			if uri == "" {
+				var available []map[string]string
+				for _, srv := range servers {
+					for _, r := range srv.Resources {
+						if !srv.CanRead(r.URI) {
+							continue
+						}
+						available = append(available, map[string]string{
+							"uri":         r.URI,
+							"name":        r.Name,
+							"description": r.Description,
+							"mime_type":   r.MimeType,
+							"source":      srv.Name,
+						})
+					}
+				}
+				return map[string]interface{}{
+					"resources": available,
+					"count":     len(available),
+				}, nil
+			}
+
+			for _, srv := range servers {
+				if !srv.CanRead(uri) {
+					continue
+				}
+				contents, err := mcpUsecase.ReadResource(ctx, botID, srv.ID, uri)
+				if err != nil {
+					return nil, fmt.Errorf("failed to read resource: %w", err)
+				}
+
+				var parts []map[string]interface{}
+				for _, c := range contents {
+					part := map[string]interface{}{"uri": c.URI, "mime_type": c.MimeType}
+					if c.IsBinary() {
+						part["binary"] = true
+						part["note"] = "Binary content cannot be shown as text."
+					} else {
+						text := c.Text
+						if len(text) > maxResourceTextChars {
+							// Corta en el inicio de una runa para no dejar UTF-8 inválido
+							cut := maxResourceTextChars
+							for cut > 0 && !utf8.RuneStart(text[cut]) {
+								cut--
+							}
+							text = text[:cut]
+							part["truncated"] = true
+						}
+						part["text"] = text
+					}
+					parts = append(parts, part)
+				}
+				return map[string]interface{}{
+					"uri":      uri,
+					"source":   srv.Name,
+					"contents": parts,
+				}, nil
+			}
+
+			return map[string]interface{}{
+				"success": false,
+				"message": fmt.Sprintf("Resource %s is not available. Call read_mcp_resource without 'uri' to see the allowed resources.", uri),
+			}, nil
+		},
+	}
+}
+
+// filterAllowedMCPs aplica la restricción de servidores de la variante activa, si existe.
+func filterAllowedMCPs(servers []domainMCP.MCPServer, contextData map[string]interface{}) []domainMCP.MCPServer {
+	var allowed []string
+	if metadata, ok := contextData["metadata"].(map[string]interface{}); ok {
+		allowed, _ = metadata["allowed_mcps"].([]string)
+	}
+
+	var out []domainMCP.MCPServer
+	for _, srv := range servers {
+		if !srv.Enabled {
+			continue
+		}
+		if len(allowed) > 0 && !containsString(allowed, srv.ID) {
+			continue
+		}
+		out = append(out, srv)
+	}
+	return out
+}
+
+func containsString(list []string, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
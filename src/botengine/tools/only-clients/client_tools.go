@@ -4,21 +4,25 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"sort"
 
 	"github.com/AzielCF/az-wap/botengine/domain"
+	domainBot "github.com/AzielCF/az-wap/botengine/domain/bot"
 	domainMCP "github.com/AzielCF/az-wap/botengine/domain/mcp"
+	clientsApp "github.com/AzielCF/az-wap/clients/application"
 	clientsDomain "github.com/AzielCF/az-wap/clients/domain"
 	portalAuth "github.com/AzielCF/az-wap/clients_portal/auth/application"
 )
 
 const (
-	// MaxMetadataFields is the maximum number of custom fields in metadata
+	// MaxMetadataFields is the maximum number of free-form fields in metadata (schema fields don't count)
 	MaxMetadataFields = 10
-	// MaxMetadataBytes is the maximum total size of serialized metadata (~2KB)
+	// MaxMetadataBytes is the maximum total size of serialized free-form metadata (~2KB)
 	MaxMetadataBytes = 2048
 )
 
-// AllowedClientFields defines the fields that the client can update via AI
+// AllowedClientFields defines the fields that the client can update via AI.
+// When the workspace defines one of them in its field schema, the definition rules.
 var AllowedClientFields = []string{
 	"name",           // Client Name
 	"email",          // Contact Email
@@ -34,18 +38,58 @@ var AllowedClientFields = []string{
 
 // ClientTools provides tools for the client to manage their information
 type ClientTools struct {
-	clientRepo  clientsDomain.ClientRepository
-	authService *portalAuth.AuthService
+	clientRepo   clientsDomain.ClientRepository
+	authService  *portalAuth.AuthService
+	fieldService *clientsApp.FieldService
 }
 
 // NewClientTools creates a new instance of ClientTools
-func NewClientTools(clientRepo clientsDomain.ClientRepository, authService *portalAuth.AuthService) *ClientTools {
+func NewClientTools(clientRepo clientsDomain.ClientRepository, authService *portalAuth.AuthService, fieldService *clientsApp.FieldService) *ClientTools {
 	return &ClientTools{
-		clientRepo:  clientRepo,
-		authService: authService,
+		clientRepo:   clientRepo,
+		authService:  authService,
+		fieldService: fieldService,
 	}
 }
 
+// fieldSchema loads the merged custom field schema of every workspace the client belongs to
+// (empty if none). A load error refuses the tool call: failing open would expose hidden fields.
+func (t *ClientTools) fieldSchema(ctx context.Context, ctxData map[string]interface{}, clientID string) (map[string]*clientsDomain.CustomField, error) {
+	workspaceID, _ := ctxData["workspace_id"].(string)
+	if t.fieldService == nil {
+		return map[string]*clientsDomain.CustomField{}, nil
+	}
+	schema, err := t.fieldService.ClientSchema(ctx, clientID, workspaceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load profile field rules: %w", err)
+	}
+	return schema, nil
+}
+
+// aiWritable reports whether the AI may write the field, checking the schema before the legacy list
+func aiWritable(schema map[string]*clientsDomain.CustomField, key string) (*clientsDomain.CustomField, bool) {
+	if def, ok := schema[key]; ok {
+		return def, def.AIWrite
+	}
+	for _, f := range AllowedClientFields {
+		if f == key {
+			return nil, true
+		}
+	}
+	return nil, false
+}
+
+// freeFormMetadata returns the metadata entries not governed by the schema (subject to the legacy limits)
+func freeFormMetadata(schema map[string]*clientsDomain.CustomField, metadata map[string]any) map[string]any {
+	free := make(map[string]any)
+	for k, v := range metadata {
+		if _, ok := schema[k]; !ok {
+			free[k] = v
+		}
+	}
+	return free
+}
+
 // UpdateMyInfoTool allows the client to update their personal information
 func (t *ClientTools) UpdateMyInfoTool() *domain.NativeTool {
 	return &domain.NativeTool{
@@ -96,6 +140,18 @@ func (t *ClientTools) UpdateMyInfoTool() *domain.NativeTool {
 						"type":        "string",
 						"description": "Any other custom information the user wants to store.",
 					},
+					"custom_fields": map[string]interface{}{
+						"type":        "array",
+						"description": "Business-specific profile fields. Only use keys listed in 'editable_fields' from get_my_info.",
+						"items": map[string]interface{}{
+							"type": "object",
+							"properties": map[string]interface{}{
+								"key":   map[string]interface{}{"type": "string"},
+								"value": map[string]interface{}{"type": "string"},
+							},
+							"required": []string{"key", "value"},
+						},
+					},
 				},
 				"required": []string{},
 			},
@@ -137,24 +193,63 @@ func (t *ClientTools) UpdateMyInfoTool() *domain.NativeTool {
 				client.Metadata = make(map[string]any)
 			}
 
-			// Update allowed fields
-			updatedFields := []string{}
+			// Collect requested values (legacy named args + workspace custom fields)
+			requested := map[string]interface{}{}
 			for _, field := range AllowedClientFields {
 				if value, ok := args[field]; ok && value != nil && value != "" {
-					client.Metadata[field] = value
-					updatedFields = append(updatedFields, field)
+					requested[field] = value
+				}
+			}
+			if items, ok := args["custom_fields"].([]interface{}); ok {
+				for _, item := range items {
+					entry, _ := item.(map[string]interface{})
+					key, _ := entry["key"].(string)
+					if key != "" && entry["value"] != nil && entry["value"] != "" {
+						requested[key] = entry["value"]
+					}
+				}
+			}
+
+			// Enforce the workspace schema: AI write permission and value validation
+			schema, err := t.fieldSchema(ctx, ctxData, clientID)
+			if err != nil {
+				return nil, err
+			}
+			updatedFields := []string{}
+			rejectedFields := map[string]string{}
+			for key, value := range requested {
+				def, writable := aiWritable(schema, key)
+				if !writable {
+					rejectedFields[key] = "this field cannot be changed from the chat"
+					continue
 				}
+				if def != nil {
+					normalized, err := def.Normalize(value)
+					if err != nil {
+						rejectedFields[key] = err.Error()
+						continue
+					}
+					value = normalized
+				}
+				client.Metadata[key] = value
+				updatedFields = append(updatedFields, key)
 			}
+			sort.Strings(updatedFields)
 
 			if len(updatedFields) == 0 {
-				return map[string]interface{}{
+				result := map[string]interface{}{
 					"success": false,
 					"message": "No valid fields provided to update.",
-				}, nil
+				}
+				if len(rejectedFields) > 0 {
+					result["rejected_fields"] = rejectedFields
+				}
+				return result, nil
 			}
 
 			// Validate limits
-			if len(client.Metadata) > MaxMetadataFields {
+			freeForm := freeFormMetadata(schema, client.Metadata)
+			if len(freeForm) > MaxMetadataFields {
 				return map[string]interface{}{
 					"success": false,
 					"message": fmt.Sprintf("Maximum of %d fields allowed. Please remove some before adding more.", MaxMetadataFields),
@@ -162,7 +257,7 @@ func (t *ClientTools) UpdateMyInfoTool() *domain.NativeTool {
 			}
 
 			// Validate size
-			metadataJSON, _ := json.Marshal(client.Metadata)
+			metadataJSON, _ := json.Marshal(freeForm)
 			if len(metadataJSON) > MaxMetadataBytes {
 				return map[string]interface{}{
 					"success": false,
@@ -175,11 +270,15 @@ func (t *ClientTools) UpdateMyInfoTool() *domain.NativeTool {
 				return nil, fmt.Errorf("failed to save profile: %w", err)
 			}
 
-			return map[string]interface{}{
+			result := map[string]interface{}{
 				"success":        true,
 				"message":        "Profile updated successfully.",
 				"updated_fields": updatedFields,
-			}, nil
+			}
+			if len(rejectedFields) > 0 {
+				result["rejected_fields"] = rejectedFields
+			}
+			return result, nil
 		},
 	}
 }
@@ -258,8 +357,40 @@ func (t *ClientTools) GetMyInfoTool() *domain.NativeTool {
 			// Let's copy metadata fields to root info to make it easier for AI, or keep as sub-object.
 			// Current implementation puts it in "personal_data".
 			// But for "name" to be visible, it must be in metadata.
-			if len(client.Metadata) > 0 {
-				info["personal_data"] = client.Metadata
+			// Fields hidden from the AI by the workspace schema are left out
+			schema, err := t.fieldSchema(ctx, ctxData, clientID)
+			if err != nil {
+				return nil, err
+			}
+			personalData := map[string]any{}
+			for key, value := range client.Metadata {
+				if def, ok := schema[key]; ok && !def.AIRead {
+					continue
+				}
+				personalData[key] = value
+			}
+			if len(personalData) > 0 {
+				info["personal_data"] = personalData
+			}
+
+			// Workspace fields the AI may fill in via update_my_info (custom_fields)
+			editable := []map[string]interface{}{}
+			for _, def := range schema {
+				if !def.AIWrite {
+					continue
+				}
+				field := map[string]interface{}{"key": def.Key, "label": def.Label, "type": string(def.Type)}
+				if def.Description != "" {
+					field["description"] = def.Description
+				}
+				if len(def.Options) > 0 {
+					field["options"] = def.Options
+				}
+				editable = append(editable, field)
+			}
+			if len(editable) > 0 {
+				sort.Slice(editable, func(i, j int) bool { return editable[i]["key"].(string) < editable[j]["key"].(string) })
+				info["editable_fields"] = editable
 			}
 
 			// Tags if exist
@@ -276,6 +407,7 @@ func (t *ClientTools) GetMyInfoTool() *domain.NativeTool {
 func (t *ClientTools) DeleteMyFieldTool() *domain.NativeTool {
 	return &domain.NativeTool{
 		IsVisible: IsClientRegistered,
+		Approval:  domainBot.ApprovalAskUser, // Borrado irreversible: pedir confirmación al usuario
 		Tool: domainMCP.Tool{
 			Name:        "delete_my_field",
 			Description: "Deletes a specific field from the user's personal profile. Use this when the user wants to remove specific information from their stored data.",
@@ -284,8 +416,7 @@ func (t *ClientTools) DeleteMyFieldTool() *domain.NativeTool {
 				"properties": map[string]interface{}{
 					"field": map[string]interface{}{
 						"type":        "string",
-						"description": "The field name to delete (e.g., 'interests', 'notes', 'custom').",
-						"enum":        AllowedClientFields,
+						"description": "The field name to delete (e.g., 'interests', 'notes', 'custom', or a key from 'editable_fields').",
 					},
 				},
 				"required": []string{"field"},
@@ -325,14 +456,11 @@ func (t *ClientTools) DeleteMyFieldTool() *domain.NativeTool {
 			}
 
 			// Verify that it is an allowed field
-			allowed := false
-			for _, f := range AllowedClientFields {
-				if f == fieldName {
-					allowed = true
-					break
-				}
+			schema, err := t.fieldSchema(ctx, ctxData, clientID)
+			if err != nil {
+				return nil, err
 			}
-			if !allowed {
+			if _, allowed := aiWritable(schema, fieldName); !allowed {
 				return map[string]interface{}{
 					"success": false,
 					"message": fmt.Sprintf("Field '%s' is not a valid profile field.", fieldName),
@@ -0,0 +1,115 @@
+package onlyclients
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/AzielCF/az-wap/botengine/domain"
+	domainMCP "github.com/AzielCF/az-wap/botengine/domain/mcp"
+	clientsApp "github.com/AzielCF/az-wap/clients/application"
+	clientsDomain "github.com/AzielCF/az-wap/clients/domain"
+	coreconfig "github.com/AzielCF/az-wap/core/config"
+	"github.com/AzielCF/az-wap/core/pkg/utils"
+)
+
+// IdentityTools lets a client link their accounts on other platforms (WhatsApp, Telegram...) to one profile
+type IdentityTools struct {
+	identityService *clientsApp.IdentityService
+}
+
+// NewIdentityTools creates a new instance of IdentityTools
+func NewIdentityTools(identityService *clientsApp.IdentityService) *IdentityTools {
+	return &IdentityTools{identityService: identityService}
+}
+
+// GetLinkCodeTool gives a registered client a one-time code to link another platform account
+func (t *IdentityTools) GetLinkCodeTool() *domain.NativeTool {
+	return &domain.NativeTool{
+		IsVisible: IsClientRegistered,
+		Tool: domainMCP.Tool{
+			Name:        "get_account_link_code",
+			Description: "Generates a one-time code so the user can link another messaging account (e.g. their Telegram or a second WhatsApp number) to their profile. Use this ONLY when the user asks to connect, link or unify another account. Tell the user to send the returned message from the other account.",
+			InputSchema: map[string]interface{}{
+				"type":       "object",
+				"properties": map[string]interface{}{},
+				"required":   []string{},
+			},
+		},
+		Handler: func(ctx context.Context, ctxData map[string]interface{}, args map[string]interface{}) (map[string]interface{}, error) {
+			cc, ok := ctxData["client_context"].(*domain.ClientContext)
+			if !ok || cc == nil || cc.ClientID == "" {
+				return map[string]interface{}{
+					"success": false,
+					"message": "I couldn't identify your client profile.",
+				}, nil
+			}
+
+			code, expiresAt, err := t.identityService.CreateLinkCode(ctx, cc.ClientID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to generate link code: %w", err)
+			}
+
+			return map[string]interface{}{
+				"success":    true,
+				"message":    fmt.Sprintf("Ask the user to send exactly \"LINK %s\" from the account they want to link. The code expires in 10 minutes.", code),
+				"code":       code,
+				"expires_at": expiresAt,
+			}, nil
+		},
+	}
+}
+
+// GetAccountLinkTool sends an unregistered sender a portal link to attach this account to an existing profile.
+// Only offered in private chats; the portal answers with a code this account must send back to finish.
+func (t *IdentityTools) GetAccountLinkTool() *domain.NativeTool {
+	return &domain.NativeTool{
+		IsVisible: func(input domain.BotInput) bool {
+			return !IsClientRegistered(input) && isPrivateChat(input)
+		},
+		Tool: domainMCP.Tool{
+			Name:        "get_account_link",
+			Description: "Generates a secure link to connect THIS messaging account to an existing client profile. Use this ONLY when the user says they already are a client on another account or platform and wants this one recognized. They must open the link, sign in to their client portal and then send here the confirmation message the portal shows them.",
+			InputSchema: map[string]interface{}{
+				"type":       "object",
+				"properties": map[string]interface{}{},
+				"required":   []string{},
+			},
+		},
+		Handler: func(ctx context.Context, ctxData map[string]interface{}, args map[string]interface{}) (map[string]interface{}, error) {
+			platform, _ := ctxData["platform"].(string)
+			senderID, _ := ctxData["sender_id"].(string)
+			secondaryID := ""
+			if metadata, ok := ctxData["metadata"].(map[string]any); ok {
+				if pn, ok := metadata["sender_pn"].(string); ok && pn != "" {
+					secondaryID = pn
+				} else if jid, ok := metadata["sender_jid"].(string); ok {
+					secondaryID = jid
+				}
+			}
+			if platform == "" || senderID == "" {
+				return map[string]interface{}{
+					"success": false,
+					"message": "I couldn't identify this account.",
+				}, nil
+			}
+
+			token, err := t.identityService.CreateLinkToken(ctx, clientsDomain.PlatformType(platform),
+				utils.CleanWhatsAppID(senderID), utils.CleanWhatsAppID(secondaryID))
+			if err != nil {
+				return nil, fmt.Errorf("failed to generate account link: %w", err)
+			}
+
+			portalURL := coreconfig.Global.App.PortalURL
+			if portalURL == "" {
+				portalURL = fmt.Sprintf("%s/portal", coreconfig.Global.App.BaseUrl)
+			}
+			link := fmt.Sprintf("%s/auth/link-account?token=%s", portalURL, token)
+
+			return map[string]interface{}{
+				"success": true,
+				"message": fmt.Sprintf("Account link generated: %s (Expires in 15m). Please send this link to the user; they must sign in to their portal and then send in this chat the \"LINK <code>\" message the portal shows them.", link),
+				"link":    link,
+			}, nil
+		},
+	}
+}
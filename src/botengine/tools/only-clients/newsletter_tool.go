@@ -94,10 +94,6 @@ func (t *NewsletterTools) SchedulePostTool() *domain.NativeTool {
 						"type":        "string",
 						"description": "ISO 8601 formatted date string for when to post (must be in future)",
 					},
-					"media_path": map[string]interface{}{
-						"type":        "string",
-						"description": "Optional absolute path to media file",
-					},
 				},
 				"required": []string{"target_name", "text", "scheduled_at"},
 			},
@@ -113,7 +109,6 @@ func (t *NewsletterTools) SchedulePostTool() *domain.NativeTool {
 
 			targetName, _ := args["target_name"].(string)
 			text, _ := args["text"].(string)
-			mediaPath, _ := args["media_path"].(string)
 			scheduledAtStr, _ := args["scheduled_at"].(string)
 
 			scheduledAt, err := time.Parse(time.RFC3339, scheduledAtStr)
@@ -132,7 +127,6 @@ func (t *NewsletterTools) SchedulePostTool() *domain.NativeTool {
 				TargetID:    targetID,
 				SenderID:    senderID,
 				Text:        text,
-				MediaPath:   mediaPath,
 				ScheduledAt: scheduledAt,
 			}
 
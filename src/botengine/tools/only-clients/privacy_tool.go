@@ -0,0 +1,226 @@
+package onlyclients
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/AzielCF/az-wap/botengine/domain"
+	domainBot "github.com/AzielCF/az-wap/botengine/domain/bot"
+	domainMCP "github.com/AzielCF/az-wap/botengine/domain/mcp"
+	clientsApp "github.com/AzielCF/az-wap/clients/application"
+	clientsDomain "github.com/AzielCF/az-wap/clients/domain"
+	coreconfig "github.com/AzielCF/az-wap/core/config"
+	"github.com/AzielCF/az-wap/core/pkg/msgworker"
+	"github.com/AzielCF/az-wap/core/pkg/utils"
+	"github.com/sirupsen/logrus"
+)
+
+// JobEraseSubject borra los datos del usuario que lo pidió desde el chat. Va por la cola durable en la
+// partición del propio chat: se ejecuta cuando termina la respuesta en curso y sobrevive a un reinicio.
+const JobEraseSubject = "privacy.erase_subject"
+
+// eraseJob identifica al sujeto sin copiar sus datos a la cola; se resuelve de nuevo al ejecutar
+type eraseJob struct {
+	ClientID   string `json:"client_id,omitempty"`
+	Platform   string `json:"platform,omitempty"`
+	SenderID   string `json:"sender_id,omitempty"`
+	InstanceID string `json:"instance_id"`
+	ChatID     string `json:"chat_id"`
+}
+
+// ChatMessenger avisa al usuario por su chat (workspace.Manager)
+type ChatMessenger interface {
+	SendText(ctx context.Context, channelID, chatID, text string) error
+}
+
+// PrivacyTools lets the user exercise their data rights (access and erasure) from the chat
+type PrivacyTools struct {
+	privacyService *clientsApp.PrivacyService
+	messenger      ChatMessenger
+}
+
+// NewPrivacyTools creates a new instance of PrivacyTools
+func NewPrivacyTools(privacyService *clientsApp.PrivacyService) *PrivacyTools {
+	return &PrivacyTools{privacyService: privacyService}
+}
+
+// SetMessenger connects the chat messenger used to report erasures that could not be completed
+func (t *PrivacyTools) SetMessenger(m ChatMessenger) {
+	t.messenger = m
+}
+
+// RegisterJobHandlers registers the erasure job handler in the worker pool
+func (t *PrivacyTools) RegisterJobHandlers(pool *msgworker.MessageWorkerPool) {
+	pool.RegisterHandler(JobEraseSubject, func(ctx context.Context, job msgworker.Job) error {
+		var p eraseJob
+		if err := json.Unmarshal(job.Payload, &p); err != nil {
+			return fmt.Errorf("invalid %s payload: %w", job.Type, err)
+		}
+		return t.erase(ctx, p)
+	})
+}
+
+// isRealChat hides the tools in the simulator and web previews: there is no real data subject there
+func isRealChat(input domain.BotInput) bool {
+	return input.Platform != domain.PlatformTest && input.Platform != domain.PlatformWeb
+}
+
+// isPrivateChat limits tools that hand out personal data links to one-to-one chats:
+// in a group every member would see the link (WhatsApp groups are @g.us, Telegram groups have negative IDs)
+func isPrivateChat(input domain.BotInput) bool {
+	return isRealChat(input) && !utils.IsGroupJID(input.ChatID) && !strings.HasPrefix(input.ChatID, "-")
+}
+
+// subject resolves the data subject of the current chat (the whole client profile if registered)
+func (t *PrivacyTools) subject(ctx context.Context, ctxData map[string]interface{}) (*clientsApp.DataSubject, error) {
+	return t.resolve(ctx, subjectRef(ctxData))
+}
+
+// subjectRef extracts from the chat context what identifies the data subject
+func subjectRef(ctxData map[string]interface{}) eraseJob {
+	var ref eraseJob
+	if cc, ok := ctxData["client_context"].(*domain.ClientContext); ok && cc != nil {
+		ref.ClientID = cc.ClientID
+	}
+	ref.Platform, _ = ctxData["platform"].(string)
+	ref.SenderID, _ = ctxData["sender_id"].(string)
+	ref.InstanceID, _ = ctxData["instance_id"].(string)
+	ref.ChatID, _ = ctxData["chat_id"].(string)
+	return ref
+}
+
+func (t *PrivacyTools) resolve(ctx context.Context, ref eraseJob) (*clientsApp.DataSubject, error) {
+	if ref.ClientID != "" {
+		subject, err := t.privacyService.SubjectForClient(ctx, ref.ClientID)
+		if err == nil || ref.Platform == "" || ref.SenderID == "" {
+			return subject, err
+		}
+		// El cliente pudo borrarse en un intento anterior: queda lo que referencia a su identidad
+	}
+	if ref.Platform == "" || ref.SenderID == "" {
+		return nil, fmt.Errorf("sender identity not available")
+	}
+	return t.privacyService.SubjectForIdentity(ctx, clientsDomain.PlatformType(ref.Platform), utils.CleanWhatsAppID(ref.SenderID))
+}
+
+// erase runs a queued erasure. Resolution errors are retried by the pool; an erasure that ran
+// but failed or could not be fully verified is reported to the user, the chat is their only way back.
+func (t *PrivacyTools) erase(ctx context.Context, ref eraseJob) error {
+	subject, err := t.resolve(ctx, ref)
+	if err != nil {
+		return fmt.Errorf("failed to resolve data subject: %w", err)
+	}
+	tombstone, err := t.privacyService.Erase(ctx, subject, clientsDomain.ErasureDelete, "chat")
+	if err != nil {
+		logrus.WithError(err).Error("[PRIVACY] Erasure requested from chat failed")
+		t.notify(ctx, ref, "We could not complete the deletion of your data. Our team has been notified and will finish it; you don't need to ask again.")
+		return nil
+	}
+	logrus.Infof("[PRIVACY] Erasure requested from chat completed: %s (verified=%v)", tombstone.ID, tombstone.Verified)
+	if !tombstone.Verified {
+		t.notify(ctx, ref, fmt.Sprintf("Your data has been deleted, but part of it could not be confirmed yet. Our team will review it (reference %s).", tombstone.ID))
+	}
+	return nil
+}
+
+func (t *PrivacyTools) notify(ctx context.Context, ref eraseJob, text string) {
+	if t.messenger == nil || ref.InstanceID == "" || ref.ChatID == "" {
+		return
+	}
+	if err := t.messenger.SendText(ctx, ref.InstanceID, ref.ChatID, text); err != nil {
+		logrus.WithError(err).Warn("[PRIVACY] Failed to notify the user about the erasure result")
+	}
+}
+
+// ExportMyDataTool sends the user a one-time link to download everything stored about them.
+// Only offered in private chats: the link is a bearer token.
+func (t *PrivacyTools) ExportMyDataTool() *domain.NativeTool {
+	return &domain.NativeTool{
+		IsVisible: isPrivateChat,
+		Approval:  domainBot.ApprovalAskUser,
+		Tool: domainMCP.Tool{
+			Name:        "export_my_data",
+			Description: "Generates a temporary link where the user can download a copy of ALL the personal data stored about them (profile, linked accounts, conversation memory, files, support conversations). Use this ONLY when the user explicitly asks for a copy or export of their data.",
+			InputSchema: map[string]interface{}{
+				"type":       "object",
+				"properties": map[string]interface{}{},
+				"required":   []string{},
+			},
+		},
+		Handler: func(ctx context.Context, ctxData map[string]interface{}, args map[string]interface{}) (map[string]interface{}, error) {
+			subject, err := t.subject(ctx, ctxData)
+			if err != nil {
+				return map[string]interface{}{
+					"success": false,
+					"message": "I couldn't identify this account.",
+				}, nil
+			}
+
+			token, expiresAt, err := t.privacyService.CreateExportToken(ctx, subject)
+			if err != nil {
+				return nil, fmt.Errorf("failed to generate export link: %w", err)
+			}
+			link := fmt.Sprintf("%s%s/api/portal/data-export/%s", coreconfig.Global.App.BaseUrl, coreconfig.Global.App.BasePath, token)
+
+			return map[string]interface{}{
+				"success":    true,
+				"message":    fmt.Sprintf("Data export link generated: %s (Works once and expires in 15m, downloads a ZIP file). Send this link to the user and remind them not to share it.", link),
+				"link":       link,
+				"expires_at": expiresAt,
+			}, nil
+		},
+	}
+}
+
+// EraseMyDataTool erases everything stored about the user once they confirm
+func (t *PrivacyTools) EraseMyDataTool() *domain.NativeTool {
+	return &domain.NativeTool{
+		IsVisible: isRealChat,
+		Approval:  domainBot.ApprovalAskUser, // Irreversible: el usuario confirma antes de ejecutar
+		Tool: domainMCP.Tool{
+			Name:        "erase_my_data",
+			Description: "Permanently erases ALL the personal data stored about the user (profile, linked accounts, subscriptions, portal account, conversation memory, files, support conversations). This cannot be undone and ends the current conversation. Use this ONLY when the user explicitly asks to delete all their data or to be forgotten; suggest export_my_data first if they may want a copy.",
+			InputSchema: map[string]interface{}{
+				"type":       "object",
+				"properties": map[string]interface{}{},
+				"required":   []string{},
+			},
+		},
+		Handler: func(ctx context.Context, ctxData map[string]interface{}, args map[string]interface{}) (map[string]interface{}, error) {
+			subject, err := t.subject(ctx, ctxData)
+			if err != nil {
+				return map[string]interface{}{
+					"success": false,
+					"message": "I couldn't identify this account.",
+				}, nil
+			}
+
+			// Encolado en la partición del chat: corre después de enviar esta respuesta
+			ref := subjectRef(ctxData)
+			ref.ClientID = subject.ClientID
+			if ref.InstanceID == "" || ref.ChatID == "" {
+				return nil, fmt.Errorf("failed to schedule erasure: chat not available")
+			}
+			job, err := msgworker.NewJob(JobEraseSubject, ref.InstanceID, ref.ChatID, ref)
+			if err != nil {
+				return nil, fmt.Errorf("failed to schedule erasure: %w", err)
+			}
+			workspaceID, _ := ctxData["workspace_id"].(string)
+			job.Pinned, job.Tenant = true, workspaceID
+			job.Handler = func(ctx context.Context) error { return t.erase(ctx, ref) }
+			if !msgworker.GetGlobalPool().TryDispatch(job) {
+				return map[string]interface{}{
+					"success": false,
+					"message": "The erasure could not be scheduled right now. Nothing was deleted; ask the user to try again in a few minutes.",
+				}, nil
+			}
+
+			return map[string]interface{}{
+				"success": true,
+				"message": "Erasure scheduled: all the user's data will be permanently deleted as soon as this reply is delivered. Tell the user this conversation will end, that writing again afterwards starts from scratch, and that they will be told here if part of it cannot be completed.",
+			}, nil
+		},
+	}
+}
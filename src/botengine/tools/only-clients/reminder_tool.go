@@ -42,6 +42,10 @@ func (t *ReminderTools) ScheduleReminderTool() *domain.NativeTool {
 						"type":        "string",
 						"description": "Time HH:MM. Calculate based on context.",
 					},
+					"rrule": map[string]interface{}{
+						"type":        "string",
+						"description": "Optional. RFC 5545 recurrence rule for repeating reminders, evaluated in the user's timezone. Supports FREQ=DAILY|WEEKLY|MONTHLY, INTERVAL, BYDAY (MO,TU... or 1MO/-1FR for monthly), BYMONTHDAY, COUNT and UNTIL. Examples: 'FREQ=DAILY', 'FREQ=WEEKLY;BYDAY=MO,WE,FR', 'FREQ=MONTHLY;BYDAY=-1FR;COUNT=6'. Omit for one-off reminders.",
+					},
 				},
 				"required": []string{"text", "date", "time"},
 			},
@@ -56,6 +60,7 @@ func (t *ReminderTools) ScheduleReminderTool() *domain.NativeTool {
 			dateStr, _ := args["date"].(string)
 			timeStr, _ := args["time"].(string)
 			recurrenceDays, _ := args["recurrence_days"].(string)
+			rrule, _ := args["rrule"].(string)
 
 			// Resolve Location
 			loc := t.resolveLocation(ctxData)
@@ -72,9 +77,12 @@ func (t *ReminderTools) ScheduleReminderTool() *domain.NativeTool {
 				ChannelID:      instanceID,
 				TargetID:       senderID,
 				SenderID:       senderID,
+				ClientID:       clientIDFrom(ctxData),
 				Text:           text,
 				ScheduledAt:    scheduledAt,
 				RecurrenceDays: recurrenceDays,
+				RRule:          rrule,
+				Timezone:       loc.String(),
 			}
 
 			post, err := t.service.SchedulePost(ctx, req)
@@ -92,6 +100,83 @@ func (t *ReminderTools) ScheduleReminderTool() *domain.NativeTool {
 	}
 }
 
+// ScheduleAITaskTool schedules a proactive task: when due, the bot runs the instruction
+// (with its tools) and sends the result to the user.
+func (t *ReminderTools) ScheduleAITaskTool() *domain.NativeTool {
+	return &domain.NativeTool{
+		IsVisible: IsClientRegistered,
+		Tool: domainMCP.Tool{
+			Name:        "schedule_ai_task",
+			Description: "Schedules a task that YOU will carry out later and then message the user with the result (e.g. 'every Monday summarize my pending reminders', 'tomorrow at 9 check the exchange rate and tell me'). Use schedule_reminder instead when the message is fixed text.",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"instruction": map[string]interface{}{
+						"type":        "string",
+						"description": "What you must do when the task runs, written as an instruction to yourself with all the context needed (e.g. 'Check the USD to PEN exchange rate and tell the user if it is below 3.70'). You will not remember this conversation when it runs.",
+					},
+					"date": map[string]interface{}{
+						"type":        "string",
+						"description": "Date YYYY-MM-DD of the first run. Calculate based on user's relative request (today, tomorrow, next monday).",
+					},
+					"time": map[string]interface{}{
+						"type":        "string",
+						"description": "Time HH:MM of the first run.",
+					},
+					"rrule": map[string]interface{}{
+						"type":        "string",
+						"description": "Optional. RFC 5545 recurrence rule evaluated in the user's timezone, e.g. 'FREQ=WEEKLY;BYDAY=MO'. Omit for one-off tasks.",
+					},
+				},
+				"required": []string{"instruction", "date", "time"},
+			},
+		},
+		Handler: func(ctx context.Context, ctxData map[string]interface{}, args map[string]interface{}) (map[string]interface{}, error) {
+			instanceID, senderID, err := t.extractIDs(ctxData)
+			if err != nil {
+				return nil, err
+			}
+
+			instruction, _ := args["instruction"].(string)
+			dateStr, _ := args["date"].(string)
+			timeStr, _ := args["time"].(string)
+			rrule, _ := args["rrule"].(string)
+			if strings.TrimSpace(instruction) == "" {
+				return nil, fmt.Errorf("instruction is required")
+			}
+
+			loc := t.resolveLocation(ctxData)
+			scheduledAt, err := t.parseVariableTime(dateStr, timeStr, loc, time.Now().In(loc))
+			if err != nil {
+				return nil, err
+			}
+
+			clientID := clientIDFrom(ctxData)
+
+			post, err := t.service.SchedulePost(ctx, domainNewsletter.SchedulePostRequest{
+				ChannelID:   instanceID,
+				TargetID:    senderID,
+				SenderID:    senderID,
+				ClientID:    clientID,
+				Text:        instruction,
+				ScheduledAt: scheduledAt,
+				RRule:       rrule,
+				Timezone:    loc.String(),
+				Payload:     aiTaskPayload(instruction, clientID),
+			})
+			if err != nil {
+				return nil, err
+			}
+
+			return map[string]interface{}{
+				"status":       "scheduled",
+				"scheduled_at": post.ScheduledAt.In(loc).Format("Mon 02 Jan 15:04"),
+				"message":      fmt.Sprintf("Task scheduled for %s. You will run it then and message the user with the result.", post.ScheduledAt.In(loc).Format("Mon, 02 Jan 15:04")),
+			}, nil
+		},
+	}
+}
+
 func (t *ReminderTools) ListPendingRemindersTool() *domain.NativeTool {
 	return &domain.NativeTool{
 		IsVisible: IsClientRegistered,
@@ -389,9 +474,16 @@ func (t *ReminderTools) UpdateReminderTool() *domain.NativeTool {
 				ChannelID:      instanceID,
 				TargetID:       senderID,
 				SenderID:       senderID,
+				ClientID:       clientIDFrom(ctxData),
 				Text:           finalText,
 				ScheduledAt:    finalTime,
 				RecurrenceDays: original.RecurrenceDays,
+				RRule:          original.RRule,
+				Timezone:       original.Timezone,
+			}
+			// AI tasks keep their payload; the new text is the new instruction
+			if original.IsAITask() {
+				req.Payload = withAIInstruction(original.Payload, finalText)
 			}
 
 			post, err := t.service.SchedulePost(ctx, req)
@@ -409,6 +501,117 @@ func (t *ReminderTools) UpdateReminderTool() *domain.NativeTool {
 	}
 }
 
+func (t *ReminderTools) SkipReminderOccurrenceTool() *domain.NativeTool {
+	return &domain.NativeTool{
+		IsVisible: IsClientRegistered,
+		Tool: domainMCP.Tool{
+			Name:        "skip_reminder_occurrence",
+			Description: "Skips ONLY the next occurrence of a REPEATING reminder (e.g. 'skip tomorrow's gym reminder'). The series continues afterwards. For one-off reminders use cancel_reminder.",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"query": map[string]interface{}{
+						"type":        "string",
+						"description": "Text description to identify the reminder (e.g., 'gym', 'pills').",
+					},
+				},
+				"required": []string{"query"},
+			},
+		},
+		Handler: func(ctx context.Context, ctxData map[string]interface{}, args map[string]interface{}) (map[string]interface{}, error) {
+			loc := t.resolveLocation(ctxData)
+			targetID, err := t.findReminder(ctx, ctxData, args, loc)
+			if err != nil {
+				return nil, err
+			}
+
+			post, err := t.service.SkipOccurrence(ctx, targetID)
+			if err != nil {
+				return nil, err
+			}
+
+			if post.Status == wsCommonDomain.ScheduledPostStatusCancelled {
+				return map[string]interface{}{
+					"status":  "finished",
+					"message": "That was the last occurrence, the repeating reminder has ended",
+				}, nil
+			}
+			return map[string]interface{}{
+				"status":       "skipped",
+				"scheduled_at": post.ScheduledAt.In(loc).Format("Mon 02 Jan 15:04"),
+				"message":      fmt.Sprintf("Occurrence skipped. Next one: %s", post.ScheduledAt.In(loc).Format("Mon, 02 Jan 15:04")),
+			}, nil
+		},
+	}
+}
+
+func (t *ReminderTools) SnoozeReminderTool() *domain.NativeTool {
+	return &domain.NativeTool{
+		IsVisible: IsClientRegistered,
+		Tool: domainMCP.Tool{
+			Name:        "snooze_reminder",
+			Description: "Postpones the next occurrence of a reminder (e.g. 'remind me 30 minutes later', 'move today's reminder to 6pm') without changing its repeating schedule.",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"query": map[string]interface{}{
+						"type":        "string",
+						"description": "Text description to identify the reminder (e.g., 'gym', 'pills').",
+					},
+					"minutes": map[string]interface{}{
+						"type":        "integer",
+						"description": "Postpone by this many minutes from the reminder's current time. Use this OR new_date/new_time.",
+					},
+					"new_date": map[string]interface{}{
+						"type":        "string",
+						"description": "New date YYYY-MM-DD for this occurrence. Optional.",
+					},
+					"new_time": map[string]interface{}{
+						"type":        "string",
+						"description": "New time HH:MM for this occurrence. Optional.",
+					},
+				},
+				"required": []string{"query"},
+			},
+		},
+		Handler: func(ctx context.Context, ctxData map[string]interface{}, args map[string]interface{}) (map[string]interface{}, error) {
+			loc := t.resolveLocation(ctxData)
+			targetID, err := t.findReminder(ctx, ctxData, args, loc)
+			if err != nil {
+				return nil, err
+			}
+
+			newDate, _ := args["new_date"].(string)
+			newTime, _ := args["new_time"].(string)
+			minutes, _ := args["minutes"].(float64)
+
+			var until time.Time
+			switch {
+			case minutes > 0:
+				until = time.Now().In(loc).Add(time.Duration(minutes) * time.Minute)
+			case newDate != "" || newTime != "":
+				until, err = t.parseVariableTime(newDate, newTime, loc, time.Now().In(loc))
+				if err != nil {
+					return nil, err
+				}
+			default:
+				return nil, fmt.Errorf("provide minutes or a new date/time to snooze the reminder")
+			}
+
+			post, err := t.service.SnoozeOccurrence(ctx, targetID, until)
+			if err != nil {
+				return nil, err
+			}
+
+			return map[string]interface{}{
+				"status":       "snoozed",
+				"scheduled_at": post.ScheduledAt.In(loc).Format("Mon 02 Jan 15:04"),
+				"message":      fmt.Sprintf("Reminder postponed to %s", post.ScheduledAt.In(loc).Format("Mon, 02 Jan 15:04")),
+			}, nil
+		},
+	}
+}
+
 func (t *ReminderTools) CountRemindersTool() *domain.NativeTool {
 	return &domain.NativeTool{
 		IsVisible: IsClientRegistered,
@@ -516,6 +719,61 @@ func (t *ReminderTools) extractIDs(ctxData map[string]interface{}) (string, stri
 	return instanceID, senderID, nil
 }
 
+// findReminder resolves the reminder described by args["query"] among the sender's active ones.
+func (t *ReminderTools) findReminder(ctx context.Context, ctxData map[string]interface{}, args map[string]interface{}, loc *time.Location) (string, error) {
+	instanceID, senderID, err := t.extractIDs(ctxData)
+	if err != nil {
+		return "", err
+	}
+
+	query, _ := args["query"].(string)
+	if query == "" {
+		return "", fmt.Errorf("query description is required to find the reminder")
+	}
+
+	posts, err := t.service.ListScheduledBySender(ctx, instanceID, senderID)
+	if err != nil {
+		return "", err
+	}
+
+	targetID := t.findBestMatch(posts, query, "", "", loc)
+	if targetID == "" {
+		return "", fmt.Errorf("could not find any reminder matching '%s'", query)
+	}
+	return targetID, nil
+}
+
+// clientIDFrom returns the client resolved for the chat, whose subscription limits recurring reminders.
+func clientIDFrom(ctxData map[string]interface{}) string {
+	if cc, ok := ctxData["client_context"].(*domain.ClientContext); ok && cc != nil {
+		return cc.ClientID
+	}
+	return ""
+}
+
+func aiTaskPayload(instruction, clientID string) *wsCommonDomain.ScheduledPayload {
+	return &wsCommonDomain.ScheduledPayload{Parts: []wsCommonDomain.ScheduledPayloadPart{{
+		Type: wsCommonDomain.PayloadPartAI,
+		AI:   &wsCommonDomain.ScheduledAITask{Instruction: instruction, ClientID: clientID},
+	}}}
+}
+
+// withAIInstruction copies a payload giving its AI part a new instruction; the other parts are kept as they were
+func withAIInstruction(payload *wsCommonDomain.ScheduledPayload, instruction string) *wsCommonDomain.ScheduledPayload {
+	updated := &wsCommonDomain.ScheduledPayload{Parts: make([]wsCommonDomain.ScheduledPayloadPart, len(payload.Parts))}
+	copy(updated.Parts, payload.Parts)
+	for i, part := range updated.Parts {
+		if part.Type != wsCommonDomain.PayloadPartAI || part.AI == nil {
+			continue
+		}
+		task := *part.AI
+		task.Instruction = instruction
+		updated.Parts[i].AI = &task
+		break
+	}
+	return updated
+}
+
 // Flexible time parser
 func (t *ReminderTools) parseVariableTime(dateStr, timeStr string, loc *time.Location, referenceTime time.Time) (time.Time, error) {
 	// If both are empty, return the reference time unchanged
@@ -635,6 +893,14 @@ func (t *ReminderTools) formatRemindersAsText(posts []wsCommonDomain.ScheduledPo
 	var sb strings.Builder
 	for _, p := range posts {
 		timeStr := p.ScheduledAt.In(loc).Format("Mon 02 Jan 15:04")
+		if p.IsRecurring() {
+			if rule, err := p.Rule(); err == nil {
+				timeStr += " | repeats " + rule.String()
+			}
+		}
+		if p.IsAITask() {
+			timeStr += " | ai task"
+		}
 		// Label as internal hint to discourage verbatim copying
 		sb.WriteString(fmt.Sprintf("- [%s] INTERNAL_SUBJECT_HINT: %s\n", timeStr, p.Text))
 	}
@@ -0,0 +1,140 @@
+package botengine
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/AzielCF/az-wap/botengine/domain"
+	"github.com/AzielCF/az-wap/botengine/domain/bot"
+	botmonitor "github.com/AzielCF/az-wap/botengine/infrastructure/monitoring"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	speechLinkRe   = regexp.MustCompile(`\[([^\]]+)\]\([^)]+\)`)
+	speechURLRe    = regexp.MustCompile(`https?://\S+`)
+	speechMarkupRe = regexp.MustCompile("[*_~`#>]+")
+)
+
+// hasVoiceNote indica si el usuario envió audio en este turno
+func hasVoiceNote(medias []*domain.BotMedia) bool {
+	for _, m := range medias {
+		if m != nil && strings.HasPrefix(strings.ToLower(m.MimeType), "audio/") {
+			return true
+		}
+	}
+	return false
+}
+
+// speakableText quita el formato markdown y los enlaces, que no se pueden leer en voz alta
+func speakableText(text string) string {
+	text = speechLinkRe.ReplaceAllString(text, "$1")
+	text = speechURLRe.ReplaceAllString(text, "")
+	text = speechMarkupRe.ReplaceAllString(text, "")
+	return strings.TrimSpace(text)
+}
+
+// sendVoiceReply sintetiza la respuesta y la envía como nota de voz cuando la política del bot lo pide.
+// Devuelve false si la respuesta debe seguir saliendo como texto (política, transporte o fallo del TTS).
+func (e *Engine) sendVoiceReply(ctx context.Context, transport domain.Transport, b bot.Bot, input domain.BotInput, output *domain.BotOutput, userSentVoice, aiWantsVoice bool) bool {
+	cfg := b.Voice
+	text := speakableText(output.Text)
+	if !cfg.Wants(userSentVoice, aiWantsVoice, text) {
+		return false
+	}
+
+	vt, ok := transport.(domain.VoiceTransport)
+	if !ok {
+		logrus.Debugf("[VOICE] Transport %s cannot send voice notes, replying with text", transport.ID())
+		return false
+	}
+
+	providerName := cfg.Provider
+	if providerName == "" {
+		providerName = string(b.Provider)
+	}
+	if providerName == "" {
+		providerName = "ai"
+	}
+	synth, ok := e.synthesizers[providerName]
+	if !ok {
+		logrus.Warnf("[VOICE] No speech synthesizer registered for %s (bot %s)", providerName, b.ID)
+		return false
+	}
+
+	apiKey := cfg.APIKey
+	if apiKey == "" {
+		apiKey = b.APIKey
+	}
+	language := cfg.Language
+	if language == "" {
+		language = input.Language
+	}
+
+	audio, err := synth.Synthesize(ctx, apiKey, domain.SpeechRequest{
+		Text:     text,
+		Model:    cfg.Model,
+		Voice:    cfg.Voice,
+		Language: language,
+		Speed:    cfg.Speed,
+	})
+	if err != nil {
+		logrus.WithError(err).Warnf("[VOICE] Speech synthesis failed for trace %s, falling back to text", input.TraceID)
+		botmonitor.Record(botmonitor.Event{
+			TraceID:    input.TraceID,
+			InstanceID: input.InstanceID, ChatJID: input.ChatID,
+			Provider: providerName, Stage: "voice_reply", Status: "error",
+			Error: err.Error(),
+		})
+		return false
+	}
+
+	// El costo del TTS se suma al de la ejecución para que la facturación lo vea
+	if audio.Usage != nil && audio.Usage.CostUSD > 0 {
+		output.TotalCost += audio.Usage.CostUSD
+		output.CostDetails = append(output.CostDetails, domain.ExecutionCost{
+			BotID: b.ID,
+			Model: audio.Usage.Model,
+			Cost:  audio.Usage.CostUSD,
+		})
+	}
+
+	duration := audio.Duration
+	if duration <= 0 {
+		duration = domain.EstimateSpeechDuration(text, cfg.Speed)
+	}
+	if !e.humanizer.SimulateRecording(ctx, transport, input.ChatID, duration) {
+		return true // Contexto cancelado: tampoco enviamos texto
+	}
+
+	if err := vt.SendVoice(ctx, input.ChatID, audio.Data, audio.MimeType); err != nil {
+		logrus.WithError(err).Warnf("[VOICE] Failed to send voice note to %s, falling back to text", input.ChatID)
+		return false
+	}
+
+	if output.Metadata == nil {
+		output.Metadata = make(map[string]any)
+	}
+	output.Metadata["voice_reply"] = true
+
+	spoken := "[REDACTED]"
+	if input.IsTester || (input.ClientContext != nil && input.ClientContext.IsTester) {
+		spoken = output.Text
+	}
+	botmonitor.Record(botmonitor.Event{
+		TraceID:    input.TraceID,
+		InstanceID: input.InstanceID, ChatJID: input.ChatID,
+		Provider: fmt.Sprintf("%s / %s", input.Platform, providerName),
+		Stage:    "outbound", Status: "ok",
+		Metadata: map[string]string{
+			"trace_id":             input.TraceID,
+			"voice_reply":          "true",
+			"output":               spoken,
+			"duration":             duration.String(),
+			"total_execution_cost": fmt.Sprintf("$%.6f", output.TotalCost),
+		},
+	})
+	return true
+}
@@ -0,0 +1,170 @@
+package rest
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/AzielCF/az-wap/clients/application"
+	"github.com/AzielCF/az-wap/clients/domain"
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+)
+
+// ImportClients importa clientes desde CSV o JSONL (multipart "file" o cuerpo crudo).
+// Query: format=csv|jsonl, mode=skip|update, dry_run=true, workspace_id (esquema de campos)
+func (h *ClientHandler) ImportClients(c *fiber.Ctx) error {
+	var body io.Reader
+	filename := ""
+	if file, err := c.FormFile("file"); err == nil {
+		f, err := file.Open()
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Failed to read uploaded file"})
+		}
+		defer f.Close()
+		body = f
+		filename = file.Filename
+	} else {
+		if len(c.Body()) == 0 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "file is required"})
+		}
+		body = bytes.NewReader(c.Body())
+	}
+
+	opts := application.ImportOptions{
+		Format:      bulkFormat(c.Query("format"), filename, c.Get(fiber.HeaderContentType)),
+		Mode:        application.ImportMode(c.Query("mode", string(application.ImportSkip))),
+		WorkspaceID: c.Query("workspace_id"),
+		DryRun:      c.QueryBool("dry_run", false),
+	}
+
+	report, err := h.bulkService.Import(c.Context(), body, opts)
+	if err != nil {
+		if report == nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{"error": err.Error(), "report": report})
+	}
+	return c.JSON(report)
+}
+
+// ExportClients exporta en streaming los clientes que cumplen los filtros de /clients.
+// Query: format=csv|jsonl, tier, tag (separados por coma), enabled, search, workspace_id, include_pii
+func (h *ClientHandler) ExportClients(c *fiber.Ctx) error {
+	opts := application.ExportOptions{
+		Format:      application.BulkFormat(c.Query("format", string(application.FormatCSV))),
+		WorkspaceID: c.Query("workspace_id"),
+		IncludePII:  c.QueryBool("include_pii", false),
+		Filter:      domain.ClientFilter{Search: c.Query("search")},
+	}
+	if opts.Format != application.FormatCSV && opts.Format != application.FormatJSONL {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "format must be csv or jsonl"})
+	}
+	if tier := c.Query("tier"); tier != "" {
+		t := domain.ClientTier(tier)
+		opts.Filter.Tier = &t
+	}
+	if enabled := c.Query("enabled"); enabled != "" {
+		e := enabled == "true"
+		opts.Filter.Enabled = &e
+	}
+	for _, tag := range strings.Split(c.Query("tag"), ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			opts.Filter.Tags = append(opts.Filter.Tags, tag)
+		}
+	}
+
+	contentType := "text/csv; charset=utf-8"
+	if opts.Format == application.FormatJSONL {
+		contentType = "application/x-ndjson"
+	}
+	c.Set(fiber.HeaderContentType, contentType)
+	c.Attachment(fmt.Sprintf("clients-%s.%s", time.Now().Format("20060102-150405"), opts.Format))
+
+	// El handler retorna antes de que termine la escritura: no usar el contexto de fiber
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		count, err := h.bulkService.Export(context.Background(), w, opts)
+		if err != nil {
+			logrus.WithError(err).Errorf("[CLIENTS] Export interrupted after %d clients", count)
+		}
+		_ = w.Flush()
+	})
+	return nil
+}
+
+// ListClientFields lista el esquema de campos personalizados de un workspace
+func (h *ClientHandler) ListClientFields(c *fiber.Ctx) error {
+	fields, err := h.fieldService.List(c.Context(), c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{"data": fields})
+}
+
+// SaveClientField crea o reemplaza la definición de un campo personalizado
+func (h *ClientHandler) SaveClientField(c *fiber.Ctx) error {
+	workspaceID := c.Params("id")
+	if _, err := h.wsRepo.GetByID(c.Context(), workspaceID); err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Workspace not found"})
+	}
+
+	var req SaveClientFieldRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	field := &domain.CustomField{
+		WorkspaceID: workspaceID,
+		Key:         c.Params("key"),
+		Label:       req.Label,
+		Description: req.Description,
+		Type:        domain.FieldType(req.Type),
+		Options:     req.Options,
+		Pattern:     req.Pattern,
+		MaxLength:   req.MaxLength,
+		PII:         req.PII,
+		AIRead:      req.AIRead == nil || *req.AIRead,
+		AIWrite:     req.AIWrite,
+	}
+	if err := h.fieldService.Save(c.Context(), field); err != nil {
+		if errors.Is(err, domain.ErrInvalidField) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(field)
+}
+
+// DeleteClientField elimina la definición de un campo (los valores ya guardados se conservan)
+func (h *ClientHandler) DeleteClientField(c *fiber.Ctx) error {
+	if err := h.fieldService.Delete(c.Context(), c.Params("id"), c.Params("key")); err != nil {
+		if errors.Is(err, domain.ErrFieldNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// bulkFormat decide el formato por query, extensión del archivo o content-type (CSV por defecto)
+func bulkFormat(query, filename, contentType string) application.BulkFormat {
+	if query != "" {
+		return application.BulkFormat(strings.ToLower(query))
+	}
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".jsonl", ".ndjson":
+		return application.FormatJSONL
+	case ".csv":
+		return application.FormatCSV
+	}
+	if strings.Contains(contentType, "ndjson") || strings.Contains(contentType, "jsonl") {
+		return application.FormatJSONL
+	}
+	return application.FormatCSV
+}
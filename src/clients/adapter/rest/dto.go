@@ -73,3 +73,51 @@ type UpdateSubscriptionRequest struct {
 	ClearInactivityWarning bool           `json:"clear_inactivity_warning"`
 	ClearMaxHistoryLimit   bool           `json:"clear_max_history_limit"`
 }
+
+// LinkIdentityRequest representa la petición para vincular una identidad de plataforma a un cliente
+type LinkIdentityRequest struct {
+	PlatformID   string `json:"platform_id"`
+	PlatformType string `json:"platform_type"`
+}
+
+// MergeClientRequest representa la petición para fusionar otro cliente dentro del actual
+type MergeClientRequest struct {
+	SourceID string `json:"source_id"`
+}
+
+// SaveClientFieldRequest representa la definición de un campo personalizado de cliente
+type SaveClientFieldRequest struct {
+	Label       string   `json:"label"`
+	Description string   `json:"description"`
+	Type        string   `json:"type"`
+	Options     []string `json:"options"`
+	Pattern     string   `json:"pattern"`
+	MaxLength   int      `json:"max_length"`
+	PII         bool     `json:"pii"`
+	AIRead      *bool    `json:"ai_read"` // Por defecto true
+	AIWrite     bool     `json:"ai_write"`
+}
+
+// EraseDataRequest DTO para suprimir o anonimizar los datos de un sujeto
+type EraseDataRequest struct {
+	PlatformType string `json:"platform_type"` // Solo /data-subjects/erase
+	PlatformID   string `json:"platform_id"`   // Solo /data-subjects/erase
+	Mode         string `json:"mode"`          // erase | anonymize (default: erase)
+	Confirm      bool   `json:"confirm"`       // Obligatorio: la operación es irreversible
+}
+
+// RenewSubscriptionRequest DTO para renovar una suscripción desde el panel
+type RenewSubscriptionRequest struct {
+	Days      int        `json:"days"`      // Se suman al vencimiento actual (o a ahora si ya venció)
+	Until     *time.Time `json:"until"`     // Fecha exacta; tiene prioridad sobre days
+	Reference string     `json:"reference"` // Opcional: id del pago registrado a mano
+	Shorten   bool       `json:"shorten"`   // Confirma un until anterior al vencimiento actual
+}
+
+// PaymentCallbackRequest DTO del callback firmado del proveedor de pagos
+type PaymentCallbackRequest struct {
+	SubscriptionID string     `json:"subscription_id"`
+	Reference      string     `json:"reference"` // Id único del pago (idempotencia)
+	Days           int        `json:"days"`
+	Until          *time.Time `json:"until"`
+}
@@ -12,19 +12,25 @@ import (
 
 // ClientHandler maneja las peticiones REST para clientes
 type ClientHandler struct {
-	clientService *application.ClientService
-	subService    *application.SubscriptionService
-	wsRepo        wsRepo.IWorkspaceRepository
-	wsUc          *wsUcase.WorkspaceUsecase
+	clientService   *application.ClientService
+	subService      *application.SubscriptionService
+	identityService *application.IdentityService
+	bulkService     *application.BulkService
+	fieldService    *application.FieldService
+	wsRepo          wsRepo.IWorkspaceRepository
+	wsUc            *wsUcase.WorkspaceUsecase
 }
 
 // NewClientHandler crea una nueva instancia del handler
-func NewClientHandler(clientService *application.ClientService, subService *application.SubscriptionService, wsRepo wsRepo.IWorkspaceRepository, wsUc *wsUcase.WorkspaceUsecase) *ClientHandler {
+func NewClientHandler(clientService *application.ClientService, subService *application.SubscriptionService, identityService *application.IdentityService, bulkService *application.BulkService, fieldService *application.FieldService, wsRepo wsRepo.IWorkspaceRepository, wsUc *wsUcase.WorkspaceUsecase) *ClientHandler {
 	return &ClientHandler{
-		clientService: clientService,
-		subService:    subService,
-		wsRepo:        wsRepo,
-		wsUc:          wsUc,
+		clientService:   clientService,
+		subService:      subService,
+		identityService: identityService,
+		bulkService:     bulkService,
+		fieldService:    fieldService,
+		wsRepo:          wsRepo,
+		wsUc:            wsUc,
 	}
 }
 
@@ -37,6 +43,8 @@ func (h *ClientHandler) RegisterRoutes(router fiber.Router) {
 	clients.Post("/", h.CreateClient)
 	clients.Get("/search", h.SearchClients)
 	clients.Get("/stats", h.GetStats)
+	clients.Get("/export", h.ExportClients)
+	clients.Post("/import", h.ImportClients)
 	clients.Get("/:id", h.GetClient)
 	clients.Put("/:id", h.UpdateClient)
 	clients.Delete("/:id", h.DeleteClient)
@@ -51,6 +59,14 @@ func (h *ClientHandler) RegisterRoutes(router fiber.Router) {
 	clients.Put("/:id/subscriptions/:subId", h.UpdateSubscription)
 	clients.Delete("/:id/subscriptions/:subId", h.DeleteSubscription)
 
+	// Identidades de plataforma (mismo cliente en WhatsApp, Telegram...)
+	clients.Get("/:id/identities", h.ListIdentities)
+	clients.Post("/:id/identities", h.LinkIdentity)
+	clients.Post("/:id/identities/link-code", h.CreateLinkCode)
+	clients.Delete("/:id/identities/:identityId", h.UnlinkIdentity)
+	clients.Post("/:id/merge", h.MergeClient)
+	clients.Post("/:id/split", h.SplitClient)
+
 	// Tier management
 	clients.Put("/:id/tier", h.UpdateTier)
 	clients.Put("/:id/enable", h.EnableClient)
@@ -59,6 +75,11 @@ func (h *ClientHandler) RegisterRoutes(router fiber.Router) {
 	// Suscripciones por canal
 	router.Get("/channels/:channelId/subscribers", h.ListChannelSubscribers)
 
+	// Esquema de campos personalizados (metadata) por workspace
+	router.Get("/workspaces/:id/client-fields", h.ListClientFields)
+	router.Put("/workspaces/:id/client-fields/:key", h.SaveClientField)
+	router.Delete("/workspaces/:id/client-fields/:key", h.DeleteClientField)
+
 	// Canales del cliente
 	clients.Get("/:id/channels", h.ListClientChannels)
 
@@ -0,0 +1,88 @@
+package rest
+
+import (
+	"errors"
+
+	"github.com/AzielCF/az-wap/clients/domain"
+	"github.com/gofiber/fiber/v2"
+)
+
+// ListIdentities lista las identidades de plataforma de un cliente
+func (h *ClientHandler) ListIdentities(c *fiber.Ctx) error {
+	identities, err := h.identityService.List(c.Context(), c.Params("id"))
+	if err != nil {
+		return identityError(c, err)
+	}
+	return c.JSON(fiber.Map{"data": identities})
+}
+
+// LinkIdentity vincula una identidad de plataforma a un cliente
+func (h *ClientHandler) LinkIdentity(c *fiber.Ctx) error {
+	var req LinkIdentityRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	identity, err := h.identityService.Link(c.Context(), c.Params("id"), req.PlatformID, domain.PlatformType(req.PlatformType))
+	if err != nil {
+		return identityError(c, err)
+	}
+	return c.Status(fiber.StatusCreated).JSON(identity)
+}
+
+// UnlinkIdentity desvincula una identidad secundaria de un cliente
+func (h *ClientHandler) UnlinkIdentity(c *fiber.Ctx) error {
+	if err := h.identityService.Unlink(c.Context(), c.Params("id"), c.Params("identityId")); err != nil {
+		return identityError(c, err)
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// CreateLinkCode genera un código de vinculación para que el cliente lo envíe desde otra plataforma
+func (h *ClientHandler) CreateLinkCode(c *fiber.Ctx) error {
+	code, expiresAt, err := h.identityService.CreateLinkCode(c.Context(), c.Params("id"))
+	if err != nil {
+		return identityError(c, err)
+	}
+	return c.JSON(fiber.Map{"code": code, "message": "LINK " + code, "expires_at": expiresAt})
+}
+
+// MergeClient fusiona el cliente source_id dentro del cliente de la ruta
+func (h *ClientHandler) MergeClient(c *fiber.Ctx) error {
+	var req MergeClientRequest
+	if err := c.BodyParser(&req); err != nil || req.SourceID == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "source_id is required"})
+	}
+
+	client, err := h.identityService.Merge(c.Context(), c.Params("id"), req.SourceID)
+	if err != nil {
+		return identityError(c, err)
+	}
+	return c.JSON(client)
+}
+
+// SplitClient separa identidades (y suscripciones) del cliente en un cliente nuevo
+func (h *ClientHandler) SplitClient(c *fiber.Ctx) error {
+	var req domain.SplitRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	client, err := h.identityService.Split(c.Context(), c.Params("id"), req)
+	if err != nil {
+		return identityError(c, err)
+	}
+	return c.Status(fiber.StatusCreated).JSON(client)
+}
+
+func identityError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, domain.ErrClientNotFound), errors.Is(err, domain.ErrIdentityNotFound), errors.Is(err, domain.ErrSubscriptionNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, domain.ErrIdentityLinked), errors.Is(err, domain.ErrDuplicateClient):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, domain.ErrPrimaryIdentity), errors.Is(err, domain.ErrClientDisabled):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+}
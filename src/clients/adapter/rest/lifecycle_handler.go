@@ -0,0 +1,134 @@
+package rest
+
+import (
+	"crypto/hmac"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/AzielCF/az-wap/clients/application"
+	"github.com/AzielCF/az-wap/clients/domain"
+	pkgUtils "github.com/AzielCF/az-wap/core/pkg/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+)
+
+// PaymentCallbackPath es la ruta pública (sin basic auth) del callback de pagos, relativa a /api
+const PaymentCallbackPath = "/billing/payment-callback"
+
+// paymentCallbackTolerance es la antigüedad máxima de X-Signature-Timestamp: un callback
+// capturado no se puede reenviar más tarde
+const paymentCallbackTolerance = 5 * time.Minute
+
+// LifecycleHandler expone las renovaciones de suscripciones y el callback firmado de pagos
+type LifecycleHandler struct {
+	lifecycle     *application.LifecycleService
+	subService    *application.SubscriptionService
+	paymentSecret string
+}
+
+// NewLifecycleHandler crea el handler. Sin paymentSecret el callback de pagos responde 404.
+func NewLifecycleHandler(lifecycle *application.LifecycleService, subService *application.SubscriptionService, paymentSecret string) *LifecycleHandler {
+	return &LifecycleHandler{lifecycle: lifecycle, subService: subService, paymentSecret: paymentSecret}
+}
+
+// RegisterRoutes registra las rutas de ciclo de vida en el router de Fiber
+func (h *LifecycleHandler) RegisterRoutes(router fiber.Router) {
+	router.Post("/clients/:id/subscriptions/:subId/renew", h.RenewSubscription)
+	router.Post("/subscriptions/lifecycle/run", h.RunLifecycle)
+	router.Post(PaymentCallbackPath, h.PaymentCallback)
+}
+
+// RenewSubscription extiende una suscripción desde el panel (days o until)
+func (h *LifecycleHandler) RenewSubscription(c *fiber.Ctx) error {
+	sub, err := h.subService.GetByID(c.Context(), c.Params("subId"))
+	if err != nil {
+		return renewalError(c, err)
+	}
+	if sub.ClientID != c.Params("id") {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Subscription does not belong to this client"})
+	}
+
+	var req RenewSubscriptionRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	sub, err = h.lifecycle.Renew(c.Context(), sub.ID, domain.RenewRequest{Days: req.Days, Until: req.Until, Source: "admin", Reference: req.Reference, Shorten: req.Shorten})
+	if err != nil && !errors.Is(err, domain.ErrRenewalApplied) {
+		return renewalError(c, err)
+	}
+	return c.JSON(sub)
+}
+
+// RunLifecycle ejecuta una pasada del worker sin esperar al siguiente intervalo
+func (h *LifecycleHandler) RunLifecycle(c *fiber.Ctx) error {
+	report, err := h.lifecycle.RunOnce(c.Context())
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(report)
+}
+
+// PaymentCallback renueva la suscripción cuando el proveedor de pagos confirma el cobro.
+// La firma HMAC-SHA256 (X-Hub-Signature-256: sha256=<hex>) cubre "<timestamp>.<cuerpo>", con el
+// timestamp Unix en X-Signature-Timestamp. La referencia del pago queda registrada en la base de
+// datos, así que los reintentos del proveedor no extienden la suscripción dos veces.
+func (h *LifecycleHandler) PaymentCallback(c *fiber.Ctx) error {
+	if h.paymentSecret == "" {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "payment callback is disabled"})
+	}
+	if !validSignature(c.Body(), c.Get("X-Signature-Timestamp"), c.Get("X-Hub-Signature-256"), h.paymentSecret, time.Now()) {
+		logrus.Warnf("[SUBSCRIPTIONS] Payment callback with invalid or expired signature from %s", c.IP())
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "invalid signature"})
+	}
+
+	var req PaymentCallbackRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	if req.SubscriptionID == "" || req.Reference == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "subscription_id and reference are required"})
+	}
+
+	sub, err := h.lifecycle.Renew(c.Context(), req.SubscriptionID, domain.RenewRequest{Days: req.Days, Until: req.Until, Source: "payment", Reference: req.Reference})
+	if errors.Is(err, domain.ErrRenewalApplied) {
+		return c.JSON(fiber.Map{"status": "already_applied", "subscription": sub})
+	}
+	if err != nil {
+		return renewalError(c, err)
+	}
+	return c.JSON(fiber.Map{"status": "renewed", "subscription": sub})
+}
+
+// validSignature comprueba la firma de "<timestamp>.<cuerpo>" y que el timestamp no sea viejo
+func validSignature(body []byte, timestamp, header, secret string, now time.Time) bool {
+	got := strings.TrimPrefix(strings.TrimSpace(header), "sha256=")
+	if got == "" {
+		return false
+	}
+	ts, err := strconv.ParseInt(strings.TrimSpace(timestamp), 10, 64)
+	if err != nil {
+		return false
+	}
+	if age := now.Sub(time.Unix(ts, 0)); age > paymentCallbackTolerance || age < -paymentCallbackTolerance {
+		return false
+	}
+	signed := append([]byte(strconv.FormatInt(ts, 10)+"."), body...)
+	want, err := pkgUtils.GetMessageDigestOrSignature(signed, []byte(secret))
+	if err != nil {
+		return false
+	}
+	return hmac.Equal([]byte(strings.ToLower(got)), []byte(want))
+}
+
+func renewalError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, domain.ErrSubscriptionNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, domain.ErrSubscriptionRevoked), errors.Is(err, domain.ErrInvalidRenewal), errors.Is(err, domain.ErrRenewalShortens):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	default:
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+}
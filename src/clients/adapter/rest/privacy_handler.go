@@ -0,0 +1,164 @@
+package rest
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/AzielCF/az-wap/clients/application"
+	"github.com/AzielCF/az-wap/clients/domain"
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+)
+
+// PrivacyHandler expone las solicitudes de acceso y supresión de datos personales (GDPR)
+type PrivacyHandler struct {
+	privacyService *application.PrivacyService
+}
+
+// NewPrivacyHandler crea una nueva instancia del handler
+func NewPrivacyHandler(privacyService *application.PrivacyService) *PrivacyHandler {
+	return &PrivacyHandler{privacyService: privacyService}
+}
+
+// RegisterRoutes registra las rutas de datos personales en el router de Fiber
+func (h *PrivacyHandler) RegisterRoutes(router fiber.Router) {
+	// Por cliente global (todas sus identidades)
+	router.Get("/clients/:id/data-export", h.ExportClientData)
+	router.Post("/clients/:id/erase", h.EraseClientData)
+
+	// Por identidad de plataforma (aunque no esté registrada como cliente)
+	subjects := router.Group("/data-subjects")
+	subjects.Get("/export", h.ExportSubjectData)
+	subjects.Post("/erase", h.EraseSubjectData)
+	subjects.Get("/tombstones", h.ListTombstones)
+	subjects.Get("/tombstones/:id", h.GetTombstone)
+}
+
+// ExportClientData exporta todo lo que se guarda de un cliente. Query: format=json|zip
+func (h *PrivacyHandler) ExportClientData(c *fiber.Ctx) error {
+	subject, err := h.privacyService.SubjectForClient(c.Context(), c.Params("id"))
+	if err != nil {
+		return subjectError(c, err)
+	}
+	return SendDataExport(c, h.privacyService, subject, c.Query("format", "json"))
+}
+
+// EraseClientData suprime (erase) o anonimiza (anonymize) los datos de un cliente
+func (h *PrivacyHandler) EraseClientData(c *fiber.Ctx) error {
+	var req EraseDataRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	if !req.Confirm {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "confirm must be true: erasure cannot be undone"})
+	}
+	subject, err := h.privacyService.SubjectForClient(c.Context(), c.Params("id"))
+	if err != nil {
+		return subjectError(c, err)
+	}
+	return h.erase(c, subject, req.Mode)
+}
+
+// ExportSubjectData exporta los datos de una identidad. Query: platform_type, platform_id, format=json|zip
+func (h *PrivacyHandler) ExportSubjectData(c *fiber.Ctx) error {
+	subject, err := h.privacyService.SubjectForIdentity(c.Context(), domain.PlatformType(c.Query("platform_type")), c.Query("platform_id"))
+	if err != nil {
+		return subjectError(c, err)
+	}
+	return SendDataExport(c, h.privacyService, subject, c.Query("format", "json"))
+}
+
+// EraseSubjectData suprime o anonimiza los datos de una identidad y de su cliente si lo tiene
+func (h *PrivacyHandler) EraseSubjectData(c *fiber.Ctx) error {
+	var req EraseDataRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	if !req.Confirm {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "confirm must be true: erasure cannot be undone"})
+	}
+	subject, err := h.privacyService.SubjectForIdentity(c.Context(), domain.PlatformType(req.PlatformType), req.PlatformID)
+	if err != nil {
+		return subjectError(c, err)
+	}
+	return h.erase(c, subject, req.Mode)
+}
+
+// ListTombstones lista los registros de supresión. Query: platform_id (buscar una identidad), limit
+func (h *PrivacyHandler) ListTombstones(c *fiber.Ctx) error {
+	var (
+		list []*domain.Tombstone
+		err  error
+	)
+	if platformID := c.Query("platform_id"); platformID != "" {
+		list, err = h.privacyService.FindTombstones(c.Context(), platformID)
+	} else {
+		list, err = h.privacyService.ListTombstones(c.Context(), c.QueryInt("limit", 100))
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{"data": list})
+}
+
+// GetTombstone obtiene el informe de una supresión
+func (h *PrivacyHandler) GetTombstone(c *fiber.Ctx) error {
+	tombstone, err := h.privacyService.GetTombstone(c.Context(), c.Params("id"))
+	if err != nil {
+		if errors.Is(err, domain.ErrTombstoneNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(tombstone)
+}
+
+func (h *PrivacyHandler) erase(c *fiber.Ctx, subject *application.DataSubject, mode string) error {
+	if mode == "" {
+		mode = string(domain.ErasureDelete)
+	}
+	tombstone, err := h.privacyService.Erase(c.Context(), subject, domain.ErasureMode(mode), "admin")
+	if err != nil {
+		if tombstone == nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error(), "report": tombstone})
+	}
+	return c.JSON(tombstone)
+}
+
+// SendDataExport responde con la exportación del sujeto en JSON o ZIP (también la usa el portal)
+func SendDataExport(c *fiber.Ctx, privacyService *application.PrivacyService, subject *application.DataSubject, format string) error {
+	if format != "json" && format != "zip" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "format must be json or zip"})
+	}
+	export, err := privacyService.Export(c.Context(), subject)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	name := fmt.Sprintf("data-export-%s", time.Now().Format("20060102-150405"))
+	if format == "json" {
+		c.Attachment(name + ".json")
+		return c.JSON(export)
+	}
+
+	c.Set(fiber.HeaderContentType, "application/zip")
+	c.Attachment(name + ".zip")
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		if err := application.WriteExportZip(w, export); err != nil {
+			logrus.WithError(err).Error("[PRIVACY] Failed to write export archive")
+		}
+		_ = w.Flush()
+	})
+	return nil
+}
+
+func subjectError(c *fiber.Ctx, err error) error {
+	if errors.Is(err, domain.ErrClientNotFound) || errors.Is(err, domain.ErrInvalidExportToken) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+}
@@ -0,0 +1,649 @@
+package application
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/AzielCF/az-wap/clients/domain"
+)
+
+// BulkFormat es el formato de archivo para importar/exportar clientes
+type BulkFormat string
+
+const (
+	FormatCSV   BulkFormat = "csv"
+	FormatJSONL BulkFormat = "jsonl"
+)
+
+// ImportMode define qué hacer cuando una fila coincide con un cliente existente
+type ImportMode string
+
+const (
+	ImportSkip   ImportMode = "skip"
+	ImportUpdate ImportMode = "update"
+)
+
+const (
+	maxImportIssues = 500
+	exportPageSize  = 500
+	maxJSONLLine    = 1 << 20
+)
+
+// Columnas de export que no se importan (las genera el sistema)
+var readOnlyColumns = map[string]bool{
+	"id": true, "enabled": true, "created_at": true, "updated_at": true,
+	"last_interaction": true, "accumulated_cost": true,
+}
+
+// ImportOptions configura una importación masiva
+type ImportOptions struct {
+	Format      BulkFormat
+	Mode        ImportMode
+	WorkspaceID string // Esquema de campos personalizados contra el que se validan las columnas de metadata
+	DryRun      bool
+}
+
+// ImportIssue describe una fila que no se creó (omitida, duplicada o con error)
+type ImportIssue struct {
+	Line       int    `json:"line"`
+	Action     string `json:"action"`
+	PlatformID string `json:"platform_id,omitempty"`
+	ClientID   string `json:"client_id,omitempty"`
+	Reason     string `json:"reason"`
+}
+
+// ImportReport resume el resultado (o el resultado previsto en dry-run) de una importación
+type ImportReport struct {
+	DryRun          bool          `json:"dry_run"`
+	Total           int           `json:"total"`
+	Created         int           `json:"created"`
+	Updated         int           `json:"updated"`
+	Skipped         int           `json:"skipped"`
+	Failed          int           `json:"failed"`
+	Issues          []ImportIssue `json:"issues"`
+	IssuesTruncated bool          `json:"issues_truncated,omitempty"`
+}
+
+func (r *ImportReport) addIssue(issue ImportIssue) {
+	switch issue.Action {
+	case "failed":
+		r.Failed++
+	case "skipped":
+		r.Skipped++
+	}
+	if len(r.Issues) >= maxImportIssues {
+		r.IssuesTruncated = true
+		return
+	}
+	r.Issues = append(r.Issues, issue)
+}
+
+// ExportOptions configura una exportación filtrada
+type ExportOptions struct {
+	Format      BulkFormat
+	Filter      domain.ClientFilter
+	WorkspaceID string // Si se indica, los campos del esquema salen como columnas propias
+	IncludePII  bool   // Incluir campos personalizados marcados como PII
+}
+
+// clientRecord es una fila de import/export
+type clientRecord struct {
+	ID              string         `json:"id,omitempty"`
+	PlatformID      string         `json:"platform_id"`
+	PlatformType    string         `json:"platform_type"`
+	DisplayName     string         `json:"display_name,omitempty"`
+	Email           string         `json:"email,omitempty"`
+	Phone           string         `json:"phone,omitempty"`
+	Tier            string         `json:"tier,omitempty"`
+	Tags            []string       `json:"tags,omitempty"`
+	Metadata        map[string]any `json:"metadata,omitempty"`
+	Notes           string         `json:"notes,omitempty"`
+	Language        string         `json:"language,omitempty"`
+	Timezone        string         `json:"timezone,omitempty"`
+	Country         string         `json:"country,omitempty"`
+	Enabled         *bool          `json:"enabled,omitempty"`
+	LastInteraction *time.Time     `json:"last_interaction,omitempty"`
+	CreatedAt       *time.Time     `json:"created_at,omitempty"`
+}
+
+// rowError marca un error de una fila concreta (no aborta la importación)
+type rowError struct{ err error }
+
+func (e rowError) Error() string { return e.err.Error() }
+
+// BulkService importa y exporta clientes en streaming (CSV o JSON Lines)
+type BulkService struct {
+	clientService *ClientService
+	clientRepo    domain.ClientRepository
+	identityRepo  domain.IdentityRepository
+	fields        *FieldService
+}
+
+// NewBulkService crea una nueva instancia de BulkService
+func NewBulkService(clientService *ClientService, clientRepo domain.ClientRepository, identityRepo domain.IdentityRepository, fields *FieldService) *BulkService {
+	return &BulkService{
+		clientService: clientService,
+		clientRepo:    clientRepo,
+		identityRepo:  identityRepo,
+		fields:        fields,
+	}
+}
+
+// Import lee las filas de r una a una, deduplica por platform ID y teléfono (en el archivo y
+// contra la base) y crea o actualiza clientes. En dry-run no escribe nada.
+func (s *BulkService) Import(ctx context.Context, r io.Reader, opts ImportOptions) (*ImportReport, error) {
+	if opts.Mode == "" {
+		opts.Mode = ImportSkip
+	}
+	if opts.Mode != ImportSkip && opts.Mode != ImportUpdate {
+		return nil, fmt.Errorf("unknown import mode %q", opts.Mode)
+	}
+	schema, err := s.fields.Schema(ctx, opts.WorkspaceID)
+	if err != nil {
+		return nil, err
+	}
+
+	var next func() (int, *clientRecord, error)
+	switch opts.Format {
+	case FormatCSV, "":
+		next, err = csvRecords(r)
+	case FormatJSONL:
+		next = jsonlRecords(r)
+	default:
+		return nil, fmt.Errorf("unsupported format %q", opts.Format)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	report := &ImportReport{DryRun: opts.DryRun, Issues: []ImportIssue{}}
+	seen := make(map[string]int)
+	for {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+		line, rec, err := next()
+		if err == io.EOF {
+			break
+		}
+		var rowErr rowError
+		if errors.As(err, &rowErr) {
+			report.Total++
+			report.addIssue(ImportIssue{Line: line, Action: "failed", Reason: rowErr.Error()})
+			continue
+		}
+		if err != nil {
+			return report, fmt.Errorf("line %d: %w", line, err)
+		}
+		report.Total++
+		s.importRow(ctx, line, rec, schema, opts, seen, report)
+	}
+	return report, nil
+}
+
+func (s *BulkService) importRow(ctx context.Context, line int, rec *clientRecord, schema map[string]*domain.CustomField, opts ImportOptions, seen map[string]int, report *ImportReport) {
+	fail := func(reason string) {
+		report.addIssue(ImportIssue{Line: line, Action: "failed", PlatformID: rec.PlatformID, Reason: reason})
+	}
+
+	phone := digitsOnly(rec.Phone)
+	if rec.PlatformType == "" {
+		rec.PlatformType = string(domain.PlatformWhatsApp)
+	}
+	if rec.PlatformID == "" && phone != "" {
+		rec.PlatformID = phone
+	}
+	if rec.PlatformID == "" {
+		fail("platform_id or phone is required")
+		return
+	}
+	if !validPlatform(domain.PlatformType(rec.PlatformType)) {
+		fail(fmt.Sprintf("unknown platform_type %q", rec.PlatformType))
+		return
+	}
+	if rec.Tier != "" && tierRank(domain.ClientTier(rec.Tier)) < 0 {
+		fail(fmt.Sprintf("unknown tier %q", rec.Tier))
+		return
+	}
+	metadata, err := NormalizeMetadata(schema, rec.Metadata)
+	if err != nil {
+		fail(err.Error())
+		return
+	}
+	rec.Metadata = metadata
+
+	// Duplicados dentro del mismo archivo
+	keys := []string{"platform:" + rec.PlatformType + ":" + rec.PlatformID}
+	if phone != "" {
+		keys = append(keys, "phone:"+phone)
+	}
+	for _, k := range keys {
+		if first, dup := seen[k]; dup {
+			report.addIssue(ImportIssue{Line: line, Action: "skipped", PlatformID: rec.PlatformID, Reason: fmt.Sprintf("duplicate of line %d", first)})
+			return
+		}
+	}
+	for _, k := range keys {
+		seen[k] = line
+	}
+
+	existing, err := s.findExisting(ctx, domain.PlatformType(rec.PlatformType), rec.PlatformID, phone)
+	if err != nil {
+		fail(err.Error())
+		return
+	}
+
+	if existing == nil {
+		client := rec.toClient()
+		if !opts.DryRun {
+			if err := s.clientService.Create(ctx, client); err != nil {
+				fail(err.Error())
+				return
+			}
+		}
+		report.Created++
+		return
+	}
+
+	if opts.Mode == ImportSkip {
+		report.addIssue(ImportIssue{Line: line, Action: "skipped", PlatformID: rec.PlatformID, ClientID: existing.ID, Reason: "client already exists"})
+		return
+	}
+
+	rec.applyTo(existing)
+	if !opts.DryRun {
+		if err := s.clientService.Update(ctx, existing); err != nil {
+			fail(err.Error())
+			return
+		}
+		// Coincidió por teléfono con otra identidad: la fila pasa a ser una identidad más del cliente
+		if existing.PlatformID != rec.PlatformID || existing.PlatformType != domain.PlatformType(rec.PlatformType) {
+			identity := &domain.ClientIdentity{
+				ClientID:     existing.ID,
+				PlatformID:   rec.PlatformID,
+				PlatformType: domain.PlatformType(rec.PlatformType),
+				Source:       domain.IdentityAdmin,
+			}
+			if err := s.identityRepo.Link(ctx, identity); err != nil {
+				fail(err.Error())
+				return
+			}
+		}
+	}
+	report.Updated++
+}
+
+// findExisting busca el cliente por identidad, PlatformID legacy y finalmente por teléfono
+func (s *BulkService) findExisting(ctx context.Context, platformType domain.PlatformType, platformID, phone string) (*domain.Client, error) {
+	identity, err := s.identityRepo.GetByPlatform(ctx, platformID, platformType)
+	if err == nil {
+		return s.clientRepo.GetByID(ctx, identity.ClientID)
+	}
+	if !errors.Is(err, domain.ErrIdentityNotFound) {
+		return nil, err
+	}
+
+	client, err := s.clientRepo.GetByPlatform(ctx, platformID, platformType)
+	if err == nil {
+		return client, nil
+	}
+	if !errors.Is(err, domain.ErrClientNotFound) {
+		return nil, err
+	}
+
+	// GetByPhone busca con LIKE: se exige el mismo número E.164 completo. Un sufijo parecido
+	// no basta, porque en modo update la fila se enlazaría como identidad de otra persona.
+	if len(phone) < 7 {
+		return nil, nil
+	}
+	client, err = s.clientRepo.GetByPhone(ctx, phone)
+	if errors.Is(err, domain.ErrClientNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if digitsOnly(client.Phone) == phone {
+		return client, nil
+	}
+	return nil, nil
+}
+
+// Export escribe en w los clientes que cumplen el filtro, página a página
+func (s *BulkService) Export(ctx context.Context, w io.Writer, opts ExportOptions) (int, error) {
+	schema, err := s.fields.Schema(ctx, opts.WorkspaceID)
+	if err != nil {
+		return 0, err
+	}
+	// Campos PII del esquema fuera salvo que se pidan explícitamente
+	hidden := make(map[string]bool)
+	for key, def := range schema {
+		if def.PII && !opts.IncludePII {
+			hidden[key] = true
+			delete(schema, key)
+		}
+	}
+
+	var write func(*domain.Client) error
+	var flush func() error
+	switch opts.Format {
+	case FormatCSV, "":
+		cw := csv.NewWriter(w)
+		columns := schemaKeys(schema)
+		header := append([]string{"id", "platform_id", "platform_type", "display_name", "email", "phone", "tier", "tags", "notes", "language", "timezone", "country", "enabled", "created_at"}, columns...)
+		if len(schema) == 0 {
+			header = append(header, "metadata")
+		}
+		if err := cw.Write(header); err != nil {
+			return 0, err
+		}
+		write = func(c *domain.Client) error {
+			row := []string{c.ID, c.PlatformID, string(c.PlatformType), c.DisplayName, c.Email, c.Phone, string(c.Tier),
+				strings.Join(c.Tags, ";"), c.Notes, c.Language, c.Timezone, c.Country, fmt.Sprint(c.Enabled), c.CreatedAt.Format(time.RFC3339)}
+			for _, key := range columns {
+				row = append(row, cellValue(c.Metadata[key]))
+			}
+			if len(schema) == 0 {
+				row = append(row, metadataJSON(c.Metadata, hidden))
+			}
+			return cw.Write(row)
+		}
+		flush = func() error {
+			cw.Flush()
+			return cw.Error()
+		}
+	case FormatJSONL:
+		enc := json.NewEncoder(w)
+		write = func(c *domain.Client) error {
+			rec := recordFromClient(c)
+			for key := range hidden {
+				delete(rec.Metadata, key)
+			}
+			return enc.Encode(rec)
+		}
+		flush = func() error { return nil }
+	default:
+		return 0, fmt.Errorf("unsupported format %q", opts.Format)
+	}
+
+	filter := opts.Filter
+	filter.Limit = exportPageSize
+	filter.Offset = 0
+	if filter.OrderBy == "" {
+		filter.OrderBy = "created_at"
+	}
+
+	count := 0
+	for {
+		if err := ctx.Err(); err != nil {
+			return count, err
+		}
+		page, err := s.clientRepo.List(ctx, filter)
+		if err != nil {
+			return count, fmt.Errorf("failed to list clients: %w", err)
+		}
+		for _, c := range page {
+			if err := write(c); err != nil {
+				return count, err
+			}
+			count++
+		}
+		if err := flush(); err != nil {
+			return count, err
+		}
+		if len(page) < filter.Limit {
+			return count, nil
+		}
+		filter.Offset += filter.Limit
+	}
+}
+
+// --- Readers ---
+
+// csvRecords lee la cabecera y devuelve un iterador de filas. Las columnas desconocidas
+// (o con prefijo "metadata.") van a Metadata; una columna "metadata" con JSON se fusiona.
+func csvRecords(r io.Reader) (func() (int, *clientRecord, error), error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil, errors.New("empty CSV file")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV header: %w", err)
+	}
+	for i, h := range header {
+		header[i] = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(h, "\ufeff")))
+	}
+
+	return func() (int, *clientRecord, error) {
+		row, err := cr.Read()
+		if err == io.EOF {
+			return 0, nil, io.EOF
+		}
+		line := 0
+		if err == nil && len(row) > 0 {
+			line, _ = cr.FieldPos(0)
+		}
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			return parseErr.Line, nil, rowError{err}
+		}
+		if err != nil {
+			return line, nil, err
+		}
+
+		rec := &clientRecord{Metadata: make(map[string]any)}
+		for i, value := range row {
+			if i >= len(header) {
+				break
+			}
+			value = strings.TrimSpace(value)
+			if value == "" {
+				continue
+			}
+			switch col := header[i]; col {
+			case "platform_id":
+				rec.PlatformID = value
+			case "platform_type":
+				rec.PlatformType = strings.ToLower(value)
+			case "display_name", "name":
+				rec.DisplayName = value
+			case "email":
+				rec.Email = value
+			case "phone":
+				rec.Phone = value
+			case "tier":
+				rec.Tier = strings.ToLower(value)
+			case "tags":
+				rec.Tags = splitTags(value)
+			case "notes":
+				rec.Notes = value
+			case "language":
+				rec.Language = value
+			case "timezone":
+				rec.Timezone = value
+			case "country":
+				rec.Country = strings.ToUpper(value)
+			case "metadata":
+				extra := map[string]any{}
+				if err := json.Unmarshal([]byte(value), &extra); err != nil {
+					return line, nil, rowError{fmt.Errorf("invalid metadata JSON: %w", err)}
+				}
+				for k, v := range extra {
+					rec.Metadata[k] = v
+				}
+			default:
+				if readOnlyColumns[col] {
+					continue
+				}
+				rec.Metadata[strings.TrimPrefix(col, "metadata.")] = value
+			}
+		}
+		return line, rec, nil
+	}, nil
+}
+
+// jsonlRecords devuelve un iterador sobre un objeto JSON por línea (se ignoran líneas vacías)
+func jsonlRecords(r io.Reader) func() (int, *clientRecord, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxJSONLLine)
+	line := 0
+
+	return func() (int, *clientRecord, error) {
+		for scanner.Scan() {
+			line++
+			raw := strings.TrimSpace(scanner.Text())
+			if raw == "" {
+				continue
+			}
+			rec := &clientRecord{}
+			if err := json.Unmarshal([]byte(raw), rec); err != nil {
+				return line, nil, rowError{fmt.Errorf("invalid JSON: %w", err)}
+			}
+			rec.PlatformType = strings.ToLower(rec.PlatformType)
+			rec.Tier = strings.ToLower(rec.Tier)
+			return line, rec, nil
+		}
+		if err := scanner.Err(); err != nil {
+			return line + 1, nil, err
+		}
+		return line, nil, io.EOF
+	}
+}
+
+// --- Helpers ---
+
+func (rec *clientRecord) toClient() *domain.Client {
+	return &domain.Client{
+		PlatformID:   rec.PlatformID,
+		PlatformType: domain.PlatformType(rec.PlatformType),
+		DisplayName:  rec.DisplayName,
+		Email:        rec.Email,
+		Phone:        rec.Phone,
+		Tier:         domain.ClientTier(rec.Tier),
+		Tags:         rec.Tags,
+		Metadata:     rec.Metadata,
+		Notes:        rec.Notes,
+		Language:     rec.Language,
+		Timezone:     rec.Timezone,
+		Country:      rec.Country,
+	}
+}
+
+// applyTo actualiza un cliente existente: los valores no vacíos de la fila ganan, tags y metadata se suman
+func (rec *clientRecord) applyTo(c *domain.Client) {
+	set := func(dst *string, v string) {
+		if v != "" {
+			*dst = v
+		}
+	}
+	set(&c.DisplayName, rec.DisplayName)
+	set(&c.Email, rec.Email)
+	set(&c.Phone, rec.Phone)
+	set(&c.Notes, rec.Notes)
+	set(&c.Language, rec.Language)
+	set(&c.Timezone, rec.Timezone)
+	set(&c.Country, rec.Country)
+	if rec.Tier != "" {
+		c.Tier = domain.ClientTier(rec.Tier)
+	}
+	c.Tags = unionStrings(c.Tags, rec.Tags)
+	if c.Metadata == nil {
+		c.Metadata = make(map[string]any)
+	}
+	for k, v := range rec.Metadata {
+		c.Metadata[k] = v
+	}
+}
+
+func recordFromClient(c *domain.Client) *clientRecord {
+	metadata := make(map[string]any, len(c.Metadata))
+	for k, v := range c.Metadata {
+		metadata[k] = v
+	}
+	enabled := c.Enabled
+	createdAt := c.CreatedAt
+	return &clientRecord{
+		ID:              c.ID,
+		PlatformID:      c.PlatformID,
+		PlatformType:    string(c.PlatformType),
+		DisplayName:     c.DisplayName,
+		Email:           c.Email,
+		Phone:           c.Phone,
+		Tier:            string(c.Tier),
+		Tags:            c.Tags,
+		Metadata:        metadata,
+		Notes:           c.Notes,
+		Language:        c.Language,
+		Timezone:        c.Timezone,
+		Country:         c.Country,
+		Enabled:         &enabled,
+		LastInteraction: c.LastInteraction,
+		CreatedAt:       &createdAt,
+	}
+}
+
+func metadataJSON(metadata map[string]any, hidden map[string]bool) string {
+	out := make(map[string]any, len(metadata))
+	for k, v := range metadata {
+		if !hidden[k] {
+			out[k] = v
+		}
+	}
+	if len(out) == 0 {
+		return ""
+	}
+	b, _ := json.Marshal(out)
+	return string(b)
+}
+
+func cellValue(v any) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return val
+	case float64, bool, int:
+		return fmt.Sprint(val)
+	}
+	b, _ := json.Marshal(v)
+	return string(b)
+}
+
+func splitTags(value string) []string {
+	parts := strings.FieldsFunc(value, func(r rune) bool { return r == ';' || r == '|' || r == ',' })
+	tags := make([]string, 0, len(parts))
+	for _, p := range parts {
+		if p = strings.TrimSpace(p); p != "" {
+			tags = append(tags, p)
+		}
+	}
+	return tags
+}
+
+func digitsOnly(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, s)
+}
+
+func validPlatform(p domain.PlatformType) bool {
+	switch p {
+	case domain.PlatformWhatsApp, domain.PlatformTelegram, domain.PlatformWebChat, domain.PlatformAPI:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,127 @@
+package application
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/AzielCF/az-wap/clients/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newBulkService(t *testing.T) (*BulkService, *FieldService, *ClientService) {
+	t.Helper()
+	r := newTestRepos(t)
+	clients := NewClientService(r.clients, r.subs, r.identities)
+	fields := NewFieldService(r.fields, nil, nil)
+	return NewBulkService(clients, r.clients, r.identities, fields), fields, clients
+}
+
+func TestBulkService_ImportDedupesAndValidates(t *testing.T) {
+	bulk, fields, clients := newBulkService(t)
+	ctx := context.Background()
+
+	require.NoError(t, fields.Save(ctx, &domain.CustomField{WorkspaceID: "ws1", Key: "plan", Type: domain.FieldSelect, Options: []string{"Basic", "Pro"}}))
+	require.NoError(t, clients.Create(ctx, &domain.Client{PlatformID: "51999000111", PlatformType: domain.PlatformWhatsApp, Phone: "+51999000111", DisplayName: "Existing"}))
+
+	csvData := strings.Join([]string{
+		"platform_id,display_name,phone,platform_type,tags,plan,company",
+		",Ana,+51 988 111 222,,vip;lead,pro,ACME",
+		",Ana again,51988111222,,,,",                 // duplicado en el archivo (mismo teléfono)
+		"tg-9,Luis,+51 999 000 111,telegram,,basic,", // ya existe (coincide por teléfono)
+		",Bad,51977333444,,,enterprise,",             // valor fuera del esquema
+		",NoID,,,,,",                                 // sin identificador
+	}, "\n")
+
+	report, err := bulk.Import(ctx, strings.NewReader(csvData), ImportOptions{Format: FormatCSV, WorkspaceID: "ws1", DryRun: true})
+	require.NoError(t, err)
+	assert.Equal(t, 5, report.Total)
+	assert.Equal(t, 1, report.Created)
+	assert.Equal(t, 2, report.Skipped)
+	assert.Equal(t, 2, report.Failed)
+
+	all, err := clients.List(ctx, domain.ClientFilter{})
+	require.NoError(t, err)
+	assert.Len(t, all, 1, "dry-run must not write")
+
+	report, err = bulk.Import(ctx, strings.NewReader(csvData), ImportOptions{Format: FormatCSV, WorkspaceID: "ws1", Mode: ImportUpdate})
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.Created)
+	assert.Equal(t, 1, report.Updated)
+
+	ana, err := clients.GetByPlatform(ctx, "51988111222", domain.PlatformWhatsApp)
+	require.NoError(t, err)
+	assert.Equal(t, "Pro", ana.Metadata["plan"])
+	assert.Equal(t, "ACME", ana.Metadata["company"])
+	assert.Equal(t, []string{"vip", "lead"}, ana.Tags)
+
+	existing, err := clients.GetByPlatform(ctx, "51999000111", domain.PlatformWhatsApp)
+	require.NoError(t, err)
+	assert.Equal(t, "Luis", existing.DisplayName)
+
+	// La cuenta de Telegram de la fila queda vinculada al cliente existente
+	viaTelegram, err := bulk.findExisting(ctx, domain.PlatformTelegram, "tg-9", "")
+	require.NoError(t, err)
+	require.NotNil(t, viaTelegram)
+	assert.Equal(t, existing.ID, viaTelegram.ID)
+
+	// Mismos últimos dígitos con otro prefijo de país: es otra persona, no se enlaza
+	other, err := bulk.findExisting(ctx, domain.PlatformTelegram, "tg-10", "1999000111")
+	require.NoError(t, err)
+	assert.Nil(t, other)
+}
+
+func TestBulkService_ExportFiltersAndHidesPII(t *testing.T) {
+	bulk, fields, clients := newBulkService(t)
+	ctx := context.Background()
+
+	require.NoError(t, fields.Save(ctx, &domain.CustomField{WorkspaceID: "ws1", Key: "dni", Type: domain.FieldText, PII: true}))
+	require.NoError(t, clients.Create(ctx, &domain.Client{PlatformID: "1", PlatformType: domain.PlatformTelegram, Tags: []string{"vip"}, Metadata: map[string]any{"dni": "123", "color": "red"}}))
+	require.NoError(t, clients.Create(ctx, &domain.Client{PlatformID: "2", PlatformType: domain.PlatformTelegram}))
+
+	var buf bytes.Buffer
+	count, err := bulk.Export(ctx, &buf, ExportOptions{Format: FormatJSONL, WorkspaceID: "ws1", Filter: domain.ClientFilter{Tags: []string{"vip"}}})
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	var rec clientRecord
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &rec))
+	assert.Equal(t, "1", rec.PlatformID)
+	assert.NotContains(t, rec.Metadata, "dni")
+	assert.Equal(t, "red", rec.Metadata["color"])
+
+	// El CSV exportado se puede volver a importar sin duplicar
+	buf.Reset()
+	_, err = bulk.Export(ctx, &buf, ExportOptions{Format: FormatCSV})
+	require.NoError(t, err)
+	report, err := bulk.Import(ctx, &buf, ImportOptions{Format: FormatCSV, DryRun: true})
+	require.NoError(t, err)
+	assert.Equal(t, 2, report.Skipped)
+	assert.Zero(t, report.Failed)
+}
+
+func TestFieldService_ClientSchemaUsesStrictestWorkspace(t *testing.T) {
+	ctx := context.Background()
+	r := newTestRepos(t)
+	fields := NewFieldService(r.fields, r.subs, fakeChannels{"ch-2": {ID: "ch-2", WorkspaceID: "ws2"}})
+
+	// ws1 deja que la IA lea y escriba el campo; ws2 (donde el cliente también está suscrito) lo oculta
+	require.NoError(t, fields.Save(ctx, &domain.CustomField{WorkspaceID: "ws1", Key: "salary", Type: domain.FieldNumber, AIRead: true, AIWrite: true}))
+	require.NoError(t, fields.Save(ctx, &domain.CustomField{WorkspaceID: "ws2", Key: "salary", Type: domain.FieldNumber, PII: true}))
+	require.NoError(t, fields.Save(ctx, &domain.CustomField{WorkspaceID: "ws2", Key: "dni", Type: domain.FieldText, PII: true}))
+	require.NoError(t, r.subs.Create(ctx, &domain.ClientSubscription{ID: "sub-1", ClientID: "client-1", ChannelID: "ch-2", Status: domain.SubscriptionActive}))
+
+	only, err := fields.ClientSchema(ctx, "", "ws1")
+	require.NoError(t, err)
+	assert.True(t, only["salary"].AIRead)
+
+	merged, err := fields.ClientSchema(ctx, "client-1", "ws1")
+	require.NoError(t, err)
+	require.Contains(t, merged, "dni")
+	assert.False(t, merged["salary"].AIRead)
+	assert.False(t, merged["salary"].AIWrite)
+	assert.True(t, merged["salary"].PII)
+}
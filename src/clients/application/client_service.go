@@ -2,6 +2,7 @@ package application
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"github.com/AzielCF/az-wap/clients/domain"
@@ -10,15 +11,17 @@ import (
 
 // ClientService contiene la lógica de negocio para la gestión de clientes
 type ClientService struct {
-	clientRepo domain.ClientRepository
-	subRepo    domain.SubscriptionRepository
+	clientRepo   domain.ClientRepository
+	subRepo      domain.SubscriptionRepository
+	identityRepo domain.IdentityRepository
 }
 
 // NewClientService crea una nueva instancia de ClientService
-func NewClientService(clientRepo domain.ClientRepository, subRepo domain.SubscriptionRepository) *ClientService {
+func NewClientService(clientRepo domain.ClientRepository, subRepo domain.SubscriptionRepository, identityRepo domain.IdentityRepository) *ClientService {
 	return &ClientService{
-		clientRepo: clientRepo,
-		subRepo:    subRepo,
+		clientRepo:   clientRepo,
+		subRepo:      subRepo,
+		identityRepo: identityRepo,
 	}
 }
 
@@ -40,7 +43,14 @@ func (s *ClientService) Create(ctx context.Context, client *domain.Client) error
 	client.CreatedAt = time.Now()
 	client.UpdatedAt = time.Now()
 
-	return s.clientRepo.Create(ctx, client)
+	// La identidad puede estar vinculada como secundaria a otro cliente
+	if identity, err := s.identityRepo.GetByPlatform(ctx, client.PlatformID, client.PlatformType); err == nil && identity.ClientID != client.ID {
+		return domain.ErrDuplicateClient
+	}
+	if err := s.clientRepo.Create(ctx, client); err != nil {
+		return err
+	}
+	return s.linkPrimary(ctx, client)
 }
 
 // GetByID obtiene un cliente por su ID
@@ -56,7 +66,14 @@ func (s *ClientService) GetByPlatform(ctx context.Context, platformID string, pl
 // Update actualiza un cliente existente
 func (s *ClientService) Update(ctx context.Context, client *domain.Client) error {
 	client.UpdatedAt = time.Now()
-	return s.clientRepo.Update(ctx, client)
+	if identity, err := s.identityRepo.GetByPlatform(ctx, client.PlatformID, client.PlatformType); err == nil && identity.ClientID != client.ID {
+		return domain.ErrDuplicateClient
+	}
+	if err := s.clientRepo.Update(ctx, client); err != nil {
+		return err
+	}
+	// Si cambió el PlatformID la identidad anterior se conserva como secundaria
+	return s.linkPrimary(ctx, client)
 }
 
 // Delete elimina un cliente y todas sus suscripciones
@@ -65,6 +82,9 @@ func (s *ClientService) Delete(ctx context.Context, id string) error {
 	if err := s.subRepo.DeleteByClientID(ctx, id); err != nil {
 		return err
 	}
+	if err := s.identityRepo.DeleteByClientID(ctx, id); err != nil {
+		return err
+	}
 	return s.clientRepo.Delete(ctx, id)
 }
 
@@ -127,3 +147,20 @@ func (s *ClientService) GetStats(ctx context.Context) (map[domain.ClientTier]int
 func (s *ClientService) RecordInteraction(ctx context.Context, id string) error {
 	return s.clientRepo.UpdateLastInteraction(ctx, id, time.Now())
 }
+
+func (s *ClientService) linkPrimary(ctx context.Context, client *domain.Client) error {
+	if client.PlatformID == "" {
+		return nil
+	}
+	err := s.identityRepo.Link(ctx, &domain.ClientIdentity{
+		ClientID:     client.ID,
+		PlatformID:   client.PlatformID,
+		PlatformType: client.PlatformType,
+		Source:       domain.IdentityPrimary,
+		Verified:     true,
+	})
+	if errors.Is(err, domain.ErrIdentityLinked) {
+		return domain.ErrDuplicateClient
+	}
+	return err
+}
@@ -0,0 +1,147 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/AzielCF/az-wap/clients/domain"
+	"github.com/AzielCF/az-wap/workspace/domain/common"
+)
+
+// FieldService gestiona el esquema de campos personalizados (Client.Metadata) de cada workspace
+type FieldService struct {
+	fieldRepo domain.FieldRepository
+	subRepo   domain.SubscriptionRepository // Workspaces del cliente (ClientSchema); nil = solo el actual
+	channels  ChannelResolver
+}
+
+// NewFieldService crea una nueva instancia de FieldService
+func NewFieldService(fieldRepo domain.FieldRepository, subRepo domain.SubscriptionRepository, channels ChannelResolver) *FieldService {
+	return &FieldService{fieldRepo: fieldRepo, subRepo: subRepo, channels: channels}
+}
+
+// List lista las definiciones de campos de un workspace
+func (s *FieldService) List(ctx context.Context, workspaceID string) ([]*domain.CustomField, error) {
+	return s.fieldRepo.ListByWorkspace(ctx, workspaceID)
+}
+
+// Save crea o reemplaza la definición de un campo
+func (s *FieldService) Save(ctx context.Context, field *domain.CustomField) error {
+	field.Key = strings.TrimSpace(strings.ToLower(field.Key))
+	if field.WorkspaceID == "" {
+		return fmt.Errorf("%w: workspace_id is required", domain.ErrInvalidField)
+	}
+	if field.Label == "" {
+		field.Label = field.Key
+	}
+	if err := field.Validate(); err != nil {
+		return err
+	}
+	return s.fieldRepo.Save(ctx, field)
+}
+
+// Delete elimina la definición (los valores guardados en los clientes no se tocan)
+func (s *FieldService) Delete(ctx context.Context, workspaceID, key string) error {
+	return s.fieldRepo.Delete(ctx, workspaceID, key)
+}
+
+// Schema devuelve las definiciones del workspace indexadas por key (vacío si no tiene esquema)
+func (s *FieldService) Schema(ctx context.Context, workspaceID string) (map[string]*domain.CustomField, error) {
+	schema := make(map[string]*domain.CustomField)
+	if workspaceID == "" {
+		return schema, nil
+	}
+	fields, err := s.fieldRepo.ListByWorkspace(ctx, workspaceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load field schema: %w", err)
+	}
+	for _, f := range fields {
+		schema[f.Key] = f
+	}
+	return schema, nil
+}
+
+// ClientSchema une los esquemas de todos los workspaces del cliente (el de la conversación más los
+// de sus suscripciones). Client.Metadata es global, así que un campo que un workspace oculta a la IA
+// o marca como PII no puede leerse ni escribirse desde el chat de otro workspace.
+// Si una clave está definida en varios, gana lo más restrictivo: AIRead/AIWrite solo si todos
+// lo permiten y PII si alguno lo marca. Cualquier error de carga se devuelve (nunca falla abierto).
+func (s *FieldService) ClientSchema(ctx context.Context, clientID, workspaceID string) (map[string]*domain.CustomField, error) {
+	workspaces := []string{}
+	if workspaceID != "" {
+		workspaces = append(workspaces, workspaceID)
+	}
+	if clientID != "" && s.subRepo != nil && s.channels != nil {
+		subs, err := s.subRepo.ListByClient(ctx, clientID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client subscriptions: %w", err)
+		}
+		seen := map[string]bool{workspaceID: true}
+		for _, sub := range subs {
+			ch, err := s.channels.GetChannel(ctx, sub.ChannelID)
+			if errors.Is(err, common.ErrChannelNotFound) {
+				continue // Suscripción huérfana de un canal borrado
+			} else if err != nil {
+				return nil, fmt.Errorf("failed to resolve channel %s: %w", sub.ChannelID, err)
+			}
+			if ch.WorkspaceID != "" && !seen[ch.WorkspaceID] {
+				seen[ch.WorkspaceID] = true
+				workspaces = append(workspaces, ch.WorkspaceID)
+			}
+		}
+	}
+
+	merged := make(map[string]*domain.CustomField)
+	for _, ws := range workspaces {
+		schema, err := s.Schema(ctx, ws)
+		if err != nil {
+			return nil, err
+		}
+		for key, def := range schema {
+			prev, ok := merged[key]
+			if !ok {
+				cp := *def
+				merged[key] = &cp
+				continue
+			}
+			prev.AIRead = prev.AIRead && def.AIRead
+			prev.AIWrite = prev.AIWrite && def.AIWrite
+			prev.PII = prev.PII || def.PII
+		}
+	}
+	return merged, nil
+}
+
+// NormalizeMetadata valida contra el esquema las claves definidas y las deja en su forma canónica.
+// Las claves sin definición se conservan tal cual (metadata libre).
+func NormalizeMetadata(schema map[string]*domain.CustomField, metadata map[string]any) (map[string]any, error) {
+	out := make(map[string]any, len(metadata))
+	var errs []error
+	for key, value := range metadata {
+		def, ok := schema[key]
+		if !ok || value == nil {
+			out[key] = value
+			continue
+		}
+		normalized, err := def.Normalize(value)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		out[key] = normalized
+	}
+	return out, errors.Join(errs...)
+}
+
+// schemaKeys devuelve las claves del esquema en orden estable (columnas de export)
+func schemaKeys(schema map[string]*domain.CustomField) []string {
+	keys := make([]string, 0, len(schema))
+	for k := range schema {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
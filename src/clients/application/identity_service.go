@@ -0,0 +1,628 @@
+package application
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/AzielCF/az-wap/clients/domain"
+	"github.com/AzielCF/az-wap/core/kvstore"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	linkCodeTTL      = 10 * time.Minute
+	linkTokenTTL     = 15 * time.Minute
+	linkCodeLength   = 8
+	linkCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789" // Sin 0/O ni 1/I para dictarlo sin errores
+)
+
+// linkCodePattern reconoce el mensaje "LINK <código>" enviado desde la segunda plataforma
+var linkCodePattern = regexp.MustCompile(`(?i)^\s*link\s+([a-z0-9]{8})\s*$`)
+
+// tierOrder ordena los tiers de menor a mayor para reconciliar clientes fusionados
+var tierOrder = []domain.ClientTier{
+	domain.TierFree, domain.TierTrial, domain.TierStandard,
+	domain.TierPremium, domain.TierVIP, domain.TierEnterprise,
+}
+
+// IdentityService gestiona las identidades de plataforma de los clientes globales:
+// vinculación por admin, merge/split de clientes y auto-vinculación verificada.
+type IdentityService struct {
+	identityRepo domain.IdentityRepository
+	clientRepo   domain.ClientRepository
+	subRepo      domain.SubscriptionRepository
+	codes        kvstore.KVStore
+}
+
+// NewIdentityService crea una nueva instancia de IdentityService
+func NewIdentityService(identityRepo domain.IdentityRepository, clientRepo domain.ClientRepository, subRepo domain.SubscriptionRepository, codes kvstore.KVStore) *IdentityService {
+	return &IdentityService{
+		identityRepo: identityRepo,
+		clientRepo:   clientRepo,
+		subRepo:      subRepo,
+		codes:        codes,
+	}
+}
+
+// List devuelve las identidades de un cliente, incluida la principal
+func (s *IdentityService) List(ctx context.Context, clientID string) ([]*domain.ClientIdentity, error) {
+	client, err := s.clientRepo.GetByID(ctx, clientID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.ensurePrimary(ctx, client); err != nil {
+		return nil, err
+	}
+	return s.identityRepo.ListByClient(ctx, clientID)
+}
+
+// Link vincula manualmente una identidad de plataforma a un cliente (operación de admin)
+func (s *IdentityService) Link(ctx context.Context, clientID, platformID string, platformType domain.PlatformType) (*domain.ClientIdentity, error) {
+	platformID = strings.TrimSpace(platformID)
+	if platformID == "" || platformType == "" {
+		return nil, errors.New("platform_id and platform_type are required")
+	}
+	client, err := s.clientRepo.GetByID(ctx, clientID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.linkIdentities(ctx, client, platformType, domain.IdentityAdmin, platformID); err != nil {
+		return nil, err
+	}
+	return s.identityRepo.GetByPlatform(ctx, platformID, platformType)
+}
+
+// Unlink desvincula una identidad secundaria del cliente
+func (s *IdentityService) Unlink(ctx context.Context, clientID, identityID string) error {
+	client, err := s.clientRepo.GetByID(ctx, clientID)
+	if err != nil {
+		return err
+	}
+	identity, err := s.identityRepo.GetByID(ctx, identityID)
+	if err != nil {
+		return err
+	}
+	if identity.ClientID != client.ID {
+		return domain.ErrIdentityNotFound
+	}
+	if isPrimaryIdentity(client, identity) {
+		return domain.ErrPrimaryIdentity
+	}
+	return s.identityRepo.Delete(ctx, identity.ID)
+}
+
+// Merge fusiona source dentro de target: une tags, metadata, bots y canales, se queda con el mejor tier,
+// mueve suscripciones (si ambos tienen una en el mismo canal gana la activa/más duradera) e identidades,
+// y elimina source.
+func (s *IdentityService) Merge(ctx context.Context, targetID, sourceID string) (*domain.Client, error) {
+	if targetID == sourceID {
+		return nil, errors.New("cannot merge a client into itself")
+	}
+	target, err := s.clientRepo.GetByID(ctx, targetID)
+	if err != nil {
+		return nil, err
+	}
+	source, err := s.clientRepo.GetByID(ctx, sourceID)
+	if err != nil {
+		return nil, err
+	}
+
+	// La identidad principal de source debe quedar en la tabla antes de moverla
+	if err := s.ensurePrimary(ctx, source); err != nil {
+		return nil, err
+	}
+
+	mergeClientFields(target, source)
+	if err := s.clientRepo.Update(ctx, target); err != nil {
+		return nil, fmt.Errorf("failed to update merged client: %w", err)
+	}
+	if source.AccumulatedCost > 0 {
+		if err := s.clientRepo.AddCost(ctx, target.ID, source.AccumulatedCost); err != nil {
+			return nil, fmt.Errorf("failed to carry accumulated cost: %w", err)
+		}
+	}
+
+	if err := s.mergeSubscriptions(ctx, target.ID, source.ID); err != nil {
+		return nil, err
+	}
+	if err := s.identityRepo.Reassign(ctx, source.ID, target.ID, nil); err != nil {
+		return nil, fmt.Errorf("failed to move identities: %w", err)
+	}
+	if err := s.clientRepo.Delete(ctx, source.ID); err != nil {
+		return nil, fmt.Errorf("failed to delete merged client: %w", err)
+	}
+
+	logrus.Infof("[CLIENTS] Merged client %s (%s) into %s (%s)", source.DisplayName, source.ID, target.DisplayName, target.ID)
+	return s.clientRepo.GetByID(ctx, target.ID)
+}
+
+func (s *IdentityService) mergeSubscriptions(ctx context.Context, targetID, sourceID string) error {
+	subs, err := s.subRepo.ListByClient(ctx, sourceID)
+	if err != nil {
+		return fmt.Errorf("failed to list subscriptions: %w", err)
+	}
+	for _, sub := range subs {
+		existing, err := s.subRepo.GetByClientAndChannel(ctx, targetID, sub.ChannelID)
+		if err != nil && !errors.Is(err, domain.ErrSubscriptionNotFound) {
+			return fmt.Errorf("failed to check subscription for channel %s: %w", sub.ChannelID, err)
+		}
+		if existing != nil {
+			if !preferSubscription(sub, existing) {
+				if err := s.subRepo.Delete(ctx, sub.ID); err != nil {
+					return fmt.Errorf("failed to drop duplicated subscription %s: %w", sub.ID, err)
+				}
+				continue
+			}
+			if err := s.subRepo.Delete(ctx, existing.ID); err != nil {
+				return fmt.Errorf("failed to replace subscription %s: %w", existing.ID, err)
+			}
+		}
+		sub.ClientID = targetID
+		if err := s.subRepo.Update(ctx, sub); err != nil {
+			return fmt.Errorf("failed to move subscription %s: %w", sub.ID, err)
+		}
+	}
+	return nil
+}
+
+// Split separa identidades (y opcionalmente suscripciones) de un cliente en un cliente nuevo.
+// La primera identidad pasa a ser la principal del cliente nuevo.
+func (s *IdentityService) Split(ctx context.Context, clientID string, req domain.SplitRequest) (*domain.Client, error) {
+	if len(req.IdentityIDs) == 0 {
+		return nil, errors.New("at least one identity is required")
+	}
+	client, err := s.clientRepo.GetByID(ctx, clientID)
+	if err != nil {
+		return nil, err
+	}
+
+	identities := make([]*domain.ClientIdentity, 0, len(req.IdentityIDs))
+	for _, id := range req.IdentityIDs {
+		identity, err := s.identityRepo.GetByID(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if identity.ClientID != client.ID {
+			return nil, domain.ErrIdentityNotFound
+		}
+		if isPrimaryIdentity(client, identity) {
+			return nil, domain.ErrPrimaryIdentity
+		}
+		identities = append(identities, identity)
+	}
+	subs := make([]*domain.ClientSubscription, 0, len(req.SubscriptionIDs))
+	for _, id := range req.SubscriptionIDs {
+		sub, err := s.subRepo.GetByID(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if sub.ClientID != client.ID {
+			return nil, domain.ErrSubscriptionNotFound
+		}
+		subs = append(subs, sub)
+	}
+
+	split := &domain.Client{
+		PlatformID:   identities[0].PlatformID,
+		PlatformType: identities[0].PlatformType,
+		DisplayName:  req.DisplayName,
+		Tier:         domain.TierStandard,
+		Tags:         []string{},
+		Metadata:     make(map[string]any),
+		Language:     client.Language,
+		Timezone:     client.Timezone,
+		Country:      client.Country,
+		Enabled:      true,
+	}
+	if split.DisplayName == "" {
+		split.DisplayName = client.DisplayName
+	}
+	if req.CopyTags {
+		split.Tags = append(split.Tags, client.Tags...)
+	}
+	if req.CopyMetadata {
+		for k, v := range client.Metadata {
+			split.Metadata[k] = v
+		}
+	}
+	if err := s.clientRepo.Create(ctx, split); err != nil {
+		return nil, fmt.Errorf("failed to create split client: %w", err)
+	}
+
+	ids := make([]string, len(identities))
+	for i, identity := range identities {
+		ids[i] = identity.ID
+	}
+	if err := s.identityRepo.Reassign(ctx, client.ID, split.ID, ids); err != nil {
+		return nil, fmt.Errorf("failed to move identities: %w", err)
+	}
+	for _, sub := range subs {
+		sub.ClientID = split.ID
+		if err := s.subRepo.Update(ctx, sub); err != nil {
+			return nil, fmt.Errorf("failed to move subscription %s: %w", sub.ID, err)
+		}
+	}
+
+	logrus.Infof("[CLIENTS] Split %d identities of client %s into new client %s", len(ids), client.ID, split.ID)
+	return split, nil
+}
+
+// CreateLinkCode genera un código de un solo uso que el cliente envía como "LINK <código>"
+// desde otra plataforma para vincularla a su cuenta.
+func (s *IdentityService) CreateLinkCode(ctx context.Context, clientID string) (string, time.Time, error) {
+	if s.codes == nil {
+		return "", time.Time{}, errors.New("link code storage unavailable")
+	}
+	client, err := s.clientRepo.GetByID(ctx, clientID)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	if !client.Enabled {
+		return "", time.Time{}, domain.ErrClientDisabled
+	}
+
+	code, err := randomLinkCode()
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	if err := s.codes.Set(ctx, linkCodeKey(code), client.ID, linkCodeTTL); err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to store link code: %w", err)
+	}
+	return code, time.Now().Add(linkCodeTTL), nil
+}
+
+// ParseLinkCode extrae el código de un mensaje "LINK <código>"
+func ParseLinkCode(text string) (string, bool) {
+	m := linkCodePattern.FindStringSubmatch(text)
+	if m == nil {
+		return "", false
+	}
+	return strings.ToUpper(m[1]), true
+}
+
+// RedeemLinkCode vincula las identidades del remitente al cliente que generó el código
+// (o al que reclamó su magic link desde el portal, si el remitente es la cuenta del enlace)
+func (s *IdentityService) RedeemLinkCode(ctx context.Context, code string, platformType domain.PlatformType, platformIDs ...string) (*domain.Client, error) {
+	if s.codes == nil {
+		return nil, errors.New("link code storage unavailable")
+	}
+	code = strings.ToUpper(strings.TrimSpace(code))
+	key := linkCodeKey(code)
+	clientID, err := s.codes.Get(ctx, key)
+	if err != nil || clientID == "" {
+		return s.redeemLinkConfirmation(ctx, code, platformType, platformIDs...)
+	}
+	client, err := s.clientRepo.GetByID(ctx, clientID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.linkIdentities(ctx, client, platformType, domain.IdentitySelfLink, platformIDs...); err != nil {
+		return nil, err
+	}
+	_ = s.codes.Delete(ctx, key)
+
+	logrus.Infof("[CLIENTS] Client %s linked %s identity %v with a one-time code", client.ID, platformType, platformIDs)
+	return client, nil
+}
+
+type linkToken struct {
+	PlatformType domain.PlatformType `json:"platform_type"`
+	PlatformIDs  []string            `json:"platform_ids"`
+}
+
+// CreateLinkToken genera el token del magic link del portal para una identidad de plataforma.
+// Se entrega en el chat de esa plataforma; quien lo abre con su sesión del portal recibe un código
+// que la propia cuenta debe enviar para completar la vinculación (ver ClaimLinkToken).
+func (s *IdentityService) CreateLinkToken(ctx context.Context, platformType domain.PlatformType, platformIDs ...string) (string, error) {
+	if s.codes == nil {
+		return "", errors.New("link token storage unavailable")
+	}
+	ids := compactIDs(platformIDs)
+	if len(ids) == 0 || platformType == "" {
+		return "", errors.New("platform identity is required")
+	}
+
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	token := hex.EncodeToString(buf)
+	data, _ := json.Marshal(linkToken{PlatformType: platformType, PlatformIDs: ids})
+	if err := s.codes.Set(ctx, linkTokenKey(token), string(data), linkTokenTTL); err != nil {
+		return "", fmt.Errorf("failed to store link token: %w", err)
+	}
+	return token, nil
+}
+
+// ClaimLinkToken canjea el magic link por un código de confirmación para el cliente del portal.
+// Abrir el enlace no vincula nada: la identidad solo pasa al cliente cuando esa misma cuenta envía
+// "LINK <código>" desde su chat, así quien obtenga el enlace sin controlar la cuenta no puede quedársela.
+func (s *IdentityService) ClaimLinkToken(ctx context.Context, token, clientID string) (string, time.Time, error) {
+	if s.codes == nil {
+		return "", time.Time{}, errors.New("link token storage unavailable")
+	}
+	data, err := s.codes.Get(ctx, linkTokenKey(token))
+	if err != nil || data == "" {
+		return "", time.Time{}, domain.ErrInvalidLinkCode
+	}
+	var lt linkToken
+	if err := json.Unmarshal([]byte(data), &lt); err != nil {
+		return "", time.Time{}, domain.ErrInvalidLinkCode
+	}
+	client, err := s.clientRepo.GetByID(ctx, clientID)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	if !client.Enabled {
+		return "", time.Time{}, domain.ErrClientDisabled
+	}
+
+	code, err := randomLinkCode()
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	confirm, _ := json.Marshal(linkConfirmation{ClientID: client.ID, PlatformType: lt.PlatformType, PlatformIDs: lt.PlatformIDs})
+	if err := s.codes.Set(ctx, linkConfirmKey(code), string(confirm), linkCodeTTL); err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to store link confirmation: %w", err)
+	}
+	_ = s.codes.Delete(ctx, linkTokenKey(token))
+
+	logrus.Infof("[CLIENTS] Client %s claimed a link to %s identity %v, waiting for confirmation from that account", client.ID, lt.PlatformType, lt.PlatformIDs)
+	return code, time.Now().Add(linkCodeTTL), nil
+}
+
+// linkConfirmation es una vinculación pedida desde el portal con un magic link; la completa la cuenta del enlace
+type linkConfirmation struct {
+	ClientID     string              `json:"client_id"`
+	PlatformType domain.PlatformType `json:"platform_type"`
+	PlatformIDs  []string            `json:"platform_ids"`
+}
+
+// redeemLinkConfirmation completa una vinculación del portal: solo vale enviado desde la cuenta del magic link
+func (s *IdentityService) redeemLinkConfirmation(ctx context.Context, code string, platformType domain.PlatformType, platformIDs ...string) (*domain.Client, error) {
+	key := linkConfirmKey(code)
+	data, err := s.codes.Get(ctx, key)
+	if err != nil || data == "" {
+		return nil, domain.ErrInvalidLinkCode
+	}
+	var lc linkConfirmation
+	if err := json.Unmarshal([]byte(data), &lc); err != nil || lc.PlatformType != platformType {
+		return nil, domain.ErrInvalidLinkCode
+	}
+	fromLinkedAccount := false
+	for _, id := range compactIDs(platformIDs) {
+		fromLinkedAccount = fromLinkedAccount || slices.Contains(lc.PlatformIDs, id)
+	}
+	if !fromLinkedAccount {
+		return nil, domain.ErrInvalidLinkCode
+	}
+
+	client, err := s.clientRepo.GetByID(ctx, lc.ClientID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.linkIdentities(ctx, client, platformType, domain.IdentitySelfLink, platformIDs...); err != nil {
+		return nil, err
+	}
+	_ = s.codes.Delete(ctx, key)
+
+	logrus.Infof("[CLIENTS] Client %s linked %s identity %v from the portal", client.ID, platformType, platformIDs)
+	return client, nil
+}
+
+// linkIdentities vincula todas las identidades o ninguna: si alguna es de otro cliente no toca nada
+func (s *IdentityService) linkIdentities(ctx context.Context, client *domain.Client, platformType domain.PlatformType, source domain.IdentitySource, platformIDs ...string) error {
+	ids := compactIDs(platformIDs)
+	if len(ids) == 0 {
+		return errors.New("platform identity is required")
+	}
+	if err := s.ensurePrimary(ctx, client); err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		identity, err := s.identityRepo.GetByPlatform(ctx, id, platformType)
+		if err == nil && identity.ClientID != client.ID {
+			return domain.ErrIdentityLinked
+		}
+		if err != nil && !errors.Is(err, domain.ErrIdentityNotFound) {
+			return err
+		}
+		// Clientes creados antes de la tabla de identidades (o sin backfill todavía)
+		legacy, err := s.clientRepo.GetByPlatform(ctx, id, platformType)
+		if err == nil && legacy.ID != client.ID {
+			return domain.ErrIdentityLinked
+		}
+	}
+
+	for _, id := range ids {
+		identity := &domain.ClientIdentity{
+			ClientID:     client.ID,
+			PlatformID:   id,
+			PlatformType: platformType,
+			Source:       source,
+			Verified:     true,
+		}
+		if err := s.identityRepo.Link(ctx, identity); err != nil {
+			return fmt.Errorf("failed to link identity %s: %w", id, err)
+		}
+	}
+	return nil
+}
+
+// ensurePrimary registra el PlatformID del cliente en la tabla de identidades si aún no está
+func (s *IdentityService) ensurePrimary(ctx context.Context, client *domain.Client) error {
+	if client.PlatformID == "" {
+		return nil
+	}
+	err := s.identityRepo.Link(ctx, &domain.ClientIdentity{
+		ClientID:     client.ID,
+		PlatformID:   client.PlatformID,
+		PlatformType: client.PlatformType,
+		Source:       domain.IdentityPrimary,
+		Verified:     true,
+	})
+	if errors.Is(err, domain.ErrIdentityLinked) {
+		// Otro cliente ya la reclamó (ej. vinculada antes de un cambio de PlatformID): no es fatal
+		logrus.Warnf("[CLIENTS] Primary identity %s of client %s belongs to another client", client.PlatformID, client.ID)
+		return nil
+	}
+	return err
+}
+
+func isPrimaryIdentity(client *domain.Client, identity *domain.ClientIdentity) bool {
+	return identity.PlatformID == client.PlatformID && identity.PlatformType == client.PlatformType
+}
+
+// mergeClientFields completa target con los datos de source sin pisar lo que target ya tiene
+func mergeClientFields(target, source *domain.Client) {
+	target.Tags = unionStrings(target.Tags, source.Tags)
+	target.AllowedBots = unionStrings(target.AllowedBots, source.AllowedBots)
+	target.OwnedChannels = unionStrings(target.OwnedChannels, source.OwnedChannels)
+
+	if target.Metadata == nil {
+		target.Metadata = make(map[string]any)
+	}
+	for k, v := range source.Metadata {
+		if _, exists := target.Metadata[k]; !exists {
+			target.Metadata[k] = v
+		}
+	}
+
+	if tierRank(source.Tier) > tierRank(target.Tier) {
+		target.Tier = source.Tier
+	}
+
+	fill := func(dst *string, src string) {
+		if *dst == "" {
+			*dst = src
+		}
+	}
+	fill(&target.DisplayName, source.DisplayName)
+	fill(&target.Email, source.Email)
+	fill(&target.Phone, source.Phone)
+	fill(&target.Language, source.Language)
+	fill(&target.Timezone, source.Timezone)
+	fill(&target.Country, source.Country)
+
+	if source.Notes != "" && source.Notes != target.Notes {
+		if target.Notes == "" {
+			target.Notes = source.Notes
+		} else {
+			target.Notes += "\n" + source.Notes
+		}
+	}
+	if target.SessionTimeout == 0 {
+		target.SessionTimeout = source.SessionTimeout
+	}
+	if target.InactivityWarningTime == 0 {
+		target.InactivityWarningTime = source.InactivityWarningTime
+	}
+	target.IsTester = target.IsTester || source.IsTester
+
+	if source.LastInteraction != nil && (target.LastInteraction == nil || source.LastInteraction.After(*target.LastInteraction)) {
+		target.LastInteraction = source.LastInteraction
+	}
+	if !source.CreatedAt.IsZero() && source.CreatedAt.Before(target.CreatedAt) {
+		target.CreatedAt = source.CreatedAt
+	}
+}
+
+// preferSubscription indica si a debe ganar sobre b cuando ambas son del mismo canal
+func preferSubscription(a, b *domain.ClientSubscription) bool {
+	if a.IsActive() != b.IsActive() {
+		return a.IsActive()
+	}
+	if a.ExpiresAt == nil || b.ExpiresAt == nil {
+		return a.ExpiresAt == nil && b.ExpiresAt != nil
+	}
+	return a.ExpiresAt.After(*b.ExpiresAt)
+}
+
+func tierRank(t domain.ClientTier) int {
+	for i, tier := range tierOrder {
+		if tier == t {
+			return i
+		}
+	}
+	return -1
+}
+
+func unionStrings(a, b []string) []string {
+	seen := make(map[string]bool, len(a)+len(b))
+	out := make([]string, 0, len(a)+len(b))
+	for _, list := range [][]string{a, b} {
+		for _, v := range list {
+			if !seen[v] {
+				seen[v] = true
+				out = append(out, v)
+			}
+		}
+	}
+	return out
+}
+
+func compactIDs(ids []string) []string {
+	out := make([]string, 0, len(ids))
+	for _, id := range ids {
+		id = strings.TrimSpace(id)
+		if id == "" {
+			continue
+		}
+		dup := false
+		for _, o := range out {
+			if o == id {
+				dup = true
+				break
+			}
+		}
+		if !dup {
+			out = append(out, id)
+		}
+	}
+	return out
+}
+
+func randomLinkCode() (string, error) {
+	b := make([]byte, linkCodeLength)
+	max := big.NewInt(int64(len(linkCodeAlphabet)))
+	for i := range b {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		b[i] = linkCodeAlphabet[n.Int64()]
+	}
+	return string(b), nil
+}
+
+func linkCodeKey(code string) string {
+	return "client_link_code:" + code
+}
+
+func linkConfirmKey(code string) string {
+	return "client_link_confirm:" + code
+}
+
+func linkTokenKey(token string) string {
+	return "client_link_token:" + token
+}
+
+// RedeemLinkMessage canjea el código si text es un mensaje "LINK <código>"; handled indica si lo era
+func (s *IdentityService) RedeemLinkMessage(ctx context.Context, text string, platformType domain.PlatformType, platformIDs ...string) (*domain.Client, bool, error) {
+	code, ok := ParseLinkCode(text)
+	if !ok {
+		return nil, false, nil
+	}
+	client, err := s.RedeemLinkCode(ctx, code, platformType, platformIDs...)
+	return client, true, err
+}
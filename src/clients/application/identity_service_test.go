@@ -0,0 +1,184 @@
+package application
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/AzielCF/az-wap/clients/domain"
+	"github.com/AzielCF/az-wap/clients/repository"
+	"github.com/AzielCF/az-wap/core/kvstore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type identityFixture struct {
+	clients    *ClientService
+	identities *IdentityService
+	identity   *repository.IdentityGormRepository
+	subRepo    *repository.SubscriptionGormRepository
+	resolver   *ClientResolver
+}
+
+func newIdentityFixture(t *testing.T) identityFixture {
+	t.Helper()
+	r := newTestRepos(t)
+	return identityFixture{
+		clients:    NewClientService(r.clients, r.subs, r.identities),
+		identities: NewIdentityService(r.identities, r.clients, r.subs, kvstore.NewSmartStore(nil)),
+		identity:   r.identities,
+		subRepo:    r.subs,
+		resolver:   NewClientResolver(r.clients, r.subs, r.identities, fakeChannels{}),
+	}
+}
+
+func (f identityFixture) client(t *testing.T, platformID string, platformType domain.PlatformType, tier domain.ClientTier, tags ...string) *domain.Client {
+	t.Helper()
+	c := &domain.Client{PlatformID: platformID, PlatformType: platformType, DisplayName: platformID, Tier: tier, Tags: tags}
+	require.NoError(t, f.clients.Create(context.Background(), c))
+	return c
+}
+
+func TestIdentityService_MergeReconcilesClients(t *testing.T) {
+	f := newIdentityFixture(t)
+	ctx := context.Background()
+
+	wa := f.client(t, "51999888777", domain.PlatformWhatsApp, domain.TierStandard, "a")
+	wa.Metadata = map[string]any{"company": "ACME"}
+	require.NoError(t, f.clients.Update(ctx, wa))
+	tg := f.client(t, "tg-42", domain.PlatformTelegram, domain.TierVIP, "b")
+	tg.Metadata = map[string]any{"company": "Other", "birthday": "01-01"}
+	require.NoError(t, f.clients.Update(ctx, tg))
+
+	past := time.Now().Add(-time.Hour)
+	require.NoError(t, f.subRepo.Create(ctx, &domain.ClientSubscription{ClientID: wa.ID, ChannelID: "ch1", Status: domain.SubscriptionActive, ExpiresAt: &past}))
+	require.NoError(t, f.subRepo.Create(ctx, &domain.ClientSubscription{ClientID: tg.ID, ChannelID: "ch1", Status: domain.SubscriptionActive}))
+	require.NoError(t, f.subRepo.Create(ctx, &domain.ClientSubscription{ClientID: tg.ID, ChannelID: "ch2", Status: domain.SubscriptionActive}))
+
+	merged, err := f.identities.Merge(ctx, wa.ID, tg.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.TierVIP, merged.Tier)
+	assert.ElementsMatch(t, []string{"a", "b"}, merged.Tags)
+	assert.Equal(t, "ACME", merged.Metadata["company"])
+	assert.Equal(t, "01-01", merged.Metadata["birthday"])
+
+	subs, err := f.subRepo.ListByClient(ctx, wa.ID)
+	require.NoError(t, err)
+	require.Len(t, subs, 2)
+	for _, sub := range subs {
+		assert.True(t, sub.IsActive(), "the active subscription of ch1 must win")
+	}
+
+	// El ID de Telegram ahora resuelve al cliente fusionado
+	found, err := f.resolver.ResolveQuick(ctx, "tg-42", domain.PlatformTelegram)
+	require.NoError(t, err)
+	assert.Equal(t, wa.ID, found.ID)
+
+	_, err = f.clients.GetByID(ctx, tg.ID)
+	assert.ErrorIs(t, err, domain.ErrClientNotFound)
+}
+
+func TestIdentityService_LinkCodeAndSplit(t *testing.T) {
+	f := newIdentityFixture(t)
+	ctx := context.Background()
+	wa := f.client(t, "51999888777", domain.PlatformWhatsApp, domain.TierPremium, "a")
+
+	code, _, err := f.identities.CreateLinkCode(ctx, wa.ID)
+	require.NoError(t, err)
+
+	_, handled, _ := f.identities.RedeemLinkMessage(ctx, "hola", domain.PlatformTelegram, "tg-7")
+	assert.False(t, handled)
+
+	client, handled, err := f.identities.RedeemLinkMessage(ctx, "link "+code, domain.PlatformTelegram, "tg-7")
+	require.True(t, handled)
+	require.NoError(t, err)
+	assert.Equal(t, wa.ID, client.ID)
+
+	// Un solo uso
+	_, err = f.identities.RedeemLinkCode(ctx, code, domain.PlatformTelegram, "tg-8")
+	assert.ErrorIs(t, err, domain.ErrInvalidLinkCode)
+
+	identities, err := f.identities.List(ctx, wa.ID)
+	require.NoError(t, err)
+	require.Len(t, identities, 2)
+	assert.ErrorIs(t, f.identities.Unlink(ctx, wa.ID, identities[0].ID), domain.ErrPrimaryIdentity)
+
+	split, err := f.identities.Split(ctx, wa.ID, domain.SplitRequest{IdentityIDs: []string{identities[1].ID}, CopyTags: true})
+	require.NoError(t, err)
+	assert.Equal(t, "tg-7", split.PlatformID)
+	assert.Equal(t, []string{"a"}, split.Tags)
+
+	found, err := f.resolver.ResolveQuick(ctx, "tg-7", domain.PlatformTelegram)
+	require.NoError(t, err)
+	assert.Equal(t, split.ID, found.ID)
+
+	// Una identidad ya vinculada no se puede reclamar desde otro cliente
+	_, err = f.identities.Link(ctx, wa.ID, "tg-7", domain.PlatformTelegram)
+	assert.ErrorIs(t, err, domain.ErrIdentityLinked)
+}
+
+func TestClientResolver_PhoneMatchesAreNotLinked(t *testing.T) {
+	f := newIdentityFixture(t)
+	ctx := context.Background()
+	c := &domain.Client{PlatformID: "crm-1", PlatformType: domain.PlatformWhatsApp, Phone: "+51999000333", DisplayName: "Ana", Enabled: true}
+	require.NoError(t, f.clients.Create(ctx, c))
+
+	botCtx, _, err := f.resolver.Resolve(ctx, "51999000333@s.whatsapp.net", "", string(domain.PlatformWhatsApp), "ch1")
+	require.NoError(t, err)
+	require.NotNil(t, botCtx)
+	assert.Equal(t, c.ID, botCtx.ClientID)
+
+	identities, err := f.identities.List(ctx, c.ID)
+	require.NoError(t, err)
+	assert.Len(t, identities, 1, "a phone match must not become an identity of the client")
+
+	// Identidades sin verificar de versiones anteriores no resuelven al cliente
+	require.NoError(t, f.identity.Link(ctx, &domain.ClientIdentity{ClientID: c.ID, PlatformID: "999@lid", PlatformType: domain.PlatformWhatsApp, Source: domain.IdentityAuto}))
+	found, _ := f.resolver.ResolveQuick(ctx, "999@lid", domain.PlatformWhatsApp)
+	assert.Nil(t, found)
+}
+
+func TestIdentityService_MagicLinkNeedsConfirmationFromTheAccount(t *testing.T) {
+	f := newIdentityFixture(t)
+	ctx := context.Background()
+	owner := f.client(t, "51999888777", domain.PlatformWhatsApp, domain.TierStandard)
+	owner.Enabled = true
+	require.NoError(t, f.clients.Update(ctx, owner))
+
+	token, err := f.identities.CreateLinkToken(ctx, domain.PlatformTelegram, "tg-9")
+	require.NoError(t, err)
+	code, _, err := f.identities.ClaimLinkToken(ctx, token, owner.ID)
+	require.NoError(t, err)
+
+	// Abrir el enlace no vincula: hace falta que la propia cuenta envíe el código
+	identities, err := f.identities.List(ctx, owner.ID)
+	require.NoError(t, err)
+	assert.Len(t, identities, 1)
+	_, _, err = f.identities.ClaimLinkToken(ctx, token, owner.ID)
+	assert.ErrorIs(t, err, domain.ErrInvalidLinkCode, "the magic link works once")
+
+	_, err = f.identities.RedeemLinkCode(ctx, code, domain.PlatformTelegram, "tg-10")
+	assert.ErrorIs(t, err, domain.ErrInvalidLinkCode, "another account cannot use the confirmation")
+
+	client, err := f.identities.RedeemLinkCode(ctx, code, domain.PlatformTelegram, "tg-9")
+	require.NoError(t, err)
+	assert.Equal(t, owner.ID, client.ID)
+}
+
+func TestClientResolver_LIDDoesNotReplaceOtherPlatformIDs(t *testing.T) {
+	f := newIdentityFixture(t)
+	ctx := context.Background()
+	tg := &domain.Client{PlatformID: "tg-5", PlatformType: domain.PlatformTelegram, DisplayName: "Leo", Enabled: true}
+	require.NoError(t, f.clients.Create(ctx, tg))
+	_, err := f.identities.Link(ctx, tg.ID, "555@lid", domain.PlatformWhatsApp)
+	require.NoError(t, err)
+
+	botCtx, _, err := f.resolver.Resolve(ctx, "555@lid", "", string(domain.PlatformWhatsApp), "ch1")
+	require.NoError(t, err)
+	require.NotNil(t, botCtx)
+	assert.Equal(t, tg.ID, botCtx.ClientID)
+
+	stored, err := f.clients.GetByID(ctx, tg.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "tg-5", stored.PlatformID, "a linked WhatsApp account must not become the Telegram client's primary ID")
+}
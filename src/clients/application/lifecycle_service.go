@@ -0,0 +1,404 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/AzielCF/az-wap/clients/domain"
+	channelDomain "github.com/AzielCF/az-wap/workspace/domain/channel"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// messageHandoff es cuánto espera una transición a que el nodo dueño del canal la procese
+	// para poder avisar al cliente. Pasado ese tiempo se aplica sin mensaje.
+	messageHandoff = time.Hour
+
+	// renewalAttempts limita los reintentos de una renovación que choca con otra simultánea
+	renewalAttempts = 5
+)
+
+// LifecycleMessenger entrega los mensajes del ciclo de vida por el canal de la suscripción.
+// Solo el nodo que tiene el canal conectado puede enviarlos.
+type LifecycleMessenger interface {
+	HasChannel(channelID string) bool
+	SendText(ctx context.Context, channelID, chatID, text string) error
+}
+
+// LifecycleReport resume una pasada del worker
+type LifecycleReport struct {
+	Notices int `json:"notices"`
+	Grace   int `json:"grace"`
+	Expired int `json:"expired"`
+}
+
+// LifecycleService avisa de los vencimientos, aplica el periodo de gracia, expira las
+// suscripciones y las renueva. Todas las transiciones emiten webhooks.
+type LifecycleService struct {
+	subRepo      domain.SubscriptionRepository
+	clientRepo   domain.ClientRepository
+	identityRepo domain.IdentityRepository
+	channels     ChannelResolver
+	messenger    LifecycleMessenger
+	webhooks     []webhookTarget
+	now          func() time.Time
+	startOnce    sync.Once
+}
+
+// NewLifecycleService crea el servicio de ciclo de vida de suscripciones
+func NewLifecycleService(subRepo domain.SubscriptionRepository, clientRepo domain.ClientRepository, identityRepo domain.IdentityRepository, channels ChannelResolver) *LifecycleService {
+	return &LifecycleService{
+		subRepo:      subRepo,
+		clientRepo:   clientRepo,
+		identityRepo: identityRepo,
+		channels:     channels,
+		now:          time.Now,
+	}
+}
+
+// SetMessenger conecta el envío de mensajes (workspace.Manager). Sin él solo se emiten webhooks.
+func (s *LifecycleService) SetMessenger(m LifecycleMessenger) {
+	s.messenger = m
+}
+
+// SetWebhook añade un destino global para los eventos de suscripción (además del webhook de cada canal)
+func (s *LifecycleService) SetWebhook(url, secret string) {
+	if url = strings.TrimSpace(url); url != "" {
+		s.webhooks = append(s.webhooks, webhookTarget{url: url, secret: secret})
+	}
+}
+
+// Start ejecuta el worker cada interval hasta que se cancele ctx. Es seguro en varios nodos:
+// cada transición se aplica con compare-and-swap y solo un nodo la gana.
+func (s *LifecycleService) Start(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	s.startOnce.Do(func() {
+		go func() {
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+			for {
+				if report, err := s.RunOnce(ctx); err != nil {
+					logrus.WithError(err).Error("[SUBSCRIPTIONS] Lifecycle pass failed")
+				} else if report.Notices+report.Grace+report.Expired > 0 {
+					logrus.Infof("[SUBSCRIPTIONS] Lifecycle: %d notices, %d in grace, %d expired", report.Notices, report.Grace, report.Expired)
+				}
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+				}
+			}
+		}()
+	})
+}
+
+// RunOnce revisa las suscripciones que vencen pronto, las vencidas y las que están en gracia
+func (s *LifecycleService) RunOnce(ctx context.Context) (LifecycleReport, error) {
+	var report LifecycleReport
+	now := s.now()
+	subs, err := s.subRepo.ListExpiring(ctx, now.AddDate(0, 0, channelDomain.MaxNoticeDays))
+	if err != nil {
+		return report, fmt.Errorf("list expiring subscriptions: %w", err)
+	}
+
+	channels := make(map[string]*channelDomain.Channel)
+	for _, sub := range subs {
+		ch, ok := channels[sub.ChannelID]
+		if !ok {
+			if found, err := s.channels.GetChannel(ctx, sub.ChannelID); err == nil {
+				ch = &found
+			}
+			channels[sub.ChannelID] = ch
+		}
+		if err := s.process(ctx, sub, ch, now, &report); err != nil {
+			logrus.WithError(err).Warnf("[SUBSCRIPTIONS] Lifecycle failed for subscription %s", sub.ID)
+		}
+	}
+	return report, nil
+}
+
+func (s *LifecycleService) process(ctx context.Context, sub *domain.ClientSubscription, ch *channelDomain.Channel, now time.Time, report *LifecycleReport) error {
+	if sub.ExpiresAt == nil {
+		return nil
+	}
+	var policy *channelDomain.SubscriptionLifecycle
+	if ch != nil {
+		policy = ch.Config.SubscriptionLifecycle
+	}
+	from := sub.Lifecycle()
+	// Si una renovación cambió expires_at después de leerla, el compare-and-swap no aplica nada
+	expiresBefore := sub.ExpiresAt.Add(time.Second)
+
+	switch sub.Status {
+	case domain.SubscriptionActive:
+		if sub.ExpiresAt.After(now) {
+			return s.notice(ctx, sub, ch, policy, now, report)
+		}
+		if days := policy.Grace(); days > 0 {
+			graceUntil := sub.ExpiresAt.AddDate(0, 0, days)
+			if graceUntil.After(now) {
+				if !s.readyFor(ch, policy, channelDomain.LifecycleGrace, *sub.ExpiresAt, now) {
+					return nil
+				}
+				to := domain.LifecycleState{Status: domain.SubscriptionGrace, LastNoticeDays: from.LastNoticeDays, GraceUntil: &graceUntil}
+				ok, err := s.subRepo.AdvanceLifecycle(ctx, sub.ID, from, to, expiresBefore)
+				if err != nil || !ok {
+					return err
+				}
+				sub.Status, sub.GraceUntil = to.Status, to.GraceUntil
+				report.Grace++
+				s.announce(ctx, sub, ch, channelDomain.LifecycleGrace, 0, "", "")
+				return nil
+			}
+		}
+		return s.expire(ctx, sub, ch, policy, from, *sub.ExpiresAt, expiresBefore, now, report)
+
+	case domain.SubscriptionGrace:
+		if sub.GraceUntil != nil && sub.GraceUntil.After(now) {
+			return nil
+		}
+		dueAt := *sub.ExpiresAt
+		if sub.GraceUntil != nil {
+			dueAt = *sub.GraceUntil
+		}
+		return s.expire(ctx, sub, ch, policy, from, dueAt, expiresBefore, now, report)
+	}
+	return nil
+}
+
+// notice envía el aviso del umbral más cercano alcanzado, una sola vez por umbral
+func (s *LifecycleService) notice(ctx context.Context, sub *domain.ClientSubscription, ch *channelDomain.Channel, policy *channelDomain.SubscriptionLifecycle, now time.Time, report *LifecycleReport) error {
+	left := sub.ExpiresAt.Sub(now)
+	threshold := 0
+	for _, d := range policy.Notices() {
+		if left <= time.Duration(d)*24*time.Hour {
+			threshold = d
+		}
+	}
+	if threshold == 0 || (sub.LastNoticeDays != 0 && threshold >= sub.LastNoticeDays) {
+		return nil
+	}
+	// El aviso solo tiene sentido si se puede entregar: lo envía el nodo que tiene el canal
+	if ch == nil || !s.canSend(ch.ID) {
+		return nil
+	}
+
+	from := sub.Lifecycle()
+	to := from
+	to.LastNoticeDays = threshold
+	ok, err := s.subRepo.AdvanceLifecycle(ctx, sub.ID, from, to, sub.ExpiresAt.Add(time.Second))
+	if err != nil || !ok {
+		return err
+	}
+	sub.LastNoticeDays = threshold
+	report.Notices++
+	s.announce(ctx, sub, ch, channelDomain.LifecycleNotice, daysLeft(left), "", "")
+	return nil
+}
+
+func (s *LifecycleService) expire(ctx context.Context, sub *domain.ClientSubscription, ch *channelDomain.Channel, policy *channelDomain.SubscriptionLifecycle, from domain.LifecycleState, dueAt, expiresBefore, now time.Time, report *LifecycleReport) error {
+	if !s.readyFor(ch, policy, channelDomain.LifecycleExpired, dueAt, now) {
+		return nil
+	}
+	to := domain.LifecycleState{Status: domain.SubscriptionExpired, LastNoticeDays: from.LastNoticeDays, GraceUntil: from.GraceUntil}
+	ok, err := s.subRepo.AdvanceLifecycle(ctx, sub.ID, from, to, expiresBefore)
+	if err != nil || !ok {
+		return err
+	}
+	sub.Status = domain.SubscriptionExpired
+	report.Expired++
+	s.announce(ctx, sub, ch, channelDomain.LifecycleExpired, 0, "", "")
+	return nil
+}
+
+// readyFor decide si este nodo aplica ya la transición. Si hay mensaje que enviar se deja
+// al nodo con el canal conectado, salvo que nadie lo haya hecho en messageHandoff.
+func (s *LifecycleService) readyFor(ch *channelDomain.Channel, policy *channelDomain.SubscriptionLifecycle, event channelDomain.LifecycleEvent, dueAt, now time.Time) bool {
+	if ch == nil || policy.Template(event, "") == "" || s.canSend(ch.ID) {
+		return true
+	}
+	return now.Sub(dueAt) >= messageHandoff
+}
+
+// Renew extiende la suscripción y la reactiva si estaba en gracia o expirada.
+// Con Reference el mismo pago solo se aplica una vez: la referencia queda registrada en la base
+// de datos y los reintentos retornan la suscripción actual con ErrRenewalApplied.
+func (s *LifecycleService) Renew(ctx context.Context, id string, req domain.RenewRequest) (*domain.ClientSubscription, error) {
+	sub, err := s.renew(ctx, id, req)
+	if errors.Is(err, domain.ErrRenewalApplied) {
+		current, getErr := s.subRepo.GetByID(ctx, id)
+		if getErr != nil {
+			return nil, getErr
+		}
+		return current, err
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	logrus.Infof("[SUBSCRIPTIONS] Subscription %s renewed until %s (source: %s)", sub.ID, sub.ExpiresAt.Format(time.RFC3339), req.Source)
+	var ch *channelDomain.Channel
+	if found, err := s.channels.GetChannel(ctx, sub.ChannelID); err == nil {
+		ch = &found
+	}
+	s.announce(ctx, sub, ch, channelDomain.LifecycleRenewed, 0, req.Source, req.Reference)
+	return sub, nil
+}
+
+// renew reintenta cuando otra renovación movió el vencimiento entre la lectura y la escritura:
+// así dos pagos simultáneos se suman en lugar de pisarse
+func (s *LifecycleService) renew(ctx context.Context, id string, req domain.RenewRequest) (*domain.ClientSubscription, error) {
+	for attempt := 1; ; attempt++ {
+		sub, err := s.tryRenew(ctx, id, req)
+		if errors.Is(err, domain.ErrRenewalConflict) && attempt < renewalAttempts {
+			continue
+		}
+		return sub, err
+	}
+}
+
+func (s *LifecycleService) tryRenew(ctx context.Context, id string, req domain.RenewRequest) (*domain.ClientSubscription, error) {
+	sub, err := s.subRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if sub.Status == domain.SubscriptionRevoked {
+		return nil, domain.ErrSubscriptionRevoked
+	}
+
+	now := s.now()
+	var until time.Time
+	switch {
+	case req.Until != nil:
+		if !req.Until.After(now) {
+			return nil, domain.ErrInvalidRenewal
+		}
+		// Un Until anterior al vencimiento acortaría una suscripción ya pagada
+		if sub.ExpiresAt != nil && req.Until.Before(*sub.ExpiresAt) && !req.Shorten {
+			return nil, domain.ErrRenewalShortens
+		}
+		until = *req.Until
+	case req.Days > 0:
+		base := now
+		if sub.ExpiresAt != nil && sub.ExpiresAt.After(now) {
+			base = *sub.ExpiresAt
+		}
+		until = base.AddDate(0, 0, req.Days)
+	default:
+		return nil, domain.ErrInvalidRenewal
+	}
+
+	prev := sub.ExpiresAt
+	sub.ExpiresAt = &until
+	sub.GraceUntil = nil
+	sub.LastNoticeDays = 0
+	if sub.Status != domain.SubscriptionPaused {
+		sub.Status = domain.SubscriptionActive
+	}
+	if err := s.subRepo.ApplyRenewal(ctx, sub, prev, req.Source, req.Reference); err != nil {
+		return nil, err
+	}
+	return sub, nil
+}
+
+// announce envía el mensaje del evento al cliente (si el canal lo tiene configurado) y emite el webhook
+func (s *LifecycleService) announce(ctx context.Context, sub *domain.ClientSubscription, ch *channelDomain.Channel, event channelDomain.LifecycleEvent, days int, source, reference string) {
+	s.emit(ch, SubscriptionEvent{
+		Event:          "subscription." + webhookEventName(event),
+		SubscriptionID: sub.ID,
+		ClientID:       sub.ClientID,
+		ChannelID:      sub.ChannelID,
+		Status:         sub.Status,
+		ExpiresAt:      sub.ExpiresAt,
+		GraceUntil:     sub.GraceUntil,
+		DaysLeft:       days,
+		Source:         source,
+		Reference:      reference,
+		Timestamp:      s.now(),
+	})
+
+	if ch == nil || !s.canSend(ch.ID) {
+		return
+	}
+	if err := s.sendMessage(ctx, sub, ch, event, days); err != nil {
+		logrus.WithError(err).Warnf("[SUBSCRIPTIONS] Could not send %s message for subscription %s", event, sub.ID)
+	}
+}
+
+func (s *LifecycleService) sendMessage(ctx context.Context, sub *domain.ClientSubscription, ch *channelDomain.Channel, event channelDomain.LifecycleEvent, days int) error {
+	policy := ch.Config.SubscriptionLifecycle
+	if policy.Template(event, "") == "" {
+		return nil
+	}
+	client, err := s.clientRepo.GetByID(ctx, sub.ClientID)
+	if err != nil {
+		return err
+	}
+	lang := client.Language
+	if lang == "" {
+		lang = ch.Config.DefaultLanguage
+	}
+	chatID, err := s.recipient(ctx, client, ch.Type)
+	if err != nil {
+		return err
+	}
+
+	loc := ch.Config.Location()
+	text := strings.NewReplacer(
+		"{{name}}", client.DisplayName,
+		"{{days}}", fmt.Sprint(days),
+		"{{expires_at}}", formatDate(sub.ExpiresAt, loc),
+		"{{grace_until}}", formatDate(sub.GraceUntil, loc),
+	).Replace(policy.Template(event, lang))
+	return s.messenger.SendText(ctx, ch.ID, chatID, text)
+}
+
+// recipient elige el chat del cliente en la plataforma del canal
+func (s *LifecycleService) recipient(ctx context.Context, client *domain.Client, chType channelDomain.ChannelType) (string, error) {
+	platform := domain.PlatformType(chType)
+	if client.PlatformType == platform && client.PlatformID != "" {
+		return client.PlatformID, nil
+	}
+	identities, err := s.identityRepo.ListByClient(ctx, client.ID)
+	if err != nil {
+		return "", err
+	}
+	for _, identity := range identities {
+		if identity.PlatformType == platform {
+			return identity.PlatformID, nil
+		}
+	}
+	if platform == domain.PlatformWhatsApp && client.Phone != "" {
+		return digitsOnly(client.Phone), nil
+	}
+	return "", fmt.Errorf("client %s has no %s identity", client.ID, chType)
+}
+
+func (s *LifecycleService) canSend(channelID string) bool {
+	return s.messenger != nil && s.messenger.HasChannel(channelID)
+}
+
+func webhookEventName(event channelDomain.LifecycleEvent) string {
+	if event == channelDomain.LifecycleNotice {
+		return "expiring"
+	}
+	return string(event)
+}
+
+func daysLeft(left time.Duration) int {
+	return int(math.Ceil(left.Hours() / 24))
+}
+
+func formatDate(t *time.Time, loc *time.Location) string {
+	if t == nil {
+		return ""
+	}
+	return t.In(loc).Format("2006-01-02")
+}
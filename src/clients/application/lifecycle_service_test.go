@@ -0,0 +1,171 @@
+package application
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/AzielCF/az-wap/clients/domain"
+	"github.com/AzielCF/az-wap/clients/repository"
+	channelDomain "github.com/AzielCF/az-wap/workspace/domain/channel"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeChannels map[string]channelDomain.Channel
+
+func (f fakeChannels) GetChannel(_ context.Context, id string) (channelDomain.Channel, error) {
+	return f[id], nil
+}
+
+// fakeMessenger guarda los mensajes enviados por chat
+type fakeMessenger struct {
+	sent []string
+}
+
+func (f *fakeMessenger) HasChannel(string) bool { return true }
+
+func (f *fakeMessenger) SendText(_ context.Context, _, chatID, text string) error {
+	f.sent = append(f.sent, chatID+": "+text)
+	return nil
+}
+
+func TestLifecycleService_NoticesGraceExpiryAndRenewal(t *testing.T) {
+	ctx := context.Background()
+	r := newTestRepos(t)
+	clientRepo, identityRepo, subRepo := r.clients, r.identities, r.subs
+
+	events := make(chan SubscriptionEvent, 10)
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		assert.True(t, len(r.Header.Get("X-Hub-Signature-256")) > len("sha256="))
+		var evt SubscriptionEvent
+		_ = json.Unmarshal(body, &evt)
+		events <- evt
+	}))
+	defer hook.Close()
+
+	channels := fakeChannels{"ch1": {ID: "ch1", Type: channelDomain.ChannelTypeWhatsApp, Config: channelDomain.ChannelConfig{
+		WebhookURL:    hook.URL,
+		WebhookSecret: "s3cret",
+		SubscriptionLifecycle: &channelDomain.SubscriptionLifecycle{
+			Enabled: true, NoticeDays: []int{7, 1}, GraceDays: 3, GraceBotTemplateID: "lite",
+		},
+	}}}
+	messenger := &fakeMessenger{}
+	lifecycle := NewLifecycleService(subRepo, clientRepo, identityRepo, channels)
+	lifecycle.SetMessenger(messenger)
+
+	clients := NewClientService(clientRepo, subRepo, identityRepo)
+	client := &domain.Client{PlatformID: "51999000111", PlatformType: domain.PlatformWhatsApp, DisplayName: "Ana", Language: "es"}
+	require.NoError(t, clients.Create(ctx, client))
+	expires := time.Now().Add(5 * 24 * time.Hour).Truncate(time.Second)
+	sub := &domain.ClientSubscription{ClientID: client.ID, ChannelID: "ch1", Status: domain.SubscriptionActive, ExpiresAt: &expires}
+	require.NoError(t, subRepo.Create(ctx, sub))
+
+	nextEvent := func() string {
+		select {
+		case evt := <-events:
+			return evt.Event
+		case <-time.After(5 * time.Second):
+			return "timeout"
+		}
+	}
+	run := func(at time.Time) LifecycleReport {
+		lifecycle.now = func() time.Time { return at }
+		report, err := lifecycle.RunOnce(ctx)
+		require.NoError(t, err)
+		return report
+	}
+
+	// 5 días antes: aviso de 7 días, una sola vez
+	assert.Equal(t, 1, run(expires.Add(-5*24*time.Hour)).Notices)
+	assert.Equal(t, "subscription.expiring", nextEvent())
+	assert.Zero(t, run(expires.Add(-4*24*time.Hour)).Notices)
+	require.Len(t, messenger.sent, 1)
+	assert.Contains(t, messenger.sent[0], "51999000111: Hola Ana, tu suscripción vence en 5 día(s)")
+
+	// 12 horas antes: aviso de 1 día
+	assert.Equal(t, 1, run(expires.Add(-12*time.Hour)).Notices)
+	assert.Equal(t, "subscription.expiring", nextEvent())
+
+	// Vencida: entra en gracia y sigue contando como activa con la variante reducida
+	assert.Equal(t, 1, run(expires.Add(time.Hour)).Grace)
+	assert.Equal(t, "subscription.grace", nextEvent())
+	stored, err := subRepo.GetByID(ctx, sub.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.SubscriptionGrace, stored.Status)
+	assert.Equal(t, expires.AddDate(0, 0, 3).Unix(), stored.GraceUntil.Unix())
+
+	// Fin de la gracia
+	assert.Equal(t, 1, run(expires.Add(4*24*time.Hour)).Expired)
+	assert.Equal(t, "subscription.expired", nextEvent())
+	assert.Len(t, messenger.sent, 4)
+
+	// Renovación por pago: idempotente por referencia
+	renewedAt := expires.Add(4 * 24 * time.Hour)
+	renewed, err := lifecycle.Renew(ctx, sub.ID, domain.RenewRequest{Days: 30, Source: "payment", Reference: "pay-1"})
+	require.NoError(t, err)
+	assert.Equal(t, domain.SubscriptionActive, renewed.Status)
+	assert.Equal(t, renewedAt.AddDate(0, 0, 30).Unix(), renewed.ExpiresAt.Unix())
+	assert.Zero(t, renewed.LastNoticeDays)
+	assert.Equal(t, "subscription.renewed", nextEvent())
+
+	// La referencia vive en la base de datos: otra instancia (u otro nodo) tampoco la aplica dos veces
+	_, err = NewLifecycleService(subRepo, clientRepo, identityRepo, channels).Renew(ctx, sub.ID, domain.RenewRequest{Days: 30, Source: "payment", Reference: "pay-1"})
+	assert.ErrorIs(t, err, domain.ErrRenewalApplied)
+	stored, err = subRepo.GetByID(ctx, sub.ID)
+	require.NoError(t, err)
+	assert.Equal(t, renewed.ExpiresAt.Unix(), stored.ExpiresAt.Unix())
+
+	// Un until anterior al vencimiento no acorta la suscripción salvo que se confirme
+	earlier := renewedAt.AddDate(0, 0, 10)
+	_, err = lifecycle.Renew(ctx, sub.ID, domain.RenewRequest{Until: &earlier, Source: "payment", Reference: "pay-2"})
+	assert.ErrorIs(t, err, domain.ErrRenewalShortens)
+	shortened, err := lifecycle.Renew(ctx, sub.ID, domain.RenewRequest{Until: &earlier, Source: "admin", Shorten: true})
+	require.NoError(t, err)
+	assert.Equal(t, earlier.Unix(), shortened.ExpiresAt.Unix())
+}
+
+// racingSubs aplica otra renovación justo antes de la primera escritura, como un pago simultáneo
+type racingSubs struct {
+	*repository.SubscriptionGormRepository
+	race func()
+}
+
+func (r *racingSubs) ApplyRenewal(ctx context.Context, sub *domain.ClientSubscription, prev *time.Time, source, reference string) error {
+	if race := r.race; race != nil {
+		r.race = nil
+		race()
+	}
+	return r.SubscriptionGormRepository.ApplyRenewal(ctx, sub, prev, source, reference)
+}
+
+func TestLifecycleService_ConcurrentRenewalsAddUp(t *testing.T) {
+	ctx := context.Background()
+	r := newTestRepos(t)
+	channels := fakeChannels{"ch1": {ID: "ch1", Type: channelDomain.ChannelTypeWhatsApp}}
+
+	client := &domain.Client{PlatformID: "51999000222", PlatformType: domain.PlatformWhatsApp, DisplayName: "Luis"}
+	require.NoError(t, NewClientService(r.clients, r.subs, r.identities).Create(ctx, client))
+	expires := time.Now().Add(5 * 24 * time.Hour).Truncate(time.Second)
+	sub := &domain.ClientSubscription{ClientID: client.ID, ChannelID: "ch1", Status: domain.SubscriptionActive, ExpiresAt: &expires}
+	require.NoError(t, r.subs.Create(ctx, sub))
+
+	other := NewLifecycleService(r.subs, r.clients, r.identities, channels)
+	racing := &racingSubs{SubscriptionGormRepository: r.subs, race: func() {
+		_, err := other.Renew(ctx, sub.ID, domain.RenewRequest{Days: 10, Source: "payment", Reference: "pay-b"})
+		require.NoError(t, err)
+	}}
+	_, err := NewLifecycleService(racing, r.clients, r.identities, channels).Renew(ctx, sub.ID, domain.RenewRequest{Days: 10, Source: "payment", Reference: "pay-a"})
+	require.NoError(t, err)
+
+	// Ninguna de las dos extensiones pagadas se pierde
+	stored, err := r.subs.GetByID(ctx, sub.ID)
+	require.NoError(t, err)
+	assert.Equal(t, expires.AddDate(0, 0, 20).Unix(), stored.ExpiresAt.Unix())
+}
@@ -0,0 +1,109 @@
+package application
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/AzielCF/az-wap/clients/domain"
+	pkgUtils "github.com/AzielCF/az-wap/core/pkg/utils"
+	channelDomain "github.com/AzielCF/az-wap/workspace/domain/channel"
+	"github.com/sirupsen/logrus"
+)
+
+const webhookAttempts = 4
+
+// SubscriptionEvent es el payload de los webhooks de ciclo de vida.
+// Event: subscription.expiring | subscription.grace | subscription.expired | subscription.renewed
+type SubscriptionEvent struct {
+	Event          string                    `json:"event"`
+	SubscriptionID string                    `json:"subscription_id"`
+	ClientID       string                    `json:"client_id"`
+	ChannelID      string                    `json:"channel_id"`
+	Status         domain.SubscriptionStatus `json:"status"`
+	ExpiresAt      *time.Time                `json:"expires_at,omitempty"`
+	GraceUntil     *time.Time                `json:"grace_until,omitempty"`
+	DaysLeft       int                       `json:"days_left,omitempty"`
+	Source         string                    `json:"source,omitempty"`
+	Reference      string                    `json:"reference,omitempty"`
+	Timestamp      time.Time                 `json:"timestamp"`
+}
+
+type webhookTarget struct {
+	url    string
+	secret string
+}
+
+var webhookClient = &http.Client{Timeout: 10 * time.Second}
+
+// emit envía el evento al webhook del canal y al global, en segundo plano y con reintentos
+func (s *LifecycleService) emit(ch *channelDomain.Channel, evt SubscriptionEvent) {
+	targets := append([]webhookTarget(nil), s.webhooks...)
+	if ch != nil && ch.Config.WebhookURL != "" {
+		for _, url := range strings.Split(ch.Config.WebhookURL, ",") {
+			if url = strings.TrimSpace(url); url != "" {
+				targets = append(targets, webhookTarget{url: url, secret: ch.Config.WebhookSecret})
+			}
+		}
+	}
+	if len(targets) == 0 {
+		return
+	}
+
+	body, err := json.Marshal(evt)
+	if err != nil {
+		logrus.WithError(err).Error("[SUBSCRIPTIONS] Failed to marshal webhook event")
+		return
+	}
+	for _, target := range targets {
+		go func(target webhookTarget) {
+			if err := deliverWebhook(context.Background(), target, body); err != nil {
+				logrus.WithError(err).Warnf("[SUBSCRIPTIONS] Webhook %s for subscription %s not delivered", evt.Event, evt.SubscriptionID)
+			}
+		}(target)
+	}
+}
+
+// deliverWebhook firma el cuerpo como los webhooks de mensajes (X-Hub-Signature-256)
+func deliverWebhook(ctx context.Context, target webhookTarget, body []byte) error {
+	var signature string
+	if target.secret != "" {
+		sum, err := pkgUtils.GetMessageDigestOrSignature(body, []byte(target.secret))
+		if err != nil {
+			return err
+		}
+		signature = "sha256=" + sum
+	}
+
+	var lastErr error
+	wait := time.Second
+	for attempt := 0; attempt < webhookAttempts; attempt++ {
+		if attempt > 0 {
+			time.Sleep(wait)
+			wait *= 2
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, target.url, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		if signature != "" {
+			req.Header.Set("X-Hub-Signature-256", signature)
+		}
+		resp, err := webhookClient.Do(req)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		resp.Body.Close()
+		if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+			return nil
+		}
+		lastErr = fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return lastErr
+}
@@ -0,0 +1,425 @@
+package application
+
+import (
+	"archive/zip"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/AzielCF/az-wap/clients/domain"
+	"github.com/AzielCF/az-wap/core/kvstore"
+	"github.com/AzielCF/az-wap/core/pkg/utils"
+	"github.com/sirupsen/logrus"
+)
+
+const exportTokenTTL = 15 * time.Minute
+
+// DataSubject reúne las identidades de una persona para localizar sus datos en todas las fuentes.
+// ClientID/Client están vacíos si la identidad nunca llegó a registrarse como cliente.
+type DataSubject struct {
+	ClientID   string
+	Client     *domain.Client
+	Identities []*domain.ClientIdentity
+	keys       map[string]bool // Identificadores normalizados (ver utils.ChatKey)
+}
+
+// Matches indica si un JID, LID, ID de plataforma o teléfono pertenece al sujeto
+func (s *DataSubject) Matches(id string) bool {
+	k := utils.ChatKey(strings.TrimSpace(id))
+	return k != "" && s.keys[k]
+}
+
+// Keys devuelve los identificadores normalizados del sujeto en orden estable
+func (s *DataSubject) Keys() []string {
+	keys := make([]string, 0, len(s.keys))
+	for k := range s.keys {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Phones devuelve los teléfonos conocidos del sujeto (solo dígitos)
+func (s *DataSubject) Phones() []string {
+	var raw []string
+	if s.Client != nil {
+		raw = append(raw, s.Client.Phone)
+	}
+	for _, ident := range s.Identities {
+		// Los LID no son teléfonos
+		if ident.PlatformType == domain.PlatformWhatsApp && !strings.Contains(ident.PlatformID, "@lid") {
+			raw = append(raw, ident.PlatformID)
+		}
+	}
+	var phones []string
+	for _, p := range raw {
+		if d := digitsOnly(utils.ChatKey(p)); len(d) >= 7 {
+			phones = append(phones, d)
+		}
+	}
+	return compactIDs(phones)
+}
+
+// Hash identifica al sujeto en los tombstones sin guardar sus datos
+func (s *DataSubject) Hash() string {
+	return hashKey(strings.Join(s.Keys(), "|"))
+}
+
+// KeyHashes devuelve el hash de cada identificador (búsqueda posterior de tombstones por identidad)
+func (s *DataSubject) KeyHashes() []string {
+	keys := s.Keys()
+	hashes := make([]string, len(keys))
+	for i, k := range keys {
+		hashes[i] = hashKey(k)
+	}
+	return hashes
+}
+
+func hashKey(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:])
+}
+
+func (s *DataSubject) addKey(id string) {
+	if k := utils.ChatKey(strings.TrimSpace(id)); k != "" {
+		s.keys[k] = true
+	}
+	if d := digitsOnly(id); len(d) >= 7 && !strings.Contains(id, "@lid") {
+		s.keys[d] = true
+	}
+}
+
+// SourceExport es lo que una fuente aporta a la exportación
+type SourceExport struct {
+	Data  any
+	Count int
+	Files []string // Archivos locales a incluir en el ZIP
+}
+
+// ErrPartialVerify lo devuelve Verify cuando la fuente solo puede comprobar una parte de su alcance
+// (ej. datos en otros nodos): el resultado queda sin verificar, pero no cuenta como fallo
+var ErrPartialVerify = errors.New("verification limited to this node")
+
+// DataSource es una fuente de datos personales (repositorio, almacén kv, servicio externo...).
+// Erase no debe fallar por datos ausentes; Verify cuenta lo que aún referencia al sujeto.
+type DataSource interface {
+	Name() string
+	Export(ctx context.Context, subject *DataSubject) (*SourceExport, error)
+	Erase(ctx context.Context, subject *DataSubject, mode domain.ErasureMode) (int, error)
+	Verify(ctx context.Context, subject *DataSubject, mode domain.ErasureMode) (int, error)
+}
+
+// DataExport es la exportación completa de un sujeto
+type DataExport struct {
+	GeneratedAt time.Time         `json:"generated_at"`
+	ClientID    string            `json:"client_id,omitempty"`
+	Identities  []string          `json:"identities"`
+	Sources     map[string]any    `json:"sources"`
+	Errors      map[string]string `json:"errors,omitempty"`
+	Files       []string          `json:"-"`
+}
+
+// PrivacyService atiende solicitudes de acceso y supresión de datos personales (GDPR)
+type PrivacyService struct {
+	clientRepo   domain.ClientRepository
+	identityRepo domain.IdentityRepository
+	tombstones   domain.TombstoneRepository
+	tokens       kvstore.KVStore
+	core         DataSource
+	sources      []DataSource
+}
+
+// NewPrivacyService crea el servicio; los datos del propio cliente siempre se procesan al final
+func NewPrivacyService(clientService *ClientService, clientRepo domain.ClientRepository, identityRepo domain.IdentityRepository, subRepo domain.SubscriptionRepository, tombstones domain.TombstoneRepository, tokens kvstore.KVStore) *PrivacyService {
+	return &PrivacyService{
+		clientRepo:   clientRepo,
+		identityRepo: identityRepo,
+		tombstones:   tombstones,
+		tokens:       tokens,
+		core:         &clientDataSource{clients: clientService, clientRepo: clientRepo, identityRepo: identityRepo, subRepo: subRepo},
+	}
+}
+
+// RegisterSource añade una fuente de datos a exportaciones y supresiones
+func (s *PrivacyService) RegisterSource(source DataSource) {
+	s.sources = append(s.sources, source)
+}
+
+// SubjectForClient arma el sujeto a partir de un cliente global y todas sus identidades
+func (s *PrivacyService) SubjectForClient(ctx context.Context, clientID string) (*DataSubject, error) {
+	client, err := s.clientRepo.GetByID(ctx, clientID)
+	if err != nil {
+		return nil, err
+	}
+	identities, err := s.identityRepo.ListByClient(ctx, client.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list identities: %w", err)
+	}
+
+	subject := &DataSubject{ClientID: client.ID, Client: client, Identities: identities, keys: make(map[string]bool)}
+	subject.addKey(client.PlatformID)
+	subject.addKey(client.Phone)
+	for _, ident := range identities {
+		subject.addKey(ident.PlatformID)
+	}
+	return subject, nil
+}
+
+// SubjectForIdentity arma el sujeto a partir de una identidad de plataforma.
+// Si la identidad no pertenece a ningún cliente el sujeto solo cubre esa identidad.
+func (s *PrivacyService) SubjectForIdentity(ctx context.Context, platformType domain.PlatformType, platformID string) (*DataSubject, error) {
+	platformID = strings.TrimSpace(platformID)
+	if platformID == "" || platformType == "" {
+		return nil, errors.New("platform_type and platform_id are required")
+	}
+	if ident, err := s.identityRepo.GetByPlatform(ctx, platformID, platformType); err == nil {
+		return s.SubjectForClient(ctx, ident.ClientID)
+	} else if !errors.Is(err, domain.ErrIdentityNotFound) {
+		return nil, err
+	}
+	if client, err := s.clientRepo.GetByPlatform(ctx, platformID, platformType); err == nil {
+		return s.SubjectForClient(ctx, client.ID)
+	}
+
+	subject := &DataSubject{
+		Identities: []*domain.ClientIdentity{{PlatformID: platformID, PlatformType: platformType}},
+		keys:       make(map[string]bool),
+	}
+	subject.addKey(platformID)
+	return subject, nil
+}
+
+// Export reúne los datos del sujeto en todas las fuentes; el fallo de una fuente no aborta el resto
+func (s *PrivacyService) Export(ctx context.Context, subject *DataSubject) (*DataExport, error) {
+	export := &DataExport{
+		GeneratedAt: time.Now().UTC(),
+		ClientID:    subject.ClientID,
+		Sources:     make(map[string]any),
+	}
+	for _, ident := range subject.Identities {
+		export.Identities = append(export.Identities, string(ident.PlatformType)+":"+ident.PlatformID)
+	}
+
+	for _, src := range s.allSources() {
+		data, err := src.Export(ctx, subject)
+		if err != nil {
+			if export.Errors == nil {
+				export.Errors = make(map[string]string)
+			}
+			export.Errors[src.Name()] = err.Error()
+			logrus.WithError(err).Warnf("[PRIVACY] Export source %s failed", src.Name())
+			continue
+		}
+		if data == nil || data.Count == 0 {
+			continue
+		}
+		export.Sources[src.Name()] = data.Data
+		export.Files = append(export.Files, data.Files...)
+	}
+	return export, nil
+}
+
+// WriteExportZip escribe la exportación como ZIP: data.json más los archivos descargados en files/
+func WriteExportZip(w io.Writer, export *DataExport) error {
+	zw := zip.NewWriter(w)
+
+	f, err := zw.Create("data.json")
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(export); err != nil {
+		return err
+	}
+
+	for i, path := range export.Files {
+		if err := addZipFile(zw, fmt.Sprintf("files/%03d_%s", i+1, filepath.Base(path)), path); err != nil {
+			// El archivo pudo borrarse al cerrar la sesión: se omite
+			logrus.WithError(err).Debugf("[PRIVACY] Skipping export file %s", path)
+		}
+	}
+	return zw.Close()
+}
+
+func addZipFile(zw *zip.Writer, name, path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	dst, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(dst, src)
+	return err
+}
+
+// Erase suprime o anonimiza los datos del sujeto en todas las fuentes, verifica el resultado
+// y deja un tombstone auditable. Una fuente que falla queda registrada y no detiene al resto.
+func (s *PrivacyService) Erase(ctx context.Context, subject *DataSubject, mode domain.ErasureMode, requestedBy string) (*domain.Tombstone, error) {
+	if mode != domain.ErasureDelete && mode != domain.ErasureAnonymize {
+		return nil, fmt.Errorf("invalid erasure mode %q", mode)
+	}
+
+	tombstone := &domain.Tombstone{
+		ClientID:       subject.ClientID,
+		SubjectHash:    subject.Hash(),
+		IdentityHashes: subject.KeyHashes(),
+		Mode:           mode,
+		RequestedBy:    requestedBy,
+		Verified:       true,
+	}
+
+	sources := s.allSources()
+	results := make([]domain.SourceResult, len(sources))
+	for i, src := range sources {
+		results[i].Source = src.Name()
+		n, err := src.Erase(ctx, subject, mode)
+		results[i].Erased = n
+		if err != nil {
+			results[i].Error = err.Error()
+			logrus.WithError(err).Errorf("[PRIVACY] Erasure failed in source %s", src.Name())
+		}
+	}
+
+	// La verificación va después de todas las fuentes: algunas dependen de datos que borran otras
+	for i, src := range sources {
+		remaining, err := src.Verify(ctx, subject, mode)
+		results[i].Remaining = remaining
+		if errors.Is(err, ErrPartialVerify) {
+			results[i].Unverified = true
+			err = nil
+		}
+		if err != nil && results[i].Error == "" {
+			results[i].Error = "verify: " + err.Error()
+		}
+		if note, ok := src.(interface{ Note() string }); ok {
+			results[i].Note = note.Note()
+		}
+		if results[i].Remaining > 0 || results[i].Error != "" || results[i].Unverified {
+			tombstone.Verified = false
+		}
+	}
+	tombstone.Results = results
+
+	if err := s.tombstones.Create(ctx, tombstone); err != nil {
+		return tombstone, fmt.Errorf("erasure done but failed to store tombstone: %w", err)
+	}
+	logrus.Infof("[PRIVACY] Data subject %s (%s) processed by %s: verified=%v", tombstone.ID, mode, requestedBy, tombstone.Verified)
+	return tombstone, nil
+}
+
+// ListTombstones lista los registros de supresión más recientes
+func (s *PrivacyService) ListTombstones(ctx context.Context, limit int) ([]*domain.Tombstone, error) {
+	return s.tombstones.List(ctx, limit)
+}
+
+// GetTombstone obtiene un registro de supresión
+func (s *PrivacyService) GetTombstone(ctx context.Context, id string) (*domain.Tombstone, error) {
+	return s.tombstones.GetByID(ctx, id)
+}
+
+// FindTombstones busca supresiones de un identificador (JID, ID de plataforma o teléfono)
+// sin necesidad de haber conservado sus datos
+func (s *PrivacyService) FindTombstones(ctx context.Context, platformID string) ([]*domain.Tombstone, error) {
+	probe := &DataSubject{keys: make(map[string]bool)}
+	probe.addKey(platformID)
+	if len(probe.keys) == 0 {
+		return nil, errors.New("platform_id is required")
+	}
+
+	var result []*domain.Tombstone
+	seen := make(map[string]bool)
+	for _, hash := range probe.KeyHashes() {
+		list, err := s.tombstones.ListByIdentityHash(ctx, hash)
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range list {
+			if !seen[t.ID] {
+				seen[t.ID] = true
+				result = append(result, t)
+			}
+		}
+	}
+	return result, nil
+}
+
+type exportTokenData struct {
+	ClientID     string              `json:"client_id,omitempty"`
+	PlatformType domain.PlatformType `json:"platform_type,omitempty"`
+	PlatformID   string              `json:"platform_id,omitempty"`
+}
+
+// CreateExportToken genera un enlace de descarga de un solo sujeto válido 15 minutos
+func (s *PrivacyService) CreateExportToken(ctx context.Context, subject *DataSubject) (string, time.Time, error) {
+	if s.tokens == nil {
+		return "", time.Time{}, errors.New("export token storage unavailable")
+	}
+	data := exportTokenData{ClientID: subject.ClientID}
+	if data.ClientID == "" && len(subject.Identities) > 0 {
+		data.PlatformType = subject.Identities[0].PlatformType
+		data.PlatformID = subject.Identities[0].PlatformID
+	}
+
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", time.Time{}, err
+	}
+	token := hex.EncodeToString(buf)
+	raw, _ := json.Marshal(data)
+	if err := s.tokens.Set(ctx, exportTokenKey(token), string(raw), exportTokenTTL); err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to store export token: %w", err)
+	}
+	return token, time.Now().Add(exportTokenTTL), nil
+}
+
+// RedeemExportToken resuelve el sujeto de un enlace de descarga. El enlace sirve una sola vez:
+// el primer canje lo reserva con un lock y lo borra, así que una segunda petición recibe ErrInvalidExportToken.
+func (s *PrivacyService) RedeemExportToken(ctx context.Context, token string) (*DataSubject, error) {
+	if s.tokens == nil || token == "" {
+		return nil, domain.ErrInvalidExportToken
+	}
+	key := exportTokenKey(token)
+	raw, err := s.tokens.Get(ctx, key)
+	if err != nil || raw == "" {
+		return nil, domain.ErrInvalidExportToken
+	}
+	if claimed, err := s.tokens.Lock(ctx, key+":redeemed", exportTokenTTL); err != nil || !claimed {
+		return nil, domain.ErrInvalidExportToken
+	}
+	if err := s.tokens.Delete(ctx, key); err != nil {
+		logrus.WithError(err).Warn("[PRIVACY] Could not delete a redeemed export token")
+	}
+	var data exportTokenData
+	if err := json.Unmarshal([]byte(raw), &data); err != nil {
+		return nil, domain.ErrInvalidExportToken
+	}
+	if data.ClientID != "" {
+		return s.SubjectForClient(ctx, data.ClientID)
+	}
+	return s.SubjectForIdentity(ctx, data.PlatformType, data.PlatformID)
+}
+
+func exportTokenKey(token string) string {
+	return "data_export_token:" + token
+}
+
+func (s *PrivacyService) allSources() []DataSource {
+	all := make([]DataSource, 0, len(s.sources)+2)
+	all = append(all, s.sources...)
+	all = append(all, &tokenDataSource{kv: s.tokens}, s.core)
+	return all
+}
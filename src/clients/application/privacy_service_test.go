@@ -0,0 +1,213 @@
+package application
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/AzielCF/az-wap/clients/domain"
+	"github.com/AzielCF/az-wap/clients/repository"
+	portalDomain "github.com/AzielCF/az-wap/clients_portal/auth/domain"
+	portalRepo "github.com/AzielCF/az-wap/clients_portal/auth/repository"
+	"github.com/AzielCF/az-wap/core/kvstore"
+	sessionDomain "github.com/AzielCF/az-wap/workspace/domain/session"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeChatStore simula las sesiones vivas del workspace.Manager
+type fakeChatStore struct {
+	sessions  []sessionDomain.ChatData
+	clustered bool
+}
+
+func (f *fakeChatStore) ExportChatData(match func(string) bool) []sessionDomain.ChatData {
+	var res []sessionDomain.ChatData
+	for _, s := range f.sessions {
+		if match(s.ChatID) || match(s.SenderID) {
+			res = append(res, s)
+		}
+	}
+	return res
+}
+
+func (f *fakeChatStore) EraseChatData(match func(string) bool) int {
+	kept := f.sessions[:0]
+	for _, s := range f.sessions {
+		if !match(s.ChatID) && !match(s.SenderID) {
+			kept = append(kept, s)
+		}
+	}
+	erased := len(f.sessions) - len(kept)
+	f.sessions = kept
+	return erased
+}
+
+func (f *fakeChatStore) CountChatData(match func(string) bool) (int, error) {
+	return len(f.ExportChatData(match)), nil
+}
+
+func (f *fakeChatStore) Clustered() bool { return f.clustered }
+
+type privacyFixture struct {
+	privacy  *PrivacyService
+	clients  *ClientService
+	identity *repository.IdentityGormRepository
+	subRepo  *repository.SubscriptionGormRepository
+	portal   *portalRepo.GormAuthRepository
+	kv       kvstore.KVStore
+	chats    *fakeChatStore
+}
+
+func newPrivacyFixture(t *testing.T) privacyFixture {
+	t.Helper()
+	r := newTestRepos(t)
+	portal := portalRepo.NewGormAuthRepository(r.db)
+	require.NoError(t, portal.AutoMigrate())
+
+	kv := kvstore.NewSmartStore(nil)
+	clients := NewClientService(r.clients, r.subs, r.identities)
+	chats := &fakeChatStore{}
+
+	privacy := NewPrivacyService(clients, r.clients, r.identities, r.subs, r.tombstones, kv)
+	privacy.RegisterSource(NewPortalUserSource(portal))
+	privacy.RegisterSource(NewSessionDataSource(chats))
+
+	return privacyFixture{privacy: privacy, clients: clients, identity: r.identities, subRepo: r.subs, portal: portal, kv: kv, chats: chats}
+}
+
+// seedSubject crea un cliente con WhatsApp + Telegram, suscripción, usuario del portal, sesión y código de vinculación
+func (f privacyFixture) seedSubject(t *testing.T) *domain.Client {
+	t.Helper()
+	ctx := context.Background()
+
+	client := &domain.Client{PlatformID: "51999000111", PlatformType: domain.PlatformWhatsApp, Phone: "+51999000111", DisplayName: "Ana", Email: "ana@example.com", Tier: domain.TierPremium}
+	require.NoError(t, f.clients.Create(ctx, client))
+	require.NoError(t, f.identity.Link(ctx, &domain.ClientIdentity{ClientID: client.ID, PlatformID: "777001", PlatformType: domain.PlatformTelegram}))
+	require.NoError(t, f.subRepo.Create(ctx, &domain.ClientSubscription{ClientID: client.ID, ChannelID: "ch1", Status: domain.SubscriptionActive}))
+	require.NoError(t, f.portal.Create(ctx, portalDomain.NewShadowUser(client.ID, "+51999000111", portalDomain.RoleOwner)))
+	require.NoError(t, f.kv.Set(ctx, linkCodeKey("ABCDEFGH"), client.ID, linkCodeTTL))
+	require.NoError(t, f.kv.Set(ctx, linkCodeKey("ZZZZZZZZ"), "someone-else", linkCodeTTL))
+
+	f.chats.sessions = []sessionDomain.ChatData{
+		{ChannelID: "ch1", ChatID: "51999000111@s.whatsapp.net", SenderID: "51999000111@s.whatsapp.net"},
+		{ChannelID: "tg", ChatID: "777001", SenderID: "777001"},
+		{ChannelID: "ch1", ChatID: "51888000222@s.whatsapp.net", SenderID: "51888000222@s.whatsapp.net"},
+	}
+	return client
+}
+
+func TestPrivacyService_ExportAndEraseAcrossSources(t *testing.T) {
+	f := newPrivacyFixture(t)
+	ctx := context.Background()
+	client := f.seedSubject(t)
+
+	// Resolver por cualquier identidad llega al mismo sujeto
+	subject, err := f.privacy.SubjectForIdentity(ctx, domain.PlatformTelegram, "777001")
+	require.NoError(t, err)
+	assert.Equal(t, client.ID, subject.ClientID)
+
+	export, err := f.privacy.Export(ctx, subject)
+	require.NoError(t, err)
+	assert.Empty(t, export.Errors)
+	assert.Contains(t, export.Sources, "client")
+	assert.Contains(t, export.Sources, "portal_users")
+	assert.Contains(t, export.Sources, "tokens")
+	assert.Len(t, export.Sources["sessions"], 2)
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteExportZip(&buf, export))
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+	assert.Equal(t, "data.json", zr.File[0].Name)
+
+	tombstone, err := f.privacy.Erase(ctx, subject, domain.ErasureDelete, "admin")
+	require.NoError(t, err)
+	assert.True(t, tombstone.Verified, "%+v", tombstone.Results)
+	assert.NotContains(t, tombstone.SubjectHash, "51999000111")
+
+	_, err = f.clients.GetByID(ctx, client.ID)
+	assert.ErrorIs(t, err, domain.ErrClientNotFound)
+	users, err := f.portal.ListByClient(ctx, client.ID)
+	require.NoError(t, err)
+	assert.Empty(t, users)
+	code, _ := f.kv.Get(ctx, linkCodeKey("ABCDEFGH"))
+	assert.Empty(t, code)
+	other, _ := f.kv.Get(ctx, linkCodeKey("ZZZZZZZZ"))
+	assert.Equal(t, "someone-else", other, "data of other subjects must survive")
+	require.Len(t, f.chats.sessions, 1)
+	assert.Equal(t, "51888000222@s.whatsapp.net", f.chats.sessions[0].ChatID)
+
+	// El tombstone se encuentra por cualquier identificador sin guardar los datos
+	found, err := f.privacy.FindTombstones(ctx, "+51 999 000 111")
+	require.NoError(t, err)
+	require.Len(t, found, 1)
+	assert.Equal(t, tombstone.ID, found[0].ID)
+}
+
+func TestPrivacyService_ExportTokenWorksOnce(t *testing.T) {
+	f := newPrivacyFixture(t)
+	ctx := context.Background()
+	client := f.seedSubject(t)
+
+	subject, err := f.privacy.SubjectForClient(ctx, client.ID)
+	require.NoError(t, err)
+	token, _, err := f.privacy.CreateExportToken(ctx, subject)
+	require.NoError(t, err)
+
+	redeemed, err := f.privacy.RedeemExportToken(ctx, token)
+	require.NoError(t, err)
+	assert.Equal(t, client.ID, redeemed.ClientID)
+
+	_, err = f.privacy.RedeemExportToken(ctx, token)
+	assert.ErrorIs(t, err, domain.ErrInvalidExportToken)
+}
+
+func TestPrivacyService_AnonymizeKeepsRecordWithoutPII(t *testing.T) {
+	f := newPrivacyFixture(t)
+	ctx := context.Background()
+	client := f.seedSubject(t)
+
+	subject, err := f.privacy.SubjectForClient(ctx, client.ID)
+	require.NoError(t, err)
+	tombstone, err := f.privacy.Erase(ctx, subject, domain.ErasureAnonymize, "portal")
+	require.NoError(t, err)
+	assert.True(t, tombstone.Verified, "%+v", tombstone.Results)
+
+	anon, err := f.clients.GetByID(ctx, client.ID)
+	require.NoError(t, err)
+	assert.Empty(t, anon.Email)
+	assert.Empty(t, anon.Phone)
+	assert.NotEqual(t, client.PlatformID, anon.PlatformID)
+	assert.Equal(t, domain.TierPremium, anon.Tier)
+	assert.False(t, anon.Enabled)
+
+	subs, err := f.subRepo.ListByClient(ctx, client.ID)
+	require.NoError(t, err)
+	assert.Len(t, subs, 1, "anonymized clients keep their subscriptions")
+
+	_, err = f.privacy.Erase(ctx, subject, domain.ErasureMode("shred"), "admin")
+	assert.Error(t, err)
+}
+
+func TestPrivacyService_ClusteredSessionsStayUnverified(t *testing.T) {
+	f := newPrivacyFixture(t)
+	ctx := context.Background()
+	client := f.seedSubject(t)
+	f.chats.clustered = true
+
+	subject, err := f.privacy.SubjectForClient(ctx, client.ID)
+	require.NoError(t, err)
+	tombstone, err := f.privacy.Erase(ctx, subject, domain.ErasureDelete, "admin")
+	require.NoError(t, err)
+	assert.False(t, tombstone.Verified, "other nodes cannot be checked from here")
+
+	for _, r := range tombstone.Results {
+		if r.Source == "sessions" {
+			assert.True(t, r.Unverified)
+			assert.Empty(t, r.Error)
+			assert.Zero(t, r.Remaining)
+		}
+	}
+}
@@ -0,0 +1,663 @@
+package application
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"strings"
+
+	"github.com/AzielCF/az-wap/clients/domain"
+	portalDomain "github.com/AzielCF/az-wap/clients_portal/auth/domain"
+	"github.com/AzielCF/az-wap/core/kvstore"
+	"github.com/AzielCF/az-wap/workspace/domain/channel"
+	"github.com/AzielCF/az-wap/workspace/domain/common"
+	sessionDomain "github.com/AzielCF/az-wap/workspace/domain/session"
+	wsDomain "github.com/AzielCF/az-wap/workspace/domain/workspace"
+)
+
+// --- Cliente, identidades y suscripciones (siempre la última fuente) ---
+
+type clientDataSource struct {
+	clients      *ClientService
+	clientRepo   domain.ClientRepository
+	identityRepo domain.IdentityRepository
+	subRepo      domain.SubscriptionRepository
+}
+
+func (s *clientDataSource) Name() string { return "client" }
+
+func (s *clientDataSource) Export(ctx context.Context, subject *DataSubject) (*SourceExport, error) {
+	if subject.ClientID == "" {
+		return nil, nil
+	}
+	subs, err := s.subRepo.ListByClient(ctx, subject.ClientID)
+	if err != nil {
+		return nil, err
+	}
+	return &SourceExport{
+		Data: map[string]any{
+			"client":        subject.Client,
+			"identities":    subject.Identities,
+			"subscriptions": subs,
+		},
+		Count: 1,
+	}, nil
+}
+
+func (s *clientDataSource) Erase(ctx context.Context, subject *DataSubject, mode domain.ErasureMode) (int, error) {
+	if subject.ClientID == "" {
+		return 0, nil
+	}
+	erased := len(subject.Identities)
+
+	if mode == domain.ErasureDelete {
+		subs, _ := s.subRepo.ListByClient(ctx, subject.ClientID)
+		if err := s.clients.Delete(ctx, subject.ClientID); err != nil {
+			return 0, err
+		}
+		return erased + len(subs) + 1, nil
+	}
+
+	// Anonimizar: el registro (tier, costos, suscripciones) se conserva sin nada que identifique a la persona
+	client, err := s.clientRepo.GetByID(ctx, subject.ClientID)
+	if err != nil {
+		return 0, err
+	}
+	if err := s.identityRepo.DeleteByClientID(ctx, client.ID); err != nil {
+		return 0, err
+	}
+	client.PlatformID = "anon-" + subject.Hash()[:16]
+	client.DisplayName = "Anonymized client"
+	client.Email = ""
+	client.Phone = ""
+	client.Notes = ""
+	client.Timezone = ""
+	client.Metadata = map[string]any{}
+	client.Enabled = false
+	client.LastInteraction = nil
+	if err := s.clientRepo.Update(ctx, client); err != nil {
+		return erased, err
+	}
+	return erased + 1, nil
+}
+
+func (s *clientDataSource) Verify(ctx context.Context, subject *DataSubject, mode domain.ErasureMode) (int, error) {
+	if subject.ClientID == "" {
+		return 0, nil
+	}
+	identities, err := s.identityRepo.ListByClient(ctx, subject.ClientID)
+	if err != nil {
+		return 0, err
+	}
+	remaining := len(identities)
+
+	client, err := s.clientRepo.GetByID(ctx, subject.ClientID)
+	if errors.Is(err, domain.ErrClientNotFound) {
+		return remaining, nil
+	}
+	if err != nil {
+		return remaining, err
+	}
+	if mode == domain.ErasureDelete || client.Email != "" || client.Phone != "" || len(client.Metadata) > 0 || subject.Matches(client.PlatformID) {
+		remaining++
+	}
+	return remaining, nil
+}
+
+// --- Tokens en el almacén kv (códigos de vinculación, magic links, enlaces de exportación) ---
+
+type tokenDataSource struct {
+	kv kvstore.KVStore
+}
+
+func (s *tokenDataSource) Name() string { return "tokens" }
+
+func (s *tokenDataSource) Note() string {
+	return "context caches are keyed by content fingerprint and expire on their own TTL"
+}
+
+// keys devuelve las claves kv que referencian al sujeto. Los valores son credenciales: nunca se exportan.
+func (s *tokenDataSource) keys(ctx context.Context, subject *DataSubject) ([]string, error) {
+	if s.kv == nil {
+		return nil, nil
+	}
+	var found []string
+	scan := func(pattern string, matches func(value string) bool) error {
+		keys, err := s.kv.Keys(ctx, pattern)
+		if err != nil {
+			return err
+		}
+		for _, key := range keys {
+			if value, err := s.kv.Get(ctx, key); err == nil && value != "" && matches(value) {
+				found = append(found, key)
+			}
+		}
+		return nil
+	}
+
+	var errs []error
+	if subject.ClientID != "" {
+		errs = append(errs, scan(linkCodeKey("*"), func(v string) bool { return v == subject.ClientID }))
+		errs = append(errs, scan("magic_client:"+subject.ClientID, func(string) bool { return true }))
+		errs = append(errs, scan("magic_token:*", func(v string) bool {
+			var data map[string]string
+			return json.Unmarshal([]byte(v), &data) == nil && data["cid"] == subject.ClientID
+		}))
+	}
+	errs = append(errs, scan(linkTokenKey("*"), func(v string) bool {
+		var lt linkToken
+		if json.Unmarshal([]byte(v), &lt) != nil {
+			return false
+		}
+		for _, id := range lt.PlatformIDs {
+			if subject.Matches(id) {
+				return true
+			}
+		}
+		return false
+	}))
+	errs = append(errs, scan(linkConfirmKey("*"), func(v string) bool {
+		var lc linkConfirmation
+		if json.Unmarshal([]byte(v), &lc) != nil {
+			return false
+		}
+		if subject.ClientID != "" && lc.ClientID == subject.ClientID {
+			return true
+		}
+		for _, id := range lc.PlatformIDs {
+			if subject.Matches(id) {
+				return true
+			}
+		}
+		return false
+	}))
+	errs = append(errs, scan(exportTokenKey("*"), func(v string) bool {
+		var data exportTokenData
+		if json.Unmarshal([]byte(v), &data) != nil {
+			return false
+		}
+		return (subject.ClientID != "" && data.ClientID == subject.ClientID) || (data.PlatformID != "" && subject.Matches(data.PlatformID))
+	}))
+	return found, errors.Join(errs...)
+}
+
+func (s *tokenDataSource) Export(ctx context.Context, subject *DataSubject) (*SourceExport, error) {
+	keys, err := s.keys(ctx, subject)
+	if err != nil || len(keys) == 0 {
+		return nil, err
+	}
+	kinds := make([]string, 0, len(keys))
+	for _, key := range keys {
+		kind, _, _ := strings.Cut(key, ":")
+		kinds = append(kinds, kind)
+	}
+	return &SourceExport{Data: map[string]any{"active_tokens": kinds}, Count: len(keys)}, nil
+}
+
+func (s *tokenDataSource) Erase(ctx context.Context, subject *DataSubject, _ domain.ErasureMode) (int, error) {
+	keys, err := s.keys(ctx, subject)
+	erased := 0
+	for _, key := range keys {
+		if s.kv.Delete(ctx, key) == nil {
+			erased++
+		}
+	}
+	return erased, err
+}
+
+func (s *tokenDataSource) Verify(ctx context.Context, subject *DataSubject, _ domain.ErasureMode) (int, error) {
+	keys, err := s.keys(ctx, subject)
+	return len(keys), err
+}
+
+// --- Usuarios del portal ---
+
+// PortalUserSource cubre las cuentas del portal del cliente (y las shadow creadas por teléfono)
+type PortalUserSource struct {
+	repo portalDomain.IAuthRepository
+}
+
+// NewPortalUserSource crea la fuente de usuarios del portal
+func NewPortalUserSource(repo portalDomain.IAuthRepository) *PortalUserSource {
+	return &PortalUserSource{repo: repo}
+}
+
+func (s *PortalUserSource) Name() string { return "portal_users" }
+
+func (s *PortalUserSource) users(ctx context.Context, subject *DataSubject) ([]*portalDomain.PortalUser, error) {
+	var users []*portalDomain.PortalUser
+	seen := make(map[string]bool)
+	add := func(u *portalDomain.PortalUser) {
+		if u != nil && !seen[u.ID] {
+			seen[u.ID] = true
+			users = append(users, u)
+		}
+	}
+
+	if subject.ClientID != "" {
+		list, err := s.repo.ListByClient(ctx, subject.ClientID)
+		if err != nil {
+			return nil, err
+		}
+		for _, u := range list {
+			add(u)
+		}
+	}
+	for _, phone := range subject.Phones() {
+		for _, candidate := range []string{phone, "+" + phone} {
+			if u, err := s.repo.GetByPhone(ctx, candidate); err == nil {
+				add(u)
+			}
+		}
+	}
+	return users, nil
+}
+
+func (s *PortalUserSource) Export(ctx context.Context, subject *DataSubject) (*SourceExport, error) {
+	users, err := s.users(ctx, subject)
+	if err != nil || len(users) == 0 {
+		return nil, err
+	}
+	views := make([]portalDomain.SafePortalUserView, 0, len(users))
+	for _, u := range users {
+		views = append(views, portalDomain.SafePortalUserView{
+			ID: u.ID, Email: u.Email, Phone: u.Phone, Username: u.Username, FullName: u.FullName,
+			Role: u.Role, Active: u.Active, IsShadow: u.IsShadow, LastLoginAt: u.LastLoginAt, CreatedAt: u.CreatedAt,
+		})
+	}
+	return &SourceExport{Data: views, Count: len(views)}, nil
+}
+
+func (s *PortalUserSource) Erase(ctx context.Context, subject *DataSubject, mode domain.ErasureMode) (int, error) {
+	users, err := s.users(ctx, subject)
+	if err != nil {
+		return 0, err
+	}
+	erased := 0
+	var errs []error
+	for _, u := range users {
+		if mode == domain.ErasureDelete {
+			err = s.repo.Delete(ctx, u.ID)
+		} else {
+			u.Email = nil
+			u.Phone = ""
+			u.FullName = ""
+			u.Username = "erased_" + hashKey(u.ID)[:8]
+			u.PasswordHash = ""
+			u.Active = false
+			err = s.repo.Update(ctx, u)
+		}
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		erased++
+	}
+	return erased, errors.Join(errs...)
+}
+
+func (s *PortalUserSource) Verify(ctx context.Context, subject *DataSubject, mode domain.ErasureMode) (int, error) {
+	users, err := s.users(ctx, subject)
+	if err != nil {
+		return 0, err
+	}
+	remaining := 0
+	for _, u := range users {
+		if mode == domain.ErasureDelete || u.Email != nil || u.Phone != "" || u.FullName != "" || u.Active {
+			remaining++
+		}
+	}
+	return remaining, nil
+}
+
+// --- Workspaces: reglas de acceso, publicaciones programadas y workspaces del cliente ---
+
+// WorkspaceDataSource cubre lo que los workspaces guardan del sujeto
+type WorkspaceDataSource struct {
+	repo wsDomain.IWorkspaceRepository
+}
+
+// NewWorkspaceDataSource crea la fuente de datos de workspaces y canales
+func NewWorkspaceDataSource(repo wsDomain.IWorkspaceRepository) *WorkspaceDataSource {
+	return &WorkspaceDataSource{repo: repo}
+}
+
+func (s *WorkspaceDataSource) Name() string { return "workspaces" }
+
+func (s *WorkspaceDataSource) Note() string {
+	return "guest entries in workspaces owned by other clients are left to their owners"
+}
+
+type workspaceSubjectData struct {
+	AccessRules      []common.AccessRule             `json:"access_rules,omitempty"`
+	ScheduledPosts   []common.ScheduledPost          `json:"scheduled_posts,omitempty"`
+	ClientWorkspaces []wsDomain.ClientWorkspace      `json:"client_workspaces,omitempty"`
+	Guests           []wsDomain.ClientWorkspaceGuest `json:"guests,omitempty"`
+}
+
+func (d *workspaceSubjectData) count() int {
+	return len(d.AccessRules) + len(d.ScheduledPosts) + len(d.ClientWorkspaces) + len(d.Guests)
+}
+
+func (s *WorkspaceDataSource) collect(ctx context.Context, subject *DataSubject) (*workspaceSubjectData, error) {
+	data := &workspaceSubjectData{}
+	channels, err := allChannels(ctx, s.repo)
+	if err != nil {
+		return nil, err
+	}
+	for _, ch := range channels {
+		rules, err := s.repo.GetAccessRules(ctx, ch.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range rules {
+			if subject.Matches(r.Identity) {
+				data.AccessRules = append(data.AccessRules, r)
+			}
+		}
+		posts, err := s.repo.ListScheduledPosts(ctx, ch.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range posts {
+			if subject.Matches(p.SenderID) || subject.Matches(p.TargetID) {
+				data.ScheduledPosts = append(data.ScheduledPosts, p)
+			}
+		}
+	}
+
+	if subject.ClientID != "" {
+		if data.ClientWorkspaces, err = s.repo.ListClientWorkspaces(ctx, subject.ClientID); err != nil {
+			return nil, err
+		}
+		if data.Guests, err = s.repo.ListGuestsByOwnerID(ctx, subject.ClientID); err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
+}
+
+func (s *WorkspaceDataSource) Export(ctx context.Context, subject *DataSubject) (*SourceExport, error) {
+	data, err := s.collect(ctx, subject)
+	if err != nil || data.count() == 0 {
+		return nil, err
+	}
+	var files []string
+	for _, p := range data.ScheduledPosts {
+		if p.MediaPath != "" {
+			files = append(files, p.MediaPath)
+		}
+	}
+	return &SourceExport{Data: data, Count: data.count(), Files: files}, nil
+}
+
+// Erase borra reglas y publicaciones en ambos modos; los workspaces propios y sus invitados
+// solo se borran en modo erase (al anonimizar siguen siendo del cliente anónimo)
+func (s *WorkspaceDataSource) Erase(ctx context.Context, subject *DataSubject, mode domain.ErasureMode) (int, error) {
+	data, err := s.collect(ctx, subject)
+	if err != nil {
+		return 0, err
+	}
+	erased := 0
+	var errs []error
+	track := func(err error) {
+		if err != nil {
+			errs = append(errs, err)
+			return
+		}
+		erased++
+	}
+
+	for _, r := range data.AccessRules {
+		track(s.repo.DeleteAccessRule(ctx, r.ID))
+	}
+	for _, p := range data.ScheduledPosts {
+		if p.MediaPath != "" {
+			_ = os.Remove(p.MediaPath)
+		}
+		track(s.repo.DeleteScheduledPost(ctx, p.ID))
+	}
+	if mode == domain.ErasureDelete {
+		for _, g := range data.Guests {
+			track(s.repo.DeleteGuest(ctx, g.ID))
+		}
+		for _, cw := range data.ClientWorkspaces {
+			track(s.repo.DeleteClientWorkspace(ctx, cw.ID))
+		}
+	}
+	return erased, errors.Join(errs...)
+}
+
+func (s *WorkspaceDataSource) Verify(ctx context.Context, subject *DataSubject, mode domain.ErasureMode) (int, error) {
+	data, err := s.collect(ctx, subject)
+	if err != nil {
+		return 0, err
+	}
+	remaining := len(data.AccessRules) + len(data.ScheduledPosts)
+	if mode == domain.ErasureDelete {
+		remaining += len(data.ClientWorkspaces) + len(data.Guests)
+	}
+	return remaining, nil
+}
+
+// --- Sesiones de chat vivas y archivos descargados ---
+
+// ChatDataStore expone las sesiones vivas de chat (implementado por workspace.Manager)
+type ChatDataStore interface {
+	ExportChatData(match func(id string) bool) []sessionDomain.ChatData
+	EraseChatData(match func(id string) bool) int
+	CountChatData(match func(id string) bool) (int, error)
+	// Clustered indica si otros nodos atienden chats: sus archivos de sesión no se ven desde aquí
+	Clustered() bool
+}
+
+// SessionDataSource cubre el historial en memoria de las sesiones y sus archivos descargados
+type SessionDataSource struct {
+	store ChatDataStore
+}
+
+// NewSessionDataSource crea la fuente de sesiones de chat
+func NewSessionDataSource(store ChatDataStore) *SessionDataSource {
+	return &SessionDataSource{store: store}
+}
+
+func (s *SessionDataSource) Name() string { return "sessions" }
+
+func (s *SessionDataSource) Note() string {
+	if s.store.Clustered() {
+		return "session files are checked on this node only; other nodes remove theirs when the session ends"
+	}
+	return ""
+}
+
+func (s *SessionDataSource) Export(_ context.Context, subject *DataSubject) (*SourceExport, error) {
+	sessions := s.store.ExportChatData(subject.Matches)
+	if len(sessions) == 0 {
+		return nil, nil
+	}
+	var files []string
+	for _, sess := range sessions {
+		files = append(files, sess.Files...)
+	}
+	return &SourceExport{Data: sessions, Count: len(sessions), Files: files}, nil
+}
+
+func (s *SessionDataSource) Erase(_ context.Context, subject *DataSubject, _ domain.ErasureMode) (int, error) {
+	return s.store.EraseChatData(subject.Matches), nil
+}
+
+// Verify cuenta las sesiones en el almacén (compartido en cluster), pero los archivos de sesión
+// viven en el disco del nodo que atendió el chat: en cluster el resultado queda sin verificar
+func (s *SessionDataSource) Verify(_ context.Context, subject *DataSubject, _ domain.ErasureMode) (int, error) {
+	remaining, err := s.store.CountChatData(subject.Matches)
+	if err != nil {
+		return remaining, err
+	}
+	if s.store.Clustered() {
+		return remaining, ErrPartialVerify
+	}
+	return remaining, nil
+}
+
+// --- Chatwoot ---
+
+// ContactDirectory localiza los contactos del sujeto en el CRM de un canal (implementado por chatwoot.ContactDirectory)
+type ContactDirectory interface {
+	ExportContact(ctx context.Context, externalRef, phone string) (any, error)
+	DeleteContact(ctx context.Context, externalRef, phone string) (bool, error)
+	HasContact(ctx context.Context, externalRef, phone string) (bool, error)
+}
+
+// ChatwootDataSource cubre los contactos y conversaciones replicados en Chatwoot
+type ChatwootDataSource struct {
+	repo     wsDomain.IWorkspaceRepository
+	contacts ContactDirectory
+}
+
+// NewChatwootDataSource crea la fuente de Chatwoot (solo canales con la integración activa)
+func NewChatwootDataSource(repo wsDomain.IWorkspaceRepository, contacts ContactDirectory) *ChatwootDataSource {
+	return &ChatwootDataSource{repo: repo, contacts: contacts}
+}
+
+func (s *ChatwootDataSource) Name() string { return "chatwoot" }
+
+// instances devuelve las instancias con Chatwoot activo
+func (s *ChatwootDataSource) instances(ctx context.Context) ([]string, error) {
+	channels, err := allChannels(ctx, s.repo)
+	if err != nil {
+		return nil, err
+	}
+	var refs []string
+	for _, ch := range channels {
+		if ch.ExternalRef != "" && ch.Config.Chatwoot != nil && ch.Config.Chatwoot.Enabled {
+			refs = append(refs, ch.ExternalRef)
+		}
+	}
+	return refs, nil
+}
+
+func (s *ChatwootDataSource) Export(ctx context.Context, subject *DataSubject) (*SourceExport, error) {
+	refs, err := s.instances(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var contacts []any
+	var errs []error
+	for _, ref := range refs {
+		for _, phone := range subject.Phones() {
+			c, err := s.contacts.ExportContact(ctx, ref, phone)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			if c != nil {
+				contacts = append(contacts, c)
+			}
+		}
+	}
+	if len(contacts) == 0 {
+		return nil, errors.Join(errs...)
+	}
+	return &SourceExport{Data: contacts, Count: len(contacts)}, errors.Join(errs...)
+}
+
+func (s *ChatwootDataSource) Erase(ctx context.Context, subject *DataSubject, _ domain.ErasureMode) (int, error) {
+	refs, err := s.instances(ctx)
+	if err != nil {
+		return 0, err
+	}
+	erased := 0
+	var errs []error
+	for _, ref := range refs {
+		for _, phone := range subject.Phones() {
+			deleted, err := s.contacts.DeleteContact(ctx, ref, phone)
+			if err != nil {
+				errs = append(errs, err)
+			} else if deleted {
+				erased++
+			}
+		}
+	}
+	return erased, errors.Join(errs...)
+}
+
+func (s *ChatwootDataSource) Verify(ctx context.Context, subject *DataSubject, _ domain.ErasureMode) (int, error) {
+	refs, err := s.instances(ctx)
+	if err != nil {
+		return 0, err
+	}
+	remaining := 0
+	var errs []error
+	for _, ref := range refs {
+		for _, phone := range subject.Phones() {
+			exists, err := s.contacts.HasContact(ctx, ref, phone)
+			if err != nil {
+				errs = append(errs, err)
+			} else if exists {
+				remaining++
+			}
+		}
+	}
+	return remaining, errors.Join(errs...)
+}
+
+// --- Monitor de bots (buffer de eventos recientes) ---
+
+// ChatEventStore expone el buffer de eventos recientes del monitor de bots (implementado por monitoring.ChatEvents)
+type ChatEventStore interface {
+	ExportChatEvents(chatKeys []string) (any, int)
+	PurgeChatEvents(chatKeys []string) int
+	CountChatEvents(chatKeys []string) int
+	// Clustered indica si otros nodos tienen su propio buffer (la purga se les pide, pero no se comprueba)
+	Clustered() bool
+}
+
+// MonitorDataSource cubre los eventos recientes del monitor de bots (en todo el cluster)
+type MonitorDataSource struct {
+	events ChatEventStore
+}
+
+// NewMonitorDataSource crea la fuente del monitor de bots
+func NewMonitorDataSource(events ChatEventStore) *MonitorDataSource {
+	return &MonitorDataSource{events: events}
+}
+
+func (s *MonitorDataSource) Name() string { return "bot_monitor" }
+
+func (s *MonitorDataSource) Export(_ context.Context, subject *DataSubject) (*SourceExport, error) {
+	events, count := s.events.ExportChatEvents(subject.Keys())
+	if count == 0 {
+		return nil, nil
+	}
+	return &SourceExport{Data: events, Count: count}, nil
+}
+
+func (s *MonitorDataSource) Erase(_ context.Context, subject *DataSubject, _ domain.ErasureMode) (int, error) {
+	return s.events.PurgeChatEvents(subject.Keys()), nil
+}
+
+func (s *MonitorDataSource) Verify(_ context.Context, subject *DataSubject, _ domain.ErasureMode) (int, error) {
+	remaining := s.events.CountChatEvents(subject.Keys())
+	if s.events.Clustered() {
+		return remaining, ErrPartialVerify
+	}
+	return remaining, nil
+}
+
+// allChannels lista los canales de todos los workspaces
+func allChannels(ctx context.Context, repo wsDomain.IWorkspaceRepository) ([]channel.Channel, error) {
+	workspaces, err := repo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var channels []channel.Channel
+	for _, ws := range workspaces {
+		list, err := repo.ListChannels(ctx, ws.ID)
+		if err != nil {
+			return nil, err
+		}
+		channels = append(channels, list...)
+	}
+	return channels, nil
+}
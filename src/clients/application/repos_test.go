@@ -0,0 +1,47 @@
+package application
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/AzielCF/az-wap/clients/repository"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// testRepos son los repositorios gorm de clientes sobre una base SQLite temporal
+type testRepos struct {
+	db         *gorm.DB
+	clients    *repository.ClientGormRepository
+	identities *repository.IdentityGormRepository
+	subs       *repository.SubscriptionGormRepository
+	fields     *repository.FieldGormRepository
+	tombstones *repository.TombstoneGormRepository
+}
+
+func newTestRepos(t *testing.T) testRepos {
+	t.Helper()
+	ctx := context.Background()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "app.db")), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+
+	r := testRepos{
+		db:         db,
+		clients:    repository.NewClientGormRepository(db),
+		identities: repository.NewIdentityGormRepository(db),
+		subs:       repository.NewSubscriptionGormRepository(db),
+		fields:     repository.NewFieldGormRepository(db),
+		tombstones: repository.NewTombstoneGormRepository(db),
+	}
+	require.NoError(t, r.clients.InitSchema(ctx))
+	require.NoError(t, r.identities.InitSchema(ctx))
+	require.NoError(t, r.subs.InitSchema(ctx))
+	require.NoError(t, r.fields.InitSchema(ctx))
+	require.NoError(t, r.tombstones.InitSchema(ctx))
+	return r
+}
@@ -19,17 +19,65 @@ type ChannelResolver interface {
 
 // ClientResolver resuelve el contexto de un cliente para un mensaje entrante
 type ClientResolver struct {
-	clientRepo  domain.ClientRepository
-	subRepo     domain.SubscriptionRepository
-	channelRepo ChannelResolver
+	clientRepo   domain.ClientRepository
+	subRepo      domain.SubscriptionRepository
+	identityRepo domain.IdentityRepository
+	channelRepo  ChannelResolver
 }
 
 // NewClientResolver crea una nueva instancia del resolver
-func NewClientResolver(clientRepo domain.ClientRepository, subRepo domain.SubscriptionRepository, channelRepo ChannelResolver) *ClientResolver {
+func NewClientResolver(clientRepo domain.ClientRepository, subRepo domain.SubscriptionRepository, identityRepo domain.IdentityRepository, channelRepo ChannelResolver) *ClientResolver {
 	return &ClientResolver{
-		clientRepo:  clientRepo,
-		subRepo:     subRepo,
-		channelRepo: channelRepo,
+		clientRepo:   clientRepo,
+		subRepo:      subRepo,
+		identityRepo: identityRepo,
+		channelRepo:  channelRepo,
+	}
+}
+
+// findByIdentity busca el cliente dueño de cualquiera de las identidades dadas (WhatsApp JID/LID, Telegram...)
+func (r *ClientResolver) findByIdentity(ctx context.Context, platformType domain.PlatformType, ids ...string) (*domain.Client, error) {
+	for _, id := range ids {
+		if id == "" {
+			continue
+		}
+		identity, err := r.identityRepo.GetByPlatform(ctx, id, platformType)
+		if errors.Is(err, domain.ErrIdentityNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if identity.Source == domain.IdentityAuto && !identity.Verified {
+			continue // Vinculada por coincidencia de teléfono: no identifica por sí sola al cliente
+		}
+		client, err := r.clientRepo.GetByID(ctx, identity.ClientID)
+		if errors.Is(err, domain.ErrClientNotFound) {
+			continue
+		}
+		return client, err
+	}
+	return nil, nil
+}
+
+// linkSeen registra como identidades del cliente los IDs con los que lo encontró un fallback por
+// ID de plataforma, así los siguientes mensajes lo encuentran con findByIdentity sin más consultas.
+// Las coincidencias por teléfono no se registran: un número reasignado no debe heredar el cliente.
+func (r *ClientResolver) linkSeen(ctx context.Context, client *domain.Client, platformType domain.PlatformType, ids ...string) {
+	for _, id := range ids {
+		if id == "" {
+			continue
+		}
+		err := r.identityRepo.Link(ctx, &domain.ClientIdentity{
+			ClientID:     client.ID,
+			PlatformID:   id,
+			PlatformType: platformType,
+			Source:       domain.IdentityAuto,
+			Verified:     true,
+		})
+		if err != nil && !errors.Is(err, domain.ErrIdentityLinked) {
+			logrus.WithError(err).Warnf("[ClientResolver] Failed to record identity %s for client %s", id, client.ID)
+		}
 	}
 }
 
@@ -44,11 +92,21 @@ func (r *ClientResolver) Resolve(ctx context.Context, platformID, secondaryID, p
 	// Bot default del canal
 	resolvedBotID := channel.Config.BotID
 
-	// 2. Buscar cliente global
-	client, err := r.clientRepo.GetByPlatform(ctx, platformID, domain.PlatformType(platformType))
-	if err != nil && !errors.Is(err, domain.ErrClientNotFound) {
+	// 2. Buscar cliente global por sus identidades vinculadas
+	client, err := r.findByIdentity(ctx, domain.PlatformType(platformType), platformID, secondaryID)
+	if err != nil {
 		return nil, "", err
 	}
+	foundByIdentity := client != nil
+	foundByPhone := false
+
+	// Clientes cuya identidad aún no está en la tabla (PlatformID legacy)
+	if client == nil {
+		client, err = r.clientRepo.GetByPlatform(ctx, platformID, domain.PlatformType(platformType))
+		if err != nil && !errors.Is(err, domain.ErrClientNotFound) {
+			return nil, "", err
+		}
+	}
 
 	// Fallback 1: Intentar con secondaryID si existe
 	if client == nil && secondaryID != "" && secondaryID != platformID {
@@ -70,6 +128,7 @@ func (r *ClientResolver) Resolve(ctx context.Context, platformID, secondaryID, p
 		if err != nil && !errors.Is(err, domain.ErrClientNotFound) {
 			return nil, "", err
 		}
+		foundByPhone = client != nil
 	}
 
 	clientCtx := &domain.ClientContext{
@@ -81,12 +140,18 @@ func (r *ClientResolver) Resolve(ctx context.Context, platformID, secondaryID, p
 
 		// AUTOMATIC MIGRATION: If we found via phone/JID fallback but msg has a WhatsApp LID,
 		// update the client's platform_id to the LID to make it the primary identifier.
-		if platformType == "whatsapp" && strings.HasSuffix(platformID, "@lid") && client.PlatformID != platformID {
+		// Not for clients found by a linked identity (the LID may be a secondary account) nor for
+		// clients whose primary platform is another one (a Telegram client keeps its Telegram ID).
+		if !foundByIdentity && client.PlatformType == domain.PlatformType(platformType) &&
+			platformType == "whatsapp" && strings.HasSuffix(platformID, "@lid") && client.PlatformID != platformID {
 			logrus.Infof("[ClientResolver] Migrating client %s (%s) from legacy ID %s to new LID %s",
 				client.DisplayName, client.ID, client.PlatformID, platformID)
 			client.PlatformID = platformID
 			_ = r.clientRepo.Update(ctx, client)
 		}
+		if !foundByIdentity && !foundByPhone {
+			r.linkSeen(ctx, client, domain.PlatformType(platformType), platformID, secondaryID)
+		}
 
 		clientCtx.Client = client
 		clientCtx.IsRegistered = true
@@ -110,6 +175,14 @@ func (r *ClientResolver) Resolve(ctx context.Context, platformID, secondaryID, p
 				logrus.Infof("[ClientResolver] Applying custom bot ID: %s (Template: %s)", resolvedBotID, sub.CustomBotTemplateID)
 			}
 
+			// Periodo de gracia: el canal puede degradar el bot a una variante reducida
+			if sub.InGrace() {
+				if variant := channel.Config.SubscriptionLifecycle.GraceTemplateID(); variant != "" {
+					clientCtx.ResolvedBotTemplateID = variant
+					logrus.Infof("[ClientResolver] Subscription %s in grace period, using bot variant %s", sub.ID, variant)
+				}
+			}
+
 			// Override de system prompt si existe
 			if sub.CustomSystemPrompt != "" {
 				clientCtx.AdditionalPrompt = sub.CustomSystemPrompt
@@ -135,8 +208,11 @@ func (r *ClientResolver) Resolve(ctx context.Context, platformID, secondaryID, p
 // GetSubscription busca una suscripción activa usando el PlatformID (teléfono o ID de plataforma)
 // Esto es utilizado por el SessionOrchestrator para aplicar configuraciones específicas de suscripción.
 func (r *ClientResolver) GetSubscription(ctx context.Context, platformID string, channelID string) (*domain.ClientSubscription, error) {
-	// 1. Intentar encontrar al cliente por su Platform ID (asumiendo WhatsApp por defecto para este contexto)
-	client, err := r.clientRepo.GetByPlatform(ctx, platformID, domain.PlatformWhatsApp)
+	// 1. Intentar encontrar al cliente por sus identidades (asumiendo WhatsApp por defecto para este contexto)
+	client, err := r.findByIdentity(ctx, domain.PlatformWhatsApp, platformID)
+	if err == nil && client == nil {
+		client, err = r.clientRepo.GetByPlatform(ctx, platformID, domain.PlatformWhatsApp)
+	}
 
 	// 2. Si no se encuentra, intentar buscar por número de teléfono directo
 	if err != nil || client == nil {
@@ -153,6 +229,10 @@ func (r *ClientResolver) GetSubscription(ctx context.Context, platformID string,
 
 // ResolveQuick hace una resolución rápida sin buscar suscripción (para verificaciones básicas)
 func (r *ClientResolver) ResolveQuick(ctx context.Context, platformID string, platformType domain.PlatformType) (*domain.Client, error) {
+	client, err := r.findByIdentity(ctx, platformType, platformID)
+	if err != nil || client != nil {
+		return client, err
+	}
 	return r.clientRepo.GetByPlatform(ctx, platformID, platformType)
 }
 
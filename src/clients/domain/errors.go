@@ -20,4 +20,46 @@ var (
 
 	// ErrSubscriptionExpired se retorna cuando la suscripción ha expirado
 	ErrSubscriptionExpired = errors.New("subscription has expired")
+
+	// ErrSubscriptionRevoked se retorna al intentar renovar una suscripción revocada
+	ErrSubscriptionRevoked = errors.New("subscription is revoked")
+
+	// ErrInvalidRenewal se retorna cuando la renovación no indica días ni fecha válidos
+	ErrInvalidRenewal = errors.New("renewal needs positive days or a future date")
+
+	// ErrRenewalShortens se retorna cuando until acortaría la suscripción sin confirmarlo (shorten)
+	ErrRenewalShortens = errors.New("until is before the current expiry; set shorten to confirm")
+
+	// ErrRenewalApplied se retorna cuando la referencia de pago ya se aplicó (reintento del proveedor)
+	ErrRenewalApplied = errors.New("renewal already applied for this payment reference")
+
+	// ErrRenewalConflict se retorna cuando otra renovación cambió el vencimiento mientras se calculaba
+	ErrRenewalConflict = errors.New("subscription expiry changed concurrently")
+
+	// ErrIdentityNotFound se retorna cuando no se encuentra una identidad de plataforma
+	ErrIdentityNotFound = errors.New("client identity not found")
+
+	// ErrIdentityLinked se retorna cuando la identidad ya pertenece a otro cliente (usar merge)
+	ErrIdentityLinked = errors.New("identity is already linked to another client")
+
+	// ErrPrimaryIdentity se retorna al intentar desvincular o separar la identidad principal del cliente
+	ErrPrimaryIdentity = errors.New("the primary identity of a client cannot be unlinked")
+
+	// ErrInvalidLinkCode se retorna cuando el código o enlace de vinculación no existe o expiró
+	ErrInvalidLinkCode = errors.New("invalid or expired link code")
+
+	// ErrFieldNotFound se retorna cuando no existe la definición del campo personalizado
+	ErrFieldNotFound = errors.New("custom field not found")
+
+	// ErrInvalidField se retorna cuando la definición de un campo personalizado no es válida
+	ErrInvalidField = errors.New("invalid custom field")
+
+	// ErrInvalidFieldValue se retorna cuando un valor no cumple la definición de su campo
+	ErrInvalidFieldValue = errors.New("invalid field value")
+
+	// ErrTombstoneNotFound se retorna cuando no existe el registro de supresión
+	ErrTombstoneNotFound = errors.New("erasure record not found")
+
+	// ErrInvalidExportToken se retorna cuando el enlace de descarga de datos no existe o expiró
+	ErrInvalidExportToken = errors.New("invalid or expired data export token")
 )
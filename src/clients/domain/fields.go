@@ -0,0 +1,164 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"net/mail"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FieldType es el tipo de dato de un campo personalizado de cliente
+type FieldType string
+
+const (
+	FieldText    FieldType = "text"
+	FieldNumber  FieldType = "number"
+	FieldBoolean FieldType = "boolean"
+	FieldDate    FieldType = "date" // YYYY-MM-DD
+	FieldEmail   FieldType = "email"
+	FieldPhone   FieldType = "phone"
+	FieldSelect  FieldType = "select"
+)
+
+var fieldKeyPattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,39}$`)
+
+// CustomField define un campo de Client.Metadata para un workspace: tipo, validación,
+// si contiene datos personales (PII) y si la IA puede leerlo o escribirlo
+type CustomField struct {
+	ID          string    `json:"id"`
+	WorkspaceID string    `json:"workspace_id"`
+	Key         string    `json:"key"`
+	Label       string    `json:"label"`
+	Description string    `json:"description,omitempty"` // Se muestra a la IA para saber qué guardar
+	Type        FieldType `json:"type"`
+	Options     []string  `json:"options,omitempty"`    // Valores permitidos (select)
+	Pattern     string    `json:"pattern,omitempty"`    // Regex opcional (text)
+	MaxLength   int       `json:"max_length,omitempty"` // 0 = sin límite (text)
+	PII         bool      `json:"pii"`
+	AIRead      bool      `json:"ai_read"`
+	AIWrite     bool      `json:"ai_write"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// Validate comprueba que la definición del campo sea coherente
+func (f *CustomField) Validate() error {
+	if !fieldKeyPattern.MatchString(f.Key) {
+		return fmt.Errorf("%w: key must be lowercase snake_case (max 40 chars)", ErrInvalidField)
+	}
+	switch f.Type {
+	case FieldText, FieldNumber, FieldBoolean, FieldDate, FieldEmail, FieldPhone:
+	case FieldSelect:
+		if len(f.Options) == 0 {
+			return fmt.Errorf("%w: select fields need at least one option", ErrInvalidField)
+		}
+	default:
+		return fmt.Errorf("%w: unknown type %q", ErrInvalidField, f.Type)
+	}
+	if f.Pattern != "" {
+		if _, err := regexp.Compile(f.Pattern); err != nil {
+			return fmt.Errorf("%w: invalid pattern: %v", ErrInvalidField, err)
+		}
+	}
+	if f.MaxLength < 0 {
+		return fmt.Errorf("%w: max_length cannot be negative", ErrInvalidField)
+	}
+	return nil
+}
+
+// Normalize valida un valor contra la definición y lo devuelve en su forma canónica
+func (f *CustomField) Normalize(value any) (any, error) {
+	invalid := func(reason string) error {
+		return fmt.Errorf("%w: %s %s", ErrInvalidFieldValue, f.Key, reason)
+	}
+
+	switch f.Type {
+	case FieldNumber:
+		switch v := value.(type) {
+		case float64:
+			return v, nil
+		case int:
+			return float64(v), nil
+		case string:
+			n, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+			if err != nil {
+				return nil, invalid("must be a number")
+			}
+			return n, nil
+		}
+		return nil, invalid("must be a number")
+
+	case FieldBoolean:
+		switch v := value.(type) {
+		case bool:
+			return v, nil
+		case string:
+			switch strings.ToLower(strings.TrimSpace(v)) {
+			case "true", "yes", "si", "sí", "1":
+				return true, nil
+			case "false", "no", "0":
+				return false, nil
+			}
+		}
+		return nil, invalid("must be true or false")
+	}
+
+	s, ok := value.(string)
+	if !ok {
+		s = fmt.Sprint(value)
+	}
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, invalid("cannot be empty")
+	}
+
+	switch f.Type {
+	case FieldDate:
+		if _, err := time.Parse("2006-01-02", s); err != nil {
+			return nil, invalid("must be a date (YYYY-MM-DD)")
+		}
+	case FieldEmail:
+		addr, err := mail.ParseAddress(s)
+		if err != nil {
+			return nil, invalid("must be a valid email")
+		}
+		s = strings.ToLower(addr.Address)
+	case FieldPhone:
+		digits := strings.Map(func(r rune) rune {
+			if r >= '0' && r <= '9' {
+				return r
+			}
+			return -1
+		}, s)
+		if len(digits) < 6 || len(digits) > 15 {
+			return nil, invalid("must be a phone number")
+		}
+		s = digits
+	case FieldSelect:
+		for _, opt := range f.Options {
+			if strings.EqualFold(opt, s) {
+				return opt, nil
+			}
+		}
+		return nil, invalid("must be one of: " + strings.Join(f.Options, ", "))
+	default:
+		if f.MaxLength > 0 && len([]rune(s)) > f.MaxLength {
+			return nil, invalid(fmt.Sprintf("exceeds %d characters", f.MaxLength))
+		}
+		if f.Pattern != "" && !regexp.MustCompile(f.Pattern).MatchString(s) {
+			return nil, invalid("has an invalid format")
+		}
+	}
+	return s, nil
+}
+
+// FieldRepository define la persistencia de las definiciones de campos personalizados
+type FieldRepository interface {
+	Save(ctx context.Context, field *CustomField) error
+	GetByKey(ctx context.Context, workspaceID, key string) (*CustomField, error)
+	ListByWorkspace(ctx context.Context, workspaceID string) ([]*CustomField, error)
+	Delete(ctx context.Context, workspaceID, key string) error
+}
@@ -0,0 +1,51 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// IdentitySource indica cómo se vinculó una identidad de plataforma al cliente
+type IdentitySource string
+
+const (
+	IdentityPrimary  IdentitySource = "primary"   // PlatformID/PlatformType del propio cliente
+	IdentityAdmin    IdentitySource = "admin"     // Vinculada o movida por un administrador
+	IdentitySelfLink IdentitySource = "self_link" // El cliente la verificó (código o magic link del portal)
+	IdentityAuto     IdentitySource = "auto"      // Descubierta por el resolver (JID/LID/teléfono del mismo remitente)
+)
+
+// ClientIdentity asocia una identidad de plataforma (JID, LID, Telegram ID...) a un cliente global.
+// Un cliente puede tener muchas; cada identidad pertenece a un solo cliente.
+type ClientIdentity struct {
+	ID           string         `json:"id"`
+	ClientID     string         `json:"client_id"`
+	PlatformID   string         `json:"platform_id"`
+	PlatformType PlatformType   `json:"platform_type"`
+	Source       IdentitySource `json:"source"`
+	Verified     bool           `json:"verified"`
+	CreatedAt    time.Time      `json:"created_at"`
+}
+
+// IdentityRepository persiste las identidades de los clientes (app.db, junto a clients)
+type IdentityRepository interface {
+	// Link crea la identidad; si ya pertenece al mismo cliente no hace nada y si es de otro retorna ErrIdentityLinked
+	Link(ctx context.Context, identity *ClientIdentity) error
+	GetByID(ctx context.Context, id string) (*ClientIdentity, error)
+	GetByPlatform(ctx context.Context, platformID string, platformType PlatformType) (*ClientIdentity, error)
+	ListByClient(ctx context.Context, clientID string) ([]*ClientIdentity, error)
+	Delete(ctx context.Context, id string) error
+	DeleteByClientID(ctx context.Context, clientID string) error
+
+	// Reassign mueve identidades a otro cliente (todas las de fromClientID si ids está vacío)
+	Reassign(ctx context.Context, fromClientID, toClientID string, ids []string) error
+}
+
+// SplitRequest describe qué se lleva el cliente nuevo al separar identidades de uno existente
+type SplitRequest struct {
+	IdentityIDs     []string `json:"identity_ids"`
+	SubscriptionIDs []string `json:"subscription_ids"`
+	DisplayName     string   `json:"display_name"`
+	CopyTags        bool     `json:"copy_tags"`
+	CopyMetadata    bool     `json:"copy_metadata"`
+}
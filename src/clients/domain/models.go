@@ -48,7 +48,8 @@ type Client struct {
 	SessionTimeout        int            `json:"session_timeout,omitempty"`         // Minutos (Override)
 	InactivityWarningTime int            `json:"inactivity_warning_time,omitempty"` // Minutos (Override)
 	Enabled               bool           `json:"enabled"`
-	IsTester              bool           `json:"is_tester"` // If true, logs are NOT redacted (full audit)
+	IsTester              bool           `json:"is_tester"`        // If true, logs are NOT redacted (full audit)
+	AccumulatedCost       float64        `json:"accumulated_cost"` // Costo de IA cargado al cliente en USD (tareas proactivas)
 	LastInteraction       *time.Time     `json:"last_interaction,omitempty"`
 	CreatedAt             time.Time      `json:"created_at"`
 	UpdatedAt             time.Time      `json:"updated_at"`
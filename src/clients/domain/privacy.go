@@ -0,0 +1,49 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// ErasureMode indica qué hacer con los datos de un sujeto al atender una solicitud de supresión
+type ErasureMode string
+
+const (
+	ErasureDelete    ErasureMode = "erase"     // Borrado total (cliente, identidades, suscripciones...)
+	ErasureAnonymize ErasureMode = "anonymize" // Se conserva el registro sin datos personales (métricas, costos)
+)
+
+// SourceResult resume lo hecho en una fuente de datos (solo conteos, nunca datos personales)
+type SourceResult struct {
+	Source    string `json:"source"`
+	Exported  int    `json:"exported,omitempty"`
+	Erased    int    `json:"erased"`
+	Remaining int    `json:"remaining"`       // Registros que aún referencian al sujeto tras verificar
+	Error     string `json:"error,omitempty"` // Fallo de la fuente (la supresión continúa con las demás)
+	Note      string `json:"note,omitempty"`  // Limitaciones conocidas de la fuente
+
+	// Unverified: la fuente no pudo comprobar todo su alcance (ej. datos en otros nodos del cluster)
+	Unverified bool `json:"unverified,omitempty"`
+}
+
+// Tombstone es el registro auditable de una supresión: prueba qué se borró sin guardar a quién.
+// IdentityHashes permite comprobar más tarde si una identidad concreta fue suprimida.
+type Tombstone struct {
+	ID             string         `json:"id"`
+	ClientID       string         `json:"client_id,omitempty"`
+	SubjectHash    string         `json:"subject_hash"`
+	IdentityHashes []string       `json:"identity_hashes"`
+	Mode           ErasureMode    `json:"mode"`
+	RequestedBy    string         `json:"requested_by"` // admin, portal, whatsapp...
+	Verified       bool           `json:"verified"`     // Ninguna fuente reportó restos tras la supresión
+	Results        []SourceResult `json:"results"`
+	CreatedAt      time.Time      `json:"created_at"`
+}
+
+// TombstoneRepository persiste los registros de supresión (app.db)
+type TombstoneRepository interface {
+	Create(ctx context.Context, tombstone *Tombstone) error
+	GetByID(ctx context.Context, id string) (*Tombstone, error)
+	List(ctx context.Context, limit int) ([]*Tombstone, error)
+	ListByIdentityHash(ctx context.Context, hash string) ([]*Tombstone, error)
+}
@@ -42,6 +42,7 @@ type ClientRepository interface {
 	UpdateLastInteraction(ctx context.Context, id string, t time.Time) error
 	AddTag(ctx context.Context, id string, tag string) error
 	RemoveTag(ctx context.Context, id string, tag string) error
+	AddCost(ctx context.Context, id string, cost float64) error
 }
 
 // SubscriptionRepository define las operaciones de persistencia para suscripciones (workspaces.db)
@@ -62,6 +63,13 @@ type SubscriptionRepository interface {
 
 	// Mantenimiento
 	ExpireOldSubscriptions(ctx context.Context) (int, error)
+	// ListExpiring lista las activas que vencen antes de before y las que están en gracia
+	ListExpiring(ctx context.Context, before time.Time) ([]*ClientSubscription, error)
+	// AdvanceLifecycle aplica to solo si la suscripción sigue en from y vence antes de expiresBefore
+	AdvanceLifecycle(ctx context.Context, id string, from, to LifecycleState, expiresBefore time.Time) (bool, error)
+	// ApplyRenewal guarda la renovación solo si el vencimiento sigue siendo prevExpiresAt (si no,
+	// ErrRenewalConflict); una reference ya aplicada retorna ErrRenewalApplied
+	ApplyRenewal(ctx context.Context, sub *ClientSubscription, prevExpiresAt *time.Time, source, reference string) error
 	DeleteByClientID(ctx context.Context, clientID string) error
 
 	// Estadísticas
@@ -9,6 +9,7 @@ const (
 	SubscriptionActive  SubscriptionStatus = "active"
 	SubscriptionPaused  SubscriptionStatus = "paused"
 	SubscriptionExpired SubscriptionStatus = "expired"
+	SubscriptionGrace   SubscriptionStatus = "grace" // Vencida pero con acceso reducido hasta GraceUntil
 	SubscriptionRevoked SubscriptionStatus = "revoked"
 )
 
@@ -28,12 +29,17 @@ type ClientSubscription struct {
 	MaxRecurringReminders *int               `json:"max_recurring_reminders,omitempty"` // Max recurrence. Nil = Default (5)
 	Status                SubscriptionStatus `json:"status"`
 	ExpiresAt             *time.Time         `json:"expires_at,omitempty"`
+	GraceUntil            *time.Time         `json:"grace_until,omitempty"`      // Fin del periodo de gracia (status grace)
+	LastNoticeDays        int                `json:"last_notice_days,omitempty"` // Último aviso de vencimiento enviado (días antes). 0 = ninguno
 	CreatedAt             time.Time          `json:"created_at"`
 	UpdatedAt             time.Time          `json:"updated_at"`
 }
 
-// IsActive verifica si la suscripción está activa y no expirada
+// IsActive verifica si la suscripción está activa y no expirada (la gracia vigente cuenta como activa)
 func (s *ClientSubscription) IsActive() bool {
+	if s.Status == SubscriptionGrace {
+		return s.InGrace()
+	}
 	if s.Status != SubscriptionActive {
 		return false
 	}
@@ -42,3 +48,30 @@ func (s *ClientSubscription) IsActive() bool {
 	}
 	return true
 }
+
+// InGrace indica si la suscripción está en su periodo de gracia
+func (s *ClientSubscription) InGrace() bool {
+	return s.Status == SubscriptionGrace && s.GraceUntil != nil && s.GraceUntil.After(time.Now())
+}
+
+// LifecycleState es la parte de la suscripción que avanza el worker de ciclo de vida.
+// Los cambios se aplican con compare-and-swap para que varios nodos no dupliquen avisos.
+type LifecycleState struct {
+	Status         SubscriptionStatus
+	LastNoticeDays int
+	GraceUntil     *time.Time
+}
+
+// Lifecycle devuelve el estado actual de ciclo de vida
+func (s *ClientSubscription) Lifecycle() LifecycleState {
+	return LifecycleState{Status: s.Status, LastNoticeDays: s.LastNoticeDays, GraceUntil: s.GraceUntil}
+}
+
+// RenewRequest extiende una suscripción. Until tiene prioridad sobre Days.
+type RenewRequest struct {
+	Days      int        `json:"days,omitempty"`      // Se suman al vencimiento actual (o a ahora si ya venció)
+	Until     *time.Time `json:"until,omitempty"`     // Nueva fecha de vencimiento exacta
+	Source    string     `json:"source,omitempty"`    // admin | payment
+	Reference string     `json:"reference,omitempty"` // Id del pago; evita aplicar dos veces el mismo callback
+	Shorten   bool       `json:"shorten,omitempty"`   // Permite un Until anterior al vencimiento actual (solo desde el panel)
+}
@@ -36,6 +36,7 @@ type clientModel struct {
 	InactivityWarningTime int            `gorm:"default:0"`
 	Enabled               bool           `gorm:"default:true"`
 	IsTester              bool           `gorm:"default:false"`
+	AccumulatedCost       float64        `gorm:"default:0"`
 	LastInteraction       *time.Time     `gorm:"column:last_interaction"`
 	CreatedAt             time.Time      `gorm:"not null"`
 	UpdatedAt             time.Time      `gorm:"not null"`
@@ -143,7 +144,8 @@ func (r *ClientGormRepository) Update(ctx context.Context, client *domain.Client
 	// Usamos Save, pero para garantizar que actualice solo si existe y manejar errores de duplicados (unique constraints)
 	// GORM Save hace upsert normalmente.
 	// Si queremos comportamiento estricto de Update (retornar not found si no existe):
-	result := r.db.WithContext(ctx).Model(&clientModel{ID: client.ID}).Select("*").Updates(&model)
+	// accumulated_cost solo cambia con AddCost, para no pisar cargos concurrentes
+	result := r.db.WithContext(ctx).Model(&clientModel{ID: client.ID}).Select("*").Omit("accumulated_cost").Updates(&model)
 
 	if result.Error != nil {
 		if strings.Contains(result.Error.Error(), "UNIQUE constraint failed") || strings.Contains(result.Error.Error(), "duplicate key value") {
@@ -189,6 +191,10 @@ func (r *ClientGormRepository) List(ctx context.Context, filter domain.ClientFil
 		query = query.Where("display_name LIKE ? OR email LIKE ? OR phone LIKE ?", searchPattern, searchPattern, searchPattern)
 	}
 
+	for _, tag := range filter.Tags {
+		query = query.Where("tags LIKE ?", "%\""+tag+"\"%")
+	}
+
 	// Order
 	orderBy := "created_at"
 	if filter.OrderBy != "" {
@@ -275,6 +281,11 @@ func (r *ClientGormRepository) UpdateLastInteraction(ctx context.Context, id str
 	return result.Error
 }
 
+func (r *ClientGormRepository) AddCost(ctx context.Context, id string, cost float64) error {
+	return r.db.WithContext(ctx).Model(&clientModel{}).Where("id = ?", id).
+		Update("accumulated_cost", gorm.Expr("accumulated_cost + ?", cost)).Error
+}
+
 func (r *ClientGormRepository) AddTag(ctx context.Context, id string, tag string) error {
 	client, err := r.GetByID(ctx, id)
 	if err != nil {
@@ -365,6 +376,7 @@ func toClientModel(c *domain.Client) (clientModel, error) {
 		InactivityWarningTime: c.InactivityWarningTime,
 		Enabled:               c.Enabled,
 		IsTester:              c.IsTester,
+		AccumulatedCost:       c.AccumulatedCost,
 		LastInteraction:       c.LastInteraction,
 		CreatedAt:             c.CreatedAt,
 		UpdatedAt:             c.UpdatedAt,
@@ -388,6 +400,7 @@ func fromClientModel(m clientModel) (*domain.Client, error) {
 		InactivityWarningTime: m.InactivityWarningTime,
 		Enabled:               m.Enabled,
 		IsTester:              m.IsTester,
+		AccumulatedCost:       m.AccumulatedCost,
 		LastInteraction:       m.LastInteraction,
 		CreatedAt:             m.CreatedAt,
 		UpdatedAt:             m.UpdatedAt,
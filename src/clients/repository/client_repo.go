@@ -58,6 +58,7 @@ func (r *SQLiteClientRepository) InitSchema(ctx context.Context) error {
 	_, _ = r.db.ExecContext(ctx, "ALTER TABLE clients ADD COLUMN allowed_bots TEXT DEFAULT '[]'")
 	_, _ = r.db.ExecContext(ctx, "ALTER TABLE clients ADD COLUMN timezone TEXT")
 	_, _ = r.db.ExecContext(ctx, "ALTER TABLE clients ADD COLUMN country TEXT")
+	_, _ = r.db.ExecContext(ctx, "ALTER TABLE clients ADD COLUMN accumulated_cost REAL DEFAULT 0")
 
 	return nil
 }
@@ -263,6 +264,11 @@ func (r *SQLiteClientRepository) List(ctx context.Context, filter domain.ClientF
 		args = append(args, searchPattern, searchPattern, searchPattern)
 	}
 
+	for _, tag := range filter.Tags {
+		query += " AND tags LIKE ?"
+		args = append(args, "%\""+tag+"\"%")
+	}
+
 	// Order
 	orderBy := "created_at"
 	if filter.OrderBy != "" {
@@ -363,6 +369,12 @@ func (r *SQLiteClientRepository) UpdateLastInteraction(ctx context.Context, id s
 	return err
 }
 
+// AddCost suma un cargo de IA al costo acumulado del cliente
+func (r *SQLiteClientRepository) AddCost(ctx context.Context, id string, cost float64) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE clients SET accumulated_cost = accumulated_cost + ? WHERE id = ?`, cost, id)
+	return err
+}
+
 // AddTag agrega un tag a un cliente
 func (r *SQLiteClientRepository) AddTag(ctx context.Context, id string, tag string) error {
 	client, err := r.GetByID(ctx, id)
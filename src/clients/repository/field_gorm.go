@@ -0,0 +1,165 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/AzielCF/az-wap/clients/domain"
+	db_pkg "github.com/AzielCF/az-wap/core/pkg/db"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// --- Persistence Model ---
+
+type fieldModel struct {
+	ID          string `gorm:"primaryKey"`
+	WorkspaceID string `gorm:"index:idx_client_fields_key,unique,priority:1;not null"`
+	FieldKey    string `gorm:"index:idx_client_fields_key,unique,priority:2;not null"`
+	Label       string
+	Description sql.NullString
+	Type        string         `gorm:"not null;default:'text'"`
+	Options     sql.NullString `gorm:"type:text;default:'[]'"` // JSON
+	Pattern     sql.NullString
+	MaxLength   int       `gorm:"default:0"`
+	PII         bool      `gorm:"column:pii;default:false"`
+	AIRead      bool      `gorm:"column:ai_read;default:true"`
+	AIWrite     bool      `gorm:"column:ai_write;default:false"`
+	CreatedAt   time.Time `gorm:"not null"`
+	UpdatedAt   time.Time `gorm:"not null"`
+}
+
+func (fieldModel) TableName() string {
+	return "client_field_definitions"
+}
+
+// --- Repository Implementation ---
+
+type FieldGormRepository struct {
+	db *gorm.DB
+}
+
+func NewFieldGormRepository(db *gorm.DB) *FieldGormRepository {
+	return &FieldGormRepository{db: db}
+}
+
+func (r *FieldGormRepository) InitSchema(ctx context.Context) error {
+	models := map[string]interface{}{
+		"client_field_definitions": &fieldModel{},
+	}
+	return db_pkg.SafeMigrateSQLite(ctx, r.db, models)
+}
+
+// Save crea o reemplaza la definición (workspace_id + key)
+func (r *FieldGormRepository) Save(ctx context.Context, field *domain.CustomField) error {
+	now := time.Now()
+	exists := false
+	if existing, err := r.GetByKey(ctx, field.WorkspaceID, field.Key); err == nil {
+		field.ID = existing.ID
+		field.CreatedAt = existing.CreatedAt
+		exists = true
+	} else if err != domain.ErrFieldNotFound {
+		return err
+	}
+	if field.ID == "" {
+		field.ID = uuid.New().String()
+	}
+	if field.CreatedAt.IsZero() {
+		field.CreatedAt = now
+	}
+	field.UpdatedAt = now
+
+	m := toFieldModel(field)
+	if exists {
+		return r.db.WithContext(ctx).Save(&m).Error
+	}
+	// Al insertar gorm cambia los false por el default de la columna (ai_read = true):
+	// los permisos se escriben explícitamente para que un campo nuevo pueda nacer oculto a la IA
+	flags := map[string]interface{}{"pii": m.PII, "ai_read": m.AIRead, "ai_write": m.AIWrite}
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&m).Error; err != nil {
+			return err
+		}
+		return tx.Model(&fieldModel{}).Where("id = ?", m.ID).UpdateColumns(flags).Error
+	})
+}
+
+func (r *FieldGormRepository) GetByKey(ctx context.Context, workspaceID, key string) (*domain.CustomField, error) {
+	var m fieldModel
+	if err := r.db.WithContext(ctx).Where("workspace_id = ? AND field_key = ?", workspaceID, key).First(&m).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, domain.ErrFieldNotFound
+		}
+		return nil, err
+	}
+	return fromFieldModel(m), nil
+}
+
+func (r *FieldGormRepository) ListByWorkspace(ctx context.Context, workspaceID string) ([]*domain.CustomField, error) {
+	var models []fieldModel
+	if err := r.db.WithContext(ctx).Where("workspace_id = ?", workspaceID).Order("field_key ASC").Find(&models).Error; err != nil {
+		return nil, err
+	}
+	result := make([]*domain.CustomField, 0, len(models))
+	for _, m := range models {
+		result = append(result, fromFieldModel(m))
+	}
+	return result, nil
+}
+
+func (r *FieldGormRepository) Delete(ctx context.Context, workspaceID, key string) error {
+	result := r.db.WithContext(ctx).Delete(&fieldModel{}, "workspace_id = ? AND field_key = ?", workspaceID, key)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return domain.ErrFieldNotFound
+	}
+	return nil
+}
+
+// --- Mappers ---
+
+func toFieldModel(f *domain.CustomField) fieldModel {
+	options, _ := json.Marshal(f.Options)
+	return fieldModel{
+		ID:          f.ID,
+		WorkspaceID: f.WorkspaceID,
+		FieldKey:    f.Key,
+		Label:       f.Label,
+		Description: sql.NullString{String: f.Description, Valid: f.Description != ""},
+		Type:        string(f.Type),
+		Options:     sql.NullString{String: string(options), Valid: true},
+		Pattern:     sql.NullString{String: f.Pattern, Valid: f.Pattern != ""},
+		MaxLength:   f.MaxLength,
+		PII:         f.PII,
+		AIRead:      f.AIRead,
+		AIWrite:     f.AIWrite,
+		CreatedAt:   f.CreatedAt,
+		UpdatedAt:   f.UpdatedAt,
+	}
+}
+
+func fromFieldModel(m fieldModel) *domain.CustomField {
+	f := &domain.CustomField{
+		ID:          m.ID,
+		WorkspaceID: m.WorkspaceID,
+		Key:         m.FieldKey,
+		Label:       m.Label,
+		Description: m.Description.String,
+		Type:        domain.FieldType(m.Type),
+		Pattern:     m.Pattern.String,
+		MaxLength:   m.MaxLength,
+		PII:         m.PII,
+		AIRead:      m.AIRead,
+		AIWrite:     m.AIWrite,
+		CreatedAt:   m.CreatedAt,
+		UpdatedAt:   m.UpdatedAt,
+	}
+	if m.Options.Valid && m.Options.String != "" {
+		_ = json.Unmarshal([]byte(m.Options.String), &f.Options)
+	}
+	return f
+}
@@ -0,0 +1,200 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/AzielCF/az-wap/clients/domain"
+	db_pkg "github.com/AzielCF/az-wap/core/pkg/db"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// --- Persistence Model ---
+
+type identityModel struct {
+	ID           string    `gorm:"primaryKey"`
+	ClientID     string    `gorm:"index:idx_client_identities_client;not null"`
+	PlatformID   string    `gorm:"index:idx_client_identities_platform,unique,priority:1;not null"`
+	PlatformType string    `gorm:"index:idx_client_identities_platform,unique,priority:2;not null"`
+	Source       string    `gorm:"default:'admin'"`
+	Verified     bool      `gorm:"default:false"`
+	CreatedAt    time.Time `gorm:"not null"`
+}
+
+func (identityModel) TableName() string {
+	return "client_identities"
+}
+
+// --- Repository Implementation ---
+
+type IdentityGormRepository struct {
+	db *gorm.DB
+}
+
+func NewIdentityGormRepository(db *gorm.DB) *IdentityGormRepository {
+	return &IdentityGormRepository{db: db}
+}
+
+// InitSchema crea la tabla y registra como identidad principal el PlatformID de los clientes existentes
+func (r *IdentityGormRepository) InitSchema(ctx context.Context) error {
+	models := map[string]interface{}{
+		"client_identities": &identityModel{},
+	}
+	if err := db_pkg.SafeMigrateSQLite(ctx, r.db, models); err != nil {
+		return err
+	}
+	return r.backfillPrimary(ctx)
+}
+
+func (r *IdentityGormRepository) backfillPrimary(ctx context.Context) error {
+	var rows []struct {
+		ID           string
+		PlatformID   string
+		PlatformType string
+		CreatedAt    time.Time
+	}
+	err := r.db.WithContext(ctx).Table("clients").
+		Select("clients.id, clients.platform_id, clients.platform_type, clients.created_at").
+		Where("clients.platform_id <> ''").
+		Where("NOT EXISTS (SELECT 1 FROM client_identities ci WHERE ci.platform_id = clients.platform_id AND ci.platform_type = clients.platform_type)").
+		Scan(&rows).Error
+	if err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		m := identityModel{
+			ID:           uuid.New().String(),
+			ClientID:     row.ID,
+			PlatformID:   row.PlatformID,
+			PlatformType: row.PlatformType,
+			Source:       string(domain.IdentityPrimary),
+			Verified:     true,
+			CreatedAt:    row.CreatedAt,
+		}
+		// Dos clientes legacy con el mismo PlatformID: el primero se queda la identidad
+		if err := r.db.WithContext(ctx).Create(&m).Error; err != nil && !isDuplicate(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *IdentityGormRepository) Link(ctx context.Context, identity *domain.ClientIdentity) error {
+	existing, err := r.GetByPlatform(ctx, identity.PlatformID, identity.PlatformType)
+	if err == nil {
+		if existing.ClientID != identity.ClientID {
+			return domain.ErrIdentityLinked
+		}
+		*identity = *existing
+		return nil
+	}
+	if !errors.Is(err, domain.ErrIdentityNotFound) {
+		return err
+	}
+
+	if identity.ID == "" {
+		identity.ID = uuid.New().String()
+	}
+	if identity.CreatedAt.IsZero() {
+		identity.CreatedAt = time.Now()
+	}
+	m := toIdentityModel(identity)
+	if err := r.db.WithContext(ctx).Create(&m).Error; err != nil {
+		if isDuplicate(err) {
+			return domain.ErrIdentityLinked
+		}
+		return err
+	}
+	return nil
+}
+
+func (r *IdentityGormRepository) GetByID(ctx context.Context, id string) (*domain.ClientIdentity, error) {
+	var m identityModel
+	if err := r.db.WithContext(ctx).First(&m, "id = ?", id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, domain.ErrIdentityNotFound
+		}
+		return nil, err
+	}
+	return fromIdentityModel(m), nil
+}
+
+func (r *IdentityGormRepository) GetByPlatform(ctx context.Context, platformID string, platformType domain.PlatformType) (*domain.ClientIdentity, error) {
+	var m identityModel
+	if err := r.db.WithContext(ctx).Where("platform_id = ? AND platform_type = ?", platformID, string(platformType)).First(&m).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, domain.ErrIdentityNotFound
+		}
+		return nil, err
+	}
+	return fromIdentityModel(m), nil
+}
+
+func (r *IdentityGormRepository) ListByClient(ctx context.Context, clientID string) ([]*domain.ClientIdentity, error) {
+	var models []identityModel
+	if err := r.db.WithContext(ctx).Where("client_id = ?", clientID).Order("created_at ASC").Find(&models).Error; err != nil {
+		return nil, err
+	}
+	result := make([]*domain.ClientIdentity, 0, len(models))
+	for _, m := range models {
+		result = append(result, fromIdentityModel(m))
+	}
+	return result, nil
+}
+
+func (r *IdentityGormRepository) Delete(ctx context.Context, id string) error {
+	result := r.db.WithContext(ctx).Delete(&identityModel{}, "id = ?", id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return domain.ErrIdentityNotFound
+	}
+	return nil
+}
+
+func (r *IdentityGormRepository) DeleteByClientID(ctx context.Context, clientID string) error {
+	return r.db.WithContext(ctx).Delete(&identityModel{}, "client_id = ?", clientID).Error
+}
+
+func (r *IdentityGormRepository) Reassign(ctx context.Context, fromClientID, toClientID string, ids []string) error {
+	query := r.db.WithContext(ctx).Model(&identityModel{}).Where("client_id = ?", fromClientID)
+	if len(ids) > 0 {
+		query = query.Where("id IN ?", ids)
+	}
+	return query.Update("client_id", toClientID).Error
+}
+
+// --- Mappers ---
+
+func toIdentityModel(i *domain.ClientIdentity) identityModel {
+	return identityModel{
+		ID:           i.ID,
+		ClientID:     i.ClientID,
+		PlatformID:   i.PlatformID,
+		PlatformType: string(i.PlatformType),
+		Source:       string(i.Source),
+		Verified:     i.Verified,
+		CreatedAt:    i.CreatedAt,
+	}
+}
+
+func fromIdentityModel(m identityModel) *domain.ClientIdentity {
+	return &domain.ClientIdentity{
+		ID:           m.ID,
+		ClientID:     m.ClientID,
+		PlatformID:   m.PlatformID,
+		PlatformType: domain.PlatformType(m.PlatformType),
+		Source:       domain.IdentitySource(m.Source),
+		Verified:     m.Verified,
+		CreatedAt:    m.CreatedAt,
+	}
+}
+
+func isDuplicate(err error) bool {
+	return strings.Contains(err.Error(), "UNIQUE constraint failed") || strings.Contains(err.Error(), "duplicate key value")
+}
@@ -27,6 +27,8 @@ type subscriptionModel struct {
 	Priority              int            `gorm:"default:0"`
 	Status                string         `gorm:"index:idx_subscriptions_status;default:'active'"`
 	ExpiresAt             *time.Time     `gorm:"index"` // Index useful for expiration checks
+	GraceUntil            *time.Time     `gorm:"index"` // End of the grace period (status grace)
+	LastNoticeDays        int            `gorm:"default:0"`
 	CreatedAt             time.Time      `gorm:"not null"`
 	UpdatedAt             time.Time      `gorm:"not null"`
 	SessionTimeout        int            `gorm:"default:0"`
@@ -39,6 +41,19 @@ func (subscriptionModel) TableName() string {
 	return "client_subscriptions"
 }
 
+// renewalModel guarda las referencias de pago ya aplicadas: un callback reenviado no extiende dos veces
+type renewalModel struct {
+	Reference      string    `gorm:"primaryKey"`
+	SubscriptionID string    `gorm:"index;not null"`
+	Source         string    `gorm:"not null"`
+	ExpiresAt      time.Time `gorm:"not null"`
+	CreatedAt      time.Time `gorm:"not null"`
+}
+
+func (renewalModel) TableName() string {
+	return "subscription_renewals"
+}
+
 // --- Repository Implementation ---
 
 type SubscriptionGormRepository struct {
@@ -51,7 +66,8 @@ func NewSubscriptionGormRepository(db *gorm.DB) *SubscriptionGormRepository {
 
 func (r *SubscriptionGormRepository) InitSchema(ctx context.Context) error {
 	models := map[string]interface{}{
-		"client_subscriptions": &subscriptionModel{},
+		"client_subscriptions":  &subscriptionModel{},
+		"subscription_renewals": &renewalModel{},
 	}
 	return db_pkg.SafeMigrateSQLite(ctx, r.db, models)
 }
@@ -115,6 +131,58 @@ func (r *SubscriptionGormRepository) Update(ctx context.Context, sub *domain.Cli
 	return nil
 }
 
+// ApplyRenewal guarda la suscripción renovada y, con reference, registra el pago en la misma
+// transacción. Una referencia ya registrada retorna ErrRenewalApplied sin tocar la suscripción.
+// Es un compare-and-swap sobre expires_at: si otra renovación lo movió desde que se leyó,
+// retorna ErrRenewalConflict y no se registra nada (el llamador recalcula sobre el valor nuevo).
+func (r *SubscriptionGormRepository) ApplyRenewal(ctx context.Context, sub *domain.ClientSubscription, prevExpiresAt *time.Time, source, reference string) error {
+	sub.UpdatedAt = time.Now()
+	model, err := toSubscriptionModel(sub)
+	if err != nil {
+		return err
+	}
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if reference != "" {
+			renewal := renewalModel{
+				Reference:      reference,
+				SubscriptionID: sub.ID,
+				Source:         source,
+				ExpiresAt:      *sub.ExpiresAt,
+				CreatedAt:      sub.UpdatedAt,
+			}
+			if err := tx.Create(&renewal).Error; err != nil {
+				if strings.Contains(err.Error(), "UNIQUE constraint failed") || strings.Contains(err.Error(), "duplicate key value") {
+					return domain.ErrRenewalApplied
+				}
+				return err
+			}
+		}
+
+		q := tx.Model(&subscriptionModel{ID: sub.ID})
+		if prevExpiresAt == nil {
+			q = q.Where("expires_at IS NULL")
+		} else {
+			q = q.Where("expires_at = ?", *prevExpiresAt)
+		}
+		result := q.Select("*").Updates(&model)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			var count int64
+			if err := tx.Model(&subscriptionModel{}).Where("id = ?", sub.ID).Count(&count).Error; err != nil {
+				return err
+			}
+			if count == 0 {
+				return domain.ErrSubscriptionNotFound
+			}
+			return domain.ErrRenewalConflict
+		}
+		return nil
+	})
+}
+
 func (r *SubscriptionGormRepository) Delete(ctx context.Context, id string) error {
 	result := r.db.WithContext(ctx).Delete(&subscriptionModel{}, "id = ?", id)
 	if result.Error != nil {
@@ -159,11 +227,12 @@ func (r *SubscriptionGormRepository) GetActiveSubscription(ctx context.Context,
 	var m subscriptionModel
 	now := time.Now()
 
-	// Query: client=X AND channel=Y AND status='active' AND (expires_at IS NULL OR expires_at > now)
 	// Order by priority DESC, limit 1
+	// Query: client=X AND channel=Y AND (active and not expired OR in grace period)
 	err := r.db.WithContext(ctx).
-		Where("client_id = ? AND channel_id = ? AND status = ?", clientID, channelID, "active").
-		Where("expires_at IS NULL OR expires_at > ?", now).
+		Where("client_id = ? AND channel_id = ?", clientID, channelID).
+		Where("(status = ? AND (expires_at IS NULL OR expires_at > ?)) OR (status = ? AND grace_until > ?)",
+			"active", now, "grace", now).
 		Order("priority DESC").
 		First(&m).Error
 
@@ -187,8 +256,47 @@ func (r *SubscriptionGormRepository) ExpireOldSubscriptions(ctx context.Context)
 			"status":     "expired",
 			"updated_at": now,
 		})
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	expired := int(result.RowsAffected)
 
-	return int(result.RowsAffected), result.Error
+	// Grace periods that already ended
+	result = r.db.WithContext(ctx).Model(&subscriptionModel{}).
+		Where("status = ? AND (grace_until IS NULL OR grace_until < ?)", "grace", now).
+		Updates(map[string]interface{}{
+			"status":     "expired",
+			"updated_at": now,
+		})
+
+	return expired + int(result.RowsAffected), result.Error
+}
+
+func (r *SubscriptionGormRepository) ListExpiring(ctx context.Context, before time.Time) ([]*domain.ClientSubscription, error) {
+	var models []subscriptionModel
+	err := r.db.WithContext(ctx).
+		Where("(status = ? AND expires_at IS NOT NULL AND expires_at < ?) OR status = ?", "active", before, "grace").
+		Order("expires_at ASC").
+		Find(&models).Error
+	if err != nil {
+		return nil, err
+	}
+	return fromSubscriptionModels(models)
+}
+
+// AdvanceLifecycle is a compare-and-swap: a renewal (new expires_at) or another node
+// that already moved the subscription makes it a no-op.
+func (r *SubscriptionGormRepository) AdvanceLifecycle(ctx context.Context, id string, from, to domain.LifecycleState, expiresBefore time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&subscriptionModel{}).
+		Where("id = ? AND status = ? AND last_notice_days = ?", id, string(from.Status), from.LastNoticeDays).
+		Where("expires_at IS NOT NULL AND expires_at < ?", expiresBefore).
+		Updates(map[string]interface{}{
+			"status":           string(to.Status),
+			"last_notice_days": to.LastNoticeDays,
+			"grace_until":      to.GraceUntil,
+			"updated_at":       time.Now(),
+		})
+	return result.RowsAffected == 1, result.Error
 }
 
 func (r *SubscriptionGormRepository) DeleteByClientID(ctx context.Context, clientID string) error {
@@ -205,8 +313,9 @@ func (r *SubscriptionGormRepository) CountActiveByChannel(ctx context.Context, c
 	var count int64
 	now := time.Now()
 	err := r.db.WithContext(ctx).Model(&subscriptionModel{}).
-		Where("channel_id = ? AND status = ?", channelID, "active").
-		Where("expires_at IS NULL OR expires_at > ?", now).
+		Where("channel_id = ?", channelID).
+		Where("(status = ? AND (expires_at IS NULL OR expires_at > ?)) OR (status = ? AND grace_until > ?)",
+			"active", now, "grace", now).
 		Count(&count).Error
 	return int(count), err
 }
@@ -234,6 +343,8 @@ func toSubscriptionModel(s *domain.ClientSubscription) (subscriptionModel, error
 		Priority:              s.Priority,
 		Status:                string(s.Status),
 		ExpiresAt:             s.ExpiresAt,
+		GraceUntil:            s.GraceUntil,
+		LastNoticeDays:        s.LastNoticeDays,
 		CreatedAt:             s.CreatedAt,
 		UpdatedAt:             s.UpdatedAt,
 		SessionTimeout:        s.SessionTimeout,
@@ -254,6 +365,8 @@ func fromSubscriptionModel(m subscriptionModel) (*domain.ClientSubscription, err
 		Priority:              m.Priority,
 		Status:                domain.SubscriptionStatus(m.Status),
 		ExpiresAt:             m.ExpiresAt,
+		GraceUntil:            m.GraceUntil,
+		LastNoticeDays:        m.LastNoticeDays,
 		CreatedAt:             m.CreatedAt,
 		UpdatedAt:             m.UpdatedAt,
 		SessionTimeout:        m.SessionTimeout,
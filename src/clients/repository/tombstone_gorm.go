@@ -0,0 +1,135 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/AzielCF/az-wap/clients/domain"
+	db_pkg "github.com/AzielCF/az-wap/core/pkg/db"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// --- Persistence Model ---
+
+type tombstoneModel struct {
+	ID             string `gorm:"primaryKey"`
+	ClientID       sql.NullString
+	SubjectHash    string         `gorm:"index;not null"`
+	IdentityHashes sql.NullString `gorm:"type:text"` // Hashes separados por coma
+	Mode           string         `gorm:"not null"`
+	RequestedBy    string         `gorm:"not null"`
+	Verified       bool           `gorm:"default:false"`
+	Results        sql.NullString `gorm:"type:text;default:'[]'"` // JSON
+	CreatedAt      time.Time      `gorm:"index;not null"`
+}
+
+func (tombstoneModel) TableName() string {
+	return "data_subject_tombstones"
+}
+
+// --- Repository Implementation ---
+
+type TombstoneGormRepository struct {
+	db *gorm.DB
+}
+
+func NewTombstoneGormRepository(db *gorm.DB) *TombstoneGormRepository {
+	return &TombstoneGormRepository{db: db}
+}
+
+func (r *TombstoneGormRepository) InitSchema(ctx context.Context) error {
+	models := map[string]interface{}{
+		"data_subject_tombstones": &tombstoneModel{},
+	}
+	return db_pkg.SafeMigrateSQLite(ctx, r.db, models)
+}
+
+func (r *TombstoneGormRepository) Create(ctx context.Context, t *domain.Tombstone) error {
+	if t.ID == "" {
+		t.ID = uuid.New().String()
+	}
+	if t.CreatedAt.IsZero() {
+		t.CreatedAt = time.Now()
+	}
+	m := toTombstoneModel(t)
+	return r.db.WithContext(ctx).Create(&m).Error
+}
+
+func (r *TombstoneGormRepository) GetByID(ctx context.Context, id string) (*domain.Tombstone, error) {
+	var m tombstoneModel
+	if err := r.db.WithContext(ctx).First(&m, "id = ?", id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, domain.ErrTombstoneNotFound
+		}
+		return nil, err
+	}
+	return fromTombstoneModel(m), nil
+}
+
+func (r *TombstoneGormRepository) List(ctx context.Context, limit int) ([]*domain.Tombstone, error) {
+	query := r.db.WithContext(ctx).Order("created_at DESC")
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+	var models []tombstoneModel
+	if err := query.Find(&models).Error; err != nil {
+		return nil, err
+	}
+	return fromTombstoneModels(models), nil
+}
+
+func (r *TombstoneGormRepository) ListByIdentityHash(ctx context.Context, hash string) ([]*domain.Tombstone, error) {
+	var models []tombstoneModel
+	if err := r.db.WithContext(ctx).Where("subject_hash = ? OR identity_hashes LIKE ?", hash, "%"+hash+"%").Order("created_at DESC").Find(&models).Error; err != nil {
+		return nil, err
+	}
+	return fromTombstoneModels(models), nil
+}
+
+// --- Mappers ---
+
+func toTombstoneModel(t *domain.Tombstone) tombstoneModel {
+	results, _ := json.Marshal(t.Results)
+	return tombstoneModel{
+		ID:             t.ID,
+		ClientID:       sql.NullString{String: t.ClientID, Valid: t.ClientID != ""},
+		SubjectHash:    t.SubjectHash,
+		IdentityHashes: sql.NullString{String: strings.Join(t.IdentityHashes, ","), Valid: len(t.IdentityHashes) > 0},
+		Mode:           string(t.Mode),
+		RequestedBy:    t.RequestedBy,
+		Verified:       t.Verified,
+		Results:        sql.NullString{String: string(results), Valid: true},
+		CreatedAt:      t.CreatedAt,
+	}
+}
+
+func fromTombstoneModel(m tombstoneModel) *domain.Tombstone {
+	t := &domain.Tombstone{
+		ID:          m.ID,
+		ClientID:    m.ClientID.String,
+		SubjectHash: m.SubjectHash,
+		Mode:        domain.ErasureMode(m.Mode),
+		RequestedBy: m.RequestedBy,
+		Verified:    m.Verified,
+		CreatedAt:   m.CreatedAt,
+	}
+	if m.Results.Valid && m.Results.String != "" {
+		_ = json.Unmarshal([]byte(m.Results.String), &t.Results)
+	}
+	if m.IdentityHashes.String != "" {
+		t.IdentityHashes = strings.Split(m.IdentityHashes.String, ",")
+	}
+	return t
+}
+
+func fromTombstoneModels(models []tombstoneModel) []*domain.Tombstone {
+	result := make([]*domain.Tombstone, 0, len(models))
+	for _, m := range models {
+		result = append(result, fromTombstoneModel(m))
+	}
+	return result
+}
@@ -205,33 +205,19 @@ func (s *AuthService) GetUserProfile(ctx context.Context, userID string) (*domai
 
 // GenerateMagicLink creates a short-lived opaque token for passwordless login
 func (s *AuthService) GenerateMagicLink(ctx context.Context, clientID, phone string) (string, error) {
-	// 1. Check if we already have a valid link for this client
-	cacheKey := fmt.Sprintf("magic_client:%s", clientID)
-	if s.tokenCache != nil {
-		if existing, _ := s.tokenCache.Get(ctx, cacheKey); existing != "" {
-			// BUGFIX: Verify the token itself still exists in cache before returning it
-			// It might have been deleted but the mapping survived
-			if tokenData, _ := s.tokenCache.Get(ctx, fmt.Sprintf("magic_token:%s", existing)); tokenData != "" {
-				return existing, nil
-			}
-			// If not found, clean up the stale mapping and proceed to generate a new one
-			_ = s.tokenCache.Delete(ctx, cacheKey)
-		}
-	}
-
-	// 2. Find or Create Shadow User
+	// 1. Find or Create Shadow User
+	// With a team, the phone picks the teammate; otherwise the link belongs to the owner
 	var user *domain.PortalUser
 	users, _ := s.repo.ListByClient(ctx, clientID)
-	if len(users) > 0 {
-		user = users[0]
-	} else if phone != "" {
+	user = pickMagicLinkUser(users, phone)
+	if user == nil && phone != "" {
 		// Fallback to phone just in case
 		user, _ = s.repo.GetByPhone(ctx, phone)
 	}
 
 	if user == nil {
-		// Create Shadow User using ClientID for uniqueness
-		user = domain.NewShadowUser(clientID, phone, domain.RoleMember)
+		// Create Shadow User using ClientID for uniqueness. The first user of an account owns it.
+		user = domain.NewShadowUser(clientID, phone, domain.RoleOwner)
 		if err := s.repo.Create(ctx, user); err != nil {
 			return "", err
 		}
@@ -241,6 +227,20 @@ func (s *AuthService) GenerateMagicLink(ctx context.Context, clientID, phone str
 		_ = s.repo.Update(ctx, user)
 	}
 
+	// 2. Check if we already have a valid link for this user
+	cacheKey := magicClientKey(clientID, user.ID)
+	if s.tokenCache != nil {
+		if existing, _ := s.tokenCache.Get(ctx, cacheKey); existing != "" {
+			// BUGFIX: Verify the token itself still exists in cache before returning it
+			// It might have been deleted but the mapping survived
+			if tokenData, _ := s.tokenCache.Get(ctx, fmt.Sprintf("magic_token:%s", existing)); tokenData != "" {
+				return existing, nil
+			}
+			// If not found, clean up the stale mapping and proceed to generate a new one
+			_ = s.tokenCache.Delete(ctx, cacheKey)
+		}
+	}
+
 	// 3. Generate Opaque Token (Short random string)
 	token, err := portalSecurity.GenerateOpaqueToken()
 	if err != nil {
@@ -264,6 +264,31 @@ func (s *AuthService) GenerateMagicLink(ctx context.Context, clientID, phone str
 	return token, nil
 }
 
+// pickMagicLinkUser chooses the teammate whose phone matches, then the owner, then anyone
+func pickMagicLinkUser(users []*domain.PortalUser, phone string) *domain.PortalUser {
+	if len(users) == 0 {
+		return nil
+	}
+	if digits := normalizePhone(phone); digits != "" {
+		for _, u := range users {
+			if normalizePhone(u.Phone) == digits {
+				return u
+			}
+		}
+	}
+	for _, u := range users {
+		if u.Role == domain.RoleOwner {
+			return u
+		}
+	}
+	return users[0]
+}
+
+// magicClientKey deduplicates pending magic links per account user
+func magicClientKey(clientID, userID string) string {
+	return fmt.Sprintf("magic_client:%s:%s", clientID, userID)
+}
+
 // RedeemMagicLink exchanges a short opaque token for a full session token
 func (s *AuthService) RedeemMagicLink(ctx context.Context, opaqueToken string) (string, *domain.PortalUser, error) {
 	// 1. Look up opaque token in cache
@@ -302,7 +327,7 @@ func (s *AuthService) RedeemMagicLink(ctx context.Context, opaqueToken string) (
 
 	// Bugfix: Also clear the client mapping so a new link can be generated
 	if cid, ok := tokenData["cid"]; ok {
-		_ = s.tokenCache.Delete(ctx, fmt.Sprintf("magic_client:%s", cid))
+		_ = s.tokenCache.Delete(ctx, magicClientKey(cid, userID))
 	}
 
 	return sessionToken, user, nil
@@ -365,8 +390,12 @@ func (s *AuthService) ListAccountsState(ctx context.Context, clientIDs []string)
 		return nil, err
 	}
 
+	// With teams an account may have several users: report the owner's
 	result := make(map[string]PortalAccountSummary)
 	for _, u := range users {
+		if _, seen := result[u.ClientID]; seen && u.Role != domain.RoleOwner {
+			continue
+		}
 		emailStr := ""
 		if u.Email != nil {
 			emailStr = *u.Email
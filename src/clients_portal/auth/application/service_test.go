@@ -54,6 +54,10 @@ func (m *mockAuthRepo) ListByClient(ctx context.Context, clientID string) ([]*do
 	}
 	return results, nil
 }
+func (m *mockAuthRepo) Delete(ctx context.Context, id string) error {
+	delete(m.users, id)
+	return nil
+}
 
 // MockCRMClientRepo
 type mockCRMClientRepo struct {
@@ -95,6 +99,9 @@ func (m *mockCRMClientRepo) AddTag(ctx context.Context, id string, tag string) e
 func (m *mockCRMClientRepo) RemoveTag(ctx context.Context, id string, tag string) error {
 	return nil
 }
+func (m *mockCRMClientRepo) AddCost(ctx context.Context, id string, cost float64) error {
+	return nil
+}
 
 func TestAuthService_MagicLink_Flow(t *testing.T) {
 	// 1. Setup minimal config for JWT
@@ -0,0 +1,382 @@
+package application
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	crmDomain "github.com/AzielCF/az-wap/clients/domain"
+	"github.com/AzielCF/az-wap/clients_portal/auth/domain"
+	portalSecurity "github.com/AzielCF/az-wap/clients_portal/shared/security"
+	coreconfig "github.com/AzielCF/az-wap/core/config"
+	"github.com/AzielCF/az-wap/workspace/domain/channel"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// inviteTTL is how long an invitation link stays valid
+const inviteTTL = 7 * 24 * time.Hour
+
+// InviteSender delivers invitation links through a running messaging channel
+type InviteSender interface {
+	HasChannel(channelID string) bool
+	SendText(ctx context.Context, channelID, chatID, text string) error
+}
+
+// ChannelLookup resolves the channels owned by a client
+type ChannelLookup interface {
+	GetChannel(ctx context.Context, channelID string) (channel.Channel, error)
+}
+
+// TeamView lists the portal users of an account and the invitations still pending
+type TeamView struct {
+	Members []domain.SafePortalUserView `json:"members"`
+	Invites []*domain.PortalInvite      `json:"invites"`
+}
+
+// InviteResult is returned to the owner after inviting someone. Link is the only
+// copy of the token: when Delivered is false it must be shared manually.
+type InviteResult struct {
+	Invite    *domain.PortalInvite `json:"invite"`
+	Link      string               `json:"link"`
+	Delivered bool                 `json:"delivered"`
+}
+
+// InvitePreview is the public information shown on the acceptance page
+type InvitePreview struct {
+	AccountName string            `json:"account_name"`
+	Role        domain.PortalRole `json:"role"`
+	Email       string            `json:"email,omitempty"`
+	Phone       string            `json:"phone,omitempty"`
+	ExpiresAt   time.Time         `json:"expires_at"`
+}
+
+// TeamService manages the portal users of a client account: invitations, roles and removal
+type TeamService struct {
+	users      domain.IAuthRepository
+	invites    domain.IInviteRepository
+	clientRepo crmDomain.ClientRepository
+	channels   ChannelLookup
+	sender     InviteSender
+	now        func() time.Time
+}
+
+func NewTeamService(users domain.IAuthRepository, invites domain.IInviteRepository, clientRepo crmDomain.ClientRepository, channels ChannelLookup) *TeamService {
+	return &TeamService{
+		users:      users,
+		invites:    invites,
+		clientRepo: clientRepo,
+		channels:   channels,
+		now:        time.Now,
+	}
+}
+
+// SetSender enables delivering phone invitations through the account's own channels
+func (s *TeamService) SetSender(sender InviteSender) {
+	s.sender = sender
+}
+
+// ListTeam returns the members of the account and its pending invitations
+func (s *TeamService) ListTeam(ctx context.Context, clientID string) (*TeamView, error) {
+	users, err := s.users.ListByClient(ctx, clientID)
+	if err != nil {
+		return nil, err
+	}
+	invites, err := s.invites.ListPending(ctx, clientID)
+	if err != nil {
+		return nil, err
+	}
+
+	view := &TeamView{Members: make([]domain.SafePortalUserView, 0, len(users)), Invites: make([]*domain.PortalInvite, 0, len(invites))}
+	for _, u := range users {
+		view.Members = append(view.Members, domain.SafePortalUserView{
+			ID:          u.ID,
+			Email:       u.Email,
+			Phone:       u.Phone,
+			Username:    u.Username,
+			FullName:    u.FullName,
+			Role:        u.Role,
+			Active:      u.Active,
+			IsShadow:    u.IsShadow,
+			LastLoginAt: u.LastLoginAt,
+			CreatedAt:   u.CreatedAt,
+		})
+	}
+	now := s.now()
+	for _, inv := range invites {
+		if inv.IsOpen(now) {
+			view.Invites = append(view.Invites, inv)
+		}
+	}
+	return view, nil
+}
+
+// Invite creates an invitation for an email or phone. A previous pending invitation
+// for the same contact is revoked so only the newest link works.
+func (s *TeamService) Invite(ctx context.Context, inviter *domain.PortalUser, email, phone string, role domain.PortalRole) (*InviteResult, error) {
+	email = strings.ToLower(strings.TrimSpace(email))
+	phone = normalizePhone(phone)
+	if email == "" && phone == "" {
+		return nil, domain.ErrInviteContact
+	}
+	if !role.IsValid() {
+		return nil, domain.ErrInvalidRole
+	}
+
+	// 1. The contact must not already have a portal account
+	if email != "" {
+		if existing, err := s.users.GetByUsername(ctx, email); err == nil && existing != nil {
+			return nil, domain.ErrAlreadyMember
+		}
+	}
+	members, err := s.users.ListByClient(ctx, inviter.ClientID)
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range members {
+		if (email != "" && m.Email != nil && strings.EqualFold(*m.Email, email)) || (phone != "" && normalizePhone(m.Phone) == phone) {
+			return nil, domain.ErrAlreadyMember
+		}
+	}
+
+	// 2. Replace older invitations for the same contact
+	pending, err := s.invites.ListPending(ctx, inviter.ClientID)
+	if err != nil {
+		return nil, err
+	}
+	now := s.now()
+	for _, inv := range pending {
+		if (email != "" && inv.Email == email) || (phone != "" && inv.Phone == phone) {
+			inv.Status = domain.InviteRevoked
+			inv.UpdatedAt = now
+			if err := s.invites.Update(ctx, inv); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// 3. Only the hash of the token is stored
+	token, err := portalSecurity.GenerateOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+	invite := &domain.PortalInvite{
+		ID:        uuid.New().String(),
+		ClientID:  inviter.ClientID,
+		Email:     email,
+		Phone:     phone,
+		Role:      role,
+		Status:    domain.InvitePending,
+		TokenHash: hashInviteToken(token),
+		InvitedBy: inviter.ID,
+		ExpiresAt: now.Add(inviteTTL),
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := s.invites.Create(ctx, invite); err != nil {
+		return nil, fmt.Errorf("failed to save invitation: %w", err)
+	}
+
+	result := &InviteResult{Invite: invite, Link: inviteLink(token)}
+	if phone != "" {
+		result.Delivered = s.deliver(ctx, inviter, phone, result.Link)
+	}
+	logrus.Infof("[PORTAL] User %s invited a %s to client %s (delivered: %v)", inviter.ID, role, inviter.ClientID, result.Delivered)
+	return result, nil
+}
+
+// RevokeInvite cancels a pending invitation of the account
+func (s *TeamService) RevokeInvite(ctx context.Context, clientID, inviteID string) error {
+	invite, err := s.invites.GetByID(ctx, inviteID)
+	if err != nil {
+		return err
+	}
+	if invite.ClientID != clientID || invite.Status != domain.InvitePending {
+		return domain.ErrInviteNotFound
+	}
+	invite.Status = domain.InviteRevoked
+	invite.UpdatedAt = s.now()
+	return s.invites.Update(ctx, invite)
+}
+
+// PreviewInvite validates a token and returns what the invitee is about to accept
+func (s *TeamService) PreviewInvite(ctx context.Context, token string) (*InvitePreview, error) {
+	invite, err := s.openInvite(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	preview := &InvitePreview{Role: invite.Role, Email: invite.Email, Phone: invite.Phone, ExpiresAt: invite.ExpiresAt}
+	if client, err := s.clientRepo.GetByID(ctx, invite.ClientID); err == nil {
+		preview.AccountName = client.DisplayName
+	}
+	return preview, nil
+}
+
+// AcceptInvite creates the invitee's portal user with the invited role and starts a session,
+// like redeeming a magic link. The user can set a password later from the profile.
+func (s *TeamService) AcceptInvite(ctx context.Context, token, fullName string) (string, *domain.PortalUser, error) {
+	invite, err := s.openInvite(ctx, token)
+	if err != nil {
+		return "", nil, err
+	}
+
+	user := domain.NewShadowUser(invite.ClientID, invite.Phone, invite.Role)
+	user.FullName = strings.TrimSpace(fullName)
+	if invite.Email != "" {
+		email := invite.Email
+		user.Email = &email
+		user.Username = email
+	}
+	if err := s.users.Create(ctx, user); err != nil {
+		return "", nil, domain.ErrAlreadyMember
+	}
+
+	// Two tabs accepting the same link: only the first one keeps its user
+	now := s.now()
+	accepted, err := s.invites.MarkAccepted(ctx, invite.ID, user.ID, now)
+	if err != nil || !accepted {
+		_ = s.users.Delete(ctx, user.ID)
+		if err != nil {
+			return "", nil, err
+		}
+		return "", nil, domain.ErrInviteNotFound
+	}
+
+	session, err := portalSecurity.GenerateToken(user.ID, user.ClientID, user.Role)
+	if err != nil {
+		return "", nil, err
+	}
+	go s.users.UpdateLastLogin(context.Background(), user.ID)
+
+	logrus.Infof("[PORTAL] Invitation %s accepted, user %s joined client %s as %s", invite.ID, user.ID, user.ClientID, user.Role)
+	return session, user, nil
+}
+
+// ChangeRole updates the role of a member of the account
+func (s *TeamService) ChangeRole(ctx context.Context, clientID, userID string, role domain.PortalRole) (*domain.PortalUser, error) {
+	if !role.IsValid() {
+		return nil, domain.ErrInvalidRole
+	}
+	member, err := s.member(ctx, clientID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if member.Role == role {
+		return member, nil
+	}
+	if member.Role == domain.RoleOwner {
+		if err := s.ensureAnotherOwner(ctx, clientID, member.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	member.Role = role
+	member.UpdatedAt = s.now()
+	if err := s.users.Update(ctx, member); err != nil {
+		return nil, err
+	}
+	return member, nil
+}
+
+// RemoveMember deletes a member's portal user. Their session stops working immediately
+// because the auth middleware reloads the user on every request.
+func (s *TeamService) RemoveMember(ctx context.Context, clientID, userID string) error {
+	member, err := s.member(ctx, clientID, userID)
+	if err != nil {
+		return err
+	}
+	if member.Role == domain.RoleOwner {
+		if err := s.ensureAnotherOwner(ctx, clientID, member.ID); err != nil {
+			return err
+		}
+	}
+	return s.users.Delete(ctx, member.ID)
+}
+
+func (s *TeamService) member(ctx context.Context, clientID, userID string) (*domain.PortalUser, error) {
+	user, err := s.users.GetByID(ctx, userID)
+	if err != nil || user == nil || user.ClientID != clientID {
+		return nil, domain.ErrMemberNotFound
+	}
+	return user, nil
+}
+
+// ensureAnotherOwner prevents leaving the account without an owner
+func (s *TeamService) ensureAnotherOwner(ctx context.Context, clientID, exceptID string) error {
+	users, err := s.users.ListByClient(ctx, clientID)
+	if err != nil {
+		return err
+	}
+	for _, u := range users {
+		if u.ID != exceptID && u.Role == domain.RoleOwner && u.Active {
+			return nil
+		}
+	}
+	return domain.ErrLastOwner
+}
+
+func (s *TeamService) openInvite(ctx context.Context, token string) (*domain.PortalInvite, error) {
+	if token == "" {
+		return nil, domain.ErrInviteNotFound
+	}
+	invite, err := s.invites.GetByTokenHash(ctx, hashInviteToken(token))
+	if err != nil {
+		return nil, err
+	}
+	if !invite.IsOpen(s.now()) {
+		return nil, domain.ErrInviteNotFound
+	}
+	return invite, nil
+}
+
+// deliver sends the link by WhatsApp through one of the account's channels running on this node
+func (s *TeamService) deliver(ctx context.Context, inviter *domain.PortalUser, phone, link string) bool {
+	if s.sender == nil || s.channels == nil {
+		return false
+	}
+	client, err := s.clientRepo.GetByID(ctx, inviter.ClientID)
+	if err != nil {
+		return false
+	}
+
+	text := fmt.Sprintf("You have been invited to join %s on the client portal. Open this link to accept (valid for 7 days): %s", client.DisplayName, link)
+	for _, channelID := range client.OwnedChannels {
+		ch, err := s.channels.GetChannel(ctx, channelID)
+		if err != nil || ch.Type != channel.ChannelTypeWhatsApp || !s.sender.HasChannel(channelID) {
+			continue
+		}
+		if err := s.sender.SendText(ctx, channelID, phone, text); err != nil {
+			logrus.WithError(err).Warnf("[PORTAL] Failed to deliver invitation through channel %s", channelID)
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+func hashInviteToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func inviteLink(token string) string {
+	portalURL := coreconfig.Global.App.PortalURL
+	if portalURL == "" {
+		portalURL = fmt.Sprintf("%s/portal", coreconfig.Global.App.BaseUrl)
+	}
+	return fmt.Sprintf("%s/auth/accept-invite?token=%s", portalURL, token)
+}
+
+// normalizePhone keeps only the digits so "+51 999-000-111" and "51999000111" match
+func normalizePhone(phone string) string {
+	var b strings.Builder
+	for _, r := range phone {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,107 @@
+package application
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/AzielCF/az-wap/clients_portal/auth/domain"
+	"github.com/AzielCF/az-wap/clients_portal/auth/repository"
+	coreconfig "github.com/AzielCF/az-wap/core/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestTeamService_InviteAcceptAndRoles(t *testing.T) {
+	coreconfig.Global = &coreconfig.Config{}
+	coreconfig.Global.Security.PortalJWTSecret = "test-secret"
+	coreconfig.Global.App.PortalURL = "https://portal.example.com"
+
+	ctx := context.Background()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "app.db")), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	users := repository.NewGormAuthRepository(db)
+	require.NoError(t, users.AutoMigrate())
+	invites := repository.NewGormInviteRepository(db)
+	require.NoError(t, invites.AutoMigrate())
+	team := NewTeamService(users, invites, &mockCRMClientRepo{}, nil)
+
+	// Cuenta antigua: su único usuario shadow era MEMBER y pasa a OWNER
+	owner := domain.NewShadowUser("client-1", "+51 999 000 111", domain.RoleMember)
+	require.NoError(t, users.Create(ctx, owner))
+	promoted, err := users.EnsureOwners(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, promoted)
+	owner, err = users.GetByID(ctx, owner.ID)
+	require.NoError(t, err)
+	require.Equal(t, domain.RoleOwner, owner.Role)
+
+	// Invitar por email; la segunda invitación reemplaza a la primera
+	first, err := team.Invite(ctx, owner, "Ana@Example.com", "", domain.RoleManager)
+	require.NoError(t, err)
+	second, err := team.Invite(ctx, owner, "ana@example.com", "", domain.RoleManager)
+	require.NoError(t, err)
+	assert.False(t, second.Delivered)
+	require.True(t, strings.HasPrefix(second.Link, "https://portal.example.com/auth/accept-invite?token="))
+	firstToken := strings.TrimPrefix(first.Link, "https://portal.example.com/auth/accept-invite?token=")
+	token := strings.TrimPrefix(second.Link, "https://portal.example.com/auth/accept-invite?token=")
+	assert.NotEqual(t, token, second.Invite.TokenHash)
+
+	_, _, err = team.AcceptInvite(ctx, firstToken, "Ana")
+	assert.ErrorIs(t, err, domain.ErrInviteNotFound)
+
+	preview, err := team.PreviewInvite(ctx, token)
+	require.NoError(t, err)
+	assert.Equal(t, domain.RoleManager, preview.Role)
+	assert.Equal(t, "Mock Client", preview.AccountName)
+
+	session, manager, err := team.AcceptInvite(ctx, token, "Ana")
+	require.NoError(t, err)
+	assert.NotEmpty(t, session)
+	assert.Equal(t, domain.RoleManager, manager.Role)
+	assert.Equal(t, "client-1", manager.ClientID)
+
+	// El enlace es de un solo uso y el contacto ya es miembro
+	_, _, err = team.AcceptInvite(ctx, token, "Ana")
+	assert.ErrorIs(t, err, domain.ErrInviteNotFound)
+	_, err = team.Invite(ctx, owner, "ana@example.com", "", domain.RoleMember)
+	assert.ErrorIs(t, err, domain.ErrAlreadyMember)
+	_, err = team.Invite(ctx, owner, "", "51999000111", domain.RoleMember)
+	assert.ErrorIs(t, err, domain.ErrAlreadyMember)
+
+	view, err := team.ListTeam(ctx, "client-1")
+	require.NoError(t, err)
+	assert.Len(t, view.Members, 2)
+	assert.Empty(t, view.Invites)
+
+	// Nunca se queda sin OWNER
+	_, err = team.ChangeRole(ctx, "client-1", owner.ID, domain.RoleMember)
+	assert.ErrorIs(t, err, domain.ErrLastOwner)
+	assert.ErrorIs(t, team.RemoveMember(ctx, "client-1", owner.ID), domain.ErrLastOwner)
+
+	_, err = team.ChangeRole(ctx, "client-1", manager.ID, domain.RoleOwner)
+	require.NoError(t, err)
+	_, err = team.ChangeRole(ctx, "client-1", owner.ID, domain.RoleMember)
+	require.NoError(t, err)
+
+	// Otra cuenta no puede tocar a este equipo
+	assert.ErrorIs(t, team.RemoveMember(ctx, "client-2", owner.ID), domain.ErrMemberNotFound)
+	require.NoError(t, team.RemoveMember(ctx, "client-1", owner.ID))
+	view, err = team.ListTeam(ctx, "client-1")
+	require.NoError(t, err)
+	require.Len(t, view.Members, 1)
+	assert.Equal(t, domain.RoleOwner, view.Members[0].Role)
+}
+
+func TestPortalRole_Allows(t *testing.T) {
+	assert.True(t, domain.RoleOwner.Allows(domain.RoleManager))
+	assert.True(t, domain.RoleManager.Allows(domain.RoleManager))
+	assert.False(t, domain.RoleMember.Allows(domain.RoleManager))
+	assert.False(t, domain.PortalRole("ADMIN").Allows(domain.RoleMember))
+}
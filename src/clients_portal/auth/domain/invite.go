@@ -0,0 +1,80 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// InviteStatus tracks the lifecycle of a team invitation
+type InviteStatus string
+
+const (
+	InvitePending  InviteStatus = "pending"
+	InviteAccepted InviteStatus = "accepted"
+	InviteRevoked  InviteStatus = "revoked"
+)
+
+var (
+	ErrInviteNotFound = errors.New("invitation not found or expired")
+	ErrInvalidRole    = errors.New("invalid role")
+	ErrInviteContact  = errors.New("an email or phone is required to invite a teammate")
+	ErrAlreadyMember  = errors.New("this person already belongs to a portal account")
+	ErrLastOwner      = errors.New("the account must keep at least one owner")
+	ErrMemberNotFound = errors.New("team member not found")
+)
+
+// PortalInvite is a pending invitation to join a client's portal team.
+// Only the SHA-256 of the magic-link token is stored.
+type PortalInvite struct {
+	ID             string       `json:"id" gorm:"primaryKey"`
+	ClientID       string       `json:"-" gorm:"index"`
+	Email          string       `json:"email,omitempty"`
+	Phone          string       `json:"phone,omitempty"`
+	Role           PortalRole   `json:"role"`
+	Status         InviteStatus `json:"status" gorm:"index"`
+	TokenHash      string       `json:"-" gorm:"uniqueIndex"`
+	InvitedBy      string       `json:"invited_by"`
+	AcceptedUserID string       `json:"accepted_user_id,omitempty"`
+	ExpiresAt      time.Time    `json:"expires_at"`
+	AcceptedAt     *time.Time   `json:"accepted_at,omitempty"`
+	CreatedAt      time.Time    `json:"created_at"`
+	UpdatedAt      time.Time    `json:"updated_at"`
+}
+
+func (PortalInvite) TableName() string {
+	return "portal_invites"
+}
+
+// IsOpen reports whether the invitation can still be accepted
+func (i *PortalInvite) IsOpen(now time.Time) bool {
+	return i.Status == InvitePending && now.Before(i.ExpiresAt)
+}
+
+// IInviteRepository persists team invitations
+type IInviteRepository interface {
+	Create(ctx context.Context, invite *PortalInvite) error
+	GetByID(ctx context.Context, id string) (*PortalInvite, error)
+	GetByTokenHash(ctx context.Context, hash string) (*PortalInvite, error)
+	ListPending(ctx context.Context, clientID string) ([]*PortalInvite, error)
+	Update(ctx context.Context, invite *PortalInvite) error
+	// MarkAccepted flips a pending invitation to accepted; false if it was no longer pending
+	MarkAccepted(ctx context.Context, id, userID string, at time.Time) (bool, error)
+}
+
+// roleRank orders roles from least to most privileged
+var roleRank = map[PortalRole]int{
+	RoleMember:  1,
+	RoleManager: 2,
+	RoleOwner:   3,
+}
+
+// IsValid reports whether the role is one of the known portal roles
+func (r PortalRole) IsValid() bool {
+	return roleRank[r] > 0
+}
+
+// Allows reports whether the role grants at least the required access level
+func (r PortalRole) Allows(required PortalRole) bool {
+	return roleRank[r] >= roleRank[required] && r.IsValid()
+}
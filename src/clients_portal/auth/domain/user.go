@@ -11,9 +11,9 @@ import (
 type PortalRole string
 
 const (
-	RoleOwner   PortalRole = "OWNER"   // Full access (Billing, Configuration)
-	RoleManager PortalRole = "MANAGER" // Team and channel management
-	RoleMember  PortalRole = "MEMBER"  // Read-only / Operative
+	RoleOwner   PortalRole = "OWNER"   // Full access (Team, Workspaces, Billing, Privacy)
+	RoleManager PortalRole = "MANAGER" // Channel and guest operation
+	RoleMember  PortalRole = "MEMBER"  // Read-only
 )
 
 // PortalUser represents a user who can log in to the Client Portal
@@ -113,6 +113,7 @@ type IAuthRepository interface {
 	UpdateLastLogin(ctx context.Context, id string) error
 	Update(ctx context.Context, user *PortalUser) error
 	ListByClient(ctx context.Context, clientID string) ([]*PortalUser, error)
+	Delete(ctx context.Context, id string) error
 }
 
 // IAuthService defines the business logic for authentication
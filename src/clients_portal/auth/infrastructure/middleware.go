@@ -56,7 +56,8 @@ func NewAuthMiddleware(userRepo domain.IAuthRepository) fiber.Handler {
 	}
 }
 
-// RequireRole is an additional middleware for granular permissions
+// RequireRole is an additional middleware for granular permissions.
+// Roles are ranked: OWNER > MANAGER > MEMBER, so a higher role always passes.
 func RequireRole(requiredRole domain.PortalRole) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		role, ok := c.Locals("portal_role").(domain.PortalRole)
@@ -64,8 +65,8 @@ func RequireRole(requiredRole domain.PortalRole) fiber.Handler {
 			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "role not found in context"})
 		}
 
-		if role != requiredRole && role != domain.RoleOwner { // Owner can always do everything
-			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "insufficient permissions"})
+		if !role.Allows(requiredRole) {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "insufficient permissions", "required_role": requiredRole})
 		}
 
 		return c.Next()
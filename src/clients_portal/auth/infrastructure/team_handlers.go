@@ -0,0 +1,142 @@
+package infrastructure
+
+import (
+	"errors"
+
+	"github.com/AzielCF/az-wap/clients_portal/auth/application"
+	"github.com/AzielCF/az-wap/clients_portal/auth/domain"
+	"github.com/gofiber/fiber/v2"
+)
+
+type TeamHandler struct {
+	teamService *application.TeamService
+}
+
+func NewTeamHandler(service *application.TeamService) *TeamHandler {
+	return &TeamHandler{teamService: service}
+}
+
+type InviteRequest struct {
+	Email string            `json:"email"`
+	Phone string            `json:"phone"`
+	Role  domain.PortalRole `json:"role"` // Optional, default MEMBER
+}
+
+type ChangeRoleRequest struct {
+	Role domain.PortalRole `json:"role"`
+}
+
+type AcceptInviteRequest struct {
+	Token    string `json:"token"`
+	FullName string `json:"full_name"`
+}
+
+// ListTeam returns the account's members and pending invitations (any role)
+func (h *TeamHandler) ListTeam(c *fiber.Ctx) error {
+	user, ok := c.Locals("user").(*domain.PortalUser)
+	if !ok || user == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+	}
+
+	team, err := h.teamService.ListTeam(c.Context(), user.ClientID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to load team"})
+	}
+	return c.JSON(team)
+}
+
+// Invite sends (or returns) an invitation link for a new teammate (owner only)
+func (h *TeamHandler) Invite(c *fiber.Ctx) error {
+	user, ok := c.Locals("user").(*domain.PortalUser)
+	if !ok || user == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+	}
+
+	var req InviteRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request body"})
+	}
+	if req.Role == "" {
+		req.Role = domain.RoleMember
+	}
+
+	result, err := h.teamService.Invite(c.Context(), user, req.Email, req.Phone, req.Role)
+	if err != nil {
+		return teamError(c, err)
+	}
+	return c.Status(fiber.StatusCreated).JSON(result)
+}
+
+// RevokeInvite cancels a pending invitation (owner only)
+func (h *TeamHandler) RevokeInvite(c *fiber.Ctx) error {
+	clientID, _ := c.Locals("portal_client_id").(string)
+	if err := h.teamService.RevokeInvite(c.Context(), clientID, c.Params("iid")); err != nil {
+		return teamError(c, err)
+	}
+	return c.JSON(fiber.Map{"message": "invitation revoked"})
+}
+
+// ChangeRole updates a teammate's role (owner only)
+func (h *TeamHandler) ChangeRole(c *fiber.Ctx) error {
+	clientID, _ := c.Locals("portal_client_id").(string)
+
+	var req ChangeRoleRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request body"})
+	}
+
+	member, err := h.teamService.ChangeRole(c.Context(), clientID, c.Params("uid"), req.Role)
+	if err != nil {
+		return teamError(c, err)
+	}
+	return c.JSON(fiber.Map{"id": member.ID, "role": member.Role})
+}
+
+// RemoveMember removes a teammate from the account (owner only)
+func (h *TeamHandler) RemoveMember(c *fiber.Ctx) error {
+	clientID, _ := c.Locals("portal_client_id").(string)
+	if err := h.teamService.RemoveMember(c.Context(), clientID, c.Params("uid")); err != nil {
+		return teamError(c, err)
+	}
+	return c.JSON(fiber.Map{"message": "member removed"})
+}
+
+// PreviewInvite shows who is inviting and with which role before accepting (public)
+func (h *TeamHandler) PreviewInvite(c *fiber.Ctx) error {
+	preview, err := h.teamService.PreviewInvite(c.Context(), c.Params("token"))
+	if err != nil {
+		return teamError(c, err)
+	}
+	return c.JSON(preview)
+}
+
+// AcceptInvite joins the account and returns a session token, like a magic link (public)
+func (h *TeamHandler) AcceptInvite(c *fiber.Ctx) error {
+	var req AcceptInviteRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request"})
+	}
+
+	sessionToken, user, err := h.teamService.AcceptInvite(c.Context(), req.Token, req.FullName)
+	if err != nil {
+		return teamError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"token": sessionToken,
+		"user":  user,
+	})
+}
+
+func teamError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, domain.ErrInviteNotFound), errors.Is(err, domain.ErrMemberNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, domain.ErrAlreadyMember), errors.Is(err, domain.ErrLastOwner):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, domain.ErrInvalidRole), errors.Is(err, domain.ErrInviteContact):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	default:
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+}
@@ -77,6 +77,10 @@ func (r *GormAuthRepository) ListByClient(ctx context.Context, clientID string)
 	return users, err
 }
 
+func (r *GormAuthRepository) Delete(ctx context.Context, id string) error {
+	return r.db.WithContext(ctx).Delete(&domain.PortalUser{}, "id = ?", id).Error
+}
+
 func (r *GormAuthRepository) GetAll(ctx context.Context, dest *[]*domain.PortalUser) error {
 	return r.db.WithContext(ctx).Find(dest).Error
 }
@@ -84,3 +88,30 @@ func (r *GormAuthRepository) GetAll(ctx context.Context, dest *[]*domain.PortalU
 func (r *GormAuthRepository) GetByClientIDs(ctx context.Context, clientIDs []string, dest *[]*domain.PortalUser) error {
 	return r.db.WithContext(ctx).Where("client_id IN ?", clientIDs).Find(dest).Error
 }
+
+// EnsureOwners promotes the oldest user of every account without an OWNER.
+// Accounts created before team roles existed got a MEMBER shadow user from the magic link.
+func (r *GormAuthRepository) EnsureOwners(ctx context.Context) (int, error) {
+	var clientIDs []string
+	err := r.db.WithContext(ctx).Model(&domain.PortalUser{}).
+		Where("client_id <> ''").
+		Group("client_id").
+		Having("SUM(CASE WHEN role = ? THEN 1 ELSE 0 END) = 0", domain.RoleOwner).
+		Pluck("client_id", &clientIDs).Error
+	if err != nil {
+		return 0, err
+	}
+
+	promoted := 0
+	for _, clientID := range clientIDs {
+		var oldest domain.PortalUser
+		if err := r.db.WithContext(ctx).Where("client_id = ?", clientID).Order("created_at ASC").First(&oldest).Error; err != nil {
+			return promoted, err
+		}
+		if err := r.db.WithContext(ctx).Model(&oldest).Update("role", domain.RoleOwner).Error; err != nil {
+			return promoted, err
+		}
+		promoted++
+	}
+	return promoted, nil
+}
@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/AzielCF/az-wap/clients_portal/auth/domain"
+	"gorm.io/gorm"
+)
+
+type GormInviteRepository struct {
+	db *gorm.DB
+}
+
+func NewGormInviteRepository(db *gorm.DB) *GormInviteRepository {
+	return &GormInviteRepository{db: db}
+}
+
+// AutoMigrate ensures the table exists
+func (r *GormInviteRepository) AutoMigrate() error {
+	return r.db.AutoMigrate(&domain.PortalInvite{})
+}
+
+func (r *GormInviteRepository) Create(ctx context.Context, invite *domain.PortalInvite) error {
+	return r.db.WithContext(ctx).Create(invite).Error
+}
+
+func (r *GormInviteRepository) GetByID(ctx context.Context, id string) (*domain.PortalInvite, error) {
+	var invite domain.PortalInvite
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&invite).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, domain.ErrInviteNotFound
+	}
+	return &invite, err
+}
+
+func (r *GormInviteRepository) GetByTokenHash(ctx context.Context, hash string) (*domain.PortalInvite, error) {
+	var invite domain.PortalInvite
+	err := r.db.WithContext(ctx).Where("token_hash = ?", hash).First(&invite).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, domain.ErrInviteNotFound
+	}
+	return &invite, err
+}
+
+func (r *GormInviteRepository) ListPending(ctx context.Context, clientID string) ([]*domain.PortalInvite, error) {
+	var invites []*domain.PortalInvite
+	err := r.db.WithContext(ctx).
+		Where("client_id = ? AND status = ?", clientID, domain.InvitePending).
+		Order("created_at DESC").
+		Find(&invites).Error
+	return invites, err
+}
+
+func (r *GormInviteRepository) Update(ctx context.Context, invite *domain.PortalInvite) error {
+	return r.db.WithContext(ctx).Save(invite).Error
+}
+
+func (r *GormInviteRepository) MarkAccepted(ctx context.Context, id, userID string, at time.Time) (bool, error) {
+	res := r.db.WithContext(ctx).Model(&domain.PortalInvite{}).
+		Where("id = ? AND status = ?", id, domain.InvitePending).
+		Updates(map[string]interface{}{
+			"status":           domain.InviteAccepted,
+			"accepted_user_id": userID,
+			"accepted_at":      at,
+			"updated_at":       at,
+		})
+	return res.RowsAffected == 1, res.Error
+}
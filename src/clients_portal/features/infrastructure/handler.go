@@ -3,7 +3,6 @@ package infrastructure
 import (
 	"context"
 	"fmt"
-	"os"
 	"sort"
 	"strings"
 	"time"
@@ -20,24 +19,29 @@ import (
 	"github.com/AzielCF/az-wap/workspace/domain/channel"
 	"github.com/AzielCF/az-wap/workspace/domain/common"
 	wsDomain "github.com/AzielCF/az-wap/workspace/domain/workspace"
+	"github.com/AzielCF/az-wap/workspace/infrastructure/whatsapp"
 	wsRepo "github.com/AzielCF/az-wap/workspace/repository"
 	wsUsecase "github.com/AzielCF/az-wap/workspace/usecase"
 	"github.com/gofiber/fiber/v2"
 )
 
 type FeaturesHandler struct {
-	subService    *clientsApp.SubscriptionService
-	clientService *clientsApp.ClientService
-	newsletter    domainNewsletter.INewsletterUsecase
-	wsRepo        wsRepo.IWorkspaceRepository
-	botUsecase    bot.IBotUsecase
-	wsUc          *wsUsecase.WorkspaceUsecase
-	wm            *workspace.Manager
+	subService      *clientsApp.SubscriptionService
+	clientService   *clientsApp.ClientService
+	identityService *clientsApp.IdentityService
+	privacyService  *clientsApp.PrivacyService
+	newsletter      domainNewsletter.INewsletterUsecase
+	wsRepo          wsRepo.IWorkspaceRepository
+	botUsecase      bot.IBotUsecase
+	wsUc            *wsUsecase.WorkspaceUsecase
+	wm              *workspace.Manager
 }
 
 func NewFeaturesHandler(
 	subService *clientsApp.SubscriptionService,
 	clientService *clientsApp.ClientService,
+	identityService *clientsApp.IdentityService,
+	privacyService *clientsApp.PrivacyService,
 	newsletter domainNewsletter.INewsletterUsecase,
 	wsRepo wsRepo.IWorkspaceRepository,
 	botUsecase bot.IBotUsecase,
@@ -45,13 +49,15 @@ func NewFeaturesHandler(
 	wm *workspace.Manager,
 ) *FeaturesHandler {
 	return &FeaturesHandler{
-		subService:    subService,
-		clientService: clientService,
-		newsletter:    newsletter,
-		wsRepo:        wsRepo,
-		botUsecase:    botUsecase,
-		wsUc:          wsUc,
-		wm:            wm,
+		subService:      subService,
+		clientService:   clientService,
+		identityService: identityService,
+		privacyService:  privacyService,
+		newsletter:      newsletter,
+		wsRepo:          wsRepo,
+		botUsecase:      botUsecase,
+		wsUc:            wsUc,
+		wm:              wm,
 	}
 }
 
@@ -79,6 +85,8 @@ type ReminderView struct {
 	ScheduledAt    time.Time `json:"scheduled_at"`
 	Status         string    `json:"status"`
 	RecurrenceDays string    `json:"recurrence_days,omitempty"`
+	RRule          string    `json:"rrule,omitempty"`
+	Timezone       string    `json:"timezone,omitempty"`
 	ChannelType    string    `json:"channel_type"`
 	BotName        string    `json:"bot_name"`
 }
@@ -131,6 +139,8 @@ func (h *FeaturesHandler) ListReminders(c *fiber.Ctx) error {
 				ScheduledAt:    p.ScheduledAt,
 				Status:         string(p.Status),
 				RecurrenceDays: p.RecurrenceDays,
+				RRule:          p.RRule,
+				Timezone:       p.Timezone,
 				ChannelType:    channelType,
 				BotName:        botName,
 			})
@@ -1100,12 +1110,8 @@ func (h *FeaturesHandler) WhatsAppLogout(c *fiber.Ctx) error {
 			}
 		} else {
 			logrus.Warnf("[REST Portal] Could not start channel %s for logout, cleaning up files only: %v", cid, err)
-			waDBPath := fmt.Sprintf("storages/whatsapp-%s.db", cid)
-			waFiles := []string{waDBPath, waDBPath + "-shm", waDBPath + "-wal"}
-			for _, f := range waFiles {
-				if err := os.Remove(f); err != nil && !os.IsNotExist(err) {
-					logrus.Warnf("[REST Portal] Failed to remove %s: %v", f, err)
-				}
+			if err := whatsapp.Devices().Delete(c.Context(), cid); err != nil {
+				logrus.Warnf("[REST Portal] Failed to remove device keys for %s: %v", cid, err)
 			}
 		}
 	}
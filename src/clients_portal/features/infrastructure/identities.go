@@ -0,0 +1,119 @@
+package infrastructure
+
+import (
+	"errors"
+	"fmt"
+
+	clientsDomain "github.com/AzielCF/az-wap/clients/domain"
+	portalDomain "github.com/AzielCF/az-wap/clients_portal/auth/domain"
+	coreconfig "github.com/AzielCF/az-wap/core/config"
+	"github.com/gofiber/fiber/v2"
+)
+
+// ListIdentities lists the platform accounts (WhatsApp, Telegram...) linked to the portal user's client
+func (h *FeaturesHandler) ListIdentities(c *fiber.Ctx) error {
+	user, ok := c.Locals("user").(*portalDomain.PortalUser)
+	if !ok || user == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	identities, err := h.identityService.List(c.Context(), user.ClientID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch linked accounts"})
+	}
+	return c.JSON(fiber.Map{"data": identities})
+}
+
+// CreateIdentityLinkCode returns a one-time code to send as "LINK <code>" from the account to link
+func (h *FeaturesHandler) CreateIdentityLinkCode(c *fiber.Ctx) error {
+	user, ok := c.Locals("user").(*portalDomain.PortalUser)
+	if !ok || user == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	code, expiresAt, err := h.identityService.CreateLinkCode(c.Context(), user.ClientID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{
+		"code":       code,
+		"message":    "LINK " + code,
+		"expires_at": expiresAt,
+	})
+}
+
+// ClaimIdentityLink exchanges a magic link (sent to that account's chat) for a confirmation code.
+// The account is only linked once it sends "LINK <code>" from its own chat.
+func (h *FeaturesHandler) ClaimIdentityLink(c *fiber.Ctx) error {
+	user, ok := c.Locals("user").(*portalDomain.PortalUser)
+	if !ok || user == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	var req struct {
+		Token string `json:"token"`
+	}
+	if err := c.BodyParser(&req); err != nil || req.Token == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request"})
+	}
+
+	code, expiresAt, err := h.identityService.ClaimLinkToken(c.Context(), req.Token, user.ClientID)
+	if err != nil {
+		if errors.Is(err, clientsDomain.ErrInvalidLinkCode) {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "invalid or expired link"})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{
+		"code":       code,
+		"message":    "LINK " + code,
+		"expires_at": expiresAt,
+	})
+}
+
+// UnlinkIdentity removes a secondary account from the portal user's client
+func (h *FeaturesHandler) UnlinkIdentity(c *fiber.Ctx) error {
+	user, ok := c.Locals("user").(*portalDomain.PortalUser)
+	if !ok || user == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	if err := h.identityService.Unlink(c.Context(), user.ClientID, c.Params("iid")); err != nil {
+		switch {
+		case errors.Is(err, clientsDomain.ErrIdentityNotFound):
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+		case errors.Is(err, clientsDomain.ErrPrimaryIdentity):
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// GenerateIdentityLink creates a portal magic link for a platform account; whoever opens it logged in
+// gets a code that the account must send back to be linked (Admin/Bot use only, delivered to that account's chat)
+func (h *FeaturesHandler) GenerateIdentityLink(c *fiber.Ctx) error {
+	secret := c.Get("X-Internal-Key")
+	if secret == "" || secret != coreconfig.Global.Security.PortalInternalKey {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "forbidden: invalid internal key"})
+	}
+
+	var req struct {
+		PlatformType string `json:"platform_type"`
+		PlatformID   string `json:"platform_id"`
+		SecondaryID  string `json:"secondary_id"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request"})
+	}
+
+	token, err := h.identityService.CreateLinkToken(c.Context(), clientsDomain.PlatformType(req.PlatformType), req.PlatformID, req.SecondaryID)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{
+		"token": token,
+		"url":   fmt.Sprintf("/auth/link-account?token=%s", token),
+	})
+}
@@ -0,0 +1,110 @@
+package infrastructure
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"time"
+
+	clientsApp "github.com/AzielCF/az-wap/clients/application"
+	clientsDomain "github.com/AzielCF/az-wap/clients/domain"
+	portalDomain "github.com/AzielCF/az-wap/clients_portal/auth/domain"
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+)
+
+// eraseConfirmation must be typed by the user to erase their data from the portal
+const eraseConfirmation = "DELETE"
+
+// ExportMyData downloads everything stored about the portal user's client. Query: format=json|zip
+func (h *FeaturesHandler) ExportMyData(c *fiber.Ctx) error {
+	user, ok := c.Locals("user").(*portalDomain.PortalUser)
+	if !ok || user == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	subject, err := h.privacyService.SubjectForClient(c.Context(), user.ClientID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to load account data"})
+	}
+	return h.sendDataExport(c, subject, c.Query("format", "zip"))
+}
+
+// DownloadDataExport serves the export behind a one-time link sent to the user's chat (no portal session needed)
+func (h *FeaturesHandler) DownloadDataExport(c *fiber.Ctx) error {
+	subject, err := h.privacyService.RedeemExportToken(c.Context(), c.Params("token"))
+	if err != nil {
+		if errors.Is(err, clientsDomain.ErrInvalidExportToken) || errors.Is(err, clientsDomain.ErrClientNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "This download link is invalid or has expired"})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to prepare the download"})
+	}
+	return h.sendDataExport(c, subject, c.Query("format", "zip"))
+}
+
+// EraseMyData erases (or anonymizes) the client's data. Only the account owner can do it.
+func (h *FeaturesHandler) EraseMyData(c *fiber.Ctx) error {
+	user, ok := c.Locals("user").(*portalDomain.PortalUser)
+	if !ok || user == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+	if user.Role != portalDomain.RoleOwner {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Only the account owner can erase its data"})
+	}
+
+	var req struct {
+		Mode    string `json:"mode"`
+		Confirm string `json:"confirm"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request"})
+	}
+	if req.Confirm != eraseConfirmation {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": fmt.Sprintf("type %q to confirm: this cannot be undone", eraseConfirmation)})
+	}
+	if req.Mode == "" {
+		req.Mode = string(clientsDomain.ErasureDelete)
+	}
+
+	subject, err := h.privacyService.SubjectForClient(c.Context(), user.ClientID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to load account data"})
+	}
+	tombstone, err := h.privacyService.Erase(c.Context(), subject, clientsDomain.ErasureMode(req.Mode), "portal")
+	if err != nil {
+		if tombstone == nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Erasure finished with errors", "reference": tombstone.ID})
+	}
+	return c.JSON(fiber.Map{
+		"reference": tombstone.ID,
+		"verified":  tombstone.Verified,
+		"mode":      tombstone.Mode,
+	})
+}
+
+func (h *FeaturesHandler) sendDataExport(c *fiber.Ctx, subject *clientsApp.DataSubject, format string) error {
+	if format != "json" && format != "zip" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "format must be json or zip"})
+	}
+	export, err := h.privacyService.Export(c.Context(), subject)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to export data"})
+	}
+
+	name := "my-data-" + time.Now().Format("20060102")
+	if format == "json" {
+		c.Attachment(name + ".json")
+		return c.JSON(export)
+	}
+	c.Set(fiber.HeaderContentType, "application/zip")
+	c.Attachment(name + ".zip")
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		if err := clientsApp.WriteExportZip(w, export); err != nil {
+			logrus.WithError(err).Error("[PORTAL] Failed to write data export archive")
+		}
+		_ = w.Flush()
+	})
+	return nil
+}
@@ -1,6 +1,7 @@
 package http
 
 import (
+	authDomain "github.com/AzielCF/az-wap/clients_portal/auth/domain"
 	authInfra "github.com/AzielCF/az-wap/clients_portal/auth/infrastructure"
 	featuresInfra "github.com/AzielCF/az-wap/clients_portal/features/infrastructure"
 	coreConfig "github.com/AzielCF/az-wap/core/config"
@@ -12,10 +13,14 @@ import (
 // 1. Internal/Admin routes (via baseAPI, protected by system BasicAuth)
 // 2. Public Portal routes (via app, separated from system auth)
 // 3. Protected Portal routes (via middleware)
+//
+// Roles inside an account: MEMBER is read-only, MANAGER also operates channels
+// and workspace guests, OWNER also manages the team, workspaces and personal data.
 func RegisterPortalRoutes(
 	app fiber.Router,
 	baseAPI fiber.Router,
 	authHandler *authInfra.AuthHandler,
+	teamHandler *authInfra.TeamHandler,
 	featuresHandler *featuresInfra.FeaturesHandler,
 	portalAuthMiddleware fiber.Handler,
 ) {
@@ -24,6 +29,7 @@ func RegisterPortalRoutes(
 	baseAPI.Post("/internal/clients/:id/portal-account", authHandler.CreatePortalAccount)
 	baseAPI.Get("/internal/portal-accounts", authHandler.ListAccountsState)
 	baseAPI.Post("/internal/magic-link/generate", authHandler.GenerateMagicLink)
+	baseAPI.Post("/internal/identity-link/generate", featuresHandler.GenerateIdentityLink)
 
 	// 2. Public Portal Routes (/api/portal)
 	// Created directly on app router to manage middleware stack independently
@@ -31,14 +37,36 @@ func RegisterPortalRoutes(
 
 	portalGroup.Post("/login", authHandler.Login)
 	portalGroup.Post("/magic-link/redeem", authHandler.RedeemMagicLink)
+	portalGroup.Get("/data-export/:token", featuresHandler.DownloadDataExport)
+	portalGroup.Get("/invites/:token", teamHandler.PreviewInvite)
+	portalGroup.Post("/invites/accept", teamHandler.AcceptInvite)
 
 	// 3. Protected Portal Routes (Require valid Portal Token)
 	protected := portalGroup.Group("", portalAuthMiddleware)
+	manager := authInfra.RequireRole(authDomain.RoleManager)
+	owner := authInfra.RequireRole(authDomain.RoleOwner)
 
 	// Auth Module Routes
 	protected.Get("/me", authHandler.Me)
 	protected.Put("/profile", authHandler.UpdateProfile)
 
+	// Team Management (invitations and roles)
+	protected.Get("/team", teamHandler.ListTeam)
+	protected.Post("/team/invites", owner, teamHandler.Invite)
+	protected.Delete("/team/invites/:iid", owner, teamHandler.RevokeInvite)
+	protected.Put("/team/members/:uid/role", owner, teamHandler.ChangeRole)
+	protected.Delete("/team/members/:uid", owner, teamHandler.RemoveMember)
+
+	// Linked platform accounts (same client on WhatsApp, Telegram...)
+	protected.Get("/identities", featuresHandler.ListIdentities)
+	protected.Post("/identities/link-code", owner, featuresHandler.CreateIdentityLinkCode)
+	protected.Post("/identities/claim", owner, featuresHandler.ClaimIdentityLink)
+	protected.Delete("/identities/:iid", owner, featuresHandler.UnlinkIdentity)
+
+	// Personal data (GDPR access and erasure)
+	protected.Get("/privacy/export", owner, featuresHandler.ExportMyData)
+	protected.Post("/privacy/erase", owner, featuresHandler.EraseMyData)
+
 	// Features Module Routes
 	protected.Get("/reminders", featuresHandler.ListReminders)
 	protected.Get("/info", featuresHandler.GetGeneralInfo)
@@ -47,21 +75,21 @@ func RegisterPortalRoutes(
 
 	// Access Rules for Channels
 	protected.Get("/owned-channels/:cid/access-rules", featuresHandler.GetChannelAccessRules)
-	protected.Post("/owned-channels/:cid/access-rules", featuresHandler.AddChannelAccessRule)
-	protected.Delete("/owned-channels/:cid/access-rules/:rid", featuresHandler.DeleteChannelAccessRule)
+	protected.Post("/owned-channels/:cid/access-rules", manager, featuresHandler.AddChannelAccessRule)
+	protected.Delete("/owned-channels/:cid/access-rules/:rid", manager, featuresHandler.DeleteChannelAccessRule)
 	protected.Get("/owned-channels/:cid/resolve-identity", featuresHandler.ResolveIdentity)
 
 	// Edit Channel
-	protected.Put("/owned-channels/:cid/name", featuresHandler.UpdateChannelName)
+	protected.Put("/owned-channels/:cid/name", manager, featuresHandler.UpdateChannelName)
 
 	// WhatsApp Channel Controls
 	protected.Get("/owned-channels/:cid/whatsapp/status", featuresHandler.GetWhatsAppStatus)
-	protected.Get("/owned-channels/:cid/whatsapp/login", featuresHandler.WhatsAppLogin)
-	protected.Post("/owned-channels/:cid/whatsapp/login-code", featuresHandler.WhatsAppLoginWithCode)
-	protected.Get("/owned-channels/:cid/whatsapp/logout", featuresHandler.WhatsAppLogout)
+	protected.Get("/owned-channels/:cid/whatsapp/login", manager, featuresHandler.WhatsAppLogin)
+	protected.Post("/owned-channels/:cid/whatsapp/login-code", manager, featuresHandler.WhatsAppLoginWithCode)
+	protected.Get("/owned-channels/:cid/whatsapp/logout", manager, featuresHandler.WhatsAppLogout)
 
-	protected.Post("/owned-channels/:cid/enable", featuresHandler.EnableChannel)
-	protected.Post("/owned-channels/:cid/disable", featuresHandler.DisableChannel)
+	protected.Post("/owned-channels/:cid/enable", manager, featuresHandler.EnableChannel)
+	protected.Post("/owned-channels/:cid/disable", manager, featuresHandler.DisableChannel)
 
 	// Workspace Management (ABAC restricted)
 	protected.Get("/workspaces", featuresHandler.ListWorkspaces)
@@ -69,16 +97,16 @@ func RegisterPortalRoutes(
 	protected.Get("/workspaces/:wid/guests", featuresHandler.ListGuests)
 
 	wsGroup := protected.Group("/workspaces", featuresHandler.EnforceWorkspaceFeature)
-	wsGroup.Post("", featuresHandler.CreateWorkspace)
-	wsGroup.Put("/:wid", featuresHandler.UpdateWorkspace)
-	wsGroup.Delete("/:wid", featuresHandler.DeleteWorkspace)
+	wsGroup.Post("", owner, featuresHandler.CreateWorkspace)
+	wsGroup.Put("/:wid", owner, featuresHandler.UpdateWorkspace)
+	wsGroup.Delete("/:wid", owner, featuresHandler.DeleteWorkspace)
 
 	// Workspace Channels (Write Actions)
-	wsGroup.Post("/:wid/channels/:cid", featuresHandler.LinkChannel)
-	wsGroup.Delete("/:wid/channels/:cid", featuresHandler.UnlinkChannel)
+	wsGroup.Post("/:wid/channels/:cid", owner, featuresHandler.LinkChannel)
+	wsGroup.Delete("/:wid/channels/:cid", owner, featuresHandler.UnlinkChannel)
 
 	// Workspace Guests (Write Actions)
-	wsGroup.Post("/:wid/guests", featuresHandler.CreateGuest)
-	wsGroup.Put("/:wid/guests/:gid", featuresHandler.UpdateGuest)
-	wsGroup.Delete("/:wid/guests/:gid", featuresHandler.DeleteGuest)
+	wsGroup.Post("/:wid/guests", manager, featuresHandler.CreateGuest)
+	wsGroup.Put("/:wid/guests/:gid", manager, featuresHandler.UpdateGuest)
+	wsGroup.Delete("/:wid/guests/:gid", manager, featuresHandler.DeleteGuest)
 }
@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	coreconfig "github.com/AzielCF/az-wap/core/config"
+	whatsappinfra "github.com/AzielCF/az-wap/workspace/infrastructure/whatsapp"
+	"github.com/spf13/cobra"
+)
+
+// migrateDevicesCmd copia las sesiones whatsmeow de los SQLite por canal al Postgres de DB_KEYS_URI.
+var migrateDevicesCmd = &cobra.Command{
+	Use:   "migrate-devices",
+	Short: "Migrate WhatsApp device keys from per-channel SQLite files to Postgres",
+	Long: `Copy every whatsapp-<channel>.db found in the storages directory into the
+shared Postgres device store configured with DB_KEYS_URI. Channels that already
+have a device in Postgres are skipped, so the command can be run more than once.`,
+	Run: runMigrateDevices,
+}
+
+func init() {
+	migrateDevicesCmd.Flags().String("dir", "", "Directory with whatsapp-<channel>.db files (default: storages path)")
+	migrateDevicesCmd.Flags().Bool("dry-run", false, "Only list the devices that would be migrated")
+	migrateDevicesCmd.Flags().Bool("archive", false, "Rename migrated SQLite files to *.migrated")
+	migrateDevicesCmd.Flags().Bool("json", false, "Print the result as JSON")
+	rootCmd.AddCommand(migrateDevicesCmd)
+}
+
+func runMigrateDevices(cmd *cobra.Command, _ []string) {
+	dir, _ := cmd.Flags().GetString("dir")
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	archive, _ := cmd.Flags().GetBool("archive")
+	asJSON, _ := cmd.Flags().GetBool("json")
+
+	if dir == "" {
+		dir = coreconfig.Global.Paths.Storages
+	}
+	dst, ok := whatsappinfra.Devices().(*whatsappinfra.PostgresDeviceStore)
+	if !ok {
+		fmt.Fprintln(os.Stderr, "migrate-devices: DB_KEYS_URI must point to Postgres (postgres://...)")
+		os.Exit(2)
+	}
+
+	results, err := whatsappinfra.MigrateSQLiteDevices(context.Background(), dir, dst, whatsappinfra.MigrateOptions{
+		DryRun:  dryRun,
+		Archive: archive,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "migrate-devices: %v\n", err)
+		os.Exit(2)
+	}
+
+	failed := 0
+	for _, r := range results {
+		if r.Error != "" {
+			failed++
+		}
+	}
+
+	if asJSON {
+		data, _ := json.MarshalIndent(results, "", "  ")
+		fmt.Println(string(data))
+	} else {
+		for _, r := range results {
+			switch {
+			case r.Error != "":
+				fmt.Printf("FAIL  %s  %s\n", r.ChannelID, r.Error)
+			case r.Skipped != "":
+				fmt.Printf("SKIP  %s  %s %s\n", r.ChannelID, r.Skipped, r.JID)
+			default:
+				fmt.Printf("OK    %s  %s (%d rows)\n", r.ChannelID, r.JID, r.Rows)
+			}
+		}
+		fmt.Printf("\n%d files, %d failed\n", len(results), failed)
+	}
+
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
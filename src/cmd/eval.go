@@ -0,0 +1,147 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	domainEval "github.com/AzielCF/az-wap/botengine/domain/eval"
+	"github.com/google/uuid"
+	"github.com/spf13/cobra"
+)
+
+// evalCmd ejecuta una suite de evaluación desde la terminal (CI). Sale con código 1 si algún caso falla.
+var evalCmd = &cobra.Command{
+	Use:   "eval",
+	Short: "Run a bot evaluation suite against the engine",
+	Long: `Run a stored suite (--suite) or a JSON suite file (--file) against a bot.
+Use --mode record to capture provider and tool responses, and --mode replay
+to run deterministically from the recording (no network, no cost).`,
+	Run: runEval,
+}
+
+func init() {
+	evalCmd.Flags().String("suite", "", "ID of a stored eval suite")
+	evalCmd.Flags().String("file", "", "Path to a JSON suite file (record mode writes the recording back)")
+	evalCmd.Flags().String("bot", "", "Bot ID (overrides the suite's bot)")
+	evalCmd.Flags().String("variant", "", "Variant ID (overrides the suite's variant)")
+	evalCmd.Flags().String("mode", "live", "live, record or replay")
+	evalCmd.Flags().Bool("json", false, "Print the run as JSON")
+	rootCmd.AddCommand(evalCmd)
+}
+
+func runEval(cmd *cobra.Command, _ []string) {
+	suiteID, _ := cmd.Flags().GetString("suite")
+	file, _ := cmd.Flags().GetString("file")
+	botID, _ := cmd.Flags().GetString("bot")
+	variantID, _ := cmd.Flags().GetString("variant")
+	modeFlag, _ := cmd.Flags().GetString("mode")
+	asJSON, _ := cmd.Flags().GetBool("json")
+
+	mode, err := domainEval.ParseMode(modeFlag)
+	if err != nil {
+		exitEval(err)
+	}
+	if (suiteID == "") == (file == "") {
+		exitEval(fmt.Errorf("use exactly one of --suite or --file"))
+	}
+
+	ctx := context.Background()
+	var suite domainEval.Suite
+	if suiteID != "" {
+		if suite, err = evalRepo.GetSuite(ctx, suiteID); err != nil {
+			exitEval(err)
+		}
+	} else {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			exitEval(err)
+		}
+		if err := json.Unmarshal(data, &suite); err != nil {
+			exitEval(fmt.Errorf("invalid suite file %s: %w", file, err))
+		}
+		if suite.ID == "" {
+			suite.ID = uuid.NewString()
+		}
+	}
+	if botID != "" {
+		suite.BotID = botID
+	}
+	if suite.BotID == "" {
+		exitEval(fmt.Errorf("the suite has no bot: use --bot"))
+	}
+
+	run, recording, err := botEngine.RunEvalSuite(ctx, suite, domainEval.RunOptions{Mode: mode, VariantID: variantID})
+	if err != nil {
+		exitEval(err)
+	}
+
+	if len(recording) > 0 {
+		if suite.Recording == nil {
+			suite.Recording = domainEval.Recording{}
+		}
+		for name, tape := range recording {
+			suite.Recording[name] = tape
+		}
+		suite.UpdatedAt = time.Now()
+		if file != "" {
+			data, _ := json.MarshalIndent(suite, "", "  ")
+			if err := os.WriteFile(file, data, 0644); err != nil {
+				exitEval(err)
+			}
+		} else if err := evalRepo.UpdateSuite(ctx, suite); err != nil {
+			exitEval(err)
+		}
+	}
+	// Las suites guardadas conservan su historial de ejecuciones
+	if suiteID != "" {
+		if err := evalRepo.SaveRun(ctx, run); err != nil {
+			exitEval(err)
+		}
+	}
+
+	if asJSON {
+		data, _ := json.MarshalIndent(run, "", "  ")
+		fmt.Println(string(data))
+	} else {
+		printEvalRun(suite, run)
+	}
+	if !run.OK() {
+		os.Exit(1)
+	}
+}
+
+func printEvalRun(suite domainEval.Suite, run domainEval.Run) {
+	fmt.Printf("Suite %q (%s) — bot %s", suite.Name, run.Mode, run.BotID)
+	if run.VariantID != "" {
+		fmt.Printf(", variant %s", run.VariantID)
+	}
+	fmt.Println()
+	for _, c := range run.Cases {
+		status := "PASS"
+		if !c.Passed {
+			status = "FAIL"
+		}
+		fmt.Printf("  [%s] %s\n", status, c.Name)
+		for i, t := range c.Turns {
+			fmt.Printf("      turn %d: %dms $%.6f tools=%v\n", i+1, t.LatencyMs, t.CostUSD, t.Tools)
+			for _, f := range t.Failures {
+				fmt.Printf("        - %s\n", f)
+			}
+			for _, s := range t.Skipped {
+				fmt.Printf("        ~ skipped %s\n", s)
+			}
+		}
+		if c.Error != "" && len(c.Turns) == 0 {
+			fmt.Printf("        - %s\n", c.Error)
+		}
+	}
+	fmt.Printf("%d passed, %d failed — $%.6f in %dms\n", run.Passed, run.Failed, run.TotalCost, run.DurationMs)
+}
+
+func exitEval(err error) {
+	fmt.Fprintf(os.Stderr, "eval: %v\n", err)
+	os.Exit(2)
+}
@@ -52,6 +52,7 @@ import (
 	domainCredential "github.com/AzielCF/az-wap/core/common/credential/domain"
 	domainHealth "github.com/AzielCF/az-wap/core/common/health/domain"
 	"github.com/AzielCF/az-wap/core/infrastructure/valkey"
+	"github.com/AzielCF/az-wap/core/pkg/msgworker"
 
 	botmonitor "github.com/AzielCF/az-wap/botengine/infrastructure/monitoring"
 	botengineInfra "github.com/AzielCF/az-wap/botengine/infrastructure/rest"
@@ -73,6 +74,9 @@ import (
 	credentialInfra "github.com/AzielCF/az-wap/core/common/credential/infrastructure"
 	healthApp "github.com/AzielCF/az-wap/core/common/health/application"
 	healthInfra "github.com/AzielCF/az-wap/core/common/health/infrastructure"
+	mcpServerApp "github.com/AzielCF/az-wap/core/common/mcpserver/application"
+	domainMCPServer "github.com/AzielCF/az-wap/core/common/mcpserver/domain"
+	mcpServerInfra "github.com/AzielCF/az-wap/core/common/mcpserver/infrastructure"
 	"github.com/AzielCF/az-wap/core/pkg/utils"
 	"github.com/AzielCF/az-wap/workspace"
 	"github.com/AzielCF/az-wap/workspace/domain/channel"
@@ -81,6 +85,7 @@ import (
 	workspaceInfra "github.com/AzielCF/az-wap/workspace/infrastructure/rest"
 	"github.com/AzielCF/az-wap/workspace/infrastructure/simulator"
 	"github.com/AzielCF/az-wap/workspace/infrastructure/telegram"
+	whatsappinfra "github.com/AzielCF/az-wap/workspace/infrastructure/whatsapp"
 	whatsappadapter "github.com/AzielCF/az-wap/workspace/infrastructure/whatsapp/adapter"
 	"github.com/AzielCF/az-wap/workspace/repository"
 	workspaceUsecaseLayer "github.com/AzielCF/az-wap/workspace/usecase"
@@ -141,22 +146,33 @@ var (
 	mcpUsecase    domainMCP.IMCPUsecase
 	healthUsecase domainHealth.IHealthUsecase
 
+	// MCP Server (az-wap exposed to external agents)
+	mcpTokenUsecase domainMCPServer.ITokenUsecase
+	mcpServer       *mcpServerApp.Server
+
 	// Bot Engine
 	botEngine *botengine.Engine
+	evalRepo  *botengineRepo.EvalGormRepository
 
 	// Workspace
-	wkRepo            repository.IWorkspaceRepository
+	wkRepo            *repository.WorkspaceGormRepository
 	workspaceManager  *workspace.Manager
 	wkUsecase         *workspaceUsecaseLayer.WorkspaceUsecase
+	bundleService     *workspaceUsecaseLayer.ChannelBundleService
 	typingStore       channel.TypingStore
 	monitorStore      monitoring.MonitoringStore
 	contextCacheStore botengineDomain.ContextCacheStore
 
 	// Clients Module
-	clientService  *clientsApp.ClientService
-	subService     *clientsApp.SubscriptionService
-	clientResolver *clientsApp.ClientResolver
-	clientHandler  *clientsRest.ClientHandler
+	clientService    *clientsApp.ClientService
+	subService       *clientsApp.SubscriptionService
+	identityService  *clientsApp.IdentityService
+	fieldService     *clientsApp.FieldService
+	bulkService      *clientsApp.BulkService
+	privacyService   *clientsApp.PrivacyService
+	lifecycleService *clientsApp.LifecycleService
+	clientResolver   *clientsApp.ClientResolver
+	clientHandler    *clientsRest.ClientHandler
 
 	// Shared Infra
 	vkClient *valkey.Client
@@ -167,6 +183,7 @@ var (
 
 	// Portal
 	portalAuthService *portalAuthApp.AuthService
+	portalTeamService *portalAuthApp.TeamService
 )
 
 // rootCmd represents the base command when called without any subcommands
@@ -193,6 +210,8 @@ func runServer(cmd *cobra.Command, _ []string) {
 		coreconfig.Global.App.Port = portFlag
 	}
 
+	startBackgroundServices(context.Background())
+
 	fiberConfig := fiber.Config{
 		EnableTrustedProxyCheck: true,
 		BodyLimit:               int(coreconfig.Global.Whatsapp.MaxVideoSize),
@@ -275,6 +294,9 @@ func runServer(cmd *cobra.Command, _ []string) {
 			if strings.HasPrefix(c.Path(), coreconfig.Global.App.BasePath+"/api/ws") {
 				return true // bypass basic auth for WS, they must authenticate via query token or logic
 			}
+			if c.Path() == coreconfig.Global.App.BasePath+"/api"+clientsRest.PaymentCallbackPath {
+				return true // payment provider callback, authenticated by its HMAC signature
+			}
 			return false
 		},
 	}))
@@ -303,18 +325,26 @@ func runServer(cmd *cobra.Command, _ []string) {
 	credentialInfra.InitRestCredential(apiGroup, credentialUsecase)
 	cacheInfra.InitRestCache(apiGroup, cacheUsecase)
 	botengineInfra.InitRestMCP(apiGroup, mcpUsecase)
+	botengineInfra.InitRestApproval(apiGroup)
+	botengineInfra.InitRestEval(apiGroup, evalRepo)
+	mcpServerInfra.InitRestMCPServer(apiGroup, mcpTokenUsecase)
 	healthInfra.InitRestHealth(apiGroup, healthUsecase)
 	wkHandler := workspaceInfra.InitRestWorkspace(apiGroup, wkUsecase, workspaceManager, appUsecase)
 	app.Post("/api/v1/telegram/webhook/:cid", wkHandler.HandleTelegramWebhook)
+	workspaceInfra.InitRestCluster(apiGroup, workspaceManager)
+	workspaceInfra.InitRestChannelBundle(apiGroup, bundleService, wkUsecase)
 	botengineInfra.InitRestMonitoring(apiGroup, monitorStore, workspaceManager, contextCacheStore)
 	simulator.InitRestSimulator(apiGroup, botEngine, wkRepo)
 
 	portalAuthHandler := portalAuthInfra.NewAuthHandler(portalAuthService)
-	portalFeaturesHandler := portalFeatures.NewFeaturesHandler(subService, clientService, newsletterUsecase, wkRepo, botUsecase, wkUsecase, workspaceManager)
+	portalTeamHandler := portalAuthInfra.NewTeamHandler(portalTeamService)
+	portalFeaturesHandler := portalFeatures.NewFeaturesHandler(subService, clientService, identityService, privacyService, newsletterUsecase, wkRepo, botUsecase, wkUsecase, workspaceManager)
 	portalAuthMiddleware := portalAuthInfra.NewAuthMiddleware(portalAuthRepo.NewGormAuthRepository(coreDB.GlobalDB))
 
-	clientPortalHTTP.RegisterPortalRoutes(app, apiGroup, portalAuthHandler, portalFeaturesHandler, portalAuthMiddleware)
+	clientPortalHTTP.RegisterPortalRoutes(app, apiGroup, portalAuthHandler, portalTeamHandler, portalFeaturesHandler, portalAuthMiddleware)
 	clientHandler.RegisterRoutes(apiGroup)
+	clientsRest.NewPrivacyHandler(privacyService).RegisterRoutes(apiGroup)
+	clientsRest.NewLifecycleHandler(lifecycleService, subService, coreconfig.Global.Billing.PaymentSecret).RegisterRoutes(apiGroup)
 
 	websocket.SetValkeyClient(vkClient, serverID)
 	websocket.RegisterRoutes(apiGroup, appUsecase)
@@ -354,6 +384,14 @@ func runServer(cmd *cobra.Command, _ []string) {
 		return c.Send(file)
 	})
 
+	if mcpServer != nil {
+		go func() {
+			if err := mcpServer.Start(coreconfig.Global.MCP.Host, coreconfig.Global.MCP.Port); err != nil {
+				logrus.Errorf("[MCP_SERVER] Failed to start: %v", err)
+			}
+		}()
+	}
+
 	if err := app.Listen(":" + coreconfig.Global.App.Port); err != nil {
 		logrus.Fatalf("[APP] Failed to start server: %v", err)
 	}
@@ -459,6 +497,10 @@ func initApp() {
 	cacheUsecase = cacheApp.NewCacheService(settingsSvc)
 	cacheUsecase.StartBackgroundCleanup(ctx)
 	mcpUsecase = botUsecaseLayer.NewMCPService(gormDB)
+	evalRepo = botengineRepo.NewEvalGormRepository(gormDB)
+	if err := evalRepo.Init(ctx); err != nil {
+		logrus.Fatalf("failed to init eval repo: %v", err)
+	}
 
 	// 2. Bot Engine Initialization (Needs BotUsecase, MCPUsecase)
 	httpFetcher := botInfrastructure.NewStandardHTTPFetcher()
@@ -497,6 +539,12 @@ func initApp() {
 	botEngine.RegisterProvider(string(domainBot.ProviderOpenAI), openaiProvider)
 	// botEngine.RegisterProvider(string(domainBot.ProviderClaude), geminiProvider)
 
+	// Motores TTS para respuestas con nota de voz ("local" genera silencio, útil en pruebas)
+	botEngine.RegisterSynthesizer(string(domainBot.ProviderAI), geminiProvider)
+	botEngine.RegisterSynthesizer(string(domainBot.ProviderGemini), geminiProvider)
+	botEngine.RegisterSynthesizer(string(domainBot.ProviderOpenAI), openaiProvider)
+	botEngine.RegisterSynthesizer("local", providers.NewLocalSynthesizer())
+
 	// 2.1 Clients Module Initialization (Using appDB from Core)
 
 	// Client Repository (app.db)
@@ -505,12 +553,30 @@ func initApp() {
 		logrus.Fatalf("failed to init client repo: %v", err)
 	}
 
+	// Client Identities (app.db): many platform accounts for one client
+	identityRepo := clientsRepo.NewIdentityGormRepository(gormDB)
+	if err := identityRepo.InitSchema(ctx); err != nil {
+		logrus.Fatalf("failed to init client identity repo: %v", err)
+	}
+
 	// Subscription Repository (Uses the separate workspaceDB)
 	subRepo := clientsRepo.NewSubscriptionGormRepository(wkGormDB)
 	if err := subRepo.InitSchema(ctx); err != nil {
 		logrus.Fatalf("failed to init subscription repo: %v", err)
 	}
 
+	// Custom field schema per workspace (workspaceDB)
+	fieldRepo := clientsRepo.NewFieldGormRepository(wkGormDB)
+	if err := fieldRepo.InitSchema(ctx); err != nil {
+		logrus.Fatalf("failed to init client field repo: %v", err)
+	}
+
+	// Data-subject erasure records (app.db)
+	tombstoneRepo := clientsRepo.NewTombstoneGormRepository(gormDB)
+	if err := tombstoneRepo.InitSchema(ctx); err != nil {
+		logrus.Fatalf("failed to init tombstone repo: %v", err)
+	}
+
 	// 2.2 Clients Portal Module Initialization
 	kvstore.Init(vkClient)
 
@@ -518,14 +584,28 @@ func initApp() {
 	if err := portalAuthRepoInst.AutoMigrate(); err != nil {
 		logrus.Fatalf("[PORTAL] Failed to migrate portal auth table: %v", err)
 	}
+	// Cuentas anteriores a los roles de equipo: su primer usuario pasa a ser OWNER
+	if promoted, err := portalAuthRepoInst.EnsureOwners(ctx); err != nil {
+		logrus.Errorf("[PORTAL] Failed to assign account owners: %v", err)
+	} else if promoted > 0 {
+		logrus.Infof("[PORTAL] Promoted %d portal users to OWNER", promoted)
+	}
+	portalInviteRepo := portalAuthRepo.NewGormInviteRepository(gormDB)
+	if err := portalInviteRepo.AutoMigrate(); err != nil {
+		logrus.Fatalf("[PORTAL] Failed to migrate portal invites table: %v", err)
+	}
 	portalAuthService = portalAuthApp.NewAuthService(portalAuthRepoInst, clientRepo, wkRepo, kvstore.Global)
+	portalTeamService = portalAuthApp.NewTeamService(portalAuthRepoInst, portalInviteRepo, clientRepo, wkRepo)
 
 	// Client Services
-	clientService = clientsApp.NewClientService(clientRepo, subRepo)
+	clientService = clientsApp.NewClientService(clientRepo, subRepo, identityRepo)
 	subService = clientsApp.NewSubscriptionService(subRepo, clientRepo)
+	identityService = clientsApp.NewIdentityService(identityRepo, clientRepo, subRepo, kvstore.Global)
+	fieldService = clientsApp.NewFieldService(fieldRepo, subRepo, wkRepo)
+	bulkService = clientsApp.NewBulkService(clientService, clientRepo, identityRepo, fieldService)
 
 	// Client Resolver (for runtime context resolution)
-	clientResolver = clientsApp.NewClientResolver(clientRepo, subRepo, wkRepo)
+	clientResolver = clientsApp.NewClientResolver(clientRepo, subRepo, identityRepo, wkRepo)
 
 	logrus.Info("[CLIENTS] Client module initialized successfully")
 
@@ -541,8 +621,28 @@ func initApp() {
 		logrus.Info("[STARTUP] Using in-memory stores for Monitoring and Typing")
 	}
 
+	// Message job queue: Valkey Streams survive restarts and are shared by all nodes
+	if shared, pinned := newMessageJobQueues(ctx); shared != nil {
+		msgworker.ConfigureGlobalPool(shared, pinned, serverID)
+	}
+
 	// 4. Workspace Manager (Needs wkRepo, BotEngine, ClientResolver, stores, serverID)
 	workspaceManager = workspace.NewManager(wkRepo, botEngine, clientResolver, typingStore, monitorStore, vkClient, serverID)
+	workspaceManager.SetIdentityLinker(identityService)
+
+	// Data-subject requests (GDPR): every store holding personal data registers as a source
+	privacyService = clientsApp.NewPrivacyService(clientService, clientRepo, identityRepo, subRepo, tombstoneRepo, kvstore.Global)
+	privacyService.RegisterSource(clientsApp.NewPortalUserSource(portalAuthRepoInst))
+	privacyService.RegisterSource(clientsApp.NewWorkspaceDataSource(wkRepo))
+	privacyService.RegisterSource(clientsApp.NewSessionDataSource(workspaceManager))
+	privacyService.RegisterSource(clientsApp.NewChatwootDataSource(wkRepo, chatwoot.ContactDirectory{}))
+	privacyService.RegisterSource(clientsApp.NewMonitorDataSource(botmonitor.ChatEvents{}))
+
+	// Subscription lifecycle: expiry notices, grace periods and renewals (per-channel policy)
+	lifecycleService = clientsApp.NewLifecycleService(subRepo, clientRepo, identityRepo, wkRepo)
+	lifecycleService.SetMessenger(workspaceManager)
+	portalTeamService.SetSender(workspaceManager)
+	lifecycleService.SetWebhook(coreconfig.Global.Billing.WebhookURL, coreconfig.Global.Billing.WebhookSecret)
 
 	// 5. Connect Bot Monitor to Cluster Stats
 	botmonitor.OnIncrement = func(key string) {
@@ -554,15 +654,21 @@ func initApp() {
 	appUsecase = appApp.NewAppService(workspaceManager, settingsSvc)
 	userUsecase = userApp.NewUserService(workspaceManager)
 	groupUsecase = groupApp.NewGroupService(workspaceManager)
-	newsletterUsecase = newsletterApp.NewNewsletterService(workspaceManager, wkRepo, subRepo, monitorStore, vkClient)
+	newsletterUsecase = newsletterApp.NewNewsletterService(workspaceManager, wkRepo, subRepo, vkClient)
 	sendUsecase = sendApp.NewSendService(appUsecase, workspaceManager)
 	messageUsecase = messageApp.NewMessageService(workspaceManager)
 	wkUsecase = workspaceUsecaseLayer.NewWorkspaceUsecase(wkRepo, workspaceManager)
+	bundleService = workspaceUsecaseLayer.NewChannelBundleService(wkRepo, workspaceManager, clientRepo, subRepo)
 
 	// Client REST Handler (Admin)
-	clientHandler = clientsRest.NewClientHandler(clientService, subService, wkRepo, wkUsecase)
+	clientHandler = clientsRest.NewClientHandler(clientService, subService, identityService, bulkService, fieldService, wkRepo, wkUsecase)
+
+	// 5. WhatsApp Device Store (SQLite per channel, or shared Postgres via DB_KEYS_URI)
+	if _, err := whatsappinfra.InitDeviceStore(ctx); err != nil {
+		logrus.Fatalf("[WHATSAPP] Failed to init device store: %v", err)
+	}
 
-	// 5. WhatsApp Adapter Factory
+	// 5.1 WhatsApp Adapter Factory
 	workspaceManager.RegisterFactory(channel.ChannelTypeWhatsApp, func(conf channel.ChannelConfig) (channel.ChannelAdapter, error) {
 		channelID, _ := conf.Settings["channel_id"].(string)
 		workspaceID, _ := conf.Settings["workspace_id"].(string)
@@ -583,12 +689,37 @@ func initApp() {
 		return telegram.NewAdapter(channelID, workspaceID, token, workspaceManager), nil
 	})
 
+	// MCP Server tokens & transport
+	mcpTokenUsecase = mcpServerApp.NewTokenService(gormDB)
+	if coreconfig.Global.MCP.ServerEnabled {
+		mcpServer = mcpServerApp.NewServer(mcpServerApp.Deps{
+			Tokens:        mcpTokenUsecase,
+			Workspaces:    wkRepo,
+			Runtime:       workspaceManager,
+			Newsletter:    newsletterUsecase,
+			Clients:       clientService,
+			Subscriptions: subService,
+		}, coreconfig.Global.App.Version)
+	}
+
 	// 6. Post-initialization
 	healthUsecase = healthApp.NewHealthService(mcpUsecase, credentialUsecase, botUsecase, workspaceManager, wkUsecase, vkClient)
 	mcpUsecase.SetHealthUsecase(healthUsecase)
 	healthUsecase.StartPeriodicChecks(ctx)
 	botengineInfra.SetBotEngine(botEngine, workspaceManager)
 
+	// Aprobaciones de herramientas: avisar al panel y caducar las pendientes
+	botEngine.Approvals().OnEvent(func(event string, a botengineDomain.PendingApproval) {
+		go func() {
+			websocket.Broadcast <- websocket.BroadcastMessage{
+				Code:    event,
+				Message: fmt.Sprintf("Tool %s is %s", a.ToolCall.Name, a.Status),
+				Result:  a,
+			}
+		}()
+	})
+	go botEngine.Approvals().StartExpiryLoop(ctx)
+
 	// Initialize Integrations
 	chatwoot.SetRepositories(wkRepo, gormDB)
 
@@ -609,6 +740,13 @@ func initApp() {
 			if err := wkRepo.AddChannelComplexCost(ctx, actualInstanceID, output.TotalCost, breakdown); err != nil {
 				logrus.WithError(err).Error("[ENGINE] Failed to accumulate channel cost")
 			}
+
+			// Las tareas proactivas se cargan además al cliente dueño de la tarea
+			if clientID, _ := input.Metadata["billing_client_id"].(string); clientID != "" {
+				if err := clientRepo.AddCost(ctx, clientID, output.TotalCost); err != nil {
+					logrus.WithError(err).Error("[ENGINE] Failed to charge cost to client")
+				}
+			}
 		}
 
 		// 2. Notificaciones Chatwoot (Dependiente de phone)
@@ -665,6 +803,12 @@ func initApp() {
 		Handler: remoteURLTool.Handler,
 	})
 
+	mcpResourceTool := botTools.NewReadMCPResourceTool(mcpUsecase)
+	botEngine.RegisterNativeTool(&domain.NativeTool{
+		Tool:    mcpResourceTool.Tool,
+		Handler: mcpResourceTool.Handler,
+	})
+
 	// Register Newsletter Tools
 	nTools := onlyClients.NewNewsletterTools(newsletterUsecase, workspaceManager)
 	botEngine.RegisterNativeTool(nTools.ListNewslettersTool())
@@ -676,50 +820,98 @@ func initApp() {
 	// Register Reminder Tools
 	rTools := onlyClients.NewReminderTools(newsletterUsecase)
 	botEngine.RegisterNativeTool(rTools.ScheduleReminderTool())
+	botEngine.RegisterNativeTool(rTools.ScheduleAITaskTool())
 	botEngine.RegisterNativeTool(rTools.ListPendingRemindersTool())
 	botEngine.RegisterNativeTool(rTools.SearchRemindersHistoryTool())
 	botEngine.RegisterNativeTool(rTools.CancelReminderTool())
 	botEngine.RegisterNativeTool(rTools.UpdateReminderTool())
+	botEngine.RegisterNativeTool(rTools.SkipReminderOccurrenceTool())
+	botEngine.RegisterNativeTool(rTools.SnoozeReminderTool())
 	botEngine.RegisterNativeTool(rTools.CountRemindersTool())
 
 	// Register Client Profile Tools (allow users to manage their personal info via AI)
-	cTools := onlyClients.NewClientTools(clientRepo, portalAuthService)
+	cTools := onlyClients.NewClientTools(clientRepo, portalAuthService, fieldService)
 	botEngine.RegisterNativeTool(cTools.UpdateMyInfoTool())
 	botEngine.RegisterNativeTool(cTools.GetMyInfoTool())
 	botEngine.RegisterNativeTool(cTools.DeleteMyFieldTool())
 	botEngine.RegisterNativeTool(cTools.GetPortalLinkTool())
 
+	// Register Identity Linking Tools (same client on several platforms)
+	iTools := onlyClients.NewIdentityTools(identityService)
+	botEngine.RegisterNativeTool(iTools.GetLinkCodeTool())
+	botEngine.RegisterNativeTool(iTools.GetAccountLinkTool())
+
+	// Register Privacy Tools (export or erase all personal data from the chat)
+	pTools := onlyClients.NewPrivacyTools(privacyService)
+	pTools.SetMessenger(workspaceManager)
+	pTools.RegisterJobHandlers(msgworker.GetGlobalPool())
+	botEngine.RegisterNativeTool(pTools.ExportMyDataTool())
+	botEngine.RegisterNativeTool(pTools.EraseMyDataTool())
+
 	// Register Currency Tools
 	cxTools := onlyClients.NewExchangeRateTools()
 	botEngine.RegisterNativeTool(cxTools.GetExchangeRateTool())
 
+}
+
+// startBackgroundServices arranca los canales y el scheduler. Solo el servidor lo llama:
+// los subcomandos (ej. eval) comparten la inicialización pero no deben conectar canales.
+func startBackgroundServices(ctx context.Context) {
 	// Workspace Channels Auto-Start
 	go func() {
 		time.Sleep(5 * time.Second) // Small delay to ensure all infrastructure is ready
+		if workspaceManager.ClusterEnabled() {
+			// En cluster cada canal corre en un solo nodo: los leases deciden quién lo arranca
+			workspaceManager.StartOwnershipLoop(ctx)
+			return
+		}
 		if err := wkUsecase.StartEnabledChannels(ctx, workspaceManager); err != nil {
 			logrus.WithError(err).Error("[WORKSPACE] Failed to auto-start enabled channels")
 		}
 	}()
 
-	// Start Newsletter Scheduler (Promoter and Worker)
-	go func() {
-		ticker := time.NewTicker(1 * time.Hour) // Promoter runs once per hour
-		defer ticker.Stop()
-		// Initial promotion run
-		_ = newsletterUsecase.ProcessScheduledPosts(context.Background())
-		for range ticker.C {
-			if err := newsletterUsecase.ProcessScheduledPosts(context.Background()); err != nil {
-				logrus.WithError(err).Error("[SCHEDULER] Failed to process posts")
-			}
-		}
-	}()
+	// Start Scheduler (reminders and scheduled posts).
+	// Claims due posts with DB leases; Valkey, if enabled, only accelerates wake-ups.
+	workspaceManager.StartSchedulerLoop(ctx)
 
-	go func() {
-		if err := newsletterUsecase.RunTaskWorker(ctx); err != nil {
-			logrus.WithError(err).Error("[SCHEDULER] Worker failed")
+	// Subscription lifecycle worker (safe on every node: transitions are compare-and-swap)
+	lifecycleService.Start(ctx, coreconfig.Global.Billing.LifecycleInterval)
+}
+
+// newMessageJobQueues elige el backend de la cola de jobs (MESSAGE_QUEUE_BACKEND): la cola
+// compartida entre nodos y la exclusiva de este nodo. nil deja al pool global en memoria.
+func newMessageJobQueues(ctx context.Context) (msgworker.JobQueue, msgworker.JobQueue) {
+	cfg := coreconfig.Global.WorkerPool
+	switch cfg.Backend {
+	case "memory":
+		return nil, nil
+	case "valkey":
+		if vkClient == nil {
+			logrus.Warn("[STARTUP] MESSAGE_QUEUE_BACKEND=valkey but Valkey is not available, using in-memory job queue")
+			return nil, nil
 		}
-	}()
+	default:
+		if vkClient == nil {
+			return nil, nil
+		}
+	}
 
+	partitions := cfg.Partitions
+	if partitions <= 0 {
+		partitions = msgworker.DefaultPartitions
+	}
+	shared, err := msgworker.NewValkeyJobQueue(ctx, vkClient, "primary", partitions)
+	if err != nil {
+		logrus.WithError(err).Warn("[STARTUP] Failed to prepare Valkey job queue, using in-memory job queue")
+		return nil, nil
+	}
+	pinned, err := msgworker.NewValkeyJobQueue(ctx, vkClient, "primary:node:"+serverID, partitions)
+	if err != nil {
+		logrus.WithError(err).Warn("[STARTUP] Failed to prepare node job queue, pinned jobs will stay in memory")
+		return shared, nil
+	}
+	logrus.Infof("[STARTUP] Using Valkey Streams for the message job queue (%d partitions)", partitions)
+	return shared, pinned
 }
 
 func GetBotEngine() *botengine.Engine {
@@ -753,7 +945,24 @@ func StopApp() {
 		mcpUsecase.Shutdown()
 	}
 
-	// 4. Report shutdown to monitoring then close Valkey
+	if mcpServer != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		if err := mcpServer.Shutdown(ctx); err != nil {
+			logrus.Warnf("[MCP_SERVER] Shutdown error: %v", err)
+		}
+		cancel()
+	}
+
+	// Finish in-flight jobs and release queue partitions while Valkey is still reachable
+	msgworker.StopGlobalPool()
+
+	// 4. Hand channels over to the surviving nodes, report shutdown to monitoring then close Valkey
+	if workspaceManager != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		workspaceManager.HandOffChannels(ctx)
+		cancel()
+	}
+
 	if monitorStore != nil {
 		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
 		_ = monitorStore.RemoveServer(ctx, serverID)
@@ -3,18 +3,18 @@ package application
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	domainClients "github.com/AzielCF/az-wap/clients/domain"
 	domainNewsletter "github.com/AzielCF/az-wap/core/common/channel/newsletter/domain"
 	"github.com/AzielCF/az-wap/core/infrastructure/valkey"
-	"github.com/AzielCF/az-wap/core/pkg/msgworker"
 	"github.com/AzielCF/az-wap/core/pkg/timeutils"
 	"github.com/AzielCF/az-wap/core/pkg/validations"
 	"github.com/AzielCF/az-wap/workspace"
+	wsApplication "github.com/AzielCF/az-wap/workspace/application"
 	wsChannelDomain "github.com/AzielCF/az-wap/workspace/domain/channel"
 	wsCommonDomain "github.com/AzielCF/az-wap/workspace/domain/common"
-	"github.com/AzielCF/az-wap/workspace/domain/monitoring"
 	wsRepo "github.com/AzielCF/az-wap/workspace/repository"
 	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
@@ -24,18 +24,17 @@ type serviceNewsletter struct {
 	workspaceMgr *workspace.Manager
 	repo         wsRepo.IWorkspaceRepository
 	subRepo      domainClients.SubscriptionRepository
-	monitor      monitoring.MonitoringStore
 	vk           *valkey.Client
 }
 
-func NewNewsletterService(workspaceMgr *workspace.Manager, repo wsRepo.IWorkspaceRepository, subRepo domainClients.SubscriptionRepository, monitor monitoring.MonitoringStore, vk *valkey.Client) domainNewsletter.INewsletterUsecase {
-	return &serviceNewsletter{
+func NewNewsletterService(workspaceMgr *workspace.Manager, repo wsRepo.IWorkspaceRepository, subRepo domainClients.SubscriptionRepository, vk *valkey.Client) domainNewsletter.INewsletterUsecase {
+	service := &serviceNewsletter{
 		workspaceMgr: workspaceMgr,
 		repo:         repo,
 		subRepo:      subRepo,
-		monitor:      monitor,
 		vk:           vk,
 	}
+	return service
 }
 
 func (service serviceNewsletter) getAdapterForToken(ctx context.Context, token string) (wsChannelDomain.ChannelAdapter, error) {
@@ -76,68 +75,71 @@ func (service serviceNewsletter) SchedulePost(ctx context.Context, request domai
 	if request.ChannelID == "" || request.TargetID == "" {
 		return wsCommonDomain.ScheduledPost{}, fmt.Errorf("channel_id and target_id are required")
 	}
+	if request.Payload != nil {
+		if err := request.Payload.Validate(); err != nil {
+			return wsCommonDomain.ScheduledPost{}, fmt.Errorf("invalid payload: %w", err)
+		}
+		if request.Payload.HasAITask() && strings.HasSuffix(request.TargetID, "@newsletter") {
+			return wsCommonDomain.ScheduledPost{}, fmt.Errorf("ai tasks cannot target newsletters")
+		}
+	} else if request.Text == "" && request.MediaPath == "" {
+		return wsCommonDomain.ScheduledPost{}, fmt.Errorf("text, media_path or payload is required")
+	}
 
 	// 0. Recurrence & Past Date logic
+	now := time.Now().UTC()
 	scheduledAt := request.ScheduledAt.UTC()
-	originalTime := "" // Store HH:MM for recurrence
+	originalTime := "" // Store HH:MM for legacy recurrence
 	recurrenceDays := request.RecurrenceDays
+	rrule := request.RRule
 
-	// If recurring, calculate original_time and validate days
-	if recurrenceDays != "" {
-		// e.g. "0,1,2"
-		originalTime = scheduledAt.Format("15:04")
+	timezone := request.Timezone
+	if timezone == "" {
+		timezone = "UTC"
+	}
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return wsCommonDomain.ScheduledPost{}, fmt.Errorf("invalid timezone %q: %v", timezone, err)
 	}
 
-	// Strict Past Date Validation
-	if scheduledAt.Before(time.Now().UTC()) {
-		if recurrenceDays != "" {
-			// Smart Logic: If it's recurring and in the past, bump to tomorrow (or next valid day)
-			// But careful: we want the *first* execution to be right.
-			// Ideally we use our timeutils to find next valid slot from NOW.
-			// If user said "every day at 8am" and it's 10am, next is tomorrow 8am.
-			next, err := timeutils.CalculateNextOccurrence(recurrenceDays, originalTime, time.Now().UTC().Add(-1*time.Minute)) // Start from now
-			if err == nil {
-				scheduledAt = next
-				logrus.Warnf("Scheduled time was in past, bumped to next recurrence: %v", scheduledAt)
-			} else {
-				return wsCommonDomain.ScheduledPost{}, fmt.Errorf("scheduled time is in the past and could not calculate next recurrence: %v", err)
+	// Legacy weekday lists ("0,1,2") are stored as an equivalent weekly RRULE
+	if recurrenceDays != "" {
+		originalTime = scheduledAt.Format("15:04")
+		if rrule == "" {
+			if rrule, err = timeutils.LegacyDaysToRRule(recurrenceDays); err != nil {
+				return wsCommonDomain.ScheduledPost{}, err
 			}
-		} else {
-			return wsCommonDomain.ScheduledPost{}, fmt.Errorf("cannot schedule in the past. Current UTC time is %v", time.Now().UTC())
 		}
 	}
 
-	// 0.1 Limiting (Only for ID-based user senders, not system)
-	if request.SenderID != "" && recurrenceDays != "" { // Only Limit Recurring
-		// Get Subscription
-		sub, err := service.subRepo.GetActiveSubscription(ctx, request.SenderID, request.ChannelID)
-		limit := 5 // Default
-		if err == nil && sub != nil && sub.MaxRecurringReminders != nil {
-			limit = *sub.MaxRecurringReminders
+	var dtstart, occurrenceAt *time.Time
+	if rrule != "" {
+		rule, err := timeutils.ParseRRule(rrule)
+		if err != nil {
+			return wsCommonDomain.ScheduledPost{}, fmt.Errorf("invalid rrule: %v", err)
 		}
+		rrule = rule.String()
 
-		// Count existing recurring posts for this sender in this channel
-		// Note: We need a specialized Count method or filter list.
-		// For now, let's list and count (MVP).
-		posts, _ := service.repo.ListScheduledPosts(ctx, request.ChannelID)
-		count := 0
-		for _, p := range posts {
-			if p.SenderID == request.SenderID && p.RecurrenceDays != "" && p.Status != wsCommonDomain.ScheduledPostStatusCancelled && p.Status != wsCommonDomain.ScheduledPostStatusFailed {
-				// Check for collision here too
-				if p.OriginalTime == originalTime {
-					// Check days collision (intersection)
-					// Simple exact match for MVP or partial?
-					// Let's go strict: if ANY day overlaps at SAME time -> collision?
-					// Or just simpler: Same Original Time = Collision (simplest for user to understand)
-					return wsCommonDomain.ScheduledPost{}, fmt.Errorf("collision detected: you already have a recurring reminder at %s", originalTime)
-				}
-				count++
-			}
+		// The series is anchored at the requested time; the first occurrence is the first
+		// match of the rule from then on (or from now, if the requested time already passed).
+		from := scheduledAt.Add(-time.Second)
+		if from.Before(now) {
+			from = now
 		}
-
-		if count >= limit {
-			return wsCommonDomain.ScheduledPost{}, fmt.Errorf("limit reached: you can only have %d recurring reminders", limit)
+		first, ok := rule.Next(scheduledAt, from, loc)
+		if !ok {
+			return wsCommonDomain.ScheduledPost{}, fmt.Errorf("recurrence rule %s has no future occurrences", rrule)
 		}
+		if scheduledAt.Before(now) {
+			logrus.Warnf("Scheduled time was in past, bumped to next recurrence: %v", first)
+		}
+		anchor := scheduledAt
+		dtstart = &anchor
+		scheduledAt = first.UTC()
+		occ := scheduledAt
+		occurrenceAt = &occ
+	} else if scheduledAt.Before(now) {
+		return wsCommonDomain.ScheduledPost{}, fmt.Errorf("cannot schedule in the past. Current UTC time is %v", now)
 	}
 
 	post := wsCommonDomain.ScheduledPost{
@@ -149,19 +151,114 @@ func (service serviceNewsletter) SchedulePost(ctx context.Context, request domai
 		MediaPath:      request.MediaPath,
 		ScheduledAt:    scheduledAt, // UTC
 		Status:         wsCommonDomain.ScheduledPostStatusPending,
-		CreatedAt:      time.Now().UTC(),
-		UpdatedAt:      time.Now().UTC(),
+		CreatedAt:      now,
+		UpdatedAt:      now,
 		RecurrenceDays: recurrenceDays,
 		OriginalTime:   originalTime,
 		ExecutionCount: 0,
+		RRule:          rrule,
+		DTStart:        dtstart,
+		OccurrenceAt:   occurrenceAt,
+	}
+	if rrule != "" {
+		post.Timezone = timezone
+	}
+	if request.MediaPath != "" {
+		post.MediaType = wsApplication.MediaTypeFromPath(request.MediaPath)
+	}
+	if request.Payload != nil {
+		payload := *request.Payload
+		payload.Parts = append([]wsCommonDomain.ScheduledPayloadPart(nil), request.Payload.Parts...)
+		post.Payload = &payload
+	}
+
+	// 0.1 Limiting (Only for ID-based user senders, not system)
+	if request.SenderID != "" && post.IsRecurring() {
+		if err := service.checkRecurringLimit(ctx, post, request.ClientID); err != nil {
+			return wsCommonDomain.ScheduledPost{}, err
+		}
+	}
+
+	// 0.2 Keep a private copy of the media until the last occurrence
+	if err := wsApplication.RetainScheduledMedia(&post); err != nil {
+		return wsCommonDomain.ScheduledPost{}, fmt.Errorf("failed to retain media: %w", err)
 	}
 
 	// 1. Persist to DB
 	if err := service.repo.CreateScheduledPost(ctx, post); err != nil {
+		wsApplication.ReleaseScheduledMedia(post.ID)
 		return wsCommonDomain.ScheduledPost{}, err
 	}
 
 	// 2. If within 24h window, push to Valkey
+	service.enqueue(ctx, &post)
+
+	return post, nil
+}
+
+// checkRecurringLimit enforces ClientSubscription.MaxRecurringReminders and rejects a second
+// series that fires at the same time as an existing one. Subscriptions are keyed by client, not by
+// chat: the client comes from the request or, for AI tasks, from the client the task is charged to.
+func (service serviceNewsletter) checkRecurringLimit(ctx context.Context, post wsCommonDomain.ScheduledPost, clientID string) error {
+	if clientID == "" && post.Payload != nil {
+		for _, part := range post.Payload.Parts {
+			if part.AI != nil && part.AI.ClientID != "" {
+				clientID = part.AI.ClientID
+				break
+			}
+		}
+	}
+
+	limit := 5 // Default
+	if service.subRepo != nil && clientID != "" {
+		sub, err := service.subRepo.GetActiveSubscription(ctx, clientID, post.ChannelID)
+		if err == nil && sub != nil && sub.MaxRecurringReminders != nil {
+			limit = *sub.MaxRecurringReminders
+		}
+	}
+
+	posts, err := service.repo.ListScheduledPosts(ctx, post.ChannelID)
+	if err != nil {
+		return err
+	}
+	count := 0
+	for _, p := range posts {
+		if p.SenderID != post.SenderID || !p.IsRecurring() || p.Status == wsCommonDomain.ScheduledPostStatusCancelled || p.Status == wsCommonDomain.ScheduledPostStatusFailed {
+			continue
+		}
+		// Same time of day = collision (simplest rule for the user to understand)
+		if (post.OriginalTime != "" && p.OriginalTime == post.OriginalTime) || seriesKey(p) == seriesKey(post) {
+			return fmt.Errorf("collision detected: you already have a recurring reminder at %s", seriesTime(post))
+		}
+		count++
+	}
+
+	if count >= limit {
+		return fmt.Errorf("limit reached: you can only have %d recurring reminders", limit)
+	}
+	return nil
+}
+
+// seriesKey identifies a recurrence by its rule and local firing time.
+func seriesKey(p wsCommonDomain.ScheduledPost) string {
+	rule, err := p.Rule()
+	if err != nil {
+		return p.ID
+	}
+	return rule.String() + "|" + p.Location().String() + "|" + seriesTime(p)
+}
+
+func seriesTime(p wsCommonDomain.ScheduledPost) string {
+	anchor := p.Nominal()
+	if p.DTStart != nil {
+		anchor = *p.DTStart
+	}
+	return anchor.In(p.Location()).Format("15:04")
+}
+
+// enqueue pushes the post to the Valkey ZSET when it falls inside the 24h window,
+// or wakes the local scheduler when Valkey is disabled.
+func (service serviceNewsletter) enqueue(ctx context.Context, post *wsCommonDomain.ScheduledPost) {
 	if service.vk != nil && post.ScheduledAt.Before(time.Now().Add(24*time.Hour)) {
 		key := service.vk.Key("scheduler:tasks")
 		signalKey := service.vk.Key("scheduler:signal")
@@ -170,18 +267,126 @@ func (service serviceNewsletter) SchedulePost(ctx context.Context, request domai
 		err := service.vk.Inner().Do(ctx, service.vk.Inner().B().Zadd().Key(key).ScoreMember().ScoreMember(float64(post.ScheduledAt.Unix()), post.ID).Build()).Error()
 		if err == nil {
 			post.Status = wsCommonDomain.ScheduledPostStatusEnqueued
-			_ = service.repo.UpdateScheduledPost(ctx, post)
+			_ = service.repo.UpdateScheduledPost(ctx, *post)
 
 			// NUDGE: Send an instant signal to wake up the scheduler worker
 			_ = service.vk.Inner().Do(ctx, service.vk.Inner().B().Publish().Channel(signalKey).Message("new_task").Build())
 		} else {
 			logrus.WithError(err).Warnf("[SCHEDULER] Failed to enqueue post %s in Valkey", post.ID)
 		}
+	} else if service.vk == nil && service.workspaceMgr != nil {
+		// Sin Valkey no hay Pub/Sub: despertar al scheduler local directamente
+		service.workspaceMgr.WakeScheduler()
+	}
+}
+
+// dequeue removes the post from the Valkey ZSET (no-op without Valkey).
+func (service serviceNewsletter) dequeue(ctx context.Context, postID string) {
+	if service.vk == nil {
+		return
+	}
+	key := service.vk.Key("scheduler:tasks")
+	_ = service.vk.Inner().Do(ctx, service.vk.Inner().B().Zrem().Key(key).Member(postID).Build())
+}
+
+// getEditablePost loads a post that has not started executing yet.
+func (service serviceNewsletter) getEditablePost(ctx context.Context, postID string) (wsCommonDomain.ScheduledPost, error) {
+	post, err := service.repo.GetScheduledPost(ctx, postID)
+	if err != nil {
+		return wsCommonDomain.ScheduledPost{}, err
+	}
+	if post.Status != wsCommonDomain.ScheduledPostStatusPending && post.Status != wsCommonDomain.ScheduledPostStatusEnqueued {
+		return wsCommonDomain.ScheduledPost{}, fmt.Errorf("cannot modify post in status %s", post.Status)
+	}
+	return post, nil
+}
+
+// SkipOccurrence drops the pending occurrence of a recurring post and re-arms it to the next one.
+// When the series has no further occurrences the post is deleted and returned as cancelled.
+func (service serviceNewsletter) SkipOccurrence(ctx context.Context, postID string) (wsCommonDomain.ScheduledPost, error) {
+	post, err := service.getEditablePost(ctx, postID)
+	if err != nil {
+		return wsCommonDomain.ScheduledPost{}, err
+	}
+	if !post.IsRecurring() {
+		return wsCommonDomain.ScheduledPost{}, fmt.Errorf("post %s is not recurring, cancel it instead", postID)
+	}
+
+	service.dequeue(ctx, post.ID)
+	now := time.Now().UTC()
+	if err := service.repo.CreateScheduledPostExecution(ctx, wsCommonDomain.ScheduledPostExecution{
+		ID:           uuid.NewString(),
+		PostID:       post.ID,
+		ChannelID:    post.ChannelID,
+		OccurrenceAt: post.Nominal(),
+		ExecutedAt:   now,
+		Status:       wsCommonDomain.ScheduledPostExecutionSkipped,
+	}); err != nil {
+		logrus.WithError(err).Warnf("[SCHEDULER] Failed to record skipped occurrence of %s", post.ID)
+	}
+
+	armed, err := post.Rearm(now)
+	if err != nil {
+		return wsCommonDomain.ScheduledPost{}, err
+	}
+	if !armed {
+		post.Status = wsCommonDomain.ScheduledPostStatusCancelled
+		if err := service.repo.DeleteScheduledPost(ctx, post.ID); err != nil {
+			return wsCommonDomain.ScheduledPost{}, err
+		}
+		wsApplication.ReleaseScheduledMedia(post.ID)
+		return post, nil
+	}
+	if err := service.repo.UpdateScheduledPost(ctx, post); err != nil {
+		return wsCommonDomain.ScheduledPost{}, err
+	}
+	service.enqueue(ctx, &post)
+	return post, nil
+}
+
+// SnoozeOccurrence postpones the pending occurrence until the given time without touching the series.
+// For recurring posts the snooze must end before the following occurrence.
+func (service serviceNewsletter) SnoozeOccurrence(ctx context.Context, postID string, until time.Time) (wsCommonDomain.ScheduledPost, error) {
+	until = until.UTC()
+	now := time.Now().UTC()
+	if !until.After(now) {
+		return wsCommonDomain.ScheduledPost{}, fmt.Errorf("cannot snooze into the past. Current UTC time is %v", now)
+	}
+
+	post, err := service.getEditablePost(ctx, postID)
+	if err != nil {
+		return wsCommonDomain.ScheduledPost{}, err
+	}
+
+	nominal := post.Nominal()
+	if post.IsRecurring() {
+		next, ok, err := post.NextOccurrence(nominal)
+		if err != nil {
+			return wsCommonDomain.ScheduledPost{}, err
+		}
+		if ok && !until.Before(next) {
+			return wsCommonDomain.ScheduledPost{}, fmt.Errorf("snooze must end before the next occurrence at %s, skip this one instead", next.UTC().Format(time.RFC3339))
+		}
 	}
 
+	service.dequeue(ctx, post.ID)
+	post.OccurrenceAt = &nominal
+	post.ScheduledAt = until
+	post.Status = wsCommonDomain.ScheduledPostStatusPending
+	post.LeaseOwner = ""
+	post.LeaseUntil = nil
+	post.UpdatedAt = now
+	if err := service.repo.UpdateScheduledPost(ctx, post); err != nil {
+		return wsCommonDomain.ScheduledPost{}, err
+	}
+	service.enqueue(ctx, &post)
 	return post, nil
 }
 
+func (service serviceNewsletter) ListExecutions(ctx context.Context, postID string) ([]wsCommonDomain.ScheduledPostExecution, error) {
+	return service.repo.ListScheduledPostExecutions(ctx, postID, 100)
+}
+
 func (service serviceNewsletter) ListScheduled(ctx context.Context, channelID string) ([]wsCommonDomain.ScheduledPost, error) {
 	return service.repo.ListScheduledPosts(ctx, channelID)
 }
@@ -230,283 +435,9 @@ func (service serviceNewsletter) CancelScheduled(ctx context.Context, postID str
 	}
 
 	// 2. Mark as cancelled or delete (User preferred deletion for cleanup)
-	return service.repo.DeleteScheduledPost(ctx, postID)
-}
-
-// ProcessScheduledTasks checks Valkey for tasks ready to be sent and executes them.
-// Following the user requirement: once sent, it is DELETED from the database to keep it clean.
-func (service serviceNewsletter) ProcessScheduledTasks(ctx context.Context) error {
-	if service.vk == nil {
-		return nil
-	}
-
-	key := service.vk.Key("scheduler:tasks")
-	now := float64(time.Now().Unix())
-
-	// 1. Get tasks where Score (Time) <= current time
-	res := service.vk.Inner().Do(ctx, service.vk.Inner().B().Zrangebyscore().Key(key).Min("-inf").Max(fmt.Sprintf("%f", now)).Build())
-	taskIDs, err := res.AsStrSlice()
-	if err != nil && !valkey.IsNil(err) {
-		return err
-	}
-
-	for _, postID := range taskIDs {
-		// 2. Try to process each task
-		post, err := service.repo.GetScheduledPost(ctx, postID)
-		if err != nil {
-			logrus.Warnf("[SCHEDULER] Task %s found in Valkey but missing in DB. Cleaning up Valkey.", postID)
-			_ = service.vk.Inner().Do(ctx, service.vk.Inner().B().Zrem().Key(key).Member(postID).Build())
-			continue
-		}
-
-		// 3. Execution (Send through adapter)
-		adapter, err := service.getAdapterForToken(ctx, post.ChannelID)
-		if err != nil {
-			logrus.Errorf("[SCHEDULER] No adapter for channel %s in task %s", post.ChannelID, post.ID)
-			continue
-		}
-
-		logrus.Infof("[SCHEDULER] Executing scheduled post %s for %s", post.ID, post.TargetID)
-
-		var sendErr error
-		if post.MediaPath != "" {
-			_, sendErr = adapter.SendNewsletterMessage(ctx, post.TargetID, post.Text, post.MediaPath)
-		} else {
-			_, sendErr = adapter.SendNewsletterMessage(ctx, post.TargetID, post.Text, "")
-		}
-
-		if sendErr != nil {
-			logrus.WithError(sendErr).Errorf("[SCHEDULER] Failed to send post %s", post.ID)
-			post.Status = wsCommonDomain.ScheduledPostStatusFailed
-			post.Error = sendErr.Error()
-			_ = service.repo.UpdateScheduledPost(ctx, post)
-			// Don't delete on failure, so user can see what happened
-		} else {
-			// 4. CLEANUP: Delete from both DB and Valkey on success
-			// 4. CLEANUP OR RECURRENCE
-			logrus.Infof("[SCHEDULER] Task %s sent successfully. Checking recurrence.", post.ID)
-
-			isRecurring := post.RecurrenceDays != "" && post.OriginalTime != ""
-			// Remove from Valkey as *this specific execution* is done.
-			// Re-enqueueing happens via ProcessScheduledPosts/Promoter or if we updated the Time now?
-			// Logic: We must update 'ScheduledAt' in DB to next occurrence.
-			// Then the Promoter will pick it up later.
-			// OR we just leave it in DB with new time.
-			// Clean up OLD entry from Valkey (for this specific timestamp execution)
-			_ = service.vk.Inner().Do(ctx, service.vk.Inner().B().Zrem().Key(key).Member(post.ID).Build())
-
-			if isRecurring {
-				// Calculate NEXT occurrence from NOW (or from last scheduled? From NOW safer to avoid backlog avalanche)
-				next, errRec := timeutils.CalculateNextOccurrence(post.RecurrenceDays, post.OriginalTime, time.Now().UTC())
-				if errRec == nil {
-					// Check max execution limit? Currently unlimited or max 5 active reminders.
-					// We only check creation limit. Execution limit not specified, assumes infinite until cancelled.
-
-					// Update Post
-					post.ScheduledAt = next
-					post.Status = wsCommonDomain.ScheduledPostStatusPending // Reset to pending
-					post.ExecutionCount++
-					post.UpdatedAt = time.Now().UTC()
-					// Reset Error?
-					post.Error = ""
-
-					logrus.Infof("[SCHEDULER] Rescheduling recurring task %s to %v", post.ID, next)
-					if errUpd := service.repo.UpdateScheduledPost(ctx, post); errUpd != nil {
-						logrus.Errorf("Failed to reschedule recurring task %s: %v", post.ID, errUpd)
-					}
-				} else {
-					logrus.Errorf("Failed to calculate next occurrence for recurring task %s, deleting: %v", post.ID, errRec)
-					_ = service.repo.DeleteScheduledPost(ctx, post.ID)
-				}
-			} else {
-				// Not recurring, delete
-				logrus.Infof("[SCHEDULER] Task %s done, deleting.", post.ID)
-				_ = service.repo.DeleteScheduledPost(ctx, post.ID)
-			}
-		}
-	}
-
-	return nil
-}
-
-// ProcessScheduledPosts (The Promoter)
-// It moves tasks from SQLite to Valkey for the next 24h window to be ready for execution.
-func (service serviceNewsletter) ProcessScheduledPosts(ctx context.Context) error {
-	if service.vk == nil {
-		return nil
-	}
-
-	// 0. Update global counter for monitoring
-	if count, err := service.repo.CountPendingScheduledPosts(ctx); err == nil {
-		_ = service.monitor.UpdateStat(ctx, "pending", count)
-	}
-
-	// 1. Acceder al lock para evitar ejecutor múltiple
-	lockKey := service.vk.Key("lock:scheduler:promo")
-	err := service.vk.Inner().Do(ctx, service.vk.Inner().B().Set().Key(lockKey).Value("1").Nx().Ex(55*time.Second).Build()).Error()
-	if err != nil {
-		if valkey.IsNil(err) {
-			// Already locked by another node
-			return nil
-		}
-		return err
-	}
-
-	// Horizon: 24h from now
-	lookAhead := time.Now().Add(24 * time.Hour).UTC()
-
-	posts, err := service.repo.ListUpcomingScheduledPosts(ctx, lookAhead)
-	if err != nil {
+	if err := service.repo.DeleteScheduledPost(ctx, postID); err != nil {
 		return err
 	}
-
-	key := service.vk.Key("scheduler:tasks")
-	for _, post := range posts {
-		if post.Status == wsCommonDomain.ScheduledPostStatusPending {
-			// Atomic update in DB to avoid race conditions
-			post.Status = wsCommonDomain.ScheduledPostStatusEnqueued
-			post.UpdatedAt = time.Now().UTC()
-			if err := service.repo.UpdateScheduledPost(ctx, post); err != nil {
-				continue
-			}
-		} else if post.Status != wsCommonDomain.ScheduledPostStatusEnqueued {
-			// Skip other statuses (sent, failed, cancelled)
-			continue
-		}
-
-		// Push (or re-push) to Valkey
-		score := float64(post.ScheduledAt.Unix())
-		err := service.vk.Inner().Do(ctx, service.vk.Inner().B().Zadd().Key(key).ScoreMember().ScoreMember(score, post.ID).Build()).Error()
-		if err != nil {
-			logrus.WithError(err).Errorf("[SCHEDULER] Failed to move post %s to Valkey", post.ID)
-		}
-	}
-
+	wsApplication.ReleaseScheduledMedia(postID)
 	return nil
 }
-
-// RunTaskWorker (The Worker)
-// Pollea Valkey y ejecuta las tareas cuyo timestamp ya pasó.
-func (service serviceNewsletter) RunTaskWorker(ctx context.Context) error {
-	if service.vk == nil {
-		logrus.Warn("[SCHEDULER] Valkey not available, Worker disabled")
-		return nil
-	}
-
-	key := service.vk.Key("scheduler:tasks")
-	ticker := time.NewTicker(15 * time.Second)
-	defer ticker.Stop()
-
-	for {
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		case <-ticker.C:
-			now := float64(time.Now().Unix())
-			// Get IDs of tasks that should be executed
-			resp, err := service.vk.Inner().Do(ctx, service.vk.Inner().B().Zrangebyscore().Key(key).Min("-inf").Max(fmt.Sprintf("%f", now)).Limit(0, 10).Build()).AsStrSlice()
-			if err != nil {
-				if !valkey.IsNil(err) {
-					logrus.WithError(err).Error("[SCHEDULER] Failed to poll Valkey ZSET")
-				}
-				continue
-			}
-
-			for _, id := range resp {
-				// Try to claim the task atómically
-				remErr := service.vk.Inner().Do(ctx, service.vk.Inner().B().Zrem().Key(key).Member(id).Build()).Error()
-				if remErr == nil {
-					// We claimed it!
-					go func(taskID string) {
-						taskCtx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
-						defer cancel()
-						service.executeTaskByID(taskCtx, taskID)
-					}(id)
-				}
-			}
-		}
-	}
-}
-
-func (service serviceNewsletter) executeTaskByID(ctx context.Context, id string) {
-	post, err := service.repo.GetScheduledPost(ctx, id)
-	if err != nil {
-		logrus.Errorf("[SCHEDULER] Failed to fetch task %s from DB: %v", id, err)
-		return
-	}
-
-	// Check eligibility
-	if post.Status != wsCommonDomain.ScheduledPostStatusEnqueued {
-		// Already cancelled or processed
-		return
-	}
-
-	service.executePost(ctx, post)
-}
-
-func (service serviceNewsletter) executePost(ctx context.Context, post wsCommonDomain.ScheduledPost) {
-	logrus.Infof("[SCHEDULER] Queuing post %s for execution", post.ID)
-
-	// Use Worker Pool for execution to ensure monitoring visibility and concurrency control
-	msgworker.GetGlobalPool().Dispatch(msgworker.MessageJob{
-		InstanceID: post.ChannelID,
-		ChatJID:    post.TargetID,
-		Handler: func(workerCtx context.Context) error {
-			logrus.Infof("[SCHEDULER] Worker executing post %s", post.ID)
-
-			// Add a timeout to prevent the worker from being blocked indefinitely
-			sendCtx, cancel := context.WithTimeout(workerCtx, 30*time.Second)
-			defer cancel()
-
-			adapter, err := service.getAdapterForToken(sendCtx, post.ChannelID)
-			if err != nil {
-				service.handlePostError(sendCtx, post, err)
-				return nil
-			}
-
-			var errSend error
-
-			// Determine logical target type
-			isNewsletter := false
-			if len(post.TargetID) > 11 && post.TargetID[len(post.TargetID)-11:] == "@newsletter" {
-				isNewsletter = true
-			}
-
-			if isNewsletter {
-				_, errSend = adapter.SendNewsletterMessage(sendCtx, post.TargetID, post.Text, post.MediaPath)
-			} else {
-				// Standard Group or Chat
-				if post.MediaPath != "" {
-					errSend = fmt.Errorf("media scheduling for groups not fully implemented yet in auto-scheduler, only text supported")
-				} else {
-					if post.Text != "" {
-						_, errSend = adapter.SendMessage(sendCtx, post.TargetID, post.Text, "")
-					}
-				}
-			}
-
-			if errSend != nil {
-				service.handlePostError(sendCtx, post, errSend)
-			} else {
-				logrus.Infof("[SCHEDULER] Post %s sent successfully", post.ID)
-				post.Status = wsCommonDomain.ScheduledPostStatusSent
-				post.Error = ""
-				post.UpdatedAt = time.Now()
-				if err := service.repo.UpdateScheduledPost(sendCtx, post); err != nil {
-					logrus.Errorf("Failed to update post status after execution %s: %v", post.ID, err)
-				}
-			}
-			return nil
-		},
-	})
-}
-
-func (service serviceNewsletter) handlePostError(ctx context.Context, post wsCommonDomain.ScheduledPost, err error) {
-	logrus.Errorf("Failed to send scheduled post %s: %v", post.ID, err)
-	post.Status = wsCommonDomain.ScheduledPostStatusFailed
-	post.Error = err.Error()
-	post.UpdatedAt = time.Now()
-	if resultErr := service.repo.UpdateScheduledPost(ctx, post); resultErr != nil {
-		logrus.Errorf("Failed to update post status %s: %v", post.ID, resultErr)
-	}
-}
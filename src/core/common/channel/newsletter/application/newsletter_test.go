@@ -0,0 +1,86 @@
+package application
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	domainClients "github.com/AzielCF/az-wap/clients/domain"
+	domainNewsletter "github.com/AzielCF/az-wap/core/common/channel/newsletter/domain"
+	coreconfig "github.com/AzielCF/az-wap/core/config"
+	wsCommonDomain "github.com/AzielCF/az-wap/workspace/domain/common"
+	wsRepo "github.com/AzielCF/az-wap/workspace/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// postsRepo keeps scheduled posts in memory
+type postsRepo struct {
+	wsRepo.IWorkspaceRepository
+	posts []wsCommonDomain.ScheduledPost
+}
+
+func (r *postsRepo) CreateScheduledPost(ctx context.Context, post wsCommonDomain.ScheduledPost) error {
+	r.posts = append(r.posts, post)
+	return nil
+}
+
+func (r *postsRepo) ListScheduledPosts(ctx context.Context, channelID string) ([]wsCommonDomain.ScheduledPost, error) {
+	var result []wsCommonDomain.ScheduledPost
+	for _, p := range r.posts {
+		if p.ChannelID == channelID {
+			result = append(result, p)
+		}
+	}
+	return result, nil
+}
+
+// clientSubs answers like the gorm repository: subscriptions are looked up by client, not by chat
+type clientSubs struct {
+	domainClients.SubscriptionRepository
+	subs map[string]*domainClients.ClientSubscription // client_id -> subscription
+}
+
+func (r *clientSubs) GetActiveSubscription(ctx context.Context, clientID, channelID string) (*domainClients.ClientSubscription, error) {
+	if sub, ok := r.subs[clientID]; ok && sub.ChannelID == channelID {
+		return sub, nil
+	}
+	return nil, domainClients.ErrSubscriptionNotFound
+}
+
+func TestSchedulePost_RecurringLimitComesFromClientSubscription(t *testing.T) {
+	prev := coreconfig.Global
+	coreconfig.Global = &coreconfig.Config{Paths: coreconfig.PathsConfig{Storages: t.TempDir()}}
+	t.Cleanup(func() { coreconfig.Global = prev })
+
+	one := 1
+	subs := &clientSubs{subs: map[string]*domainClients.ClientSubscription{
+		"client-1": {ClientID: "client-1", ChannelID: "ch1", MaxRecurringReminders: &one},
+	}}
+	repo := &postsRepo{}
+	service := NewNewsletterService(nil, repo, subs, nil)
+
+	at := time.Now().Add(24 * time.Hour)
+	request := func(clientID string, at time.Time) domainNewsletter.SchedulePostRequest {
+		return domainNewsletter.SchedulePostRequest{
+			ChannelID:      "ch1",
+			TargetID:       "51999@s.whatsapp.net",
+			SenderID:       "51999@s.whatsapp.net",
+			ClientID:       clientID,
+			Text:           "Tomar agua",
+			ScheduledAt:    at,
+			RecurrenceDays: "1,3,5",
+		}
+	}
+
+	_, err := service.SchedulePost(context.Background(), request("client-1", at))
+	require.NoError(t, err)
+
+	_, err = service.SchedulePost(context.Background(), request("client-1", at.Add(2*time.Hour)))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "only have 1 recurring reminders")
+
+	// Without a subscription the default limit applies
+	_, err = service.SchedulePost(context.Background(), request("", at.Add(2*time.Hour)))
+	assert.NoError(t, err)
+}
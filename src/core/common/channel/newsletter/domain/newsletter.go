@@ -14,8 +14,9 @@ type INewsletterUsecase interface {
 	ListScheduled(ctx context.Context, channelID string) ([]wsDomainCommon.ScheduledPost, error)
 	ListScheduledBySender(ctx context.Context, channelID, senderID string) ([]wsDomainCommon.ScheduledPost, error)
 	CancelScheduled(ctx context.Context, postID string) error
-	ProcessScheduledPosts(ctx context.Context) error
-	RunTaskWorker(ctx context.Context) error
+	SkipOccurrence(ctx context.Context, postID string) (wsDomainCommon.ScheduledPost, error)
+	SnoozeOccurrence(ctx context.Context, postID string, until time.Time) (wsDomainCommon.ScheduledPost, error)
+	ListExecutions(ctx context.Context, postID string) ([]wsDomainCommon.ScheduledPostExecution, error)
 }
 
 type UnfollowRequest struct {
@@ -26,9 +27,19 @@ type UnfollowRequest struct {
 type SchedulePostRequest struct {
 	ChannelID      string    `json:"channel_id"`
 	TargetID       string    `json:"target_id"`
-	SenderID       string    `json:"sender_id"` // Optional: who is scheduling
+	SenderID       string    `json:"sender_id"`           // Optional: who is scheduling
+	ClientID       string    `json:"client_id,omitempty"` // Optional: client whose subscription limits recurring series
 	Text           string    `json:"text"`
 	MediaPath      string    `json:"media_path,omitempty"`
 	ScheduledAt    time.Time `json:"scheduled_at"`
-	RecurrenceDays string    `json:"recurrence_days"` // "0,1,2" (Sun, Mon, Tue)
+	RecurrenceDays string    `json:"recurrence_days"`    // "0,1,2" (Sun, Mon, Tue)
+	RRule          string    `json:"rrule,omitempty"`    // RFC 5545, e.g. "FREQ=MONTHLY;BYDAY=-1FR;COUNT=6"
+	Timezone       string    `json:"timezone,omitempty"` // IANA zone for the recurrence (default UTC)
+
+	// Typed content (media, polls, locations, links or a sequence of them). Overrides Text/MediaPath
+	Payload *wsDomainCommon.ScheduledPayload `json:"payload,omitempty"`
+}
+
+type SnoozeRequest struct {
+	Until time.Time `json:"until"`
 }
@@ -17,6 +17,9 @@ func InitRestNewsletter(app fiber.Router, service domainNewsletter.INewsletterUs
 	app.Post("/newsletter/schedule", rest.SchedulePost)
 	app.Get("/newsletter/scheduled/:channel_id", rest.ListScheduled)
 	app.Delete("/newsletter/scheduled/:id", rest.CancelScheduled)
+	app.Post("/newsletter/scheduled/:id/skip", rest.SkipOccurrence)
+	app.Post("/newsletter/scheduled/:id/snooze", rest.SnoozeOccurrence)
+	app.Get("/newsletter/scheduled/:id/executions", rest.ListExecutions)
 	return rest
 }
 
@@ -126,3 +129,57 @@ func (controller *Newsletter) CancelScheduled(c *fiber.Ctx) error {
 		Message: "Success cancel scheduled post",
 	})
 }
+
+func (controller *Newsletter) SkipOccurrence(c *fiber.Ctx) error {
+	post, err := controller.Service.SkipOccurrence(c.UserContext(), c.Params("id"))
+	if err != nil {
+		return c.Status(500).JSON(utils.ResponseData{Status: 500, Message: err.Error()})
+	}
+
+	return c.JSON(utils.ResponseData{
+		Status:  200,
+		Code:    "SUCCESS",
+		Message: "Success skip occurrence",
+		Results: post,
+	})
+}
+
+func (controller *Newsletter) SnoozeOccurrence(c *fiber.Ctx) error {
+	var request domainNewsletter.SnoozeRequest
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(400).JSON(utils.ResponseData{Status: 400, Message: err.Error()})
+	}
+	if request.Until.IsZero() {
+		return c.Status(400).JSON(utils.ResponseData{
+			Status:  400,
+			Code:    "BAD_REQUEST",
+			Message: "until is required",
+		})
+	}
+
+	post, err := controller.Service.SnoozeOccurrence(c.UserContext(), c.Params("id"), request.Until)
+	if err != nil {
+		return c.Status(500).JSON(utils.ResponseData{Status: 500, Message: err.Error()})
+	}
+
+	return c.JSON(utils.ResponseData{
+		Status:  200,
+		Code:    "SUCCESS",
+		Message: "Success snooze occurrence",
+		Results: post,
+	})
+}
+
+func (controller *Newsletter) ListExecutions(c *fiber.Ctx) error {
+	executions, err := controller.Service.ListExecutions(c.UserContext(), c.Params("id"))
+	if err != nil {
+		return c.Status(500).JSON(utils.ResponseData{Status: 500, Message: err.Error()})
+	}
+
+	return c.JSON(utils.ResponseData{
+		Status:  200,
+		Code:    "SUCCESS",
+		Message: "Success fetch executions",
+		Results: executions,
+	})
+}
@@ -588,26 +588,7 @@ func (service serviceSend) SendAudio(ctx context.Context, request domainSend.Aud
 		fileName = request.Audio.Filename
 	}
 
-	if !strings.HasPrefix(strings.ToLower(audioMimeType), "audio/ogg") {
-		if _, errFF := exec.LookPath("ffmpeg"); errFF == nil {
-			id := fiberUtils.UUIDv4()
-			inputPath := fmt.Sprintf("%s/%s-audio-input", coreconfig.Global.Paths.SendItems, id)
-			outputPath := fmt.Sprintf("%s/%s-audio-output.ogg", coreconfig.Global.Paths.SendItems, id)
-			if errWrite := os.WriteFile(inputPath, audioBytes, 0644); errWrite == nil {
-				cmd := exec.Command("ffmpeg", "-y", "-i", inputPath, "-acodec", "libopus", "-b:a", "32k", outputPath)
-				if out, errRun := cmd.CombinedOutput(); errRun != nil {
-					logrus.WithError(errRun).WithField("output", string(out)).Error("failed to transcode audio to ogg")
-				} else {
-					if newBytes, errRead := os.ReadFile(outputPath); errRead == nil && len(newBytes) > 0 {
-						audioBytes = newBytes
-						audioMimeType = "audio/ogg; codecs=opus"
-						fileName = id + ".ogg"
-					}
-				}
-			}
-			go pkgUtils.RemoveFile(0, inputPath, outputPath)
-		}
-	}
+	audioBytes, audioMimeType, fileName = pkgUtils.ConvertToVoiceNote(audioBytes, audioMimeType, fileName)
 
 	mediaUpload := wsDomainCommon.MediaUpload{
 		Data:     audioBytes,
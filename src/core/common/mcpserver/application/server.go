@@ -0,0 +1,224 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	botengineDomain "github.com/AzielCF/az-wap/botengine/domain"
+	clientsApp "github.com/AzielCF/az-wap/clients/application"
+	domainNewsletter "github.com/AzielCF/az-wap/core/common/channel/newsletter/domain"
+	domainMCPServer "github.com/AzielCF/az-wap/core/common/mcpserver/domain"
+	channelDomain "github.com/AzielCF/az-wap/workspace/domain/channel"
+	workspaceDomain "github.com/AzielCF/az-wap/workspace/domain/workspace"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/sirupsen/logrus"
+)
+
+// ChannelRuntime is the subset of the workspace manager the MCP server needs.
+type ChannelRuntime interface {
+	GetAdapter(channelID string) (channelDomain.ChannelAdapter, bool)
+	GetActiveChats(channelID string) []string
+	GetSessionHistory(channelID, chatID string) []botengineDomain.ChatTurn
+}
+
+// Deps groups the services exposed through MCP tools.
+type Deps struct {
+	Tokens        domainMCPServer.ITokenUsecase
+	Workspaces    workspaceDomain.IWorkspaceRepository
+	Runtime       ChannelRuntime
+	Newsletter    domainNewsletter.INewsletterUsecase
+	Clients       *clientsApp.ClientService
+	Subscriptions *clientsApp.SubscriptionService
+}
+
+// Server exposes az-wap operations to external agents over the MCP
+// streamable HTTP transport. Every request must carry a bearer token whose
+// scopes decide which tools are listed and callable, and whose workspaces
+// decide which channels the tools can touch.
+type Server struct {
+	deps    Deps
+	mcp     *server.MCPServer
+	http    *server.StreamableHTTPServer
+	httpSrv *http.Server
+	mu      sync.Mutex
+}
+
+type scopedTool struct {
+	scope   domainMCPServer.Scope
+	tool    mcp.Tool
+	handler server.ToolHandlerFunc
+}
+
+func NewServer(deps Deps, version string) *Server {
+	s := &Server{deps: deps}
+
+	tools := s.tools()
+	scopeByTool := make(map[string]domainMCPServer.Scope, len(tools))
+	for _, t := range tools {
+		scopeByTool[t.tool.Name] = t.scope
+	}
+
+	s.mcp = server.NewMCPServer(
+		"az-wap",
+		version,
+		server.WithToolCapabilities(false),
+		server.WithRecovery(),
+		server.WithInstructions("Operate az-wap workspaces: send messages, read chats and sessions, schedule posts, manage groups and query clients. Every channel_id must belong to a workspace granted to your token."),
+		server.WithToolFilter(func(ctx context.Context, all []mcp.Tool) []mcp.Tool {
+			token, ok := domainMCPServer.TokenFromContext(ctx)
+			if !ok {
+				return nil
+			}
+			var visible []mcp.Tool
+			for _, t := range all {
+				if token.HasScope(scopeByTool[t.Name]) {
+					visible = append(visible, t)
+				}
+			}
+			return visible
+		}),
+	)
+
+	for _, t := range tools {
+		s.mcp.AddTool(t.tool, s.requireScope(t.scope, t.handler))
+	}
+
+	s.http = server.NewStreamableHTTPServer(s.mcp, server.WithEndpointPath(domainMCPServer.DefaultEndpointPath))
+	return s
+}
+
+// requireScope re-checks the token on every call; the tool filter only hides
+// tools from listings and is not an authorization boundary on its own.
+func (s *Server) requireScope(scope domainMCPServer.Scope, next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		token, ok := domainMCPServer.TokenFromContext(ctx)
+		if !ok {
+			return mcp.NewToolResultError("unauthenticated"), nil
+		}
+		if !token.HasScope(scope) {
+			return mcp.NewToolResultError(fmt.Sprintf("token is missing scope %q", scope)), nil
+		}
+		return next(ctx, req)
+	}
+}
+
+// ServeHTTP authenticates the bearer token and forwards to the MCP transport.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	raw := strings.TrimSpace(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+	if raw == "" {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="az-wap-mcp"`)
+		http.Error(w, "missing bearer token", http.StatusUnauthorized)
+		return
+	}
+
+	token, err := s.deps.Tokens.Authenticate(r.Context(), raw)
+	if err != nil {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="az-wap-mcp", error="invalid_token"`)
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	s.http.ServeHTTP(w, r.WithContext(domainMCPServer.WithToken(r.Context(), token)))
+}
+
+// Start listens on host:port. It blocks until the server is shut down.
+func (s *Server) Start(host, port string) error {
+	mux := http.NewServeMux()
+	mux.Handle(domainMCPServer.DefaultEndpointPath, s)
+
+	s.mu.Lock()
+	s.httpSrv = &http.Server{
+		Addr:              net.JoinHostPort(host, port),
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	srv := s.httpSrv
+	s.mu.Unlock()
+
+	logrus.Infof("[MCP_SERVER] Listening on http://%s%s", srv.Addr, domainMCPServer.DefaultEndpointPath)
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.mu.Lock()
+	srv := s.httpSrv
+	s.mu.Unlock()
+	if srv == nil {
+		return nil
+	}
+	_ = s.http.Shutdown(ctx)
+	return srv.Shutdown(ctx)
+}
+
+// authorizeChannel ensures the channel exists and belongs to a workspace granted to the token.
+func (s *Server) authorizeChannel(ctx context.Context, channelID string) (channelDomain.Channel, error) {
+	token, ok := domainMCPServer.TokenFromContext(ctx)
+	if !ok {
+		return channelDomain.Channel{}, errors.New("unauthenticated")
+	}
+	channelID = strings.TrimSpace(channelID)
+	if channelID == "" {
+		return channelDomain.Channel{}, errors.New("channel_id is required")
+	}
+	ch, err := s.deps.Workspaces.GetChannel(ctx, channelID)
+	if err != nil {
+		return channelDomain.Channel{}, fmt.Errorf("channel %s not found", channelID)
+	}
+	if !token.AllowsWorkspace(ch.WorkspaceID) {
+		// Same message as not found so tokens cannot probe foreign channels.
+		return channelDomain.Channel{}, fmt.Errorf("channel %s not found", channelID)
+	}
+	return ch, nil
+}
+
+func (s *Server) adapterFor(ctx context.Context, channelID string) (channelDomain.ChannelAdapter, error) {
+	if _, err := s.authorizeChannel(ctx, channelID); err != nil {
+		return nil, err
+	}
+	adapter, ok := s.deps.Runtime.GetAdapter(channelID)
+	if !ok {
+		return nil, fmt.Errorf("channel %s is not running", channelID)
+	}
+	return adapter, nil
+}
+
+// allowedChannels lists every channel in the workspaces granted to the token.
+func (s *Server) allowedChannels(ctx context.Context) ([]channelDomain.Channel, error) {
+	token, ok := domainMCPServer.TokenFromContext(ctx)
+	if !ok {
+		return nil, errors.New("unauthenticated")
+	}
+
+	var workspaceIDs []string
+	if token.AllowsWorkspace("*") {
+		wss, err := s.deps.Workspaces.List(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, ws := range wss {
+			workspaceIDs = append(workspaceIDs, ws.ID)
+		}
+	} else {
+		workspaceIDs = token.WorkspaceIDs
+	}
+
+	var out []channelDomain.Channel
+	for _, wsID := range workspaceIDs {
+		chs, err := s.deps.Workspaces.ListChannels(ctx, wsID)
+		if err != nil {
+			continue
+		}
+		out = append(out, chs...)
+	}
+	return out, nil
+}
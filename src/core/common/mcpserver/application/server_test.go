@@ -0,0 +1,285 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	botengineDomain "github.com/AzielCF/az-wap/botengine/domain"
+	clientsApp "github.com/AzielCF/az-wap/clients/application"
+	clientsDomain "github.com/AzielCF/az-wap/clients/domain"
+	"github.com/AzielCF/az-wap/clients/repository"
+	domainNewsletter "github.com/AzielCF/az-wap/core/common/channel/newsletter/domain"
+	domainMCPServer "github.com/AzielCF/az-wap/core/common/mcpserver/domain"
+	channelDomain "github.com/AzielCF/az-wap/workspace/domain/channel"
+	"github.com/AzielCF/az-wap/workspace/domain/common"
+	workspaceDomain "github.com/AzielCF/az-wap/workspace/domain/workspace"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// stubWorkspaces resolves channels from a fixed channel -> workspace map.
+type stubWorkspaces struct {
+	workspaceDomain.IWorkspaceRepository
+	channels map[string]string
+}
+
+func (s *stubWorkspaces) GetChannel(_ context.Context, channelID string) (channelDomain.Channel, error) {
+	wsID, ok := s.channels[channelID]
+	if !ok {
+		return channelDomain.Channel{}, errors.New("not found")
+	}
+	return channelDomain.Channel{ID: channelID, WorkspaceID: wsID}, nil
+}
+
+// stubNewsletter records scheduled posts instead of persisting them.
+type stubNewsletter struct {
+	domainNewsletter.INewsletterUsecase
+	scheduled []domainNewsletter.SchedulePostRequest
+}
+
+func (s *stubNewsletter) SchedulePost(_ context.Context, req domainNewsletter.SchedulePostRequest) (common.ScheduledPost, error) {
+	s.scheduled = append(s.scheduled, req)
+	return common.ScheduledPost{ID: "post-1", ChannelID: req.ChannelID}, nil
+}
+
+// stubRuntime has no running channels or active sessions.
+type stubRuntime struct{}
+
+func (stubRuntime) GetAdapter(string) (channelDomain.ChannelAdapter, bool)      { return nil, false }
+func (stubRuntime) GetActiveChats(string) []string                              { return nil }
+func (stubRuntime) GetSessionHistory(string, string) []botengineDomain.ChatTurn { return nil }
+
+type testServer struct {
+	srv        *Server
+	newsletter *stubNewsletter
+	subs       *repository.SubscriptionGormRepository
+}
+
+// newTestServer builds a server with channel ch-a in workspace ws-a and ch-b in ws-b.
+// Client cl-a is subscribed only to ch-a, client cl-b only to ch-b.
+func newTestServer(t *testing.T) testServer {
+	t.Helper()
+	ctx := context.Background()
+
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "clients.db")), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+
+	clientRepo := repository.NewClientGormRepository(db)
+	subRepo := repository.NewSubscriptionGormRepository(db)
+	identityRepo := repository.NewIdentityGormRepository(db)
+	require.NoError(t, clientRepo.InitSchema(ctx))
+	require.NoError(t, subRepo.InitSchema(ctx))
+	require.NoError(t, identityRepo.InitSchema(ctx))
+
+	for _, c := range []struct{ clientID, channelID string }{{"cl-a", "ch-a"}, {"cl-b", "ch-b"}} {
+		require.NoError(t, clientRepo.Create(ctx, &clientsDomain.Client{
+			ID:           c.clientID,
+			PlatformID:   c.clientID + "@lid",
+			PlatformType: clientsDomain.PlatformWhatsApp,
+			DisplayName:  c.clientID,
+			Enabled:      true,
+		}))
+		require.NoError(t, subRepo.Create(ctx, &clientsDomain.ClientSubscription{
+			ID:        "sub-" + c.clientID,
+			ClientID:  c.clientID,
+			ChannelID: c.channelID,
+			Status:    clientsDomain.SubscriptionActive,
+		}))
+	}
+
+	newsletter := &stubNewsletter{}
+	srv := NewServer(Deps{
+		Workspaces:    &stubWorkspaces{channels: map[string]string{"ch-a": "ws-a", "ch-b": "ws-b"}},
+		Runtime:       stubRuntime{},
+		Newsletter:    newsletter,
+		Clients:       clientsApp.NewClientService(clientRepo, subRepo, identityRepo),
+		Subscriptions: clientsApp.NewSubscriptionService(subRepo, clientRepo),
+	}, "test")
+	return testServer{srv: srv, newsletter: newsletter, subs: subRepo}
+}
+
+// call runs a tool the same way the MCP server does, scope check included.
+func (ts testServer) call(t *testing.T, ctx context.Context, name string, args map[string]any) *mcp.CallToolResult {
+	t.Helper()
+	for _, tool := range ts.srv.tools() {
+		if tool.tool.Name != name {
+			continue
+		}
+		req := mcp.CallToolRequest{}
+		req.Params.Name = name
+		req.Params.Arguments = args
+		result, err := ts.srv.requireScope(tool.scope, tool.handler)(ctx, req)
+		require.NoError(t, err)
+		require.NotNil(t, result)
+		return result
+	}
+	t.Fatalf("tool %s is not registered", name)
+	return nil
+}
+
+func resultText(result *mcp.CallToolResult) string {
+	var parts []string
+	for _, c := range result.Content {
+		if text, ok := c.(mcp.TextContent); ok {
+			parts = append(parts, text.Text)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+func tokenCtx(workspaces []string, scopes ...domainMCPServer.Scope) context.Context {
+	return domainMCPServer.WithToken(context.Background(), domainMCPServer.AccessToken{
+		ID:           "tok-1",
+		WorkspaceIDs: workspaces,
+		Scopes:       scopes,
+	})
+}
+
+func TestServer_ToolAuthorization(t *testing.T) {
+	ts := newTestServer(t)
+
+	scheduleArgs := func(channelID string) map[string]any {
+		return map[string]any{
+			"channel_id":   channelID,
+			"target_id":    "123@s.whatsapp.net",
+			"text":         "hola",
+			"scheduled_at": "2030-01-01T10:00:00Z",
+		}
+	}
+
+	tests := []struct {
+		name      string
+		ctx       context.Context
+		tool      string
+		args      map[string]any
+		wantError string // empty means the call must succeed
+	}{
+		{
+			name:      "no token",
+			ctx:       context.Background(),
+			tool:      "schedule_post",
+			args:      scheduleArgs("ch-a"),
+			wantError: "unauthenticated",
+		},
+		{
+			name:      "missing scope",
+			ctx:       tokenCtx([]string{"ws-a"}, domainMCPServer.ScopeChatsRead),
+			tool:      "schedule_post",
+			args:      scheduleArgs("ch-a"),
+			wantError: `token is missing scope "schedule:write"`,
+		},
+		{
+			name:      "scope for another tool",
+			ctx:       tokenCtx([]string{"ws-a"}, domainMCPServer.ScopeScheduleWrite),
+			tool:      "get_client",
+			args:      map[string]any{"client_id": "cl-a"},
+			wantError: `token is missing scope "clients:read"`,
+		},
+		{
+			name:      "channel in another workspace",
+			ctx:       tokenCtx([]string{"ws-a"}, domainMCPServer.ScopeScheduleWrite),
+			tool:      "schedule_post",
+			args:      scheduleArgs("ch-b"),
+			wantError: "channel ch-b not found",
+		},
+		{
+			name:      "foreign channel looks like a missing one",
+			ctx:       tokenCtx([]string{"ws-a"}, domainMCPServer.ScopeChatsRead),
+			tool:      "list_chats",
+			args:      map[string]any{"channel_id": "ch-b"},
+			wantError: "channel ch-b not found",
+		},
+		{
+			name: "media part rejected",
+			ctx:  tokenCtx([]string{"ws-a"}, domainMCPServer.ScopeScheduleWrite),
+			tool: "schedule_post",
+			args: func() map[string]any {
+				args := scheduleArgs("ch-a")
+				args["payload"] = map[string]any{"parts": []any{
+					map[string]any{"type": "text", "text": "mira"},
+					map[string]any{"type": "media", "media_path": "/etc/passwd"},
+				}}
+				return args
+			}(),
+			wantError: "media parts cannot be scheduled over MCP",
+		},
+		{
+			name: "schedule on own channel",
+			ctx:  tokenCtx([]string{"ws-a"}, domainMCPServer.ScopeScheduleWrite),
+			tool: "schedule_post",
+			args: scheduleArgs("ch-a"),
+		},
+		{
+			name: "wildcard scope and workspace",
+			ctx:  tokenCtx([]string{"*"}, domainMCPServer.ScopeAll),
+			tool: "schedule_post",
+			args: scheduleArgs("ch-b"),
+		},
+		{
+			name:      "client subscribed only to a foreign channel",
+			ctx:       tokenCtx([]string{"ws-a"}, domainMCPServer.ScopeClientsRead),
+			tool:      "get_client",
+			args:      map[string]any{"client_id": "cl-b"},
+			wantError: "client not found",
+		},
+		{
+			name:      "unknown client",
+			ctx:       tokenCtx([]string{"ws-a"}, domainMCPServer.ScopeClientsRead),
+			tool:      "get_client",
+			args:      map[string]any{"client_id": "cl-missing"},
+			wantError: "client not found",
+		},
+		{
+			name: "client subscribed to own channel",
+			ctx:  tokenCtx([]string{"ws-a"}, domainMCPServer.ScopeClientsRead),
+			tool: "get_client",
+			args: map[string]any{"client_id": "cl-a"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts.newsletter.scheduled = nil
+			result := ts.call(t, tt.ctx, tt.tool, tt.args)
+			text := resultText(result)
+			if tt.wantError == "" {
+				assert.False(t, result.IsError, text)
+				return
+			}
+			assert.True(t, result.IsError)
+			assert.Equal(t, tt.wantError, text)
+			assert.Empty(t, ts.newsletter.scheduled, "a denied call must not schedule anything")
+		})
+	}
+}
+
+func TestServer_GetClientHidesForeignSubscriptions(t *testing.T) {
+	ts := newTestServer(t)
+	ctx := context.Background()
+
+	// cl-a also gets a subscription on ch-b, which the ws-a token must not see
+	require.NoError(t, ts.subs.Create(ctx, &clientsDomain.ClientSubscription{
+		ID:        "sub-cl-a-b",
+		ClientID:  "cl-a",
+		ChannelID: "ch-b",
+		Status:    clientsDomain.SubscriptionActive,
+	}))
+
+	result := ts.call(t, tokenCtx([]string{"ws-a"}, domainMCPServer.ScopeClientsRead), "get_client", map[string]any{"client_id": "cl-a"})
+	require.False(t, result.IsError, resultText(result))
+	text := resultText(result)
+	assert.Contains(t, text, `"channel_id":"ch-a"`)
+	assert.NotContains(t, text, "ch-b")
+}
@@ -0,0 +1,228 @@
+package application
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	domainMCPServer "github.com/AzielCF/az-wap/core/common/mcpserver/domain"
+	db_pkg "github.com/AzielCF/az-wap/core/pkg/db"
+	pkgError "github.com/AzielCF/az-wap/core/pkg/error"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// --- Persistence Model ---
+
+type accessTokenModel struct {
+	ID           string     `gorm:"primaryKey;column:id"`
+	Name         string     `gorm:"column:name;not null"`
+	TokenPrefix  string     `gorm:"column:token_prefix;not null"`
+	TokenHash    string     `gorm:"column:token_hash;uniqueIndex;not null"`
+	WorkspaceIDs string     `gorm:"column:workspace_ids;type:text"`
+	Scopes       string     `gorm:"column:scopes;type:text"`
+	Enabled      bool       `gorm:"column:enabled;default:true"`
+	ExpiresAt    *time.Time `gorm:"column:expires_at"`
+	LastUsedAt   *time.Time `gorm:"column:last_used_at"`
+	CreatedAt    time.Time  `gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt    time.Time  `gorm:"column:updated_at;autoUpdateTime"`
+}
+
+func (accessTokenModel) TableName() string {
+	return "mcp_access_tokens"
+}
+
+func (m accessTokenModel) toDomain() domainMCPServer.AccessToken {
+	token := domainMCPServer.AccessToken{
+		ID:          m.ID,
+		Name:        m.Name,
+		TokenPrefix: m.TokenPrefix,
+		Enabled:     m.Enabled,
+		ExpiresAt:   m.ExpiresAt,
+		LastUsedAt:  m.LastUsedAt,
+		CreatedAt:   m.CreatedAt,
+	}
+	_ = json.Unmarshal([]byte(m.WorkspaceIDs), &token.WorkspaceIDs)
+	_ = json.Unmarshal([]byte(m.Scopes), &token.Scopes)
+	return token
+}
+
+type tokenService struct {
+	db *gorm.DB
+}
+
+func NewTokenService(db *gorm.DB) domainMCPServer.ITokenUsecase {
+	s := &tokenService{db: db}
+	if db != nil {
+		models := map[string]interface{}{
+			"mcp_access_tokens": &accessTokenModel{},
+		}
+		if err := db_pkg.SafeMigrateSQLite(context.Background(), db, models); err != nil {
+			logrus.WithError(err).Error("[MCP_SERVER] failed to init token schema")
+		}
+	} else {
+		logrus.Error("[MCP_SERVER] GORM DB is nil, token service will be disabled")
+	}
+	return s
+}
+
+func (s *tokenService) ensureDB() error {
+	if s.db == nil {
+		return pkgError.InternalServerError("mcp token storage is not initialized")
+	}
+	return nil
+}
+
+// hashToken returns the hex sha256 of a raw token. Tokens are high-entropy
+// random strings, so a plain digest is enough for lookup without storing secrets.
+func hashToken(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
+
+func generateRawToken() (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return domainMCPServer.TokenPrefix + hex.EncodeToString(buf), nil
+}
+
+func (s *tokenService) Create(ctx context.Context, req domainMCPServer.CreateTokenRequest) (domainMCPServer.CreatedToken, error) {
+	if err := s.ensureDB(); err != nil {
+		return domainMCPServer.CreatedToken{}, err
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return domainMCPServer.CreatedToken{}, pkgError.ValidationError("name: cannot be blank.")
+	}
+
+	var workspaces []string
+	for _, id := range req.WorkspaceIDs {
+		if id = strings.TrimSpace(id); id != "" {
+			workspaces = append(workspaces, id)
+		}
+	}
+	if len(workspaces) == 0 {
+		return domainMCPServer.CreatedToken{}, pkgError.ValidationError("workspace_ids: at least one workspace is required.")
+	}
+
+	if len(req.Scopes) == 0 {
+		return domainMCPServer.CreatedToken{}, pkgError.ValidationError("scopes: at least one scope is required.")
+	}
+	for _, sc := range req.Scopes {
+		if !domainMCPServer.IsValidScope(sc) {
+			return domainMCPServer.CreatedToken{}, pkgError.ValidationError("scopes: unknown scope " + string(sc))
+		}
+	}
+
+	raw, err := generateRawToken()
+	if err != nil {
+		return domainMCPServer.CreatedToken{}, pkgError.InternalServerError("failed to generate token")
+	}
+
+	wsJSON, _ := json.Marshal(workspaces)
+	scopesJSON, _ := json.Marshal(req.Scopes)
+
+	model := accessTokenModel{
+		ID:           uuid.NewString(),
+		Name:         name,
+		TokenPrefix:  raw[:len(domainMCPServer.TokenPrefix)+6],
+		TokenHash:    hashToken(raw),
+		WorkspaceIDs: string(wsJSON),
+		Scopes:       string(scopesJSON),
+		Enabled:      true,
+		ExpiresAt:    req.ExpiresAt,
+	}
+	if err := s.db.WithContext(ctx).Create(&model).Error; err != nil {
+		return domainMCPServer.CreatedToken{}, pkgError.InternalServerError(err.Error())
+	}
+
+	return domainMCPServer.CreatedToken{AccessToken: model.toDomain(), Token: raw}, nil
+}
+
+func (s *tokenService) List(ctx context.Context) ([]domainMCPServer.AccessToken, error) {
+	if err := s.ensureDB(); err != nil {
+		return nil, err
+	}
+	var models []accessTokenModel
+	if err := s.db.WithContext(ctx).Order("created_at DESC").Find(&models).Error; err != nil {
+		return nil, pkgError.InternalServerError(err.Error())
+	}
+	out := make([]domainMCPServer.AccessToken, 0, len(models))
+	for _, m := range models {
+		out = append(out, m.toDomain())
+	}
+	return out, nil
+}
+
+func (s *tokenService) GetByID(ctx context.Context, id string) (domainMCPServer.AccessToken, error) {
+	if err := s.ensureDB(); err != nil {
+		return domainMCPServer.AccessToken{}, err
+	}
+	if strings.TrimSpace(id) == "" {
+		return domainMCPServer.AccessToken{}, pkgError.ValidationError("id: cannot be blank.")
+	}
+	var model accessTokenModel
+	if err := s.db.WithContext(ctx).First(&model, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return domainMCPServer.AccessToken{}, pkgError.NotFoundError("mcp token not found")
+		}
+		return domainMCPServer.AccessToken{}, pkgError.InternalServerError(err.Error())
+	}
+	return model.toDomain(), nil
+}
+
+func (s *tokenService) Revoke(ctx context.Context, id string) error {
+	if _, err := s.GetByID(ctx, id); err != nil {
+		return err
+	}
+	return s.db.WithContext(ctx).Model(&accessTokenModel{}).Where("id = ?", id).Update("enabled", false).Error
+}
+
+func (s *tokenService) Delete(ctx context.Context, id string) error {
+	if err := s.ensureDB(); err != nil {
+		return err
+	}
+	if strings.TrimSpace(id) == "" {
+		return pkgError.ValidationError("id: cannot be blank.")
+	}
+	return s.db.WithContext(ctx).Delete(&accessTokenModel{}, "id = ?", id).Error
+}
+
+func (s *tokenService) Authenticate(ctx context.Context, rawToken string) (domainMCPServer.AccessToken, error) {
+	if err := s.ensureDB(); err != nil {
+		return domainMCPServer.AccessToken{}, err
+	}
+	rawToken = strings.TrimSpace(rawToken)
+	if !strings.HasPrefix(rawToken, domainMCPServer.TokenPrefix) {
+		return domainMCPServer.AccessToken{}, pkgError.AuthError("invalid mcp token")
+	}
+
+	hash := hashToken(rawToken)
+	var model accessTokenModel
+	if err := s.db.WithContext(ctx).First(&model, "token_hash = ?", hash).Error; err != nil {
+		return domainMCPServer.AccessToken{}, pkgError.AuthError("invalid mcp token")
+	}
+	if subtle.ConstantTimeCompare([]byte(model.TokenHash), []byte(hash)) != 1 {
+		return domainMCPServer.AccessToken{}, pkgError.AuthError("invalid mcp token")
+	}
+
+	token := model.toDomain()
+	now := time.Now()
+	if !token.IsUsable(now) {
+		return domainMCPServer.AccessToken{}, pkgError.AuthError("mcp token revoked or expired")
+	}
+
+	s.db.WithContext(ctx).Model(&accessTokenModel{}).Where("id = ?", model.ID).Update("last_used_at", now)
+	token.LastUsedAt = &now
+	return token, nil
+}
@@ -0,0 +1,113 @@
+package application
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	domainMCPServer "github.com/AzielCF/az-wap/core/common/mcpserver/domain"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func newTestTokenService(t *testing.T) domainMCPServer.ITokenUsecase {
+	t.Helper()
+
+	dbPath := filepath.Join(t.TempDir(), "test_app.db")
+	db, err := gorm.Open(sqlite.Open("file:"+dbPath), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("Failed to open test database: %v", err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return NewTokenService(db)
+}
+
+func TestTokenService_CreateAndAuthenticate(t *testing.T) {
+	svc := newTestTokenService(t)
+	ctx := context.Background()
+
+	created, err := svc.Create(ctx, domainMCPServer.CreateTokenRequest{
+		Name:         "agent",
+		WorkspaceIDs: []string{"ws-1"},
+		Scopes:       []domainMCPServer.Scope{domainMCPServer.ScopeMessagesSend},
+	})
+	if err != nil {
+		t.Fatalf("Create() unexpected error: %v", err)
+	}
+	if !strings.HasPrefix(created.Token, domainMCPServer.TokenPrefix) {
+		t.Fatalf("Create() token %q missing prefix", created.Token)
+	}
+
+	token, err := svc.Authenticate(ctx, created.Token)
+	if err != nil {
+		t.Fatalf("Authenticate() unexpected error: %v", err)
+	}
+	if !token.HasScope(domainMCPServer.ScopeMessagesSend) || token.HasScope(domainMCPServer.ScopeClientsRead) {
+		t.Fatalf("Authenticate() returned wrong scopes: %v", token.Scopes)
+	}
+	if !token.AllowsWorkspace("ws-1") || token.AllowsWorkspace("ws-2") {
+		t.Fatalf("Authenticate() returned wrong workspaces: %v", token.WorkspaceIDs)
+	}
+
+	if _, err := svc.Authenticate(ctx, created.Token+"x"); err == nil {
+		t.Fatalf("Authenticate() expected error for tampered token, got nil")
+	}
+}
+
+func TestTokenService_RevokedAndExpired(t *testing.T) {
+	svc := newTestTokenService(t)
+	ctx := context.Background()
+
+	created, err := svc.Create(ctx, domainMCPServer.CreateTokenRequest{
+		Name:         "agent",
+		WorkspaceIDs: []string{"ws-1"},
+		Scopes:       []domainMCPServer.Scope{domainMCPServer.ScopeAll},
+	})
+	if err != nil {
+		t.Fatalf("Create() unexpected error: %v", err)
+	}
+	if err := svc.Revoke(ctx, created.ID); err != nil {
+		t.Fatalf("Revoke() unexpected error: %v", err)
+	}
+	if _, err := svc.Authenticate(ctx, created.Token); err == nil {
+		t.Fatalf("Authenticate() expected error for revoked token, got nil")
+	}
+
+	past := time.Now().Add(-time.Hour)
+	expired, err := svc.Create(ctx, domainMCPServer.CreateTokenRequest{
+		Name:         "old",
+		WorkspaceIDs: []string{"ws-1"},
+		Scopes:       []domainMCPServer.Scope{domainMCPServer.ScopeAll},
+		ExpiresAt:    &past,
+	})
+	if err != nil {
+		t.Fatalf("Create() unexpected error: %v", err)
+	}
+	if _, err := svc.Authenticate(ctx, expired.Token); err == nil {
+		t.Fatalf("Authenticate() expected error for expired token, got nil")
+	}
+}
+
+func TestTokenService_Create_Validation(t *testing.T) {
+	svc := newTestTokenService(t)
+	ctx := context.Background()
+
+	cases := []domainMCPServer.CreateTokenRequest{
+		{Name: "", WorkspaceIDs: []string{"ws"}, Scopes: []domainMCPServer.Scope{domainMCPServer.ScopeAll}},
+		{Name: "a", Scopes: []domainMCPServer.Scope{domainMCPServer.ScopeAll}},
+		{Name: "a", WorkspaceIDs: []string{"ws"}},
+		{Name: "a", WorkspaceIDs: []string{"ws"}, Scopes: []domainMCPServer.Scope{"bogus"}},
+	}
+	for i, req := range cases {
+		if _, err := svc.Create(ctx, req); err == nil {
+			t.Fatalf("case %d: Create() expected validation error, got nil", i)
+		}
+	}
+}
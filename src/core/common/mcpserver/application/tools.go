@@ -0,0 +1,491 @@
+package application
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	clientsDomain "github.com/AzielCF/az-wap/clients/domain"
+	domainNewsletter "github.com/AzielCF/az-wap/core/common/channel/newsletter/domain"
+	domainMCPServer "github.com/AzielCF/az-wap/core/common/mcpserver/domain"
+	"github.com/AzielCF/az-wap/core/pkg/utils"
+	"github.com/AzielCF/az-wap/workspace/domain/common"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func (s *Server) tools() []scopedTool {
+	return []scopedTool{
+		{
+			scope: domainMCPServer.ScopeChatsRead,
+			tool: mcp.NewTool("list_channels",
+				mcp.WithDescription("List the channels available to this token with their status."),
+				mcp.WithReadOnlyHintAnnotation(true),
+			),
+			handler: s.handleListChannels,
+		},
+		{
+			scope: domainMCPServer.ScopeMessagesSend,
+			tool: mcp.NewTool("send_message",
+				mcp.WithDescription("Send a text message to a chat through a channel."),
+				mcp.WithString("channel_id", mcp.Required(), mcp.Description("Channel to send from")),
+				mcp.WithString("chat_id", mcp.Required(), mcp.Description("Destination chat (phone JID, group JID or platform chat id)")),
+				mcp.WithString("text", mcp.Required(), mcp.Description("Message body")),
+				mcp.WithString("quote_message_id", mcp.Description("Optional message id to reply to")),
+			),
+			handler: s.handleSendMessage,
+		},
+		{
+			scope: domainMCPServer.ScopeMessagesSend,
+			tool: mcp.NewTool("send_media",
+				mcp.WithDescription("Send an image, video, audio or document to a chat. Provide either url or base64 data."),
+				mcp.WithString("channel_id", mcp.Required(), mcp.Description("Channel to send from")),
+				mcp.WithString("chat_id", mcp.Required(), mcp.Description("Destination chat")),
+				mcp.WithString("media_type", mcp.Required(), mcp.Enum("image", "video", "audio", "document")),
+				mcp.WithString("url", mcp.Description("Public URL of the file")),
+				mcp.WithString("data", mcp.Description("Base64 encoded file content")),
+				mcp.WithString("file_name", mcp.Description("File name (required with data)")),
+				mcp.WithString("mime_type", mcp.Description("MIME type (required with data)")),
+				mcp.WithString("caption", mcp.Description("Optional caption")),
+			),
+			handler: s.handleSendMedia,
+		},
+		{
+			scope: domainMCPServer.ScopeChatsRead,
+			tool: mcp.NewTool("list_chats",
+				mcp.WithDescription("List chats that currently have an active bot session on a channel."),
+				mcp.WithString("channel_id", mcp.Required()),
+				mcp.WithReadOnlyHintAnnotation(true),
+			),
+			handler: s.handleListChats,
+		},
+		{
+			scope: domainMCPServer.ScopeSessionsRead,
+			tool: mcp.NewTool("get_session_history",
+				mcp.WithDescription("Read the conversation kept in the active bot session of a chat."),
+				mcp.WithString("channel_id", mcp.Required()),
+				mcp.WithString("chat_id", mcp.Required()),
+				mcp.WithReadOnlyHintAnnotation(true),
+			),
+			handler: s.handleSessionHistory,
+		},
+		{
+			scope: domainMCPServer.ScopeScheduleWrite,
+			tool: mcp.NewTool("schedule_post",
+				mcp.WithDescription("Schedule a reminder or post to be sent later, optionally repeating on weekdays."),
+				mcp.WithString("channel_id", mcp.Required()),
+				mcp.WithString("target_id", mcp.Required(), mcp.Description("Chat, group or newsletter id")),
+				mcp.WithString("text", mcp.Required()),
+				mcp.WithString("scheduled_at", mcp.Required(), mcp.Description("RFC3339 timestamp")),
+				mcp.WithString("recurrence_days", mcp.Description("Comma separated weekdays, 0=Sunday (e.g. \"1,3,5\")")),
+				mcp.WithString("rrule", mcp.Description("RFC 5545 recurrence rule, e.g. \"FREQ=MONTHLY;BYDAY=-1FR;COUNT=6\". Takes precedence over recurrence_days")),
+				mcp.WithString("timezone", mcp.Description("IANA timezone the recurrence is evaluated in (default UTC)")),
+				mcp.WithObject("payload", mcp.Description("Typed content instead of text: {\"parts\":[{\"type\":\"text|poll|location|link\", ...}]}. Parts are sent in order; mark a part \"optional\" to keep going if it fails")),
+			),
+			handler: s.handleSchedulePost,
+		},
+		{
+			scope: domainMCPServer.ScopeScheduleWrite,
+			tool: mcp.NewTool("list_scheduled_posts",
+				mcp.WithDescription("List scheduled posts of a channel."),
+				mcp.WithString("channel_id", mcp.Required()),
+				mcp.WithReadOnlyHintAnnotation(true),
+			),
+			handler: s.handleListScheduled,
+		},
+		{
+			scope: domainMCPServer.ScopeScheduleWrite,
+			tool: mcp.NewTool("cancel_scheduled_post",
+				mcp.WithDescription("Cancel a scheduled post."),
+				mcp.WithString("post_id", mcp.Required()),
+				mcp.WithDestructiveHintAnnotation(true),
+			),
+			handler: s.handleCancelScheduled,
+		},
+		{
+			scope: domainMCPServer.ScopeGroupsManage,
+			tool: mcp.NewTool("list_groups",
+				mcp.WithDescription("List groups the channel account has joined."),
+				mcp.WithString("channel_id", mcp.Required()),
+				mcp.WithReadOnlyHintAnnotation(true),
+			),
+			handler: s.handleListGroups,
+		},
+		{
+			scope: domainMCPServer.ScopeGroupsManage,
+			tool: mcp.NewTool("get_group_info",
+				mcp.WithDescription("Get group details and participants."),
+				mcp.WithString("channel_id", mcp.Required()),
+				mcp.WithString("group_id", mcp.Required()),
+				mcp.WithReadOnlyHintAnnotation(true),
+			),
+			handler: s.handleGroupInfo,
+		},
+		{
+			scope: domainMCPServer.ScopeGroupsManage,
+			tool: mcp.NewTool("update_group_participants",
+				mcp.WithDescription("Add, remove, promote or demote group participants."),
+				mcp.WithString("channel_id", mcp.Required()),
+				mcp.WithString("group_id", mcp.Required()),
+				mcp.WithString("action", mcp.Required(), mcp.Enum("add", "remove", "promote", "demote")),
+				mcp.WithArray("participants", mcp.Required(), mcp.WithStringItems(), mcp.Description("Phone numbers or JIDs")),
+				mcp.WithDestructiveHintAnnotation(true),
+			),
+			handler: s.handleUpdateParticipants,
+		},
+		{
+			scope: domainMCPServer.ScopeClientsRead,
+			tool: mcp.NewTool("list_clients",
+				mcp.WithDescription("List clients subscribed to a channel."),
+				mcp.WithString("channel_id", mcp.Required()),
+				mcp.WithReadOnlyHintAnnotation(true),
+			),
+			handler: s.handleListClients,
+		},
+		{
+			scope: domainMCPServer.ScopeClientsRead,
+			tool: mcp.NewTool("get_client",
+				mcp.WithDescription("Get a client profile. Only clients subscribed to your channels are visible."),
+				mcp.WithString("client_id", mcp.Required()),
+				mcp.WithReadOnlyHintAnnotation(true),
+			),
+			handler: s.handleGetClient,
+		},
+	}
+}
+
+func (s *Server) handleListChannels(ctx context.Context, _ mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	chs, err := s.allowedChannels(ctx)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	type channelView struct {
+		ID          string `json:"id"`
+		WorkspaceID string `json:"workspace_id"`
+		Name        string `json:"name"`
+		Type        string `json:"type"`
+		Status      string `json:"status"`
+		Enabled     bool   `json:"enabled"`
+		Running     bool   `json:"running"`
+	}
+	out := make([]channelView, 0, len(chs))
+	for _, ch := range chs {
+		_, running := s.deps.Runtime.GetAdapter(ch.ID)
+		out = append(out, channelView{
+			ID:          ch.ID,
+			WorkspaceID: ch.WorkspaceID,
+			Name:        ch.Name,
+			Type:        string(ch.Type),
+			Status:      string(ch.Status),
+			Enabled:     ch.Enabled,
+			Running:     running,
+		})
+	}
+	return mcp.NewToolResultJSON(map[string]any{"channels": out})
+}
+
+func (s *Server) handleSendMessage(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	adapter, err := s.adapterFor(ctx, req.GetString("channel_id", ""))
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	chatID, err := req.RequireString("chat_id")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	text, err := req.RequireString("text")
+	if err != nil || strings.TrimSpace(text) == "" {
+		return mcp.NewToolResultError("text is required"), nil
+	}
+
+	resp, err := adapter.SendMessage(ctx, chatID, text, req.GetString("quote_message_id", ""))
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("send failed", err), nil
+	}
+	return mcp.NewToolResultJSON(resp)
+}
+
+func (s *Server) handleSendMedia(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	adapter, err := s.adapterFor(ctx, req.GetString("channel_id", ""))
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	chatID, err := req.RequireString("chat_id")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	mediaType := common.MediaType(req.GetString("media_type", ""))
+	upload := common.MediaUpload{
+		Type:    mediaType,
+		Caption: req.GetString("caption", ""),
+	}
+
+	if data := req.GetString("data", ""); data != "" {
+		raw, err := base64.StdEncoding.DecodeString(data)
+		if err != nil {
+			return mcp.NewToolResultError("data is not valid base64"), nil
+		}
+		upload.Data = raw
+		upload.FileName = req.GetString("file_name", "file")
+		upload.MimeType = req.GetString("mime_type", http.DetectContentType(raw))
+	} else if url := req.GetString("url", ""); url != "" {
+		var (
+			raw  []byte
+			name string
+		)
+		switch mediaType {
+		case common.MediaTypeImage:
+			raw, name, err = utils.DownloadImageFromURL(url)
+		case common.MediaTypeAudio:
+			raw, name, err = utils.DownloadAudioFromURL(url)
+		case common.MediaTypeVideo:
+			raw, name, err = utils.DownloadVideoFromURL(url)
+		default:
+			return mcp.NewToolResultError("documents must be sent as base64 data"), nil
+		}
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("download failed", err), nil
+		}
+		upload.Data = raw
+		upload.FileName = name
+		upload.MimeType = http.DetectContentType(raw)
+	} else {
+		return mcp.NewToolResultError("either url or data is required"), nil
+	}
+
+	resp, err := adapter.SendMedia(ctx, chatID, upload, "")
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("send failed", err), nil
+	}
+	return mcp.NewToolResultJSON(resp)
+}
+
+func (s *Server) handleListChats(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	channelID := req.GetString("channel_id", "")
+	if _, err := s.authorizeChannel(ctx, channelID); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	chats := s.deps.Runtime.GetActiveChats(channelID)
+	if chats == nil {
+		chats = []string{}
+	}
+	return mcp.NewToolResultJSON(map[string]any{"chats": chats})
+}
+
+func (s *Server) handleSessionHistory(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	channelID := req.GetString("channel_id", "")
+	if _, err := s.authorizeChannel(ctx, channelID); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	chatID, err := req.RequireString("chat_id")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	type turnView struct {
+		Role      string   `json:"role"`
+		Text      string   `json:"text,omitempty"`
+		ToolCalls []string `json:"tool_calls,omitempty"`
+	}
+	history := s.deps.Runtime.GetSessionHistory(channelID, chatID)
+	turns := make([]turnView, 0, len(history))
+	for _, t := range history {
+		v := turnView{Role: t.Role, Text: t.Text}
+		for _, tc := range t.ToolCalls {
+			v.ToolCalls = append(v.ToolCalls, tc.Name)
+		}
+		turns = append(turns, v)
+	}
+	return mcp.NewToolResultJSON(map[string]any{"active": history != nil, "turns": turns})
+}
+
+func (s *Server) handleSchedulePost(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	channelID := req.GetString("channel_id", "")
+	if _, err := s.authorizeChannel(ctx, channelID); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	at, err := time.Parse(time.RFC3339, req.GetString("scheduled_at", ""))
+	if err != nil {
+		return mcp.NewToolResultError("scheduled_at must be an RFC3339 timestamp"), nil
+	}
+	var args struct {
+		Payload *common.ScheduledPayload `json:"payload"`
+	}
+	if err := req.BindArguments(&args); err != nil {
+		return mcp.NewToolResultError("payload is malformed"), nil
+	}
+	if args.Payload != nil {
+		for _, part := range args.Payload.Parts {
+			// Los clientes MCP no pueden referenciar archivos del servidor
+			if part.Type == common.PayloadPartMedia {
+				return mcp.NewToolResultError("media parts cannot be scheduled over MCP"), nil
+			}
+		}
+	}
+	token, _ := domainMCPServer.TokenFromContext(ctx)
+
+	post, err := s.deps.Newsletter.SchedulePost(ctx, domainNewsletter.SchedulePostRequest{
+		ChannelID:      channelID,
+		TargetID:       req.GetString("target_id", ""),
+		SenderID:       "mcp:" + token.ID,
+		Text:           req.GetString("text", ""),
+		ScheduledAt:    at,
+		RecurrenceDays: req.GetString("recurrence_days", ""),
+		RRule:          req.GetString("rrule", ""),
+		Timezone:       req.GetString("timezone", ""),
+		Payload:        args.Payload,
+	})
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("schedule failed", err), nil
+	}
+	return mcp.NewToolResultJSON(post)
+}
+
+func (s *Server) handleListScheduled(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	channelID := req.GetString("channel_id", "")
+	if _, err := s.authorizeChannel(ctx, channelID); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	posts, err := s.deps.Newsletter.ListScheduled(ctx, channelID)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("list failed", err), nil
+	}
+	return mcp.NewToolResultJSON(map[string]any{"posts": posts})
+}
+
+func (s *Server) handleCancelScheduled(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	postID, err := req.RequireString("post_id")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	post, err := s.deps.Workspaces.GetScheduledPost(ctx, postID)
+	if err != nil {
+		return mcp.NewToolResultError("post not found"), nil
+	}
+	if _, err := s.authorizeChannel(ctx, post.ChannelID); err != nil {
+		return mcp.NewToolResultError("post not found"), nil
+	}
+	if err := s.deps.Newsletter.CancelScheduled(ctx, postID); err != nil {
+		return mcp.NewToolResultErrorFromErr("cancel failed", err), nil
+	}
+	return mcp.NewToolResultText("cancelled"), nil
+}
+
+func (s *Server) handleListGroups(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	adapter, err := s.adapterFor(ctx, req.GetString("channel_id", ""))
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	groups, err := adapter.GetJoinedGroups(ctx)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("list groups failed", err), nil
+	}
+	type groupView struct {
+		JID          string `json:"jid"`
+		Name         string `json:"name"`
+		Participants int    `json:"participants"`
+		IsAnnounce   bool   `json:"is_announce"`
+		IsCommunity  bool   `json:"is_community"`
+	}
+	out := make([]groupView, 0, len(groups))
+	for _, g := range groups {
+		out = append(out, groupView{JID: g.JID, Name: g.Name, Participants: len(g.Participants), IsAnnounce: g.IsAnnounce, IsCommunity: g.IsCommunity})
+	}
+	return mcp.NewToolResultJSON(map[string]any{"groups": out})
+}
+
+func (s *Server) handleGroupInfo(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	adapter, err := s.adapterFor(ctx, req.GetString("channel_id", ""))
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	groupID, err := req.RequireString("group_id")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	info, err := adapter.GetGroupInfo(ctx, groupID)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("get group failed", err), nil
+	}
+	return mcp.NewToolResultJSON(info)
+}
+
+func (s *Server) handleUpdateParticipants(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	adapter, err := s.adapterFor(ctx, req.GetString("channel_id", ""))
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	groupID, err := req.RequireString("group_id")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	participants, err := req.RequireStringSlice("participants")
+	if err != nil || len(participants) == 0 {
+		return mcp.NewToolResultError("participants is required"), nil
+	}
+
+	action := common.ParticipantAction(req.GetString("action", ""))
+	switch action {
+	case common.ParticipantActionAdd, common.ParticipantActionRemove,
+		common.ParticipantActionPromote, common.ParticipantActionDemote:
+	default:
+		return mcp.NewToolResultError(fmt.Sprintf("unsupported action %q", action)), nil
+	}
+
+	if err := adapter.UpdateGroupParticipants(ctx, groupID, participants, action); err != nil {
+		return mcp.NewToolResultErrorFromErr("update failed", err), nil
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("%s applied to %d participant(s)", action, len(participants))), nil
+}
+
+func (s *Server) handleListClients(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	channelID := req.GetString("channel_id", "")
+	if _, err := s.authorizeChannel(ctx, channelID); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	subs, err := s.deps.Subscriptions.ListByChannel(ctx, channelID)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("list failed", err), nil
+	}
+
+	clients := make([]*clientsDomain.Client, 0, len(subs))
+	for _, sub := range subs {
+		client, err := s.deps.Clients.GetByID(ctx, sub.ClientID)
+		if err != nil || client == nil {
+			continue
+		}
+		clients = append(clients, client)
+	}
+	return mcp.NewToolResultJSON(map[string]any{"clients": clients})
+}
+
+func (s *Server) handleGetClient(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	clientID, err := req.RequireString("client_id")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	subs, err := s.deps.Subscriptions.ListByClient(ctx, clientID)
+	if err != nil {
+		return mcp.NewToolResultError("client not found"), nil
+	}
+
+	// Solo las suscripciones de canales que el token puede ver: las de otros workspaces no se filtran
+	visible := make([]*clientsDomain.ClientSubscription, 0, len(subs))
+	for _, sub := range subs {
+		if _, err := s.authorizeChannel(ctx, sub.ChannelID); err == nil {
+			visible = append(visible, sub)
+		}
+	}
+	if len(visible) == 0 {
+		return mcp.NewToolResultError("client not found"), nil
+	}
+
+	client, err := s.deps.Clients.GetByID(ctx, clientID)
+	if err != nil || client == nil {
+		return mcp.NewToolResultError("client not found"), nil
+	}
+	return mcp.NewToolResultJSON(map[string]any{"client": client, "subscriptions": visible})
+}
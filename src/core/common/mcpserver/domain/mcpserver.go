@@ -0,0 +1,131 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// Scope limits which group of MCP tools an access token can use.
+type Scope string
+
+const (
+	ScopeMessagesSend  Scope = "messages:send"
+	ScopeChatsRead     Scope = "chats:read"
+	ScopeSessionsRead  Scope = "sessions:read"
+	ScopeScheduleWrite Scope = "schedule:write"
+	ScopeGroupsManage  Scope = "groups:manage"
+	ScopeClientsRead   Scope = "clients:read"
+	ScopeAll           Scope = "*"
+)
+
+const (
+	// TokenPrefix makes MCP tokens easy to recognise in logs and secret scanners.
+	TokenPrefix = "azmcp_"
+	// DefaultEndpointPath is where the streamable HTTP transport is served.
+	DefaultEndpointPath = "/mcp"
+)
+
+// AllScopes returns every scope an access token can be granted.
+func AllScopes() []Scope {
+	return []Scope{
+		ScopeMessagesSend,
+		ScopeChatsRead,
+		ScopeSessionsRead,
+		ScopeScheduleWrite,
+		ScopeGroupsManage,
+		ScopeClientsRead,
+	}
+}
+
+// IsValidScope reports whether s is a known scope (or the wildcard).
+func IsValidScope(s Scope) bool {
+	if s == ScopeAll {
+		return true
+	}
+	for _, known := range AllScopes() {
+		if known == s {
+			return true
+		}
+	}
+	return false
+}
+
+// AccessToken authorizes an external MCP client against a set of workspaces.
+// The raw secret is only returned once on creation; only its hash is stored.
+type AccessToken struct {
+	ID           string     `json:"id"`
+	Name         string     `json:"name"`
+	TokenPrefix  string     `json:"token_prefix"`
+	WorkspaceIDs []string   `json:"workspace_ids"`
+	Scopes       []Scope    `json:"scopes"`
+	Enabled      bool       `json:"enabled"`
+	ExpiresAt    *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt   *time.Time `json:"last_used_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+// HasScope reports whether the token grants the given scope.
+func (t *AccessToken) HasScope(scope Scope) bool {
+	for _, s := range t.Scopes {
+		if s == scope || s == ScopeAll {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowsWorkspace reports whether the token can operate on the given workspace.
+func (t *AccessToken) AllowsWorkspace(workspaceID string) bool {
+	for _, id := range t.WorkspaceIDs {
+		if id == workspaceID || id == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// IsUsable reports whether the token is enabled and not expired.
+func (t *AccessToken) IsUsable(now time.Time) bool {
+	if !t.Enabled {
+		return false
+	}
+	if t.ExpiresAt != nil && now.After(*t.ExpiresAt) {
+		return false
+	}
+	return true
+}
+
+type CreateTokenRequest struct {
+	Name         string     `json:"name"`
+	WorkspaceIDs []string   `json:"workspace_ids"`
+	Scopes       []Scope    `json:"scopes"`
+	ExpiresAt    *time.Time `json:"expires_at,omitempty"`
+}
+
+// CreatedToken is returned once on creation and carries the plaintext secret.
+type CreatedToken struct {
+	AccessToken
+	Token string `json:"token"`
+}
+
+type ITokenUsecase interface {
+	Create(ctx context.Context, req CreateTokenRequest) (CreatedToken, error)
+	List(ctx context.Context) ([]AccessToken, error)
+	GetByID(ctx context.Context, id string) (AccessToken, error)
+	Revoke(ctx context.Context, id string) error
+	Delete(ctx context.Context, id string) error
+	Authenticate(ctx context.Context, rawToken string) (AccessToken, error)
+}
+
+type tokenCtxKey struct{}
+
+// WithToken stores the authenticated token in the request context.
+func WithToken(ctx context.Context, token AccessToken) context.Context {
+	return context.WithValue(ctx, tokenCtxKey{}, token)
+}
+
+// TokenFromContext returns the authenticated token stored by WithToken.
+func TokenFromContext(ctx context.Context) (AccessToken, bool) {
+	token, ok := ctx.Value(tokenCtxKey{}).(AccessToken)
+	return token, ok
+}
@@ -0,0 +1,120 @@
+package infrastructure
+
+import (
+	domainMCPServer "github.com/AzielCF/az-wap/core/common/mcpserver/domain"
+	pkgError "github.com/AzielCF/az-wap/core/pkg/error"
+	"github.com/AzielCF/az-wap/core/pkg/utils"
+	"github.com/gofiber/fiber/v2"
+)
+
+type MCPServerTokens struct {
+	Service domainMCPServer.ITokenUsecase
+}
+
+// InitRestMCPServer registers the admin endpoints used to issue and revoke
+// tokens for the exposed MCP server.
+func InitRestMCPServer(app fiber.Router, service domainMCPServer.ITokenUsecase) MCPServerTokens {
+	rest := MCPServerTokens{Service: service}
+
+	group := app.Group("/mcp-server")
+	group.Get("/scopes", rest.ListScopes)
+	group.Get("/tokens", rest.ListTokens)
+	group.Post("/tokens", rest.CreateToken)
+	group.Get("/tokens/:id", rest.GetToken)
+	group.Post("/tokens/:id/revoke", rest.RevokeToken)
+	group.Delete("/tokens/:id", rest.DeleteToken)
+
+	return rest
+}
+
+func (h *MCPServerTokens) ListScopes(c *fiber.Ctx) error {
+	return c.JSON(utils.ResponseData{
+		Status:  200,
+		Code:    "SUCCESS",
+		Results: domainMCPServer.AllScopes(),
+	})
+}
+
+func (h *MCPServerTokens) ListTokens(c *fiber.Ctx) error {
+	tokens, err := h.Service.List(c.UserContext())
+	if err != nil {
+		return handleError(c, err)
+	}
+	return c.JSON(utils.ResponseData{
+		Status:  200,
+		Code:    "SUCCESS",
+		Message: "MCP tokens retrieved",
+		Results: tokens,
+	})
+}
+
+func (h *MCPServerTokens) CreateToken(c *fiber.Ctx) error {
+	var req domainMCPServer.CreateTokenRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(utils.ResponseData{
+			Status:  400,
+			Code:    "BAD_REQUEST",
+			Message: err.Error(),
+		})
+	}
+	created, err := h.Service.Create(c.UserContext(), req)
+	if err != nil {
+		return handleError(c, err)
+	}
+	return c.Status(201).JSON(utils.ResponseData{
+		Status:  201,
+		Code:    "SUCCESS",
+		Message: "MCP token created. Store it now, it will not be shown again",
+		Results: created,
+	})
+}
+
+func (h *MCPServerTokens) GetToken(c *fiber.Ctx) error {
+	token, err := h.Service.GetByID(c.UserContext(), c.Params("id"))
+	if err != nil {
+		return handleError(c, err)
+	}
+	return c.JSON(utils.ResponseData{
+		Status:  200,
+		Code:    "SUCCESS",
+		Results: token,
+	})
+}
+
+func (h *MCPServerTokens) RevokeToken(c *fiber.Ctx) error {
+	if err := h.Service.Revoke(c.UserContext(), c.Params("id")); err != nil {
+		return handleError(c, err)
+	}
+	return c.JSON(utils.ResponseData{
+		Status:  200,
+		Code:    "SUCCESS",
+		Message: "MCP token revoked",
+	})
+}
+
+func (h *MCPServerTokens) DeleteToken(c *fiber.Ctx) error {
+	if err := h.Service.Delete(c.UserContext(), c.Params("id")); err != nil {
+		return handleError(c, err)
+	}
+	return c.JSON(utils.ResponseData{
+		Status:  200,
+		Code:    "SUCCESS",
+		Message: "MCP token deleted",
+	})
+}
+
+func handleError(c *fiber.Ctx, err error) error {
+	status := 500
+	code := "INTERNAL_SERVER_ERROR"
+
+	if gerr, ok := err.(pkgError.GenericError); ok {
+		status = gerr.StatusCode()
+		code = gerr.ErrCode()
+	}
+
+	return c.Status(status).JSON(utils.ResponseData{
+		Status:  status,
+		Code:    code,
+		Message: err.Error(),
+	})
+}
@@ -5,6 +5,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"go.mau.fi/whatsmeow/proto/waCompanionReg"
 )
@@ -21,6 +22,7 @@ type Config struct {
 	Security   SecurityConfig
 	APIKeys    APIKeysConfig
 	Telegram   TelegramConfig
+	Billing    BillingConfig
 }
 
 type AppConfig struct {
@@ -42,6 +44,18 @@ type AppConfig struct {
 type MCPConfig struct {
 	Port string
 	Host string
+	// ServerEnabled exposes az-wap itself as an MCP server on Host:Port.
+	ServerEnabled bool
+
+	// Stdio servers spawn local processes, so they are only available when an
+	// operator enables them through the environment (never through the API).
+	StdioEnabled      bool
+	StdioWorkDir      string        // Root of the per-server working directory jail
+	StdioEnvAllowlist []string      // Host env vars forwarded to the child process
+	StdioServerEnv    []string      // Env var names a server may set itself ("*" prefix/suffix wildcard)
+	StdioCPUSeconds   uint64        // RLIMIT_CPU
+	StdioMemoryMB     uint64        // RLIMIT_AS
+	StdioMaxLifetime  time.Duration // Wall-clock limit before the process is recycled
 }
 
 type PathsConfig struct {
@@ -89,11 +103,16 @@ type AIConfig struct {
 	MaxImageBytes      int64
 	MaxRAMDownloadMB   int
 	MaxGlobalRAMMB     int
+	ApprovalTimeout    time.Duration // Vida máxima de una llamada de herramienta pendiente de aprobación
 }
 
 type WorkerPoolConfig struct {
-	Size      int
-	QueueSize int
+	Size        int
+	QueueSize   int
+	Backend     string // auto | memory | valkey (cola durable de jobs)
+	Partitions  int    // Debe coincidir en todos los nodos que comparten Valkey
+	MaxAttempts int
+	TenantCap   int // Jobs simultáneos por workspace/cliente en cada nodo (0 = sin límite)
 }
 
 type SecurityConfig struct {
@@ -114,6 +133,15 @@ type TelegramConfig struct {
 	WebhookURL     string // Base URL for webhooks
 }
 
+// BillingConfig controla el ciclo de vida de las suscripciones (avisos, gracia y renovaciones).
+// Los avisos y la gracia se configuran por canal; aquí solo lo que es global.
+type BillingConfig struct {
+	LifecycleInterval time.Duration // Cada cuánto revisa el worker los vencimientos
+	WebhookURL        string        // Destino global de eventos de suscripción (además del webhook del canal)
+	WebhookSecret     string
+	PaymentSecret     string // HMAC del callback de pagos. Vacío = callback deshabilitado
+}
+
 // Global provides access to the loaded configuration globally (Migration Helper)
 var Global *Config
 
@@ -213,6 +241,29 @@ func LoadConfig() (*Config, error) {
 		MaxImageBytes:      getEnvInt64("AI_MAX_IMAGE_BYTES", 4*1024*1024),
 		MaxRAMDownloadMB:   getEnvInt("AI_MAX_RAM_DOWNLOAD_MB", 5),
 		MaxGlobalRAMMB:     getEnvInt("AI_MAX_GLOBAL_RAM_MB", 50),
+		ApprovalTimeout:    time.Duration(getEnvInt("AI_TOOL_APPROVAL_TIMEOUT_MIN", 15)) * time.Minute,
+	}
+
+	// MCP
+	stdioEnv := []string{"PATH", "LANG", "LC_ALL", "TZ"}
+	if v := os.Getenv("MCP_STDIO_ENV_ALLOWLIST"); v != "" {
+		stdioEnv = strings.Split(v, ",")
+	}
+	stdioServerEnv := []string{"API_KEY", "*_API_KEY", "*_TOKEN", "*_SECRET", "*_URL"}
+	if v := os.Getenv("MCP_STDIO_SERVER_ENV_ALLOWLIST"); v != "" {
+		stdioServerEnv = strings.Split(v, ",")
+	}
+	mcpCfg := MCPConfig{
+		Port:              getEnv("MCP_PORT", "8080"),
+		Host:              getEnv("MCP_HOST", "localhost"),
+		ServerEnabled:     getEnvBool("MCP_SERVER_ENABLED", false),
+		StdioEnabled:      getEnvBool("MCP_STDIO_ENABLED", false),
+		StdioWorkDir:      getEnv("MCP_STDIO_WORKDIR", filepath.Join(pathsCfg.Storages, "mcp-stdio")),
+		StdioEnvAllowlist: stdioEnv,
+		StdioServerEnv:    stdioServerEnv,
+		StdioCPUSeconds:   uint64(getEnvInt64("MCP_STDIO_CPU_SECONDS", 120)),
+		StdioMemoryMB:     uint64(getEnvInt64("MCP_STDIO_MEMORY_MB", 1024)),
+		StdioMaxLifetime:  time.Duration(getEnvInt("MCP_STDIO_MAX_LIFETIME_MIN", 60)) * time.Minute,
 	}
 
 	// Worker Pool & Security & API Keys
@@ -223,13 +274,20 @@ func LoadConfig() (*Config, error) {
 	}
 
 	cfg := &Config{
-		App:        appCfg,
-		MCP:        MCPConfig{Port: getEnv("MCP_PORT", "8080"), Host: getEnv("MCP_HOST", "localhost")},
-		Paths:      pathsCfg,
-		Database:   dbCfg,
-		Whatsapp:   waCfg,
-		AI:         aiCfg,
-		WorkerPool: WorkerPoolConfig{Size: poolSize, QueueSize: getEnvInt("MESSAGE_WORKER_QUEUE_SIZE", 1000)},
+		App:      appCfg,
+		MCP:      mcpCfg,
+		Paths:    pathsCfg,
+		Database: dbCfg,
+		Whatsapp: waCfg,
+		AI:       aiCfg,
+		WorkerPool: WorkerPoolConfig{
+			Size:        poolSize,
+			QueueSize:   getEnvInt("MESSAGE_WORKER_QUEUE_SIZE", 1000),
+			Backend:     getEnv("MESSAGE_QUEUE_BACKEND", "auto"),
+			Partitions:  getEnvInt("MESSAGE_QUEUE_PARTITIONS", 64),
+			MaxAttempts: getEnvInt("MESSAGE_QUEUE_MAX_ATTEMPTS", 5),
+			TenantCap:   getEnvInt("MESSAGE_WORKER_TENANT_CAP", 0),
+		},
 		Security: SecurityConfig{
 			SecretKey:         getEnv("APP_SECRET_KEY", "changeme_please_change_me_in_prod_12345"),
 			PortalJWTSecret:   getEnv("PORTAL_JWT_SECRET", getEnv("APP_SECRET_KEY", "changeme_portal_jwt")),
@@ -245,6 +303,12 @@ func LoadConfig() (*Config, error) {
 			WebhookEnabled: getEnvBool("TELEGRAM_WEBHOOK_ENABLED", false),
 			WebhookURL:     getEnv("TELEGRAM_WEBHOOK_URL", ""),
 		},
+		Billing: BillingConfig{
+			LifecycleInterval: time.Duration(getEnvInt("SUBSCRIPTION_LIFECYCLE_INTERVAL_MIN", 15)) * time.Minute,
+			WebhookURL:        getEnv("SUBSCRIPTION_WEBHOOK_URL", ""),
+			WebhookSecret:     getEnv("SUBSCRIPTION_WEBHOOK_SECRET", ""),
+			PaymentSecret:     getEnv("SUBSCRIPTION_PAYMENT_SECRET", ""),
+		},
 	}
 
 	Global = cfg
@@ -0,0 +1,37 @@
+package crypto
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSealWithPassphrase_RoundTrip(t *testing.T) {
+	sealed, err := SealWithPassphrase("test-bundle", []byte("device keys"), "correct horse")
+	require.NoError(t, err)
+	assert.NotContains(t, string(sealed), "device keys")
+
+	plain, err := OpenWithPassphrase("test-bundle", sealed, "correct horse")
+	require.NoError(t, err)
+	assert.Equal(t, "device keys", string(plain))
+
+	_, err = OpenWithPassphrase("test-bundle", sealed, "wrong")
+	assert.ErrorIs(t, err, ErrBadPassphrase)
+
+	_, err = OpenWithPassphrase("other-format", sealed, "correct horse")
+	assert.Error(t, err)
+
+	_, err = SealWithPassphrase("test-bundle", []byte("x"), "")
+	assert.Error(t, err)
+
+	// Un sobre con parámetros scrypt alterados se rechaza sin derivar la clave
+	var env envelope
+	require.NoError(t, json.Unmarshal(sealed, &env))
+	env.N = 1 << 10
+	weak, err := json.Marshal(env)
+	require.NoError(t, err)
+	_, err = OpenWithPassphrase("test-bundle", weak, "correct horse")
+	assert.ErrorContains(t, err, "unsupported scrypt parameters")
+}
@@ -0,0 +1,340 @@
+package msgworker
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Lane es el carril de prioridad de un job. Un worker siempre atiende primero el carril más alto
+// con trabajo listo; el envejecimiento evita que los carriles bajos esperen para siempre.
+type Lane string
+
+const (
+	LanePriority    Lane = "priority"    // Chat interactivo de clientes VIP/Enterprise
+	LaneInteractive Lane = "interactive" // Resto del chat interactivo
+	LaneScheduled   Lane = "scheduled"   // Posts programados, avisos de inactividad
+	LaneBatch       Lane = "batch"       // Envíos masivos y tareas de fondo
+)
+
+var laneOrder = []Lane{LanePriority, LaneInteractive, LaneScheduled, LaneBatch}
+
+const (
+	// laneAging sube un carril a un job por cada intervalo que lleva esperando
+	laneAging = 10 * time.Second
+	// queueTimeSamples es la ventana para el p95 de tiempo en cola por carril
+	queueTimeSamples = 512
+	// tenantIdleTTL: los tenants sin actividad se olvidan (y su tiempo virtual con ellos)
+	tenantIdleTTL  = 10 * time.Minute
+	maxTenantStats = 50
+)
+
+// Pesos por tier de cliente (mismos valores que clients/domain.ClientTier): cuanto mayor el peso,
+// menos "cuesta" al tenant cada segundo de worker y más turnos recibe frente a otros tenants.
+var tierWeights = map[string]float64{
+	"enterprise": 8,
+	"vip":        6,
+	"premium":    4,
+	"standard":   2,
+	"trial":      1,
+	"free":       1,
+}
+
+// InteractiveLane devuelve el carril de un mensaje de chat según el tier del cliente
+func InteractiveLane(tier string) Lane {
+	switch strings.ToLower(tier) {
+	case "vip", "enterprise":
+		return LanePriority
+	}
+	return LaneInteractive
+}
+
+func laneRank(l Lane) int {
+	for i, lane := range laneOrder {
+		if lane == l {
+			return i
+		}
+	}
+	return 1 // Sin carril: interactivo
+}
+
+func tierWeight(tier string) float64 {
+	if w, ok := tierWeights[strings.ToLower(tier)]; ok {
+		return w
+	}
+	return 1
+}
+
+// tenantOf agrupa los jobs para el reparto justo; sin tenant explícito cada canal es un tenant
+func tenantOf(j Job) string {
+	if j.Tenant != "" {
+		return j.Tenant
+	}
+	return j.InstanceID
+}
+
+// LaneStats resume el tiempo en cola de un carril
+type LaneStats struct {
+	Waiting    int     `json:"waiting"`
+	Processed  int64   `json:"processed"`
+	AvgQueueMs float64 `json:"avg_queue_ms"`
+	P95QueueMs int64   `json:"p95_queue_ms"`
+	MaxQueueMs int64   `json:"max_queue_ms"`
+}
+
+// TenantStats muestra el uso del pool por tenant (workspace o canal)
+type TenantStats struct {
+	Tenant    string  `json:"tenant"`
+	Active    int     `json:"active"`
+	Waiting   int     `json:"waiting"`
+	Processed int64   `json:"processed"`
+	BusyMs    int64   `json:"busy_ms"`
+	Share     float64 `json:"share"` // Fracción del tiempo de worker consumido desde el arranque
+}
+
+type laneMetrics struct {
+	waiting   int
+	processed int64
+	totalWait time.Duration
+	maxWait   time.Duration
+	samples   []time.Duration
+	next      int
+}
+
+type tenantState struct {
+	vtime     float64 // Tiempo virtual WFQ: segundos de servicio / peso
+	active    int
+	waiting   int
+	processed int64
+	busy      time.Duration
+	lastSeen  time.Time
+}
+
+// fairScheduler elige qué job listo atiende un worker: primero por carril (con envejecimiento),
+// luego el tenant con menor tiempo virtual (weighted fair queuing), y por último el más antiguo.
+// Es compartido por todos los workers del pool.
+//
+// Solo es elegible el primer job de cada chat en el buffer (el orden por chat es FIFO); los demás
+// chats de la misma partición compiten igual que los de otras, así un chat VIP que comparte partición
+// con un tenant ruidoso no espera detrás de su backlog. No se interrumpe el job en curso.
+type fairScheduler struct {
+	mu        sync.Mutex
+	tenantCap int // Jobs simultáneos por tenant (0 = sin límite)
+	tenants   map[string]*tenantState
+	lanes     map[Lane]*laneMetrics
+	minV      float64
+	busy      time.Duration
+	freed     chan struct{} // Se cierra cuando un tenant libera un slot
+	lastPrune time.Time
+}
+
+func newFairScheduler(tenantCap int) *fairScheduler {
+	s := &fairScheduler{
+		tenantCap: tenantCap,
+		tenants:   make(map[string]*tenantState),
+		lanes:     make(map[Lane]*laneMetrics),
+		freed:     make(chan struct{}),
+		lastPrune: time.Now(),
+	}
+	for _, l := range laneOrder {
+		s.lanes[l] = &laneMetrics{}
+	}
+	return s
+}
+
+func (s *fairScheduler) tenant(name string) *tenantState {
+	t, ok := s.tenants[name]
+	if !ok {
+		// Un tenant nuevo (o que vuelve tras estar ocioso) entra al nivel actual, sin crédito acumulado
+		t = &tenantState{vtime: s.minV}
+		s.tenants[name] = t
+	}
+	t.lastSeen = time.Now()
+	return t
+}
+
+func (s *fairScheduler) lane(l Lane) *laneMetrics {
+	if m, ok := s.lanes[l]; ok {
+		return m
+	}
+	return s.lanes[LaneInteractive]
+}
+
+// queued registra un job listo en el buffer de un worker
+func (s *fairScheduler) queued(j Job) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tenant(tenantOf(j)).waiting++
+	s.lane(j.Lane).waiting++
+}
+
+// dropped descuenta un job listo que no llegará a pick (ej. shutdown con cola durable)
+func (s *fairScheduler) dropped(j Job) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tenant(tenantOf(j)).waiting--
+	s.lane(j.Lane).waiting--
+}
+
+// chatHead identifica un chat dentro de una cola: solo su primer job en el buffer es elegible
+type chatHead struct {
+	q    JobQueue
+	chat string
+}
+
+// pick elige entre los jobs listos y reserva el slot del tenant. -1 si todos están topados.
+func (s *fairScheduler) pick(ready []pendingDelivery) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	best := -1
+	var bestRank int
+	var bestV float64
+	var bestAt time.Time
+	minV := -1.0
+	heads := make(map[chatHead]bool, len(ready))
+	for i, pd := range ready {
+		j := pd.d.Job
+		head := chatHead{q: pd.q, chat: j.ChatKey()}
+		if heads[head] {
+			continue
+		}
+		heads[head] = true
+		t := s.tenant(tenantOf(j))
+		if t.vtime < s.minV {
+			t.vtime = s.minV
+		}
+		if minV < 0 || t.vtime < minV {
+			minV = t.vtime
+		}
+		if s.tenantCap > 0 && t.active >= s.tenantCap {
+			continue
+		}
+
+		rank := laneRank(j.Lane)
+		if !j.EnqueuedAt.IsZero() {
+			rank -= int(now.Sub(j.EnqueuedAt) / laneAging)
+			if rank < 0 {
+				rank = 0
+			}
+		}
+		if best < 0 || rank < bestRank ||
+			(rank == bestRank && (t.vtime < bestV || (t.vtime == bestV && j.EnqueuedAt.Before(bestAt)))) {
+			best, bestRank, bestV, bestAt = i, rank, t.vtime, j.EnqueuedAt
+		}
+	}
+	if minV > s.minV {
+		s.minV = minV
+	}
+	if best < 0 {
+		return -1
+	}
+
+	j := ready[best].d.Job
+	t := s.tenant(tenantOf(j))
+	t.active++
+	t.waiting--
+
+	lm := s.lane(j.Lane)
+	lm.waiting--
+	lm.processed++
+	if !j.EnqueuedAt.IsZero() {
+		wait := now.Sub(j.EnqueuedAt)
+		lm.totalWait += wait
+		if wait > lm.maxWait {
+			lm.maxWait = wait
+		}
+		if len(lm.samples) < queueTimeSamples {
+			lm.samples = append(lm.samples, wait)
+		} else {
+			lm.samples[lm.next] = wait
+			lm.next = (lm.next + 1) % queueTimeSamples
+		}
+	}
+
+	if now.Sub(s.lastPrune) > time.Minute {
+		s.prune(now)
+	}
+	return best
+}
+
+// released deshace un pick cuyo job no llegó a ejecutarse (la partición cambió de dueño);
+// el job sigue pendiente en la cola y el nuevo dueño lo vuelve a contar
+func (s *fairScheduler) released(j Job) {
+	s.mu.Lock()
+	s.tenant(tenantOf(j)).active--
+	s.lane(j.Lane).processed--
+	close(s.freed)
+	s.freed = make(chan struct{})
+	s.mu.Unlock()
+}
+
+// done libera el slot del tenant y le cobra el tiempo de worker según el peso de su tier
+func (s *fairScheduler) done(j Job, took time.Duration) {
+	s.mu.Lock()
+	t := s.tenant(tenantOf(j))
+	t.active--
+	t.processed++
+	t.busy += took
+	t.vtime += took.Seconds() / tierWeight(j.Tier)
+	s.busy += took
+	close(s.freed)
+	s.freed = make(chan struct{})
+	s.mu.Unlock()
+}
+
+// slotFreed devuelve un canal que se cierra en el próximo done
+func (s *fairScheduler) slotFreed() <-chan struct{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.freed
+}
+
+func (s *fairScheduler) prune(now time.Time) {
+	s.lastPrune = now
+	for name, t := range s.tenants {
+		if t.active == 0 && t.waiting <= 0 && now.Sub(t.lastSeen) > tenantIdleTTL {
+			delete(s.tenants, name)
+		}
+	}
+}
+
+func (s *fairScheduler) stats() (map[string]LaneStats, []TenantStats) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	lanes := make(map[string]LaneStats, len(s.lanes))
+	for l, m := range s.lanes {
+		ls := LaneStats{Waiting: m.waiting, Processed: m.processed, MaxQueueMs: m.maxWait.Milliseconds()}
+		if m.processed > 0 {
+			ls.AvgQueueMs = float64(m.totalWait.Milliseconds()) / float64(m.processed)
+		}
+		if len(m.samples) > 0 {
+			sorted := append([]time.Duration(nil), m.samples...)
+			sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+			ls.P95QueueMs = sorted[(len(sorted)*95)/100].Milliseconds()
+		}
+		lanes[string(l)] = ls
+	}
+
+	tenants := make([]TenantStats, 0, len(s.tenants))
+	for name, t := range s.tenants {
+		ts := TenantStats{Tenant: name, Active: t.active, Waiting: t.waiting, Processed: t.processed, BusyMs: t.busy.Milliseconds()}
+		if s.busy > 0 {
+			ts.Share = float64(t.busy) / float64(s.busy)
+		}
+		tenants = append(tenants, ts)
+	}
+	sort.Slice(tenants, func(i, j int) bool {
+		if tenants[i].BusyMs != tenants[j].BusyMs {
+			return tenants[i].BusyMs > tenants[j].BusyMs
+		}
+		return tenants[i].Tenant < tenants[j].Tenant
+	})
+	if len(tenants) > maxTenantStats {
+		tenants = tenants[:maxTenantStats]
+	}
+	return lanes, tenants
+}
@@ -0,0 +1,180 @@
+package msgworker
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func ready(jobs ...Job) []pendingDelivery {
+	out := make([]pendingDelivery, len(jobs))
+	for i, j := range jobs {
+		if j.ChatJID == "" {
+			j.ChatJID = fmt.Sprintf("chat-%d", i)
+		}
+		out[i] = pendingDelivery{d: Delivery{Job: j, Partition: i}}
+	}
+	return out
+}
+
+func TestFairScheduler_HigherLaneFirst(t *testing.T) {
+	s := newFairScheduler(0)
+	now := time.Now()
+	jobs := ready(
+		Job{Tenant: "noisy", Tier: "free", Lane: LaneInteractive, EnqueuedAt: now.Add(-time.Second)},
+		Job{Tenant: "news", Lane: LaneBatch, EnqueuedAt: now.Add(-2 * time.Second)},
+		Job{Tenant: "vip", Tier: "vip", Lane: InteractiveLane("VIP"), EnqueuedAt: now},
+	)
+	assert.Equal(t, 2, s.pick(jobs))
+
+	// Un job batch que lleva mucho esperando sube de carril y deja de quedar relegado
+	old := ready(
+		Job{Tenant: "a", Lane: LaneInteractive, EnqueuedAt: now},
+		Job{Tenant: "b", Lane: LaneBatch, EnqueuedAt: now.Add(-40 * time.Second)},
+	)
+	assert.Equal(t, 1, s.pick(old))
+}
+
+func TestFairScheduler_NoisyTenantYieldsToQuiet(t *testing.T) {
+	s := newFairScheduler(0)
+	now := time.Now()
+
+	noisy := Job{Tenant: "noisy", Tier: "free", EnqueuedAt: now.Add(-time.Minute)}
+	require.Equal(t, 0, s.pick(ready(noisy)))
+	s.done(noisy, 5*time.Second)
+
+	// Aunque el job del tenant ruidoso es más antiguo, el tenant que no ha consumido worker va primero
+	idx := s.pick(ready(
+		Job{Tenant: "noisy", Tier: "free", EnqueuedAt: now.Add(-time.Second)},
+		Job{Tenant: "quiet", Tier: "free", EnqueuedAt: now},
+	))
+	assert.Equal(t, 1, idx)
+
+	_, tenants := s.stats()
+	require.Len(t, tenants, 2)
+	assert.Equal(t, "noisy", tenants[0].Tenant)
+	assert.Equal(t, 1.0, tenants[0].Share)
+}
+
+func TestFairScheduler_TierWeightsServiceCost(t *testing.T) {
+	s := newFairScheduler(0)
+	free := Job{Tenant: "free", Tier: "free"}
+	ent := Job{Tenant: "ent", Tier: "enterprise"}
+	s.pick(ready(free))
+	s.done(free, time.Second)
+	s.pick(ready(ent))
+	s.done(ent, 4*time.Second) // 4s / peso 8 cuesta menos que 1s / peso 1
+
+	assert.Equal(t, 1, s.pick(ready(free, ent)))
+}
+
+func TestFairScheduler_TenantCap(t *testing.T) {
+	s := newFairScheduler(1)
+	a1 := Job{Tenant: "a"}
+	require.Equal(t, 0, s.pick(ready(a1)))
+
+	assert.Equal(t, -1, s.pick(ready(Job{Tenant: "a"})))
+	assert.Equal(t, 1, s.pick(ready(Job{Tenant: "a"}, Job{Tenant: "b"})))
+
+	freed := s.slotFreed()
+	s.done(a1, time.Millisecond)
+	select {
+	case <-freed:
+	default:
+		t.Fatal("done should signal a freed slot")
+	}
+	assert.Equal(t, 0, s.pick(ready(Job{Tenant: "a"})))
+}
+
+func TestFairScheduler_OnlyFirstJobOfEachChat(t *testing.T) {
+	s := newFairScheduler(0)
+	now := time.Now()
+
+	// Mismo chat: aunque el segundo job es de un carril más alto, primero va el que llegó antes
+	jobs := ready(
+		Job{InstanceID: "i", ChatJID: "a", Lane: LaneBatch, EnqueuedAt: now},
+		Job{InstanceID: "i", ChatJID: "a", Lane: LanePriority, EnqueuedAt: now},
+		Job{InstanceID: "i", ChatJID: "b", Lane: LaneInteractive, EnqueuedAt: now},
+	)
+	assert.Equal(t, 2, s.pick(jobs))
+}
+
+func TestPool_StatsExposeLanesAndTenants(t *testing.T) {
+	pool := newTestPool(t, 1, 10)
+
+	done := make(chan struct{})
+	pool.Dispatch(MessageJob{
+		InstanceID: "inst1",
+		ChatJID:    "chat1",
+		Tenant:     "ws1",
+		Tier:       "vip",
+		Lane:       LanePriority,
+		Handler: func(ctx context.Context) error {
+			close(done)
+			return nil
+		},
+	})
+	<-done
+
+	require.Eventually(t, func() bool {
+		return pool.GetStats().Lanes[string(LanePriority)].Processed == 1
+	}, time.Second, 5*time.Millisecond)
+	stats := pool.GetStats()
+	require.NotEmpty(t, stats.Tenants)
+	assert.Equal(t, "ws1", stats.Tenants[0].Tenant)
+	assert.Len(t, stats.Lanes, len(laneOrder))
+}
+
+func TestPool_NoisyFloodKeepsVIPLatency(t *testing.T) {
+	pool := NewMessageWorkerPool(2, 1000)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	pool.Start(ctx)
+	defer pool.Stop()
+
+	parts := pool.queue.Partitions()
+	noisyParts := map[int]bool{}
+	var noisyDone int64
+	for i := 0; i < 400; i++ {
+		chat := fmt.Sprintf("noisy-%d", i%10)
+		noisyParts[PartitionFor("flood", chat, parts)] = true
+		require.True(t, pool.TryDispatch(MessageJob{
+			InstanceID: "flood", ChatJID: chat, Tenant: "noisy", Tier: "free",
+			Handler: func(ctx context.Context) error {
+				time.Sleep(5 * time.Millisecond)
+				atomic.AddInt64(&noisyDone, 1)
+				return nil
+			},
+		}))
+	}
+
+	// El chat VIP cae en una de las particiones inundadas: no debe esperar detrás de su backlog
+	vipChat := ""
+	for i := 0; vipChat == ""; i++ {
+		if c := fmt.Sprintf("vip-%d", i); noisyParts[PartitionFor("vip", c, parts)] {
+			vipChat = c
+		}
+	}
+	started := make(chan time.Duration, 1)
+	enqueued := time.Now()
+	require.True(t, pool.TryDispatch(MessageJob{
+		InstanceID: "vip", ChatJID: vipChat, Tenant: "vip", Tier: "vip", Lane: InteractiveLane("vip"),
+		Handler: func(ctx context.Context) error {
+			started <- time.Since(enqueued)
+			return nil
+		},
+	}))
+
+	select {
+	case wait := <-started:
+		assert.Less(t, wait, 100*time.Millisecond, "the VIP job waits at most for the job in progress")
+		assert.Less(t, atomic.LoadInt64(&noisyDone), int64(200), "the flood must still be running")
+	case <-time.After(5 * time.Second):
+		t.Fatal("VIP job starved by the noisy tenant")
+	}
+}
@@ -0,0 +1,167 @@
+package msgworker
+
+import (
+	"context"
+	"hash/fnv"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ownershipLoop reparte las particiones de la cola durable entre los nodos vivos.
+// Cada nodo aspira a ceil(particiones/nodos): renueva las suyas, suelta las que le sobran
+// cuando quedan vacías y toma las libres. Si no tiene trabajo, además roba
+// particiones libres con backlog (las de un nodo caído) aunque supere su cuota.
+func (p *MessageWorkerPool) ownershipLoop(ctx context.Context) {
+	defer p.wg.Done()
+
+	ticker := time.NewTicker(ownershipInterval)
+	defer ticker.Stop()
+	for {
+		p.rebalance(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-p.stopCh:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (p *MessageWorkerPool) rebalance(ctx context.Context) {
+	total := p.queue.Partitions()
+
+	live, err := p.queue.Heartbeat(ctx, p.nodeID, leaseTTL)
+	if err != nil {
+		if ctx.Err() == nil {
+			logrus.WithError(err).Warn("[MSG_WORKER_POOL] Queue heartbeat failed")
+		}
+		return
+	}
+	if live < 1 {
+		live = 1
+	}
+	fair := (total + live - 1) / live
+
+	// 1. Renovar las propias; las perdidas (lease expirado y tomado por otro) se sueltan
+	for _, partition := range p.ownedPartitions() {
+		ok, err := p.queue.Renew(ctx, partition, p.nodeID, leaseTTL)
+		if err != nil {
+			continue
+		}
+		if !ok {
+			p.ownedMu.Lock()
+			delete(p.owned, partition)
+			p.ownedMu.Unlock()
+			logrus.Warnf("[MSG_WORKER_POOL] Lost partition %d to another node", partition)
+		}
+	}
+
+	// 2. Profundidades (monitoreo y detección de backlog)
+	busy := false
+	for partition := 0; partition < total; partition++ {
+		n, err := p.queue.Depth(ctx, partition)
+		if err != nil {
+			continue
+		}
+		atomic.StoreInt64(&p.depths[partition], n)
+		if n > 0 && p.isOwned(partition) {
+			busy = true
+		}
+	}
+
+	for partition := range p.pinnedDepths {
+		if n, err := p.pinned.Depth(ctx, partition); err == nil {
+			atomic.StoreInt64(&p.pinnedDepths[partition], n)
+		}
+	}
+
+	// 3. Soltar las que sobran (de la última a la primera) si están vacías y sin job en curso;
+	//    las que tienen backlog se conservan hasta drenarlas para no rebotarlas entre nodos
+	owned := p.ownedPartitions()
+	for i := len(owned) - 1; i >= 0 && len(owned) > fair; i-- {
+		partition := owned[i]
+		p.ownedMu.Lock()
+		if p.inflight[partition] || atomic.LoadInt64(&p.depths[partition]) > 0 {
+			p.ownedMu.Unlock()
+			continue
+		}
+		delete(p.owned, partition)
+		p.ownedMu.Unlock()
+		if err := p.queue.Release(ctx, partition, p.nodeID); err != nil {
+			logrus.WithError(err).Warnf("[MSG_WORKER_POOL] Failed to release partition %d", partition)
+		}
+		owned = append(owned[:i], owned[i+1:]...)
+	}
+
+	// 3b. Saturado (más particiones con backlog que workers): ofrecer una sin job en curso
+	//     para que la robe un nodo ocioso. No se vuelve a tomar en este ciclo.
+	shed := -1
+	if live > 1 {
+		var backlogged []int
+		for _, partition := range owned {
+			if atomic.LoadInt64(&p.depths[partition]) > 0 {
+				backlogged = append(backlogged, partition)
+			}
+		}
+		if len(backlogged) > 2*p.numWorkers {
+			p.ownedMu.Lock()
+			for i := len(backlogged) - 1; i >= 0; i-- {
+				if !p.inflight[backlogged[i]] {
+					shed = backlogged[i]
+					delete(p.owned, shed)
+					break
+				}
+			}
+			p.ownedMu.Unlock()
+			if shed >= 0 {
+				_ = p.queue.Release(ctx, shed, p.nodeID)
+				for i, partition := range owned {
+					if partition == shed {
+						owned = append(owned[:i], owned[i+1:]...)
+						break
+					}
+				}
+			}
+		}
+	}
+
+	// 4. Tomar particiones libres hasta la cuota; cada nodo empieza por un offset distinto
+	//    para no competir todos por las mismas. Sin trabajo propio, también roba las libres con backlog.
+	h := fnv.New32a()
+	h.Write([]byte(p.nodeID))
+	offset := int(h.Sum32() % uint32(total))
+	count := len(owned)
+	for i := 0; i < total; i++ {
+		partition := (offset + i) % total
+		if partition == shed || p.isOwned(partition) {
+			continue
+		}
+		steal := !busy && atomic.LoadInt64(&p.depths[partition]) > 0
+		if count >= fair && !steal {
+			continue
+		}
+		ok, err := p.queue.Acquire(ctx, partition, p.nodeID, leaseTTL)
+		if err != nil || !ok {
+			continue
+		}
+		if err := p.queue.Adopt(ctx, partition, p.consumer(partition%p.numWorkers)); err != nil {
+			logrus.WithError(err).Warnf("[MSG_WORKER_POOL] Failed to adopt pending jobs of partition %d", partition)
+		}
+		p.ownedMu.Lock()
+		p.owned[partition] = true
+		p.ownedMu.Unlock()
+		count++
+		if steal && count > fair {
+			logrus.Infof("[MSG_WORKER_POOL] Stole partition %d with %d pending jobs", partition, atomic.LoadInt64(&p.depths[partition]))
+		}
+	}
+}
+
+func (p *MessageWorkerPool) isOwned(partition int) bool {
+	p.ownedMu.RLock()
+	defer p.ownedMu.RUnlock()
+	return p.owned[partition]
+}
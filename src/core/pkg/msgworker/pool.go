@@ -2,7 +2,11 @@ package msgworker
 
 import (
 	"context"
-	"hash/fnv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -10,24 +14,56 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
-// MessageJob representa un job de procesamiento de mensaje WhatsApp
+const (
+	DefaultPartitions  = 64
+	DefaultMaxAttempts = 5
+
+	leaseTTL          = 30 * time.Second
+	ownershipInterval = 10 * time.Second
+	durableBlock      = 250 * time.Millisecond
+	memoryBlock       = time.Second
+)
+
+// MessageJob representa un job de procesamiento de mensaje WhatsApp.
+// Con Handler es un job local (closure): se ejecuta en este nodo, sin reintentos, y se pierde si el proceso cae.
+// Con Type es un job tipado: su Payload viaja por la cola (durable con Valkey) y lo ejecuta el handler
+// registrado con RegisterHandler en el nodo que sea dueño de la partición del chat.
+// Pinned lo fija a este nodo (ej. necesita el adapter del canal): sigue siendo durable y se retoma
+// al reiniciar con el mismo server ID, pero ningún otro nodo lo roba.
+// Un job tipado y fijado puede traer además Handler: mientras el proceso siga vivo se ejecuta la
+// closure (sin perder tipos en el JSON) y el handler registrado solo se usa al retomarlo tras un reinicio.
 type MessageJob struct {
 	InstanceID string
 	ChatJID    string
 	Handler    func(ctx context.Context) error
+	Type       string
+	Payload    json.RawMessage
+	Pinned     bool
+	Tenant     string // Workspace o cliente que origina el job (vacío: el canal)
+	Tier       string // ClientTier del tenant
+	Lane       Lane   // Carril de prioridad (vacío: interactivo)
 }
 
 // PoolStats contiene métricas en tiempo real del worker pool
 type PoolStats struct {
-	NumWorkers      int            `json:"num_workers"`
-	QueueSize       int            `json:"queue_size"`
-	ActiveWorkers   int            `json:"active_workers"`
-	TotalDispatched int64          `json:"total_dispatched"`
-	TotalProcessed  int64          `json:"total_processed"`
-	TotalDropped    int64          `json:"total_dropped"`
-	TotalErrors     int64          `json:"total_errors"`
-	WorkerStats     []WorkerStats  `json:"worker_stats"`
-	ActiveChats     map[string]int `json:"active_chats"` // instanceID|chatJID -> worker_id
+	NumWorkers       int                  `json:"num_workers"`
+	QueueSize        int                  `json:"queue_size"`
+	ActiveWorkers    int                  `json:"active_workers"`
+	TotalDispatched  int64                `json:"total_dispatched"`
+	TotalProcessed   int64                `json:"total_processed"`
+	TotalDropped     int64                `json:"total_dropped"`
+	TotalErrors      int64                `json:"total_errors"`
+	TotalRetried     int64                `json:"total_retried"`
+	TotalQuarantined int64                `json:"total_quarantined"`
+	Backend          string               `json:"backend"`
+	NodeID           string               `json:"node_id,omitempty"`
+	Partitions       int                  `json:"partitions"`
+	OwnedPartitions  int                  `json:"owned_partitions"`
+	TenantCap        int                  `json:"tenant_cap"`
+	Lanes            map[string]LaneStats `json:"lanes"`
+	Tenants          []TenantStats        `json:"tenants"`
+	WorkerStats      []WorkerStats        `json:"worker_stats"`
+	ActiveChats      map[string]int       `json:"active_chats"` // instanceID|chatJID -> worker_id
 }
 
 // WorkerStats contiene métricas por worker individual
@@ -38,12 +74,23 @@ type WorkerStats struct {
 	JobsProcessed int64 `json:"jobs_processed"`
 }
 
+// PoolOptions ajusta el comportamiento de un pool sobre una cola compartida
+type PoolOptions struct {
+	NodeID       string        // Identifica al nodo en leases y consumidores (server ID)
+	Pinned       JobQueue      // Cola exclusiva del nodo para jobs Pinned (nil: van a la cola compartida)
+	MaxAttempts  int           // Intentos de un job tipado antes de ir a cuarentena
+	RetryBackoff time.Duration // Espera base entre reintentos (se duplica en cada intento)
+	TenantCap    int           // Jobs simultáneos por tenant en este nodo (0 = sin límite)
+}
+
 type activeChatEntry struct {
 	workerID  int
 	updatedAt time.Time
 }
 
-// MessageWorkerPool maneja un pool de workers para procesar mensajes de WhatsApp
+// MessageWorkerPool maneja un pool de workers para procesar mensajes de WhatsApp.
+// Cada chat cae en una partición de la cola y cada partición la atiende un único worker,
+// así se conserva el orden por chat. Con una cola durable, las particiones se reparten entre nodos.
 type MessageWorkerPool struct {
 	numWorkers int
 	queueSize  int
@@ -52,33 +99,57 @@ type MessageWorkerPool struct {
 	stopOnce   sync.Once
 	stopped    int32
 	stopCh     chan struct{}
+	cancel     context.CancelFunc
+
+	queue        JobQueue
+	local        *MemoryJobQueue // Closures cuando queue es durable (no se pueden serializar)
+	pinned       JobQueue        // Jobs que solo puede ejecutar este nodo
+	nodeID       string
+	maxAttempts  int
+	retryBackoff time.Duration
+	seq          int64
+	closures     sync.Map // job ID -> func(ctx) error
+	sched        *fairScheduler
+
+	handlersMu sync.RWMutex
+	handlers   map[string]JobHandler
+
+	// Particiones de la cola durable que este nodo consume
+	ownedMu      sync.RWMutex
+	owned        map[int]bool
+	inflight     map[int]bool
+	depths       []int64 // Profundidad por partición, refrescada en cada ciclo de ownership
+	pinnedDepths []int64
 
 	// Métricas
-	totalDispatched int64
-	totalProcessed  int64
-	totalDropped    int64
-	totalErrors     int64
-	activeChatsMu   sync.RWMutex
-	activeChats     map[string]activeChatEntry // chatKey -> workerID
-	startTime       time.Time
+	totalDispatched  int64
+	totalProcessed   int64
+	totalDropped     int64
+	totalErrors      int64
+	totalRetried     int64
+	totalQuarantined int64
+	activeChatsMu    sync.RWMutex
+	activeChats      map[string]activeChatEntry // chatKey -> workerID
+	startTime        time.Time
 
 	// Hooks para monitoreo externo
 	OnWorkerStart func(workerID int, chatKey string)
 	OnWorkerEnd   func(workerID int, chatKey string)
 }
 
-// worker representa un worker individual con su cola
+// worker representa un worker individual; atiende las particiones p donde p % numWorkers == id
 type worker struct {
 	id            int
-	jobQueue      chan MessageJob
 	ctx           context.Context
 	cancel        context.CancelFunc
 	isProcessing  int32              // atomic: 1 if processing, 0 if idle
 	jobsProcessed int64              // atomic counter
+	ready         []pendingDelivery  // Jobs recibidos (varios por partición), esperando turno del scheduler
 	pool          *MessageWorkerPool // referencia al pool para actualizar métricas globales
 }
 
-// NewMessageWorkerPool crea un nuevo pool de workers para mensajes
+// NewMessageWorkerPool crea un nuevo pool de workers con una cola en memoria
+// (queueSize jobs por worker, compartidos entre todas las particiones)
 func NewMessageWorkerPool(numWorkers, queueSize int) *MessageWorkerPool {
 	if numWorkers <= 0 {
 		numWorkers = 10
@@ -86,14 +157,60 @@ func NewMessageWorkerPool(numWorkers, queueSize int) *MessageWorkerPool {
 	if queueSize <= 0 {
 		queueSize = 100
 	}
+	partitions := DefaultPartitions
+	if numWorkers > partitions {
+		partitions = numWorkers
+	}
+	return NewMessageWorkerPoolWithQueue(numWorkers, queueSize, NewMemoryJobQueue(partitions, numWorkers*queueSize), PoolOptions{})
+}
+
+// NewMessageWorkerPoolWithQueue crea un pool sobre una cola concreta (ej. ValkeyJobQueue)
+func NewMessageWorkerPoolWithQueue(numWorkers, queueSize int, queue JobQueue, opts PoolOptions) *MessageWorkerPool {
+	if numWorkers <= 0 {
+		numWorkers = 10
+	}
+	if queueSize <= 0 {
+		queueSize = 100
+	}
+	if opts.NodeID == "" {
+		opts.NodeID = "local"
+	}
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = DefaultMaxAttempts
+	}
+	if opts.RetryBackoff <= 0 {
+		opts.RetryBackoff = time.Second
+	}
 
 	pool := &MessageWorkerPool{
-		numWorkers:  numWorkers,
-		queueSize:   queueSize,
-		workers:     make([]*worker, numWorkers),
-		activeChats: make(map[string]activeChatEntry),
-		stopCh:      make(chan struct{}),
-		startTime:   time.Now(),
+		numWorkers:   numWorkers,
+		queueSize:    queueSize,
+		workers:      make([]*worker, numWorkers),
+		activeChats:  make(map[string]activeChatEntry),
+		stopCh:       make(chan struct{}),
+		startTime:    time.Now(),
+		queue:        queue,
+		nodeID:       opts.NodeID,
+		maxAttempts:  opts.MaxAttempts,
+		retryBackoff: opts.RetryBackoff,
+		handlers:     make(map[string]JobHandler),
+		owned:        make(map[int]bool),
+		inflight:     make(map[int]bool),
+		depths:       make([]int64, queue.Partitions()),
+		sched:        newFairScheduler(opts.TenantCap),
+	}
+	if opts.Pinned != nil && opts.Pinned.Kind() != QueueMemory && opts.Pinned.Partitions() == queue.Partitions() {
+		pool.pinned = opts.Pinned
+		pool.pinnedDepths = make([]int64, opts.Pinned.Partitions())
+	}
+
+	if queue.Kind() == QueueMemory {
+		// En memoria este nodo es dueño de todas las particiones
+		for i := 0; i < queue.Partitions(); i++ {
+			pool.owned[i] = true
+		}
+	} else {
+		pool.local = NewMemoryJobQueue(queue.Partitions(), numWorkers*queueSize)
 	}
 
 	return pool
@@ -104,8 +221,36 @@ func (p *MessageWorkerPool) NumWorkers() int {
 	return p.numWorkers
 }
 
+// RegisterHandler asocia un tipo de job con su handler. Todos los nodos deben registrar
+// los mismos tipos: cualquiera de ellos puede recibir el job.
+func (p *MessageWorkerPool) RegisterHandler(jobType string, handler JobHandler) {
+	p.handlersMu.Lock()
+	defer p.handlersMu.Unlock()
+	p.handlers[jobType] = handler
+}
+
+// Quarantined lista los jobs que agotaron sus reintentos, del más reciente al más antiguo
+func (p *MessageWorkerPool) Quarantined(ctx context.Context, limit int) ([]DeadJob, error) {
+	dead, err := p.queue.Quarantined(ctx, limit)
+	if err != nil || p.pinned == nil {
+		return dead, err
+	}
+	pinned, err := p.pinned.Quarantined(ctx, limit)
+	if err != nil {
+		return nil, err
+	}
+	dead = append(dead, pinned...)
+	sort.Slice(dead, func(i, j int) bool { return dead[i].QuarantinedAt.After(dead[j].QuarantinedAt) })
+	if limit > 0 && len(dead) > limit {
+		dead = dead[:limit]
+	}
+	return dead, nil
+}
+
 // Start inicia todos los workers del pool
 func (p *MessageWorkerPool) Start(ctx context.Context) {
+	ctx, p.cancel = context.WithCancel(ctx)
+
 	p.wg.Add(1)
 	go func() {
 		defer p.wg.Done()
@@ -130,14 +275,26 @@ func (p *MessageWorkerPool) Start(ctx context.Context) {
 		}
 	}()
 
+	if p.pinned != nil {
+		// Jobs fijados que quedaron sin confirmar en la ejecución anterior de este nodo
+		for partition := 0; partition < p.pinned.Partitions(); partition++ {
+			if err := p.pinned.Adopt(ctx, partition, p.consumer(partition%p.numWorkers)); err != nil {
+				logrus.WithError(err).Warnf("[MSG_WORKER_POOL] Failed to recover pinned jobs of partition %d", partition)
+			}
+		}
+	}
+	if p.local != nil {
+		p.wg.Add(1)
+		go p.ownershipLoop(ctx)
+	}
+
 	for i := 0; i < p.numWorkers; i++ {
 		workerCtx, cancel := context.WithCancel(ctx)
 		w := &worker{
-			id:       i,
-			jobQueue: make(chan MessageJob, p.queueSize),
-			ctx:      workerCtx,
-			cancel:   cancel,
-			pool:     p, // pasar referencia al pool
+			id:     i,
+			ctx:    workerCtx,
+			cancel: cancel,
+			pool:   p, // pasar referencia al pool
 		}
 		p.workers[i] = w
 
@@ -145,18 +302,20 @@ func (p *MessageWorkerPool) Start(ctx context.Context) {
 		go w.run(&p.wg)
 	}
 
-	logrus.Infof("[MSG_WORKER_POOL] Started with %d workers, queue size: %d", p.numWorkers, p.queueSize)
+	logrus.Infof("[MSG_WORKER_POOL] Started with %d workers, queue size: %d, backend: %s (%d partitions)",
+		p.numWorkers, p.queueSize, p.queue.Kind(), p.queue.Partitions())
 }
 
-// TryDispatch envía un job al worker apropiado (no bloqueante) y retorna
-// si el job pudo encolarse. Útil para aplicar backpressure en endpoints HTTP.
+// TryDispatch encola un job (no bloqueante) y retorna si pudo encolarse.
+// Útil para aplicar backpressure en endpoints HTTP.
 func (p *MessageWorkerPool) TryDispatch(job MessageJob) bool {
 	if atomic.LoadInt32(&p.stopped) == 1 {
 		atomic.AddInt64(&p.totalDropped, 1)
 		return false
 	}
 
-	shard := p.shardForChat(job.InstanceID, job.ChatJID)
+	partition := PartitionFor(job.InstanceID, job.ChatJID, p.queue.Partitions())
+	shard := partition % p.numWorkers
 	atomic.AddInt64(&p.totalDispatched, 1)
 
 	// Track active chat
@@ -165,64 +324,162 @@ func (p *MessageWorkerPool) TryDispatch(job MessageJob) bool {
 	p.activeChats[chatKey] = activeChatEntry{workerID: shard, updatedAt: time.Now()}
 	p.activeChatsMu.Unlock()
 
-	sent := func() (ok bool) {
-		defer func() {
-			if r := recover(); r != nil {
-				ok = false
-			}
-		}()
-		select {
-		case p.workers[shard].jobQueue <- job:
-			return true
-		default:
-			return false
-		}
-	}()
+	qjob := Job{
+		ID:         p.nodeID + "-" + strconv.FormatInt(atomic.AddInt64(&p.seq, 1), 10),
+		Type:       job.Type,
+		InstanceID: job.InstanceID,
+		ChatJID:    job.ChatJID,
+		Payload:    job.Payload,
+		EnqueuedAt: time.Now(),
+		Tenant:     job.Tenant,
+		Tier:       job.Tier,
+		Lane:       job.Lane,
+	}
 
-	if sent {
+	err := p.enqueue(partition, qjob, job.Handler, job.Pinned)
+	if err == nil {
 		return true
 	}
+
 	p.activeChatsMu.Lock()
 	delete(p.activeChats, chatKey)
 	p.activeChatsMu.Unlock()
 
 	atomic.AddInt64(&p.totalDropped, 1)
-	logrus.Warnf("[MSG_WORKER_POOL] Worker %d queue full (or stopped), dropping job for %s|%s",
-		shard, job.InstanceID, job.ChatJID)
+	logrus.WithError(err).Warnf("[MSG_WORKER_POOL] Partition %d rejected job for %s|%s, dropping it",
+		partition, job.InstanceID, job.ChatJID)
 	return false
 }
 
+func (p *MessageWorkerPool) enqueue(partition int, job Job, handler func(ctx context.Context) error, pinned bool) error {
+	if handler != nil && job.Type != "" && (pinned || p.local == nil) {
+		p.closures.Store(job.ID, handler)
+	} else if handler != nil {
+		job.Type = ""
+		p.closures.Store(job.ID, handler)
+		q := JobQueue(p.queue)
+		if p.local != nil {
+			q = p.local
+		}
+		if err := q.Enqueue(context.Background(), partition, job); err != nil {
+			p.closures.Delete(job.ID)
+			return err
+		}
+		return nil
+	}
+
+	if job.Type == "" {
+		return errors.New("job has neither handler nor type")
+	}
+
+	q := p.queue
+	if pinned && p.local != nil {
+		// Sin cola propia del nodo, un job fijado se queda en memoria antes que ejecutarse en otro nodo
+		q = p.local
+		if p.pinned != nil {
+			q = p.pinned
+		}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	err := q.Enqueue(ctx, partition, job)
+	if err != nil && p.local != nil && q != JobQueue(p.local) {
+		// Valkey no disponible: el job se ejecuta en este nodo, sin durabilidad
+		logrus.WithError(err).Warnf("[MSG_WORKER_POOL] Durable enqueue failed, keeping %s job in memory", job.Type)
+		err = p.local.Enqueue(context.Background(), partition, job)
+	}
+	if err != nil {
+		p.closures.Delete(job.ID)
+	}
+	return err
+}
+
 // Dispatch envía un job al worker apropiado (no bloqueante)
 func (p *MessageWorkerPool) Dispatch(job MessageJob) {
 	_ = p.TryDispatch(job)
 }
 
-// Stop detiene el pool de forma graceful
+// Stop detiene el pool de forma graceful: los jobs en curso terminan, los locales pendientes
+// se drenan y las particiones durables se liberan para que otro nodo las tome.
 func (p *MessageWorkerPool) Stop() {
 	p.stopOnce.Do(func() {
 		atomic.StoreInt32(&p.stopped, 1)
 		close(p.stopCh)
 		logrus.Info("[MSG_WORKER_POOL] Stopping workers...")
 
-		// Cancelar contextos y cerrar colas
 		for _, w := range p.workers {
-			w.cancel()
-			close(w.jobQueue)
+			if w != nil {
+				w.cancel()
+			}
+		}
+		if p.cancel != nil {
+			p.cancel()
 		}
 
 		// Esperar a que terminen los workers
 		p.wg.Wait()
 
+		if p.local != nil {
+			ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+			for _, partition := range p.ownedPartitions() {
+				_ = p.queue.Release(ctx, partition, p.nodeID)
+			}
+			cancel()
+		}
+
 		logrus.Info("[MSG_WORKER_POOL] All workers stopped")
 	})
 }
 
-// shardForChat calcula el shard (worker) para un chat específico usando hash consistente
+// shardForChat calcula el worker de un chat: hash consistente a una partición y partición a worker
 func (p *MessageWorkerPool) shardForChat(instanceID, chatJID string) int {
-	key := instanceID + "|" + chatJID
-	h := fnv.New32a()
-	h.Write([]byte(key))
-	return int(h.Sum32() % uint32(p.numWorkers))
+	return PartitionFor(instanceID, chatJID, p.queue.Partitions()) % p.numWorkers
+}
+
+func (p *MessageWorkerPool) ownedPartitions() []int {
+	p.ownedMu.RLock()
+	defer p.ownedMu.RUnlock()
+	out := make([]int, 0, len(p.owned))
+	for partition := range p.owned {
+		out = append(out, partition)
+	}
+	sort.Ints(out)
+	return out
+}
+
+// partitionsFor devuelve las particiones propias que atiende un worker
+func (p *MessageWorkerPool) partitionsFor(workerID int) []int {
+	p.ownedMu.RLock()
+	defer p.ownedMu.RUnlock()
+	var out []int
+	for partition := workerID; partition < p.queue.Partitions(); partition += p.numWorkers {
+		if p.owned[partition] {
+			out = append(out, partition)
+		}
+	}
+	return out
+}
+
+// claim marca la partición con un job en curso. Falla si es de la cola compartida y el nodo ya no
+// la posee: el job sigue pendiente en la cola y lo retoma el nuevo dueño.
+func (p *MessageWorkerPool) claim(q JobQueue, partition int) bool {
+	p.ownedMu.Lock()
+	defer p.ownedMu.Unlock()
+	if q == p.queue && !p.owned[partition] {
+		return false
+	}
+	p.inflight[partition] = true
+	return true
+}
+
+func (p *MessageWorkerPool) releaseInflight(partition int) {
+	p.ownedMu.Lock()
+	delete(p.inflight, partition)
+	p.ownedMu.Unlock()
+}
+
+func (p *MessageWorkerPool) consumer(workerID int) string {
+	return fmt.Sprintf("%s:%d", p.nodeID, workerID)
 }
 
 // GetStats retorna estadísticas en tiempo real del pool
@@ -230,7 +487,12 @@ func (p *MessageWorkerPool) GetStats() PoolStats {
 	workerStats := make([]WorkerStats, len(p.workers))
 	activeWorkers := 0
 
-	for i, w := range p.workers {
+	for i := range p.workers {
+		w := p.workers[i]
+		if w == nil {
+			workerStats[i] = WorkerStats{WorkerID: i}
+			continue
+		}
 		isProcessing := atomic.LoadInt32(&w.isProcessing) == 1
 		if isProcessing {
 			activeWorkers++
@@ -238,7 +500,7 @@ func (p *MessageWorkerPool) GetStats() PoolStats {
 
 		workerStats[i] = WorkerStats{
 			WorkerID:      w.id,
-			QueueDepth:    len(w.jobQueue),
+			QueueDepth:    p.queueDepth(w.id),
 			IsProcessing:  isProcessing,
 			JobsProcessed: atomic.LoadInt64(&w.jobsProcessed),
 		}
@@ -256,96 +518,378 @@ func (p *MessageWorkerPool) GetStats() PoolStats {
 	}
 	p.activeChatsMu.Unlock()
 
+	p.ownedMu.RLock()
+	owned := len(p.owned)
+	p.ownedMu.RUnlock()
+
+	lanes, tenants := p.sched.stats()
+
 	return PoolStats{
-		NumWorkers:      p.numWorkers,
-		QueueSize:       p.queueSize,
-		ActiveWorkers:   activeWorkers,
-		TotalDispatched: atomic.LoadInt64(&p.totalDispatched),
-		TotalProcessed:  atomic.LoadInt64(&p.totalProcessed),
-		TotalDropped:    atomic.LoadInt64(&p.totalDropped),
-		TotalErrors:     atomic.LoadInt64(&p.totalErrors),
-		WorkerStats:     workerStats,
-		ActiveChats:     activeChatsSnapshot,
+		NumWorkers:       p.numWorkers,
+		QueueSize:        p.queueSize,
+		ActiveWorkers:    activeWorkers,
+		TotalDispatched:  atomic.LoadInt64(&p.totalDispatched),
+		TotalProcessed:   atomic.LoadInt64(&p.totalProcessed),
+		TotalDropped:     atomic.LoadInt64(&p.totalDropped),
+		TotalErrors:      atomic.LoadInt64(&p.totalErrors),
+		TotalRetried:     atomic.LoadInt64(&p.totalRetried),
+		TotalQuarantined: atomic.LoadInt64(&p.totalQuarantined),
+		Backend:          p.queue.Kind(),
+		NodeID:           p.nodeID,
+		Partitions:       p.queue.Partitions(),
+		OwnedPartitions:  owned,
+		TenantCap:        p.sched.tenantCap,
+		Lanes:            lanes,
+		Tenants:          tenants,
+		WorkerStats:      workerStats,
+		ActiveChats:      activeChatsSnapshot,
+	}
+}
+
+// queueDepth suma los jobs pendientes de las particiones de un worker.
+// En Valkey usa la profundidad cacheada en el último ciclo de ownership.
+func (p *MessageWorkerPool) queueDepth(workerID int) int {
+	var depth int64
+	for _, partition := range p.partitionsFor(workerID) {
+		if p.local == nil {
+			n, _ := p.queue.Depth(context.Background(), partition)
+			depth += n
+			continue
+		}
+		depth += atomic.LoadInt64(&p.depths[partition])
+	}
+	if p.local != nil {
+		for partition := workerID; partition < p.local.Partitions(); partition += p.numWorkers {
+			n, _ := p.local.Depth(context.Background(), partition)
+			depth += n
+		}
 	}
+	for partition := workerID; partition < len(p.pinnedDepths); partition += p.numWorkers {
+		depth += atomic.LoadInt64(&p.pinnedDepths[partition])
+	}
+	return int(depth)
+}
+
+// pendingDelivery es un job ya recibido que espera turno en el buffer del worker
+type pendingDelivery struct {
+	q JobQueue
+	d Delivery
 }
 
-// run ejecuta el loop principal del worker
+// run ejecuta el loop principal del worker: llena el buffer con los jobs de sus particiones y deja
+// que el scheduler elija cuál atender entre el primero de cada chat (así se respeta el orden por chat).
 func (w *worker) run(wg *sync.WaitGroup) {
 	defer wg.Done()
 
 	logrus.Debugf("[MSG_WORKER_POOL] Worker %d started", w.id)
 
+	p := w.pool
 	for {
-		select {
-		case job, ok := <-w.jobQueue:
-			if !ok {
-				// Canal cerrado, terminar
-				logrus.Debugf("[MSG_WORKER_POOL] Worker %d shutting down", w.id)
-				return
+		if w.ctx.Err() != nil {
+			// Contexto cancelado, procesar jobs locales restantes antes de terminar
+			logrus.Debugf("[MSG_WORKER_POOL] Worker %d context cancelled, draining queue...", w.id)
+			w.drainQueue()
+			return
+		}
+
+		w.fill()
+		w.dropReleased()
+		if len(w.ready) == 0 {
+			continue
+		}
+
+		idx := p.sched.pick(w.ready)
+		if idx < 0 {
+			// Todos los jobs listos son de tenants en su tope: esperar a que se libere un slot
+			select {
+			case <-p.sched.slotFreed():
+			case <-time.After(100 * time.Millisecond):
+			case <-w.ctx.Done():
 			}
+			continue
+		}
+		next := w.ready[idx]
+		w.ready = append(w.ready[:idx], w.ready[idx+1:]...)
+		if !p.claim(next.q, next.d.Partition) {
+			p.sched.released(next.d.Job)
+			continue
+		}
+		w.process(next.q, next.d)
+	}
+}
 
-			// Procesar job con defer para garantizar limpieza
-			func() {
-				chatKey := job.InstanceID + "|" + job.ChatJID
+// fill trae jobs hasta llenar el buffer (queueSize por worker), uno por partición en cada vuelta para
+// no dejar que una partición con backlog ocupe todo el buffer. Primero los locales (closures) y los
+// fijados a este nodo; solo bloquea esperando la cola compartida si el buffer está vacío.
+func (w *worker) fill() {
+	p := w.pool
+	block := memoryBlock
+	if p.local != nil {
+		block = durableBlock
+	}
 
-				if w.pool.OnWorkerStart != nil {
-					w.pool.OnWorkerStart(w.id, chatKey)
-				}
-				atomic.StoreInt32(&w.isProcessing, 1)
-				defer func() {
-					if r := recover(); r != nil {
-						atomic.AddInt64(&w.pool.totalErrors, 1)
-						logrus.Errorf("[MSG_WORKER_POOL] Worker %d panic for %s: %v", w.id, chatKey, r)
-					}
-					if w.pool.OnWorkerEnd != nil {
-						w.pool.OnWorkerEnd(w.id, chatKey)
+	sources := []JobQueue{}
+	if p.local != nil {
+		sources = append(sources, p.local)
+	}
+	if p.pinned != nil {
+		sources = append(sources, p.pinned)
+	}
+	sources = append(sources, p.queue)
+
+	for {
+		added := 0
+		for i, q := range sources {
+			partitions := w.partitions(q)
+			if len(partitions) == 0 {
+				continue
+			}
+			wait := time.Duration(0)
+			if i == len(sources)-1 && len(w.ready) == 0 {
+				wait = block
+			}
+			ds, err := q.Fetch(w.ctx, p.consumer(w.id), partitions, wait)
+			if err != nil {
+				if w.ctx.Err() == nil {
+					logrus.WithError(err).Warnf("[MSG_WORKER_POOL] Worker %d fetch from %s queue failed", w.id, q.Kind())
+					if len(w.ready) == 0 {
+						w.sleep(time.Second)
 					}
-					atomic.StoreInt32(&w.isProcessing, 0)
-					atomic.AddInt64(&w.jobsProcessed, 1)
-					atomic.AddInt64(&w.pool.totalProcessed, 1)
-				}()
+				}
+				continue
+			}
+			for _, d := range ds {
+				// Una partición recuperada puede volver a entregar pendientes que siguen en el buffer
+				if q.Kind() != QueueMemory && w.buffered(q, d) {
+					continue
+				}
+				p.sched.queued(d.Job)
+				w.ready = append(w.ready, pendingDelivery{q: q, d: d})
+				added++
+			}
+		}
+		if added == 0 || len(w.ready) >= p.queueSize {
+			return
+		}
+	}
+}
 
-				err := job.Handler(w.ctx)
+// partitions devuelve las particiones del worker en q, o ninguna si el buffer está lleno
+func (w *worker) partitions(q JobQueue) []int {
+	if len(w.ready) >= w.pool.queueSize {
+		return nil
+	}
+	if q == w.pool.queue {
+		return w.pool.partitionsFor(w.id)
+	}
+	var all []int
+	for partition := w.id; partition < q.Partitions(); partition += w.pool.numWorkers {
+		all = append(all, partition)
+	}
+	return all
+}
 
-				if err != nil {
-					atomic.AddInt64(&w.pool.totalErrors, 1)
-					logrus.WithError(err).Errorf("[MSG_WORKER_POOL] Worker %d job failed for %s|%s",
-						w.id, job.InstanceID, job.ChatJID)
-				}
-			}()
+func (w *worker) buffered(q JobQueue, d Delivery) bool {
+	for _, pd := range w.ready {
+		if pd.q == q && pd.d.Partition == d.Partition && pd.d.Ref == d.Ref {
+			return true
+		}
+	}
+	return false
+}
 
-		case <-w.ctx.Done():
-			// Contexto cancelado, procesar jobs restantes antes de terminar
-			logrus.Debugf("[MSG_WORKER_POOL] Worker %d context cancelled, draining queue...", w.id)
-			w.drainQueue()
+// dropReleased descarta los jobs de particiones que el nodo soltó o perdió mientras esperaban:
+// siguen pendientes en la cola y el nuevo dueño los adopta
+func (w *worker) dropReleased() {
+	p := w.pool
+	if p.local == nil {
+		return // En memoria el nodo es dueño de todas las particiones
+	}
+	kept := w.ready[:0]
+	for _, pd := range w.ready {
+		if pd.q == p.queue && !p.isOwned(pd.d.Partition) {
+			p.sched.dropped(pd.d.Job)
+			continue
+		}
+		kept = append(kept, pd)
+	}
+	w.ready = kept
+}
+
+func (w *worker) sleep(d time.Duration) bool {
+	select {
+	case <-time.After(d):
+		return true
+	case <-w.ctx.Done():
+		return false
+	}
+}
+
+// process ejecuta una entrega: las closures una sola vez, los jobs tipados con reintentos
+// y backoff exponencial. Al agotar los intentos el job pasa a cuarentena y se confirma.
+func (w *worker) process(q JobQueue, d Delivery) {
+	p := w.pool
+	job := d.Job
+	chatKey := job.ChatKey()
+
+	defer p.releaseInflight(d.Partition)
+
+	// El tiempo que el job ocupa al worker (reintentos incluidos) se cobra a su tenant
+	start := time.Now()
+	defer func() { p.sched.done(job, time.Since(start)) }()
+
+	p.activeChatsMu.Lock()
+	p.activeChats[chatKey] = activeChatEntry{workerID: w.id, updatedAt: time.Now()}
+	p.activeChatsMu.Unlock()
+
+	if p.OnWorkerStart != nil {
+		p.OnWorkerStart(w.id, chatKey)
+	}
+	atomic.StoreInt32(&w.isProcessing, 1)
+	defer func() {
+		if p.OnWorkerEnd != nil {
+			p.OnWorkerEnd(w.id, chatKey)
+		}
+		atomic.StoreInt32(&w.isProcessing, 0)
+		atomic.AddInt64(&w.jobsProcessed, 1)
+		atomic.AddInt64(&p.totalProcessed, 1)
+	}()
+
+	if job.Type == "" {
+		if err := w.runClosure(job); err != nil {
+			atomic.AddInt64(&p.totalErrors, 1)
+			logrus.WithError(err).Errorf("[MSG_WORKER_POOL] Worker %d job failed for %s", w.id, chatKey)
+		}
+		_ = q.Ack(context.Background(), d)
+		return
+	}
+
+	defer p.closures.Delete(job.ID)
+	for {
+		attempt, err := q.Attempt(context.Background(), d)
+		if err != nil {
+			logrus.WithError(err).Warnf("[MSG_WORKER_POOL] Worker %d could not record attempt for job %s", w.id, job.ID)
+			attempt = 1
+		}
+		if attempt > p.maxAttempts {
+			// Ya falló en ejecuciones anteriores (ej. el nodo cayó procesándolo)
+			w.quarantine(q, d, errors.New("max attempts exceeded before delivery"), attempt-1)
+			return
+		}
+
+		err = w.runTyped(job)
+		if err == nil {
+			if err := q.Ack(context.Background(), d); err != nil {
+				logrus.WithError(err).Warnf("[MSG_WORKER_POOL] Worker %d could not ack job %s", w.id, job.ID)
+			}
+			return
+		}
+
+		atomic.AddInt64(&p.totalErrors, 1)
+		if errors.Is(err, ErrNoHandler) || attempt >= p.maxAttempts {
+			w.quarantine(q, d, err, attempt)
+			return
+		}
+
+		atomic.AddInt64(&p.totalRetried, 1)
+		backoff := p.retryBackoff << (attempt - 1)
+		logrus.WithError(err).Warnf("[MSG_WORKER_POOL] Worker %d %s job for %s failed (attempt %d/%d), retrying in %s",
+			w.id, job.Type, chatKey, attempt, p.maxAttempts, backoff)
+		if !w.sleep(backoff) {
+			// Shutdown: en Valkey el job queda pendiente y lo retoma el próximo dueño de la partición
+			if q.Kind() == QueueMemory {
+				_ = q.Ack(context.Background(), d)
+			}
 			return
 		}
 	}
 }
 
-// drainQueue procesa jobs pendientes antes del shutdown
+func (w *worker) quarantine(q JobQueue, d Delivery, cause error, attempts int) {
+	p := w.pool
+	atomic.AddInt64(&p.totalQuarantined, 1)
+	logrus.WithError(cause).Errorf("[MSG_WORKER_POOL] Quarantining %s job %s for %s after %d attempts",
+		d.Job.Type, d.Job.ID, d.Job.ChatKey(), attempts)
+
+	err := q.Quarantine(context.Background(), d, DeadJob{
+		Job:           d.Job,
+		Error:         cause.Error(),
+		Attempts:      attempts,
+		NodeID:        p.nodeID,
+		QuarantinedAt: time.Now(),
+	})
+	if err != nil {
+		logrus.WithError(err).Errorf("[MSG_WORKER_POOL] Failed to quarantine job %s", d.Job.ID)
+	}
+}
+
+func (w *worker) runClosure(job Job) (err error) {
+	v, ok := w.pool.closures.LoadAndDelete(job.ID)
+	if !ok {
+		return fmt.Errorf("handler for job %s not found", job.ID)
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return v.(func(ctx context.Context) error)(w.ctx)
+}
+
+func (w *worker) runTyped(job Job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	if closure, ok := w.pool.closures.Load(job.ID); ok {
+		return closure.(func(ctx context.Context) error)(w.ctx)
+	}
+
+	w.pool.handlersMu.RLock()
+	handler, ok := w.pool.handlers[job.Type]
+	w.pool.handlersMu.RUnlock()
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrNoHandler, job.Type)
+	}
+	return handler(w.ctx, job)
+}
+
+// drainQueue procesa los jobs locales pendientes antes del shutdown.
+// Los jobs de la cola durable se quedan en Valkey para el próximo dueño de la partición.
 func (w *worker) drainQueue() {
+	p := w.pool
+	var mq *MemoryJobQueue
+	if p.local != nil {
+		mq = p.local
+	} else if q, ok := p.queue.(*MemoryJobQueue); ok {
+		mq = q
+	}
+
+	for _, pd := range w.ready {
+		if pd.q.Kind() == QueueMemory {
+			p.sched.pick([]pendingDelivery{pd})
+			p.claim(pd.q, pd.d.Partition)
+			w.process(pd.q, pd.d)
+		} else {
+			p.sched.dropped(pd.d.Job)
+		}
+	}
+	w.ready = nil
+
+	if mq == nil {
+		return
+	}
 	for {
-		select {
-		case job, ok := <-w.jobQueue:
-			if !ok {
-				return
-			}
-			// Procesar job restante
-			func() {
-				defer func() {
-					if r := recover(); r != nil {
-						atomic.AddInt64(&w.pool.totalErrors, 1)
-						logrus.Errorf("[MSG_WORKER_POOL] Worker %d drain panic: %v", w.id, r)
-					}
-				}()
-				if err := job.Handler(w.ctx); err != nil {
-					logrus.WithError(err).Errorf("[MSG_WORKER_POOL] Worker %d drain job failed", w.id)
-				}
-			}()
-		default:
-			// No hay más jobs
+		ds, _ := mq.Fetch(context.Background(), "", w.partitions(mq), 0)
+		if len(ds) == 0 {
 			return
 		}
+		for _, d := range ds {
+			p.sched.queued(d.Job)
+			p.sched.pick([]pendingDelivery{{q: mq, d: d}})
+			p.claim(mq, d.Partition)
+			w.process(mq, d)
+		}
 	}
 }
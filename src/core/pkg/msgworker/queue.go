@@ -0,0 +1,107 @@
+package msgworker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"hash/fnv"
+	"time"
+)
+
+const (
+	QueueMemory = "memory"
+	QueueValkey = "valkey"
+)
+
+var (
+	ErrQueueFull  = errors.New("job queue is full")
+	ErrNoHandler  = errors.New("no handler registered for job type")
+	ErrPoolClosed = errors.New("worker pool stopped")
+)
+
+// Job es un trabajo serializable: viaja por la cola durable y lo ejecuta el handler registrado
+// para su Type en cualquier nodo. Los jobs del mismo InstanceID|ChatJID van a la misma partición
+// y se procesan en orden.
+type Job struct {
+	ID         string          `json:"id"`
+	Type       string          `json:"type"`
+	InstanceID string          `json:"instance_id"`
+	ChatJID    string          `json:"chat_jid"`
+	Payload    json.RawMessage `json:"payload,omitempty"`
+	EnqueuedAt time.Time       `json:"enqueued_at"`
+	Tenant     string          `json:"tenant,omitempty"` // Workspace/cliente para el reparto justo (vacío: InstanceID)
+	Tier       string          `json:"tier,omitempty"`   // ClientTier del tenant, da el peso en el reparto
+	Lane       Lane            `json:"lane,omitempty"`
+}
+
+// ChatKey identifica la conversación del job (misma clave que usa el monitoreo)
+func (j Job) ChatKey() string {
+	return j.InstanceID + "|" + j.ChatJID
+}
+
+// JobHandler ejecuta un job tipado. Un error provoca reintentos; al agotarlos el job va a cuarentena.
+type JobHandler func(ctx context.Context, job Job) error
+
+// Delivery es un job entregado a un consumidor y pendiente de confirmar
+type Delivery struct {
+	Job       Job
+	Partition int
+	Ref       string // ID de la entrada en el backend (stream ID en Valkey)
+}
+
+// DeadJob es un job en cuarentena tras fallar todos sus intentos (poison message)
+type DeadJob struct {
+	Job           Job       `json:"job"`
+	Error         string    `json:"error"`
+	Attempts      int       `json:"attempts"`
+	NodeID        string    `json:"node_id"`
+	QuarantinedAt time.Time `json:"quarantined_at"`
+}
+
+// JobQueue es el backend de la cola de jobs. Está particionado: cada partición la consume un solo
+// worker de un solo nodo a la vez, así se conserva el orden por chat aunque haya varios nodos.
+type JobQueue interface {
+	Kind() string
+	Partitions() int
+
+	Enqueue(ctx context.Context, partition int, job Job) error
+
+	// Fetch espera hasta block por jobs de las particiones dadas y devuelve como mucho uno por partición:
+	// el siguiente que el consumidor aún no recibió. Las entregas sin confirmar no bloquean la partición;
+	// el pool solo ejecuta el primero de cada chat.
+	Fetch(ctx context.Context, consumer string, partitions []int, block time.Duration) ([]Delivery, error)
+	Ack(ctx context.Context, d Delivery) error
+
+	// Attempt registra un intento de entrega y devuelve el total; sobrevive a reinicios en backends durables.
+	Attempt(ctx context.Context, d Delivery) (int, error)
+	Quarantine(ctx context.Context, d Delivery, dead DeadJob) error
+	Quarantined(ctx context.Context, limit int) ([]DeadJob, error)
+
+	Depth(ctx context.Context, partition int) (int64, error)
+
+	// Propiedad de particiones entre nodos. Heartbeat devuelve cuántos nodos consumen la cola.
+	Heartbeat(ctx context.Context, nodeID string, ttl time.Duration) (int, error)
+	Acquire(ctx context.Context, partition int, nodeID string, ttl time.Duration) (bool, error)
+	Renew(ctx context.Context, partition int, nodeID string, ttl time.Duration) (bool, error)
+	Release(ctx context.Context, partition int, nodeID string) error
+
+	// Adopt pasa a consumer los jobs sin confirmar que dejó el dueño anterior de la partición,
+	// para que se entreguen antes que los nuevos.
+	Adopt(ctx context.Context, partition int, consumer string) error
+}
+
+// PartitionFor calcula la partición de un chat con hash consistente
+func PartitionFor(instanceID, chatJID string, partitions int) int {
+	h := fnv.New32a()
+	h.Write([]byte(instanceID + "|" + chatJID))
+	return int(h.Sum32() % uint32(partitions))
+}
+
+// NewJob arma un job tipado serializando el payload a JSON
+func NewJob(jobType, instanceID, chatJID string, payload any) (MessageJob, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return MessageJob{}, err
+	}
+	return MessageJob{InstanceID: instanceID, ChatJID: chatJID, Type: jobType, Payload: data}, nil
+}
@@ -0,0 +1,173 @@
+package msgworker
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const maxMemoryQuarantine = 1000
+
+type memoryPartition struct {
+	items   []Delivery
+	pending int // Entregados sin confirmar
+}
+
+// MemoryJobQueue es la cola en proceso (sin Valkey). No sobrevive a reinicios, pero la capacidad
+// es compartida entre particiones: un chat muy activo ya no llena "su" worker mientras otros están vacíos.
+type MemoryJobQueue struct {
+	mu         sync.Mutex
+	partitions []memoryPartition
+	capacity   int
+	size       int
+	seq        int64
+	wake       chan struct{}
+	attempts   map[string]int
+	dead       []DeadJob
+}
+
+func NewMemoryJobQueue(partitions, capacity int) *MemoryJobQueue {
+	if partitions <= 0 {
+		partitions = 1
+	}
+	return &MemoryJobQueue{
+		partitions: make([]memoryPartition, partitions),
+		capacity:   capacity,
+		wake:       make(chan struct{}),
+		attempts:   make(map[string]int),
+	}
+}
+
+func (q *MemoryJobQueue) Kind() string {
+	return QueueMemory
+}
+
+func (q *MemoryJobQueue) Partitions() int {
+	return len(q.partitions)
+}
+
+func (q *MemoryJobQueue) Enqueue(ctx context.Context, partition int, job Job) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.capacity > 0 && q.size >= q.capacity {
+		return ErrQueueFull
+	}
+	q.seq++
+	p := &q.partitions[partition]
+	p.items = append(p.items, Delivery{Job: job, Partition: partition, Ref: strconv.FormatInt(q.seq, 10)})
+	q.size++
+
+	// Despierta a los workers esperando en Fetch
+	close(q.wake)
+	q.wake = make(chan struct{})
+	return nil
+}
+
+func (q *MemoryJobQueue) Fetch(ctx context.Context, consumer string, partitions []int, block time.Duration) ([]Delivery, error) {
+	var timer *time.Timer
+	for {
+		// Como en Valkey: el siguiente job de cada partición; el pool decide en qué orden atenderlos
+		var out []Delivery
+		q.mu.Lock()
+		for _, idx := range partitions {
+			p := &q.partitions[idx]
+			if len(p.items) == 0 {
+				continue
+			}
+			out = append(out, p.items[0])
+			p.items = p.items[1:]
+			p.pending++
+		}
+		wake := q.wake
+		q.mu.Unlock()
+		if len(out) > 0 {
+			return out, nil
+		}
+
+		if block <= 0 {
+			return nil, nil
+		}
+		if timer == nil {
+			timer = time.NewTimer(block)
+			defer timer.Stop()
+		}
+		select {
+		case <-wake:
+		case <-timer.C:
+			return nil, nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+func (q *MemoryJobQueue) Ack(ctx context.Context, d Delivery) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.partitions[d.Partition].pending--
+	q.size--
+	delete(q.attempts, d.Ref)
+
+	close(q.wake)
+	q.wake = make(chan struct{})
+	return nil
+}
+
+func (q *MemoryJobQueue) Attempt(ctx context.Context, d Delivery) (int, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.attempts[d.Ref]++
+	return q.attempts[d.Ref], nil
+}
+
+func (q *MemoryJobQueue) Quarantine(ctx context.Context, d Delivery, dead DeadJob) error {
+	q.mu.Lock()
+	q.dead = append(q.dead, dead)
+	if len(q.dead) > maxMemoryQuarantine {
+		q.dead = q.dead[len(q.dead)-maxMemoryQuarantine:]
+	}
+	q.mu.Unlock()
+	return q.Ack(ctx, d)
+}
+
+// Quarantined devuelve los últimos jobs en cuarentena, del más reciente al más antiguo
+func (q *MemoryJobQueue) Quarantined(ctx context.Context, limit int) ([]DeadJob, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	out := make([]DeadJob, 0, len(q.dead))
+	for i := len(q.dead) - 1; i >= 0 && (limit <= 0 || len(out) < limit); i-- {
+		out = append(out, q.dead[i])
+	}
+	return out, nil
+}
+
+// Depth cuenta también los entregados sin confirmar, como XLEN en Valkey
+func (q *MemoryJobQueue) Depth(ctx context.Context, partition int) (int64, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	p := q.partitions[partition]
+	return int64(len(p.items) + p.pending), nil
+}
+
+// En memoria solo hay un nodo y es dueño de todas las particiones
+func (q *MemoryJobQueue) Heartbeat(ctx context.Context, nodeID string, ttl time.Duration) (int, error) {
+	return 1, nil
+}
+
+func (q *MemoryJobQueue) Acquire(ctx context.Context, partition int, nodeID string, ttl time.Duration) (bool, error) {
+	return true, nil
+}
+
+func (q *MemoryJobQueue) Renew(ctx context.Context, partition int, nodeID string, ttl time.Duration) (bool, error) {
+	return true, nil
+}
+
+func (q *MemoryJobQueue) Release(ctx context.Context, partition int, nodeID string) error {
+	return nil
+}
+
+func (q *MemoryJobQueue) Adopt(ctx context.Context, partition int, consumer string) error {
+	return nil
+}
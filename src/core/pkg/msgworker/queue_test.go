@@ -0,0 +1,140 @@
+package msgworker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestPool(t *testing.T, workers, queueSize int) *MessageWorkerPool {
+	t.Helper()
+	pool := NewMessageWorkerPoolWithQueue(workers, queueSize, NewMemoryJobQueue(8, workers*queueSize), PoolOptions{
+		MaxAttempts:  3,
+		RetryBackoff: time.Millisecond,
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	pool.Start(ctx)
+	t.Cleanup(func() {
+		cancel()
+		pool.Stop()
+	})
+	return pool
+}
+
+func TestPool_TypedJobsKeepChatOrder(t *testing.T) {
+	pool := newTestPool(t, 2, 10)
+
+	var mu sync.Mutex
+	var got []int
+	done := make(chan struct{})
+	pool.RegisterHandler("test.append", func(ctx context.Context, job Job) error {
+		var n int
+		require.NoError(t, json.Unmarshal(job.Payload, &n))
+		mu.Lock()
+		got = append(got, n)
+		if len(got) == 5 {
+			close(done)
+		}
+		mu.Unlock()
+		return nil
+	})
+
+	for i := 1; i <= 5; i++ {
+		job, err := NewJob("test.append", "inst1", "chat1", i)
+		require.NoError(t, err)
+		require.True(t, pool.TryDispatch(job))
+	}
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("jobs were not processed")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []int{1, 2, 3, 4, 5}, got)
+}
+
+func TestPool_RetriesThenQuarantines(t *testing.T) {
+	pool := newTestPool(t, 1, 10)
+
+	var calls int32
+	pool.RegisterHandler("test.flaky", func(ctx context.Context, job Job) error {
+		if atomic.AddInt32(&calls, 1) < 2 {
+			return errors.New("temporary")
+		}
+		return nil
+	})
+	pool.RegisterHandler("test.poison", func(ctx context.Context, job Job) error {
+		return errors.New("always fails")
+	})
+
+	flaky, _ := NewJob("test.flaky", "inst1", "a", nil)
+	poison, _ := NewJob("test.poison", "inst1", "b", nil)
+	pool.Dispatch(flaky)
+	pool.Dispatch(poison)
+
+	require.Eventually(t, func() bool {
+		return pool.GetStats().TotalProcessed == 2
+	}, time.Second, 5*time.Millisecond)
+
+	dead, err := pool.Quarantined(context.Background(), 10)
+	require.NoError(t, err)
+	require.Len(t, dead, 1)
+	assert.Equal(t, "test.poison", dead[0].Job.Type)
+	assert.Equal(t, 3, dead[0].Attempts)
+	assert.Equal(t, "always fails", dead[0].Error)
+
+	stats := pool.GetStats()
+	assert.Equal(t, int64(1), stats.TotalQuarantined)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+	assert.Equal(t, int64(3), stats.TotalRetried) // 1 del flaky + 2 del poison
+	assert.Equal(t, QueueMemory, stats.Backend)
+}
+
+func TestPool_UnknownTypeIsQuarantined(t *testing.T) {
+	pool := newTestPool(t, 1, 10)
+
+	job, _ := NewJob("test.missing", "inst1", "a", nil)
+	pool.Dispatch(job)
+
+	require.Eventually(t, func() bool {
+		dead, _ := pool.Quarantined(context.Background(), 10)
+		return len(dead) == 1
+	}, time.Second, 5*time.Millisecond)
+	assert.Equal(t, int64(0), pool.GetStats().TotalRetried)
+}
+
+func TestMemoryJobQueue_SharedCapacityAndPartitionOrder(t *testing.T) {
+	q := NewMemoryJobQueue(4, 2)
+	ctx := context.Background()
+
+	require.NoError(t, q.Enqueue(ctx, 0, Job{ID: "1"}))
+	require.NoError(t, q.Enqueue(ctx, 0, Job{ID: "2"}))
+	assert.ErrorIs(t, q.Enqueue(ctx, 3, Job{ID: "3"}), ErrQueueFull)
+
+	ds, err := q.Fetch(ctx, "", []int{0, 1}, 0)
+	require.NoError(t, err)
+	require.Len(t, ds, 1)
+	assert.Equal(t, "1", ds[0].Job.ID)
+
+	// La entrega sin confirmar no bloquea la partición, pero sigue ocupando capacidad y profundidad
+	ds2, _ := q.Fetch(ctx, "", []int{0}, 0)
+	require.Len(t, ds2, 1)
+	assert.Equal(t, "2", ds2[0].Job.ID)
+	depth, _ := q.Depth(ctx, 0)
+	assert.Equal(t, int64(2), depth)
+	assert.ErrorIs(t, q.Enqueue(ctx, 3, Job{ID: "3"}), ErrQueueFull)
+
+	require.NoError(t, q.Ack(ctx, ds[0]))
+	require.NoError(t, q.Enqueue(ctx, 3, Job{ID: "3"}))
+	ds, _ = q.Fetch(ctx, "", []int{0}, 10*time.Millisecond)
+	assert.Empty(t, ds)
+}
@@ -0,0 +1,360 @@
+package msgworker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/AzielCF/az-wap/core/infrastructure/valkey"
+	valkeylib "github.com/valkey-io/valkey-go"
+)
+
+const (
+	jobGroup         = "workers"
+	maxDeadJobs      = 10000
+	adoptBatchSize   = 100
+	jobFieldName     = "job"
+	deadFieldName    = "dead"
+	defaultValkeyTTL = 30 * time.Second
+)
+
+// Lua script: toma la partición si está libre o ya es de este nodo (reinicio con el mismo server ID)
+const acquirePartitionScript = `
+local cur = redis.call("get", KEYS[1])
+if cur == ARGV[1] then
+	redis.call("pexpire", KEYS[1], ARGV[2])
+	return 1
+end
+if cur then
+	return 0
+end
+redis.call("set", KEYS[1], ARGV[1], "PX", ARGV[2])
+return 1
+`
+
+// Lua script: renueva solo si el lease sigue siendo del nodo
+const renewPartitionScript = `
+if redis.call("get", KEYS[1]) == ARGV[1] then
+	return redis.call("pexpire", KEYS[1], ARGV[2])
+end
+return 0
+`
+
+const releasePartitionScript = `
+if redis.call("get", KEYS[1]) == ARGV[1] then
+	return redis.call("del", KEYS[1])
+end
+return 0
+`
+
+// ValkeyJobQueue guarda cada partición en un stream de Valkey con un consumer group.
+// Los jobs sobreviven a reinicios y, si un nodo cae, otro toma sus particiones y reclama
+// las entregas sin confirmar (XAUTOCLAIM) antes de leer jobs nuevos.
+type ValkeyJobQueue struct {
+	client     *valkey.Client
+	prefix     string
+	partitions int
+
+	mu         sync.Mutex
+	recovering map[int]string // Particiones adoptadas: se releen sus pendientes desde este ID ("0" al adoptar)
+}
+
+// NewValkeyJobQueue crea la cola durable; name separa colas de distintos pools (ej. "primary").
+func NewValkeyJobQueue(ctx context.Context, client *valkey.Client, name string, partitions int) (*ValkeyJobQueue, error) {
+	if partitions <= 0 {
+		partitions = 1
+	}
+	q := &ValkeyJobQueue{
+		client:     client,
+		prefix:     client.Key("jobs:"+name) + ":",
+		partitions: partitions,
+		recovering: make(map[int]string),
+	}
+	inner := client.Inner()
+	for i := 0; i < partitions; i++ {
+		err := inner.Do(ctx, inner.B().XgroupCreate().Key(q.streamKey(i)).Group(jobGroup).Id("0").Mkstream().Build()).Error()
+		if err != nil && !strings.Contains(err.Error(), "BUSYGROUP") {
+			return nil, fmt.Errorf("failed to create job group for partition %d: %w", i, err)
+		}
+	}
+	return q, nil
+}
+
+func (q *ValkeyJobQueue) streamKey(partition int) string {
+	return q.prefix + "stream:" + strconv.Itoa(partition)
+}
+
+func (q *ValkeyJobQueue) leaseKey(partition int) string {
+	return q.prefix + "lease:" + strconv.Itoa(partition)
+}
+
+func (q *ValkeyJobQueue) attemptsKey() string {
+	return q.prefix + "attempts"
+}
+
+func (q *ValkeyJobQueue) deadKey() string {
+	return q.prefix + "dead"
+}
+
+func (q *ValkeyJobQueue) nodesKey() string {
+	return q.prefix + "nodes"
+}
+
+func (q *ValkeyJobQueue) partitionOf(stream string) int {
+	n, _ := strconv.Atoi(strings.TrimPrefix(stream, q.prefix+"stream:"))
+	return n
+}
+
+func (q *ValkeyJobQueue) Kind() string {
+	return QueueValkey
+}
+
+func (q *ValkeyJobQueue) Partitions() int {
+	return q.partitions
+}
+
+func (q *ValkeyJobQueue) Enqueue(ctx context.Context, partition int, job Job) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	inner := q.client.Inner()
+	return inner.Do(ctx, inner.B().Xadd().Key(q.streamKey(partition)).Id("*").FieldValue().FieldValue(jobFieldName, string(data)).Build()).Error()
+}
+
+func (q *ValkeyJobQueue) Fetch(ctx context.Context, consumer string, partitions []int, block time.Duration) ([]Delivery, error) {
+	if block <= 0 {
+		block = -1 // Sin BLOCK: en Valkey BLOCK 0 espera indefinidamente
+	}
+	if len(partitions) == 0 {
+		if block < 0 {
+			return nil, nil
+		}
+		select {
+		case <-time.After(block):
+		case <-ctx.Done():
+		}
+		return nil, ctx.Err()
+	}
+
+	// Primero los pendientes de particiones adoptadas (lectura inmediata desde su cursor); el cursor
+	// avanza con cada entrega para no repetir las que el pool aún no confirmó
+	var recovering, fresh []int
+	var cursors []string
+	q.mu.Lock()
+	for _, p := range partitions {
+		if cursor, ok := q.recovering[p]; ok {
+			recovering = append(recovering, p)
+			cursors = append(cursors, cursor)
+		} else {
+			fresh = append(fresh, p)
+		}
+	}
+	q.mu.Unlock()
+
+	if len(recovering) > 0 {
+		out, last, err := q.read(ctx, consumer, recovering, cursors, -1)
+		if err != nil {
+			return nil, err
+		}
+		q.mu.Lock()
+		for _, p := range recovering {
+			if id, ok := last[p]; ok {
+				q.recovering[p] = id
+			} else {
+				delete(q.recovering, p)
+			}
+		}
+		q.mu.Unlock()
+		if len(out) > 0 {
+			return out, nil
+		}
+	}
+	if len(fresh) == 0 {
+		return nil, nil
+	}
+	ids := make([]string, len(fresh))
+	for i := range ids {
+		ids[i] = ">"
+	}
+	out, _, err := q.read(ctx, consumer, fresh, ids, block)
+	return out, err
+}
+
+// read lee una entrada por partición y devuelve también el último ID leído de cada una
+// (incluidas las entradas borradas, que solo se confirman)
+func (q *ValkeyJobQueue) read(ctx context.Context, consumer string, partitions []int, ids []string, block time.Duration) ([]Delivery, map[int]string, error) {
+	keys := make([]string, len(partitions))
+	for i, p := range partitions {
+		keys[i] = q.streamKey(p)
+	}
+
+	inner := q.client.Inner()
+	var cmd valkeylib.Completed
+	if block >= 0 {
+		cmd = inner.B().Xreadgroup().Group(jobGroup, consumer).Count(1).Block(block.Milliseconds()).Streams().Key(keys...).Id(ids...).Build()
+	} else {
+		cmd = inner.B().Xreadgroup().Group(jobGroup, consumer).Count(1).Streams().Key(keys...).Id(ids...).Build()
+	}
+	res, err := inner.Do(ctx, cmd).AsXRead()
+	if err != nil {
+		if valkeylib.IsValkeyNil(err) {
+			return nil, nil, nil
+		}
+		return nil, nil, err
+	}
+
+	var out []Delivery
+	last := make(map[int]string, len(res))
+	for stream, entries := range res {
+		partition := q.partitionOf(stream)
+		for _, e := range entries {
+			last[partition] = e.ID
+			d := Delivery{Partition: partition, Ref: e.ID}
+			raw, ok := e.FieldValues[jobFieldName]
+			if !ok {
+				// Entrada borrada mientras estaba pendiente: solo se confirma
+				_ = q.Ack(ctx, d)
+				continue
+			}
+			if err := json.Unmarshal([]byte(raw), &d.Job); err != nil {
+				d.Job = Job{ID: e.ID, Type: "invalid", Payload: json.RawMessage(strconv.Quote(raw))}
+			}
+			out = append(out, d)
+		}
+	}
+	return out, last, nil
+}
+
+func (q *ValkeyJobQueue) Ack(ctx context.Context, d Delivery) error {
+	inner := q.client.Inner()
+	stream := q.streamKey(d.Partition)
+	cmds := valkeylib.Commands{
+		inner.B().Xack().Key(stream).Group(jobGroup).Id(d.Ref).Build(),
+		inner.B().Xdel().Key(stream).Id(d.Ref).Build(),
+		inner.B().Hdel().Key(q.attemptsKey()).Field(stream + ":" + d.Ref).Build(),
+	}
+	for _, res := range inner.DoMulti(ctx, cmds...) {
+		if err := res.Error(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (q *ValkeyJobQueue) Attempt(ctx context.Context, d Delivery) (int, error) {
+	inner := q.client.Inner()
+	n, err := inner.Do(ctx, inner.B().Hincrby().Key(q.attemptsKey()).Field(q.streamKey(d.Partition)+":"+d.Ref).Increment(1).Build()).AsInt64()
+	return int(n), err
+}
+
+func (q *ValkeyJobQueue) Quarantine(ctx context.Context, d Delivery, dead DeadJob) error {
+	data, err := json.Marshal(dead)
+	if err != nil {
+		return err
+	}
+	inner := q.client.Inner()
+	err = inner.Do(ctx, inner.B().Xadd().Key(q.deadKey()).Maxlen().Almost().Threshold(strconv.Itoa(maxDeadJobs)).
+		Id("*").FieldValue().FieldValue(deadFieldName, string(data)).Build()).Error()
+	if err != nil {
+		return err
+	}
+	return q.Ack(ctx, d)
+}
+
+func (q *ValkeyJobQueue) Quarantined(ctx context.Context, limit int) ([]DeadJob, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+	inner := q.client.Inner()
+	entries, err := inner.Do(ctx, inner.B().Xrevrange().Key(q.deadKey()).End("+").Start("-").Count(int64(limit)).Build()).AsXRange()
+	if err != nil {
+		if valkeylib.IsValkeyNil(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	out := make([]DeadJob, 0, len(entries))
+	for _, e := range entries {
+		var dead DeadJob
+		if err := json.Unmarshal([]byte(e.FieldValues[deadFieldName]), &dead); err == nil {
+			out = append(out, dead)
+		}
+	}
+	return out, nil
+}
+
+func (q *ValkeyJobQueue) Depth(ctx context.Context, partition int) (int64, error) {
+	inner := q.client.Inner()
+	return inner.Do(ctx, inner.B().Xlen().Key(q.streamKey(partition)).Build()).AsInt64()
+}
+
+func (q *ValkeyJobQueue) Heartbeat(ctx context.Context, nodeID string, ttl time.Duration) (int, error) {
+	if ttl <= 0 {
+		ttl = defaultValkeyTTL
+	}
+	inner := q.client.Inner()
+	now := time.Now()
+	cmds := valkeylib.Commands{
+		inner.B().Zadd().Key(q.nodesKey()).ScoreMember().ScoreMember(float64(now.Add(ttl).UnixMilli()), nodeID).Build(),
+		inner.B().Zremrangebyscore().Key(q.nodesKey()).Min("-inf").Max(strconv.FormatInt(now.UnixMilli(), 10)).Build(),
+		inner.B().Zcard().Key(q.nodesKey()).Build(),
+	}
+	res := inner.DoMulti(ctx, cmds...)
+	for _, r := range res[:2] {
+		if err := r.Error(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := res[2].AsInt64()
+	return int(n), err
+}
+
+func (q *ValkeyJobQueue) lease(ctx context.Context, script string, partition int, nodeID string, ttl time.Duration) (bool, error) {
+	inner := q.client.Inner()
+	n, err := inner.Do(ctx, inner.B().Eval().Script(script).Numkeys(1).Key(q.leaseKey(partition)).
+		Arg(nodeID, strconv.FormatInt(ttl.Milliseconds(), 10)).Build()).AsInt64()
+	return n == 1, err
+}
+
+func (q *ValkeyJobQueue) Acquire(ctx context.Context, partition int, nodeID string, ttl time.Duration) (bool, error) {
+	return q.lease(ctx, acquirePartitionScript, partition, nodeID, ttl)
+}
+
+func (q *ValkeyJobQueue) Renew(ctx context.Context, partition int, nodeID string, ttl time.Duration) (bool, error) {
+	return q.lease(ctx, renewPartitionScript, partition, nodeID, ttl)
+}
+
+func (q *ValkeyJobQueue) Release(ctx context.Context, partition int, nodeID string) error {
+	inner := q.client.Inner()
+	return inner.Do(ctx, inner.B().Eval().Script(releasePartitionScript).Numkeys(1).Key(q.leaseKey(partition)).Arg(nodeID).Build()).Error()
+}
+
+func (q *ValkeyJobQueue) Adopt(ctx context.Context, partition int, consumer string) error {
+	inner := q.client.Inner()
+	start := "0-0"
+	for {
+		arr, err := inner.Do(ctx, inner.B().Xautoclaim().Key(q.streamKey(partition)).Group(jobGroup).Consumer(consumer).
+			MinIdleTime("0").Start(start).Count(adoptBatchSize).Justid().Build()).ToArray()
+		if err != nil {
+			return err
+		}
+		if len(arr) == 0 {
+			break
+		}
+		if start, err = arr[0].ToString(); err != nil {
+			return err
+		}
+		if start == "0-0" {
+			break
+		}
+	}
+	q.mu.Lock()
+	q.recovering[partition] = "0"
+	q.mu.Unlock()
+	return nil
+}
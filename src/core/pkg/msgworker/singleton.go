@@ -13,26 +13,61 @@ var (
 	globalPoolOnce sync.Once
 	globalPoolCtx  context.Context
 	globalCancel   context.CancelFunc
+
+	globalQueue  JobQueue
+	globalPinned JobQueue
+	globalNodeID string
 )
 
+// ConfigureGlobalPool define las colas y el ID de nodo del pool global: queue es compartida por
+// todos los nodos y pinned (opcional) solo la consume este nodo. Debe llamarse antes del primer
+// GetGlobalPool; sin configurar se usa una cola en memoria.
+func ConfigureGlobalPool(queue, pinned JobQueue, nodeID string) {
+	globalQueue = queue
+	globalPinned = pinned
+	globalNodeID = nodeID
+}
+
 // GetGlobalPool returns the singleton message worker pool
 func GetGlobalPool() *MessageWorkerPool {
 	globalPoolOnce.Do(func() {
 		globalPoolCtx, globalCancel = context.WithCancel(context.Background())
 
-		size := coreconfig.Global.WorkerPool.Size
+		size, queue, partitions, maxAttempts, tenantCap := 0, 0, 0, 0, 0
+		if coreconfig.Global != nil {
+			size = coreconfig.Global.WorkerPool.Size
+			queue = coreconfig.Global.WorkerPool.QueueSize
+			partitions = coreconfig.Global.WorkerPool.Partitions
+			maxAttempts = coreconfig.Global.WorkerPool.MaxAttempts
+			tenantCap = coreconfig.Global.WorkerPool.TenantCap
+		}
 		if size <= 0 {
 			size = 6
 		}
-
-		queue := coreconfig.Global.WorkerPool.QueueSize
 		if queue <= 0 {
 			queue = 250
 		}
 
-		globalPool = NewMessageWorkerPool(size, queue)
+		if partitions <= 0 {
+			partitions = DefaultPartitions
+		}
+		if partitions < size {
+			partitions = size // Menos particiones que workers dejaría workers sin trabajo
+		}
+
+		jobQueue := globalQueue
+		if jobQueue == nil {
+			jobQueue = NewMemoryJobQueue(partitions, size*queue)
+		}
+
+		globalPool = NewMessageWorkerPoolWithQueue(size, queue, jobQueue, PoolOptions{
+			NodeID:      globalNodeID,
+			Pinned:      globalPinned,
+			MaxAttempts: maxAttempts,
+			TenantCap:   tenantCap,
+		})
 		globalPool.Start(globalPoolCtx)
-		logrus.Infof("[MSG_WORKER_POOL] Global instance started with %d workers and queue size %d", size, queue)
+		logrus.Infof("[MSG_WORKER_POOL] Global instance started with %d workers, queue size %d and %s backend", size, queue, jobQueue.Kind())
 	})
 	return globalPool
 }
@@ -0,0 +1,417 @@
+package timeutils
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Frequency is the RRULE FREQ part. Only the frequencies used by reminders are supported.
+type Frequency string
+
+const (
+	FreqDaily   Frequency = "DAILY"
+	FreqWeekly  Frequency = "WEEKLY"
+	FreqMonthly Frequency = "MONTHLY"
+)
+
+// maxRRulePeriods bounds the occurrence search for rules that rarely or never match (e.g. BYMONTHDAY=31;BYDAY=-1MO).
+const maxRRulePeriods = 5000
+
+var rruleDays = map[string]time.Weekday{
+	"SU": time.Sunday, "MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday,
+	"TH": time.Thursday, "FR": time.Friday, "SA": time.Saturday,
+}
+
+var rruleDayNames = [...]string{"SU", "MO", "TU", "WE", "TH", "FR", "SA"}
+
+// WeekdayNum is a BYDAY entry. N is the ordinal inside the month (1MO, -1FR); 0 means every such weekday.
+type WeekdayNum struct {
+	N   int
+	Day time.Weekday
+}
+
+// RRule is the subset of RFC 5545 recurrence rules understood by the scheduler:
+// FREQ=DAILY|WEEKLY|MONTHLY, INTERVAL, BYDAY, BYMONTHDAY, COUNT and UNTIL.
+type RRule struct {
+	Freq       Frequency
+	Interval   int
+	ByDay      []WeekdayNum
+	ByMonthDay []int
+	Count      int
+	Until      time.Time
+
+	untilFloating bool // UNTIL without 'Z' (or date-only): wall time in the rule's timezone
+}
+
+// ParseRRule parses a rule such as "FREQ=WEEKLY;BYDAY=MO,WE;UNTIL=20261231T235959Z". The "RRULE:" prefix is optional.
+func ParseRRule(s string) (RRule, error) {
+	s = strings.TrimPrefix(strings.TrimSpace(strings.ToUpper(s)), "RRULE:")
+	if s == "" {
+		return RRule{}, fmt.Errorf("empty rrule")
+	}
+
+	r := RRule{Interval: 1}
+	for _, part := range strings.Split(s, ";") {
+		if part == "" {
+			continue
+		}
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) != 2 {
+			return RRule{}, fmt.Errorf("invalid rrule part %q", part)
+		}
+		key, value := kv[0], kv[1]
+
+		switch key {
+		case "FREQ":
+			switch Frequency(value) {
+			case FreqDaily, FreqWeekly, FreqMonthly:
+				r.Freq = Frequency(value)
+			default:
+				return RRule{}, fmt.Errorf("unsupported FREQ %q (use DAILY, WEEKLY or MONTHLY)", value)
+			}
+		case "INTERVAL":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return RRule{}, fmt.Errorf("invalid INTERVAL %q", value)
+			}
+			r.Interval = n
+		case "COUNT":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return RRule{}, fmt.Errorf("invalid COUNT %q", value)
+			}
+			r.Count = n
+		case "UNTIL":
+			until, floating, err := parseRRuleUntil(value)
+			if err != nil {
+				return RRule{}, err
+			}
+			r.Until, r.untilFloating = until, floating
+		case "BYDAY":
+			for _, item := range strings.Split(value, ",") {
+				wd, err := parseWeekdayNum(item)
+				if err != nil {
+					return RRule{}, err
+				}
+				r.ByDay = append(r.ByDay, wd)
+			}
+		case "BYMONTHDAY":
+			for _, item := range strings.Split(value, ",") {
+				n, err := strconv.Atoi(item)
+				if err != nil || n == 0 || n < -31 || n > 31 {
+					return RRule{}, fmt.Errorf("invalid BYMONTHDAY %q", item)
+				}
+				r.ByMonthDay = append(r.ByMonthDay, n)
+			}
+		case "WKST":
+			if value != "MO" {
+				return RRule{}, fmt.Errorf("only WKST=MO is supported")
+			}
+		default:
+			return RRule{}, fmt.Errorf("unsupported rrule part %s", key)
+		}
+	}
+
+	if r.Freq == "" {
+		return RRule{}, fmt.Errorf("rrule requires FREQ")
+	}
+	if r.Count > 0 && !r.Until.IsZero() {
+		return RRule{}, fmt.Errorf("COUNT and UNTIL cannot be combined")
+	}
+	for _, wd := range r.ByDay {
+		if wd.N != 0 && r.Freq != FreqMonthly {
+			return RRule{}, fmt.Errorf("ordinal BYDAY (e.g. 1MO) is only valid with FREQ=MONTHLY")
+		}
+	}
+	return r, nil
+}
+
+func parseWeekdayNum(s string) (WeekdayNum, error) {
+	s = strings.TrimSpace(s)
+	if len(s) < 2 {
+		return WeekdayNum{}, fmt.Errorf("invalid BYDAY %q", s)
+	}
+	day, ok := rruleDays[s[len(s)-2:]]
+	if !ok {
+		return WeekdayNum{}, fmt.Errorf("invalid BYDAY %q", s)
+	}
+	wd := WeekdayNum{Day: day}
+	if prefix := s[:len(s)-2]; prefix != "" {
+		n, err := strconv.Atoi(prefix)
+		if err != nil || n == 0 || n < -5 || n > 5 {
+			return WeekdayNum{}, fmt.Errorf("invalid BYDAY ordinal %q", s)
+		}
+		wd.N = n
+	}
+	return wd, nil
+}
+
+func parseRRuleUntil(value string) (time.Time, bool, error) {
+	if t, err := time.Parse("20060102T150405Z", value); err == nil {
+		return t, false, nil
+	}
+	if t, err := time.Parse("20060102T150405", value); err == nil {
+		return t, true, nil
+	}
+	if t, err := time.Parse("20060102", value); err == nil {
+		// A date-only UNTIL includes the whole day
+		return t.Add(24*time.Hour - time.Second), true, nil
+	}
+	return time.Time{}, false, fmt.Errorf("invalid UNTIL %q", value)
+}
+
+// String renders the rule in canonical RRULE form (without the "RRULE:" prefix).
+func (r RRule) String() string {
+	parts := []string{"FREQ=" + string(r.Freq)}
+	if r.Interval > 1 {
+		parts = append(parts, fmt.Sprintf("INTERVAL=%d", r.Interval))
+	}
+	if len(r.ByDay) > 0 {
+		days := make([]string, len(r.ByDay))
+		for i, wd := range r.ByDay {
+			days[i] = rruleDayNames[wd.Day]
+			if wd.N != 0 {
+				days[i] = strconv.Itoa(wd.N) + days[i]
+			}
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+	if len(r.ByMonthDay) > 0 {
+		days := make([]string, len(r.ByMonthDay))
+		for i, d := range r.ByMonthDay {
+			days[i] = strconv.Itoa(d)
+		}
+		parts = append(parts, "BYMONTHDAY="+strings.Join(days, ","))
+	}
+	if r.Count > 0 {
+		parts = append(parts, fmt.Sprintf("COUNT=%d", r.Count))
+	}
+	if !r.Until.IsZero() {
+		if r.untilFloating {
+			parts = append(parts, "UNTIL="+r.Until.Format("20060102T150405"))
+		} else {
+			parts = append(parts, "UNTIL="+r.Until.UTC().Format("20060102T150405Z"))
+		}
+	}
+	return strings.Join(parts, ";")
+}
+
+// Next returns the first occurrence strictly after 'after' for a series anchored at dtstart.
+// Occurrences keep dtstart's wall-clock time in loc, so they stay at the same local hour across DST changes.
+// ok is false when the series has ended (COUNT/UNTIL) or no occurrence can be found.
+func (r RRule) Next(dtstart, after time.Time, loc *time.Location) (time.Time, bool) {
+	if loc == nil {
+		loc = time.UTC
+	}
+	start := dtstart.In(loc)
+	hour, minute, sec := start.Clock()
+	interval := r.Interval
+	if interval < 1 {
+		interval = 1
+	}
+
+	until := r.Until
+	if r.untilFloating && !until.IsZero() {
+		until = time.Date(until.Year(), until.Month(), until.Day(), until.Hour(), until.Minute(), until.Second(), 0, loc)
+	}
+
+	// Without COUNT there is no need to enumerate from the anchor: jump close to 'after'
+	period := 0
+	if r.Count == 0 {
+		period = r.periodsBetween(start, after.In(loc))/interval - 1
+		if period < 0 {
+			period = 0
+		}
+	}
+
+	count := 0
+	for guard := 0; guard < maxRRulePeriods; guard, period = guard+1, period+1 {
+		for _, day := range r.candidates(start, period*interval) {
+			occ := time.Date(day.Year(), day.Month(), day.Day(), hour, minute, sec, 0, loc)
+			if occ.Before(start) {
+				continue
+			}
+			if !until.IsZero() && occ.After(until) {
+				return time.Time{}, false
+			}
+			count++
+			if r.Count > 0 && count > r.Count {
+				return time.Time{}, false
+			}
+			if occ.After(after) {
+				return occ, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+// periodsBetween counts whole FREQ periods between the anchor and t (calendar based, DST independent).
+func (r RRule) periodsBetween(start, t time.Time) int {
+	if !t.After(start) {
+		return 0
+	}
+	switch r.Freq {
+	case FreqMonthly:
+		return (t.Year()-start.Year())*12 + int(t.Month()) - int(start.Month())
+	case FreqWeekly:
+		return daysBetween(weekStart(start), t) / 7
+	default:
+		return daysBetween(start, t)
+	}
+}
+
+// candidates returns the dates (at midnight UTC, only Y/M/D matter) of the n-th period, sorted.
+func (r RRule) candidates(start time.Time, n int) []time.Time {
+	var days []time.Time
+	switch r.Freq {
+	case FreqDaily:
+		day := civilDate(start.Year(), start.Month(), start.Day()+n)
+		if r.matchesDay(day) {
+			days = append(days, day)
+		}
+	case FreqWeekly:
+		ws := weekStart(start)
+		monday := civilDate(ws.Year(), ws.Month(), ws.Day()+7*n)
+		weekdays := []time.Weekday{start.Weekday()}
+		if len(r.ByDay) > 0 {
+			weekdays = weekdays[:0]
+			for _, wd := range r.ByDay {
+				weekdays = append(weekdays, wd.Day)
+			}
+		}
+		for _, wd := range weekdays {
+			offset := (int(wd) + 6) % 7 // Monday = 0
+			days = append(days, civilDate(monday.Year(), monday.Month(), monday.Day()+offset))
+		}
+	case FreqMonthly:
+		first := civilDate(start.Year(), start.Month()+time.Month(n), 1)
+		daysInMonth := civilDate(first.Year(), first.Month()+1, 0).Day()
+
+		switch {
+		case len(r.ByDay) > 0:
+			for _, wd := range r.ByDay {
+				for _, d := range monthWeekdays(first, daysInMonth, wd) {
+					day := civilDate(first.Year(), first.Month(), d)
+					if r.matchesMonthDay(day, daysInMonth) {
+						days = append(days, day)
+					}
+				}
+			}
+		case len(r.ByMonthDay) > 0:
+			for _, md := range r.ByMonthDay {
+				d := md
+				if md < 0 {
+					d = daysInMonth + md + 1
+				}
+				if d >= 1 && d <= daysInMonth {
+					days = append(days, civilDate(first.Year(), first.Month(), d))
+				}
+			}
+		default:
+			// Months without the anchor's day (e.g. the 31st) are skipped, as in RFC 5545
+			if start.Day() <= daysInMonth {
+				days = append(days, civilDate(first.Year(), first.Month(), start.Day()))
+			}
+		}
+	}
+
+	sort.Slice(days, func(i, j int) bool { return days[i].Before(days[j]) })
+	out := days[:0]
+	for i, d := range days {
+		if i == 0 || !d.Equal(days[i-1]) {
+			out = append(out, d)
+		}
+	}
+	return out
+}
+
+// matchesDay applies BYDAY/BYMONTHDAY as filters for FREQ=DAILY.
+func (r RRule) matchesDay(day time.Time) bool {
+	if len(r.ByDay) > 0 {
+		found := false
+		for _, wd := range r.ByDay {
+			if wd.Day == day.Weekday() {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	daysInMonth := civilDate(day.Year(), day.Month()+1, 0).Day()
+	return r.matchesMonthDay(day, daysInMonth)
+}
+
+func (r RRule) matchesMonthDay(day time.Time, daysInMonth int) bool {
+	if len(r.ByMonthDay) == 0 {
+		return true
+	}
+	for _, md := range r.ByMonthDay {
+		d := md
+		if md < 0 {
+			d = daysInMonth + md + 1
+		}
+		if d == day.Day() {
+			return true
+		}
+	}
+	return false
+}
+
+// monthWeekdays returns the days of the month matching a BYDAY entry (all, n-th or n-th from the end).
+func monthWeekdays(first time.Time, daysInMonth int, wd WeekdayNum) []int {
+	firstMatch := 1 + (int(wd.Day)-int(first.Weekday())+7)%7
+	var all []int
+	for d := firstMatch; d <= daysInMonth; d += 7 {
+		all = append(all, d)
+	}
+	switch {
+	case wd.N == 0:
+		return all
+	case wd.N > 0 && wd.N <= len(all):
+		return []int{all[wd.N-1]}
+	case wd.N < 0 && -wd.N <= len(all):
+		return []int{all[len(all)+wd.N]}
+	}
+	return nil
+}
+
+func civilDate(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func weekStart(t time.Time) time.Time {
+	offset := (int(t.Weekday()) + 6) % 7
+	return civilDate(t.Year(), t.Month(), t.Day()-offset)
+}
+
+func daysBetween(a, b time.Time) int {
+	da := civilDate(a.Year(), a.Month(), a.Day())
+	db := civilDate(b.Year(), b.Month(), b.Day())
+	return int(db.Sub(da).Hours() / 24)
+}
+
+// LegacyDaysToRRule converts the old "0,1,2" (Sunday=0) recurrence days into a weekly RRULE.
+func LegacyDaysToRRule(recurrenceDays string) (string, error) {
+	r := RRule{Freq: FreqWeekly, Interval: 1}
+	seen := make(map[int]bool)
+	for _, p := range strings.Split(recurrenceDays, ",") {
+		d, err := strconv.Atoi(strings.TrimSpace(p))
+		if err != nil || d < 0 || d > 6 {
+			return "", fmt.Errorf("invalid day in recurrence: %s", p)
+		}
+		if !seen[d] {
+			seen[d] = true
+			r.ByDay = append(r.ByDay, WeekdayNum{Day: time.Weekday(d)})
+		}
+	}
+	sort.Slice(r.ByDay, func(i, j int) bool {
+		return (int(r.ByDay[i].Day)+6)%7 < (int(r.ByDay[j].Day)+6)%7
+	})
+	return r.String(), nil
+}
@@ -0,0 +1,105 @@
+package timeutils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseRRule_RoundTrip(t *testing.T) {
+	cases := []string{
+		"FREQ=DAILY",
+		"FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,WE,FR",
+		"FREQ=MONTHLY;BYDAY=-1FR;COUNT=3",
+		"FREQ=MONTHLY;BYMONTHDAY=1,15;UNTIL=20261231T235959Z",
+	}
+	for _, in := range cases {
+		r, err := ParseRRule("RRULE:" + in)
+		if err != nil {
+			t.Fatalf("ParseRRule(%q) unexpected error: %v", in, err)
+		}
+		if got := r.String(); got != in {
+			t.Errorf("String() = %q, want %q", got, in)
+		}
+	}
+
+	for _, bad := range []string{"", "BYDAY=MO", "FREQ=YEARLY", "FREQ=WEEKLY;BYDAY=1MO", "FREQ=DAILY;COUNT=2;UNTIL=20260101"} {
+		if _, err := ParseRRule(bad); err == nil {
+			t.Errorf("ParseRRule(%q) expected error, got nil", bad)
+		}
+	}
+}
+
+func TestRRuleNext_KeepsLocalTimeAcrossDST(t *testing.T) {
+	loc, err := time.LoadLocation("Europe/Madrid")
+	if err != nil {
+		t.Skipf("tzdata not available: %v", err)
+	}
+	r, _ := ParseRRule("FREQ=DAILY")
+	// El 25/10/2026 Madrid pasa de CEST (+2) a CET (+1)
+	dtstart := time.Date(2026, 10, 24, 9, 0, 0, 0, loc)
+
+	next, ok := r.Next(dtstart, dtstart, loc)
+	if !ok {
+		t.Fatalf("Next() ended unexpectedly")
+	}
+	next, _ = r.Next(dtstart, next, loc)
+	if h, m, _ := next.In(loc).Clock(); h != 9 || m != 0 {
+		t.Fatalf("occurrence after DST change at %s, want 09:00 local", next.In(loc))
+	}
+	if got := next.UTC().Hour(); got != 8 {
+		t.Fatalf("UTC hour = %d, want 8 (CET)", got)
+	}
+}
+
+func TestRRuleNext_CountAndUntil(t *testing.T) {
+	dtstart := time.Date(2026, 1, 5, 10, 0, 0, 0, time.UTC) // Lunes
+
+	r, _ := ParseRRule("FREQ=WEEKLY;BYDAY=MO,TH;COUNT=3")
+	var got []time.Time
+	for after := dtstart.Add(-time.Second); ; {
+		next, ok := r.Next(dtstart, after, time.UTC)
+		if !ok {
+			break
+		}
+		got = append(got, next)
+		after = next
+	}
+	want := []time.Time{
+		dtstart,
+		time.Date(2026, 1, 8, 10, 0, 0, 0, time.UTC),
+		time.Date(2026, 1, 12, 10, 0, 0, 0, time.UTC),
+	}
+	if len(got) != len(want) {
+		t.Fatalf("occurrences = %v, want %v", got, want)
+	}
+	for i := range want {
+		if !got[i].Equal(want[i]) {
+			t.Errorf("occurrence %d = %s, want %s", i, got[i], want[i])
+		}
+	}
+
+	r, _ = ParseRRule("FREQ=DAILY;UNTIL=20260107")
+	if _, ok := r.Next(dtstart, time.Date(2026, 1, 7, 10, 0, 0, 0, time.UTC), time.UTC); ok {
+		t.Errorf("Next() past UNTIL should end the series")
+	}
+}
+
+func TestRRuleNext_MonthlyLastFriday(t *testing.T) {
+	r, _ := ParseRRule("FREQ=MONTHLY;BYDAY=-1FR")
+	dtstart := time.Date(2026, 1, 1, 18, 30, 0, 0, time.UTC)
+
+	next, ok := r.Next(dtstart, time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC), time.UTC)
+	if !ok || !next.Equal(time.Date(2026, 2, 27, 18, 30, 0, 0, time.UTC)) {
+		t.Fatalf("Next() = %s, %v, want 2026-02-27 18:30", next, ok)
+	}
+}
+
+func TestLegacyDaysToRRule(t *testing.T) {
+	got, err := LegacyDaysToRRule("0,1,5")
+	if err != nil || got != "FREQ=WEEKLY;BYDAY=MO,FR,SU" {
+		t.Fatalf("LegacyDaysToRRule() = %q, %v", got, err)
+	}
+	if _, err := LegacyDaysToRRule("1,9"); err == nil {
+		t.Fatalf("LegacyDaysToRRule() expected error for invalid day")
+	}
+}
@@ -0,0 +1,45 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	coreconfig "github.com/AzielCF/az-wap/core/config"
+	fiberUtils "github.com/gofiber/fiber/v2/utils"
+	"github.com/sirupsen/logrus"
+)
+
+// ConvertToVoiceNote transcodes audio to OGG/Opus so WhatsApp renders it as a voice note (PTT).
+// If the audio is already OGG or ffmpeg is not installed, the input is returned untouched.
+func ConvertToVoiceNote(data []byte, mimeType, fileName string) ([]byte, string, string) {
+	if strings.HasPrefix(strings.ToLower(mimeType), "audio/ogg") {
+		return data, mimeType, fileName
+	}
+	if _, err := exec.LookPath("ffmpeg"); err != nil {
+		return data, mimeType, fileName
+	}
+
+	id := fiberUtils.UUIDv4()
+	inputPath := fmt.Sprintf("%s/%s-audio-input", coreconfig.Global.Paths.SendItems, id)
+	outputPath := fmt.Sprintf("%s/%s-audio-output.ogg", coreconfig.Global.Paths.SendItems, id)
+	defer func() { go RemoveFile(0, inputPath, outputPath) }()
+
+	if err := os.WriteFile(inputPath, data, 0644); err != nil {
+		logrus.WithError(err).Error("failed to write audio for transcoding")
+		return data, mimeType, fileName
+	}
+
+	cmd := exec.Command("ffmpeg", "-y", "-i", inputPath, "-acodec", "libopus", "-b:a", "32k", outputPath)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		logrus.WithError(err).WithField("output", string(out)).Error("failed to transcode audio to ogg")
+		return data, mimeType, fileName
+	}
+
+	newBytes, err := os.ReadFile(outputPath)
+	if err != nil || len(newBytes) == 0 {
+		return data, mimeType, fileName
+	}
+	return newBytes, "audio/ogg; codecs=opus", id + ".ogg"
+}
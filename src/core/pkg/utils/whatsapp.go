@@ -877,6 +877,18 @@ func CleanWhatsAppID(id string) string {
 	return id
 }
 
+// ChatKey reduces a JID (user@server, with or without device), platform ID or phone to its user part.
+// It is the key shared by the bot monitor and data-subject requests to find a person's chats.
+func ChatKey(jid string) string {
+	if idx := strings.Index(jid, "@"); idx != -1 {
+		jid = jid[:idx]
+	}
+	if idx := strings.Index(jid, ":"); idx != -1 {
+		jid = jid[:idx]
+	}
+	return strings.TrimPrefix(jid, "+")
+}
+
 // NormalizeWhatsAppIdentity removes all whatsapp platform suffixes (including @lid) for comparison.
 func NormalizeWhatsAppIdentity(id string) string {
 	id = CleanWhatsAppID(id)
@@ -24,6 +24,10 @@ import (
 type MessageProcessor struct {
 	repo         workspaceDomain.IWorkspaceRepository
 	orchestrator *SessionOrchestrator
+
+	// OnOffHours handles a message that arrived outside the channel's business hours.
+	// Returns true when it took care of the message and the bot must not reply now.
+	OnOffHours func(ctx context.Context, ch channelDomain.Channel, msg messageDomain.IncomingMessage, botID string) bool
 }
 
 func NewMessageProcessor(repo workspaceDomain.IWorkspaceRepository, orch *SessionOrchestrator) *MessageProcessor {
@@ -66,6 +70,13 @@ func (p *MessageProcessor) ProcessFinal(ctx context.Context, ch channelDomain.Ch
 		chatwoot.ForwardIncomingMessageWithConfig(ctx, cwCfg, phone, name, msg.Text, nil)
 	}
 
+	// Business hours: outside the schedule the channel policy may answer for the bot or defer it
+	if p.OnOffHours != nil && !ch.Config.BusinessHours.IsOpen(time.Now(), ch.Config.Location()) {
+		if p.OnOffHours(ctx, ch, msg, botID) {
+			return botengineDomain.BotOutput{}, nil
+		}
+	}
+
 	entry, hasSession := p.orchestrator.GetEntry(key)
 	currentFocus := 0
 	lastBubbleCount := 0
@@ -87,9 +98,21 @@ func (p *MessageProcessor) ProcessFinal(ctx context.Context, ch channelDomain.Ch
 		botTemplateID = cCtx.ResolvedBotTemplateID
 	}
 
+	// Experimento A/B del canal: cada chat queda fijo en uno de los brazos.
+	// La plantilla resuelta para un cliente registrado tiene prioridad sobre la variante del brazo.
+	botRevision, splitArm := 0, ""
+	if arm, ok := ch.Config.TrafficSplit.Choose(ch.ID + "|" + msg.ChatID); ok {
+		botRevision, splitArm = arm.Revision, arm.Name
+		if botTemplateID == "" {
+			botTemplateID = arm.VariantID
+		}
+	}
+
 	input := botengineDomain.BotInput{
 		BotID:         botID,
 		BotTemplateID: botTemplateID,
+		BotRevision:   botRevision,
+		SplitArm:      splitArm,
 		WorkspaceID:   ch.WorkspaceID,
 		TraceID:       fmt.Sprintf("%v", safeMetadata["message_id"]),
 		InstanceID:    ch.ID,
@@ -107,6 +130,10 @@ func (p *MessageProcessor) ProcessFinal(ctx context.Context, ch channelDomain.Ch
 	if ch.Config.Timezone != "" {
 		input.Metadata["channel_timezone"] = ch.Config.Timezone
 	}
+	// Channel typing profile (takes precedence over the bot's)
+	if ch.Config.Typing != nil {
+		input.Metadata["typing_settings"] = ch.Config.Typing
+	}
 
 	// Attach ClientContext if present in metadata
 	if cc, ok := safeMetadata["client_context"].(*botengineDomain.ClientContext); ok {
@@ -162,6 +189,7 @@ func (p *MessageProcessor) ProcessFinal(ctx context.Context, ch channelDomain.Ch
 		input.PendingTasks = entry.PendingTasks
 		input.LastReplyTime = entry.LastReplyTime
 		input.History = entry.Memory.GetHistory()
+		input.HistoryTokenBudget = ch.Config.HistoryTokenBudget
 
 		// Resources
 		rawResources := entry.Memory.GetResources()
@@ -231,6 +259,9 @@ func (p *MessageProcessor) ProcessFinal(ctx context.Context, ch channelDomain.Ch
 			userTextToStore = output.UserText
 		}
 
+		// El motor condensó el inicio del historial: se aplica antes de añadir los turnos nuevos
+		entry.Memory.ApplySummary(output.HistorySummary)
+
 		if userTextToStore != "" {
 			// Use configured limit (0=Default 10, -1=Unlimited, >0=Limit)
 			entry.Memory.AddTurn("user", userTextToStore, entry.MaxHistoryLimit)
@@ -272,6 +303,25 @@ func (p *MessageProcessor) ProcessFinal(ctx context.Context, ch channelDomain.Ch
 	return output, nil
 }
 
+// RecordResumedReply stores the reply sent after an operator decided a held tool call. It is
+// delivered outside ProcessFinal, so without this the next turn would not know what the bot said.
+func (p *MessageProcessor) RecordResumedReply(ctx context.Context, input botengineDomain.BotInput, output botengineDomain.BotOutput) {
+	if output.Text == "" {
+		return
+	}
+	key := input.InstanceID + "|" + input.ChatID + "|" + input.SenderID
+	entry, ok := p.orchestrator.GetEntry(key)
+	if !ok {
+		// The session already closed: there is no memory left to update
+		return
+	}
+	entry.Memory.AddTurn("assistant", output.Text, entry.MaxHistoryLimit)
+	entry.LastReplyTime = time.Now()
+	if err := p.orchestrator.store.Save(ctx, key, entry, 4*time.Minute); err != nil {
+		logrus.WithError(err).Errorf("[MessageProcessor] Failed to persist resumed reply for %s", key)
+	}
+}
+
 func (p *MessageProcessor) loadBotMedia(m *messageDomain.IncomingMedia) *botengineDomain.BotMedia {
 	if m == nil {
 		return nil
@@ -0,0 +1,39 @@
+package application
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	botengineDomain "github.com/AzielCF/az-wap/botengine/domain"
+	"github.com/AzielCF/az-wap/workspace/domain/message"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordResumedReply_AddsAssistantTurnToSession(t *testing.T) {
+	ctx := context.Background()
+	orch := NewSessionOrchestrator(nil)
+	p := NewMessageProcessor(nil, orch)
+
+	key := "ch1|chat1|sender1"
+	entry, _ := orch.GetOrCreateEntry(key, message.IncomingMessage{ChatID: "chat1", SenderID: "sender1"})
+	entry.Memory.AddTurn("user", "Cancela mi pedido", 0)
+	entry.Memory.AddTurn("assistant", "Un operador debe aprobarlo, te aviso", 0)
+	require.NoError(t, orch.store.Save(ctx, key, entry, time.Minute))
+
+	input := botengineDomain.BotInput{InstanceID: "ch1", ChatID: "chat1", SenderID: "sender1"}
+	p.RecordResumedReply(ctx, input, botengineDomain.BotOutput{Text: "Listo, tu pedido fue cancelado"})
+
+	stored, ok := orch.GetEntry(key)
+	require.True(t, ok)
+	require.Len(t, stored.Memory.History, 3)
+	last := stored.Memory.History[2]
+	assert.Equal(t, "assistant", last.Role)
+	assert.Equal(t, "Listo, tu pedido fue cancelado", last.Text)
+
+	// A closed session is not recreated just to hold the reply
+	p.RecordResumedReply(ctx, botengineDomain.BotInput{InstanceID: "ch1", ChatID: "other", SenderID: "other"}, botengineDomain.BotOutput{Text: "x"})
+	_, ok = orch.GetEntry("ch1|other|other")
+	assert.False(t, ok)
+}
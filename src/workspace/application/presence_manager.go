@@ -24,6 +24,10 @@ type PresenceManager struct {
 
 	// Callback to check if channel is active
 	IsChannelActive func(channelID string) bool
+
+	// Callback to check if the channel is outside its business hours (channel timezone).
+	// Nil = ventana nocturna con el reloj del servidor
+	IsOffHours func(channelID string, now time.Time) bool
 }
 
 func NewPresenceManager(store channel.PresenceStore) *PresenceManager {
@@ -67,7 +71,10 @@ func (pm *PresenceManager) DeleteStatus(ctx context.Context, channelID string) e
 	return pm.store.Delete(ctx, channelID)
 }
 
-func (pm *PresenceManager) isNightWindow() bool {
+func (pm *PresenceManager) isNightWindow(channelID string) bool {
+	if pm.IsOffHours != nil {
+		return pm.IsOffHours(channelID, time.Now())
+	}
 	hour := time.Now().Hour()
 	// Night window for faster visual offline: 12 AM - 6 AM
 	return hour >= 0 && hour < 6
@@ -136,8 +143,8 @@ func (pm *PresenceManager) CheckChannelPresence(channelID string) {
 
 	// How long to stay visually 'Online' after idle
 	delay := time.Duration(15+rand.Intn(10)) * time.Minute
-	if pm.isNightWindow() {
-		// Faster visual offline during the night (1-3 mins)
+	if pm.isNightWindow(channelID) {
+		// Faster visual offline during the night / off-hours (1-3 mins)
 		delay = time.Duration(1+rand.Intn(2)) * time.Minute
 	}
 
@@ -0,0 +1,86 @@
+package application
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	botengineDomain "github.com/AzielCF/az-wap/botengine/domain"
+	channelDomain "github.com/AzielCF/az-wap/workspace/domain/channel"
+	commonDomain "github.com/AzielCF/az-wap/workspace/domain/common"
+)
+
+// AuthorizeTaskBot rejects a task whose bot is outside the client's allowed bots. An empty list
+// means the client has no restriction.
+func AuthorizeTaskBot(clientCtx *botengineDomain.ClientContext, botID string) error {
+	if clientCtx != nil && len(clientCtx.AllowedBots) > 0 && !slices.Contains(clientCtx.AllowedBots, botID) {
+		return fmt.Errorf("client %s is not allowed to use bot %s", clientCtx.ClientID, botID)
+	}
+	return nil
+}
+
+// ProactiveTaskInput builds the bot input for a scheduled AI task. The instruction is framed as
+// a note from the system so the bot writes the outgoing message instead of answering the note.
+func (p *MessageProcessor) ProactiveTaskInput(ch channelDomain.Channel, post commonDomain.ScheduledPost, task commonDomain.ScheduledAITask, botID string, clientCtx *botengineDomain.ClientContext, history []botengineDomain.ChatTurn) botengineDomain.BotInput {
+	senderID := post.SenderID
+	if senderID == "" {
+		senderID = post.TargetID
+	}
+
+	input := botengineDomain.BotInput{
+		BotID:         botID,
+		WorkspaceID:   ch.WorkspaceID,
+		TraceID:       fmt.Sprintf("task_%s_%d", post.ID, time.Now().Unix()),
+		InstanceID:    ch.ID,
+		ChatID:        post.TargetID,
+		SenderID:      senderID,
+		Platform:      p.mapChannelTypeToPlatform(ch.Type),
+		History:       history,
+		Language:      ch.Config.DefaultLanguage,
+		IsTester:      ch.Config.IsTester,
+		ClientContext: clientCtx,
+		Text: "[SCHEDULED TASK] The user asked you earlier to carry out this task at this moment. " +
+			"Do it now (use your tools if needed) and reply ONLY with the message to send them, " +
+			"without mentioning this note.\nTask: " + task.Instruction,
+		Metadata: map[string]any{
+			"proactive_task":    true,
+			"scheduled_post_id": post.ID,
+		},
+	}
+	input.Metadata["trace_id"] = input.TraceID
+
+	// Deferred reply: the messages arrived while the channel was closed
+	if task.Origin == commonDomain.AITaskOriginOffHours {
+		input.Text = "[DEFERRED REPLY] These messages arrived while the business was closed and we are open now. " +
+			"Reply to them as a normal conversation (use your tools if needed), without mentioning this note.\nMessages:\n" + task.Instruction
+		input.Metadata["off_hours_reply"] = true
+	}
+
+	if ch.Config.Timezone != "" {
+		input.Metadata["channel_timezone"] = ch.Config.Timezone
+	}
+	if ch.Config.Typing != nil {
+		input.Metadata["typing_settings"] = ch.Config.Typing
+	}
+
+	// The cost goes to the client that owns the task, falling back to the chat's client
+	billingClientID := task.ClientID
+	if clientCtx != nil {
+		input.BotTemplateID = clientCtx.ResolvedBotTemplateID
+		input.Metadata["client_context"] = clientCtx
+		if clientCtx.Language != "" {
+			input.Language = clientCtx.Language
+		}
+		if billingClientID == "" {
+			billingClientID = clientCtx.ClientID
+		}
+	}
+	if billingClientID != "" {
+		input.Metadata["billing_client_id"] = billingClientID
+	}
+
+	if input.Language == "" {
+		input.Language = "en"
+	}
+	return input
+}
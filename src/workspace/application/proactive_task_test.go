@@ -0,0 +1,41 @@
+package application
+
+import (
+	"testing"
+
+	botengineDomain "github.com/AzielCF/az-wap/botengine/domain"
+	channelDomain "github.com/AzielCF/az-wap/workspace/domain/channel"
+	"github.com/AzielCF/az-wap/workspace/domain/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProactiveTaskInput_ChargesTaskClient(t *testing.T) {
+	p := NewMessageProcessor(nil, nil)
+	ch := channelDomain.Channel{ID: "ch1", WorkspaceID: "ws1", Type: channelDomain.ChannelTypeWhatsApp}
+	post := common.ScheduledPost{ID: "post1", TargetID: "123@s.whatsapp.net"}
+	chatClient := &botengineDomain.ClientContext{ClientID: "chat-client"}
+
+	// The task belongs to another client: the cost goes to it, not to the chat client
+	input := p.ProactiveTaskInput(ch, post, common.ScheduledAITask{Instruction: "x", ClientID: "task-client"}, "bot1", chatClient, nil)
+	assert.Equal(t, "task-client", input.Metadata["billing_client_id"])
+
+	// Without an explicit owner the chat client pays
+	input = p.ProactiveTaskInput(ch, post, common.ScheduledAITask{Instruction: "x"}, "bot1", chatClient, nil)
+	assert.Equal(t, "chat-client", input.Metadata["billing_client_id"])
+
+	// No client at all: nobody to charge
+	input = p.ProactiveTaskInput(ch, post, common.ScheduledAITask{Instruction: "x"}, "bot1", nil, nil)
+	assert.NotContains(t, input.Metadata, "billing_client_id")
+}
+
+func TestAuthorizeTaskBot(t *testing.T) {
+	restricted := &botengineDomain.ClientContext{ClientID: "c1", AllowedBots: []string{"bot1"}}
+
+	assert.NoError(t, AuthorizeTaskBot(restricted, "bot1"))
+	err := AuthorizeTaskBot(restricted, "bot2")
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "not allowed to use bot bot2")
+	}
+	assert.NoError(t, AuthorizeTaskBot(&botengineDomain.ClientContext{ClientID: "c2"}, "bot2"))
+	assert.NoError(t, AuthorizeTaskBot(nil, "bot2"))
+}
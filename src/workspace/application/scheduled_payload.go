@@ -0,0 +1,299 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	coreconfig "github.com/AzielCF/az-wap/core/config"
+	"github.com/AzielCF/az-wap/workspace/domain/channel"
+	wsCommonDomain "github.com/AzielCF/az-wap/workspace/domain/common"
+	"github.com/sirupsen/logrus"
+)
+
+// ScheduledMediaDir is where the media of a scheduled post is kept until its last occurrence.
+// It lives outside the send-items/statics folders so the cache janitor never prunes it.
+func ScheduledMediaDir(postID string) string {
+	return filepath.Join(coreconfig.Global.Paths.Storages, "scheduled", postID)
+}
+
+// ErrMediaPathNotAllowed is returned for media outside the upload, statics and scheduled folders.
+var ErrMediaPathNotAllowed = errors.New("media path is outside the allowed directories")
+
+// mediaRoots are the folders a scheduled post may take media from: uploads (send items),
+// workspace statics and the private copies kept by RetainScheduledMedia.
+func mediaRoots() []string {
+	return []string{
+		coreconfig.Global.Paths.SendItems,
+		coreconfig.Global.Paths.Statics,
+		filepath.Join(coreconfig.Global.Paths.Storages, "scheduled"),
+	}
+}
+
+// resolveMediaPath returns the real absolute path of a media file (symlinks followed) and rejects
+// anything outside the allowed folders: the path can come from a request or from the bot.
+func resolveMediaPath(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	resolved, err := filepath.EvalSymlinks(abs)
+	if err != nil {
+		return "", err
+	}
+	for _, root := range mediaRoots() {
+		if root == "" {
+			continue
+		}
+		absRoot, err := filepath.Abs(root)
+		if err != nil {
+			continue
+		}
+		// The folder itself may be a symlink
+		if realRoot, err := filepath.EvalSymlinks(absRoot); err == nil {
+			absRoot = realRoot
+		}
+		rel, err := filepath.Rel(absRoot, resolved)
+		if err == nil && rel != "." && rel != ".." && !strings.HasPrefix(rel, ".."+string(os.PathSeparator)) {
+			return resolved, nil
+		}
+	}
+	return "", fmt.Errorf("%w: %s", ErrMediaPathNotAllowed, path)
+}
+
+// RetainScheduledMedia copies every media file referenced by the post into its own folder and
+// rewrites the paths, so temporary uploads can be cleaned up while the series is still running.
+// Media outside the upload and statics folders is rejected.
+func RetainScheduledMedia(post *wsCommonDomain.ScheduledPost) error {
+	dir := ScheduledMediaDir(post.ID)
+
+	retain := func(i int, path string) (string, error) {
+		if path == "" {
+			return path, nil
+		}
+		src, err := resolveMediaPath(path)
+		if err != nil {
+			return "", err
+		}
+		if realDir, err := filepath.EvalSymlinks(dir); err == nil && strings.HasPrefix(src, realDir+string(os.PathSeparator)) {
+			return src, nil
+		}
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			return "", err
+		}
+		dst := filepath.Join(dir, fmt.Sprintf("%02d-%s", i, filepath.Base(src)))
+		if err := copyFile(src, dst); err != nil {
+			return "", fmt.Errorf("media %s: %w", src, err)
+		}
+		return dst, nil
+	}
+
+	var err error
+	if post.MediaPath, err = retain(0, post.MediaPath); err != nil {
+		ReleaseScheduledMedia(post.ID)
+		return err
+	}
+	if post.Payload != nil {
+		for i := range post.Payload.Parts {
+			part := &post.Payload.Parts[i]
+			if part.Type != wsCommonDomain.PayloadPartMedia {
+				continue
+			}
+			if part.MediaPath, err = retain(i+1, part.MediaPath); err != nil {
+				ReleaseScheduledMedia(post.ID)
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// ReleaseScheduledMedia deletes the retained media once the post will not be sent again.
+func ReleaseScheduledMedia(postID string) {
+	if postID == "" {
+		return
+	}
+	if err := os.RemoveAll(ScheduledMediaDir(postID)); err != nil {
+		logrus.WithError(err).Warnf("[SCHEDULER] Failed to release media of task %s", postID)
+	}
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// payloadResult summarizes how the parts of one occurrence went.
+type payloadResult struct {
+	sent     int
+	failures []string
+	aborted  bool // A required part failed and the rest of the sequence was not sent
+}
+
+func (r payloadResult) status() wsCommonDomain.ScheduledPostExecutionStatus {
+	switch {
+	case r.aborted:
+		return wsCommonDomain.ScheduledPostExecutionFailed
+	case len(r.failures) > 0:
+		return wsCommonDomain.ScheduledPostExecutionPartial
+	default:
+		return wsCommonDomain.ScheduledPostExecutionSent
+	}
+}
+
+func (r payloadResult) errorText() string {
+	return strings.Join(r.failures, "; ")
+}
+
+// AITaskRunner runs the bot for a proactive AI part. The bot engine delivers the generated
+// reply itself through the channel transport, with humanized typing.
+type AITaskRunner func(ctx context.Context, post wsCommonDomain.ScheduledPost, task wsCommonDomain.ScheduledAITask) error
+
+// sendPayload delivers the parts of a post in order. A failing optional part is recorded and
+// skipped; a failing required part aborts the remaining ones.
+func sendPayload(ctx context.Context, adapter channel.ChannelAdapter, runAI AITaskRunner, post wsCommonDomain.ScheduledPost) payloadResult {
+	var res payloadResult
+	parts := post.Parts()
+	for i, part := range parts {
+		// The lease was lost (or the node is stopping): the remaining parts belong to whoever retries
+		if ctx.Err() != nil {
+			res.failures = append(res.failures, fmt.Sprintf("part %d/%d (%s): %v", i+1, len(parts), part.Label(), ctx.Err()))
+			res.aborted = true
+			return res
+		}
+		var err error
+		if part.Type == wsCommonDomain.PayloadPartAI {
+			err = runAIPart(ctx, runAI, post, part)
+		} else {
+			err = sendPart(ctx, adapter, post.TargetID, part)
+		}
+		if err != nil {
+			res.failures = append(res.failures, fmt.Sprintf("part %d/%d (%s): %v", i+1, len(parts), part.Label(), err))
+			if !part.Optional {
+				res.aborted = true
+				return res
+			}
+			continue
+		}
+		res.sent++
+	}
+	return res
+}
+
+func runAIPart(ctx context.Context, runAI AITaskRunner, post wsCommonDomain.ScheduledPost, part wsCommonDomain.ScheduledPayloadPart) error {
+	if runAI == nil {
+		return fmt.Errorf("bot engine not available on this node")
+	}
+	if strings.HasSuffix(post.TargetID, "@newsletter") {
+		return fmt.Errorf("ai tasks cannot target newsletters")
+	}
+	return runAI(ctx, post, *part.AI)
+}
+
+func sendPart(ctx context.Context, adapter channel.ChannelAdapter, target string, part wsCommonDomain.ScheduledPayloadPart) error {
+	isNewsletter := strings.HasSuffix(target, "@newsletter")
+
+	var err error
+	switch part.Type {
+	case wsCommonDomain.PayloadPartText:
+		if isNewsletter {
+			_, err = adapter.SendNewsletterMessage(ctx, target, part.Text, "")
+		} else {
+			_, err = adapter.SendMessage(ctx, target, part.Text, "")
+		}
+	case wsCommonDomain.PayloadPartMedia:
+		err = sendMediaPart(ctx, adapter, target, isNewsletter, part)
+	case wsCommonDomain.PayloadPartPoll:
+		_, err = adapter.SendPoll(ctx, target, part.Poll.Question, part.Poll.Options, part.Poll.MaxSelections, "")
+	case wsCommonDomain.PayloadPartLocation:
+		_, err = adapter.SendLocation(ctx, target, part.Location.Latitude, part.Location.Longitude, part.Location.Address, "")
+	case wsCommonDomain.PayloadPartLink:
+		_, err = adapter.SendLink(ctx, target, part.Link.URL, part.Text, part.Link.Title, part.Link.Description, nil, "")
+	default:
+		err = fmt.Errorf("unknown part type %q", part.Type)
+	}
+	return err
+}
+
+func sendMediaPart(ctx context.Context, adapter channel.ChannelAdapter, target string, isNewsletter bool, part wsCommonDomain.ScheduledPayloadPart) error {
+	mediaType := part.MediaType
+	if mediaType == "" {
+		mediaType = MediaTypeFromPath(part.MediaPath)
+	}
+
+	// Los canales solo admiten imágenes por ahora
+	if isNewsletter {
+		if mediaType != wsCommonDomain.MediaTypeImage {
+			return fmt.Errorf("newsletters only support image media, got %s", mediaType)
+		}
+		path, err := resolveMediaPath(part.MediaPath)
+		if err != nil {
+			return err
+		}
+		_, err = adapter.SendNewsletterMessage(ctx, target, part.Text, path)
+		return err
+	}
+
+	path, err := resolveMediaPath(part.MediaPath)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read media file: %w", err)
+	}
+	fileName := part.FileName
+	if fileName == "" {
+		fileName = filepath.Base(part.MediaPath)
+	}
+	mimeType := part.MimeType
+	if mimeType == "" {
+		mimeType = mime.TypeByExtension(filepath.Ext(part.MediaPath))
+	}
+	if mimeType == "" {
+		mimeType = http.DetectContentType(data)
+	}
+
+	_, err = adapter.SendMedia(ctx, target, wsCommonDomain.MediaUpload{
+		Data:     data,
+		FileName: fileName,
+		MimeType: mimeType,
+		Caption:  part.Text,
+		Type:     mediaType,
+	}, "")
+	return err
+}
+
+// MediaTypeFromPath guesses the WhatsApp media kind from the file extension.
+func MediaTypeFromPath(path string) wsCommonDomain.MediaType {
+	mimeType := mime.TypeByExtension(strings.ToLower(filepath.Ext(path)))
+	switch {
+	case strings.HasPrefix(mimeType, "image/"):
+		return wsCommonDomain.MediaTypeImage
+	case strings.HasPrefix(mimeType, "video/"):
+		return wsCommonDomain.MediaTypeVideo
+	case strings.HasPrefix(mimeType, "audio/"):
+		return wsCommonDomain.MediaTypeAudio
+	default:
+		return wsCommonDomain.MediaTypeDocument
+	}
+}
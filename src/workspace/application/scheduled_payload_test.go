@@ -0,0 +1,169 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	coreconfig "github.com/AzielCF/az-wap/core/config"
+	"github.com/AzielCF/az-wap/workspace/domain/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// payloadAdapter records sent parts and fails polls
+type payloadAdapter struct {
+	MockAdapter
+	sent []string
+}
+
+func (a *payloadAdapter) SendMessage(ctx context.Context, chatID, text, quote string) (common.SendResponse, error) {
+	a.sent = append(a.sent, "text:"+text)
+	return common.SendResponse{}, nil
+}
+
+func (a *payloadAdapter) SendPoll(ctx context.Context, chatID, q string, opt []string, max int, quote string) (common.SendResponse, error) {
+	return common.SendResponse{}, errors.New("polls disabled")
+}
+
+func (a *payloadAdapter) SendLocation(ctx context.Context, chatID string, lat, long float64, addr, quote string) (common.SendResponse, error) {
+	a.sent = append(a.sent, "location:"+addr)
+	return common.SendResponse{}, nil
+}
+
+func TestSendPayload_OptionalPartFailureContinues(t *testing.T) {
+	adapter := &payloadAdapter{}
+	poll := &common.ScheduledPoll{Question: "¿Vienes?", Options: []string{"Sí", "No"}}
+	post := common.ScheduledPost{TargetID: "123@s.whatsapp.net", Payload: &common.ScheduledPayload{Parts: []common.ScheduledPayloadPart{
+		{Type: common.PayloadPartText, Text: "Hola"},
+		{Type: common.PayloadPartPoll, Poll: poll, Optional: true},
+		{Type: common.PayloadPartLocation, Location: &common.ScheduledLocation{Latitude: 1, Longitude: 2, Address: "Oficina"}},
+	}}}
+
+	res := sendPayload(context.Background(), adapter, nil, post)
+
+	assert.Equal(t, []string{"text:Hola", "location:Oficina"}, adapter.sent)
+	assert.Equal(t, common.ScheduledPostExecutionPartial, res.status())
+	assert.Contains(t, res.errorText(), "part 2/3 (poll)")
+}
+
+func TestSendPayload_RequiredPartFailureAborts(t *testing.T) {
+	adapter := &payloadAdapter{}
+	poll := &common.ScheduledPoll{Question: "¿Vienes?", Options: []string{"Sí", "No"}}
+	post := common.ScheduledPost{TargetID: "123@s.whatsapp.net", Payload: &common.ScheduledPayload{Parts: []common.ScheduledPayloadPart{
+		{Type: common.PayloadPartPoll, Poll: poll},
+		{Type: common.PayloadPartText, Text: "Hola"},
+	}}}
+
+	res := sendPayload(context.Background(), adapter, nil, post)
+
+	assert.Empty(t, adapter.sent)
+	assert.True(t, res.aborted)
+	assert.Equal(t, common.ScheduledPostExecutionFailed, res.status())
+}
+
+func TestScheduledPayload_Validate(t *testing.T) {
+	valid := common.ScheduledPayload{Parts: []common.ScheduledPayloadPart{
+		{Type: common.PayloadPartMedia, MediaPath: "/tmp/a.jpg", MediaType: common.MediaTypeImage},
+		{Type: common.PayloadPartLink, Link: &common.ScheduledLink{URL: "https://example.com"}},
+	}}
+	assert.NoError(t, valid.Validate())
+
+	invalid := []common.ScheduledPayload{
+		{},
+		{Parts: []common.ScheduledPayloadPart{{Type: common.PayloadPartPoll, Poll: &common.ScheduledPoll{Question: "?", Options: []string{"uno"}}}}},
+		{Parts: []common.ScheduledPayloadPart{{Type: common.PayloadPartLocation, Location: &common.ScheduledLocation{Latitude: 120}}}},
+		{Parts: []common.ScheduledPayloadPart{{Type: "sticker"}}},
+	}
+	for _, p := range invalid {
+		assert.Error(t, p.Validate())
+	}
+}
+
+func TestSendPayload_AIPartUsesRunner(t *testing.T) {
+	adapter := &payloadAdapter{}
+	var got []string
+	runAI := func(ctx context.Context, post common.ScheduledPost, task common.ScheduledAITask) error {
+		got = append(got, task.Instruction)
+		return nil
+	}
+	post := common.ScheduledPost{TargetID: "123@s.whatsapp.net", Payload: &common.ScheduledPayload{Parts: []common.ScheduledPayloadPart{
+		{Type: common.PayloadPartText, Text: "Buenos días"},
+		{Type: common.PayloadPartAI, AI: &common.ScheduledAITask{Instruction: "Resume mis recordatorios"}},
+	}}}
+
+	res := sendPayload(context.Background(), adapter, runAI, post)
+	assert.Equal(t, []string{"text:Buenos días"}, adapter.sent)
+	assert.Equal(t, []string{"Resume mis recordatorios"}, got)
+	assert.Equal(t, common.ScheduledPostExecutionSent, res.status())
+
+	// Sin motor de bots la parte falla en lugar de enviarse vacía
+	res = sendPayload(context.Background(), adapter, nil, post)
+	assert.True(t, res.aborted)
+	assert.Contains(t, res.errorText(), "part 2/2 (ai)")
+}
+
+func TestSendPayload_CancelledContextStopsRemainingParts(t *testing.T) {
+	adapter := &payloadAdapter{}
+	post := common.ScheduledPost{TargetID: "123@s.whatsapp.net", Payload: &common.ScheduledPayload{Parts: []common.ScheduledPayloadPart{
+		{Type: common.PayloadPartText, Text: "Hola"},
+		{Type: common.PayloadPartText, Text: "Adiós"},
+	}}}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	res := sendPayload(ctx, adapter, nil, post)
+
+	assert.Empty(t, adapter.sent)
+	assert.True(t, res.aborted)
+}
+
+func TestRetainScheduledMedia_RejectsPathsOutsideMediaFolders(t *testing.T) {
+	base := t.TempDir()
+	prev := coreconfig.Global
+	coreconfig.Global = &coreconfig.Config{Paths: coreconfig.PathsConfig{
+		Statics:   filepath.Join(base, "statics"),
+		SendItems: filepath.Join(base, "statics", "senditems"),
+		Storages:  filepath.Join(base, "storages"),
+	}}
+	t.Cleanup(func() { coreconfig.Global = prev })
+
+	sendItems := coreconfig.Global.Paths.SendItems
+	require.NoError(t, os.MkdirAll(sendItems, 0700))
+	require.NoError(t, os.MkdirAll(coreconfig.Global.Paths.Storages, 0700))
+	upload := filepath.Join(sendItems, "photo.jpg")
+	require.NoError(t, os.WriteFile(upload, []byte("img"), 0600))
+	secret := filepath.Join(coreconfig.Global.Paths.Storages, "whatsapp.db")
+	require.NoError(t, os.WriteFile(secret, []byte("keys"), 0600))
+	link := filepath.Join(sendItems, "link.jpg")
+	require.NoError(t, os.Symlink(secret, link))
+
+	post := common.ScheduledPost{ID: "ok", MediaPath: upload}
+	require.NoError(t, RetainScheduledMedia(&post))
+	assert.FileExists(t, post.MediaPath)
+	assert.NotEqual(t, upload, post.MediaPath)
+
+	for _, path := range []string{
+		filepath.Join(sendItems, "..", "..", "storages", "whatsapp.db"),
+		secret,
+		"/etc/passwd",
+		link,
+	} {
+		post := common.ScheduledPost{ID: "bad", MediaPath: path}
+		err := RetainScheduledMedia(&post)
+		assert.ErrorIs(t, err, ErrMediaPathNotAllowed, path)
+
+		post = common.ScheduledPost{ID: "bad", Payload: &common.ScheduledPayload{Parts: []common.ScheduledPayloadPart{
+			{Type: common.PayloadPartMedia, MediaPath: path},
+		}}}
+		assert.ErrorIs(t, RetainScheduledMedia(&post), ErrMediaPathNotAllowed, path)
+	}
+	assert.NoDirExists(t, ScheduledMediaDir("bad"))
+
+	// Media already stored on disk is checked again before sending
+	adapter := &payloadAdapter{}
+	err := sendMediaPart(context.Background(), adapter, "123@s.whatsapp.net", false, common.ScheduledPayloadPart{Type: common.PayloadPartMedia, MediaPath: secret})
+	assert.ErrorIs(t, err, ErrMediaPathNotAllowed)
+}
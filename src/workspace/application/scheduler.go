@@ -2,46 +2,88 @@ package application
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"time"
 
 	"github.com/AzielCF/az-wap/core/infrastructure/valkey"
+	"github.com/AzielCF/az-wap/core/pkg/msgworker"
 	wsCommonDomain "github.com/AzielCF/az-wap/workspace/domain/common"
 	workspaceDomain "github.com/AzielCF/az-wap/workspace/domain/workspace"
+	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
 	valkeylib "github.com/valkey-io/valkey-go"
 )
 
-// TaskScheduler manages the lifecycle of scheduled messages using
-// a combination of SQLite persistence and Valkey's reactive capabilities.
+const (
+	schedulerLease        = 2 * time.Minute  // How long a claimed task stays reserved for this node
+	schedulerLeaseRenewal = 30 * time.Second // Renewal period while a task is being delivered
+	schedulerClaimBatch   = 20               // Max tasks claimed from the DB per pass
+	schedulerPollInterval = 30 * time.Second // Without Valkey nobody signals new tasks, so cap the sleep
+	adapterRetryDelay     = 30 * time.Second // Back-off when the channel is not running on this node
+)
+
+// JobScheduledPost is the worker pool job that sends a claimed post.
+// Only the post ID travels in the queue; the handler reloads the post under its lease.
+const JobScheduledPost = "workspace.scheduled_post"
+
+type scheduledPostJob struct {
+	PostID string `json:"post_id"`
+}
+
+// TaskScheduler manages the lifecycle of scheduled messages.
+// The database is the source of truth: tasks are claimed with lease columns so that
+// only one node sends each post. Valkey, when available, only accelerates the loop
+// (ZSET of upcoming tasks + Pub/Sub wake-ups).
 type TaskScheduler struct {
-	repo         workspaceDomain.IWorkspaceRepository
+	repo         workspaceDomain.ISchedulerRepository
 	valkeyClient *valkey.Client
 	channels     *ChannelService
 	acquireLock  func(key string, expiration time.Duration) bool
 	wakeUpChan   chan struct{}
+	owner        string       // Lease owner (server ID)
+	runAI        AITaskRunner // Runs proactive AI parts (nil = they fail)
 }
 
 // NewTaskScheduler creates a new instance of the scheduler.
 func NewTaskScheduler(
-	repo workspaceDomain.IWorkspaceRepository,
+	repo workspaceDomain.ISchedulerRepository,
 	vk *valkey.Client,
 	channels *ChannelService,
 	lockFunc func(key string, expiration time.Duration) bool,
+	owner string,
 ) *TaskScheduler {
+	if owner == "" {
+		owner = uuid.NewString()
+	}
 	return &TaskScheduler{
 		repo:         repo,
 		valkeyClient: vk,
 		channels:     channels,
 		acquireLock:  lockFunc,
 		wakeUpChan:   make(chan struct{}, 1),
+		owner:        owner,
+	}
+}
+
+// SetAITaskRunner wires the bot engine used for proactive AI tasks.
+func (s *TaskScheduler) SetAITaskRunner(runner AITaskRunner) {
+	s.runAI = runner
+}
+
+// Wake nudges the worker to re-check due tasks (e.g. right after scheduling one).
+func (s *TaskScheduler) Wake() {
+	select {
+	case s.wakeUpChan <- struct{}{}:
+	default:
 	}
 }
 
 // StartLoop initiates the reactive background worker.
 func (s *TaskScheduler) StartLoop(ctx context.Context) {
 	if s.valkeyClient == nil {
-		logrus.Warn("[SCHEDULER] Valkey disabled. Background scheduler will not run.")
+		logrus.Infof("[SCHEDULER] Valkey disabled. Using database leasing (poll every %s) as owner %s", schedulerPollInterval, s.owner)
+		go s.runWorker(ctx)
 		return
 	}
 
@@ -73,17 +115,22 @@ func (s *TaskScheduler) runWorker(ctx context.Context) {
 	safetyTicker := time.NewTicker(5 * time.Minute)
 	defer safetyTicker.Stop()
 
+	maxSleep := 1 * time.Hour
+	if s.valkeyClient == nil {
+		maxSleep = schedulerPollInterval
+	}
+
 	for {
 		nextTaskAt := s.ExecTasks(ctx)
 
-		sleepDuration := 1 * time.Hour
+		sleepDuration := maxSleep
 		if !nextTaskAt.IsZero() {
 			sleepDuration = time.Until(nextTaskAt)
 			if sleepDuration < 0 {
 				sleepDuration = 1 * time.Second
 			}
-			if sleepDuration > 1*time.Hour {
-				sleepDuration = 1 * time.Hour
+			if sleepDuration > maxSleep {
+				sleepDuration = maxSleep
 			}
 		}
 
@@ -107,6 +154,7 @@ func (s *TaskScheduler) runWorker(ctx context.Context) {
 }
 
 // PromoteTasks looks 24h ahead in SQLite and populates Valkey ZSET.
+// Posts stuck in processing with an expired lease are promoted again so they are not lost.
 func (s *TaskScheduler) PromoteTasks(ctx context.Context) error {
 	if s.valkeyClient == nil {
 		return nil
@@ -141,7 +189,12 @@ func (s *TaskScheduler) PromoteTasks(ctx context.Context) error {
 			_ = s.valkeyClient.Inner().Do(ctx, s.valkeyClient.Inner().B().Hincrby().Key(statsKey).Field("tasks_db").Increment(-1).Build())
 		}
 
-		if post.Status == wsCommonDomain.ScheduledPostStatusEnqueued {
+		if post.Status == wsCommonDomain.ScheduledPostStatusProcessing {
+			// Lease vencido: vuelve al ZSET y ClaimScheduledPost lo reclama de nuevo
+			logrus.Warnf("[SCHEDULER] Task %s was left processing by %s with an expired lease, re-enqueueing", post.ID, post.LeaseOwner)
+		}
+
+		if post.Status == wsCommonDomain.ScheduledPostStatusEnqueued || post.Status == wsCommonDomain.ScheduledPostStatusProcessing {
 			score := float64(post.ScheduledAt.Unix())
 			_ = s.valkeyClient.Inner().Do(ctx, s.valkeyClient.Inner().B().Zadd().Key(key).ScoreMember().ScoreMember(score, post.ID).Build())
 		}
@@ -151,43 +204,47 @@ func (s *TaskScheduler) PromoteTasks(ctx context.Context) error {
 
 // ExecTasks executes matured tasks and returns the time for the NEXT task.
 func (s *TaskScheduler) ExecTasks(ctx context.Context) time.Time {
+	if s.valkeyClient == nil {
+		return s.execLeasedTasks(ctx)
+	}
+
 	key := s.valkeyClient.Key("scheduler:tasks")
 	now := float64(time.Now().Unix())
 
 	res := s.valkeyClient.Inner().Do(ctx, s.valkeyClient.Inner().B().Zrangebyscore().Key(key).Min("-inf").Max(fmt.Sprintf("%f", now)).Build())
 	taskIDs, err := res.AsStrSlice()
 
+	// Due posts whose channel runs on another node stay in the ZSET for their owner; this node only
+	// looks at them again after a back-off instead of spinning on them
+	var foreignRetry time.Time
 	if err == nil && len(taskIDs) > 0 {
 		for _, id := range taskIDs {
-			if !s.acquireLock("lock:exec:"+id, 30*time.Second) {
-				continue
-			}
-
-			post, err := s.repo.GetScheduledPost(ctx, id)
+			stored, err := s.repo.GetScheduledPost(ctx, id)
 			if err != nil {
 				_ = s.valkeyClient.Inner().Do(ctx, s.valkeyClient.Inner().B().Zrem().Key(key).Member(id).Build())
 				continue
 			}
+			if _, local := s.channels.GetAdapter(stored.ChannelID); !local {
+				foreignRetry = time.Now().Add(adapterRetryDelay)
+				continue
+			}
+			if !s.acquireLock("lock:exec:"+id, 30*time.Second) {
+				continue
+			}
 
-			adapter, ok := s.channels.GetAdapter(post.ChannelID)
-			if !ok {
-				logrus.Errorf("[SCHEDULER] Adapter %s not found for task %s. Removing from memory to prevent loop.", post.ChannelID, id)
+			// The DB lease is the real guard: only one node can claim the post
+			claimedAt := time.Now().UTC()
+			post, ok, err := s.repo.ClaimScheduledPost(ctx, id, s.owner, claimedAt, claimedAt.Add(schedulerLease))
+			if err != nil || !ok {
 				_ = s.valkeyClient.Inner().Do(ctx, s.valkeyClient.Inner().B().Zrem().Key(key).Member(id).Build())
 				continue
 			}
 
-			logrus.Infof("[SCHEDULER] Executing task %s -> %s", id, post.TargetID)
-
-			_, err = adapter.SendMessage(ctx, post.TargetID, post.Text, "")
-			if err != nil {
-				logrus.WithError(err).Errorf("[SCHEDULER] Task %s failed", id)
-				post.Status = wsCommonDomain.ScheduledPostStatusFailed
-				post.Error = err.Error()
-				_ = s.repo.UpdateScheduledPost(ctx, post)
-			} else {
-				logrus.Infof("[SCHEDULER] Success! Cleaning up task %s.", id)
-				_ = s.repo.DeleteScheduledPost(ctx, id)
-				_ = s.valkeyClient.Inner().Do(ctx, s.valkeyClient.Inner().B().Zrem().Key(key).Member(id).Build())
+			// Remove before dispatching: a recurring post re-enqueues itself under the same ID,
+			// and a post that cannot be dispatched is put back by release
+			_ = s.valkeyClient.Inner().Do(ctx, s.valkeyClient.Inner().B().Zrem().Key(key).Member(id).Build())
+			if !s.dispatch(ctx, post) {
+				logrus.Warnf("[SCHEDULER] Could not dispatch task %s on channel %s. Retrying in %s.", id, post.ChannelID, adapterRetryDelay)
 			}
 		}
 	}
@@ -196,15 +253,280 @@ func (s *TaskScheduler) ExecTasks(ctx context.Context) time.Time {
 	cmdPeek := s.valkeyClient.Inner().B().Zrangebyscore().Key(key).Min("-inf").Max("+inf").Limit(0, 1).Build()
 	peekRes, _ := s.valkeyClient.Inner().Do(ctx, cmdPeek).AsStrSlice()
 
+	next := time.Time{}
 	if len(peekRes) > 0 && peekRes[0] != "" {
 		memberID := peekRes[0]
 		score, err := s.valkeyClient.Inner().Do(ctx, s.valkeyClient.Inner().B().Zscore().Key(key).Member(memberID).Build()).AsFloat64()
 		if err == nil {
-			return time.Unix(int64(score), 0)
+			next = time.Unix(int64(score), 0)
+		}
+	}
+	if !foreignRetry.IsZero() && (next.IsZero() || next.Before(foreignRetry)) {
+		next = foreignRetry
+	}
+	return next
+}
+
+// execLeasedTasks claims due posts straight from the database (no Valkey).
+func (s *TaskScheduler) execLeasedTasks(ctx context.Context) time.Time {
+	now := time.Now().UTC()
+	posts, err := s.repo.ClaimDueScheduledPosts(ctx, s.owner, now, now.Add(schedulerLease), schedulerClaimBatch)
+	if err != nil {
+		logrus.WithError(err).Error("[SCHEDULER] Failed to claim due tasks")
+	}
+
+	for _, post := range posts {
+		if !s.dispatch(ctx, post) {
+			logrus.Warnf("[SCHEDULER] Could not dispatch task %s on channel %s. Retrying in %s.", post.ID, post.ChannelID, adapterRetryDelay)
+		}
+	}
+
+	// A full batch means there may be more due tasks waiting
+	if len(posts) == schedulerClaimBatch {
+		return time.Now()
+	}
+
+	next, err := s.repo.NextScheduledPostAt(ctx, time.Now().UTC())
+	if err != nil {
+		logrus.WithError(err).Error("[SCHEDULER] Failed to peek next task")
+		return time.Time{}
+	}
+	return next
+}
+
+// dispatch hands a claimed post to the worker pool, pinned to this node (it needs the local adapter).
+// It returns false when the channel adapter is not running here or the pool rejected the job; the lease
+// is then released with a short back-off so the post can be retried (here or on another node).
+func (s *TaskScheduler) dispatch(ctx context.Context, post wsCommonDomain.ScheduledPost) bool {
+	if _, ok := s.channels.GetAdapter(post.ChannelID); !ok {
+		s.release(ctx, post)
+		return false
+	}
+
+	tenant := ""
+	if ch, err := s.repo.GetChannel(ctx, post.ChannelID); err == nil {
+		tenant = ch.WorkspaceID
+	}
+
+	postID := post.ID
+	job, err := msgworker.NewJob(JobScheduledPost, post.ChannelID, post.TargetID, scheduledPostJob{PostID: postID})
+	if err != nil {
+		s.release(ctx, post)
+		return false
+	}
+	job.Pinned, job.Tenant, job.Lane = true, tenant, msgworker.LaneScheduled
+	job.Handler = func(workerCtx context.Context) error {
+		s.deliver(workerCtx, postID)
+		return nil
+	}
+	if !msgworker.GetGlobalPool().TryDispatch(job) {
+		s.release(ctx, post)
+		return false
+	}
+	return true
+}
+
+// RegisterJobHandlers registers the scheduled-post job so it can resume after a restart
+func (s *TaskScheduler) RegisterJobHandlers(pool *msgworker.MessageWorkerPool) {
+	pool.RegisterHandler(JobScheduledPost, func(ctx context.Context, job msgworker.Job) error {
+		var p scheduledPostJob
+		if err := json.Unmarshal(job.Payload, &p); err != nil {
+			return fmt.Errorf("invalid %s payload: %w", JobScheduledPost, err)
+		}
+		s.deliver(ctx, p.PostID)
+		return nil
+	})
+}
+
+// release returns a claimed post to the pending pool with a short back-off. With Valkey the post was
+// already removed from the ZSET, so it goes back in at retryAt; otherwise the promoter would only
+// find it on its slow safety tick.
+func (s *TaskScheduler) release(ctx context.Context, post wsCommonDomain.ScheduledPost) {
+	retryAt := time.Now().UTC().Add(adapterRetryDelay)
+	post.Status = wsCommonDomain.ScheduledPostStatusPending
+	if s.valkeyClient != nil {
+		post.Status = wsCommonDomain.ScheduledPostStatusEnqueued
+	}
+	post.LeaseOwner = ""
+	post.LeaseUntil = &retryAt
+	post.UpdatedAt = time.Now().UTC()
+	if err := s.repo.UpdateScheduledPost(ctx, post); err != nil {
+		logrus.WithError(err).Warnf("[SCHEDULER] Failed to release task %s", post.ID)
+		return
+	}
+	if s.valkeyClient != nil {
+		key := s.valkeyClient.Key("scheduler:tasks")
+		_ = s.valkeyClient.Inner().Do(ctx, s.valkeyClient.Inner().B().Zadd().Key(key).ScoreMember().ScoreMember(float64(retryAt.Unix()), post.ID).Build())
+	}
+}
+
+// deliver sends a post from the worker pool and settles its lease.
+// The job may have waited in the queue (or survived a restart), so the lease is renewed first:
+// if another node already reclaimed the post, this node drops it instead of sending it twice.
+func (s *TaskScheduler) deliver(ctx context.Context, postID string) {
+	post, ok, err := s.repo.RenewScheduledPostLease(ctx, postID, s.owner, time.Now().UTC().Add(schedulerLease))
+	if err != nil {
+		logrus.WithError(err).Errorf("[SCHEDULER] Failed to renew lease of task %s", postID)
+		return
+	}
+	if !ok {
+		logrus.Debugf("[SCHEDULER] Task %s is no longer leased by %s, skipping", postID, s.owner)
+		return
+	}
+
+	adapter, ok := s.channels.GetAdapter(post.ChannelID)
+	if !ok {
+		logrus.Warnf("[SCHEDULER] Adapter %s not found for task %s. Retrying in %s.", post.ChannelID, post.ID, adapterRetryDelay)
+		s.release(ctx, post)
+		return
+	}
+
+	logrus.Infof("[SCHEDULER] Executing task %s -> %s", post.ID, post.TargetID)
+
+	sendCtx, stillHeld := s.holdLease(ctx, post.ID)
+	result := sendPayload(sendCtx, adapter, s.runAI, post)
+	if !stillHeld() {
+		// Another node reclaimed the post: it settles it, this node must not touch it anymore
+		logrus.Warnf("[SCHEDULER] Lost lease of task %s while delivering it, leaving it to its new owner", post.ID)
+		return
+	}
+	errMsg := result.errorText()
+	if result.aborted {
+		logrus.Errorf("[SCHEDULER] Task %s failed: %s", post.ID, errMsg)
+	} else if errMsg != "" {
+		logrus.Warnf("[SCHEDULER] Task %s partially delivered: %s", post.ID, errMsg)
+	}
+	s.recordExecution(ctx, post, result.status(), errMsg)
+
+	// Recurring posts re-arm to their next occurrence, even after a failed one
+	if post.IsRecurring() {
+		if !result.aborted {
+			post.ExecutionCount++
+		}
+		post.Error = errMsg
+		armed, rerr := post.Rearm(time.Now().UTC())
+		if rerr != nil {
+			logrus.WithError(rerr).Errorf("[SCHEDULER] Invalid recurrence for task %s, ending series", post.ID)
 		}
+		if armed {
+			logrus.Infof("[SCHEDULER] Task %s re-armed for %s", post.ID, post.ScheduledAt.Format(time.RFC3339))
+			if uerr := s.repo.UpdateScheduledPost(ctx, post); uerr != nil {
+				logrus.WithError(uerr).Errorf("[SCHEDULER] Failed to re-arm task %s", post.ID)
+				return
+			}
+			s.enqueue(ctx, post)
+			return
+		}
+		logrus.Infof("[SCHEDULER] Recurring task %s reached its end condition", post.ID)
+		_ = s.repo.DeleteScheduledPost(ctx, post.ID)
+		ReleaseScheduledMedia(post.ID)
+		return
+	}
+
+	if result.aborted {
+		// Failed posts stay visible with their media so they can be inspected and rescheduled
+		post.Status = wsCommonDomain.ScheduledPostStatusFailed
+		post.Error = errMsg
+		post.LeaseOwner = ""
+		post.LeaseUntil = nil
+		post.UpdatedAt = time.Now().UTC()
+		_ = s.repo.UpdateScheduledPost(ctx, post)
+		return
 	}
 
-	return time.Time{}
+	logrus.Infof("[SCHEDULER] Success! Cleaning up task %s.", post.ID)
+	_ = s.repo.DeleteScheduledPost(ctx, post.ID)
+	ReleaseScheduledMedia(post.ID)
+}
+
+// holdLease renews the lease of a post on a ticker while it is delivered: AI parts and media uploads
+// can take longer than schedulerLease. When the lease is lost (another node reclaimed the post, or it
+// could not be renewed before expiring) the returned context is cancelled so no further part goes out.
+// The returned function stops the renewal and reports whether the lease was held throughout.
+func (s *TaskScheduler) holdLease(ctx context.Context, postID string) (context.Context, func() bool) {
+	sendCtx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	exited := make(chan struct{})
+	lost := false
+
+	go func() {
+		defer close(exited)
+		ticker := time.NewTicker(schedulerLeaseRenewal)
+		defer ticker.Stop()
+
+		renewedAt := time.Now()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+			_, ok, err := s.repo.RenewScheduledPostLease(ctx, postID, s.owner, time.Now().UTC().Add(schedulerLease))
+			switch {
+			case err != nil && time.Since(renewedAt) < schedulerLease-schedulerLeaseRenewal:
+				logrus.WithError(err).Warnf("[SCHEDULER] Failed to renew lease of task %s", postID)
+			case err != nil || !ok:
+				lost = true
+				cancel()
+				return
+			default:
+				renewedAt = time.Now()
+			}
+		}
+	}()
+
+	return sendCtx, func() bool {
+		close(done)
+		<-exited
+		cancel()
+		return !lost
+	}
+}
+
+// recordExecution appends a history row for the occurrence that was just handled.
+func (s *TaskScheduler) recordExecution(ctx context.Context, post wsCommonDomain.ScheduledPost, status wsCommonDomain.ScheduledPostExecutionStatus, errMsg string) {
+	exec := wsCommonDomain.ScheduledPostExecution{
+		ID:           uuid.NewString(),
+		PostID:       post.ID,
+		ChannelID:    post.ChannelID,
+		OccurrenceAt: post.Nominal(),
+		ExecutedAt:   time.Now().UTC(),
+		Status:       status,
+		Error:        errMsg,
+	}
+	if err := s.repo.CreateScheduledPostExecution(ctx, exec); err != nil {
+		logrus.WithError(err).Warnf("[SCHEDULER] Failed to record execution of task %s", post.ID)
+	}
+}
+
+// enqueue pushes a re-armed post into the Valkey ZSET when it falls inside the promotion window.
+// Without Valkey the DB poll picks it up on its own.
+func (s *TaskScheduler) enqueue(ctx context.Context, post wsCommonDomain.ScheduledPost) {
+	if s.valkeyClient == nil || post.ScheduledAt.After(time.Now().Add(24*time.Hour)) {
+		return
+	}
+	post.Status = wsCommonDomain.ScheduledPostStatusEnqueued
+	if err := s.repo.UpdateScheduledPost(ctx, post); err != nil {
+		return
+	}
+	key := s.valkeyClient.Key("scheduler:tasks")
+	score := float64(post.ScheduledAt.Unix())
+	_ = s.valkeyClient.Inner().Do(ctx, s.valkeyClient.Inner().B().Zadd().Key(key).ScoreMember().ScoreMember(score, post.ID).Build())
+}
+
+// Schedule stores a post created by the workspace itself (e.g. off-hours deferred replies)
+// and arms it like the newsletter service does for user-created posts.
+func (s *TaskScheduler) Schedule(ctx context.Context, post wsCommonDomain.ScheduledPost) error {
+	if err := s.repo.CreateScheduledPost(ctx, post); err != nil {
+		return err
+	}
+	s.enqueue(ctx, post)
+	if s.valkeyClient != nil {
+		signalKey := s.valkeyClient.Key("scheduler:signal")
+		_ = s.valkeyClient.Inner().Do(ctx, s.valkeyClient.Inner().B().Publish().Channel(signalKey).Message("new_task").Build())
+	}
+	s.Wake()
+	return nil
 }
 
 // CountActiveTasks returns the number of tasks currently in the memory queue (Valkey).
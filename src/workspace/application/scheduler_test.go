@@ -0,0 +1,141 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/AzielCF/az-wap/core/infrastructure/valkey"
+	"github.com/AzielCF/az-wap/workspace/domain/channel"
+	"github.com/AzielCF/az-wap/workspace/domain/common"
+	"github.com/AzielCF/az-wap/workspace/domain/message"
+	workspaceDomain "github.com/AzielCF/az-wap/workspace/domain/workspace"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sharedPostsRepo plays the database both nodes see, with the same lease rules as the gorm repository
+type sharedPostsRepo struct {
+	workspaceDomain.ISchedulerRepository
+	mu    sync.Mutex
+	posts map[string]common.ScheduledPost
+}
+
+func (r *sharedPostsRepo) get(id string) (common.ScheduledPost, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	p, ok := r.posts[id]
+	return p, ok
+}
+
+func (r *sharedPostsRepo) GetScheduledPost(ctx context.Context, id string) (common.ScheduledPost, error) {
+	if p, ok := r.get(id); ok {
+		return p, nil
+	}
+	return common.ScheduledPost{}, fmt.Errorf("not found")
+}
+
+func (r *sharedPostsRepo) GetChannel(ctx context.Context, channelID string) (channel.Channel, error) {
+	return channel.Channel{ID: channelID, WorkspaceID: "ws1"}, nil
+}
+
+func (r *sharedPostsRepo) ClaimScheduledPost(ctx context.Context, id, owner string, now, leaseUntil time.Time) (common.ScheduledPost, bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	p, ok := r.posts[id]
+	if !ok || p.ScheduledAt.After(now) || (p.LeaseUntil != nil && !p.LeaseUntil.Before(now)) {
+		return common.ScheduledPost{}, false, nil
+	}
+	p.Status, p.LeaseOwner, p.LeaseUntil = common.ScheduledPostStatusProcessing, owner, &leaseUntil
+	r.posts[id] = p
+	return p, true, nil
+}
+
+func (r *sharedPostsRepo) RenewScheduledPostLease(ctx context.Context, id, owner string, leaseUntil time.Time) (common.ScheduledPost, bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	p, ok := r.posts[id]
+	if !ok || p.LeaseOwner != owner {
+		return common.ScheduledPost{}, false, nil
+	}
+	p.LeaseUntil = &leaseUntil
+	r.posts[id] = p
+	return p, true, nil
+}
+
+func (r *sharedPostsRepo) UpdateScheduledPost(ctx context.Context, post common.ScheduledPost) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.posts[post.ID] = post
+	return nil
+}
+
+func (r *sharedPostsRepo) DeleteScheduledPost(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.posts, id)
+	return nil
+}
+
+func (r *sharedPostsRepo) CreateScheduledPostExecution(ctx context.Context, exec common.ScheduledPostExecution) error {
+	return nil
+}
+
+func TestExecTasks_OnlyTheChannelOwnerClaimsInValkeyMode(t *testing.T) {
+	vk, _ := valkey.NewClient(valkey.Config{Address: "localhost:6379", KeyPrefix: "test-" + uuid.NewString()[:8]})
+	if vk == nil {
+		t.Skip("Valkey not available at localhost:6379")
+	}
+	defer vk.Close()
+	ctx := context.Background()
+	key := vk.Key("scheduler:tasks")
+	defer vk.Inner().Do(ctx, vk.Inner().B().Del().Key(key).Build())
+
+	adapter := &payloadAdapter{}
+	post := common.ScheduledPost{
+		ID:          uuid.NewString(),
+		ChannelID:   adapter.ID(),
+		TargetID:    "123@s.whatsapp.net",
+		Text:        "Hola",
+		ScheduledAt: time.Now().Add(-time.Minute).UTC(),
+		Status:      common.ScheduledPostStatusEnqueued,
+	}
+	repo := &sharedPostsRepo{posts: map[string]common.ScheduledPost{post.ID: post}}
+	require.NoError(t, vk.Inner().Do(ctx, vk.Inner().B().Zadd().Key(key).ScoreMember().ScoreMember(float64(post.ScheduledAt.Unix()), post.ID).Build()).Error())
+
+	var locksMu sync.Mutex
+	locks := map[string]bool{}
+	lock := func(k string, _ time.Duration) bool {
+		locksMu.Lock()
+		defer locksMu.Unlock()
+		if locks[k] {
+			return false
+		}
+		locks[k] = true
+		return true
+	}
+
+	// Node B does not run the channel; node A does
+	nodeB := NewTaskScheduler(repo, vk, NewChannelService(repo, nil, nil), lock, "node-b")
+	channelsA := NewChannelService(repo, nil, nil)
+	channelsA.RegisterAdapter(adapter, func(channel.ChannelAdapter, message.IncomingMessage) {})
+	nodeA := NewTaskScheduler(repo, vk, channelsA, lock, "node-a")
+
+	next := nodeB.ExecTasks(ctx)
+	stored, _ := repo.get(post.ID)
+	assert.Empty(t, stored.LeaseOwner, "node B must not claim a post whose channel it does not run")
+	score, err := vk.Inner().Do(ctx, vk.Inner().B().Zscore().Key(key).Member(post.ID).Build()).AsFloat64()
+	require.NoError(t, err, "the post must stay queued for its owner")
+	assert.Equal(t, float64(post.ScheduledAt.Unix()), score)
+	assert.True(t, next.After(time.Now()), "node B must back off instead of spinning on the foreign post")
+
+	nodeA.ExecTasks(ctx)
+	assert.Eventually(t, func() bool {
+		_, exists := repo.get(post.ID)
+		return !exists
+	}, 5*time.Second, 20*time.Millisecond, "node A must deliver the post right away")
+	assert.Equal(t, []string{"text:Hola"}, adapter.sent)
+}
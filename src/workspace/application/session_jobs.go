@@ -0,0 +1,227 @@
+package application
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	botengineDomain "github.com/AzielCF/az-wap/botengine/domain"
+	coreconfig "github.com/AzielCF/az-wap/core/config"
+	"github.com/AzielCF/az-wap/core/pkg/msgworker"
+	"github.com/AzielCF/az-wap/workspace/domain/channel"
+	"github.com/AzielCF/az-wap/workspace/domain/message"
+	"github.com/sirupsen/logrus"
+)
+
+// Tipos de job de la sesión. Viajan por la cola durable fijados al nodo dueño del canal:
+// necesitan su adapter para responder, pero sobreviven a un reinicio del proceso.
+// Mientras el proceso vive se ejecuta la closure original; el payload solo se usa al retomarlos.
+// El payload lleva solo el ID del canal: su config (secretos, API keys) no debe acabar en Valkey.
+const (
+	JobSessionProcessFinal = "session.process_final"
+	JobSessionInactivity   = "session.inactivity_warning"
+)
+
+type processFinalJob struct {
+	Key       string                  `json:"key"`
+	ChannelID string                  `json:"channel_id"`
+	Msg       message.IncomingMessage `json:"msg"`
+	BotID     string                  `json:"bot_id"`
+}
+
+type inactivityJob struct {
+	Key       string `json:"key"`
+	ChannelID string `json:"channel_id"`
+}
+
+// RegisterJobHandlers registra en el pool los handlers de los jobs de sesión
+func (s *SessionOrchestrator) RegisterJobHandlers(pool *msgworker.MessageWorkerPool) {
+	pool.RegisterHandler(JobSessionProcessFinal, func(ctx context.Context, job msgworker.Job) error {
+		var p processFinalJob
+		if err := json.Unmarshal(job.Payload, &p); err != nil {
+			return fmt.Errorf("invalid %s payload: %w", job.Type, err)
+		}
+		ch, err := s.loadChannel(ctx, p.ChannelID)
+		if err != nil {
+			return err
+		}
+		p.Msg.Metadata = restoreJobMetadata(p.Msg.Metadata)
+		s.processFinal(ctx, p, ch, s.markReadFunc(p.ChannelID))
+		return nil
+	})
+	pool.RegisterHandler(JobSessionInactivity, func(ctx context.Context, job msgworker.Job) error {
+		var p inactivityJob
+		if err := json.Unmarshal(job.Payload, &p); err != nil {
+			return fmt.Errorf("invalid %s payload: %w", job.Type, err)
+		}
+		if s.OnInactivityWarn == nil {
+			return nil
+		}
+		ch, err := s.loadChannel(ctx, p.ChannelID)
+		if err != nil {
+			return err
+		}
+		s.OnInactivityWarn(p.Key, ch)
+		return nil
+	})
+}
+
+// loadChannel recarga el canal de un job retomado de la cola
+func (s *SessionOrchestrator) loadChannel(ctx context.Context, channelID string) (channel.Channel, error) {
+	if s.GetChannel == nil {
+		return channel.Channel{}, fmt.Errorf("no channel loader to resume jobs of channel %s", channelID)
+	}
+	ch, err := s.GetChannel(ctx, channelID)
+	if err != nil {
+		return channel.Channel{}, fmt.Errorf("failed to load channel %s: %w", channelID, err)
+	}
+	return ch, nil
+}
+
+// restoreJobMetadata recupera los tipos que JSON pierde (message_ids vuelve como []any).
+// client_context llega como map y MessageProcessor ya sabe reconstruirlo.
+func restoreJobMetadata(meta map[string]any) map[string]any {
+	if raw, ok := meta["message_ids"].([]any); ok {
+		ids := make([]string, 0, len(raw))
+		for _, v := range raw {
+			if id, ok := v.(string); ok {
+				ids = append(ids, id)
+			}
+		}
+		meta["message_ids"] = ids
+	}
+	return meta
+}
+
+// markReadFunc reconstruye el callback de lectura para jobs que vienen de la cola
+func (s *SessionOrchestrator) markReadFunc(channelID string) func(string, []string) {
+	if s.OnMarkRead == nil {
+		return nil
+	}
+	return func(chatID string, ids []string) {
+		s.OnMarkRead(context.Background(), channelID, chatID, ids)
+	}
+}
+
+func (s *SessionOrchestrator) dispatchInactivityWarn(key string, ch channel.Channel) {
+	parts := strings.Split(key, "|")
+	chatID := ""
+	if len(parts) >= 2 {
+		chatID = parts[1]
+	}
+	// Sin payload serializable queda como closure local, igual que antes
+	job, _ := msgworker.NewJob(JobSessionInactivity, ch.ID, chatID, inactivityJob{Key: key, ChannelID: ch.ID})
+	job.InstanceID, job.ChatJID, job.Pinned = ch.ID, chatID, true
+	job.Tenant, job.Lane = ch.WorkspaceID, msgworker.LaneScheduled
+	job.Handler = func(_ context.Context) error {
+		s.OnInactivityWarn(key, ch)
+		return nil
+	}
+	msgworker.GetGlobalPool().Dispatch(job)
+}
+
+func (s *SessionOrchestrator) dispatchProcessFinal(key string, ch channel.Channel, finalMsg message.IncomingMessage, botID string, markRead func(string, []string)) {
+	payload := processFinalJob{Key: key, ChannelID: ch.ID, Msg: finalMsg, BotID: botID}
+	job, _ := msgworker.NewJob(JobSessionProcessFinal, ch.ID, finalMsg.ChatID, payload)
+	job.InstanceID, job.ChatJID, job.Pinned = ch.ID, finalMsg.ChatID, true
+	// El workspace es el tenant del reparto justo; el tier del cliente decide su carril y peso
+	job.Tenant, job.Tier = ch.WorkspaceID, clientTier(finalMsg)
+	job.Lane = msgworker.InteractiveLane(job.Tier)
+	job.Handler = func(workerCtx context.Context) error {
+		s.processFinal(workerCtx, payload, ch, markRead)
+		return nil
+	}
+	msgworker.GetGlobalPool().Dispatch(job)
+}
+
+func clientTier(msg message.IncomingMessage) string {
+	if cc, ok := msg.Metadata["client_context"].(*botengineDomain.ClientContext); ok && cc != nil {
+		return cc.Tier
+	}
+	return ""
+}
+
+// processFinal entrega el lote al bot y deja la sesión esperando o re-encolada si llegaron más mensajes
+func (s *SessionOrchestrator) processFinal(workerCtx context.Context, job processFinalJob, ch channel.Channel, markRead func(string, []string)) {
+	key, finalMsg, botID := job.Key, job.Msg, job.BotID
+	storeCtx := context.Background()
+	sessionDuration, warningDelay, valkeyTTL, _ := s.calculateSessionParams(ch, finalMsg)
+
+	if s.OnWaitIdle != nil {
+		s.OnWaitIdle(workerCtx, ch.ID, finalMsg.ChatID)
+	}
+
+	if s.OnProcessFinal != nil {
+		output, _ := s.OnProcessFinal(workerCtx, ch, finalMsg, botID)
+
+		// Re-fetch entry from store (may have been updated)
+		if curr, _ := s.store.Get(storeCtx, key); curr != nil {
+			if bCount, ok := output.Metadata["bubbles"].(string); ok {
+				_, _ = fmt.Sscanf(bCount, "%d", &curr.LastBubbleCount)
+			}
+
+			if len(curr.Texts) > 0 {
+				if curr.Msg.Metadata == nil {
+					curr.Msg.Metadata = make(map[string]any)
+				}
+				curr.Msg.Metadata["is_delayed"] = true
+				readingPause := time.Duration(0)
+				if s.botEngine != nil {
+					totalContent := strings.Join(curr.Texts, "")
+					readingPause = s.botEngine.Humanizer().CalculateReadingTime(totalContent)
+				}
+				curr.State = StateDebouncing
+				debounce := (time.Duration(coreconfig.Global.AI.DebounceMs) * time.Millisecond) + readingPause
+				_ = s.store.Save(storeCtx, key, curr, valkeyTTL)
+
+				tb := &timerBundle{
+					debounce: time.AfterFunc(debounce, func() {
+						s.FlushDebounced(key, ch, botID, markRead)
+					}),
+				}
+				s.setTimers(key, tb)
+				logrus.Debugf("[SessionOrchestrator] Re-queuing %s with reading pause of %s", key, readingPause)
+			} else {
+				curr.State = StateWaiting
+				curr.ExpireAt = time.Now().Add(sessionDuration)
+				curr.ChatOpen = false
+
+				// Capture latest config flags
+				curr.InactivityWarningEnabled = true // Default
+				if ch.Config.InactivityWarning != nil {
+					curr.InactivityWarningEnabled = ch.Config.InactivityWarning.Enabled
+				}
+				curr.SessionClosingEnabled = true // Default
+				if ch.Config.SessionClosing != nil {
+					curr.SessionClosingEnabled = ch.Config.SessionClosing.Enabled
+				}
+
+				_ = s.store.Save(storeCtx, key, curr, valkeyTTL)
+
+				tb := &timerBundle{}
+				if s.OnInactivityWarn != nil {
+					tb.warning = time.AfterFunc(warningDelay, func() {
+						s.dispatchInactivityWarn(key, ch)
+					})
+				}
+				tb.debounce = time.AfterFunc(sessionDuration, func() {
+					if c, _ := s.store.Get(storeCtx, key); c != nil && c.State == StateWaiting {
+						if s.OnSessionClosed != nil {
+							go s.OnSessionClosed(c, ch)
+						}
+						_ = s.store.Delete(storeCtx, key)
+						if s.OnCleanupFiles != nil {
+							s.OnCleanupFiles(c)
+						}
+						if s.OnChannelIdle != nil {
+							go s.OnChannelIdle(c.Msg.ChannelID)
+						}
+					}
+				})
+				s.setTimers(key, tb)
+			}
+		}
+	}
+}
@@ -13,7 +13,6 @@ import (
 	botengineDomain "github.com/AzielCF/az-wap/botengine/domain"
 	clientDomain "github.com/AzielCF/az-wap/clients/domain"
 	coreconfig "github.com/AzielCF/az-wap/core/config"
-	"github.com/AzielCF/az-wap/core/pkg/msgworker"
 	"github.com/AzielCF/az-wap/workspace/domain/channel"
 	"github.com/AzielCF/az-wap/workspace/domain/message"
 	"github.com/AzielCF/az-wap/workspace/domain/session"
@@ -64,11 +63,13 @@ type SessionOrchestrator struct {
 	// Callbacks
 	OnProcessFinal        func(ctx context.Context, ch channel.Channel, msg message.IncomingMessage, botID string) (botengineDomain.BotOutput, error)
 	OnInactivityWarn      func(key string, ch channel.Channel)
+	OnMarkRead            func(ctx context.Context, channelID, chatID string, ids []string) // Jobs retomados de la cola
 	OnCleanupFiles        func(e *SessionEntry)
 	OnChannelIdle         func(channelID string)
 	OnWaitIdle            func(ctx context.Context, channelID, chatID string)
 	OnSessionClosed       func(e *SessionEntry, ch channel.Channel)
 	GetWorkspaceConfig    func(workspaceID string) *workspace.WorkspaceConfig
+	GetChannel            func(ctx context.Context, channelID string) (channel.Channel, error) // Jobs retomados de la cola
 	GetSubscriptionConfig func(clientID, channelID string) *clientDomain.ClientSubscription
 }
 
@@ -156,6 +157,9 @@ func (s *SessionOrchestrator) GetOrCreateEntry(key string, msg message.IncomingM
 	return e, false
 }
 
+// summaryHistoryCap es el tope de mensajes de un canal que resume el historial por presupuesto de tokens
+const summaryHistoryCap = 100
+
 // calculateSessionParams determines the session duration, warning time, and storage TTL
 // Priority: Client Config > Workspace Config > Channel Config > Default (4m)
 // calculateSessionParams determines the session duration, warning time, storage TTL, and history limit
@@ -173,6 +177,9 @@ func (s *SessionOrchestrator) calculateSessionParams(ch channel.Channel, msg mes
 	if ch.Config.InactivityWarningTime > 0 {
 		warningMinutes = ch.Config.InactivityWarningTime
 	}
+	if ch.Config.HistoryTokenBudget > 0 {
+		maxHistoryLimit = summaryHistoryCap // El resumen por presupuesto de tokens sustituye al recorte por defecto
+	}
 	if ch.Config.MaxHistoryLimit != 0 {
 		maxHistoryLimit = ch.Config.MaxHistoryLimit
 	}
@@ -223,6 +230,11 @@ func (s *SessionOrchestrator) calculateSessionParams(ch channel.Channel, msg mes
 		warningMinutes = minWarningTime
 	}
 
+	// Con resumen por tokens "ilimitado" sigue teniendo tope: si el resumen falla el historial no crece sin fin
+	if ch.Config.HistoryTokenBudget > 0 && maxHistoryLimit < 0 {
+		maxHistoryLimit = summaryHistoryCap
+	}
+
 	sessionDuration = time.Duration(totalMinutes) * time.Minute
 	warningDelay = time.Duration(warningMinutes) * time.Minute
 	valkeyTTL = sessionDuration + 30*time.Second
@@ -246,12 +258,20 @@ func (s *SessionOrchestrator) CloseSession(key string) {
 }
 
 func (s *SessionOrchestrator) GetActiveSessions() []ActiveSessionInfo {
-	ctx := context.Background()
-	entries, err := s.store.GetAll(ctx)
+	sessions, err := s.ListActiveSessions(context.Background())
 	if err != nil {
 		logrus.WithError(err).Warn("[SessionOrchestrator] Failed to get all sessions")
 		return nil
 	}
+	return sessions
+}
+
+// ListActiveSessions es GetActiveSessions sin ocultar los fallos del almacén (verificaciones)
+func (s *SessionOrchestrator) ListActiveSessions(ctx context.Context) ([]ActiveSessionInfo, error) {
+	entries, err := s.store.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
 
 	var sessions []ActiveSessionInfo
 	for k, e := range entries {
@@ -274,7 +294,7 @@ func (s *SessionOrchestrator) GetActiveSessions() []ActiveSessionInfo {
 		}
 		sessions = append(sessions, info)
 	}
-	return sessions
+	return sessions, nil
 }
 
 func (s *SessionOrchestrator) EnqueueDebounced(ctx context.Context, ch channel.Channel, msg message.IncomingMessage, botID string, markRead func(string, []string)) {
@@ -381,19 +401,7 @@ func (s *SessionOrchestrator) EnqueueDebounced(ctx context.Context, ch channel.C
 		tb := &timerBundle{}
 		if s.OnInactivityWarn != nil {
 			tb.warning = time.AfterFunc(warningDelay, func() {
-				parts := strings.Split(key, "|")
-				chatID := ""
-				if len(parts) >= 2 {
-					chatID = parts[1]
-				}
-				msgworker.GetGlobalPool().Dispatch(msgworker.MessageJob{
-					InstanceID: ch.ID,
-					ChatJID:    chatID,
-					Handler: func(_ context.Context) error {
-						s.OnInactivityWarn(key, ch)
-						return nil
-					},
-				})
+				s.dispatchInactivityWarn(key, ch)
 			})
 		}
 		tb.debounce = time.AfterFunc(sessionDuration, func() {
@@ -520,7 +528,7 @@ func (s *SessionOrchestrator) FlushDebounced(key string, ch channel.Channel, bot
 	}
 
 	// Calculate session params immediately for use throughout the function scope
-	sessionDuration, warningDelay, valkeyTTL, maxHistoryLimit := s.calculateSessionParams(ch, e.Msg)
+	_, _, valkeyTTL, maxHistoryLimit := s.calculateSessionParams(ch, e.Msg)
 	e.MaxHistoryLimit = maxHistoryLimit
 
 	typingState, _ := s.typing.Get(storeCtx, ch.ID, e.Msg.ChatID)
@@ -572,98 +580,5 @@ func (s *SessionOrchestrator) FlushDebounced(key string, ch channel.Channel, bot
 	// Save updated state
 	_ = s.store.Save(storeCtx, key, e, valkeyTTL)
 
-	msgworker.GetGlobalPool().Dispatch(msgworker.MessageJob{
-		InstanceID: ch.ID,
-		ChatJID:    finalMsg.ChatID,
-		Handler: func(workerCtx context.Context) error {
-			if s.OnWaitIdle != nil {
-				s.OnWaitIdle(workerCtx, ch.ID, finalMsg.ChatID)
-			}
-
-			if s.OnProcessFinal != nil {
-				output, _ := s.OnProcessFinal(workerCtx, ch, finalMsg, botID)
-
-				// Re-fetch entry from store (may have been updated)
-				if curr, _ := s.store.Get(storeCtx, key); curr != nil {
-					if bCount, ok := output.Metadata["bubbles"].(string); ok {
-						_, _ = fmt.Sscanf(bCount, "%d", &curr.LastBubbleCount)
-					}
-
-					if len(curr.Texts) > 0 {
-						if curr.Msg.Metadata == nil {
-							curr.Msg.Metadata = make(map[string]any)
-						}
-						curr.Msg.Metadata["is_delayed"] = true
-						readingPause := time.Duration(0)
-						if s.botEngine != nil {
-							totalContent := strings.Join(curr.Texts, "")
-							readingPause = s.botEngine.Humanizer().CalculateReadingTime(totalContent)
-						}
-						curr.State = StateDebouncing
-						debounce := (time.Duration(coreconfig.Global.AI.DebounceMs) * time.Millisecond) + readingPause
-						_ = s.store.Save(storeCtx, key, curr, valkeyTTL)
-
-						tb := &timerBundle{
-							debounce: time.AfterFunc(debounce, func() {
-								s.FlushDebounced(key, ch, botID, markRead)
-							}),
-						}
-						s.setTimers(key, tb)
-						logrus.Debugf("[SessionOrchestrator] Re-queuing %s with reading pause of %s", key, readingPause)
-					} else {
-						curr.State = StateWaiting
-						curr.ExpireAt = time.Now().Add(sessionDuration)
-						curr.ChatOpen = false
-
-						// Capture latest config flags
-						curr.InactivityWarningEnabled = true // Default
-						if ch.Config.InactivityWarning != nil {
-							curr.InactivityWarningEnabled = ch.Config.InactivityWarning.Enabled
-						}
-						curr.SessionClosingEnabled = true // Default
-						if ch.Config.SessionClosing != nil {
-							curr.SessionClosingEnabled = ch.Config.SessionClosing.Enabled
-						}
-
-						_ = s.store.Save(storeCtx, key, curr, valkeyTTL)
-
-						tb := &timerBundle{}
-						if s.OnInactivityWarn != nil {
-							tb.warning = time.AfterFunc(warningDelay, func() {
-								parts := strings.Split(key, "|")
-								chatID := ""
-								if len(parts) >= 2 {
-									chatID = parts[1]
-								}
-								msgworker.GetGlobalPool().Dispatch(msgworker.MessageJob{
-									InstanceID: ch.ID,
-									ChatJID:    chatID,
-									Handler: func(_ context.Context) error {
-										s.OnInactivityWarn(key, ch)
-										return nil
-									},
-								})
-							})
-						}
-						tb.debounce = time.AfterFunc(sessionDuration, func() {
-							if c, _ := s.store.Get(storeCtx, key); c != nil && c.State == StateWaiting {
-								if s.OnSessionClosed != nil {
-									go s.OnSessionClosed(c, ch)
-								}
-								_ = s.store.Delete(storeCtx, key)
-								if s.OnCleanupFiles != nil {
-									s.OnCleanupFiles(c)
-								}
-								if s.OnChannelIdle != nil {
-									go s.OnChannelIdle(c.Msg.ChannelID)
-								}
-							}
-						})
-						s.setTimers(key, tb)
-					}
-				}
-			}
-			return nil
-		},
-	})
+	s.dispatchProcessFinal(key, ch, finalMsg, botID, markRead)
 }
@@ -0,0 +1,27 @@
+package application
+
+import (
+	"testing"
+
+	"github.com/AzielCF/az-wap/workspace/domain/channel"
+	"github.com/AzielCF/az-wap/workspace/domain/message"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCalculateSessionParams_TokenBudgetKeepsHardCap(t *testing.T) {
+	s := &SessionOrchestrator{}
+	msg := message.IncomingMessage{ChatID: "chat", SenderID: "sender"}
+
+	_, _, _, limit := s.calculateSessionParams(channel.Channel{Config: channel.ChannelConfig{HistoryTokenBudget: 2000}}, msg)
+	assert.Equal(t, summaryHistoryCap, limit)
+
+	// "Ilimitado" con resumen por tokens conserva el tope por si el resumen falla
+	_, _, _, limit = s.calculateSessionParams(channel.Channel{Config: channel.ChannelConfig{HistoryTokenBudget: 2000, MaxHistoryLimit: -1}}, msg)
+	assert.Equal(t, summaryHistoryCap, limit)
+
+	_, _, _, limit = s.calculateSessionParams(channel.Channel{Config: channel.ChannelConfig{HistoryTokenBudget: 2000, MaxHistoryLimit: 30}}, msg)
+	assert.Equal(t, 30, limit)
+
+	_, _, _, limit = s.calculateSessionParams(channel.Channel{Config: channel.ChannelConfig{MaxHistoryLimit: -1}}, msg)
+	assert.Equal(t, -1, limit)
+}
@@ -0,0 +1,88 @@
+package workspace
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	sessionDomain "github.com/AzielCF/az-wap/workspace/domain/session"
+	"github.com/sirupsen/logrus"
+)
+
+// ExportChatData devuelve las sesiones activas cuyo chat o remitente cumple match
+func (m *Manager) ExportChatData(match func(id string) bool) []sessionDomain.ChatData {
+	var res []sessionDomain.ChatData
+	for _, s := range m.sessions.GetActiveSessions() {
+		if !match(s.ChatID) && !match(s.SenderID) {
+			continue
+		}
+		entry, ok := m.sessions.GetEntry(s.Key)
+		if !ok {
+			continue
+		}
+		data := sessionDomain.ChatData{
+			ChannelID:   s.ChannelID,
+			WorkspaceID: entry.Msg.WorkspaceID,
+			ChatID:      s.ChatID,
+			SenderID:    s.SenderID,
+			BotID:       entry.BotID,
+			PendingText: entry.Texts,
+			History:     entry.Memory.GetHistory(),
+			LastSeen:    entry.LastSeen,
+		}
+		if entry.SessionPath != "" {
+			_ = filepath.WalkDir(entry.SessionPath, func(path string, d os.DirEntry, err error) error {
+				if err == nil && !d.IsDir() {
+					data.Files = append(data.Files, path)
+				}
+				return nil
+			})
+		}
+		res = append(res, data)
+	}
+	return res
+}
+
+// EraseChatData cierra sin aviso las sesiones que cumplen match y borra sus archivos descargados.
+// Retorna cuántas sesiones se eliminaron.
+func (m *Manager) EraseChatData(match func(id string) bool) int {
+	erased := 0
+	for _, s := range m.sessions.GetActiveSessions() {
+		if !match(s.ChatID) && !match(s.SenderID) {
+			continue
+		}
+		if entry, ok := m.sessions.GetEntry(s.Key); ok && entry.SessionPath != "" {
+			// Síncrono: el informe de verificación no puede adelantarse al borrado
+			if err := os.RemoveAll(entry.SessionPath); err != nil {
+				logrus.WithError(err).Warnf("[WS_MANAGER] Failed to remove session files at %s", entry.SessionPath)
+			}
+		}
+		m.sessions.DeleteEntry(s.Key)
+		erased++
+	}
+	if erased > 0 {
+		logrus.Infof("[WS_MANAGER] Erased %d sessions on data-subject request", erased)
+	}
+	return erased
+}
+
+// CountChatData cuenta las sesiones que siguen guardando datos del sujeto (verificación)
+func (m *Manager) CountChatData(match func(id string) bool) (int, error) {
+	sessions, err := m.sessions.ListActiveSessions(context.Background())
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	for _, s := range sessions {
+		if match(s.ChatID) || match(s.SenderID) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// Clustered indica si las sesiones se comparten con otros nodos (Valkey): cada nodo guarda
+// en su disco los archivos de los chats que atiende
+func (m *Manager) Clustered() bool {
+	return m.valkeyClient != nil
+}
@@ -0,0 +1,255 @@
+package channel
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// OffHoursMode decide qué hace el canal con los mensajes que llegan fuera del horario comercial.
+type OffHoursMode string
+
+const (
+	OffHoursReply     OffHoursMode = "reply"      // El bot responde igual; solo cambia la presencia
+	OffHoursDelay     OffHoursMode = "delay"      // Responde más tarde, como alguien que mira el móvil de vez en cuando
+	OffHoursAutoReply OffHoursMode = "auto_reply" // Envía la plantilla y no despierta al bot
+	OffHoursDefer     OffHoursMode = "defer"      // Envía la plantilla y el bot responde a la hora de apertura
+)
+
+var weekdayKeys = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+// maxOpeningLookahead limita la búsqueda de la próxima apertura (vacaciones largas incluidas)
+const maxOpeningLookahead = 60
+
+// TimeRange es un tramo horario "HH:MM"-"HH:MM" en la zona del canal. Close "24:00" = fin del día.
+type TimeRange struct {
+	Open  string `json:"open"`
+	Close string `json:"close"`
+}
+
+// Holiday cierra el canal todo el día. Date es "YYYY-MM-DD" o "MM-DD" para festivos que se repiten cada año.
+type Holiday struct {
+	Date string `json:"date"`
+	Name string `json:"name,omitempty"`
+}
+
+// OffHoursPolicy configura el comportamiento fuera de horario
+type OffHoursPolicy struct {
+	Mode            OffHoursMode      `json:"mode"`
+	MinDelayMinutes int               `json:"min_delay_minutes,omitempty"` // Modo delay. Default 5
+	MaxDelayMinutes int               `json:"max_delay_minutes,omitempty"` // Modo delay. Default 20
+	Templates       map[string]string `json:"templates,omitempty"`         // Por idioma. Admite {{opens_at}}
+	DefaultLang     string            `json:"default_lang,omitempty"`
+}
+
+// BusinessHours es el calendario comercial de un canal, evaluado en ChannelConfig.Timezone.
+type BusinessHours struct {
+	Enabled  bool                   `json:"enabled"`
+	Weekly   map[string][]TimeRange `json:"weekly"` // "mon".."sun". Día sin tramos = cerrado
+	Holidays []Holiday              `json:"holidays,omitempty"`
+	OffHours OffHoursPolicy         `json:"off_hours"`
+}
+
+// Location devuelve la zona horaria del canal (hora local del servidor si no está configurada o es inválida).
+func (c ChannelConfig) Location() *time.Location {
+	if c.Timezone != "" {
+		if loc, err := time.LoadLocation(c.Timezone); err == nil {
+			return loc
+		}
+	}
+	return time.Local
+}
+
+// ValidateSchedule revisa la zona horaria, el horario comercial y el perfil de escritura del canal.
+func (c ChannelConfig) ValidateSchedule() error {
+	if c.Timezone != "" {
+		if _, err := time.LoadLocation(c.Timezone); err != nil {
+			return fmt.Errorf("timezone: unknown zone %q", c.Timezone)
+		}
+	}
+	if err := c.BusinessHours.Validate(); err != nil {
+		return err
+	}
+	if err := c.Typing.Validate(); err != nil {
+		return fmt.Errorf("typing: %w", err)
+	}
+	return nil
+}
+
+// IsOffHours indica si el canal está "fuera de horario" en t. Sin calendario se usa la
+// ventana nocturna (00:00 - 06:00) en la zona horaria del canal.
+func (c ChannelConfig) IsOffHours(t time.Time) bool {
+	loc := c.Location()
+	if c.BusinessHours != nil && c.BusinessHours.Enabled {
+		return !c.BusinessHours.IsOpen(t, loc)
+	}
+	hour := t.In(loc).Hour()
+	return hour >= 0 && hour < 6
+}
+
+// IsOpen indica si el calendario está abierto en t. Un calendario nil o deshabilitado siempre está abierto.
+func (b *BusinessHours) IsOpen(t time.Time, loc *time.Location) bool {
+	if b == nil || !b.Enabled {
+		return true
+	}
+	local := t.In(loc)
+	if b.isHoliday(local) {
+		return false
+	}
+	minute := local.Hour()*60 + local.Minute()
+	for _, r := range b.Weekly[weekdayKeys[local.Weekday()]] {
+		open, close, err := r.minutes()
+		if err == nil && minute >= open && minute < close {
+			return true
+		}
+	}
+	return false
+}
+
+// NextOpening devuelve el próximo instante de apertura a partir de t (t mismo si ya está abierto).
+func (b *BusinessHours) NextOpening(t time.Time, loc *time.Location) (time.Time, bool) {
+	if b.IsOpen(t, loc) {
+		return t, true
+	}
+	local := t.In(loc)
+	for i := 0; i <= maxOpeningLookahead; i++ {
+		// La hora se construye con time.Date: sumar minutos a la medianoche falla los días de cambio de hora
+		d := time.Date(local.Year(), local.Month(), local.Day()+i, 0, 0, 0, 0, loc)
+		if b.isHoliday(d) {
+			continue
+		}
+		var best time.Time
+		for _, r := range b.Weekly[weekdayKeys[d.Weekday()]] {
+			open, _, err := r.minutes()
+			if err != nil {
+				continue
+			}
+			candidate := time.Date(d.Year(), d.Month(), d.Day(), open/60, open%60, 0, 0, loc)
+			if candidate.After(local) && (best.IsZero() || candidate.Before(best)) {
+				best = candidate
+			}
+		}
+		if !best.IsZero() {
+			return best, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// Validate revisa el formato de los tramos, festivos y la política fuera de horario.
+func (b *BusinessHours) Validate() error {
+	if b == nil {
+		return nil
+	}
+	for day, ranges := range b.Weekly {
+		if !validWeekdayKey(day) {
+			return fmt.Errorf("business_hours: unknown weekday %q (use mon..sun)", day)
+		}
+		for _, r := range ranges {
+			open, close, err := r.minutes()
+			if err != nil {
+				return fmt.Errorf("business_hours: %s: %w", day, err)
+			}
+			if close <= open {
+				return fmt.Errorf("business_hours: %s: close %s must be after open %s", day, r.Close, r.Open)
+			}
+		}
+	}
+	for _, h := range b.Holidays {
+		if _, _, err := h.parse(); err != nil {
+			return fmt.Errorf("business_hours: holiday %q: %w", h.Date, err)
+		}
+	}
+
+	p := b.OffHours
+	switch p.Mode {
+	case "", OffHoursReply, OffHoursDelay, OffHoursAutoReply, OffHoursDefer:
+	default:
+		return fmt.Errorf("business_hours: unknown off-hours mode %q", p.Mode)
+	}
+	if p.MinDelayMinutes < 0 || p.MaxDelayMinutes < 0 || (p.MaxDelayMinutes > 0 && p.MaxDelayMinutes < p.MinDelayMinutes) {
+		return fmt.Errorf("business_hours: invalid off-hours delay range")
+	}
+	return nil
+}
+
+// DelayRange devuelve el rango de espera del modo delay con sus valores por defecto.
+func (p OffHoursPolicy) DelayRange() (time.Duration, time.Duration) {
+	minDelay, maxDelay := p.MinDelayMinutes, p.MaxDelayMinutes
+	if minDelay <= 0 {
+		minDelay = 5
+	}
+	if maxDelay < minDelay {
+		maxDelay = minDelay + 15
+	}
+	return time.Duration(minDelay) * time.Minute, time.Duration(maxDelay) * time.Minute
+}
+
+// Template devuelve la plantilla en el idioma pedido (o el de respaldo). Vacío si no hay ninguna.
+func (p OffHoursPolicy) Template(lang string) string {
+	if t := p.Templates[lang]; t != "" {
+		return t
+	}
+	if t := p.Templates[p.DefaultLang]; t != "" {
+		return t
+	}
+	return p.Templates["en"]
+}
+
+func (b *BusinessHours) isHoliday(local time.Time) bool {
+	for _, h := range b.Holidays {
+		year, md, err := h.parse()
+		if err != nil {
+			continue
+		}
+		if md == local.Format("01-02") && (year == 0 || year == local.Year()) {
+			return true
+		}
+	}
+	return false
+}
+
+// parse devuelve el año (0 = cada año) y el "MM-DD" del festivo
+func (h Holiday) parse() (int, string, error) {
+	date := strings.TrimSpace(h.Date)
+	if t, err := time.Parse("2006-01-02", date); err == nil {
+		return t.Year(), t.Format("01-02"), nil
+	}
+	if t, err := time.Parse("01-02", date); err == nil {
+		return 0, t.Format("01-02"), nil
+	}
+	return 0, "", fmt.Errorf("expected YYYY-MM-DD or MM-DD")
+}
+
+func (r TimeRange) minutes() (int, int, error) {
+	open, err := parseClock(r.Open)
+	if err != nil {
+		return 0, 0, err
+	}
+	close, err := parseClock(r.Close)
+	if err != nil {
+		return 0, 0, err
+	}
+	return open, close, nil
+}
+
+func parseClock(v string) (int, error) {
+	v = strings.TrimSpace(v)
+	if v == "24:00" {
+		return 24 * 60, nil
+	}
+	t, err := time.Parse("15:04", v)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q (expected HH:MM)", v)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+func validWeekdayKey(day string) bool {
+	for _, k := range weekdayKeys {
+		if k == day {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,70 @@
+package channel
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBusinessHours_OpenAndNextOpening(t *testing.T) {
+	loc, err := time.LoadLocation("America/Lima")
+	assert.NoError(t, err)
+
+	bh := &BusinessHours{
+		Enabled: true,
+		Weekly: map[string][]TimeRange{
+			"mon": {{Open: "09:00", Close: "13:00"}, {Open: "15:00", Close: "19:00"}},
+			"tue": {{Open: "09:00", Close: "19:00"}},
+		},
+		Holidays: []Holiday{{Date: "12-25", Name: "Navidad"}},
+	}
+	assert.NoError(t, bh.Validate())
+
+	// Lunes 2026-10-19 en Lima
+	assert.True(t, bh.IsOpen(time.Date(2026, 10, 19, 10, 0, 0, 0, loc), loc))
+	assert.False(t, bh.IsOpen(time.Date(2026, 10, 19, 14, 0, 0, 0, loc), loc))
+
+	next, ok := bh.NextOpening(time.Date(2026, 10, 19, 14, 0, 0, 0, loc), loc)
+	assert.True(t, ok)
+	assert.Equal(t, time.Date(2026, 10, 19, 15, 0, 0, 0, loc), next)
+
+	// Martes de noche -> el próximo lunes
+	next, ok = bh.NextOpening(time.Date(2026, 10, 20, 20, 0, 0, 0, loc), loc)
+	assert.True(t, ok)
+	assert.Equal(t, time.Date(2026, 10, 26, 9, 0, 0, 0, loc), next)
+
+	// Festivo anual (25/12/2028 es lunes)
+	assert.False(t, bh.IsOpen(time.Date(2028, 12, 25, 10, 0, 0, 0, loc), loc))
+
+	// La hora se evalúa en la zona del canal, no en la del servidor
+	cfg := ChannelConfig{Timezone: "America/Lima", BusinessHours: bh}
+	assert.False(t, cfg.IsOffHours(time.Date(2026, 10, 19, 15, 0, 0, 0, time.UTC))) // 10:00 en Lima
+}
+
+func TestBusinessHours_NextOpeningOnDSTChange(t *testing.T) {
+	loc, err := time.LoadLocation("Europe/Madrid")
+	assert.NoError(t, err)
+
+	bh := &BusinessHours{Enabled: true, Weekly: map[string][]TimeRange{
+		"sun": {{Open: "09:00", Close: "14:00"}},
+	}}
+
+	// 2026-03-29 el reloj salta de 02:00 a 03:00: la apertura sigue siendo a las 09:00 locales
+	next, ok := bh.NextOpening(time.Date(2026, 3, 28, 20, 0, 0, 0, loc), loc)
+	assert.True(t, ok)
+	assert.Equal(t, time.Date(2026, 3, 29, 9, 0, 0, 0, loc), next)
+	assert.Equal(t, 9, next.In(loc).Hour())
+}
+
+func TestBusinessHours_Validate(t *testing.T) {
+	invalid := []*BusinessHours{
+		{Weekly: map[string][]TimeRange{"monday": {{Open: "09:00", Close: "18:00"}}}},
+		{Weekly: map[string][]TimeRange{"mon": {{Open: "18:00", Close: "09:00"}}}},
+		{Holidays: []Holiday{{Date: "25/12"}}},
+		{OffHours: OffHoursPolicy{Mode: "sleep"}},
+	}
+	for _, bh := range invalid {
+		assert.Error(t, bh.Validate())
+	}
+}
@@ -1,6 +1,11 @@
 package channel
 
-import "time"
+import (
+	"fmt"
+	"time"
+
+	"github.com/AzielCF/az-wap/botengine/domain/bot"
+)
 
 type Channel struct {
 	ID              string             `json:"id"`
@@ -72,7 +77,26 @@ type ChannelConfig struct {
 	SessionTimeout        int                         `json:"session_timeout,omitempty"`         // Minutes. Default: 4
 	InactivityWarningTime int                         `json:"inactivity_warning_time,omitempty"` // Minutes. When to alert. Must be >= 80% of total
 	MaxHistoryLimit       int                         `json:"max_history_limit,omitempty"`       // Max messages in context. 0 = 10, -1 = Unlimited
+	HistoryTokenBudget    int                         `json:"history_token_budget,omitempty"`    // Tokens de historial antes de resumir los turnos antiguos. 0 = sin resumen
 	GuestAccess           map[string]GuestConfigCache `json:"guest_access,omitempty"`            // Guest specific config propagation
+	BusinessHours         *BusinessHours              `json:"business_hours,omitempty"`          // Horario comercial (en Timezone) y comportamiento fuera de horario
+	Typing                *bot.TypingSettings         `json:"typing,omitempty"`                  // Perfil de escritura del canal. Tiene prioridad sobre el del bot
+	TrafficSplit          *bot.TrafficSplit           `json:"traffic_split,omitempty"`           // Experimento A/B entre dos revisiones o variantes del bot
+	SubscriptionLifecycle *SubscriptionLifecycle      `json:"subscription_lifecycle,omitempty"`  // Avisos de vencimiento y periodo de gracia de las suscripciones
+}
+
+// Validate revisa la configuración editable del canal antes de guardarla.
+func (c ChannelConfig) Validate() error {
+	if err := c.ValidateSchedule(); err != nil {
+		return err
+	}
+	if err := c.TrafficSplit.Validate(); err != nil {
+		return fmt.Errorf("traffic_split: %w", err)
+	}
+	if err := c.SubscriptionLifecycle.Validate(); err != nil {
+		return fmt.Errorf("subscription_lifecycle: %w", err)
+	}
+	return nil
 }
 
 type GuestConfigCache struct {
@@ -0,0 +1,134 @@
+package channel
+
+import (
+	"fmt"
+	"sort"
+)
+
+// MaxNoticeDays es el mayor adelanto con el que se avisa de un vencimiento
+const MaxNoticeDays = 30
+
+// defaultNoticeDays se usa cuando el canal activa los avisos sin indicar los días
+var defaultNoticeDays = []int{7, 3, 1}
+
+// LifecycleEvent identifica cada mensaje que el ciclo de vida puede enviar al cliente
+type LifecycleEvent string
+
+const (
+	LifecycleNotice  LifecycleEvent = "notice"  // Aviso N días antes del vencimiento
+	LifecycleGrace   LifecycleEvent = "grace"   // La suscripción venció y entra en periodo de gracia
+	LifecycleExpired LifecycleEvent = "expired" // Fin definitivo (tras la gracia, si la hay)
+	LifecycleRenewed LifecycleEvent = "renewed" // Renovación confirmada
+)
+
+// defaultLifecycleTemplates son los textos usados cuando el canal no define los suyos.
+// Admiten {{name}}, {{days}}, {{expires_at}} y {{grace_until}}.
+var defaultLifecycleTemplates = map[LifecycleEvent]map[string]string{
+	LifecycleNotice: {
+		"en": "Hi {{name}}, your subscription expires in {{days}} day(s) ({{expires_at}}). Renew it to keep the service without interruptions.",
+		"es": "Hola {{name}}, tu suscripción vence en {{days}} día(s) ({{expires_at}}). Renuévala para seguir usando el servicio sin interrupciones.",
+	},
+	LifecycleGrace: {
+		"en": "Hi {{name}}, your subscription expired on {{expires_at}}. You keep limited access until {{grace_until}}; renew it to recover the full service.",
+		"es": "Hola {{name}}, tu suscripción venció el {{expires_at}}. Mantienes un acceso limitado hasta el {{grace_until}}; renuévala para recuperar el servicio completo.",
+	},
+	LifecycleExpired: {
+		"en": "Hi {{name}}, your subscription has ended. Contact us whenever you want to renew it.",
+		"es": "Hola {{name}}, tu suscripción ha finalizado. Escríbenos cuando quieras renovarla.",
+	},
+	LifecycleRenewed: {
+		"en": "Thanks {{name}}! Your subscription is active until {{expires_at}}.",
+		"es": "¡Gracias {{name}}! Tu suscripción está activa hasta el {{expires_at}}.",
+	},
+}
+
+// SubscriptionLifecycle configura los avisos de vencimiento y el periodo de gracia de las
+// suscripciones del canal. Sin política (o deshabilitada) las suscripciones solo expiran al vencer.
+type SubscriptionLifecycle struct {
+	Enabled            bool                                 `json:"enabled"`
+	NoticeDays         []int                                `json:"notice_days,omitempty"`           // Días antes del vencimiento. Default 7, 3, 1
+	GraceDays          int                                  `json:"grace_days,omitempty"`            // 0 = expira al vencer
+	GraceBotTemplateID string                               `json:"grace_bot_template_id,omitempty"` // Variante reducida del bot durante la gracia
+	Templates          map[LifecycleEvent]map[string]string `json:"templates,omitempty"`             // Por evento e idioma. Vacío = textos por defecto
+	DefaultLang        string                               `json:"default_lang,omitempty"`          // Default "en"
+	DisabledMessages   map[LifecycleEvent]bool              `json:"disabled_messages,omitempty"`     // Eventos que no envían mensaje (el webhook se emite igual)
+}
+
+// Validate revisa los límites de la política antes de guardarla
+func (l *SubscriptionLifecycle) Validate() error {
+	if l == nil {
+		return nil
+	}
+	for _, d := range l.NoticeDays {
+		if d < 1 || d > MaxNoticeDays {
+			return fmt.Errorf("notice_days must be between 1 and %d", MaxNoticeDays)
+		}
+	}
+	if l.GraceDays < 0 || l.GraceDays > 90 {
+		return fmt.Errorf("grace_days must be between 0 and 90")
+	}
+	return nil
+}
+
+// IsEnabled indica si el canal avisa y aplica gracia
+func (l *SubscriptionLifecycle) IsEnabled() bool {
+	return l != nil && l.Enabled
+}
+
+// Notices devuelve los días de aviso ordenados de mayor a menor
+func (l *SubscriptionLifecycle) Notices() []int {
+	if !l.IsEnabled() {
+		return nil
+	}
+	days := l.NoticeDays
+	if len(days) == 0 {
+		days = defaultNoticeDays
+	}
+	res := make([]int, 0, len(days))
+	for _, d := range days {
+		if d >= 1 && d <= MaxNoticeDays {
+			res = append(res, d)
+		}
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(res)))
+	return res
+}
+
+// Grace devuelve los días de gracia (0 si la política está deshabilitada)
+func (l *SubscriptionLifecycle) Grace() int {
+	if !l.IsEnabled() || l.GraceDays < 0 {
+		return 0
+	}
+	return l.GraceDays
+}
+
+// GraceTemplateID devuelve la variante del bot a usar durante la gracia ("" = la normal)
+func (l *SubscriptionLifecycle) GraceTemplateID() string {
+	if !l.IsEnabled() {
+		return ""
+	}
+	return l.GraceBotTemplateID
+}
+
+// Template devuelve el texto del evento en el idioma pedido. Vacío si el evento no envía mensaje.
+func (l *SubscriptionLifecycle) Template(event LifecycleEvent, lang string) string {
+	if !l.IsEnabled() || l.DisabledMessages[event] {
+		return ""
+	}
+	defaultLang := l.DefaultLang
+	if defaultLang == "" {
+		defaultLang = "en"
+	}
+	for _, set := range []map[string]string{l.Templates[event], defaultLifecycleTemplates[event]} {
+		if t := set[lang]; t != "" {
+			return t
+		}
+		if t := set[defaultLang]; t != "" {
+			return t
+		}
+		if t := set["en"]; t != "" {
+			return t
+		}
+	}
+	return ""
+}
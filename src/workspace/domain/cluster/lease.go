@@ -0,0 +1,144 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"time"
+)
+
+var (
+	// ErrLeaseHeld indica que otro nodo vivo es dueño del canal.
+	ErrLeaseHeld = errors.New("channel is owned by another node")
+	// ErrNodeUnavailable indica que el nodo destino no tiene heartbeat reciente o está drenando.
+	ErrNodeUnavailable = errors.New("node is not available")
+	// ErrFenced indica que este nodo ya no tiene el lease del canal con la generación con la que arrancó.
+	ErrFenced = errors.New("channel lease is no longer held by this node")
+)
+
+// ChannelLease es la propiedad temporal de un canal por un nodo del cluster.
+// Solo el dueño del lease puede arrancar el adaptador (whatsmeow, telegram) del canal.
+//
+// Token es el fencing token del lease: crece cada vez que el canal cambia de dueño. Además de Renew
+// y Release, el adaptador lo comprueba con Check antes de cada envío y de cada escritura de claves
+// en el device store, así un nodo aislado de Valkey deja de enviar y de rotar claves en cuanto no
+// puede confirmar su generación, aunque su adaptador siga conectado hasta la siguiente renovación.
+type ChannelLease struct {
+	ChannelID string    `json:"channel_id"`
+	NodeID    string    `json:"node_id"`
+	Token     int64     `json:"token"` // Fencing token: crece cada vez que el canal cambia de dueño
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// HeldError describe quién tiene el canal cuando no se pudo adquirir.
+type HeldError struct {
+	Lease ChannelLease
+}
+
+func (e *HeldError) Error() string {
+	return fmt.Sprintf("channel %s is owned by node %s until %s", e.Lease.ChannelID, e.Lease.NodeID, e.Lease.ExpiresAt.Format(time.RFC3339))
+}
+
+func (e *HeldError) Unwrap() error {
+	return ErrLeaseHeld
+}
+
+// LeaseStore persiste los leases de canales y las órdenes del operador (drenado y movimientos).
+type LeaseStore interface {
+	// Acquire toma el lease si está libre o expirado; si ya es del nodo lo renueva.
+	// Devuelve el lease vigente y si pertenece al nodo que lo pidió.
+	Acquire(ctx context.Context, channelID, nodeID string, ttl time.Duration) (ChannelLease, bool, error)
+
+	// Renew extiende el lease solo si nodo y token siguen coincidiendo.
+	Renew(ctx context.Context, lease ChannelLease, ttl time.Duration) (bool, error)
+
+	// Release libera el lease solo si nodo y token siguen coincidiendo.
+	Release(ctx context.Context, lease ChannelLease) error
+
+	// Check indica si el lease sigue vigente con el mismo nodo y token (fencing).
+	Check(ctx context.Context, lease ChannelLease) (bool, error)
+
+	// Get devuelve el lease vigente del canal (nil si no tiene dueño).
+	Get(ctx context.Context, channelID string) (*ChannelLease, error)
+
+	// List devuelve todos los leases vigentes.
+	List(ctx context.Context) ([]ChannelLease, error)
+
+	// Drenado: el nodo entrega sus canales y no recibe nuevos.
+	SetDraining(ctx context.Context, nodeID string, draining bool) error
+	DrainingNodes(ctx context.Context) ([]string, error)
+
+	// Pins: el operador fija un canal a un nodo concreto. nodeID vacío quita el pin.
+	SetPin(ctx context.Context, channelID, nodeID string) error
+	Pins(ctx context.Context) (map[string]string, error)
+}
+
+// ChannelPlacement es el estado de un canal en el cluster para el panel de administración.
+type ChannelPlacement struct {
+	ChannelID string     `json:"channel_id"`
+	Owner     string     `json:"owner,omitempty"` // Nodo con el lease vigente
+	Token     int64      `json:"token,omitempty"` // Generación del lease vigente
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	Desired   string     `json:"desired,omitempty"` // Nodo según la topología actual
+	PinnedTo  string     `json:"pinned_to,omitempty"`
+}
+
+// Status resume la propiedad de canales del cluster.
+type Status struct {
+	NodeID   string             `json:"node_id"` // Nodo que responde
+	Nodes    []string           `json:"nodes"`
+	Draining []string           `json:"draining"`
+	Channels []ChannelPlacement `json:"channels"`
+}
+
+// Topology es la vista del cluster usada para decidir dónde debe correr cada canal.
+type Topology struct {
+	Nodes    []string          // Nodos con heartbeat reciente
+	Draining map[string]bool   // Nodos que están entregando sus canales
+	Pins     map[string]string // Canal -> nodo fijado por el operador
+}
+
+// IsAlive indica si el nodo reportó heartbeat reciente.
+func (t Topology) IsAlive(nodeID string) bool {
+	for _, n := range t.Nodes {
+		if n == nodeID {
+			return true
+		}
+	}
+	return false
+}
+
+// Owner devuelve el nodo donde debería correr el canal. Un pin a un nodo vivo gana;
+// si no, se usa rendezvous hashing sobre los nodos vivos que no están drenando.
+// Devuelve "" si no hay candidato (ej. todos los nodos drenando).
+func (t Topology) Owner(channelID string) string {
+	if pin := t.Pins[channelID]; pin != "" && t.IsAlive(pin) && !t.Draining[pin] {
+		return pin
+	}
+	candidates := make([]string, 0, len(t.Nodes))
+	for _, n := range t.Nodes {
+		if !t.Draining[n] {
+			candidates = append(candidates, n)
+		}
+	}
+	return Place(channelID, candidates)
+}
+
+// Place elige un nodo para el canal con rendezvous hashing (highest random weight).
+// Cuando un nodo entra o sale, solo se mueven los canales que le tocaban a ese nodo.
+func Place(channelID string, nodes []string) string {
+	var best string
+	var bestScore uint64
+	for _, n := range nodes {
+		h := fnv.New64a()
+		_, _ = h.Write([]byte(n))
+		_, _ = h.Write([]byte{0})
+		_, _ = h.Write([]byte(channelID))
+		score := h.Sum64()
+		if best == "" || score > bestScore || (score == bestScore && n < best) {
+			best, bestScore = n, score
+		}
+	}
+	return best
+}
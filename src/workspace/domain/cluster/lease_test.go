@@ -0,0 +1,55 @@
+package cluster
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlace_StableAndMinimalMovement(t *testing.T) {
+	nodes := []string{"node-a", "node-b", "node-c"}
+
+	assert.Equal(t, "", Place("ch-1", nil))
+	assert.Equal(t, Place("ch-1", nodes), Place("ch-1", []string{"node-c", "node-a", "node-b"}))
+
+	// Al caer un nodo solo se reubican los canales que le tocaban
+	moved := 0
+	for i := 0; i < 200; i++ {
+		id := fmt.Sprintf("ch-%d", i)
+		before := Place(id, nodes)
+		after := Place(id, []string{"node-a", "node-b"})
+		if before != "node-c" {
+			assert.Equal(t, before, after, id)
+		} else {
+			moved++
+		}
+	}
+	assert.Greater(t, moved, 0)
+}
+
+func TestTopology_OwnerRespectsPinsAndDraining(t *testing.T) {
+	topo := Topology{
+		Nodes:    []string{"node-a", "node-b"},
+		Draining: map[string]bool{"node-a": true},
+		Pins:     map[string]string{"ch-pinned": "node-a", "ch-dead": "node-x"},
+	}
+
+	// Pin a un nodo drenando o muerto se ignora
+	assert.Equal(t, "node-b", topo.Owner("ch-pinned"))
+	assert.Equal(t, "node-b", topo.Owner("ch-dead"))
+	assert.Equal(t, "node-b", topo.Owner("ch-any"))
+
+	topo.Draining = nil
+	assert.Equal(t, "node-a", topo.Owner("ch-pinned"))
+
+	topo.Draining = map[string]bool{"node-a": true, "node-b": true}
+	assert.Equal(t, "", topo.Owner("ch-any"))
+}
+
+func TestHeldError_WrapsErrLeaseHeld(t *testing.T) {
+	err := error(&HeldError{Lease: ChannelLease{ChannelID: "ch-1", NodeID: "node-b"}})
+	assert.True(t, errors.Is(err, ErrLeaseHeld))
+	assert.Contains(t, err.Error(), "node-b")
+}
@@ -0,0 +1,178 @@
+package common
+
+import (
+	"fmt"
+	"strings"
+)
+
+type PayloadPartType string
+
+const (
+	PayloadPartText     PayloadPartType = "text"
+	PayloadPartMedia    PayloadPartType = "media"
+	PayloadPartPoll     PayloadPartType = "poll"
+	PayloadPartLocation PayloadPartType = "location"
+	PayloadPartLink     PayloadPartType = "link"
+	PayloadPartAI       PayloadPartType = "ai" // Generated by the bot when due
+)
+
+// ScheduledPayload is the typed content of a scheduled post: an ordered sequence of parts
+// sent one after the other on every occurrence.
+type ScheduledPayload struct {
+	Parts []ScheduledPayloadPart `json:"parts"`
+}
+
+// ScheduledPayloadPart is a single message of a scheduled payload. Only the fields of its Type are used.
+type ScheduledPayloadPart struct {
+	Type PayloadPartType `json:"type"`
+	Text string          `json:"text,omitempty"` // Body for text parts, caption for media and link parts
+
+	// Media
+	MediaPath string    `json:"media_path,omitempty"`
+	MediaType MediaType `json:"media_type,omitempty"`
+	FileName  string    `json:"file_name,omitempty"`
+	MimeType  string    `json:"mime_type,omitempty"`
+
+	Poll     *ScheduledPoll     `json:"poll,omitempty"`
+	Location *ScheduledLocation `json:"location,omitempty"`
+	Link     *ScheduledLink     `json:"link,omitempty"`
+	AI       *ScheduledAITask   `json:"ai,omitempty"`
+
+	// Optional parts do not abort the rest of the sequence when they fail
+	Optional bool `json:"optional,omitempty"`
+}
+
+type ScheduledPoll struct {
+	Question      string   `json:"question"`
+	Options       []string `json:"options"`
+	MaxSelections int      `json:"max_selections,omitempty"`
+}
+
+type ScheduledLocation struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	Address   string  `json:"address,omitempty"`
+}
+
+type ScheduledLink struct {
+	URL         string `json:"url"`
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// ScheduledAITask is a proactive bot run: when the occurrence is due the bot receives the
+// instruction with the target chat's client context and its reply is what gets sent.
+type ScheduledAITask struct {
+	Instruction string `json:"instruction"`
+	BotID       string `json:"bot_id,omitempty"`    // Empty = the bot resolved for the chat when the task runs
+	ClientID    string `json:"client_id,omitempty"` // Client the AI cost is charged to
+	Origin      string `json:"origin,omitempty"`    // Empty = requested by the user; AITaskOriginOffHours = deferred reply
+}
+
+// AITaskOriginOffHours marks tasks created to answer messages received outside business hours
+const AITaskOriginOffHours = "off_hours"
+
+const (
+	maxScheduledPayloadParts = 10
+	maxAIInstructionLength   = 2000
+)
+
+// Validate checks that every part carries the fields its type needs.
+func (p ScheduledPayload) Validate() error {
+	if len(p.Parts) == 0 {
+		return fmt.Errorf("payload has no parts")
+	}
+	if len(p.Parts) > maxScheduledPayloadParts {
+		return fmt.Errorf("payload has %d parts, maximum is %d", len(p.Parts), maxScheduledPayloadParts)
+	}
+	for i, part := range p.Parts {
+		if err := part.Validate(); err != nil {
+			return fmt.Errorf("part %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+func (part ScheduledPayloadPart) Validate() error {
+	switch part.Type {
+	case PayloadPartText:
+		if strings.TrimSpace(part.Text) == "" {
+			return fmt.Errorf("text part requires text")
+		}
+	case PayloadPartMedia:
+		if part.MediaPath == "" {
+			return fmt.Errorf("media part requires media_path")
+		}
+		switch part.MediaType {
+		case "", MediaTypeImage, MediaTypeVideo, MediaTypeAudio, MediaTypeDocument, MediaTypeSticker:
+		default:
+			return fmt.Errorf("unsupported media_type %q", part.MediaType)
+		}
+	case PayloadPartPoll:
+		if part.Poll == nil || strings.TrimSpace(part.Poll.Question) == "" {
+			return fmt.Errorf("poll part requires a question")
+		}
+		if len(part.Poll.Options) < 2 || len(part.Poll.Options) > 12 {
+			return fmt.Errorf("poll requires between 2 and 12 options")
+		}
+		if part.Poll.MaxSelections < 0 || part.Poll.MaxSelections > len(part.Poll.Options) {
+			return fmt.Errorf("poll max_selections must be between 0 and the number of options")
+		}
+	case PayloadPartLocation:
+		if part.Location == nil {
+			return fmt.Errorf("location part requires a location")
+		}
+		if part.Location.Latitude < -90 || part.Location.Latitude > 90 || part.Location.Longitude < -180 || part.Location.Longitude > 180 {
+			return fmt.Errorf("location coordinates out of range")
+		}
+	case PayloadPartLink:
+		if part.Link == nil || !strings.HasPrefix(part.Link.URL, "http") {
+			return fmt.Errorf("link part requires an http(s) url")
+		}
+	case PayloadPartAI:
+		if part.AI == nil || strings.TrimSpace(part.AI.Instruction) == "" {
+			return fmt.Errorf("ai part requires an instruction")
+		}
+		if len(part.AI.Instruction) > maxAIInstructionLength {
+			return fmt.Errorf("ai instruction is longer than %d characters", maxAIInstructionLength)
+		}
+	default:
+		return fmt.Errorf("unknown part type %q", part.Type)
+	}
+	return nil
+}
+
+// HasAITask reports whether any part is generated by the bot.
+func (p ScheduledPayload) HasAITask() bool {
+	for _, part := range p.Parts {
+		if part.Type == PayloadPartAI {
+			return true
+		}
+	}
+	return false
+}
+
+// Label is a short description of the part for logs and execution history.
+func (part ScheduledPayloadPart) Label() string {
+	if part.Type == PayloadPartMedia && part.MediaType != "" {
+		return string(part.MediaType)
+	}
+	return string(part.Type)
+}
+
+// Parts returns what the post sends on each occurrence. Posts created before typed payloads
+// existed are mapped from Text/MediaPath.
+func (p ScheduledPost) Parts() []ScheduledPayloadPart {
+	if p.Payload != nil && len(p.Payload.Parts) > 0 {
+		return p.Payload.Parts
+	}
+	if p.MediaPath != "" {
+		return []ScheduledPayloadPart{{Type: PayloadPartMedia, Text: p.Text, MediaPath: p.MediaPath, MediaType: p.MediaType}}
+	}
+	return []ScheduledPayloadPart{{Type: PayloadPartText, Text: p.Text}}
+}
+
+// IsAITask reports whether the post asks the bot to generate (part of) what is sent.
+func (p ScheduledPost) IsAITask() bool {
+	return p.Payload != nil && p.Payload.HasAITask()
+}
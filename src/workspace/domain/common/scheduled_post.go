@@ -1,6 +1,11 @@
 package common
 
-import "time"
+import (
+	"fmt"
+	"time"
+
+	"github.com/AzielCF/az-wap/core/pkg/timeutils"
+)
 
 type ScheduledPostStatus string
 
@@ -29,4 +34,115 @@ type ScheduledPost struct {
 	ExecutionCount int                 `json:"execution_count"`           // Number of times executed
 	CreatedAt      time.Time           `json:"created_at"`
 	UpdatedAt      time.Time           `json:"updated_at"`
+
+	// Lease: the scheduler node that claimed the post and until when it holds it
+	LeaseOwner string     `json:"lease_owner,omitempty"`
+	LeaseUntil *time.Time `json:"lease_until,omitempty"`
+
+	// Recurrence (RFC 5545). RRule takes precedence over the legacy RecurrenceDays/OriginalTime pair
+	RRule        string     `json:"rrule,omitempty"`
+	Timezone     string     `json:"timezone,omitempty"`      // IANA zone the rule is evaluated in
+	DTStart      *time.Time `json:"dtstart,omitempty"`       // Series anchor (first requested occurrence)
+	OccurrenceAt *time.Time `json:"occurrence_at,omitempty"` // Nominal time of the pending occurrence (differs from ScheduledAt when snoozed)
+
+	// Typed content. When nil the post sends Text (and MediaPath) as before
+	Payload *ScheduledPayload `json:"payload,omitempty"`
+}
+
+// IsRecurring reports whether the post re-arms after each execution.
+func (p ScheduledPost) IsRecurring() bool {
+	return p.RRule != "" || (p.RecurrenceDays != "" && p.OriginalTime != "")
+}
+
+// Nominal returns the occurrence the post currently stands for, ignoring any snooze.
+func (p ScheduledPost) Nominal() time.Time {
+	if p.OccurrenceAt != nil {
+		return *p.OccurrenceAt
+	}
+	return p.ScheduledAt
+}
+
+// Location returns the timezone the recurrence is evaluated in (UTC for legacy posts).
+func (p ScheduledPost) Location() *time.Location {
+	if p.Timezone == "" {
+		return time.UTC
+	}
+	loc, err := time.LoadLocation(p.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// Rule returns the recurrence rule, converting legacy weekday lists on the fly.
+func (p ScheduledPost) Rule() (timeutils.RRule, error) {
+	raw := p.RRule
+	if raw == "" {
+		if !p.IsRecurring() {
+			return timeutils.RRule{}, fmt.Errorf("scheduled post %s is not recurring", p.ID)
+		}
+		legacy, err := timeutils.LegacyDaysToRRule(p.RecurrenceDays)
+		if err != nil {
+			return timeutils.RRule{}, err
+		}
+		raw = legacy
+	}
+	return timeutils.ParseRRule(raw)
+}
+
+// NextOccurrence returns the first occurrence of the series strictly after 'after'.
+// ok is false once the series has ended (COUNT/UNTIL).
+func (p ScheduledPost) NextOccurrence(after time.Time) (time.Time, bool, error) {
+	rule, err := p.Rule()
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	dtstart := p.Nominal()
+	if p.DTStart != nil {
+		dtstart = *p.DTStart
+	}
+	next, ok := rule.Next(dtstart, after, p.Location())
+	return next, ok, nil
+}
+
+// Rearm moves a recurring post to its next occurrence after both its nominal time and now,
+// leaving it pending and unleased. Occurrences missed while the node was down are not replayed.
+// Returns false when the series has ended.
+func (p *ScheduledPost) Rearm(now time.Time) (bool, error) {
+	after := p.Nominal()
+	if now.After(after) {
+		after = now
+	}
+	next, ok, err := p.NextOccurrence(after)
+	if err != nil || !ok {
+		return false, err
+	}
+	p.ScheduledAt = next.UTC()
+	occ := p.ScheduledAt
+	p.OccurrenceAt = &occ
+	p.Status = ScheduledPostStatusPending
+	p.LeaseOwner = ""
+	p.LeaseUntil = nil
+	p.UpdatedAt = now
+	return true, nil
+}
+
+type ScheduledPostExecutionStatus string
+
+const (
+	ScheduledPostExecutionSent    ScheduledPostExecutionStatus = "sent"
+	ScheduledPostExecutionFailed  ScheduledPostExecutionStatus = "failed"
+	ScheduledPostExecutionSkipped ScheduledPostExecutionStatus = "skipped"
+	ScheduledPostExecutionPartial ScheduledPostExecutionStatus = "partial" // Only optional parts failed
+)
+
+// ScheduledPostExecution is a history row for one occurrence of a scheduled post.
+type ScheduledPostExecution struct {
+	ID           string                       `json:"id"`
+	PostID       string                       `json:"post_id"`
+	ChannelID    string                       `json:"channel_id"`
+	OccurrenceAt time.Time                    `json:"occurrence_at"` // Nominal time of the occurrence
+	ExecutedAt   time.Time                    `json:"executed_at"`
+	Status       ScheduledPostExecutionStatus `json:"status"`
+	Error        string                       `json:"error,omitempty"`
 }
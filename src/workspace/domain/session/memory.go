@@ -34,11 +34,44 @@ func (sm *SessionMemory) AddFullTurn(turn botengineDomain.ChatTurn, limit int) {
 		limit = 10
 	}
 
-	if limit > 0 && len(sm.History) > limit {
-		sm.History = sm.History[len(sm.History)-limit:]
+	// El turno fijo de resumen no cuenta para el límite y nunca se recorta
+	pinned := sm.pinnedCount()
+	if limit > 0 && len(sm.History)-pinned > limit {
+		sm.History = append(sm.History[:pinned:pinned], sm.History[len(sm.History)-limit:]...)
 	}
 }
 
+// ApplySummary reemplaza los turnos condensados por el motor con el turno fijo de resumen.
+// Los turnos que llegaron después del resumen se conservan.
+func (sm *SessionMemory) ApplySummary(sum *botengineDomain.HistorySummary) {
+	if sum == nil || sum.Text == "" {
+		return
+	}
+	rest := sm.History[sm.pinnedCount():]
+	condensed := sum.Condensed
+	if condensed > len(rest) {
+		condensed = len(rest)
+	}
+	history := make([]botengineDomain.ChatTurn, 0, len(rest)-condensed+1)
+	history = append(history, botengineDomain.SummaryTurn(sum.Text))
+	sm.History = append(history, rest[condensed:]...)
+}
+
+// Summary devuelve el resumen de la conversación anterior ("" si no hay)
+func (sm *SessionMemory) Summary() string {
+	if sm.pinnedCount() == 0 {
+		return ""
+	}
+	return botengineDomain.SummaryText(sm.History[0])
+}
+
+func (sm *SessionMemory) pinnedCount() int {
+	if len(sm.History) > 0 && sm.History[0].Pinned {
+		return 1
+	}
+	return 0
+}
+
 // GetHistory devuelve el historial actual de la conversación.
 func (sm *SessionMemory) GetHistory() []botengineDomain.ChatTurn {
 	return sm.History
@@ -0,0 +1,51 @@
+package session
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	botengineDomain "github.com/AzielCF/az-wap/botengine/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSessionMemory_SummaryKeepsToolPairsAndSurvivesSerialization(t *testing.T) {
+	long := strings.Repeat("detalle ", 50) // ~100 tokens por turno
+	var sm SessionMemory
+	for i := 0; i < 6; i++ {
+		sm.AddTurn("user", long, -1)
+		sm.AddTurn("assistant", long, -1)
+	}
+	// Par de herramienta justo donde caería el corte
+	sm.AddFullTurn(botengineDomain.ChatTurn{Role: "assistant", ToolCalls: []botengineDomain.ToolCall{{ID: "1", Name: "get_order"}}}, -1)
+	sm.AddFullTurn(botengineDomain.ChatTurn{Role: "user", ToolResponses: []botengineDomain.ToolResponse{{ID: "1", Name: "get_order", Data: map[string]any{"status": "sent"}}}}, -1)
+	sm.AddTurn("assistant", long, -1)
+	sm.AddTurn("user", "ok", -1)
+
+	history := sm.GetHistory()
+	n := botengineDomain.SummarySplit(history, 500)
+	assert.Greater(t, n, 0)
+	// El primer turno conservado nunca es una respuesta de herramienta suelta
+	assert.Empty(t, history[n].ToolResponses)
+
+	kept := len(history) - n
+	sm.AddTurn("assistant", "nuevo", -1) // Llega mientras se resumía
+	sm.ApplySummary(&botengineDomain.HistorySummary{Text: "- pidió el pedido 42", Condensed: n})
+
+	assert.Len(t, sm.History, kept+2)
+	assert.True(t, sm.History[0].Pinned)
+	assert.Equal(t, "- pidió el pedido 42", sm.Summary())
+	assert.Equal(t, "nuevo", sm.History[len(sm.History)-1].Text)
+
+	// El recorte por límite nunca elimina el resumen
+	sm.AddTurn("user", "otro", 2)
+	assert.Len(t, sm.History, 3)
+	assert.True(t, sm.History[0].Pinned)
+
+	// El resumen sobrevive a la serialización de la sesión (Valkey)
+	data, err := json.Marshal(&SessionEntry{Memory: sm})
+	assert.NoError(t, err)
+	var restored SessionEntry
+	assert.NoError(t, json.Unmarshal(data, &restored))
+	assert.Equal(t, "- pidió el pedido 42", restored.Memory.Summary())
+}
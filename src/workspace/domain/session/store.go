@@ -132,3 +132,16 @@ type SessionStore interface {
 	// Si la implementación no soporta updates parciales, debe hacer Get+Save.
 	UpdateField(ctx context.Context, key string, field string, value any) error
 }
+
+// ChatData es lo que una sesión viva guarda de un chat (exportación de datos personales)
+type ChatData struct {
+	ChannelID   string                     `json:"channel_id"`
+	WorkspaceID string                     `json:"workspace_id"`
+	ChatID      string                     `json:"chat_id"`
+	SenderID    string                     `json:"sender_id"`
+	BotID       string                     `json:"bot_id,omitempty"`
+	PendingText []string                   `json:"pending_text,omitempty"`
+	History     []botengineDomain.ChatTurn `json:"history,omitempty"`
+	Files       []string                   `json:"files,omitempty"` // Rutas locales de los archivos descargados
+	LastSeen    time.Time                  `json:"last_seen"`
+}
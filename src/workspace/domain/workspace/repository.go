@@ -64,4 +64,26 @@ type IWorkspaceRepository interface {
 	UpdateScheduledPost(ctx context.Context, post common.ScheduledPost) error
 	DeleteScheduledPost(ctx context.Context, id string) error
 	CountPendingScheduledPosts(ctx context.Context) (int64, error)
+
+	// Scheduled Post execution history
+	CreateScheduledPostExecution(ctx context.Context, exec common.ScheduledPostExecution) error
+	ListScheduledPostExecutions(ctx context.Context, postID string, limit int) ([]common.ScheduledPostExecution, error)
+}
+
+// IScheduledPostLeaser reserves due scheduled posts for a single node (store-agnostic scheduler)
+type IScheduledPostLeaser interface {
+	ClaimDueScheduledPosts(ctx context.Context, owner string, now, leaseUntil time.Time, limit int) ([]common.ScheduledPost, error)
+	ClaimScheduledPost(ctx context.Context, id, owner string, now, leaseUntil time.Time) (common.ScheduledPost, bool, error)
+	NextScheduledPostAt(ctx context.Context, now time.Time) (time.Time, error)
+	// RenewScheduledPostLease extends a lease still held by owner; false if another node took the post
+	RenewScheduledPostLease(ctx context.Context, id, owner string, leaseUntil time.Time) (common.ScheduledPost, bool, error)
+	// UpdateUnclaimedScheduledPostPayload replaces the payload only while the post is pending/enqueued
+	// and not leased; false if a node already claimed it
+	UpdateUnclaimedScheduledPostPayload(ctx context.Context, id string, payload *common.ScheduledPayload, now time.Time) (bool, error)
+}
+
+// ISchedulerRepository is the store used by the workspace manager and its task scheduler
+type ISchedulerRepository interface {
+	IWorkspaceRepository
+	IScheduledPostLeaser
 }
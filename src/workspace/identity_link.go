@@ -0,0 +1,82 @@
+package workspace
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	clientDomain "github.com/AzielCF/az-wap/clients/domain"
+	"github.com/AzielCF/az-wap/core/pkg/utils"
+	channelDomain "github.com/AzielCF/az-wap/workspace/domain/channel"
+	messageDomain "github.com/AzielCF/az-wap/workspace/domain/message"
+	"github.com/sirupsen/logrus"
+)
+
+var linkReplies = map[string]map[string]string{
+	"en": {
+		"linked":  "Done! This account is now linked to your profile (%s).",
+		"invalid": "That link code is invalid or has expired. Ask for a new one from your linked account.",
+		"taken":   "This account is already linked to another profile. Please contact support to merge them.",
+	},
+	"es": {
+		"linked":  "¡Listo! Esta cuenta ya está vinculada a tu perfil (%s).",
+		"invalid": "El código de vinculación no es válido o expiró. Pide uno nuevo desde tu cuenta vinculada.",
+		"taken":   "Esta cuenta ya está vinculada a otro perfil. Contacta a soporte para unificarlas.",
+	},
+}
+
+// SetIdentityLinker habilita la vinculación de plataformas con "LINK <código>"
+func (m *Manager) SetIdentityLinker(linker IdentityLinker) {
+	m.identityLinker = linker
+}
+
+// senderIdentity devuelve el ID principal del remitente y el alternativo (JID o teléfono si el principal es un LID)
+func senderIdentity(msg messageDomain.IncomingMessage) (string, string) {
+	// Normalization for WhatsApp (JID and LID)
+	platformID := utils.CleanWhatsAppID(msg.SenderID)
+
+	// Backup JID (original from platform or phone number)
+	secondaryID := ""
+	if pn, ok := msg.Metadata["sender_pn"].(string); ok && pn != "" {
+		secondaryID = pn
+	} else if jid, ok := msg.Metadata["sender_jid"].(string); ok {
+		secondaryID = jid
+	}
+	return platformID, utils.CleanWhatsAppID(secondaryID)
+}
+
+// handleLinkCode canjea un código de vinculación enviado por el remitente y le responde.
+// Retorna true si el mensaje era un comando de vinculación (no debe llegar al bot).
+func (m *Manager) handleLinkCode(ctx context.Context, adapter channelDomain.ChannelAdapter, ch channelDomain.Channel, msg messageDomain.IncomingMessage) bool {
+	if m.identityLinker == nil || msg.Text == "" {
+		return false
+	}
+
+	platformID, secondaryID := senderIdentity(msg)
+	client, handled, err := m.identityLinker.RedeemLinkMessage(ctx, msg.Text, clientDomain.PlatformType(adapter.Type()), platformID, secondaryID)
+	if !handled {
+		return false
+	}
+
+	replies := linkReplies["en"]
+	if r, ok := linkReplies[ch.Config.DefaultLanguage]; ok {
+		replies = r
+	}
+	var text string
+	switch {
+	case err == nil:
+		text = fmt.Sprintf(replies["linked"], client.DisplayName)
+	case errors.Is(err, clientDomain.ErrIdentityLinked):
+		text = replies["taken"]
+	case errors.Is(err, clientDomain.ErrInvalidLinkCode):
+		text = replies["invalid"]
+	default:
+		logrus.WithError(err).Errorf("[WS_MANAGER] Failed to link identity %s on channel %s", platformID, ch.ID)
+		text = replies["invalid"]
+	}
+
+	if _, err := adapter.SendMessage(ctx, msg.ChatID, text, ""); err != nil {
+		logrus.WithError(err).Warnf("[WS_MANAGER] Failed to send link confirmation to %s", msg.ChatID)
+	}
+	return true
+}
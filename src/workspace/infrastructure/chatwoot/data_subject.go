@@ -0,0 +1,177 @@
+package chatwoot
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// maxExportBody limita la lectura de respuestas de exportación (jsonRequest solo lee 8 KB)
+const maxExportBody = 4 << 20
+
+// ContactExport es lo que Chatwoot guarda de un contacto: ficha, conversaciones y sus mensajes
+type ContactExport struct {
+	InstanceID    string           `json:"instance_id"`
+	ContactID     int64            `json:"contact_id"`
+	Contact       json.RawMessage  `json:"contact"`
+	Conversations []map[string]any `json:"conversations"`
+}
+
+// ExportContact devuelve los datos del contacto con ese teléfono en el Chatwoot del canal.
+// Retorna nil sin error si el canal no usa Chatwoot o el contacto no existe.
+func ExportContact(ctx context.Context, externalRef, phone string) (*ContactExport, error) {
+	cfg, contactID, err := lookupContact(ctx, externalRef, phone)
+	if err != nil || contactID == 0 {
+		return nil, err
+	}
+
+	out := &ContactExport{InstanceID: externalRef, ContactID: contactID}
+	base := fmt.Sprintf("%s/api/v1/accounts/%d", cfg.BaseURL, cfg.AccountID)
+
+	var contact struct {
+		Payload json.RawMessage `json:"payload"`
+	}
+	if err := exportRequest(ctx, cfg, fmt.Sprintf("%s/contacts/%d", base, contactID), &contact); err != nil {
+		return nil, fmt.Errorf("failed to fetch contact: %w", err)
+	}
+	out.Contact = contact.Payload
+
+	var convs struct {
+		Payload []map[string]any `json:"payload"`
+	}
+	if err := exportRequest(ctx, cfg, fmt.Sprintf("%s/contacts/%d/conversations", base, contactID), &convs); err != nil {
+		return nil, fmt.Errorf("failed to fetch conversations: %w", err)
+	}
+	for _, conv := range convs.Payload {
+		id, _ := conv["id"].(float64)
+		if id > 0 {
+			var msgs struct {
+				Payload []map[string]any `json:"payload"`
+			}
+			if err := exportRequest(ctx, cfg, fmt.Sprintf("%s/conversations/%d/messages", base, int64(id)), &msgs); err == nil {
+				conv["messages"] = msgs.Payload
+			}
+		}
+		out.Conversations = append(out.Conversations, conv)
+	}
+	return out, nil
+}
+
+// DeleteContact borra el contacto (y con él sus conversaciones) del Chatwoot del canal.
+// Retorna false si no había nada que borrar.
+func DeleteContact(ctx context.Context, externalRef, phone string) (bool, error) {
+	cfg, contactID, err := lookupContact(ctx, externalRef, phone)
+	if err != nil || contactID == 0 {
+		return false, err
+	}
+	url := fmt.Sprintf("%s/api/v1/accounts/%d/contacts/%d", cfg.BaseURL, cfg.AccountID, contactID)
+	if err := jsonRequestWithConfig(ctx, http.MethodDelete, url, cfg, nil, nil); err != nil {
+		return false, fmt.Errorf("failed to delete contact: %w", err)
+	}
+	forgetContact(externalRef, phone)
+	return true, nil
+}
+
+// HasContact indica si el contacto sigue existiendo (verificación tras el borrado)
+func HasContact(ctx context.Context, externalRef, phone string) (bool, error) {
+	_, contactID, err := lookupContact(ctx, externalRef, phone)
+	return contactID != 0, err
+}
+
+// ContactDirectory expone los contactos de Chatwoot a las solicitudes de datos personales
+// (ver clients/application.ContactDirectory)
+type ContactDirectory struct{}
+
+func (ContactDirectory) ExportContact(ctx context.Context, externalRef, phone string) (any, error) {
+	c, err := ExportContact(ctx, externalRef, phone)
+	if c == nil {
+		return nil, err
+	}
+	return c, err
+}
+
+func (ContactDirectory) DeleteContact(ctx context.Context, externalRef, phone string) (bool, error) {
+	return DeleteContact(ctx, externalRef, phone)
+}
+
+func (ContactDirectory) HasContact(ctx context.Context, externalRef, phone string) (bool, error) {
+	return HasContact(ctx, externalRef, phone)
+}
+
+func lookupContact(ctx context.Context, externalRef, phone string) (*Config, int64, error) {
+	phone = "+" + digitsOnly(phone)
+	if len(phone) < 2 {
+		return nil, 0, nil
+	}
+	cfg, err := loadChannelConfig(ctx, externalRef)
+	if err != nil || cfg == nil || !cfg.Enabled {
+		return nil, 0, err
+	}
+	id, _, err := findContactByPhone(ctx, cfg, phone)
+	if err != nil {
+		return nil, 0, fmt.Errorf("contact search failed: %w", err)
+	}
+	return cfg, id, nil
+}
+
+// forgetContact limpia las cachés de contacto y conversación de ese teléfono en la instancia
+func forgetContact(instance, phone string) {
+	digits := digitsOnly(phone)
+	match := func(key string) bool {
+		inst, p, ok := strings.Cut(key, "|")
+		return ok && inst == instance && digitsOnly(p) == digits
+	}
+
+	contactCacheMu.Lock()
+	for key := range contactCache {
+		if match(key) {
+			delete(contactCache, key)
+		}
+	}
+	contactCacheMu.Unlock()
+
+	conversationCacheMu.Lock()
+	for key := range conversationCache {
+		if match(key) {
+			delete(conversationCache, key)
+		}
+	}
+	conversationCacheMu.Unlock()
+}
+
+func exportRequest(ctx context.Context, cfg *Config, url string, dest interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("api_access_token", cfg.AccountToken)
+
+	client := httpClient
+	if cfg.InsecureSkipVerify {
+		client = insecureHttpClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, maxExportBody))
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("request failed: status=%d", resp.StatusCode)
+	}
+	return json.Unmarshal(data, dest)
+}
+
+func digitsOnly(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,98 @@
+package rest
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/AzielCF/az-wap/core/pkg/crypto"
+	"github.com/AzielCF/az-wap/workspace"
+	"github.com/AzielCF/az-wap/workspace/domain/cluster"
+	"github.com/AzielCF/az-wap/workspace/domain/common"
+	"github.com/AzielCF/az-wap/workspace/infrastructure/whatsapp"
+	"github.com/AzielCF/az-wap/workspace/usecase"
+	"github.com/gofiber/fiber/v2"
+)
+
+type ChannelBundleHandler struct {
+	svc *usecase.ChannelBundleService
+	uc  *usecase.WorkspaceUsecase
+}
+
+// InitRestChannelBundle registra la exportación e importación de canales de WhatsApp vinculados
+func InitRestChannelBundle(app fiber.Router, svc *usecase.ChannelBundleService, uc *usecase.WorkspaceUsecase) ChannelBundleHandler {
+	handler := ChannelBundleHandler{svc: svc, uc: uc}
+
+	g := app.Group("/workspaces/:id/channels")
+	g.Post("/import", handler.ImportChannel)
+	g.Post("/:cid/export", handler.ExportChannel)
+
+	return handler
+}
+
+func (h *ChannelBundleHandler) ExportChannel(c *fiber.Ctx) error {
+	var req struct {
+		Passphrase string `json:"passphrase"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid body"})
+	}
+
+	cid := c.Params("cid")
+	ch, err := h.uc.GetChannel(c.UserContext(), cid)
+	if err != nil || ch.WorkspaceID != c.Params("id") {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "channel not found"})
+	}
+
+	data, err := h.svc.Export(c.UserContext(), cid, req.Passphrase)
+	if err != nil {
+		return bundleError(c, err)
+	}
+
+	c.Set(fiber.HeaderContentType, fiber.MIMEOctetStream)
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="channel-%s.azbundle"`, cid))
+	return c.Send(data)
+}
+
+// ImportChannel recibe el bundle como multipart (campo "file") junto a "passphrase" y "overwrite".
+func (h *ChannelBundleHandler) ImportChannel(c *fiber.Ctx) error {
+	fh, err := c.FormFile("file")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "file is required"})
+	}
+	f, err := fh.Open()
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	defer f.Close()
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	res, err := h.svc.Import(c.UserContext(), data, c.FormValue("passphrase"), usecase.ImportBundleOptions{
+		WorkspaceID: c.Params("id"),
+		Overwrite:   c.FormValue("overwrite") == "true",
+	})
+	if err != nil {
+		return bundleError(c, err)
+	}
+	return c.Status(fiber.StatusCreated).JSON(res)
+}
+
+func bundleError(c *fiber.Ctx, err error) error {
+	status := fiber.StatusInternalServerError
+	switch {
+	case errors.Is(err, common.ErrChannelNotFound), errors.Is(err, common.ErrWorkspaceNotFound):
+		status = fiber.StatusNotFound
+	case errors.Is(err, workspace.ErrChannelRunning), errors.Is(err, workspace.ErrChannelReserved),
+		errors.Is(err, cluster.ErrLeaseHeld), errors.Is(err, common.ErrDuplicateChannel),
+		errors.Is(err, whatsapp.ErrDeviceExists):
+		status = fiber.StatusConflict
+	case errors.Is(err, crypto.ErrBadPassphrase), errors.Is(err, whatsapp.ErrDeviceNotLinked),
+		errors.Is(err, usecase.ErrUnsupportedBundleChannel), errors.Is(err, usecase.ErrWeakBundlePassphrase),
+		errors.Is(err, usecase.ErrInvalidBundle):
+		status = fiber.StatusBadRequest
+	}
+	return c.Status(status).JSON(fiber.Map{"error": err.Error()})
+}
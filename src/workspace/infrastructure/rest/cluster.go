@@ -0,0 +1,72 @@
+package rest
+
+import (
+	"errors"
+
+	"github.com/AzielCF/az-wap/workspace"
+	"github.com/AzielCF/az-wap/workspace/domain/cluster"
+	"github.com/AzielCF/az-wap/workspace/domain/common"
+	"github.com/gofiber/fiber/v2"
+)
+
+type ClusterHandler struct {
+	wm *workspace.Manager
+}
+
+// InitRestCluster registra la administración de propiedad de canales entre nodos
+func InitRestCluster(app fiber.Router, wm *workspace.Manager) ClusterHandler {
+	handler := ClusterHandler{wm: wm}
+
+	g := app.Group("/cluster")
+	g.Get("/channels", handler.ListChannels)
+	g.Post("/channels/:cid/move", handler.MoveChannel)
+	g.Post("/nodes/:id/drain", handler.DrainNode)
+	g.Delete("/nodes/:id/drain", handler.UndrainNode)
+
+	return handler
+}
+
+func (h *ClusterHandler) ListChannels(c *fiber.Ctx) error {
+	status, err := h.wm.ClusterStatus(c.UserContext())
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(status)
+}
+
+func (h *ClusterHandler) MoveChannel(c *fiber.Ctx) error {
+	var req struct {
+		NodeID string `json:"node_id"` // Vacío: quitar el pin
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid body"})
+	}
+
+	cid := c.Params("cid")
+	if err := h.wm.MoveChannel(c.UserContext(), cid, req.NodeID); err != nil {
+		switch {
+		case errors.Is(err, common.ErrChannelNotFound):
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "channel not found"})
+		case errors.Is(err, cluster.ErrNodeUnavailable):
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{"channel_id": cid, "pinned_to": req.NodeID})
+}
+
+func (h *ClusterHandler) DrainNode(c *fiber.Ctx) error {
+	return h.setDraining(c, true)
+}
+
+func (h *ClusterHandler) UndrainNode(c *fiber.Ctx) error {
+	return h.setDraining(c, false)
+}
+
+func (h *ClusterHandler) setDraining(c *fiber.Ctx, drain bool) error {
+	id := c.Params("id")
+	if err := h.wm.DrainNode(c.UserContext(), id, drain); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{"node_id": id, "draining": drain})
+}
@@ -2,7 +2,6 @@ package rest
 
 import (
 	"fmt"
-	"os"
 	"strings"
 
 	domainApp "github.com/AzielCF/az-wap/core/common/channel/app/domain"
@@ -12,6 +11,7 @@ import (
 	"github.com/AzielCF/az-wap/workspace/domain/common"
 	tgAdapter "github.com/AzielCF/az-wap/workspace/infrastructure/telegram"
 	tgDomain "github.com/AzielCF/az-wap/workspace/infrastructure/telegram/domain"
+	"github.com/AzielCF/az-wap/workspace/infrastructure/whatsapp"
 	"github.com/AzielCF/az-wap/workspace/usecase"
 	"github.com/gofiber/fiber/v2"
 	"github.com/sirupsen/logrus"
@@ -245,6 +245,9 @@ func (h *WorkspaceHandler) UpdateChannelConfig(c *fiber.Ctx) error {
 	if err := c.BodyParser(&cfg); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid body"})
 	}
+	if err := cfg.Validate(); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
 
 	// First get the channel to ensure it exists
 	ch, err := h.uc.GetChannel(c.Context(), cid)
@@ -295,6 +298,9 @@ func (h *WorkspaceHandler) UpdateChannel(c *fiber.Ctx) error {
 	if err := c.BodyParser(&req); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid body"})
 	}
+	if err := req.Config.Validate(); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
 
 	// First get the channel to ensure it exists
 	ch, err := h.uc.GetChannel(c.Context(), cid)
@@ -664,15 +670,9 @@ func (h *WorkspaceHandler) WhatsAppLogout(c *fiber.Ctx) error {
 			// Could not start channel, just cleanup files directly
 			logrus.Warnf("[REST] Could not start channel %s for logout, cleaning up files only: %v", cid, err)
 
-			// Cleanup WhatsApp storage files
-			waDBPath := fmt.Sprintf("storages/whatsapp-%s.db", cid)
-			waFiles := []string{waDBPath, waDBPath + "-shm", waDBPath + "-wal"}
-			for _, f := range waFiles {
-				if err := os.Remove(f); err != nil && !os.IsNotExist(err) {
-					logrus.Warnf("[REST] Failed to remove %s: %v", f, err)
-				} else if err == nil {
-					logrus.Infof("[REST] Removed %s", f)
-				}
+			// Cleanup WhatsApp device keys (SQLite file or shared Postgres rows)
+			if err := whatsapp.Devices().Delete(c.Context(), cid); err != nil {
+				logrus.Warnf("[REST] Failed to remove device keys for %s: %v", cid, err)
 			}
 
 			// Cleanup ChatStorage files discontinued
@@ -2,8 +2,11 @@ package simulator
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
+	"sync"
 
+	botengineDomain "github.com/AzielCF/az-wap/botengine/domain"
 	"github.com/gofiber/websocket/v2"
 	"github.com/sirupsen/logrus"
 )
@@ -11,6 +14,7 @@ import (
 type Transport struct {
 	instanceID string
 	conn       *websocket.Conn
+	mu         sync.Mutex // Los deltas en streaming y las burbujas se escriben desde goroutines distintas
 }
 
 func NewTransport(instanceID string, conn *websocket.Conn) *Transport {
@@ -31,7 +35,7 @@ func (t *Transport) SendMessage(ctx context.Context, chatID string, text string,
 	}
 	data, _ := json.Marshal(msg)
 	logrus.Infof("[SimulatorTransport] Sending message: %s", text)
-	return t.conn.WriteMessage(websocket.TextMessage, data)
+	return t.write(data)
 }
 
 func (t *Transport) SendPresence(ctx context.Context, chatID string, isTyping bool, isAudio bool) error {
@@ -40,7 +44,7 @@ func (t *Transport) SendPresence(ctx context.Context, chatID string, isTyping bo
 		"is_typing": isTyping,
 	}
 	data, _ := json.Marshal(msg)
-	return t.conn.WriteMessage(websocket.TextMessage, data)
+	return t.write(data)
 }
 
 func (t *Transport) MarkRead(ctx context.Context, chatID string, messageIDs []string) error {
@@ -48,5 +52,36 @@ func (t *Transport) MarkRead(ctx context.Context, chatID string, messageIDs []st
 		"type": "read",
 	}
 	data, _ := json.Marshal(msg)
+	return t.write(data)
+}
+
+func (t *Transport) SendMedia(ctx context.Context, chatID string, media *botengineDomain.BotMedia, caption string) error {
+	msg := map[string]interface{}{
+		"type":      "media",
+		"mime_type": media.MimeType,
+		"file_name": media.FileName,
+		"caption":   caption,
+		"data":      base64.StdEncoding.EncodeToString(media.Data),
+	}
+	data, _ := json.Marshal(msg)
+	logrus.Infof("[SimulatorTransport] Sending media: %s (%s)", media.FileName, media.MimeType)
+	return t.write(data)
+}
+
+// SendDelta implementa domain.StreamTransport: el simulador muestra los tokens mientras la IA escribe.
+// final=true cierra el borrador; las burbujas definitivas siguen llegando como "message".
+func (t *Transport) SendDelta(ctx context.Context, chatID string, delta string, final bool) error {
+	msg := map[string]interface{}{
+		"type":  "delta",
+		"text":  delta,
+		"final": final,
+	}
+	data, _ := json.Marshal(msg)
+	return t.write(data)
+}
+
+func (t *Transport) write(data []byte) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
 	return t.conn.WriteMessage(websocket.TextMessage, data)
 }
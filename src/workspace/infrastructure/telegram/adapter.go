@@ -235,6 +235,12 @@ func (ta *TelegramAdapter) UpdateConfig(config channel.ChannelConfig) {
 
 // Mensajería (Solo delegación)
 func (ta *TelegramAdapter) SendMessage(ctx context.Context, chatID, text, quoteID string) (common.SendResponse, error) {
+	// Modo cluster: solo el dueño del lease del canal envía
+	if ta.manager != nil {
+		if err := ta.manager.CheckChannelFence(ctx, ta.channelID); err != nil {
+			return common.SendResponse{}, err
+		}
+	}
 	msgID, err := ta.service.SendMessage(ctx, chatID, text)
 	if err != nil {
 		return common.SendResponse{}, err
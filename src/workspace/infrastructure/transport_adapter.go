@@ -2,8 +2,12 @@ package infrastructure
 
 import (
 	"context"
+	"strings"
 
+	botengineDomain "github.com/AzielCF/az-wap/botengine/domain"
+	pkgUtils "github.com/AzielCF/az-wap/core/pkg/utils"
 	"github.com/AzielCF/az-wap/workspace/domain/channel"
+	"github.com/AzielCF/az-wap/workspace/domain/common"
 )
 
 // BotTransportAdapter puentea ChannelAdapter a botengine.Transport
@@ -27,3 +31,41 @@ func (a *BotTransportAdapter) SendPresence(ctx context.Context, chatID string, i
 func (a *BotTransportAdapter) MarkRead(ctx context.Context, chatID string, messageIDs []string) error {
 	return a.Adapter.MarkRead(ctx, chatID, messageIDs)
 }
+
+// SendMedia reenvía un archivo generado por una herramienta usando el adaptador del canal.
+func (a *BotTransportAdapter) SendMedia(ctx context.Context, chatID string, media *botengineDomain.BotMedia, caption string) error {
+	_, err := a.Adapter.SendMedia(ctx, chatID, common.MediaUpload{
+		Data:     media.Data,
+		FileName: media.FileName,
+		MimeType: media.MimeType,
+		Caption:  caption,
+		Type:     mediaTypeFromMime(media.MimeType),
+	}, "")
+	return err
+}
+
+// SendVoice envía audio sintetizado como nota de voz (PTT), convirtiéndolo a OGG/Opus si hace falta.
+func (a *BotTransportAdapter) SendVoice(ctx context.Context, chatID string, audio []byte, mimeType string) error {
+	data, mime, fileName := pkgUtils.ConvertToVoiceNote(audio, mimeType, "voice.ogg")
+	_, err := a.Adapter.SendMedia(ctx, chatID, common.MediaUpload{
+		Data:     data,
+		FileName: fileName,
+		MimeType: mime,
+		Type:     common.MediaTypeAudio,
+		PTT:      true,
+	}, "")
+	return err
+}
+
+func mediaTypeFromMime(mimeType string) common.MediaType {
+	switch {
+	case strings.HasPrefix(mimeType, "image/"):
+		return common.MediaTypeImage
+	case strings.HasPrefix(mimeType, "video/"):
+		return common.MediaTypeVideo
+	case strings.HasPrefix(mimeType, "audio/"):
+		return common.MediaTypeAudio
+	default:
+		return common.MediaTypeDocument
+	}
+}
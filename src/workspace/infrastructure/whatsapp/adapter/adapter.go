@@ -21,7 +21,7 @@ type WhatsAppAdapter struct {
 	workspaceID  string
 	sessionID    string // Optional: for legacy migration persistence
 	client       *whatsmeow.Client
-	dbContainer  interface{ Close() error } // sqlstore.Container for cleanup
+	ownsDevice   bool // Client created from whatsapp.Devices() (not injected)
 	repoMu       sync.Mutex
 	eventHandler func(message.IncomingMessage)
 	handlerID    uint32
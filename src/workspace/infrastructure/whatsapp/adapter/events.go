@@ -12,6 +12,7 @@ import (
 	pkgUtils "github.com/AzielCF/az-wap/core/pkg/utils"
 	"github.com/AzielCF/az-wap/workspace/domain/channel"
 	"github.com/AzielCF/az-wap/workspace/domain/message"
+	"github.com/AzielCF/az-wap/workspace/infrastructure/whatsapp"
 	waUtils "github.com/AzielCF/az-wap/workspace/infrastructure/whatsapp/adapter/utils"
 	"github.com/sirupsen/logrus"
 	"go.mau.fi/whatsmeow"
@@ -57,6 +58,13 @@ func (wa *WhatsAppAdapter) handleEvent(evt interface{}) {
 		wa.currentQR = ""
 		wa.qrMu.Unlock()
 
+		// Remember which device belongs to the channel (Postgres shares one key store across channels)
+		if wa.ownsDevice && wa.client != nil && wa.client.Store.ID != nil {
+			if err := whatsapp.Devices().Bind(context.Background(), wa.channelID, *wa.client.Store.ID); err != nil {
+				logrus.WithError(err).Warnf("[WHATSAPP] Failed to bind device to channel %s", wa.channelID)
+			}
+		}
+
 		// RECOVERY: Resubscribe to all active presence events
 		if wa.manager != nil {
 			activeChats := wa.manager.GetActiveChats(wa.channelID)
@@ -13,7 +13,6 @@ import (
 	"go.mau.fi/whatsmeow"
 	"go.mau.fi/whatsmeow/proto/waCompanionReg"
 	"go.mau.fi/whatsmeow/store"
-	"go.mau.fi/whatsmeow/store/sqlstore"
 	"go.mau.fi/whatsmeow/types"
 	waLog "go.mau.fi/whatsmeow/util/log"
 )
@@ -71,30 +70,16 @@ func (wa *WhatsAppAdapter) Start(ctx context.Context, config channel.ChannelConf
 	}
 
 	// 2. Si NO tenemos cliente, inicializamos uno nuevo (Modo Autónomo / Workspace Nativo)
-	// Usar siempre el ID del canal para consistencia entre whatsapp-{id}.db y chat-{id}.db
-	dbKey := wa.channelID
-
-	// Ensure storage directory exists
-	if err := os.MkdirAll("storages", 0755); err != nil {
-		return fmt.Errorf("failed to create storage dir: %w", err)
-	}
-
-	dbPath := fmt.Sprintf("storages/whatsapp-%s.db?_foreign_keys=on", dbKey)
-	dbLog := waLog.Stdout("DB-"+dbKey[:8], coreconfig.Global.Whatsapp.LogLevel, true)
-
-	container, err := sqlstore.New(ctx, "sqlite3", "file:"+dbPath, dbLog)
-	if err != nil {
-		return fmt.Errorf("failed to init channel db: %w", err)
-	}
-
-	device, err := container.GetFirstDevice(ctx)
+	// El almacén de dispositivos (SQLite por canal o Postgres compartido) se elige con DB_KEYS_URI
+	device, err := whatsapp.Devices().Open(ctx, wa.channelID)
 	if err != nil {
-		return fmt.Errorf("failed to get device: %w", err)
+		return err
 	}
-
-	// Si es login nuevo
-	if device == nil {
-		device = container.NewDevice()
+	// En modo cluster las claves solo se escriben mientras este nodo tenga el lease del canal
+	if wa.manager != nil {
+		whatsapp.FenceDevice(device, func(ctx context.Context) error {
+			return wa.manager.CheckChannelFence(ctx, wa.channelID)
+		})
 	}
 
 	// Configurar props del dispositivo desde variables de entorno
@@ -118,7 +103,7 @@ func (wa *WhatsAppAdapter) Start(ctx context.Context, config channel.ChannelConf
 	wa.client = whatsmeow.NewClient(device, whatsapp.NewFilteredLogger(clientLog))
 	wa.client.EnableAutoReconnect = config.AutoReconnect
 	wa.client.AutoTrustIdentity = true
-	wa.dbContainer = container // Store reference for cleanup
+	wa.ownsDevice = true // Device from the shared store: released and deleted through it
 
 	// Event handler will be registered via RegisterAdapter -> OnMessage
 	// wa.handlerID = wa.client.AddEventHandler(wa.handleEvent)
@@ -166,8 +151,17 @@ func (wa *WhatsAppAdapter) SetOnline(ctx context.Context, online bool) error {
 	return nil // Don't return error to caller to avoid cascading failures
 }
 
-// Stop removes the event handler
+// Stop removes the event handler, closes the socket and releases the channel's device store,
+// so another node can take the channel over.
 func (wa *WhatsAppAdapter) Stop(ctx context.Context) error {
+	wa.connMu.Lock()
+	defer wa.connMu.Unlock()
+
+	wa.stopLocked()
+	return nil
+}
+
+func (wa *WhatsAppAdapter) stopLocked() {
 	if wa.client != nil && wa.handlerID != 0 {
 		wa.client.RemoveEventHandler(wa.handlerID)
 		wa.handlerID = 0
@@ -179,53 +173,47 @@ func (wa *WhatsAppAdapter) Stop(ctx context.Context) error {
 		default:
 		}
 	}
-	return nil
+
+	if wa.client != nil && wa.ownsDevice {
+		if wa.client.IsConnected() {
+			wa.client.Disconnect()
+		}
+		wa.client = nil
+		if err := whatsapp.Devices().Release(wa.channelID); err != nil {
+			logrus.WithError(err).Warnf("[WHATSAPP] Failed to release device store for channel %s", wa.channelID)
+		}
+	}
 }
 
-// Cleanup removes all persistent data (WhatsApp DB and ChatStorage DB)
+// Cleanup removes all persistent data (device keys and ChatStorage DB)
 func (wa *WhatsAppAdapter) Cleanup(ctx context.Context) error {
 	// Acquire lock to ensure we don't cleanup while a connection attempt is in progress
 	wa.connMu.Lock()
 	defer wa.connMu.Unlock()
 
-	// 1. Stop the client first (removes event handlers, stops sync)
-	_ = wa.Stop(ctx)
+	// 1. Stop the client first (removes event handlers, stops sync, releases the device store)
+	wa.stopLocked()
 
-	// 2. Disconnect if connected
-	if wa.client != nil && wa.client.IsConnected() {
-		wa.client.Disconnect()
-	}
-
-	// 3. Close the SQLite container - CRITICAL for file deletion
-	if wa.dbContainer != nil {
-		_ = wa.dbContainer.Close()
-		wa.dbContainer = nil
-	} else if wa.client != nil && wa.client.Store != nil {
-		// Fallback: Try to close the container from the Store (legacy clients)
-		if container, ok := wa.client.Store.Container.(interface{ Close() error }); ok {
-			_ = container.Close()
+	// 2. Legacy clients (created outside the device store) close their own container
+	if wa.client != nil {
+		if wa.client.IsConnected() {
+			wa.client.Disconnect()
 		}
+		if wa.client.Store != nil {
+			if container, ok := wa.client.Store.Container.(interface{ Close() error }); ok {
+				_ = container.Close()
+			}
+		}
+		// Nullify the client to prevent any reconnection attempts
+		wa.client = nil
 	}
 
-	// Nullify the client to prevent any reconnection attempts
-	wa.client = nil
-
-	// Give SQLite time to release file locks
-	time.Sleep(500 * time.Millisecond)
-
-	// 4. Delete WhatsApp session database
-	dbKey := wa.channelID
-	waDBPath := fmt.Sprintf("storages/whatsapp-%s.db", dbKey)
-	waFiles := []string{waDBPath, waDBPath + "-shm", waDBPath + "-wal"}
-	for _, f := range waFiles {
-		if err := os.Remove(f); err != nil && !os.IsNotExist(err) {
-			logrus.Errorf("[WHATSAPP] Failed to remove %s: %v", f, err)
-		} else if err == nil {
-			logrus.Infof("[WHATSAPP] Removed %s", f)
-		}
+	// 3. Delete WhatsApp device keys (SQLite file or Postgres rows of this channel)
+	if err := whatsapp.Devices().Delete(ctx, wa.channelID); err != nil {
+		logrus.WithError(err).Errorf("[WHATSAPP] Failed to delete device keys for channel %s", wa.channelID)
 	}
 
-	// 5. ChatStorage cleanup discontinued
+	// 4. ChatStorage cleanup discontinued
 	// infraChatStorage.CleanupInstanceRepository(wa.channelID)
 
 	logrus.Infof("[WHATSAPP] Cleanup completed for channel %s", wa.channelID)
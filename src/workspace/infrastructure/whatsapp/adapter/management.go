@@ -164,7 +164,7 @@ func (wa *WhatsAppAdapter) ReactMessage(ctx context.Context, chatID, messageID,
 			SenderTimestampMS: proto.Int64(time.Now().UnixMilli()),
 		},
 	}
-	resp, err := cli.SendMessage(ctx, jid, msg)
+	resp, err := wa.sendMessage(ctx, cli, jid, msg)
 	if err != nil {
 		return "", err
 	}
@@ -180,7 +180,7 @@ func (wa *WhatsAppAdapter) RevokeMessage(ctx context.Context, chatID, messageID
 	if err != nil {
 		return "", err
 	}
-	resp, err := cli.SendMessage(ctx, jid, cli.BuildRevoke(jid, types.EmptyJID, messageID))
+	resp, err := wa.sendMessage(ctx, cli, jid, cli.BuildRevoke(jid, types.EmptyJID, messageID))
 	if err != nil {
 		return "", err
 	}
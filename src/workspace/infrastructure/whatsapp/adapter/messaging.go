@@ -13,6 +13,16 @@ import (
 	"google.golang.org/protobuf/proto"
 )
 
+// sendMessage sends through whatsmeow only while this node still owns the channel lease (cluster fencing)
+func (wa *WhatsAppAdapter) sendMessage(ctx context.Context, cli *whatsmeow.Client, jid types.JID, msg *waE2E.Message) (whatsmeow.SendResponse, error) {
+	if wa.manager != nil {
+		if err := wa.manager.CheckChannelFence(ctx, wa.channelID); err != nil {
+			return whatsmeow.SendResponse{}, err
+		}
+	}
+	return cli.SendMessage(ctx, jid, msg)
+}
+
 // SendMessage sends a text message
 func (wa *WhatsAppAdapter) SendMessage(ctx context.Context, chatID, text, quoteMessageID string) (common.SendResponse, error) {
 	if err := wa.ensureConnected(ctx); err != nil {
@@ -43,7 +53,7 @@ func (wa *WhatsAppAdapter) SendMessage(ctx context.Context, chatID, text, quoteM
 		}
 	}
 
-	resp, err := cli.SendMessage(ctx, jid, msg)
+	resp, err := wa.sendMessage(ctx, cli, jid, msg)
 	if err != nil {
 		return common.SendResponse{}, err
 	}
@@ -182,7 +192,7 @@ func (wa *WhatsAppAdapter) SendMedia(ctx context.Context, chatID string, media c
 			msg.DocumentMessage.ContextInfo = ctxInfo
 		}
 	}
-	resp, err := cli.SendMessage(ctx, jid, &msg)
+	resp, err := wa.sendMessage(ctx, cli, jid, &msg)
 	if err != nil {
 		return common.SendResponse{}, err
 	}
@@ -65,7 +65,7 @@ func (wa *WhatsAppAdapter) SendNewsletterMessage(ctx context.Context, newsletter
 		}
 	}
 
-	resp, err := cli.SendMessage(ctx, jid, msg)
+	resp, err := wa.sendMessage(ctx, cli, jid, msg)
 	if err != nil {
 		return common.SendResponse{}, err
 	}
@@ -0,0 +1,231 @@
+package whatsapp
+
+import (
+	"context"
+
+	"go.mau.fi/whatsmeow/store"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/util/keys"
+)
+
+// fence comprueba antes de escribir que el nodo sigue siendo dueño del canal
+type fence func(ctx context.Context) error
+
+func (f fence) isFenced() {}
+
+// FenceDevice hace que las escrituras del material criptográfico del dispositivo (identidades,
+// sesiones Signal, prekeys, sender keys, claves y versiones de app state y el propio dispositivo)
+// comprueben antes el lease del canal. Un nodo que perdió el canal falla la escritura en vez de
+// pisar las claves que ya rota el nuevo dueño. Contactos y ajustes de chats no se protegen: son
+// cachés que el nuevo dueño vuelve a sincronizar.
+func FenceDevice(device *store.Device, check func(ctx context.Context) error) {
+	if device == nil || check == nil {
+		return
+	}
+	f := fence(check)
+	if device.Identities != nil && !isFenced(device.Identities) {
+		device.Identities = fencedIdentities{device.Identities, f}
+	}
+	if device.Sessions != nil && !isFenced(device.Sessions) {
+		device.Sessions = fencedSessions{device.Sessions, f}
+	}
+	if device.PreKeys != nil && !isFenced(device.PreKeys) {
+		device.PreKeys = fencedPreKeys{device.PreKeys, f}
+	}
+	if device.SenderKeys != nil && !isFenced(device.SenderKeys) {
+		device.SenderKeys = fencedSenderKeys{device.SenderKeys, f}
+	}
+	if device.AppStateKeys != nil && !isFenced(device.AppStateKeys) {
+		device.AppStateKeys = fencedAppStateKeys{device.AppStateKeys, f}
+	}
+	if device.AppState != nil && !isFenced(device.AppState) {
+		device.AppState = fencedAppState{device.AppState, f}
+	}
+	if device.Container != nil && !isFenced(device.Container) {
+		device.Container = fencedContainer{device.Container, f}
+	}
+}
+
+func isFenced(s any) bool {
+	_, ok := s.(interface{ isFenced() })
+	return ok
+}
+
+type fencedIdentities struct {
+	store.IdentityStore
+	fence
+}
+
+func (s fencedIdentities) PutIdentity(ctx context.Context, address string, key [32]byte) error {
+	if err := s.fence(ctx); err != nil {
+		return err
+	}
+	return s.IdentityStore.PutIdentity(ctx, address, key)
+}
+
+func (s fencedIdentities) DeleteAllIdentities(ctx context.Context, phone string) error {
+	if err := s.fence(ctx); err != nil {
+		return err
+	}
+	return s.IdentityStore.DeleteAllIdentities(ctx, phone)
+}
+
+func (s fencedIdentities) DeleteIdentity(ctx context.Context, address string) error {
+	if err := s.fence(ctx); err != nil {
+		return err
+	}
+	return s.IdentityStore.DeleteIdentity(ctx, address)
+}
+
+type fencedSessions struct {
+	store.SessionStore
+	fence
+}
+
+func (s fencedSessions) PutSession(ctx context.Context, address string, session []byte) error {
+	if err := s.fence(ctx); err != nil {
+		return err
+	}
+	return s.SessionStore.PutSession(ctx, address, session)
+}
+
+func (s fencedSessions) PutManySessions(ctx context.Context, sessions map[string][]byte) error {
+	if err := s.fence(ctx); err != nil {
+		return err
+	}
+	return s.SessionStore.PutManySessions(ctx, sessions)
+}
+
+func (s fencedSessions) DeleteAllSessions(ctx context.Context, phone string) error {
+	if err := s.fence(ctx); err != nil {
+		return err
+	}
+	return s.SessionStore.DeleteAllSessions(ctx, phone)
+}
+
+func (s fencedSessions) DeleteSession(ctx context.Context, address string) error {
+	if err := s.fence(ctx); err != nil {
+		return err
+	}
+	return s.SessionStore.DeleteSession(ctx, address)
+}
+
+func (s fencedSessions) MigratePNToLID(ctx context.Context, pn, lid types.JID) error {
+	if err := s.fence(ctx); err != nil {
+		return err
+	}
+	return s.SessionStore.MigratePNToLID(ctx, pn, lid)
+}
+
+type fencedPreKeys struct {
+	store.PreKeyStore
+	fence
+}
+
+func (s fencedPreKeys) GetOrGenPreKeys(ctx context.Context, count uint32) ([]*keys.PreKey, error) {
+	if err := s.fence(ctx); err != nil {
+		return nil, err
+	}
+	return s.PreKeyStore.GetOrGenPreKeys(ctx, count)
+}
+
+func (s fencedPreKeys) GenOnePreKey(ctx context.Context) (*keys.PreKey, error) {
+	if err := s.fence(ctx); err != nil {
+		return nil, err
+	}
+	return s.PreKeyStore.GenOnePreKey(ctx)
+}
+
+func (s fencedPreKeys) RemovePreKey(ctx context.Context, id uint32) error {
+	if err := s.fence(ctx); err != nil {
+		return err
+	}
+	return s.PreKeyStore.RemovePreKey(ctx, id)
+}
+
+func (s fencedPreKeys) MarkPreKeysAsUploaded(ctx context.Context, upToID uint32) error {
+	if err := s.fence(ctx); err != nil {
+		return err
+	}
+	return s.PreKeyStore.MarkPreKeysAsUploaded(ctx, upToID)
+}
+
+type fencedSenderKeys struct {
+	store.SenderKeyStore
+	fence
+}
+
+func (s fencedSenderKeys) PutSenderKey(ctx context.Context, group, user string, session []byte) error {
+	if err := s.fence(ctx); err != nil {
+		return err
+	}
+	return s.SenderKeyStore.PutSenderKey(ctx, group, user, session)
+}
+
+type fencedAppStateKeys struct {
+	store.AppStateSyncKeyStore
+	fence
+}
+
+func (s fencedAppStateKeys) PutAppStateSyncKey(ctx context.Context, id []byte, key store.AppStateSyncKey) error {
+	if err := s.fence(ctx); err != nil {
+		return err
+	}
+	return s.AppStateSyncKeyStore.PutAppStateSyncKey(ctx, id, key)
+}
+
+type fencedAppState struct {
+	store.AppStateStore
+	fence
+}
+
+func (s fencedAppState) PutAppStateVersion(ctx context.Context, name string, version uint64, hash [128]byte) error {
+	if err := s.fence(ctx); err != nil {
+		return err
+	}
+	return s.AppStateStore.PutAppStateVersion(ctx, name, version, hash)
+}
+
+func (s fencedAppState) DeleteAppStateVersion(ctx context.Context, name string) error {
+	if err := s.fence(ctx); err != nil {
+		return err
+	}
+	return s.AppStateStore.DeleteAppStateVersion(ctx, name)
+}
+
+func (s fencedAppState) PutAppStateMutationMACs(ctx context.Context, name string, version uint64, mutations []store.AppStateMutationMAC) error {
+	if err := s.fence(ctx); err != nil {
+		return err
+	}
+	return s.AppStateStore.PutAppStateMutationMACs(ctx, name, version, mutations)
+}
+
+func (s fencedAppState) DeleteAppStateMutationMACs(ctx context.Context, name string, indexMACs [][]byte) error {
+	if err := s.fence(ctx); err != nil {
+		return err
+	}
+	return s.AppStateStore.DeleteAppStateMutationMACs(ctx, name, indexMACs)
+}
+
+type fencedContainer struct {
+	store.DeviceContainer
+	fence
+}
+
+// PutDevice vuelve a proteger el dispositivo: al guardarlo por primera vez (vinculación)
+// sqlstore reemplaza todos sus stores por los suyos.
+func (s fencedContainer) PutDevice(ctx context.Context, device *store.Device) error {
+	if err := s.fence(ctx); err != nil {
+		return err
+	}
+	err := s.DeviceContainer.PutDevice(ctx, device)
+	FenceDevice(device, s.fence)
+	return err
+}
+
+func (s fencedContainer) DeleteDevice(ctx context.Context, device *store.Device) error {
+	if err := s.fence(ctx); err != nil {
+		return err
+	}
+	return s.DeviceContainer.DeleteDevice(ctx, device)
+}
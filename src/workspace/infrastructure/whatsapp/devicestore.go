@@ -0,0 +1,329 @@
+package whatsapp
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	coreconfig "github.com/AzielCF/az-wap/core/config"
+	"github.com/sirupsen/logrus"
+	"go.mau.fi/whatsmeow/store"
+	"go.mau.fi/whatsmeow/store/sqlstore"
+	"go.mau.fi/whatsmeow/types"
+	waLog "go.mau.fi/whatsmeow/util/log"
+)
+
+const (
+	DeviceStoreSQLite   = "sqlite"
+	DeviceStorePostgres = "postgres"
+)
+
+// DeviceStore guarda las claves de los dispositivos whatsmeow de cada canal.
+// SQLite usa un archivo por canal en PathsConfig.Storages; Postgres comparte una base
+// entre todos los nodos, así un canal puede moverse de nodo sin perder su sesión.
+type DeviceStore interface {
+	Kind() string
+
+	// Open devuelve el dispositivo del canal, o uno nuevo si nunca se vinculó.
+	Open(ctx context.Context, channelID string) (*store.Device, error)
+
+	// Bind recuerda qué dispositivo usa el canal (se llama al conectar tras vincular).
+	Bind(ctx context.Context, channelID string, jid types.JID) error
+
+	// Release libera los recursos locales del canal sin borrar sus claves.
+	Release(channelID string) error
+
+	// Delete borra las claves del canal (logout o eliminación del canal).
+	Delete(ctx context.Context, channelID string) error
+
+	// Export vuelca las filas whatsmeow del dispositivo del canal (el adaptador no debe estar corriendo).
+	Export(ctx context.Context, channelID string) (*DeviceDump, error)
+
+	// Import restaura un volcado como dispositivo del canal; falla si el canal ya tiene uno.
+	Import(ctx context.Context, channelID string, dump *DeviceDump) error
+
+	// Replace restaura un volcado sustituyendo el dispositivo actual del canal. Las claves
+	// anteriores solo se borran cuando la restauración terminó bien (el adaptador no debe estar corriendo).
+	Replace(ctx context.Context, channelID string, dump *DeviceDump) error
+
+	Close() error
+}
+
+var (
+	devicesMu sync.RWMutex
+	devices   DeviceStore
+)
+
+// InitDeviceStore elige el almacén según DB_KEYS_URI: una URI postgres:// usa Postgres compartido,
+// cualquier otro valor (o vacío) mantiene un SQLite por canal en el directorio de storages.
+func InitDeviceStore(ctx context.Context) (DeviceStore, error) {
+	var (
+		ds  DeviceStore
+		err error
+	)
+	if uri := coreconfig.Global.Database.KeysURI; IsPostgresURI(uri) {
+		ds, err = NewPostgresDeviceStore(ctx, uri)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		ds = NewSQLiteDeviceStore(coreconfig.Global.Paths.Storages)
+	}
+	SetDeviceStore(ds)
+	logrus.Infof("[WHATSAPP] Device store: %s", ds.Kind())
+	return ds, nil
+}
+
+// SetDeviceStore reemplaza el almacén global de dispositivos.
+func SetDeviceStore(ds DeviceStore) {
+	devicesMu.Lock()
+	defer devicesMu.Unlock()
+	devices = ds
+}
+
+// Devices devuelve el almacén global; si no se inicializó, SQLite en el directorio de storages.
+func Devices() DeviceStore {
+	devicesMu.RLock()
+	ds := devices
+	devicesMu.RUnlock()
+	if ds != nil {
+		return ds
+	}
+
+	devicesMu.Lock()
+	defer devicesMu.Unlock()
+	if devices == nil {
+		dir := ""
+		if coreconfig.Global != nil {
+			dir = coreconfig.Global.Paths.Storages
+		}
+		devices = NewSQLiteDeviceStore(dir)
+	}
+	return devices
+}
+
+func IsPostgresURI(uri string) bool {
+	return strings.HasPrefix(uri, "postgres://") || strings.HasPrefix(uri, "postgresql://")
+}
+
+func dbLogger(channelID string) waLog.Logger {
+	short := channelID
+	if len(short) > 8 {
+		short = short[:8]
+	}
+	return waLog.Stdout("DB-"+short, logLevel(), true)
+}
+
+func logLevel() string {
+	if coreconfig.Global == nil || coreconfig.Global.Whatsapp.LogLevel == "" {
+		return "ERROR"
+	}
+	return coreconfig.Global.Whatsapp.LogLevel
+}
+
+// --- SQLite (un archivo por canal) ---
+
+// SQLiteDeviceStore mantiene un whatsapp-<canal>.db por canal.
+type SQLiteDeviceStore struct {
+	dir        string
+	mu         sync.Mutex
+	containers map[string]*sqlstore.Container
+}
+
+func NewSQLiteDeviceStore(dir string) *SQLiteDeviceStore {
+	if dir == "" {
+		dir = "storages"
+	}
+	return &SQLiteDeviceStore{dir: dir, containers: make(map[string]*sqlstore.Container)}
+}
+
+func (s *SQLiteDeviceStore) Kind() string {
+	return DeviceStoreSQLite
+}
+
+// Path devuelve el archivo SQLite de un canal.
+func (s *SQLiteDeviceStore) Path(channelID string) string {
+	return filepath.Join(s.dir, fmt.Sprintf("whatsapp-%s.db", channelID))
+}
+
+func (s *SQLiteDeviceStore) Open(ctx context.Context, channelID string) (*store.Device, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	container, ok := s.containers[channelID]
+	if !ok {
+		if err := os.MkdirAll(s.dir, 0755); err != nil {
+			return nil, fmt.Errorf("failed to create storage dir: %w", err)
+		}
+		var err error
+		container, err = sqlstore.New(ctx, "sqlite3", "file:"+s.Path(channelID)+"?_foreign_keys=on", dbLogger(channelID))
+		if err != nil {
+			return nil, fmt.Errorf("failed to init channel db: %w", err)
+		}
+		s.containers[channelID] = container
+	}
+
+	device, err := container.GetFirstDevice(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get device: %w", err)
+	}
+	if device == nil {
+		device = container.NewDevice()
+	}
+	return device, nil
+}
+
+// Bind no hace nada: el archivo ya identifica al canal.
+func (s *SQLiteDeviceStore) Bind(ctx context.Context, channelID string, jid types.JID) error {
+	return nil
+}
+
+func (s *SQLiteDeviceStore) Release(channelID string) error {
+	s.mu.Lock()
+	container, ok := s.containers[channelID]
+	delete(s.containers, channelID)
+	s.mu.Unlock()
+
+	if !ok {
+		return nil
+	}
+	return container.Close()
+}
+
+func (s *SQLiteDeviceStore) Delete(ctx context.Context, channelID string) error {
+	if err := s.Release(channelID); err == nil {
+		// Give SQLite time to release file locks
+		time.Sleep(500 * time.Millisecond)
+	}
+
+	path := s.Path(channelID)
+	for _, f := range []string{path, path + "-shm", path + "-wal"} {
+		if err := os.Remove(f); err != nil && !os.IsNotExist(err) {
+			logrus.Errorf("[WHATSAPP] Failed to remove %s: %v", f, err)
+		} else if err == nil {
+			logrus.Infof("[WHATSAPP] Removed %s", f)
+		}
+	}
+	return nil
+}
+
+func (s *SQLiteDeviceStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, c := range s.containers {
+		_ = c.Close()
+		delete(s.containers, id)
+	}
+	return nil
+}
+
+// --- Postgres (compartido entre nodos) ---
+
+// PostgresDeviceStore guarda todos los dispositivos en una sola base whatsmeow.
+// La tabla azwap_channel_devices relaciona cada canal con el JID de su dispositivo.
+type PostgresDeviceStore struct {
+	db        *sql.DB
+	container *sqlstore.Container
+}
+
+func NewPostgresDeviceStore(ctx context.Context, uri string) (*PostgresDeviceStore, error) {
+	db, err := sql.Open("postgres", uri)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open device store: %w", err)
+	}
+	container := sqlstore.NewWithDB(db, "postgres", waLog.Stdout("DB-keys", logLevel(), true))
+	if err := container.Upgrade(ctx); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("failed to upgrade device store: %w", err)
+	}
+	_, err = db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS azwap_channel_devices (
+		channel_id TEXT PRIMARY KEY,
+		jid        TEXT NOT NULL,
+		updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+	)`)
+	if err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("failed to create channel device table: %w", err)
+	}
+	return &PostgresDeviceStore{db: db, container: container}, nil
+}
+
+func (s *PostgresDeviceStore) Kind() string {
+	return DeviceStorePostgres
+}
+
+func (s *PostgresDeviceStore) deviceJID(ctx context.Context, channelID string) (types.JID, bool, error) {
+	var raw string
+	err := s.db.QueryRowContext(ctx, `SELECT jid FROM azwap_channel_devices WHERE channel_id = $1`, channelID).Scan(&raw)
+	if err == sql.ErrNoRows {
+		return types.JID{}, false, nil
+	}
+	if err != nil {
+		return types.JID{}, false, err
+	}
+	jid, err := types.ParseJID(raw)
+	if err != nil {
+		return types.JID{}, false, fmt.Errorf("invalid device jid for channel %s: %w", channelID, err)
+	}
+	return jid, true, nil
+}
+
+func (s *PostgresDeviceStore) Open(ctx context.Context, channelID string) (*store.Device, error) {
+	jid, ok, err := s.deviceJID(ctx, channelID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get channel device: %w", err)
+	}
+	if ok {
+		device, err := s.container.GetDevice(ctx, jid)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get device: %w", err)
+		}
+		// El dispositivo pudo borrarse al hacer logout: se vincula de nuevo
+		if device != nil {
+			return device, nil
+		}
+	}
+	return s.container.NewDevice(), nil
+}
+
+func (s *PostgresDeviceStore) Bind(ctx context.Context, channelID string, jid types.JID) error {
+	_, err := s.db.ExecContext(ctx, `INSERT INTO azwap_channel_devices (channel_id, jid, updated_at) VALUES ($1, $2, NOW())
+		ON CONFLICT (channel_id) DO UPDATE SET jid = EXCLUDED.jid, updated_at = EXCLUDED.updated_at`, channelID, jid.String())
+	return err
+}
+
+// Release no hace nada: la conexión es compartida por todos los canales.
+func (s *PostgresDeviceStore) Release(channelID string) error {
+	return nil
+}
+
+// Delete borra el dispositivo del canal; las tablas whatsmeow eliminan en cascada sus claves.
+func (s *PostgresDeviceStore) Delete(ctx context.Context, channelID string) error {
+	jid, ok, err := s.deviceJID(ctx, channelID)
+	if err != nil {
+		return err
+	}
+	if ok {
+		if _, err := s.db.ExecContext(ctx, `DELETE FROM whatsmeow_device WHERE jid = $1`, jid.String()); err != nil {
+			return fmt.Errorf("failed to delete device of channel %s: %w", channelID, err)
+		}
+		// privacy_tokens no tiene foreign key al dispositivo
+		if _, err := s.db.ExecContext(ctx, `DELETE FROM whatsmeow_privacy_tokens WHERE our_jid = $1`, jid.String()); err != nil {
+			logrus.WithError(err).Warnf("[WHATSAPP] Failed to delete privacy tokens of channel %s", channelID)
+		}
+	}
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM azwap_channel_devices WHERE channel_id = $1`, channelID); err != nil {
+		return err
+	}
+	logrus.Infof("[WHATSAPP] Removed device keys of channel %s", channelID)
+	return nil
+}
+
+func (s *PostgresDeviceStore) Close() error {
+	return s.container.Close()
+}
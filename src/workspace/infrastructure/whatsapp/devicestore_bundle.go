@@ -0,0 +1,444 @@
+package whatsapp
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"go.mau.fi/whatsmeow/types"
+)
+
+var (
+	ErrDeviceNotLinked = errors.New("channel has no linked whatsapp device")
+	ErrDeviceExists    = errors.New("channel already has a whatsapp device")
+)
+
+// DeviceDump es una copia portable de las filas whatsmeow de un dispositivo.
+// Se usa para exportar un canal vinculado e importarlo en otra instancia sin escanear el QR.
+type DeviceDump struct {
+	JID    string               `json:"jid"`
+	Tables map[string]TableDump `json:"tables"`
+}
+
+// TableDump guarda las filas de una tabla con sus columnas en el mismo orden.
+type TableDump struct {
+	Columns []string `json:"columns"`
+	Rows    [][]Cell `json:"rows"`
+}
+
+// Cell conserva el tipo del valor (JSON no distingue bytes de texto). Todos nil = NULL.
+type Cell struct {
+	B *[]byte  `json:"b,omitempty"`
+	S *string  `json:"s,omitempty"`
+	I *int64   `json:"i,omitempty"`
+	F *float64 `json:"f,omitempty"`
+	T *bool    `json:"t,omitempty"`
+}
+
+func newCell(v any) Cell {
+	switch x := v.(type) {
+	case []byte:
+		b := append([]byte{}, x...)
+		return Cell{B: &b}
+	case string:
+		return Cell{S: &x}
+	case int64:
+		return Cell{I: &x}
+	case float64:
+		return Cell{F: &x}
+	case bool:
+		return Cell{T: &x}
+	}
+	if v == nil {
+		return Cell{}
+	}
+	s := fmt.Sprint(v)
+	return Cell{S: &s}
+}
+
+func (c Cell) Value() any {
+	switch {
+	case c.B != nil:
+		return *c.B
+	case c.S != nil:
+		return *c.S
+	case c.I != nil:
+		return *c.I
+	case c.F != nil:
+		return *c.F
+	case c.T != nil:
+		return *c.T
+	}
+	return nil
+}
+
+// Rows devuelve el total de filas del volcado.
+func (d *DeviceDump) Rows() int {
+	n := 0
+	for _, t := range d.Tables {
+		n += len(t.Rows)
+	}
+	return n
+}
+
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// deviceOwnerColumn devuelve la columna que identifica al dispositivo dueño de cada fila.
+// Las tablas sin ella (whatsmeow_lid_map, whatsmeow_version) son globales y no se exportan.
+func deviceOwnerColumn(cols []string) string {
+	for _, want := range []string{"our_jid", "jid"} {
+		for _, c := range cols {
+			if c == want {
+				return c
+			}
+		}
+	}
+	return ""
+}
+
+// dumpDeviceTables copia las filas del dispositivo jid de cada tabla; arg es el placeholder del driver (? o $1).
+func dumpDeviceTables(ctx context.Context, q queryer, tables []string, jid string, arg string) (map[string]TableDump, error) {
+	out := make(map[string]TableDump, len(tables))
+	for _, table := range tables {
+		cols, err := tableColumns(ctx, q, table)
+		if err != nil {
+			return nil, fmt.Errorf("table %s: %w", table, err)
+		}
+		owner := deviceOwnerColumn(cols)
+		if owner == "" {
+			continue
+		}
+
+		rows, err := q.QueryContext(ctx, fmt.Sprintf(`SELECT * FROM "%s" WHERE "%s" = %s`, table, owner, arg), jid)
+		if err != nil {
+			return nil, fmt.Errorf("table %s: %w", table, err)
+		}
+		dump := TableDump{Columns: cols}
+		values := make([]any, len(cols))
+		ptrs := make([]any, len(cols))
+		for i := range values {
+			ptrs[i] = &values[i]
+		}
+		for rows.Next() {
+			if err := rows.Scan(ptrs...); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			row := make([]Cell, len(cols))
+			for i, v := range values {
+				row[i] = newCell(v)
+			}
+			dump.Rows = append(dump.Rows, row)
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return nil, err
+		}
+		out[table] = dump
+	}
+	return out, nil
+}
+
+func tableColumns(ctx context.Context, q queryer, table string) ([]string, error) {
+	rows, err := q.QueryContext(ctx, `SELECT * FROM "`+table+`" LIMIT 0`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return rows.Columns()
+}
+
+// restoreDeviceTables inserta el volcado en orden de foreign keys. destCols trae las columnas de cada tabla
+// destino con su tipo Postgres (vacío en SQLite); las columnas que no existen en el destino se ignoran.
+func restoreDeviceTables(ctx context.Context, tx execer, dump *DeviceDump, destCols map[string]map[string]string, insertVerb, conflict string, param func(int) string) (int64, error) {
+	found := make(map[string]bool, len(dump.Tables))
+	for t := range dump.Tables {
+		found[t] = true
+	}
+
+	var restored int64
+	for _, table := range orderDeviceTables(found) {
+		td := dump.Tables[table]
+		dest, ok := destCols[table]
+		if !ok || len(td.Rows) == 0 {
+			continue
+		}
+		var keep []int
+		var names, params []string
+		for i, c := range td.Columns {
+			if _, ok := dest[c]; ok {
+				keep = append(keep, i)
+				names = append(names, `"`+c+`"`)
+				params = append(params, param(len(keep)))
+			}
+		}
+		insert := fmt.Sprintf(`%s "%s" (%s) VALUES (%s)%s`, insertVerb, table, strings.Join(names, ", "), strings.Join(params, ", "), conflict)
+		for _, row := range td.Rows {
+			args := make([]any, len(keep))
+			for j, i := range keep {
+				args[j] = convertForPostgres(row[i].Value(), dest[td.Columns[i]])
+			}
+			if _, err := tx.ExecContext(ctx, insert, args...); err != nil {
+				return restored, fmt.Errorf("table %s: %w", table, err)
+			}
+			restored++
+		}
+	}
+	return restored, nil
+}
+
+func validateDump(dump *DeviceDump) (types.JID, error) {
+	if dump == nil || dump.JID == "" {
+		return types.JID{}, errors.New("device dump is empty")
+	}
+	if _, ok := dump.Tables["whatsmeow_device"]; !ok {
+		return types.JID{}, errors.New("device dump has no whatsmeow_device rows")
+	}
+	return types.ParseJID(dump.JID)
+}
+
+// --- SQLite ---
+
+func (s *SQLiteDeviceStore) Export(ctx context.Context, channelID string) (*DeviceDump, error) {
+	path := s.Path(channelID)
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil, ErrDeviceNotLinked
+	}
+	db, err := sql.Open("sqlite3", "file:"+path+"?mode=ro")
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	var jid string
+	if err := db.QueryRowContext(ctx, `SELECT jid FROM whatsmeow_device LIMIT 1`).Scan(&jid); err != nil {
+		if err == sql.ErrNoRows || strings.Contains(err.Error(), "no such table") {
+			return nil, ErrDeviceNotLinked
+		}
+		return nil, err
+	}
+	tables, err := sqliteDeviceTables(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	dumped, err := dumpDeviceTables(ctx, db, tables, jid, "?")
+	if err != nil {
+		return nil, err
+	}
+	return &DeviceDump{JID: jid, Tables: dumped}, nil
+}
+
+func (s *SQLiteDeviceStore) Import(ctx context.Context, channelID string, dump *DeviceDump) error {
+	if _, err := validateDump(dump); err != nil {
+		return err
+	}
+	// Open crea el archivo con el esquema whatsmeow actual
+	device, err := s.Open(ctx, channelID)
+	if err != nil {
+		return err
+	}
+	if err := s.Release(channelID); err != nil {
+		return err
+	}
+	if device.ID != nil {
+		return ErrDeviceExists
+	}
+
+	db, err := sql.Open("sqlite3", "file:"+s.Path(channelID)+"?_foreign_keys=on")
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	destCols := make(map[string]map[string]string, len(dump.Tables))
+	for table := range dump.Tables {
+		cols, err := tableColumns(ctx, db, table)
+		if err != nil {
+			continue // Tabla de una versión distinta de whatsmeow
+		}
+		destCols[table] = make(map[string]string, len(cols))
+		for _, c := range cols {
+			destCols[table][c] = ""
+		}
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	n, err := restoreDeviceTables(ctx, tx, dump, destCols, "INSERT OR IGNORE INTO", "", func(int) string { return "?" })
+	if err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	logrus.Infof("[WHATSAPP] Imported device %s into channel %s (%d rows)", dump.JID, channelID, n)
+	return nil
+}
+
+// Replace importa en un archivo temporal y solo al terminar lo renombra sobre el del canal:
+// si la importación falla, el dispositivo anterior queda intacto.
+func (s *SQLiteDeviceStore) Replace(ctx context.Context, channelID string, dump *DeviceDump) error {
+	staging := channelID + ".import"
+	_ = s.Delete(ctx, staging) // Restos de un intento anterior
+	if err := s.Import(ctx, staging, dump); err != nil {
+		_ = s.Delete(ctx, staging)
+		return err
+	}
+	if err := s.Release(staging); err != nil {
+		return err
+	}
+
+	if err := s.Release(channelID); err != nil {
+		return err
+	}
+	path, stagingPath := s.Path(channelID), s.Path(staging)
+	for _, f := range []string{path + "-shm", path + "-wal"} {
+		if err := os.Remove(f); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove %s: %w", f, err)
+		}
+	}
+	if err := os.Rename(stagingPath, path); err != nil {
+		_ = s.Delete(ctx, staging)
+		return fmt.Errorf("failed to swap device file: %w", err)
+	}
+	for _, suffix := range []string{"-shm", "-wal"} {
+		_ = os.Remove(stagingPath + suffix)
+	}
+	return nil
+}
+
+// --- Postgres ---
+
+func (s *PostgresDeviceStore) Export(ctx context.Context, channelID string) (*DeviceDump, error) {
+	jid, ok, err := s.deviceJID(ctx, channelID)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrDeviceNotLinked
+	}
+
+	tx, err := s.db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true, Isolation: sql.LevelRepeatableRead})
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	tables, err := postgresDeviceTables(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+	dumped, err := dumpDeviceTables(ctx, tx, tables, jid.String(), "$1")
+	if err != nil {
+		return nil, err
+	}
+	if len(dumped["whatsmeow_device"].Rows) == 0 {
+		return nil, ErrDeviceNotLinked
+	}
+	return &DeviceDump{JID: jid.String(), Tables: dumped}, nil
+}
+
+func (s *PostgresDeviceStore) Import(ctx context.Context, channelID string, dump *DeviceDump) error {
+	return s.restore(ctx, channelID, dump, false)
+}
+
+// Replace borra el dispositivo anterior dentro de la misma transacción que restaura el nuevo:
+// si algo falla, el rollback deja las claves anteriores como estaban.
+func (s *PostgresDeviceStore) Replace(ctx context.Context, channelID string, dump *DeviceDump) error {
+	return s.restore(ctx, channelID, dump, true)
+}
+
+func (s *PostgresDeviceStore) restore(ctx context.Context, channelID string, dump *DeviceDump, replace bool) error {
+	jid, err := validateDump(dump)
+	if err != nil {
+		return err
+	}
+	oldJID, bound, err := s.deviceJID(ctx, channelID)
+	if err != nil {
+		return err
+	} else if bound && !replace {
+		return ErrDeviceExists
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if bound {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM whatsmeow_device WHERE jid = $1`, oldJID.String()); err != nil {
+			return fmt.Errorf("failed to delete device of channel %s: %w", channelID, err)
+		}
+		if _, err := tx.ExecContext(ctx, `DELETE FROM whatsmeow_privacy_tokens WHERE our_jid = $1`, oldJID.String()); err != nil {
+			return fmt.Errorf("failed to delete privacy tokens of channel %s: %w", channelID, err)
+		}
+		if _, err := tx.ExecContext(ctx, `DELETE FROM azwap_channel_devices WHERE channel_id = $1`, channelID); err != nil {
+			return err
+		}
+	}
+	var taken int
+	if err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM whatsmeow_device WHERE jid = $1`, jid.String()).Scan(&taken); err != nil {
+		return err
+	} else if taken > 0 {
+		return fmt.Errorf("device %s is already stored for another channel", jid)
+	}
+
+	destCols := make(map[string]map[string]string, len(dump.Tables))
+	for table := range dump.Tables {
+		cols, err := postgresColumnTypes(ctx, tx, table)
+		if err != nil {
+			return err
+		}
+		if len(cols) > 0 {
+			destCols[table] = cols
+		}
+	}
+
+	n, err := restoreDeviceTables(ctx, tx, dump, destCols, "INSERT INTO", " ON CONFLICT DO NOTHING", func(i int) string { return fmt.Sprintf("$%d", i) })
+	if err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `INSERT INTO azwap_channel_devices (channel_id, jid, updated_at) VALUES ($1, $2, NOW())`, channelID, jid.String()); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	logrus.Infof("[WHATSAPP] Imported device %s into channel %s (%d rows)", jid, channelID, n)
+	return nil
+}
+
+func postgresDeviceTables(ctx context.Context, q queryer) ([]string, error) {
+	rows, err := q.QueryContext(ctx, `SELECT table_name FROM information_schema.tables
+		WHERE table_schema = current_schema() AND table_name LIKE 'whatsmeow\_%' AND table_name != 'whatsmeow_version'`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	found := map[string]bool{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		found[name] = true
+	}
+	return orderDeviceTables(found), rows.Err()
+}
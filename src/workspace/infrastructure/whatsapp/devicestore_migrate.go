@@ -0,0 +1,257 @@
+package whatsapp
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"go.mau.fi/whatsmeow/store/sqlstore"
+)
+
+// MigrateResult resume la migración de un archivo SQLite de dispositivo
+type MigrateResult struct {
+	ChannelID string `json:"channel_id"`
+	File      string `json:"file"`
+	JID       string `json:"jid,omitempty"`
+	Rows      int64  `json:"rows"`
+	Skipped   string `json:"skipped,omitempty"` // Motivo si no se copió
+	Error     string `json:"error,omitempty"`
+}
+
+// MigrateOptions controla la migración de SQLite a Postgres
+type MigrateOptions struct {
+	DryRun  bool // Solo listar lo que se migraría
+	Archive bool // Renombrar los archivos migrados a .migrated
+}
+
+// Las tablas padre van primero por las foreign keys
+var migrateTableOrder = []string{"whatsmeow_device", "whatsmeow_app_state_version"}
+
+// MigrateSQLiteDevices copia cada whatsapp-<canal>.db de dir al almacén Postgres compartido.
+// Es idempotente: los canales que ya tienen dispositivo en Postgres se omiten.
+func MigrateSQLiteDevices(ctx context.Context, dir string, dst *PostgresDeviceStore, opts MigrateOptions) ([]MigrateResult, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "whatsapp-*.db"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+
+	results := make([]MigrateResult, 0, len(files))
+	for _, file := range files {
+		res := MigrateResult{
+			File:      file,
+			ChannelID: strings.TrimSuffix(strings.TrimPrefix(filepath.Base(file), "whatsapp-"), ".db"),
+		}
+		if err := migrateDeviceFile(ctx, file, dst, opts, &res); err != nil {
+			res.Error = err.Error()
+			logrus.WithError(err).Errorf("[WHATSAPP] Failed to migrate %s", file)
+		}
+		results = append(results, res)
+	}
+	return results, nil
+}
+
+func migrateDeviceFile(ctx context.Context, file string, dst *PostgresDeviceStore, opts MigrateOptions, res *MigrateResult) error {
+	// sqlstore.New actualiza el esquema del archivo a la misma versión que Postgres
+	container, err := sqlstore.New(ctx, "sqlite3", "file:"+file+"?_foreign_keys=on", nil)
+	if err != nil {
+		return err
+	}
+	device, err := container.GetFirstDevice(ctx)
+	_ = container.Close()
+	if err != nil {
+		return err
+	}
+	if device == nil || device.ID == nil {
+		res.Skipped = "device not linked"
+		return nil
+	}
+	res.JID = device.ID.String()
+
+	if _, bound, err := dst.deviceJID(ctx, res.ChannelID); err != nil {
+		return err
+	} else if bound {
+		res.Skipped = "already migrated"
+		return nil
+	}
+	if opts.DryRun {
+		res.Skipped = "dry run"
+		return nil
+	}
+
+	src, err := sql.Open("sqlite3", "file:"+file+"?mode=ro")
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	tx, err := dst.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	tables, err := sqliteDeviceTables(ctx, src)
+	if err != nil {
+		return err
+	}
+	for _, table := range tables {
+		n, err := copyDeviceTable(ctx, src, tx, table)
+		if err != nil {
+			return fmt.Errorf("table %s: %w", table, err)
+		}
+		res.Rows += n
+	}
+	if _, err := tx.ExecContext(ctx, `INSERT INTO azwap_channel_devices (channel_id, jid, updated_at) VALUES ($1, $2, NOW())
+		ON CONFLICT (channel_id) DO UPDATE SET jid = EXCLUDED.jid, updated_at = EXCLUDED.updated_at`, res.ChannelID, res.JID); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	logrus.Infof("[WHATSAPP] Migrated channel %s (%s): %d rows", res.ChannelID, res.JID, res.Rows)
+
+	if opts.Archive {
+		src.Close()
+		for _, f := range []string{file, file + "-shm", file + "-wal"} {
+			if err := os.Rename(f, f+".migrated"); err != nil && !os.IsNotExist(err) {
+				logrus.Warnf("[WHATSAPP] Failed to archive %s: %v", f, err)
+			}
+		}
+	}
+	return nil
+}
+
+func sqliteDeviceTables(ctx context.Context, src *sql.DB) ([]string, error) {
+	rows, err := src.QueryContext(ctx, `SELECT name FROM sqlite_master WHERE type = 'table' AND name LIKE 'whatsmeow_%' AND name != 'whatsmeow_version'`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	found := map[string]bool{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		found[name] = true
+	}
+	return orderDeviceTables(found), rows.Err()
+}
+
+// orderDeviceTables pone primero las tablas padre y luego el resto en orden alfabético
+func orderDeviceTables(found map[string]bool) []string {
+	var tables []string
+	for _, t := range migrateTableOrder {
+		if found[t] {
+			tables = append(tables, t)
+			delete(found, t)
+		}
+	}
+	rest := make([]string, 0, len(found))
+	for t := range found {
+		rest = append(rest, t)
+	}
+	sort.Strings(rest)
+	return append(tables, rest...)
+}
+
+func copyDeviceTable(ctx context.Context, src *sql.DB, tx *sql.Tx, table string) (int64, error) {
+	pgTypes, err := postgresColumnTypes(ctx, tx, table)
+	if err != nil {
+		return 0, err
+	}
+	if len(pgTypes) == 0 {
+		return 0, nil
+	}
+
+	rows, err := src.QueryContext(ctx, `SELECT * FROM "`+table+`"`)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	cols, err := rows.Columns()
+	if err != nil {
+		return 0, err
+	}
+	// Solo las columnas que existen en ambos lados
+	var keep []int
+	var names, params []string
+	for i, c := range cols {
+		if _, ok := pgTypes[c]; ok {
+			keep = append(keep, i)
+			names = append(names, `"`+c+`"`)
+			params = append(params, fmt.Sprintf("$%d", len(keep)))
+		}
+	}
+	insert := fmt.Sprintf(`INSERT INTO "%s" (%s) VALUES (%s) ON CONFLICT DO NOTHING`, table, strings.Join(names, ", "), strings.Join(params, ", "))
+
+	var copied int64
+	values := make([]any, len(cols))
+	ptrs := make([]any, len(cols))
+	for i := range values {
+		ptrs[i] = &values[i]
+	}
+	for rows.Next() {
+		if err := rows.Scan(ptrs...); err != nil {
+			return copied, err
+		}
+		args := make([]any, len(keep))
+		for j, i := range keep {
+			args[j] = convertForPostgres(values[i], pgTypes[cols[i]])
+		}
+		if _, err := tx.ExecContext(ctx, insert, args...); err != nil {
+			return copied, err
+		}
+		copied++
+	}
+	return copied, rows.Err()
+}
+
+func postgresColumnTypes(ctx context.Context, tx queryer, table string) (map[string]string, error) {
+	rows, err := tx.QueryContext(ctx, `SELECT column_name, data_type FROM information_schema.columns
+		WHERE table_name = $1 AND table_schema = current_schema()`, table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	types := map[string]string{}
+	for rows.Next() {
+		var name, dataType string
+		if err := rows.Scan(&name, &dataType); err != nil {
+			return nil, err
+		}
+		types[name] = dataType
+	}
+	return types, rows.Err()
+}
+
+// convertForPostgres ajusta los valores de SQLite (tipado dinámico) al tipo de la columna en Postgres
+func convertForPostgres(v any, pgType string) any {
+	switch pgType {
+	case "boolean":
+		switch b := v.(type) {
+		case int64:
+			return b != 0
+		case string:
+			return b == "1" || strings.EqualFold(b, "true")
+		}
+	case "bytea":
+		if s, ok := v.(string); ok {
+			return []byte(s)
+		}
+	case "text", "character varying":
+		if b, ok := v.([]byte); ok {
+			return string(b)
+		}
+	}
+	return v
+}
@@ -0,0 +1,128 @@
+package whatsapp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mau.fi/whatsmeow/proto/waAdv"
+	"go.mau.fi/whatsmeow/types"
+)
+
+func TestSQLiteDeviceStore_OpenAndDelete(t *testing.T) {
+	ctx := context.Background()
+	ds := NewSQLiteDeviceStore(t.TempDir())
+
+	device, err := ds.Open(ctx, "channel-1234")
+	require.NoError(t, err)
+	assert.Nil(t, device.ID) // Nunca vinculado
+
+	_, err = os.Stat(ds.Path("channel-1234"))
+	require.NoError(t, err)
+
+	require.NoError(t, ds.Delete(ctx, "channel-1234"))
+	_, err = os.Stat(ds.Path("channel-1234"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestConvertForPostgres(t *testing.T) {
+	assert.Equal(t, true, convertForPostgres(int64(1), "boolean"))
+	assert.Equal(t, false, convertForPostgres(int64(0), "boolean"))
+	assert.Equal(t, []byte("key"), convertForPostgres("key", "bytea"))
+	assert.Equal(t, "jid", convertForPostgres([]byte("jid"), "text"))
+	assert.Equal(t, int64(42), convertForPostgres(int64(42), "bigint"))
+	assert.True(t, IsPostgresURI("postgres://user@host/keys"))
+	assert.False(t, IsPostgresURI("file:storages/whatsapp.db"))
+}
+
+func TestSQLiteDeviceStore_ExportImport(t *testing.T) {
+	ctx := context.Background()
+	src := NewSQLiteDeviceStore(t.TempDir())
+
+	_, err := src.Export(ctx, "channel-a")
+	assert.ErrorIs(t, err, ErrDeviceNotLinked)
+
+	device, err := src.Open(ctx, "channel-a")
+	require.NoError(t, err)
+	jid := types.NewADJID("5491100000000", 0, 12)
+	device.ID = &jid
+	device.PushName = "Bundle"
+	device.Account = &waAdv.ADVSignedDeviceIdentity{Details: []byte("d"), AccountSignature: make([]byte, 64), AccountSignatureKey: make([]byte, 32), DeviceSignature: make([]byte, 64)}
+	require.NoError(t, device.Save(ctx))
+	require.NoError(t, src.Release("channel-a"))
+
+	dump, err := src.Export(ctx, "channel-a")
+	require.NoError(t, err)
+	assert.Equal(t, jid.String(), dump.JID)
+	assert.Len(t, dump.Tables["whatsmeow_device"].Rows, 1)
+
+	// Ida y vuelta por JSON, como dentro del bundle cifrado
+	data, err := json.Marshal(dump)
+	require.NoError(t, err)
+	var restored DeviceDump
+	require.NoError(t, json.Unmarshal(data, &restored))
+
+	dst := NewSQLiteDeviceStore(t.TempDir())
+	require.NoError(t, dst.Import(ctx, "channel-a", &restored))
+	imported, err := dst.Open(ctx, "channel-a")
+	require.NoError(t, err)
+	require.NotNil(t, imported.ID)
+	assert.Equal(t, jid, *imported.ID)
+	assert.Equal(t, "Bundle", imported.PushName)
+	assert.Equal(t, device.IdentityKey.Priv, imported.IdentityKey.Priv)
+	require.NoError(t, dst.Release("channel-a"))
+
+	assert.ErrorIs(t, dst.Import(ctx, "channel-a", &restored), ErrDeviceExists)
+
+	// Un volcado inválido no toca las claves que ya tenía el canal
+	assert.Error(t, dst.Replace(ctx, "channel-a", &DeviceDump{JID: jid.String()}))
+	kept, err := dst.Open(ctx, "channel-a")
+	require.NoError(t, err)
+	require.NotNil(t, kept.ID)
+	assert.Equal(t, jid, *kept.ID)
+	require.NoError(t, dst.Release("channel-a"))
+
+	require.NoError(t, dst.Replace(ctx, "channel-a", &restored))
+	replaced, err := dst.Open(ctx, "channel-a")
+	require.NoError(t, err)
+	require.NotNil(t, replaced.ID)
+	assert.Equal(t, device.IdentityKey.Priv, replaced.IdentityKey.Priv)
+	require.NoError(t, dst.Release("channel-a"))
+	assert.NoFileExists(t, dst.Path("channel-a.import"))
+}
+
+func TestFenceDevice_BlocksKeyWritesAfterLosingLease(t *testing.T) {
+	ctx := context.Background()
+	ds := NewSQLiteDeviceStore(t.TempDir())
+	defer ds.Close()
+
+	device, err := ds.Open(ctx, "channel-f")
+	require.NoError(t, err)
+	owned := true
+	FenceDevice(device, func(ctx context.Context) error {
+		if !owned {
+			return errors.New("fenced")
+		}
+		return nil
+	})
+
+	// Al vincular, sqlstore reemplaza los stores: deben seguir protegidos
+	jid := types.NewADJID("5491100000001", 0, 12)
+	device.ID = &jid
+	device.Account = &waAdv.ADVSignedDeviceIdentity{Details: []byte("d"), AccountSignature: make([]byte, 64), AccountSignatureKey: make([]byte, 32), DeviceSignature: make([]byte, 64)}
+	require.NoError(t, device.Save(ctx))
+	require.NoError(t, device.Sessions.PutSession(ctx, "peer", []byte("s1")))
+
+	owned = false
+	assert.Error(t, device.Sessions.PutSession(ctx, "peer", []byte("s2")))
+	assert.Error(t, device.Save(ctx))
+
+	session, err := device.Sessions.GetSession(ctx, "peer")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("s1"), session, "reads keep working, the stale write never landed")
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"crypto/sha256"
 	"encoding/hex"
+	"fmt"
 	"net/url"
 	"os"
 	"strings"
@@ -12,6 +13,7 @@ import (
 
 	botengine "github.com/AzielCF/az-wap/botengine"
 	botengineDomain "github.com/AzielCF/az-wap/botengine/domain"
+	botengineDomainBot "github.com/AzielCF/az-wap/botengine/domain/bot"
 	clientDomain "github.com/AzielCF/az-wap/clients/domain"
 	coreconfig "github.com/AzielCF/az-wap/core/config"
 	"github.com/AzielCF/az-wap/core/infrastructure/valkey"
@@ -19,6 +21,8 @@ import (
 	"github.com/AzielCF/az-wap/core/pkg/utils"
 	"github.com/AzielCF/az-wap/workspace/application"
 	channelDomain "github.com/AzielCF/az-wap/workspace/domain/channel"
+	"github.com/AzielCF/az-wap/workspace/domain/cluster"
+	commonDomain "github.com/AzielCF/az-wap/workspace/domain/common"
 	messageDomain "github.com/AzielCF/az-wap/workspace/domain/message"
 	"github.com/AzielCF/az-wap/workspace/domain/monitoring"
 	sessionDomain "github.com/AzielCF/az-wap/workspace/domain/session"
@@ -36,8 +40,14 @@ type ClientResolver interface {
 	GetSubscription(ctx context.Context, platformID string, channelID string) (*clientDomain.ClientSubscription, error)
 }
 
+// IdentityLinker vincula la identidad del remitente a un cliente global con un código de un solo uso
+type IdentityLinker interface {
+	// RedeemLinkMessage retorna handled=false si el texto no es un comando de vinculación
+	RedeemLinkMessage(ctx context.Context, text string, platformType clientDomain.PlatformType, platformIDs ...string) (client *clientDomain.Client, handled bool, err error)
+}
+
 type Manager struct {
-	repo            workspaceDomain.IWorkspaceRepository
+	repo            workspaceDomain.ISchedulerRepository
 	botEngine       *botengine.Engine
 	channels        *application.ChannelService
 	sessions        *application.SessionOrchestrator
@@ -45,6 +55,7 @@ type Manager struct {
 	presence        *application.PresenceManager
 	typingStore     channelDomain.TypingStore
 	clientResolver  ClientResolver
+	identityLinker  IdentityLinker
 	monitor         monitoring.MonitoringStore
 	serverID        string
 	startTime       time.Time
@@ -52,10 +63,17 @@ type Manager struct {
 	messageDedup    sync.Map       // Local deduplication for non-Valkey environments
 	scheduler       *application.TaskScheduler
 	lastDBCountTime time.Time
+
+	// Propiedad de canales en el cluster (leases con generación)
+	leases      cluster.LeaseStore
+	heldMu      sync.Mutex
+	heldLeases  map[string]cluster.ChannelLease
+	orphanSince map[string]time.Time
+	reserved    map[string]bool // Canales tomados sin adaptador (export/import de sesión)
 }
 
 func NewManager(
-	repo workspaceDomain.IWorkspaceRepository,
+	repo workspaceDomain.ISchedulerRepository,
 	botEngine *botengine.Engine,
 	clientResolver ClientResolver,
 	typingStore channelDomain.TypingStore,
@@ -72,6 +90,14 @@ func NewManager(
 		serverID:       serverID,
 		startTime:      time.Now(),
 		valkeyClient:   vkClient,
+		heldLeases:     make(map[string]cluster.ChannelLease),
+		orphanSince:    make(map[string]time.Time),
+		reserved:       make(map[string]bool),
+	}
+	if vkClient != nil {
+		m.leases = repository.NewValkeyLeaseStore(vkClient)
+	} else {
+		m.leases = repository.NewMemoryLeaseStore()
 	}
 
 	// 2. Initialize Internal Distributed Stores (Sessions, Presence)
@@ -96,6 +122,12 @@ func NewManager(
 	m.sessions.OnInactivityWarn = m.sendInactivityWarning
 	m.sessions.OnCleanupFiles = m.cleanupSessionFiles
 	m.sessions.OnSessionClosed = m.sendSessionClosedMessage
+	m.sessions.GetChannel = repo.GetChannel
+	m.sessions.OnMarkRead = func(ctx context.Context, channelID, chatID string, ids []string) {
+		if adapter, ok := m.channels.GetAdapter(channelID); ok {
+			_ = adapter.MarkRead(ctx, chatID, ids)
+		}
+	}
 	m.sessions.OnChannelIdle = func(channelID string) {
 		m.presence.CheckChannelPresence(channelID)
 		m.presence.EnsureChannelConnectivity(channelID)
@@ -113,6 +145,14 @@ func NewManager(
 	m.presence.IsChannelActive = func(channelID string) bool {
 		return len(m.GetActiveChats(channelID)) > 0
 	}
+	m.presence.IsOffHours = func(channelID string, now time.Time) bool {
+		ch, err := m.repo.GetChannel(context.Background(), channelID)
+		if err != nil {
+			return channelDomain.ChannelConfig{}.IsOffHours(now)
+		}
+		return ch.Config.IsOffHours(now)
+	}
+	m.processor.OnOffHours = m.handleOffHours
 
 	m.channels.OnAdapterRegistered = func(adapter channelDomain.ChannelAdapter) {
 		m.presence.RegisterAdapter(adapter.ID(), adapter)
@@ -128,12 +168,21 @@ func NewManager(
 	}
 
 	// 8. Initialize Scheduler
-	m.scheduler = application.NewTaskScheduler(repo, vkClient, m.channels, m.acquireLock)
+	m.scheduler = application.NewTaskScheduler(repo, vkClient, m.channels, m.acquireLock, serverID)
+	if botEngine != nil {
+		m.scheduler.SetAITaskRunner(m.runAITask)
+		// Replies resumed after an operator approval bypass ProcessFinal: keep them in session memory
+		botEngine.RegisterResumedReplyHook(func(ctx context.Context, _ botengineDomainBot.Bot, input botengineDomain.BotInput, output botengineDomain.BotOutput) {
+			m.processor.RecordResumedReply(ctx, input, output)
+		})
+	}
 
 	// 9. Start Internal Loops
 	m.StartPresenceLoop(context.Background())
 
-	// Initialize Monitoring Hooks for Global Pool
+	// Initialize Monitoring Hooks and job handlers for Global Pool
+	m.sessions.RegisterJobHandlers(msgworker.GetGlobalPool())
+	m.scheduler.RegisterJobHandlers(msgworker.GetGlobalPool())
 	m.setupMonitoringHooks(msgworker.GetGlobalPool(), "primary")
 
 	// Start Heartbeat Loop
@@ -242,20 +291,37 @@ func (m *Manager) RegisterAdapter(adapter channelDomain.ChannelAdapter) {
 func (m *Manager) UnregisterAdapter(channelID string) {
 	m.channels.UnregisterAdapter(channelID)
 	m.presence.UnregisterAdapter(channelID)
+	m.releaseChannel(context.Background(), channelID)
 }
 
 // UnregisterAndCleanup stops the adapter and deletes all persistent data (DBs, files, and presence)
 func (m *Manager) UnregisterAndCleanup(channelID string) {
 	m.channels.UnregisterAndCleanup(channelID)
 	_ = m.presence.DeleteStatus(context.Background(), channelID)
+	m.releaseChannel(context.Background(), channelID)
 }
 
 func (m *Manager) GetAdapter(channelID string) (channelDomain.ChannelAdapter, bool) {
 	return m.channels.GetAdapter(channelID)
 }
 
+// StartChannel arranca el adaptador del canal en este nodo. Antes toma el lease del canal:
+// si otro nodo vivo lo tiene devuelve un *cluster.HeldError y no se arranca nada.
 func (m *Manager) StartChannel(ctx context.Context, channelID string) error {
-	return m.channels.StartChannel(ctx, channelID, m.handleIncomingMessage)
+	if _, ok := m.channels.GetAdapter(channelID); ok {
+		return nil
+	}
+	if m.isReserved(channelID) {
+		return ErrChannelReserved
+	}
+	if err := m.claimChannel(ctx, channelID); err != nil {
+		return err
+	}
+	if err := m.channels.StartChannel(ctx, channelID, m.handleIncomingMessage); err != nil {
+		m.releaseChannel(ctx, channelID)
+		return err
+	}
+	return nil
 }
 
 func (m *Manager) UpdateChannelConfig(channelID string, config channelDomain.ChannelConfig) {
@@ -288,6 +354,11 @@ func (m *Manager) handleIncomingMessage(adapter channelDomain.ChannelAdapter, ms
 		return
 	}
 
+	// "LINK <código>": el remitente vincula esta plataforma a su cuenta de cliente
+	if m.handleLinkCode(ctx, adapter, ch, msg) {
+		return
+	}
+
 	botID := ch.Config.BotID
 	var clientCtx *botengineDomain.ClientContext
 
@@ -327,21 +398,7 @@ func (m *Manager) handleIncomingMessage(adapter channelDomain.ChannelAdapter, ms
 	if m.clientResolver != nil {
 		pType := string(adapter.Type()) // Use adapter type (e.g., "whatsapp")
 
-		platformID := msg.SenderID
-
-		// Normalization for WhatsApp (JID and LID)
-		platformID = utils.CleanWhatsAppID(platformID)
-
-		// Backup JID (original from platform or phone number)
-		secondaryID := ""
-		if pn, ok := msg.Metadata["sender_pn"].(string); ok && pn != "" {
-			secondaryID = pn
-		} else if jid, ok := msg.Metadata["sender_jid"].(string); ok {
-			secondaryID = jid
-		}
-
-		// Normalize secondaryID too
-		secondaryID = utils.CleanWhatsAppID(secondaryID)
+		platformID, secondaryID := senderIdentity(msg)
 
 		logrus.WithFields(logrus.Fields{
 			"platform_id":   platformID,
@@ -503,6 +560,20 @@ func (m *Manager) GetActiveChats(channelID string) []string {
 	return chats
 }
 
+// GetSessionHistory returns the AI conversation turns kept in the active session of a chat.
+// Returns nil when there is no live session for the chat.
+func (m *Manager) GetSessionHistory(channelID, chatID string) []botengineDomain.ChatTurn {
+	for _, s := range m.sessions.GetActiveSessions() {
+		if s.ChannelID != channelID || s.ChatID != chatID {
+			continue
+		}
+		if entry, ok := m.sessions.GetEntry(s.Key); ok {
+			return entry.Memory.GetHistory()
+		}
+	}
+	return nil
+}
+
 func (m *Manager) PrepareSessionFile(workspaceID, channelID, sessionKey string, fileName string, friendlyName string, mimeType string, fileHash string) (string, error) {
 	return m.processor.PrepareSessionFile(workspaceID, channelID, sessionKey, fileName, friendlyName, mimeType, fileHash)
 }
@@ -704,6 +775,59 @@ func (m *Manager) StartSchedulerLoop(ctx context.Context) {
 	}
 }
 
+// WakeScheduler nudges the local scheduler worker (used when Valkey Pub/Sub is not available).
+func (m *Manager) WakeScheduler() {
+	if m.scheduler != nil {
+		m.scheduler.Wake()
+	}
+}
+
+// runAITask runs a proactive scheduled task: the bot gets the stored instruction with the target
+// chat's client context, and the engine delivers the reply through the channel transport.
+func (m *Manager) runAITask(ctx context.Context, post commonDomain.ScheduledPost, task commonDomain.ScheduledAITask) error {
+	ch, err := m.repo.GetChannel(ctx, post.ChannelID)
+	if err != nil {
+		return fmt.Errorf("channel %s: %w", post.ChannelID, err)
+	}
+
+	botID := ch.Config.BotID
+	var clientCtx *botengineDomain.ClientContext
+	if m.clientResolver != nil {
+		platformID := utils.CleanWhatsAppID(post.TargetID)
+		resolvedCtx, overrideBotID, err := m.clientResolver.Resolve(ctx, platformID, "", string(ch.Type), ch.ID)
+		if err != nil {
+			logrus.WithError(err).Warnf("[WS_MANAGER] Client resolution failed for AI task %s", post.ID)
+		} else if resolvedCtx != nil {
+			clientCtx = resolvedCtx
+			if overrideBotID != "" {
+				botID = overrideBotID
+			}
+		}
+	}
+	if task.BotID != "" {
+		botID = task.BotID
+	}
+	if botID == "" {
+		return fmt.Errorf("no bot assigned to channel %s", ch.ID)
+	}
+	if err := application.AuthorizeTaskBot(clientCtx, botID); err != nil {
+		return err
+	}
+
+	input := m.processor.ProactiveTaskInput(ch, post, task, botID, clientCtx, m.GetSessionHistory(ch.ID, post.TargetID))
+	logrus.Infof("[WS_MANAGER] Running AI task %s with bot %s -> %s", post.ID, botID, post.TargetID)
+
+	output, err := m.botEngine.Process(ctx, input)
+	if err != nil {
+		return err
+	}
+	// Media or an action (e.g. a reaction) sent by the engine count as delivered even without text
+	if strings.TrimSpace(output.Text) == "" && len(output.Medias) == 0 && output.Action == "" {
+		return fmt.Errorf("bot produced no message")
+	}
+	return nil
+}
+
 func (m *Manager) GetChannelPresence(ctx context.Context, channelID string) (*channelDomain.ChannelPresence, error) {
 	return m.presence.GetStatus(ctx, channelID)
 }
@@ -0,0 +1,182 @@
+package workspace
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+
+	botengineDomain "github.com/AzielCF/az-wap/botengine/domain"
+	channelDomain "github.com/AzielCF/az-wap/workspace/domain/channel"
+	commonDomain "github.com/AzielCF/az-wap/workspace/domain/common"
+	messageDomain "github.com/AzielCF/az-wap/workspace/domain/message"
+	"github.com/AzielCF/az-wap/workspace/infrastructure"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	offHoursBacklogLimit = 1800           // Máximo de texto acumulado en una respuesta diferida
+	offHoursNoticeTTL    = 12 * time.Hour // Sin próxima apertura conocida, la plantilla se repite como mucho cada 12h
+)
+
+// handleOffHours applies the channel's off-hours policy to a message received while closed.
+// Returns true when the bot must not reply now.
+func (m *Manager) handleOffHours(ctx context.Context, ch channelDomain.Channel, msg messageDomain.IncomingMessage, botID string) bool {
+	bh := ch.Config.BusinessHours
+	if bh == nil {
+		return false
+	}
+	policy := bh.OffHours
+	loc := ch.Config.Location()
+	now := time.Now()
+	opensAt, hasOpening := bh.NextOpening(now, loc)
+
+	switch policy.Mode {
+	case channelDomain.OffHoursAutoReply:
+		m.sendOffHoursNotice(ctx, ch, msg, opensAt, hasOpening)
+		return true
+
+	case channelDomain.OffHoursDelay:
+		minDelay, maxDelay := policy.DelayRange()
+		delay := minDelay
+		if maxDelay > minDelay {
+			delay += time.Duration(rand.Int63n(int64(maxDelay - minDelay)))
+		}
+		if _, err := m.deferReply(ctx, ch, msg, botID, now.Add(delay)); err != nil {
+			logrus.WithError(err).Warnf("[WS_MANAGER] Could not delay off-hours reply for %s, replying now", msg.ChatID)
+			return false
+		}
+		return true
+
+	case channelDomain.OffHoursDefer:
+		if !hasOpening {
+			// Sin apertura en el horizonte (calendario vacío): solo avisamos
+			m.sendOffHoursNotice(ctx, ch, msg, opensAt, false)
+			return true
+		}
+		created, err := m.deferReply(ctx, ch, msg, botID, opensAt)
+		if err != nil {
+			logrus.WithError(err).Warnf("[WS_MANAGER] Could not defer off-hours reply for %s, replying now", msg.ChatID)
+			return false
+		}
+		if created {
+			m.sendOffHoursNotice(ctx, ch, msg, opensAt, true)
+		}
+		return true
+	}
+	return false
+}
+
+// deferReply schedules an AI task that answers the chat at the given time. Messages that arrive
+// before it runs are appended to the same task so the bot answers them together.
+func (m *Manager) deferReply(ctx context.Context, ch channelDomain.Channel, msg messageDomain.IncomingMessage, botID string, at time.Time) (bool, error) {
+	if m.scheduler == nil {
+		return false, fmt.Errorf("scheduler not available")
+	}
+
+	line := strings.TrimSpace(msg.Text)
+	if line == "" && (msg.Media != nil || len(msg.Medias) > 0) {
+		line = "[the user sent a file]"
+	}
+	line = "- " + line
+
+	posts, err := m.repo.ListScheduledPosts(ctx, ch.ID)
+	if err != nil {
+		return false, err
+	}
+	for _, post := range posts {
+		if post.TargetID != msg.ChatID || !isPendingOffHoursReply(post) {
+			continue
+		}
+		task := post.Payload.Parts[0].AI
+		if len(task.Instruction)+len(line) >= offHoursBacklogLimit {
+			continue // Llena: el mensaje va en otra respuesta pendiente o en una nueva
+		}
+		task.Instruction += "\n" + line
+		updated, err := m.repo.UpdateUnclaimedScheduledPostPayload(ctx, post.ID, post.Payload, time.Now().UTC())
+		if err != nil {
+			return false, err
+		}
+		if updated {
+			return false, nil
+		}
+		// El scheduler ya reclamó esa respuesta: este mensaje va en una tarea nueva
+		break
+	}
+
+	clientID := ""
+	if cc, ok := msg.Metadata["client_context"].(*botengineDomain.ClientContext); ok && cc != nil {
+		clientID = cc.ClientID
+	}
+
+	now := time.Now().UTC()
+	post := commonDomain.ScheduledPost{
+		ID:          uuid.NewString(),
+		ChannelID:   ch.ID,
+		TargetID:    msg.ChatID,
+		SenderID:    msg.SenderID,
+		Text:        "Off-hours reply",
+		ScheduledAt: at.UTC(),
+		Status:      commonDomain.ScheduledPostStatusPending,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+		Payload: &commonDomain.ScheduledPayload{Parts: []commonDomain.ScheduledPayloadPart{{
+			Type: commonDomain.PayloadPartAI,
+			AI: &commonDomain.ScheduledAITask{
+				Instruction: line,
+				BotID:       botID,
+				ClientID:    clientID,
+				Origin:      commonDomain.AITaskOriginOffHours,
+			},
+		}}},
+	}
+	if err := m.scheduler.Schedule(ctx, post); err != nil {
+		return false, err
+	}
+	logrus.Infof("[WS_MANAGER] Off-hours reply for %s deferred until %s (channel %s)", msg.ChatID, at.Format(time.RFC3339), ch.ID)
+	return true, nil
+}
+
+// sendOffHoursNotice sends the off-hours template once per closed window and chat.
+func (m *Manager) sendOffHoursNotice(ctx context.Context, ch channelDomain.Channel, msg messageDomain.IncomingMessage, opensAt time.Time, hasOpening bool) {
+	policy := ch.Config.BusinessHours.OffHours
+	text := policy.Template(msg.Language)
+	if text == "" {
+		return
+	}
+
+	ttl := offHoursNoticeTTL
+	opens := ""
+	if hasOpening {
+		ttl = time.Until(opensAt)
+		opens = opensAt.In(ch.Config.Location()).Format("2006-01-02 15:04")
+	}
+	if ttl <= 0 || !m.acquireLock("offhours:"+ch.ID+":"+msg.ChatID, ttl) {
+		return
+	}
+	text = strings.ReplaceAll(text, "{{opens_at}}", opens)
+
+	adapter, ok := m.channels.GetAdapter(ch.ID)
+	if !ok {
+		return
+	}
+	wrapper := &infrastructure.BotTransportAdapter{Adapter: adapter}
+	m.botEngine.Humanizer().SimulateTyping(ctx, wrapper, msg.ChatID, text)
+	if _, err := adapter.SendMessage(ctx, msg.ChatID, text, ""); err != nil {
+		logrus.WithError(err).Warnf("[WS_MANAGER] Failed to send off-hours notice to %s", msg.ChatID)
+	}
+}
+
+// isPendingOffHoursReply reports whether the post is a deferred reply that has not run yet
+func isPendingOffHoursReply(post commonDomain.ScheduledPost) bool {
+	if post.Status != commonDomain.ScheduledPostStatusPending && post.Status != commonDomain.ScheduledPostStatusEnqueued {
+		return false
+	}
+	if post.Payload == nil || len(post.Payload.Parts) != 1 {
+		return false
+	}
+	part := post.Payload.Parts[0]
+	return part.Type == commonDomain.PayloadPartAI && part.AI != nil && part.AI.Origin == commonDomain.AITaskOriginOffHours
+}
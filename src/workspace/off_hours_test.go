@@ -0,0 +1,61 @@
+package workspace
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/AzielCF/az-wap/workspace/application"
+	channelDomain "github.com/AzielCF/az-wap/workspace/domain/channel"
+	messageDomain "github.com/AzielCF/az-wap/workspace/domain/message"
+	"github.com/AzielCF/az-wap/workspace/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestDeferReply_BacklogLimitStartsNewTask(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "workspaces.db")), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.NoError(t, err)
+	repo := repository.NewWorkspaceGormRepository(db)
+	require.NoError(t, repo.Init(context.Background()))
+
+	m := &Manager{repo: repo}
+	m.scheduler = application.NewTaskScheduler(repo, nil, nil, nil, "test-node")
+
+	ctx := context.Background()
+	ch := channelDomain.Channel{ID: "chan1"}
+	at := time.Now().Add(time.Hour)
+	text := strings.Repeat("x", 100)
+
+	// Suficientes mensajes para pasar el límite de una respuesta diferida
+	total := offHoursBacklogLimit/len(text) + 5
+	created := 0
+	for i := 0; i < total; i++ {
+		msg := messageDomain.IncomingMessage{ChatID: "123@s.whatsapp.net", SenderID: "123@s.whatsapp.net", Text: fmt.Sprintf("%d %s", i, text)}
+		ok, err := m.deferReply(ctx, ch, msg, "bot1", at)
+		require.NoError(t, err)
+		if ok {
+			created++
+		}
+	}
+
+	posts, err := repo.ListScheduledPosts(ctx, ch.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, created)
+	require.Len(t, posts, 2)
+
+	// Ningún mensaje se pierde: cada uno está en alguna de las respuestas
+	lines := 0
+	for _, post := range posts {
+		instruction := post.Payload.Parts[0].AI.Instruction
+		assert.Less(t, len(instruction), offHoursBacklogLimit)
+		lines += len(strings.Split(instruction, "\n"))
+	}
+	assert.Equal(t, total, lines)
+}
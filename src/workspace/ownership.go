@@ -0,0 +1,492 @@
+package workspace
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	channelDomain "github.com/AzielCF/az-wap/workspace/domain/channel"
+	"github.com/AzielCF/az-wap/workspace/domain/cluster"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	channelLeaseTTL   = 45 * time.Second // Un nodo caído pierde sus canales como mucho tras este tiempo
+	ownershipInterval = 15 * time.Second // Renovación de leases y reconciliación
+	// El vencimiento local se cuenta desde antes de pedir el lease y con este margen: así el nodo
+	// deja de considerarse dueño antes de que el lease expire en Valkey (latencia, pausas del GC)
+	leaseSafetyMargin = 5 * time.Second
+	nodeLiveWindow    = 75 * time.Second // Heartbeat cada 30s: más de dos perdidos = nodo caído
+	// Si el nodo elegido no toma un canal huérfano en este tiempo (ej. proceso sin servidor), lo toma cualquiera
+	orphanGrace = 2 * channelLeaseTTL
+)
+
+var (
+	ErrChannelRunning  = errors.New("channel is running, stop it first")
+	ErrChannelReserved = errors.New("channel is reserved by an export or import in progress")
+)
+
+// ClusterEnabled indica si hay varios nodos posibles (modo Valkey) y la propiedad de canales se coordina.
+func (m *Manager) ClusterEnabled() bool {
+	return m.valkeyClient != nil
+}
+
+// claimChannel adquiere el lease del canal para este nodo antes de arrancar su adaptador.
+func (m *Manager) claimChannel(ctx context.Context, channelID string) error {
+	started := time.Now()
+	lease, owned, err := m.leases.Acquire(ctx, channelID, m.serverID, channelLeaseTTL)
+	if err != nil {
+		return fmt.Errorf("failed to acquire channel lease: %w", err)
+	}
+	if !owned {
+		return &cluster.HeldError{Lease: lease}
+	}
+	lease.ExpiresAt = started.Add(channelLeaseTTL - leaseSafetyMargin)
+
+	m.heldMu.Lock()
+	prev, had := m.heldLeases[channelID]
+	m.heldLeases[channelID] = lease
+	m.heldMu.Unlock()
+
+	if !had || prev.Token != lease.Token {
+		logrus.Infof("[CLUSTER] Node %s owns channel %s (token %d)", m.serverID, channelID, lease.Token)
+	}
+	return nil
+}
+
+// releaseChannel suelta el lease del canal si este nodo lo tenía.
+func (m *Manager) releaseChannel(ctx context.Context, channelID string) {
+	m.heldMu.Lock()
+	lease, ok := m.heldLeases[channelID]
+	delete(m.heldLeases, channelID)
+	m.heldMu.Unlock()
+
+	if !ok {
+		return
+	}
+	if err := m.leases.Release(ctx, lease); err != nil {
+		logrus.WithError(err).Warnf("[CLUSTER] Failed to release lease of channel %s", channelID)
+	}
+}
+
+// CheckChannelFence confirma que este nodo sigue siendo dueño del canal con la misma generación.
+// Los adaptadores la llaman antes de cada envío y de cada escritura de claves del dispositivo:
+// un nodo que perdió el canal (o no puede confirmarlo con Valkey) no envía ni rota claves.
+func (m *Manager) CheckChannelFence(ctx context.Context, channelID string) error {
+	if !m.ClusterEnabled() {
+		return nil
+	}
+	m.heldMu.Lock()
+	lease, held := m.heldLeases[channelID]
+	m.heldMu.Unlock()
+
+	if !held || time.Now().After(lease.ExpiresAt) {
+		return fmt.Errorf("%w: %s", cluster.ErrFenced, channelID)
+	}
+	ok, err := m.leases.Check(ctx, lease)
+	if err != nil {
+		return fmt.Errorf("%w: %s: %v", cluster.ErrFenced, channelID, err)
+	}
+	if !ok {
+		return fmt.Errorf("%w: %s (token %d)", cluster.ErrFenced, channelID, lease.Token)
+	}
+	return nil
+}
+
+// stopLocal detiene el adaptador sin tocar el lease (ya no es nuestro).
+func (m *Manager) stopLocal(channelID string) {
+	m.heldMu.Lock()
+	delete(m.heldLeases, channelID)
+	m.heldMu.Unlock()
+
+	m.channels.UnregisterAdapter(channelID)
+	m.presence.UnregisterAdapter(channelID)
+}
+
+// ReserveChannel toma el lease de un canal detenido para operar sobre su sesión (exportar o importar
+// las claves del dispositivo) sin que ningún nodo lo arranque mientras tanto. El adaptador no puede
+// estar corriendo: whatsmeow rota claves en cada mensaje y una copia tomada en caliente quedaría desfasada.
+// La función devuelta suelta la reserva y el lease.
+func (m *Manager) ReserveChannel(ctx context.Context, channelID string) (func(), error) {
+	if _, ok := m.channels.GetAdapter(channelID); ok {
+		return nil, ErrChannelRunning
+	}
+
+	m.heldMu.Lock()
+	if m.reserved[channelID] {
+		m.heldMu.Unlock()
+		return nil, ErrChannelReserved
+	}
+	m.reserved[channelID] = true
+	m.heldMu.Unlock()
+
+	unreserve := func() {
+		m.heldMu.Lock()
+		delete(m.reserved, channelID)
+		m.heldMu.Unlock()
+	}
+	if err := m.claimChannel(ctx, channelID); err != nil {
+		unreserve()
+		return nil, err
+	}
+	// Pudo arrancar entre la comprobación y la reserva
+	if _, ok := m.channels.GetAdapter(channelID); ok {
+		unreserve()
+		return nil, ErrChannelRunning
+	}
+	return func() {
+		unreserve()
+		m.releaseChannel(context.Background(), channelID)
+	}, nil
+}
+
+func (m *Manager) isReserved(channelID string) bool {
+	m.heldMu.Lock()
+	defer m.heldMu.Unlock()
+	return m.reserved[channelID]
+}
+
+// StartOwnershipLoop renueva los leases de este nodo, entrega canales (drenado o movimiento)
+// y adopta los canales huérfanos que le corresponden. Sustituye al auto-arranque en modo cluster.
+func (m *Manager) StartOwnershipLoop(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(ownershipInterval)
+		defer ticker.Stop()
+
+		m.reconcileOwnership(ctx)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				m.reconcileOwnership(ctx)
+			}
+		}
+	}()
+}
+
+func (m *Manager) reconcileOwnership(ctx context.Context) {
+	topo, err := m.clusterTopology(ctx)
+	if err != nil {
+		logrus.WithError(err).Warn("[CLUSTER] Failed to read cluster topology")
+		return
+	}
+
+	m.renewLeases(ctx)
+	m.handOff(topo)
+	m.adoptChannels(ctx, topo)
+}
+
+// renewLeases extiende los leases de los adaptadores locales y de los canales reservados. Si otro
+// nodo tiene un token más nuevo (o el lease expiró sin poder renovarlo) el adaptador local se detiene;
+// una reserva que pierde su lease deja de estar cubierta y CheckChannelFence lo rechaza.
+func (m *Manager) renewLeases(ctx context.Context) {
+	for _, adapter := range m.channels.GetAdapters() {
+		channelID := adapter.ID()
+
+		m.heldMu.Lock()
+		lease, held := m.heldLeases[channelID]
+		m.heldMu.Unlock()
+
+		// Adaptador registrado sin pasar por StartChannel: reclamamos el lease o lo apagamos
+		if !held {
+			if err := m.claimChannel(ctx, channelID); err != nil {
+				if errors.Is(err, cluster.ErrLeaseHeld) {
+					logrus.Warnf("[CLUSTER] Stopping duplicate adapter for channel %s: %v", channelID, err)
+					m.stopLocal(channelID)
+				}
+			}
+			continue
+		}
+
+		if !m.renewLease(ctx, lease) {
+			logrus.Warnf("[CLUSTER] Lost lease of channel %s (token %d), stopping adapter", channelID, lease.Token)
+			m.stopLocal(channelID)
+		}
+	}
+
+	// Las reservas (export/import de sesión) no tienen adaptador pero retienen el lease hasta soltarse
+	for _, lease := range m.reservedLeases() {
+		if !m.renewLease(ctx, lease) {
+			logrus.Warnf("[CLUSTER] Lost lease of reserved channel %s (token %d)", lease.ChannelID, lease.Token)
+			m.heldMu.Lock()
+			if cur, ok := m.heldLeases[lease.ChannelID]; ok && cur.Token == lease.Token {
+				delete(m.heldLeases, lease.ChannelID)
+			}
+			m.heldMu.Unlock()
+		}
+	}
+}
+
+// renewLease extiende un lease propio y actualiza su vencimiento local. Devuelve false si el lease
+// se perdió; un error transitorio con el lease aún vigente no cuenta como pérdida.
+func (m *Manager) renewLease(ctx context.Context, lease cluster.ChannelLease) bool {
+	started := time.Now()
+	ok, err := m.leases.Renew(ctx, lease, channelLeaseTTL)
+	switch {
+	case err != nil && time.Now().After(lease.ExpiresAt):
+		logrus.WithError(err).Warnf("[CLUSTER] Lease of channel %s expired without renewal", lease.ChannelID)
+		return false
+	case err != nil:
+		logrus.WithError(err).Warnf("[CLUSTER] Failed to renew lease of channel %s", lease.ChannelID)
+		return true
+	case !ok:
+		return false
+	}
+	lease.ExpiresAt = started.Add(channelLeaseTTL - leaseSafetyMargin)
+	m.heldMu.Lock()
+	if cur, ok := m.heldLeases[lease.ChannelID]; ok && cur.Token == lease.Token {
+		m.heldLeases[lease.ChannelID] = lease
+	}
+	m.heldMu.Unlock()
+	return true
+}
+
+// reservedLeases devuelve los leases de los canales reservados que siguen sin adaptador.
+func (m *Manager) reservedLeases() []cluster.ChannelLease {
+	m.heldMu.Lock()
+	defer m.heldMu.Unlock()
+	var result []cluster.ChannelLease
+	for channelID := range m.reserved {
+		if lease, ok := m.heldLeases[channelID]; ok {
+			result = append(result, lease)
+		}
+	}
+	return result
+}
+
+// handOff entrega los canales que el operador movió a otro nodo o que este nodo debe drenar.
+// Un nodo nuevo no provoca reubicaciones: reconectar whatsmeow tiene costo y no hay motivo.
+func (m *Manager) handOff(topo cluster.Topology) {
+	for _, channelID := range m.heldChannelIDs() {
+		if m.isReserved(channelID) {
+			continue
+		}
+		target := topo.Owner(channelID)
+		if target == "" || target == m.serverID {
+			continue
+		}
+		if !topo.Draining[m.serverID] && topo.Pins[channelID] != target {
+			continue
+		}
+		logrus.Infof("[CLUSTER] Handing off channel %s to node %s", channelID, target)
+		m.UnregisterAdapter(channelID)
+	}
+}
+
+// adoptChannels arranca los canales auto-arrancables sin dueño que la topología asigna a este nodo.
+func (m *Manager) adoptChannels(ctx context.Context, topo cluster.Topology) {
+	channels, err := m.autoStartChannels(ctx)
+	if err != nil {
+		logrus.WithError(err).Warn("[CLUSTER] Failed to list channels for ownership")
+		return
+	}
+
+	now := time.Now()
+	seen := make(map[string]bool, len(channels))
+	for _, ch := range channels {
+		seen[ch.ID] = true
+		if _, running := m.channels.GetAdapter(ch.ID); running {
+			delete(m.orphanSince, ch.ID)
+			continue
+		}
+
+		lease, err := m.leases.Get(ctx, ch.ID)
+		if err != nil {
+			continue
+		}
+		if lease != nil {
+			delete(m.orphanSince, ch.ID)
+			continue
+		}
+
+		since, ok := m.orphanSince[ch.ID]
+		if !ok {
+			since = now
+			m.orphanSince[ch.ID] = now
+		}
+		desired := topo.Owner(ch.ID)
+		if desired != m.serverID && now.Sub(since) < orphanGrace {
+			continue
+		}
+		// Un nodo drenando solo adopta si ningún otro nodo puede hacerlo
+		if topo.Draining[m.serverID] && desired != "" {
+			continue
+		}
+
+		if err := m.StartChannel(ctx, ch.ID); err != nil {
+			if !errors.Is(err, cluster.ErrLeaseHeld) {
+				logrus.WithError(err).Warnf("[CLUSTER] Failed to adopt channel %s", ch.ID)
+			}
+			continue
+		}
+		delete(m.orphanSince, ch.ID)
+		logrus.Infof("[CLUSTER] Node %s adopted channel %s", m.serverID, ch.ID)
+	}
+
+	for id := range m.orphanSince {
+		if !seen[id] {
+			delete(m.orphanSince, id)
+		}
+	}
+}
+
+// autoStartChannels aplica la misma regla que StartEnabledChannels: workspace y canal habilitados
+// y el canal estuvo conectado (evita crear sesiones vacías de canales nunca vinculados).
+func (m *Manager) autoStartChannels(ctx context.Context) ([]channelDomain.Channel, error) {
+	workspaces, err := m.repo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var result []channelDomain.Channel
+	for _, ws := range workspaces {
+		if !ws.Enabled {
+			continue
+		}
+		channels, err := m.repo.ListChannels(ctx, ws.ID)
+		if err != nil {
+			continue
+		}
+		for _, ch := range channels {
+			if ch.Enabled && ch.Status == channelDomain.ChannelStatusConnected {
+				result = append(result, ch)
+			}
+		}
+	}
+	return result, nil
+}
+
+func (m *Manager) heldChannelIDs() []string {
+	m.heldMu.Lock()
+	defer m.heldMu.Unlock()
+	ids := make([]string, 0, len(m.heldLeases))
+	for id := range m.heldLeases {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// clusterTopology arma la vista actual: nodos con heartbeat reciente, drenados y pins.
+func (m *Manager) clusterTopology(ctx context.Context) (cluster.Topology, error) {
+	servers, err := m.monitor.GetActiveServers(ctx)
+	if err != nil {
+		return cluster.Topology{}, err
+	}
+	topo := cluster.Topology{Draining: map[string]bool{}}
+	self := false
+	for _, s := range servers {
+		if time.Since(s.LastSeen) > nodeLiveWindow {
+			continue
+		}
+		topo.Nodes = append(topo.Nodes, s.ID)
+		self = self || s.ID == m.serverID
+	}
+	if !self {
+		topo.Nodes = append(topo.Nodes, m.serverID)
+	}
+	sort.Strings(topo.Nodes)
+
+	draining, err := m.leases.DrainingNodes(ctx)
+	if err != nil {
+		return cluster.Topology{}, err
+	}
+	for _, n := range draining {
+		topo.Draining[n] = true
+	}
+	if topo.Pins, err = m.leases.Pins(ctx); err != nil {
+		return cluster.Topology{}, err
+	}
+	return topo, nil
+}
+
+// ClusterStatus devuelve dónde corre cada canal y dónde debería correr.
+func (m *Manager) ClusterStatus(ctx context.Context) (cluster.Status, error) {
+	topo, err := m.clusterTopology(ctx)
+	if err != nil {
+		return cluster.Status{}, err
+	}
+	leases, err := m.leases.List(ctx)
+	if err != nil {
+		return cluster.Status{}, err
+	}
+	channels, err := m.autoStartChannels(ctx)
+	if err != nil {
+		return cluster.Status{}, err
+	}
+
+	byChannel := make(map[string]cluster.ChannelLease, len(leases))
+	for _, l := range leases {
+		byChannel[l.ChannelID] = l
+	}
+	ids := make(map[string]bool, len(channels)+len(leases))
+	for _, ch := range channels {
+		ids[ch.ID] = true
+	}
+	for id := range byChannel {
+		ids[id] = true
+	}
+
+	status := cluster.Status{NodeID: m.serverID, Nodes: topo.Nodes, Draining: []string{}}
+	for n := range topo.Draining {
+		status.Draining = append(status.Draining, n)
+	}
+	sort.Strings(status.Draining)
+
+	for id := range ids {
+		p := cluster.ChannelPlacement{ChannelID: id, Desired: topo.Owner(id), PinnedTo: topo.Pins[id]}
+		if l, ok := byChannel[id]; ok {
+			expires := l.ExpiresAt
+			p.Owner, p.Token, p.ExpiresAt = l.NodeID, l.Token, &expires
+		}
+		status.Channels = append(status.Channels, p)
+	}
+	sort.Slice(status.Channels, func(i, j int) bool { return status.Channels[i].ChannelID < status.Channels[j].ChannelID })
+	return status, nil
+}
+
+// DrainNode marca (o desmarca) un nodo para que entregue sus canales a los demás.
+func (m *Manager) DrainNode(ctx context.Context, nodeID string, drain bool) error {
+	if err := m.leases.SetDraining(ctx, nodeID, drain); err != nil {
+		return err
+	}
+	logrus.Infof("[CLUSTER] Node %s draining=%v", nodeID, drain)
+	return nil
+}
+
+// MoveChannel fija un canal a un nodo; el dueño actual lo entrega en su próxima reconciliación.
+// Con nodeID vacío se quita el pin y el canal vuelve a la ubicación por hash.
+func (m *Manager) MoveChannel(ctx context.Context, channelID, nodeID string) error {
+	if _, err := m.repo.GetChannel(ctx, channelID); err != nil {
+		return err
+	}
+	if nodeID != "" {
+		topo, err := m.clusterTopology(ctx)
+		if err != nil {
+			return err
+		}
+		if !topo.IsAlive(nodeID) || topo.Draining[nodeID] {
+			return fmt.Errorf("%w: %s", cluster.ErrNodeUnavailable, nodeID)
+		}
+	}
+	if err := m.leases.SetPin(ctx, channelID, nodeID); err != nil {
+		return err
+	}
+	logrus.Infof("[CLUSTER] Channel %s pinned to node %q", channelID, nodeID)
+	return nil
+}
+
+// HandOffChannels detiene los adaptadores de este nodo y suelta sus leases para que
+// otro nodo los tome de inmediato en vez de esperar a que expiren (apagado ordenado).
+func (m *Manager) HandOffChannels(ctx context.Context) {
+	if !m.ClusterEnabled() {
+		return
+	}
+	for _, channelID := range m.heldChannelIDs() {
+		m.channels.UnregisterAdapter(channelID)
+		m.presence.UnregisterAdapter(channelID)
+		m.releaseChannel(ctx, channelID)
+	}
+}
@@ -0,0 +1,77 @@
+package workspace
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/AzielCF/az-wap/workspace/application"
+	"github.com/AzielCF/az-wap/workspace/domain/cluster"
+	"github.com/AzielCF/az-wap/workspace/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newOwnershipManager(leases cluster.LeaseStore) *Manager {
+	return &Manager{
+		serverID:   "node-a",
+		channels:   application.NewChannelService(nil, nil, nil),
+		leases:     leases,
+		heldLeases: make(map[string]cluster.ChannelLease),
+		reserved:   make(map[string]bool),
+	}
+}
+
+func TestRenewLeases_RenewsReservedChannels(t *testing.T) {
+	ctx := context.Background()
+	store := repository.NewMemoryLeaseStore()
+	m := newOwnershipManager(store)
+
+	release, err := m.ReserveChannel(ctx, "ch1")
+	require.NoError(t, err)
+
+	before, err := store.Get(ctx, "ch1")
+	require.NoError(t, err)
+	require.NotNil(t, before)
+
+	time.Sleep(20 * time.Millisecond)
+	m.renewLeases(ctx)
+
+	after, err := store.Get(ctx, "ch1")
+	require.NoError(t, err)
+	require.NotNil(t, after)
+	assert.Equal(t, before.Token, after.Token)
+	assert.True(t, after.ExpiresAt.After(before.ExpiresAt), "reserved lease must be extended")
+
+	release()
+	m.renewLeases(ctx)
+
+	gone, err := store.Get(ctx, "ch1")
+	require.NoError(t, err)
+	assert.Nil(t, gone, "released reservation must not be renewed again")
+}
+
+func TestRenewLeases_DropsLostReservedLease(t *testing.T) {
+	ctx := context.Background()
+	store := repository.NewMemoryLeaseStore()
+	m := newOwnershipManager(store)
+
+	release, err := m.ReserveChannel(ctx, "ch1")
+	require.NoError(t, err)
+	defer release()
+
+	// Otro nodo toma el canal (el lease se soltó fuera de este nodo)
+	held := m.heldLeases["ch1"]
+	require.NoError(t, store.Release(ctx, held))
+	_, owned, err := store.Acquire(ctx, "ch1", "node-b", channelLeaseTTL)
+	require.NoError(t, err)
+	require.True(t, owned)
+
+	m.renewLeases(ctx)
+
+	assert.NotContains(t, m.heldChannelIDs(), "ch1")
+	cur, err := store.Get(ctx, "ch1")
+	require.NoError(t, err)
+	require.NotNil(t, cur)
+	assert.Equal(t, "node-b", cur.NodeID)
+}
@@ -0,0 +1,158 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/AzielCF/az-wap/workspace/domain/cluster"
+)
+
+// MemoryLeaseStore implementa cluster.LeaseStore en memoria (modo de un solo nodo).
+type MemoryLeaseStore struct {
+	mu          sync.Mutex
+	leases      map[string]cluster.ChannelLease
+	generations map[string]int64
+	draining    map[string]bool
+	pins        map[string]string
+}
+
+// NewMemoryLeaseStore crea una nueva instancia de MemoryLeaseStore.
+func NewMemoryLeaseStore() *MemoryLeaseStore {
+	return &MemoryLeaseStore{
+		leases:      make(map[string]cluster.ChannelLease),
+		generations: make(map[string]int64),
+		draining:    make(map[string]bool),
+		pins:        make(map[string]string),
+	}
+}
+
+func (s *MemoryLeaseStore) Acquire(ctx context.Context, channelID, nodeID string, ttl time.Duration) (cluster.ChannelLease, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if cur, ok := s.leases[channelID]; ok && now.Before(cur.ExpiresAt) {
+		if cur.NodeID != nodeID {
+			return cur, false, nil
+		}
+		cur.ExpiresAt = now.Add(ttl)
+		s.leases[channelID] = cur
+		return cur, true, nil
+	}
+
+	s.generations[channelID]++
+	lease := cluster.ChannelLease{
+		ChannelID: channelID,
+		NodeID:    nodeID,
+		Token:     s.generations[channelID],
+		ExpiresAt: now.Add(ttl),
+	}
+	s.leases[channelID] = lease
+	return lease, true, nil
+}
+
+func (s *MemoryLeaseStore) Renew(ctx context.Context, lease cluster.ChannelLease, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cur, ok := s.leases[lease.ChannelID]
+	if !ok || cur.NodeID != lease.NodeID || cur.Token != lease.Token || time.Now().After(cur.ExpiresAt) {
+		return false, nil
+	}
+	cur.ExpiresAt = time.Now().Add(ttl)
+	s.leases[lease.ChannelID] = cur
+	return true, nil
+}
+
+func (s *MemoryLeaseStore) Release(ctx context.Context, lease cluster.ChannelLease) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if cur, ok := s.leases[lease.ChannelID]; ok && cur.NodeID == lease.NodeID && cur.Token == lease.Token {
+		delete(s.leases, lease.ChannelID)
+	}
+	return nil
+}
+
+func (s *MemoryLeaseStore) Check(ctx context.Context, lease cluster.ChannelLease) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cur, ok := s.leases[lease.ChannelID]
+	return ok && cur.NodeID == lease.NodeID && cur.Token == lease.Token && time.Now().Before(cur.ExpiresAt), nil
+}
+
+func (s *MemoryLeaseStore) Get(ctx context.Context, channelID string) (*cluster.ChannelLease, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cur, ok := s.leases[channelID]
+	if !ok || time.Now().After(cur.ExpiresAt) {
+		return nil, nil
+	}
+	return &cur, nil
+}
+
+func (s *MemoryLeaseStore) List(ctx context.Context) ([]cluster.ChannelLease, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	result := make([]cluster.ChannelLease, 0, len(s.leases))
+	for _, l := range s.leases {
+		if now.Before(l.ExpiresAt) {
+			result = append(result, l)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ChannelID < result[j].ChannelID })
+	return result, nil
+}
+
+func (s *MemoryLeaseStore) SetDraining(ctx context.Context, nodeID string, draining bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if draining {
+		s.draining[nodeID] = true
+	} else {
+		delete(s.draining, nodeID)
+	}
+	return nil
+}
+
+func (s *MemoryLeaseStore) DrainingNodes(ctx context.Context) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	nodes := make([]string, 0, len(s.draining))
+	for n := range s.draining {
+		nodes = append(nodes, n)
+	}
+	sort.Strings(nodes)
+	return nodes, nil
+}
+
+func (s *MemoryLeaseStore) SetPin(ctx context.Context, channelID, nodeID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if nodeID == "" {
+		delete(s.pins, channelID)
+	} else {
+		s.pins[channelID] = nodeID
+	}
+	return nil
+}
+
+func (s *MemoryLeaseStore) Pins(ctx context.Context) (map[string]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pins := make(map[string]string, len(s.pins))
+	for k, v := range s.pins {
+		pins[k] = v
+	}
+	return pins, nil
+}
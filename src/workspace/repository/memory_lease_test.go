@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryLeaseStore_Generations(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryLeaseStore()
+
+	first, owned, err := store.Acquire(ctx, "ch-1", "node-a", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, owned)
+
+	// Otro nodo no puede tomarlo mientras el lease está vigente
+	held, owned, err := store.Acquire(ctx, "ch-1", "node-b", time.Minute)
+	require.NoError(t, err)
+	assert.False(t, owned)
+	assert.Equal(t, "node-a", held.NodeID)
+
+	// Expirado, el nuevo dueño recibe un token mayor y el anterior ya no puede renovar ni liberar
+	_, _, _ = store.Acquire(ctx, "ch-1", "node-a", -time.Second)
+	second, owned, err := store.Acquire(ctx, "ch-1", "node-b", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, owned)
+	assert.Greater(t, second.Token, first.Token)
+
+	ok, err := store.Renew(ctx, first, time.Minute)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	// Fencing: el token anterior ya no pasa la comprobación, el nuevo sí
+	ok, err = store.Check(ctx, first)
+	require.NoError(t, err)
+	assert.False(t, ok)
+	ok, err = store.Check(ctx, second)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	require.NoError(t, store.Release(ctx, first))
+	cur, err := store.Get(ctx, "ch-1")
+	require.NoError(t, err)
+	require.NotNil(t, cur)
+	assert.Equal(t, "node-b", cur.NodeID)
+
+	require.NoError(t, store.Release(ctx, second))
+	cur, err = store.Get(ctx, "ch-1")
+	require.NoError(t, err)
+	assert.Nil(t, cur)
+}
@@ -92,6 +92,26 @@ func (r *SQLiteRepository) Init(ctx context.Context) error {
 		`ALTER TABLE scheduled_posts ADD COLUMN recurrence_days TEXT DEFAULT '';`,
 		`ALTER TABLE scheduled_posts ADD COLUMN original_time TEXT DEFAULT '';`,
 		`ALTER TABLE scheduled_posts ADD COLUMN execution_count INTEGER DEFAULT 0;`,
+		// Migration for scheduler leasing
+		`ALTER TABLE scheduled_posts ADD COLUMN lease_owner TEXT DEFAULT '';`,
+		`ALTER TABLE scheduled_posts ADD COLUMN lease_until DATETIME;`,
+		// Migration for RFC 5545 recurrence
+		`ALTER TABLE scheduled_posts ADD COLUMN rrule TEXT DEFAULT '';`,
+		`ALTER TABLE scheduled_posts ADD COLUMN timezone TEXT DEFAULT '';`,
+		`ALTER TABLE scheduled_posts ADD COLUMN dtstart DATETIME;`,
+		`ALTER TABLE scheduled_posts ADD COLUMN occurrence_at DATETIME;`,
+		// Migration for typed payloads
+		`ALTER TABLE scheduled_posts ADD COLUMN payload TEXT;`,
+		`CREATE TABLE IF NOT EXISTS scheduled_post_executions (
+			id TEXT PRIMARY KEY,
+			post_id TEXT NOT NULL,
+			channel_id TEXT NOT NULL,
+			occurrence_at DATETIME NOT NULL,
+			executed_at DATETIME NOT NULL,
+			status TEXT NOT NULL,
+			error TEXT
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_scheduled_post_executions_post ON scheduled_post_executions(post_id);`,
 		// Migration: Convert empty external_ref to NULL to fix UNIQUE constraint issue
 		`UPDATE channels SET external_ref = NULL WHERE external_ref = '';`,
 		// Client Workspaces
@@ -470,19 +490,28 @@ func (r *SQLiteRepository) AddChannelComplexCost(ctx context.Context, channelID
 // Scheduled Post CRUD
 
 func (r *SQLiteRepository) CreateScheduledPost(ctx context.Context, post common.ScheduledPost) error {
-	query := `INSERT INTO scheduled_posts (id, channel_id, target_id, sender_id, text, media_path, media_type, scheduled_at, status, error, created_at, updated_at, recurrence_days, original_time, execution_count) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
-	_, err := r.db.ExecContext(ctx, query, post.ID, post.ChannelID, post.TargetID, post.SenderID, post.Text, post.MediaPath, post.MediaType, post.ScheduledAt, post.Status, post.Error, post.CreatedAt, post.UpdatedAt, post.RecurrenceDays, post.OriginalTime, post.ExecutionCount)
+	query := `INSERT INTO scheduled_posts (id, channel_id, target_id, sender_id, text, media_path, media_type, scheduled_at, status, error, created_at, updated_at, recurrence_days, original_time, execution_count, rrule, timezone, dtstart, occurrence_at, payload) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	_, err := r.db.ExecContext(ctx, query, post.ID, post.ChannelID, post.TargetID, post.SenderID, post.Text, post.MediaPath, post.MediaType, post.ScheduledAt, post.Status, post.Error, post.CreatedAt, post.UpdatedAt, post.RecurrenceDays, post.OriginalTime, post.ExecutionCount, post.RRule, post.Timezone, post.DTStart, post.OccurrenceAt, marshalScheduledPayload(post.Payload))
 	return err
 }
 
 func (r *SQLiteRepository) GetScheduledPost(ctx context.Context, id string) (common.ScheduledPost, error) {
-	query := `SELECT id, channel_id, target_id, sender_id, text, media_path, media_type, scheduled_at, status, error, created_at, updated_at FROM scheduled_posts WHERE id = ?`
+	query := `SELECT id, channel_id, target_id, sender_id, text, media_path, media_type, scheduled_at, status, error, created_at, updated_at, recurrence_days, original_time, execution_count, rrule, timezone, dtstart, occurrence_at, payload FROM scheduled_posts WHERE id = ?`
 	row := r.db.QueryRowContext(ctx, query, id)
 
 	var post common.ScheduledPost
-	if err := row.Scan(&post.ID, &post.ChannelID, &post.TargetID, &post.SenderID, &post.Text, &post.MediaPath, &post.MediaType, &post.ScheduledAt, &post.Status, &post.Error, &post.CreatedAt, &post.UpdatedAt, &post.RecurrenceDays, &post.OriginalTime, &post.ExecutionCount); err != nil {
+	var dtstart, occurrenceAt sql.NullTime
+	var payload sql.NullString
+	if err := row.Scan(&post.ID, &post.ChannelID, &post.TargetID, &post.SenderID, &post.Text, &post.MediaPath, &post.MediaType, &post.ScheduledAt, &post.Status, &post.Error, &post.CreatedAt, &post.UpdatedAt, &post.RecurrenceDays, &post.OriginalTime, &post.ExecutionCount, &post.RRule, &post.Timezone, &dtstart, &occurrenceAt, &payload); err != nil {
 		return common.ScheduledPost{}, err
 	}
+	if dtstart.Valid {
+		post.DTStart = &dtstart.Time
+	}
+	if occurrenceAt.Valid {
+		post.OccurrenceAt = &occurrenceAt.Time
+	}
+	post.Payload = unmarshalScheduledPayload(payload)
 	return post, nil
 }
 
@@ -551,8 +580,8 @@ func (r *SQLiteRepository) CountPendingScheduledPosts(ctx context.Context) (int6
 }
 
 func (r *SQLiteRepository) UpdateScheduledPost(ctx context.Context, post common.ScheduledPost) error {
-	query := `UPDATE scheduled_posts SET text=?, media_path=?, media_type=?, scheduled_at=?, status=?, error=?, updated_at=?, recurrence_days=?, original_time=?, execution_count=? WHERE id=?`
-	res, err := r.db.ExecContext(ctx, query, post.Text, post.MediaPath, post.MediaType, post.ScheduledAt, post.Status, post.Error, post.UpdatedAt, post.RecurrenceDays, post.OriginalTime, post.ExecutionCount, post.ID)
+	query := `UPDATE scheduled_posts SET text=?, media_path=?, media_type=?, scheduled_at=?, status=?, error=?, updated_at=?, recurrence_days=?, original_time=?, execution_count=?, lease_owner=?, lease_until=?, rrule=?, timezone=?, dtstart=?, occurrence_at=?, payload=? WHERE id=?`
+	res, err := r.db.ExecContext(ctx, query, post.Text, post.MediaPath, post.MediaType, post.ScheduledAt, post.Status, post.Error, post.UpdatedAt, post.RecurrenceDays, post.OriginalTime, post.ExecutionCount, post.LeaseOwner, post.LeaseUntil, post.RRule, post.Timezone, post.DTStart, post.OccurrenceAt, marshalScheduledPayload(post.Payload), post.ID)
 	if err != nil {
 		return err
 	}
@@ -569,6 +598,38 @@ func (r *SQLiteRepository) DeleteScheduledPost(ctx context.Context, id string) e
 	return err
 }
 
+// Scheduled Post Execution History
+
+func (r *SQLiteRepository) CreateScheduledPostExecution(ctx context.Context, exec common.ScheduledPostExecution) error {
+	query := `INSERT INTO scheduled_post_executions (id, post_id, channel_id, occurrence_at, executed_at, status, error) VALUES (?, ?, ?, ?, ?, ?, ?)`
+	_, err := r.db.ExecContext(ctx, query, exec.ID, exec.PostID, exec.ChannelID, exec.OccurrenceAt, exec.ExecutedAt, exec.Status, exec.Error)
+	return err
+}
+
+func (r *SQLiteRepository) ListScheduledPostExecutions(ctx context.Context, postID string, limit int) ([]common.ScheduledPostExecution, error) {
+	if limit <= 0 {
+		limit = -1 // SQLite: sin límite
+	}
+	query := `SELECT id, post_id, channel_id, occurrence_at, executed_at, status, error FROM scheduled_post_executions WHERE post_id = ? ORDER BY executed_at DESC LIMIT ?`
+	rows, err := r.db.QueryContext(ctx, query, postID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var execs []common.ScheduledPostExecution
+	for rows.Next() {
+		var exec common.ScheduledPostExecution
+		var errMsg sql.NullString
+		if err := rows.Scan(&exec.ID, &exec.PostID, &exec.ChannelID, &exec.OccurrenceAt, &exec.ExecutedAt, &exec.Status, &errMsg); err != nil {
+			return nil, err
+		}
+		exec.Error = errMsg.String
+		execs = append(execs, exec)
+	}
+	return execs, rows.Err()
+}
+
 // Client Workspace CRUD
 
 func (r *SQLiteRepository) CreateClientWorkspace(ctx context.Context, ws workspace.ClientWorkspace) error {
@@ -0,0 +1,245 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/AzielCF/az-wap/core/infrastructure/valkey"
+	"github.com/AzielCF/az-wap/workspace/domain/cluster"
+)
+
+// Lua script: toma el lease si está libre (nueva generación) o lo renueva si ya es del nodo.
+// Devuelve {valor "nodo|token", ttl restante en ms}.
+const acquireLeaseScript = `
+local cur = redis.call("get", KEYS[1])
+if cur then
+	if string.sub(cur, 1, #ARGV[1] + 1) == ARGV[1] .. "|" then
+		redis.call("pexpire", KEYS[1], ARGV[2])
+		return {cur, tonumber(ARGV[2])}
+	end
+	return {cur, redis.call("pttl", KEYS[1])}
+end
+local token = redis.call("incr", KEYS[2])
+cur = ARGV[1] .. "|" .. token
+redis.call("set", KEYS[1], cur, "PX", ARGV[2])
+return {cur, tonumber(ARGV[2])}
+`
+
+// Lua script: renueva solo si el valor (nodo + token) no cambió
+const renewLeaseScript = `
+if redis.call("get", KEYS[1]) == ARGV[1] then
+	return redis.call("pexpire", KEYS[1], ARGV[2])
+end
+return 0
+`
+
+// ValkeyLeaseStore implements cluster.LeaseStore using Valkey.
+// Leases expire by TTL, so a crashed node loses its channels without any cleanup.
+type ValkeyLeaseStore struct {
+	client *valkey.Client
+	prefix string
+}
+
+// NewValkeyLeaseStore creates a new ValkeyLeaseStore instance.
+func NewValkeyLeaseStore(client *valkey.Client) *ValkeyLeaseStore {
+	return &ValkeyLeaseStore{
+		client: client,
+		prefix: client.Key("cluster") + ":",
+	}
+}
+
+func (s *ValkeyLeaseStore) leaseKey(channelID string) string {
+	return s.prefix + "lease:" + channelID
+}
+
+// generationKey guarda el contador de fencing tokens del canal
+func (s *ValkeyLeaseStore) generationKey(channelID string) string {
+	return s.prefix + "fence:" + channelID
+}
+
+func (s *ValkeyLeaseStore) drainingKey() string {
+	return s.prefix + "draining"
+}
+
+func (s *ValkeyLeaseStore) pinsKey() string {
+	return s.prefix + "pins"
+}
+
+func leaseValue(lease cluster.ChannelLease) string {
+	return lease.NodeID + "|" + strconv.FormatInt(lease.Token, 10)
+}
+
+func parseLeaseValue(channelID, value string, ttl time.Duration) (cluster.ChannelLease, error) {
+	idx := strings.LastIndex(value, "|")
+	if idx <= 0 {
+		return cluster.ChannelLease{}, fmt.Errorf("invalid lease value for channel %s", channelID)
+	}
+	token, err := strconv.ParseInt(value[idx+1:], 10, 64)
+	if err != nil {
+		return cluster.ChannelLease{}, fmt.Errorf("invalid lease generation for channel %s: %w", channelID, err)
+	}
+	return cluster.ChannelLease{
+		ChannelID: channelID,
+		NodeID:    value[:idx],
+		Token:     token,
+		ExpiresAt: time.Now().Add(ttl),
+	}, nil
+}
+
+// Acquire takes the lease when free, or renews it when the node already holds it.
+func (s *ValkeyLeaseStore) Acquire(ctx context.Context, channelID, nodeID string, ttl time.Duration) (cluster.ChannelLease, bool, error) {
+	cmd := s.client.Inner().B().Eval().
+		Script(acquireLeaseScript).
+		Numkeys(2).
+		Key(s.leaseKey(channelID), s.generationKey(channelID)).
+		Arg(nodeID, strconv.FormatInt(ttl.Milliseconds(), 10)).
+		Build()
+
+	res, err := s.client.Inner().Do(ctx, cmd).ToArray()
+	if err != nil {
+		return cluster.ChannelLease{}, false, fmt.Errorf("failed to acquire lease: %w", err)
+	}
+	if len(res) != 2 {
+		return cluster.ChannelLease{}, false, fmt.Errorf("unexpected acquire lease reply for channel %s", channelID)
+	}
+	value, err := res[0].ToString()
+	if err != nil {
+		return cluster.ChannelLease{}, false, err
+	}
+	remaining, _ := res[1].AsInt64()
+
+	lease, err := parseLeaseValue(channelID, value, time.Duration(remaining)*time.Millisecond)
+	if err != nil {
+		return cluster.ChannelLease{}, false, err
+	}
+	return lease, lease.NodeID == nodeID, nil
+}
+
+// Renew extends the lease only if node and fencing token still match.
+func (s *ValkeyLeaseStore) Renew(ctx context.Context, lease cluster.ChannelLease, ttl time.Duration) (bool, error) {
+	cmd := s.client.Inner().B().Eval().
+		Script(renewLeaseScript).
+		Numkeys(1).
+		Key(s.leaseKey(lease.ChannelID)).
+		Arg(leaseValue(lease), strconv.FormatInt(ttl.Milliseconds(), 10)).
+		Build()
+
+	n, err := s.client.Inner().Do(ctx, cmd).AsInt64()
+	if err != nil {
+		return false, fmt.Errorf("failed to renew lease: %w", err)
+	}
+	return n == 1, nil
+}
+
+// Release deletes the lease only if node and fencing token still match.
+func (s *ValkeyLeaseStore) Release(ctx context.Context, lease cluster.ChannelLease) error {
+	cmd := s.client.Inner().B().Eval().
+		Script(releaseLockScript).
+		Numkeys(1).
+		Key(s.leaseKey(lease.ChannelID)).
+		Arg(leaseValue(lease)).
+		Build()
+
+	if err := s.client.Inner().Do(ctx, cmd).Error(); err != nil {
+		return fmt.Errorf("failed to release lease: %w", err)
+	}
+	return nil
+}
+
+// Check reports whether the lease is still live with the same node and fencing token.
+func (s *ValkeyLeaseStore) Check(ctx context.Context, lease cluster.ChannelLease) (bool, error) {
+	value, err := s.client.Inner().Do(ctx, s.client.Inner().B().Get().Key(s.leaseKey(lease.ChannelID)).Build()).ToString()
+	if err != nil {
+		if valkey.IsNil(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to check lease: %w", err)
+	}
+	return value == leaseValue(lease), nil
+}
+
+// Get returns the current lease of a channel, or nil if nobody owns it.
+func (s *ValkeyLeaseStore) Get(ctx context.Context, channelID string) (*cluster.ChannelLease, error) {
+	key := s.leaseKey(channelID)
+	value, err := s.client.Inner().Do(ctx, s.client.Inner().B().Get().Key(key).Build()).ToString()
+	if err != nil {
+		if valkey.IsNil(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get lease from valkey: %w", err)
+	}
+	ttl, _ := s.client.Inner().Do(ctx, s.client.Inner().B().Pttl().Key(key).Build()).AsInt64()
+
+	lease, err := parseLeaseValue(channelID, value, time.Duration(ttl)*time.Millisecond)
+	if err != nil {
+		return nil, err
+	}
+	return &lease, nil
+}
+
+// List returns every live lease in the cluster.
+func (s *ValkeyLeaseStore) List(ctx context.Context) ([]cluster.ChannelLease, error) {
+	leasePrefix := s.leaseKey("")
+	var leases []cluster.ChannelLease
+	var cursor uint64
+
+	for {
+		scanCmd := s.client.Inner().B().Scan().Cursor(cursor).Match(leasePrefix + "*").Count(100).Build()
+		result, err := s.client.Inner().Do(ctx, scanCmd).AsScanEntry()
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan leases: %w", err)
+		}
+
+		for _, key := range result.Elements {
+			lease, err := s.Get(ctx, strings.TrimPrefix(key, leasePrefix))
+			if err != nil || lease == nil {
+				continue
+			}
+			leases = append(leases, *lease)
+		}
+
+		cursor = result.Cursor
+		if cursor == 0 {
+			break
+		}
+	}
+
+	sort.Slice(leases, func(i, j int) bool { return leases[i].ChannelID < leases[j].ChannelID })
+	return leases, nil
+}
+
+func (s *ValkeyLeaseStore) SetDraining(ctx context.Context, nodeID string, draining bool) error {
+	if draining {
+		return s.client.Inner().Do(ctx, s.client.Inner().B().Sadd().Key(s.drainingKey()).Member(nodeID).Build()).Error()
+	}
+	return s.client.Inner().Do(ctx, s.client.Inner().B().Srem().Key(s.drainingKey()).Member(nodeID).Build()).Error()
+}
+
+func (s *ValkeyLeaseStore) DrainingNodes(ctx context.Context) ([]string, error) {
+	nodes, err := s.client.Inner().Do(ctx, s.client.Inner().B().Smembers().Key(s.drainingKey()).Build()).AsStrSlice()
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(nodes)
+	return nodes, nil
+}
+
+func (s *ValkeyLeaseStore) SetPin(ctx context.Context, channelID, nodeID string) error {
+	if nodeID == "" {
+		return s.client.Inner().Do(ctx, s.client.Inner().B().Hdel().Key(s.pinsKey()).Field(channelID).Build()).Error()
+	}
+	cmd := s.client.Inner().B().Hset().
+		Key(s.pinsKey()).
+		FieldValue().
+		FieldValue(channelID, nodeID).
+		Build()
+	return s.client.Inner().Do(ctx, cmd).Error()
+}
+
+func (s *ValkeyLeaseStore) Pins(ctx context.Context) (map[string]string, error) {
+	return s.client.Inner().Do(ctx, s.client.Inner().B().Hgetall().Key(s.pinsKey()).Build()).AsStrMap()
+}
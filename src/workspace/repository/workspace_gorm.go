@@ -11,6 +11,7 @@ import (
 	"github.com/AzielCF/az-wap/workspace/domain/common"
 	"github.com/AzielCF/az-wap/workspace/domain/workspace"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	db_pkg "github.com/AzielCF/az-wap/core/pkg/db"
 )
 
@@ -82,10 +83,34 @@ type scheduledPostModel struct {
 	ExecutionCount int            `gorm:"column:execution_count;default:0"`
 	CreatedAt      time.Time      `gorm:"not null"`
 	UpdatedAt      time.Time      `gorm:"not null"`
+
+	// Lease del scheduler (quién reclamó la tarea y hasta cuándo)
+	LeaseOwner sql.NullString `gorm:"column:lease_owner"`
+	LeaseUntil *time.Time     `gorm:"column:lease_until;index"`
+
+	// Recurrencia RFC 5545
+	RRule        sql.NullString `gorm:"column:rrule"`
+	Timezone     sql.NullString `gorm:"column:timezone"`
+	DTStart      *time.Time     `gorm:"column:dtstart"`
+	OccurrenceAt *time.Time     `gorm:"column:occurrence_at"`
+
+	Payload sql.NullString `gorm:"column:payload;type:text"` // JSON
 }
 
 func (scheduledPostModel) TableName() string { return "scheduled_posts" }
 
+type scheduledPostExecutionModel struct {
+	ID           string         `gorm:"primaryKey"`
+	PostID       string         `gorm:"column:post_id;not null;index"`
+	ChannelID    string         `gorm:"column:channel_id;not null;index"`
+	OccurrenceAt time.Time      `gorm:"column:occurrence_at;not null"`
+	ExecutedAt   time.Time      `gorm:"column:executed_at;not null;index"`
+	Status       string         `gorm:"column:status;not null"`
+	Error        sql.NullString `gorm:"column:error"`
+}
+
+func (scheduledPostExecutionModel) TableName() string { return "scheduled_post_executions" }
+
 type clientWorkspaceModel struct {
 	ID          string         `gorm:"primaryKey;column:id"`
 	OwnerID     string         `gorm:"column:owner_id;not null;index"`
@@ -135,6 +160,7 @@ func (r *WorkspaceGormRepository) Init(ctx context.Context) error {
 		"channels":                  &channelModel{},
 		"access_rules":              &accessRuleModel{},
 		"scheduled_posts":           &scheduledPostModel{},
+		"scheduled_post_executions": &scheduledPostExecutionModel{},
 		"client_workspaces":         &clientWorkspaceModel{},
 		"client_workspace_channels": &clientWorkspaceChannelModel{},
 		"client_workspace_guests":   &clientWorkspaceGuestModel{},
@@ -339,9 +365,15 @@ func (r *WorkspaceGormRepository) ListPendingScheduledPosts(ctx context.Context)
 	return res, nil
 }
 
+// ListUpcomingScheduledPosts incluye los posts en processing con el lease vencido: el nodo que los
+// reclamó cayó (o perdió el job) después de sacarlos del ZSET y, sin esto, no volverían a ejecutarse.
 func (r *WorkspaceGormRepository) ListUpcomingScheduledPosts(ctx context.Context, limitTime time.Time) ([]common.ScheduledPost, error) {
 	var models []scheduledPostModel
-	if err := r.db.WithContext(ctx).Where("status IN ? AND scheduled_at <= ?", []string{"pending", "enqueued"}, limitTime).Find(&models).Error; err != nil {
+	if err := r.db.WithContext(ctx).
+		Where("scheduled_at <= ?", limitTime).
+		Where("status IN ? OR (status = ? AND (lease_until IS NULL OR lease_until < ?))",
+			[]string{"pending", "enqueued"}, string(common.ScheduledPostStatusProcessing), time.Now().UTC()).
+		Find(&models).Error; err != nil {
 		return nil, err
 	}
 	res := make([]common.ScheduledPost, len(models))
@@ -366,6 +398,204 @@ func (r *WorkspaceGormRepository) CountPendingScheduledPosts(ctx context.Context
 	return count, err
 }
 
+// Scheduled Post Leasing
+//
+// Un post es reclamable si está pendiente/encolado (o quedó en processing con un lease vencido),
+// ya venció y nadie lo tiene reservado. El lease evita que dos nodos envíen el mismo post.
+
+var claimableScheduledStatuses = []string{
+	string(common.ScheduledPostStatusPending),
+	string(common.ScheduledPostStatusEnqueued),
+	string(common.ScheduledPostStatusProcessing),
+}
+
+const claimableScheduledPostCond = "status IN ? AND scheduled_at <= ? AND (lease_until IS NULL OR lease_until < ?)"
+
+func (r *WorkspaceGormRepository) ClaimDueScheduledPosts(ctx context.Context, owner string, now, leaseUntil time.Time, limit int) ([]common.ScheduledPost, error) {
+	lease := map[string]interface{}{
+		"status":      string(common.ScheduledPostStatusProcessing),
+		"lease_owner": owner,
+		"lease_until": leaseUntil,
+		"updated_at":  now,
+	}
+
+	var ids []string
+	if r.db.Dialector.Name() == "postgres" {
+		// Postgres: bloquear las filas y saltar las que otro nodo está reclamando
+		err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			if err := tx.Model(&scheduledPostModel{}).
+				Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+				Where(claimableScheduledPostCond, claimableScheduledStatuses, now, now).
+				Order("scheduled_at ASC").Limit(limit).
+				Pluck("id", &ids).Error; err != nil {
+				return err
+			}
+			if len(ids) == 0 {
+				return nil
+			}
+			return tx.Model(&scheduledPostModel{}).Where("id IN ?", ids).Updates(lease).Error
+		})
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		// SQLite: un único UPDATE es atómico (las escrituras están serializadas)
+		sub := r.db.Model(&scheduledPostModel{}).Select("id").
+			Where(claimableScheduledPostCond, claimableScheduledStatuses, now, now).
+			Order("scheduled_at ASC").Limit(limit)
+		res := r.db.WithContext(ctx).Model(&scheduledPostModel{}).Where("id IN (?)", sub).Updates(lease)
+		if res.Error != nil {
+			return nil, res.Error
+		}
+		if res.RowsAffected == 0 {
+			return nil, nil
+		}
+	}
+
+	var models []scheduledPostModel
+	q := r.db.WithContext(ctx).Where("lease_owner = ? AND lease_until = ? AND status = ?", owner, leaseUntil, string(common.ScheduledPostStatusProcessing))
+	if len(ids) > 0 {
+		q = q.Where("id IN ?", ids)
+	}
+	if err := q.Order("scheduled_at ASC").Find(&models).Error; err != nil {
+		return nil, err
+	}
+	res := make([]common.ScheduledPost, len(models))
+	for i, m := range models {
+		res[i] = fromScheduledPostModel(m)
+	}
+	return res, nil
+}
+
+func (r *WorkspaceGormRepository) ClaimScheduledPost(ctx context.Context, id, owner string, now, leaseUntil time.Time) (common.ScheduledPost, bool, error) {
+	res := r.db.WithContext(ctx).Model(&scheduledPostModel{}).
+		Where("id = ?", id).
+		Where(claimableScheduledPostCond, claimableScheduledStatuses, now, now).
+		Updates(map[string]interface{}{
+			"status":      string(common.ScheduledPostStatusProcessing),
+			"lease_owner": owner,
+			"lease_until": leaseUntil,
+			"updated_at":  now,
+		})
+	if res.Error != nil {
+		return common.ScheduledPost{}, false, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return common.ScheduledPost{}, false, nil
+	}
+	post, err := r.GetScheduledPost(ctx, id)
+	if err != nil {
+		return common.ScheduledPost{}, false, err
+	}
+	return post, true, nil
+}
+
+// RenewScheduledPostLease extiende el lease solo si el post sigue en processing a nombre de owner.
+// Un job que esperó en la cola más que el lease comprueba así que ningún otro nodo lo reclamó.
+func (r *WorkspaceGormRepository) RenewScheduledPostLease(ctx context.Context, id, owner string, leaseUntil time.Time) (common.ScheduledPost, bool, error) {
+	res := r.db.WithContext(ctx).Model(&scheduledPostModel{}).
+		Where("id = ? AND lease_owner = ? AND status = ?", id, owner, string(common.ScheduledPostStatusProcessing)).
+		Updates(map[string]interface{}{
+			"lease_until": leaseUntil,
+			"updated_at":  time.Now().UTC(),
+		})
+	if res.Error != nil {
+		return common.ScheduledPost{}, false, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return common.ScheduledPost{}, false, nil
+	}
+	post, err := r.GetScheduledPost(ctx, id)
+	if err != nil {
+		return common.ScheduledPost{}, false, err
+	}
+	return post, true, nil
+}
+
+// UpdateUnclaimedScheduledPostPayload cambia solo el payload y únicamente si ningún nodo reclamó el post.
+// Un Save del registro completo pisaría el estado y el lease que el scheduler fijó entre la lectura y la escritura.
+func (r *WorkspaceGormRepository) UpdateUnclaimedScheduledPostPayload(ctx context.Context, id string, payload *common.ScheduledPayload, now time.Time) (bool, error) {
+	res := r.db.WithContext(ctx).Model(&scheduledPostModel{}).
+		Where("id = ? AND status IN ? AND (lease_until IS NULL OR lease_until < ?)", id,
+			[]string{string(common.ScheduledPostStatusPending), string(common.ScheduledPostStatusEnqueued)}, now).
+		Updates(map[string]interface{}{
+			"payload":    marshalScheduledPayload(payload),
+			"updated_at": now,
+		})
+	return res.RowsAffected == 1, res.Error
+}
+
+// NextScheduledPostAt devuelve el próximo instante en que habrá un post reclamable (cero si no hay ninguno).
+func (r *WorkspaceGormRepository) NextScheduledPostAt(ctx context.Context, now time.Time) (time.Time, error) {
+	var next time.Time
+
+	// 1. Posts libres: cuentan desde su hora programada
+	var free []scheduledPostModel
+	if err := r.db.WithContext(ctx).
+		Where("status IN ? AND (lease_until IS NULL OR lease_until < ?)", claimableScheduledStatuses, now).
+		Order("scheduled_at ASC").Limit(1).Find(&free).Error; err != nil {
+		return time.Time{}, err
+	}
+	if len(free) > 0 {
+		next = free[0].ScheduledAt
+	}
+
+	// 2. Posts reservados: vuelven a estar disponibles cuando vence el lease
+	var leased []scheduledPostModel
+	if err := r.db.WithContext(ctx).
+		Where("status IN ? AND lease_until >= ?", claimableScheduledStatuses, now).
+		Order("lease_until ASC").Limit(1).Find(&leased).Error; err != nil {
+		return time.Time{}, err
+	}
+	if len(leased) > 0 && leased[0].LeaseUntil != nil {
+		at := *leased[0].LeaseUntil
+		if leased[0].ScheduledAt.After(at) {
+			at = leased[0].ScheduledAt
+		}
+		if next.IsZero() || at.Before(next) {
+			next = at
+		}
+	}
+	return next, nil
+}
+
+func (r *WorkspaceGormRepository) CreateScheduledPostExecution(ctx context.Context, exec common.ScheduledPostExecution) error {
+	model := scheduledPostExecutionModel{
+		ID:           exec.ID,
+		PostID:       exec.PostID,
+		ChannelID:    exec.ChannelID,
+		OccurrenceAt: exec.OccurrenceAt,
+		ExecutedAt:   exec.ExecutedAt,
+		Status:       string(exec.Status),
+		Error:        sql.NullString{String: exec.Error, Valid: exec.Error != ""},
+	}
+	return r.db.WithContext(ctx).Create(&model).Error
+}
+
+func (r *WorkspaceGormRepository) ListScheduledPostExecutions(ctx context.Context, postID string, limit int) ([]common.ScheduledPostExecution, error) {
+	var models []scheduledPostExecutionModel
+	q := r.db.WithContext(ctx).Where("post_id = ?", postID).Order("executed_at DESC")
+	if limit > 0 {
+		q = q.Limit(limit)
+	}
+	if err := q.Find(&models).Error; err != nil {
+		return nil, err
+	}
+	res := make([]common.ScheduledPostExecution, len(models))
+	for i, m := range models {
+		res[i] = common.ScheduledPostExecution{
+			ID:           m.ID,
+			PostID:       m.PostID,
+			ChannelID:    m.ChannelID,
+			OccurrenceAt: m.OccurrenceAt,
+			ExecutedAt:   m.ExecutedAt,
+			Status:       common.ScheduledPostExecutionStatus(m.Status),
+			Error:        nullStringValue(m.Error),
+		}
+	}
+	return res, nil
+}
+
 // Client Workspace CRUD
 
 func (r *WorkspaceGormRepository) CreateClientWorkspace(ctx context.Context, ws workspace.ClientWorkspace) error {
@@ -649,6 +879,13 @@ func toScheduledPostModel(p common.ScheduledPost) scheduledPostModel {
 		ExecutionCount: p.ExecutionCount,
 		CreatedAt:      p.CreatedAt,
 		UpdatedAt:      p.UpdatedAt,
+		LeaseOwner:     sql.NullString{String: p.LeaseOwner, Valid: p.LeaseOwner != ""},
+		LeaseUntil:     p.LeaseUntil,
+		RRule:          sql.NullString{String: p.RRule, Valid: p.RRule != ""},
+		Timezone:       sql.NullString{String: p.Timezone, Valid: p.Timezone != ""},
+		DTStart:        p.DTStart,
+		OccurrenceAt:   p.OccurrenceAt,
+		Payload:        marshalScheduledPayload(p.Payload),
 	}
 }
 
@@ -669,7 +906,36 @@ func fromScheduledPostModel(m scheduledPostModel) common.ScheduledPost {
 		ExecutionCount: m.ExecutionCount,
 		CreatedAt:      m.CreatedAt,
 		UpdatedAt:      m.UpdatedAt,
+		LeaseOwner:     nullStringValue(m.LeaseOwner),
+		LeaseUntil:     m.LeaseUntil,
+		RRule:          nullStringValue(m.RRule),
+		Timezone:       nullStringValue(m.Timezone),
+		DTStart:        m.DTStart,
+		OccurrenceAt:   m.OccurrenceAt,
+		Payload:        unmarshalScheduledPayload(m.Payload),
+	}
+}
+
+func marshalScheduledPayload(p *common.ScheduledPayload) sql.NullString {
+	if p == nil {
+		return sql.NullString{}
+	}
+	raw, err := json.Marshal(p)
+	if err != nil {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: string(raw), Valid: true}
+}
+
+func unmarshalScheduledPayload(ns sql.NullString) *common.ScheduledPayload {
+	if !ns.Valid || ns.String == "" {
+		return nil
+	}
+	var p common.ScheduledPayload
+	if err := json.Unmarshal([]byte(ns.String), &p); err != nil {
+		return nil
 	}
+	return &p
 }
 
 // nullStringValue returns a trimmed string or empty if null to prevent legacy data panics.
@@ -0,0 +1,174 @@
+package repository
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/AzielCF/az-wap/workspace/domain/common"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func newTestGormRepo(t *testing.T) *WorkspaceGormRepository {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "workspaces.db")), &gorm.Config{
+		Logger:  logger.Default.LogMode(logger.Silent),
+		NowFunc: func() time.Time { return time.Now().UTC() },
+	})
+	if err != nil {
+		t.Fatalf("failed to open db: %v", err)
+	}
+	repo := NewWorkspaceGormRepository(db)
+	if err := repo.Init(context.Background()); err != nil {
+		t.Fatalf("failed to init repo: %v", err)
+	}
+	return repo
+}
+
+func createTestPost(t *testing.T, repo *WorkspaceGormRepository, id string, at time.Time, status common.ScheduledPostStatus) {
+	t.Helper()
+	now := time.Now().UTC()
+	post := common.ScheduledPost{ID: id, ChannelID: "chan1", TargetID: "123@s.whatsapp.net", Text: "hola", ScheduledAt: at, Status: status, CreatedAt: now, UpdatedAt: now}
+	if err := repo.CreateScheduledPost(context.Background(), post); err != nil {
+		t.Fatalf("CreateScheduledPost() unexpected error: %v", err)
+	}
+}
+
+func TestClaimDueScheduledPosts_LeasesOnce(t *testing.T) {
+	repo := newTestGormRepo(t)
+	ctx := context.Background()
+	now := time.Now().UTC()
+
+	createTestPost(t, repo, "due-1", now.Add(-2*time.Minute), common.ScheduledPostStatusPending)
+	createTestPost(t, repo, "due-2", now.Add(-1*time.Minute), common.ScheduledPostStatusEnqueued)
+	createTestPost(t, repo, "future", now.Add(time.Hour), common.ScheduledPostStatusPending)
+	createTestPost(t, repo, "failed", now.Add(-time.Hour), common.ScheduledPostStatusFailed)
+
+	claimed, err := repo.ClaimDueScheduledPosts(ctx, "node-a", now, now.Add(2*time.Minute), 10)
+	if err != nil {
+		t.Fatalf("ClaimDueScheduledPosts() unexpected error: %v", err)
+	}
+	if len(claimed) != 2 || claimed[0].ID != "due-1" || claimed[1].ID != "due-2" {
+		t.Fatalf("claimed = %+v, want [due-1 due-2]", claimed)
+	}
+	if claimed[0].LeaseOwner != "node-a" || claimed[0].Status != common.ScheduledPostStatusProcessing {
+		t.Fatalf("claimed[0] lease = %q/%s, want node-a/processing", claimed[0].LeaseOwner, claimed[0].Status)
+	}
+
+	// Otro nodo no puede reclamar los mismos posts mientras el lease siga vigente
+	again, err := repo.ClaimDueScheduledPosts(ctx, "node-b", now.Add(time.Minute), now.Add(3*time.Minute), 10)
+	if err != nil {
+		t.Fatalf("ClaimDueScheduledPosts() unexpected error: %v", err)
+	}
+	if len(again) != 0 {
+		t.Fatalf("second claim = %+v, want none", again)
+	}
+	if _, ok, _ := repo.ClaimScheduledPost(ctx, "due-1", "node-b", now.Add(time.Minute), now.Add(3*time.Minute)); ok {
+		t.Fatalf("ClaimScheduledPost() stole a leased post")
+	}
+
+	// Con el lease vencido (nodo caído) el post vuelve a estar disponible
+	later := now.Add(5 * time.Minute)
+	post, ok, err := repo.ClaimScheduledPost(ctx, "due-1", "node-b", later, later.Add(2*time.Minute))
+	if err != nil || !ok || post.LeaseOwner != "node-b" {
+		t.Fatalf("ClaimScheduledPost() after expiry = (%+v, %v, %v), want claimed by node-b", post, ok, err)
+	}
+
+	// El nodo original ya no puede renovar: su job en cola no debe enviar el post
+	if _, ok, _ := repo.RenewScheduledPostLease(ctx, "due-1", "node-a", later.Add(4*time.Minute)); ok {
+		t.Fatalf("RenewScheduledPostLease() renewed a lease taken by another node")
+	}
+	if _, ok, err := repo.RenewScheduledPostLease(ctx, "due-1", "node-b", later.Add(4*time.Minute)); err != nil || !ok {
+		t.Fatalf("RenewScheduledPostLease() by owner = (%v, %v), want renewed", ok, err)
+	}
+}
+
+func TestListUpcomingScheduledPosts_RecoversExpiredLeases(t *testing.T) {
+	repo := newTestGormRepo(t)
+	ctx := context.Background()
+	now := time.Now().UTC()
+
+	createTestPost(t, repo, "pending", now.Add(time.Hour), common.ScheduledPostStatusPending)
+	createTestPost(t, repo, "stuck", now.Add(-10*time.Minute), common.ScheduledPostStatusPending)
+	createTestPost(t, repo, "running", now.Add(-time.Minute), common.ScheduledPostStatusPending)
+	createTestPost(t, repo, "failed", now.Add(-time.Hour), common.ScheduledPostStatusFailed)
+
+	// "stuck" quedó en processing con un lease ya vencido (nodo caído tras sacarlo del ZSET)
+	if _, ok, err := repo.ClaimScheduledPost(ctx, "stuck", "node-a", now.Add(-10*time.Minute), now.Add(-5*time.Minute)); err != nil || !ok {
+		t.Fatalf("ClaimScheduledPost() = (%v, %v), want claimed", ok, err)
+	}
+	if _, ok, err := repo.ClaimScheduledPost(ctx, "running", "node-b", now, now.Add(2*time.Minute)); err != nil || !ok {
+		t.Fatalf("ClaimScheduledPost() = (%v, %v), want claimed", ok, err)
+	}
+
+	posts, err := repo.ListUpcomingScheduledPosts(ctx, now.Add(24*time.Hour))
+	if err != nil {
+		t.Fatalf("ListUpcomingScheduledPosts() unexpected error: %v", err)
+	}
+	got := map[string]bool{}
+	for _, p := range posts {
+		got[p.ID] = true
+	}
+	if len(got) != 2 || !got["pending"] || !got["stuck"] {
+		t.Fatalf("ListUpcomingScheduledPosts() = %v, want [pending stuck]", got)
+	}
+}
+
+func TestNextScheduledPostAt(t *testing.T) {
+	repo := newTestGormRepo(t)
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Second)
+
+	next, err := repo.NextScheduledPostAt(ctx, now)
+	if err != nil || !next.IsZero() {
+		t.Fatalf("NextScheduledPostAt() on empty table = (%v, %v), want zero", next, err)
+	}
+
+	createTestPost(t, repo, "later", now.Add(time.Hour), common.ScheduledPostStatusPending)
+	createTestPost(t, repo, "soon", now.Add(10*time.Minute), common.ScheduledPostStatusPending)
+	if next, _ := repo.NextScheduledPostAt(ctx, now); !next.Equal(now.Add(10 * time.Minute)) {
+		t.Fatalf("NextScheduledPostAt() = %v, want %v", next, now.Add(10*time.Minute))
+	}
+
+	// Un post vencido pero reservado cuenta desde el fin de su lease
+	createTestPost(t, repo, "leased", now.Add(-time.Minute), common.ScheduledPostStatusPending)
+	if _, err := repo.ClaimDueScheduledPosts(ctx, "node-a", now, now.Add(2*time.Minute), 10); err != nil {
+		t.Fatalf("ClaimDueScheduledPosts() unexpected error: %v", err)
+	}
+	if next, _ := repo.NextScheduledPostAt(ctx, now); !next.Equal(now.Add(2 * time.Minute)) {
+		t.Fatalf("NextScheduledPostAt() = %v, want lease expiry %v", next, now.Add(2*time.Minute))
+	}
+}
+
+func TestUpdateUnclaimedScheduledPostPayload(t *testing.T) {
+	repo := newTestGormRepo(t)
+	ctx := context.Background()
+	now := time.Now().UTC()
+
+	createTestPost(t, repo, "free", now.Add(time.Hour), common.ScheduledPostStatusEnqueued)
+	createTestPost(t, repo, "claimed", now.Add(-time.Minute), common.ScheduledPostStatusPending)
+	if _, ok, err := repo.ClaimScheduledPost(ctx, "claimed", "node-a", now, now.Add(2*time.Minute)); err != nil || !ok {
+		t.Fatalf("ClaimScheduledPost() = (%v, %v), want claimed", ok, err)
+	}
+
+	payload := &common.ScheduledPayload{Parts: []common.ScheduledPayloadPart{{Type: common.PayloadPartAI, AI: &common.ScheduledAITask{Instruction: "- hola\n- sigo aquí"}}}}
+	if ok, err := repo.UpdateUnclaimedScheduledPostPayload(ctx, "free", payload, now); err != nil || !ok {
+		t.Fatalf("UpdateUnclaimedScheduledPostPayload(free) = (%v, %v), want updated", ok, err)
+	}
+	free, _ := repo.GetScheduledPost(ctx, "free")
+	if free.Status != common.ScheduledPostStatusEnqueued || free.Payload == nil || free.Payload.Parts[0].AI.Instruction != "- hola\n- sigo aquí" {
+		t.Fatalf("free post = %+v, want enqueued with the new payload", free)
+	}
+
+	// Un post reclamado no se toca: el estado y el lease del scheduler se conservan
+	if ok, err := repo.UpdateUnclaimedScheduledPostPayload(ctx, "claimed", payload, now); err != nil || ok {
+		t.Fatalf("UpdateUnclaimedScheduledPostPayload(claimed) = (%v, %v), want not updated", ok, err)
+	}
+	claimed, _ := repo.GetScheduledPost(ctx, "claimed")
+	if claimed.Status != common.ScheduledPostStatusProcessing || claimed.LeaseOwner != "node-a" || claimed.Payload != nil {
+		t.Fatalf("claimed post = %+v, want untouched processing lease", claimed)
+	}
+}
@@ -0,0 +1,22 @@
+package workspace
+
+import (
+	"context"
+	"fmt"
+)
+
+// HasChannel indica si el canal está conectado en este nodo (solo este nodo puede enviar por él)
+func (m *Manager) HasChannel(channelID string) bool {
+	_, ok := m.channels.GetAdapter(channelID)
+	return ok
+}
+
+// SendText envía un mensaje fuera de una conversación, p. ej. los avisos de vencimiento de suscripción
+func (m *Manager) SendText(ctx context.Context, channelID, chatID, text string) error {
+	adapter, ok := m.channels.GetAdapter(channelID)
+	if !ok {
+		return fmt.Errorf("channel %s is not running on this node", channelID)
+	}
+	_, err := adapter.SendMessage(ctx, chatID, text, "")
+	return err
+}
@@ -0,0 +1,349 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	clientsDomain "github.com/AzielCF/az-wap/clients/domain"
+	"github.com/AzielCF/az-wap/core/pkg/crypto"
+	"github.com/AzielCF/az-wap/workspace"
+	"github.com/AzielCF/az-wap/workspace/domain/channel"
+	"github.com/AzielCF/az-wap/workspace/domain/common"
+	"github.com/AzielCF/az-wap/workspace/infrastructure/whatsapp"
+	"github.com/AzielCF/az-wap/workspace/repository"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"go.mau.fi/whatsmeow/types"
+)
+
+const (
+	ChannelBundleFormat  = "azwap-channel-bundle"
+	channelBundleVersion = 1
+)
+
+var (
+	ErrUnsupportedBundleChannel = errors.New("only whatsapp channels can be exported")
+	ErrWeakBundlePassphrase     = errors.New("passphrase must have at least 8 characters")
+	ErrInvalidBundle            = errors.New("invalid channel bundle")
+)
+
+// ChannelBundle es la copia completa de un canal vinculado: sesión whatsmeow, configuración,
+// reglas de acceso y suscripciones (con sus clientes). Viaja cifrado con una contraseña.
+type ChannelBundle struct {
+	Version       int                                 `json:"version"`
+	ExportedAt    time.Time                           `json:"exported_at"`
+	Channel       channel.Channel                     `json:"channel"`
+	AccessRules   []common.AccessRule                 `json:"access_rules"`
+	Subscriptions []*clientsDomain.ClientSubscription `json:"subscriptions"`
+	Clients       []*clientsDomain.Client             `json:"clients"` // Dueño del canal y clientes de las suscripciones
+	Device        *whatsapp.DeviceDump                `json:"device"`
+}
+
+// ImportBundleOptions controla dónde y cómo se restaura un bundle.
+type ImportBundleOptions struct {
+	WorkspaceID string // Workspace destino; vacío = el mismo del bundle
+	Overwrite   bool   // Reemplaza el canal (y sus claves) si ya existe en esta instancia
+}
+
+// ImportBundleResult resume lo restaurado.
+type ImportBundleResult struct {
+	ChannelID      string   `json:"channel_id"`
+	WorkspaceID    string   `json:"workspace_id"`
+	JID            string   `json:"jid"`
+	ExternalRef    string   `json:"external_ref"`
+	DeviceRows     int      `json:"device_rows"`
+	AccessRules    int      `json:"access_rules"`
+	Subscriptions  int      `json:"subscriptions"`
+	ClientsCreated int      `json:"clients_created"`
+	Warnings       []string `json:"warnings,omitempty"`
+}
+
+// ChannelBundleService exporta e importa canales de WhatsApp entre instancias sin volver a escanear el QR.
+type ChannelBundleService struct {
+	repo    repository.IWorkspaceRepository
+	manager *workspace.Manager
+	clients clientsDomain.ClientRepository
+	subs    clientsDomain.SubscriptionRepository
+}
+
+func NewChannelBundleService(repo repository.IWorkspaceRepository, manager *workspace.Manager, clients clientsDomain.ClientRepository, subs clientsDomain.SubscriptionRepository) *ChannelBundleService {
+	return &ChannelBundleService{repo: repo, manager: manager, clients: clients, subs: subs}
+}
+
+// reserve evita que el canal arranque (en este u otro nodo) mientras se copian sus claves.
+func (s *ChannelBundleService) reserve(ctx context.Context, channelID string) (func(), error) {
+	if s.manager == nil {
+		return func() {}, nil
+	}
+	return s.manager.ReserveChannel(ctx, channelID)
+}
+
+// Export arma el bundle cifrado del canal. El canal debe estar detenido.
+func (s *ChannelBundleService) Export(ctx context.Context, channelID, passphrase string) ([]byte, error) {
+	if err := validBundlePassphrase(passphrase); err != nil {
+		return nil, err
+	}
+	ch, err := s.repo.GetChannel(ctx, channelID)
+	if err != nil {
+		return nil, fmt.Errorf("channel not found: %w", err)
+	}
+	if ch.Type != channel.ChannelTypeWhatsApp {
+		return nil, ErrUnsupportedBundleChannel
+	}
+
+	release, err := s.reserve(ctx, channelID)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	dump, err := whatsapp.Devices().Export(ctx, channelID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export device: %w", err)
+	}
+
+	bundle := ChannelBundle{
+		Version:    channelBundleVersion,
+		ExportedAt: time.Now().UTC(),
+		Channel:    ch,
+		Device:     dump,
+	}
+	if bundle.AccessRules, err = s.repo.GetAccessRules(ctx, channelID); err != nil {
+		return nil, fmt.Errorf("failed to read access rules: %w", err)
+	}
+
+	clientIDs := []string{}
+	if ch.OwnerID != "" {
+		clientIDs = append(clientIDs, ch.OwnerID)
+	}
+	if s.subs != nil {
+		if bundle.Subscriptions, err = s.subs.ListByChannel(ctx, channelID); err != nil {
+			return nil, fmt.Errorf("failed to read subscriptions: %w", err)
+		}
+		for _, sub := range bundle.Subscriptions {
+			clientIDs = append(clientIDs, sub.ClientID)
+		}
+	}
+	if s.clients != nil {
+		seen := map[string]bool{}
+		for _, id := range clientIDs {
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+			client, err := s.clients.GetByID(ctx, id)
+			if err != nil {
+				logrus.WithError(err).Warnf("[BUNDLE] Client %s of channel %s not exported", id, channelID)
+				continue
+			}
+			bundle.Clients = append(bundle.Clients, client)
+		}
+	}
+
+	plain, err := json.Marshal(bundle)
+	if err != nil {
+		return nil, err
+	}
+	sealed, err := crypto.SealWithPassphrase(ChannelBundleFormat, plain, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	logrus.Infof("[BUNDLE] Exported channel %s (%s, %d device rows)", channelID, dump.JID, dump.Rows())
+	return sealed, nil
+}
+
+// Import restaura un bundle conservando el ID del canal. El canal queda detenido y desconectado:
+// el administrador lo arranca cuando ya no corre en la instancia de origen (dos sesiones con las
+// mismas claves se desincronizan).
+func (s *ChannelBundleService) Import(ctx context.Context, sealed []byte, passphrase string, opts ImportBundleOptions) (*ImportBundleResult, error) {
+	plain, err := crypto.OpenWithPassphrase(ChannelBundleFormat, sealed, passphrase)
+	if errors.Is(err, crypto.ErrBadPassphrase) {
+		return nil, err
+	} else if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidBundle, err)
+	}
+	var bundle ChannelBundle
+	if err := json.Unmarshal(plain, &bundle); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidBundle, err)
+	}
+	if bundle.Version != channelBundleVersion {
+		return nil, fmt.Errorf("%w: unsupported version %d", ErrInvalidBundle, bundle.Version)
+	}
+	if bundle.Channel.ID == "" || bundle.Device == nil {
+		return nil, fmt.Errorf("%w: missing channel or device", ErrInvalidBundle)
+	}
+	jid, err := types.ParseJID(bundle.Device.JID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: device jid: %v", ErrInvalidBundle, err)
+	}
+
+	ch := bundle.Channel
+	if opts.WorkspaceID != "" {
+		ch.WorkspaceID = opts.WorkspaceID
+	}
+	if _, err := s.repo.GetByID(ctx, ch.WorkspaceID); err != nil {
+		return nil, fmt.Errorf("workspace not found: %w", err)
+	}
+
+	_, err = s.repo.GetChannel(ctx, ch.ID)
+	exists := err == nil
+	if err != nil && !errors.Is(err, common.ErrChannelNotFound) {
+		return nil, err
+	}
+	if exists && !opts.Overwrite {
+		return nil, common.ErrDuplicateChannel
+	}
+
+	// Igual que en el REST de canales: instance_id manda, si no el teléfono del dispositivo
+	if instID, ok := ch.Config.Settings["instance_id"].(string); ok && instID != "" {
+		ch.ExternalRef = instID
+	} else {
+		ch.ExternalRef = jid.User
+	}
+	if other, err := s.repo.GetChannelByExternalRef(ctx, ch.ExternalRef); err == nil && other.ID != ch.ID {
+		return nil, fmt.Errorf("%w: channel %s already uses %s", common.ErrDuplicateChannel, other.ID, ch.ExternalRef)
+	}
+
+	release, err := s.reserve(ctx, ch.ID)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	res := &ImportBundleResult{ChannelID: ch.ID, WorkspaceID: ch.WorkspaceID, JID: jid.String(), ExternalRef: ch.ExternalRef, DeviceRows: bundle.Device.Rows()}
+
+	// Al sobrescribir, las claves actuales solo se sustituyen si la importación termina bien; se
+	// guarda una copia para restaurarlas si después falla el guardado del canal
+	devices := whatsapp.Devices()
+	importDevice := devices.Import
+	var previous *whatsapp.DeviceDump
+	if exists {
+		importDevice = devices.Replace
+		previous, err = devices.Export(ctx, ch.ID)
+		if err != nil && !errors.Is(err, whatsapp.ErrDeviceNotLinked) {
+			return nil, fmt.Errorf("failed to back up current device: %w", err)
+		}
+	}
+	if err := importDevice(ctx, ch.ID, bundle.Device); err != nil {
+		return nil, fmt.Errorf("failed to import device: %w", err)
+	}
+
+	clientIDs := s.importClients(ctx, bundle.Clients, res)
+
+	if owner := ch.OwnerID; owner != "" {
+		ch.OwnerID = clientIDs[owner]
+		if ch.OwnerID == "" {
+			res.Warnings = append(res.Warnings, fmt.Sprintf("owner client %s not available, channel left without owner", owner))
+		}
+	}
+	ch.Status = channel.ChannelStatusDisconnected
+	ch.UpdatedAt = time.Now().UTC()
+
+	if exists {
+		err = s.repo.UpdateChannel(ctx, ch)
+	} else {
+		err = s.repo.CreateChannel(ctx, ch)
+	}
+	if err != nil {
+		s.rollbackDevice(ctx, ch.ID, previous)
+		return nil, fmt.Errorf("failed to save channel: %w", err)
+	}
+
+	if err := s.repo.DeleteAllAccessRules(ctx, ch.ID); err != nil {
+		res.Warnings = append(res.Warnings, fmt.Sprintf("access rules: %v", err))
+	}
+	for _, rule := range bundle.AccessRules {
+		rule.ChannelID = ch.ID
+		if err := s.repo.AddAccessRule(ctx, rule); err != nil {
+			res.Warnings = append(res.Warnings, fmt.Sprintf("access rule %s: %v", rule.Identity, err))
+			continue
+		}
+		res.AccessRules++
+	}
+
+	s.importSubscriptions(ctx, ch.ID, bundle.Subscriptions, clientIDs, res)
+
+	logrus.Infof("[BUNDLE] Imported channel %s into workspace %s (%s, %d device rows)", ch.ID, ch.WorkspaceID, res.JID, res.DeviceRows)
+	return res, nil
+}
+
+// rollbackDevice deshace la importación de claves cuando no se pudo guardar el canal: restaura las
+// del canal sobrescrito o, si no tenía, las borra (sin canal quedarían huérfanas).
+func (s *ChannelBundleService) rollbackDevice(ctx context.Context, channelID string, previous *whatsapp.DeviceDump) {
+	devices := whatsapp.Devices()
+	if previous == nil {
+		_ = devices.Delete(ctx, channelID)
+		return
+	}
+	if err := devices.Replace(ctx, channelID, previous); err != nil {
+		logrus.WithError(err).Errorf("[BUNDLE] Failed to restore previous device of channel %s (%s)", channelID, previous.JID)
+	}
+}
+
+// importClients resuelve cada cliente del bundle en esta instancia: por ID, luego por plataforma,
+// y si no existe lo crea. Devuelve el mapa ID del bundle -> ID local.
+func (s *ChannelBundleService) importClients(ctx context.Context, clients []*clientsDomain.Client, res *ImportBundleResult) map[string]string {
+	ids := map[string]string{}
+	if s.clients == nil {
+		return ids
+	}
+	for _, c := range clients {
+		if existing, err := s.clients.GetByID(ctx, c.ID); err == nil {
+			ids[c.ID] = existing.ID
+			continue
+		}
+		if existing, err := s.clients.GetByPlatform(ctx, c.PlatformID, c.PlatformType); err == nil {
+			ids[c.ID] = existing.ID
+			continue
+		}
+		if err := s.clients.Create(ctx, c); err != nil {
+			res.Warnings = append(res.Warnings, fmt.Sprintf("client %s: %v", c.ID, err))
+			continue
+		}
+		ids[c.ID] = c.ID
+		res.ClientsCreated++
+	}
+	return ids
+}
+
+func (s *ChannelBundleService) importSubscriptions(ctx context.Context, channelID string, subs []*clientsDomain.ClientSubscription, clientIDs map[string]string, res *ImportBundleResult) {
+	if s.subs == nil {
+		if len(subs) > 0 {
+			res.Warnings = append(res.Warnings, "subscriptions skipped: no subscription repository")
+		}
+		return
+	}
+	for _, sub := range subs {
+		clientID, ok := clientIDs[sub.ClientID]
+		if !ok {
+			res.Warnings = append(res.Warnings, fmt.Sprintf("subscription %s: client %s not available", sub.ID, sub.ClientID))
+			continue
+		}
+		if _, err := s.subs.GetByClientAndChannel(ctx, clientID, channelID); err == nil {
+			res.Warnings = append(res.Warnings, fmt.Sprintf("subscription %s: client %s already subscribed", sub.ID, clientID))
+			continue
+		}
+		sub.ClientID = clientID
+		sub.ChannelID = channelID
+		if _, err := s.subs.GetByID(ctx, sub.ID); err == nil {
+			sub.ID = uuid.NewString()
+		}
+		if err := s.subs.Create(ctx, sub); err != nil {
+			res.Warnings = append(res.Warnings, fmt.Sprintf("subscription %s: %v", sub.ID, err))
+			continue
+		}
+		res.Subscriptions++
+	}
+}
+
+// validBundlePassphrase aplica un mínimo razonable para una contraseña que protege claves de sesión.
+func validBundlePassphrase(passphrase string) error {
+	if len(strings.TrimSpace(passphrase)) < 8 {
+		return ErrWeakBundlePassphrase
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/AzielCF/az-wap/workspace/domain/channel"
+	"github.com/AzielCF/az-wap/workspace/domain/common"
+	wsDomain "github.com/AzielCF/az-wap/workspace/domain/workspace"
+	"github.com/AzielCF/az-wap/workspace/infrastructure/whatsapp"
+	"github.com/AzielCF/az-wap/workspace/repository"
+	"github.com/AzielCF/az-wap/workspace/usecase"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mau.fi/whatsmeow/proto/waAdv"
+	"go.mau.fi/whatsmeow/types"
+)
+
+func TestChannelBundle_ExportImport(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now().UTC()
+
+	// Origen: canal vinculado con una regla de acceso
+	srcRepo := setupTestDB(t)
+	require.NoError(t, srcRepo.Create(ctx, wsDomain.Workspace{ID: "ws-1", Name: "WS", Enabled: true, CreatedAt: now, UpdatedAt: now}))
+	ch := channel.Channel{ID: "11111111-2222", WorkspaceID: "ws-1", Type: channel.ChannelTypeWhatsApp, Name: "Sales", Enabled: true,
+		Status: channel.ChannelStatusConnected, Config: channel.ChannelConfig{Settings: map[string]any{}}, CreatedAt: now, UpdatedAt: now}
+	require.NoError(t, srcRepo.CreateChannel(ctx, ch))
+	require.NoError(t, srcRepo.AddAccessRule(ctx, common.AccessRule{ID: "rule-1", ChannelID: ch.ID, Identity: "51999@s.whatsapp.net", Action: common.AccessActionDeny, CreatedAt: now, UpdatedAt: now}))
+
+	srcDevices := whatsapp.NewSQLiteDeviceStore(t.TempDir())
+	device, err := srcDevices.Open(ctx, ch.ID)
+	require.NoError(t, err)
+	jid := types.NewADJID("51987654321", 0, 3)
+	device.ID = &jid
+	device.Account = &waAdv.ADVSignedDeviceIdentity{Details: []byte("d"), AccountSignature: make([]byte, 64), AccountSignatureKey: make([]byte, 32), DeviceSignature: make([]byte, 64)}
+	require.NoError(t, device.Save(ctx))
+	require.NoError(t, srcDevices.Release(ch.ID))
+
+	whatsapp.SetDeviceStore(srcDevices)
+	defer whatsapp.SetDeviceStore(nil)
+
+	src := usecase.NewChannelBundleService(srcRepo, nil, nil, nil)
+	_, err = src.Export(ctx, ch.ID, "short")
+	assert.Error(t, err)
+	sealed, err := src.Export(ctx, ch.ID, "bundle-passphrase")
+	require.NoError(t, err)
+
+	// Destino: otra instancia con su propio almacén
+	dstRepo := setupTestDB(t)
+	require.NoError(t, dstRepo.Create(ctx, wsDomain.Workspace{ID: "ws-2", Name: "Other", Enabled: true, CreatedAt: now, UpdatedAt: now}))
+	dstDevices := whatsapp.NewSQLiteDeviceStore(t.TempDir())
+	whatsapp.SetDeviceStore(dstDevices)
+
+	dst := usecase.NewChannelBundleService(dstRepo, nil, nil, nil)
+	_, err = dst.Import(ctx, sealed, "wrong-passphrase", usecase.ImportBundleOptions{WorkspaceID: "ws-2"})
+	assert.Error(t, err)
+
+	res, err := dst.Import(ctx, sealed, "bundle-passphrase", usecase.ImportBundleOptions{WorkspaceID: "ws-2"})
+	require.NoError(t, err)
+	assert.Equal(t, ch.ID, res.ChannelID)
+	assert.Equal(t, "51987654321", res.ExternalRef)
+	assert.Equal(t, 1, res.AccessRules)
+
+	imported, err := dstRepo.GetChannel(ctx, ch.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "ws-2", imported.WorkspaceID)
+	assert.Equal(t, channel.ChannelStatusDisconnected, imported.Status)
+	assert.Equal(t, "51987654321", imported.ExternalRef)
+
+	restored, err := dstDevices.Open(ctx, ch.ID)
+	require.NoError(t, err)
+	require.NotNil(t, restored.ID)
+	assert.Equal(t, jid, *restored.ID)
+	require.NoError(t, dstDevices.Release(ch.ID))
+
+	// Sin overwrite no se pisa un canal existente
+	_, err = dst.Import(ctx, sealed, "bundle-passphrase", usecase.ImportBundleOptions{WorkspaceID: "ws-2"})
+	assert.ErrorIs(t, err, common.ErrDuplicateChannel)
+
+	// Si al sobrescribir falla el guardado del canal, vuelven las claves de la sesión que ya estaba
+	require.NoError(t, dstDevices.Delete(ctx, ch.ID))
+	current, err := dstDevices.Open(ctx, ch.ID)
+	require.NoError(t, err)
+	currentJID := types.NewADJID("51911111111", 0, 5)
+	current.ID = &currentJID
+	current.Account = device.Account
+	require.NoError(t, current.Save(ctx))
+	require.NoError(t, dstDevices.Release(ch.ID))
+
+	failing := usecase.NewChannelBundleService(failingUpdateRepo{dstRepo}, nil, nil, nil)
+	_, err = failing.Import(ctx, sealed, "bundle-passphrase", usecase.ImportBundleOptions{WorkspaceID: "ws-2", Overwrite: true})
+	require.Error(t, err)
+
+	kept, err := dstDevices.Open(ctx, ch.ID)
+	require.NoError(t, err)
+	require.NotNil(t, kept.ID)
+	assert.Equal(t, currentJID, *kept.ID)
+	require.NoError(t, dstDevices.Release(ch.ID))
+}
+
+type failingUpdateRepo struct {
+	*repository.SQLiteRepository
+}
+
+func (failingUpdateRepo) UpdateChannel(context.Context, channel.Channel) error {
+	return errors.New("update failed")
+}